@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/cpcf/weft/engine"
+)
+
+func runCheck(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("check", stderr)
+	projectPath := projectFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: weft check [flags]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Regenerates the project in memory and fails if the files in its output")
+		fmt.Fprintln(stderr, "directory are missing or differ from what the templates now produce.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+
+	p, err := loadProject(*projectPath)
+	if err != nil {
+		return err
+	}
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	drifts, err := p.Check(context.Background(), engine.WithLogger(logger))
+	if err != nil {
+		return err
+	}
+
+	output := relative(p.Dir, p.OutputDir())
+	if len(drifts) == 0 {
+		fmt.Fprintf(stdout, "%s is up to date\n", output)
+		return nil
+	}
+	for _, d := range drifts {
+		d.Path = filepath.Join(output, d.Path)
+		fmt.Fprintln(stdout, d)
+	}
+	fmt.Fprintf(stdout, "%d generated files are out of date; run weft generate\n", len(drifts))
+	return errFailed
+}
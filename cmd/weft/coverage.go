@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/cpcf/weft/engine"
+	"github.com/cpcf/weft/project"
+)
+
+func runCoverage(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("coverage", stderr)
+	projectPath := projectFlag(flags)
+	htmlPath := flags.String("html", "", "also write an HTML report to this file")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: weft coverage [flags] [data-file ...]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Renders the project once with each data file in place of the project's data,")
+		fmt.Fprintln(stderr, "or once with its own data, and reports the if, range and with branches and the")
+		fmt.Fprintln(stderr, "defines no render entered. Nothing is written to the output directory.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	p, err := loadProject(*projectPath)
+	if err != nil {
+		return err
+	}
+	out, err := os.MkdirTemp("", "weft-coverage-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(out)
+	p.Output = out
+
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	eng, err := p.Engine(engine.WithLogger(logger), engine.WithCoverage())
+	if err != nil {
+		return err
+	}
+
+	// Each data file stands in for the project's data; without any the
+	// project's own data is used.
+	inputs := flags.Args()
+	if len(inputs) == 0 {
+		inputs = []string{""}
+	}
+	for _, input := range inputs {
+		if input != "" {
+			abs, err := filepath.Abs(input)
+			if err != nil {
+				return err
+			}
+			p.Data = []project.DataSource{{File: abs}}
+		}
+		if _, err := p.Generate(context.Background(), eng); err != nil {
+			if input != "" {
+				return fmt.Errorf("failed to generate with %s: %w", input, err)
+			}
+			return err
+		}
+	}
+
+	report := eng.Coverage()
+	if err := report.WriteText(stdout); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "coverage: %.1f%% of %d branches and defines\n", report.Percent(), len(report.Points))
+
+	if *htmlPath != "" {
+		f, err := os.Create(*htmlPath)
+		if err != nil {
+			return err
+		}
+		if err := report.WriteHTML(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	return nil
+}
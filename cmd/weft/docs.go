@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cpcf/weft/project"
+)
+
+func runDocs(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("docs", stderr)
+	projectPath := projectFlag(flags)
+	output := flags.String("o", "", "write the documentation to a file instead of standard output")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+
+	p, err := loadProject(*projectPath)
+	if err != nil {
+		return err
+	}
+	doc, err := projectDocs(p)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = io.WriteString(stdout, doc)
+		return err
+	}
+	return os.WriteFile(*output, []byte(doc), 0o644)
+}
+
+// leadingComment matches a {{/* comment */}} at the start of a template,
+// which documents the template.
+var leadingComment = regexp.MustCompile(`^\s*{{-?\s*/\*([\s\S]*?)\*/\s*-?}}`)
+
+// projectDocs documents the templates and data sources of p in Markdown.
+func projectDocs(p *project.Project) (string, error) {
+	var doc strings.Builder
+	fmt.Fprintf(&doc, "# %s\n\n", filepath.Base(p.Dir))
+	fmt.Fprintf(&doc, "Generated into `%s`", filepath.ToSlash(relative(p.Dir, p.OutputDir())))
+	if p.Package != "" {
+		fmt.Fprintf(&doc, " as package `%s`", p.Package)
+	}
+	doc.WriteString(".\n\n## Templates\n\n")
+	doc.WriteString("| Template | Output | Description |\n|----------|--------|-------------|\n")
+
+	packs := make(map[string]string)
+	for _, pk := range p.ResolvedPacks() {
+		packs[pk.TemplateDir()] = pk.Name
+	}
+	for _, dir := range p.TemplateDirs() {
+		err := fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Layouts, partials and macros are not rendered on their own.
+			if path != "." && strings.HasPrefix(d.Name(), "_") {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || !strings.HasSuffix(path, ".tmpl") {
+				return nil
+			}
+			content, err := os.ReadFile(filepath.Join(dir, path))
+			if err != nil {
+				return err
+			}
+			description := ""
+			if m := leadingComment.FindSubmatch(content); m != nil {
+				description = strings.Join(strings.Fields(string(m[1])), " ")
+			}
+			template := filepath.ToSlash(relative(p.Dir, filepath.Join(dir, path)))
+			if pack, ok := packs[dir]; ok {
+				template = pack + ":" + path
+			}
+			fmt.Fprintf(&doc, "| `%s` | `%s` | %s |\n", template, strings.TrimSuffix(path, ".tmpl"),
+				strings.ReplaceAll(description, "|", `\|`))
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to read templates: %w", err)
+		}
+	}
+
+	if len(p.Data) > 0 {
+		doc.WriteString("\n## Data\n\n| Key | Source |\n|-----|--------|\n")
+		for _, d := range p.Data {
+			key := "`." + d.Name + "`"
+			if d.Name == "" {
+				key = "top level"
+			}
+			fmt.Fprintf(&doc, "| %s | %s |\n", key, describeSource(d))
+		}
+	}
+
+	for _, pk := range p.ResolvedPacks() {
+		fmt.Fprintf(&doc, "\n## Pack %s", pk.Name)
+		if pk.Version != "" {
+			fmt.Fprintf(&doc, " %s", pk.Version)
+		}
+		doc.WriteString("\n\n")
+		if pk.Description != "" {
+			fmt.Fprintf(&doc, "%s\n\n", pk.Description)
+		}
+		if len(pk.Data) == 0 {
+			continue
+		}
+		doc.WriteString("| Data | Type | Description |\n|------|------|-------------|\n")
+		for _, f := range pk.Data {
+			typ := f.Type
+			if typ == "" {
+				typ = "any"
+			}
+			if f.Optional {
+				typ += ", optional"
+			}
+			fmt.Fprintf(&doc, "| `.%s` | %s | %s |\n", f.Path, typ, strings.ReplaceAll(f.Description, "|", `\|`))
+		}
+	}
+	return doc.String(), nil
+}
+
+func describeSource(d project.DataSource) string {
+	var s string
+	switch {
+	case d.File != "":
+		s = "file `" + d.File + "`"
+	case d.URL != "":
+		s = "URL `" + d.URL + "`"
+	case d.Env != "":
+		s = "environment variables `" + d.Env + "*`"
+	case d.CSV != "":
+		s = "CSV `" + d.CSV + "`"
+	case d.XLSX != "":
+		s = "workbook `" + d.XLSX + "`"
+		if d.Sheet != "" {
+			s += ", sheet " + d.Sheet
+		}
+	case d.OpenAPI != "":
+		s = "OpenAPI document `" + d.OpenAPI + "`"
+	case d.SQL != "":
+		s = "SQL schema `" + d.SQL + "`"
+	case d.Go != "":
+		s = "Go package `" + d.Go + "`"
+	}
+	if d.Select != "" {
+		s += ", selecting `" + d.Select + "`"
+	}
+	if d.KeyBy != "" {
+		s += ", keyed by `" + d.KeyBy + "`"
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cpcf/weft/project"
+)
+
+func runFmt(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("fmt", stderr)
+	projectPath := projectFlag(flags)
+	list := flags.Bool("l", false, "list files whose formatting differs instead of rewriting them")
+	check := flags.Bool("check", false, "like -l, but fail if any file needs formatting")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: weft fmt [flags] [path ...]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Formats the given template files and directories, or the project's template")
+		fmt.Fprintln(stderr, "directories: line endings become \\n, trailing whitespace is removed and files")
+		fmt.Fprintln(stderr, "end with a single newline.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		p, err := project.Load(*projectPath)
+		if err != nil {
+			return err
+		}
+		paths = p.TemplateDirs()
+	}
+
+	var unformatted []string
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !isTemplate(path) && path != root {
+				return nil
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			formatted := formatTemplate(content)
+			if bytes.Equal(content, formatted) {
+				return nil
+			}
+			unformatted = append(unformatted, path)
+			fmt.Fprintln(stdout, path)
+			if *list || *check {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(path, formatted, info.Mode().Perm())
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if *check && len(unformatted) > 0 {
+		return errFailed
+	}
+	return nil
+}
+
+func isTemplate(path string) bool {
+	return strings.HasSuffix(path, ".tmpl") || strings.HasSuffix(path, ".tpl")
+}
+
+// formatTemplate normalises the whitespace of a template: line endings become
+// "\n", trailing spaces and tabs are removed and the file ends with exactly
+// one newline. Whitespace inside lines is left alone, as it is output.
+func formatTemplate(content []byte) []byte {
+	content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	lines := bytes.Split(content, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimRight(line, " \t")
+	}
+	content = bytes.TrimRight(bytes.Join(lines, []byte("\n")), "\n")
+	if len(content) == 0 {
+		return content
+	}
+	return append(content, '\n')
+}
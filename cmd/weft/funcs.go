@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cpcf/weft/processors"
+	"github.com/cpcf/weft/render"
+)
+
+// funcInfo describes a template function for list-funcs.
+type funcInfo struct {
+	Name        string `json:"name"`
+	Signature   string `json:"signature"`
+	Category    string `json:"category,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func runListFuncs(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("list-funcs", stderr)
+	asJSON := flags.Bool("json", false, "print JSON")
+	category := flags.String("category", "", "only list functions in this category")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+
+	funcs := templateFuncs()
+	if *category != "" {
+		var kept []funcInfo
+		for _, f := range funcs {
+			if f.Category == *category {
+				kept = append(kept, f)
+			}
+		}
+		funcs = kept
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(funcs)
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSIGNATURE\tDESCRIPTION")
+	for _, f := range funcs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", f.Name, f.Signature, f.Description)
+	}
+	return tw.Flush()
+}
+
+// templateFuncs lists the functions the engine parses templates with,
+// described by the function registry where it has metadata.
+func templateFuncs() []funcInfo {
+	registry := render.NewFunctionRegistry()
+	registry.RegisterDefaults()
+
+	var funcs []funcInfo
+	for name, fn := range render.DefaultFuncMap() {
+		info := funcInfo{Name: name, Signature: signature(reflect.TypeOf(fn))}
+		if meta, ok := registry.GetMetadata(name); ok {
+			info.Category = meta.Category
+			info.Description = meta.Description
+		}
+		funcs = append(funcs, info)
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].Name < funcs[j].Name })
+	return funcs
+}
+
+// signature formats a function type as "(string, int) string".
+func signature(t reflect.Type) string {
+	in := make([]string, t.NumIn())
+	for i := range in {
+		in[i] = typeName(t.In(i))
+		if t.IsVariadic() && i == len(in)-1 {
+			in[i] = "..." + typeName(t.In(i).Elem())
+		}
+	}
+	out := make([]string, t.NumOut())
+	for i := range out {
+		out[i] = typeName(t.Out(i))
+	}
+
+	s := "(" + strings.Join(in, ", ") + ")"
+	switch len(out) {
+	case 0:
+		return s
+	case 1:
+		return s + " " + out[0]
+	}
+	return s + " (" + strings.Join(out, ", ") + ")"
+}
+
+func typeName(t reflect.Type) string {
+	return strings.ReplaceAll(t.String(), "interface {}", "any")
+}
+
+func runDoctor(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("doctor", stderr)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	return processors.DefaultTools.WriteDoctorReport(stdout)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/cpcf/weft/engine"
+	"github.com/cpcf/weft/project"
+)
+
+// projectFlag registers the -project flag shared by project commands.
+func projectFlag(flags *flag.FlagSet) *string {
+	return flags.String("project", ".", "project file, or a directory containing "+project.FileName)
+}
+
+// loadProject loads the project file at path and fetches its packs.
+func loadProject(path string) (*project.Project, error) {
+	p, err := project.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.ResolvePacks(context.Background()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func runGenerate(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("generate", stderr)
+	projectPath := projectFlag(flags)
+	output := flags.String("output", "", "override the project's output directory")
+	verbose := flags.Bool("v", false, "list the generated files")
+	checkFormats := flags.Bool("check-formats", false, "fail when a generated file does not parse in its format, such as JSON")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+
+	p, err := loadProject(*projectPath)
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		abs, err := filepath.Abs(*output)
+		if err != nil {
+			return err
+		}
+		p.Output = abs
+	}
+
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	opts := []engine.Option{engine.WithLogger(logger)}
+	if *checkFormats {
+		opts = append(opts, engine.WithOutputFormatCheck())
+	}
+	eng, err := p.Engine(opts...)
+	if err != nil {
+		return err
+	}
+	files, err := p.Generate(context.Background(), eng)
+	if err != nil {
+		return err
+	}
+	if _, err := p.WriteLock(); err != nil {
+		return err
+	}
+
+	if *verbose {
+		for _, f := range files {
+			fmt.Fprintln(stdout, relative(p.Dir, f.OutputPath))
+		}
+	}
+	fmt.Fprintf(stdout, "generated %d files in %s\n", len(files), relative(p.Dir, p.OutputDir()))
+	return nil
+}
+
+// relative returns path relative to dir when it is inside it.
+func relative(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil && filepath.IsLocal(rel) {
+		return rel
+	}
+	return path
+}
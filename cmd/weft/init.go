@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/cpcf/weft/project"
+)
+
+func runInit(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("init", stderr)
+	module := flags.String("module", "", "Go module path of the new project (default: the generator name)")
+	name := flags.String("name", "", "generator name (default: the directory name)")
+	force := flags.Bool("force", false, "overwrite existing files")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: weft init [flags] [dir]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Scaffolds a generator project in dir, or the current directory: a spec type")
+		fmt.Fprintln(stderr, "with validation, an example specification, a starter template and a main.go.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("%w: unexpected arguments %q", errUsage, flags.Args()[1:])
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	opts := []project.InitOption{project.WithModule(*module), project.WithName(*name)}
+	if *force {
+		opts = append(opts, project.WithOverwrite())
+	}
+	paths, err := project.Init(dir, opts...)
+	if err != nil {
+		return err
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		fmt.Fprintf(stdout, "created %s\n", relative(abs, path))
+	}
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, "Next steps:")
+	if dir != "." {
+		fmt.Fprintf(stdout, "  cd %s\n", dir)
+	}
+	fmt.Fprintln(stdout, "  go mod tidy")
+	fmt.Fprintln(stdout, "  go run . -config configs/example.yaml")
+	return nil
+}
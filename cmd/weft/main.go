@@ -0,0 +1,140 @@
+// Command weft generates code from a weft.yaml project file, so generators
+// that only need templates and data do not need a main.go of their own.
+//
+// Usage:
+//
+//	weft <command> [flags]
+//
+// The commands are:
+//
+//	init        scaffold a new generator project
+//	generate    render the project's templates into its output directory
+//	check       fail if the generated files are not up to date
+//	upgrade     move template packs to their latest release
+//	validate    check templates for errors and that all data sources load
+//	coverage    report the template branches a set of data files never reach
+//	repl        evaluate template snippets against the project's data
+//	fmt         normalise whitespace in template files
+//	docs        write Markdown documentation for the project's templates
+//	list-funcs  list the functions available to templates
+//	doctor      report which external tools processors can use
+//
+// Commands that read the project take -project, the project file or its
+// directory, which defaults to the current directory.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a weft subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{"init", "scaffold a new generator project", runInit},
+	{"generate", "render the project's templates into its output directory", runGenerate},
+	{"check", "fail if the generated files are not up to date", runCheck},
+	{"upgrade", "move template packs to their latest release", runUpgrade},
+	{"validate", "check templates for errors and that all data sources load", runValidate},
+	{"coverage", "report the template branches a set of data files never reach", runCoverage},
+	{"repl", "evaluate template snippets against the project's data", runREPL},
+	{"fmt", "normalise whitespace in template files", runFmt},
+	{"docs", "write Markdown documentation for the project's templates", runDocs},
+	{"list-funcs", "list the functions available to templates", runListFuncs},
+	{"doctor", "report which external tools processors can use", runDoctor},
+}
+
+// errFailed reports that a command found problems it has already printed.
+var errFailed = errors.New("failed")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit code: 0 on success,
+// 1 when the command fails and 2 for usage errors.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(args[1:], stdout, stderr)
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+			return 0
+		case err == errUsage:
+			// The flag package has already reported it.
+			return 2
+		case err == errFailed:
+			// The command has already reported the problems it found.
+			return 1
+		case errors.Is(err, errUsage):
+			fmt.Fprintf(stderr, "weft %s: %v\n", cmd.name, err)
+			return 2
+		}
+		fmt.Fprintf(stderr, "weft %s: %v\n", cmd.name, err)
+		return 1
+	}
+
+	fmt.Fprintf(stderr, "weft: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: weft <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "weft <command> -h" for the flags of a command.`)
+}
+
+// errUsage marks command line errors, which exit with status 2.
+var errUsage = errors.New("invalid usage")
+
+// newFlagSet returns a flag set for a subcommand that reports errors to
+// stderr instead of exiting.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet("weft "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+// parseFlags parses args. The flag package prints its own errors, so they
+// are returned as errUsage alone.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// noArgs rejects positional arguments for commands that take none.
+func noArgs(flags *flag.FlagSet) error {
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected arguments %q", errUsage, flags.Args())
+	}
+	return nil
+}
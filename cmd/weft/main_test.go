@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func runWeft(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+const testProject = `
+templates: [templates]
+output: out
+data:
+  - file: data.yaml
+`
+
+func TestGenerate(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"weft.yaml":                  testProject,
+		"data.yaml":                  "name: shop\n",
+		"templates/README.md.tmpl":   "# {{ .name | title }}\n",
+		"templates/cmd/main.go.tmpl": "package main\n",
+	})
+
+	code, stdout, stderr := runWeft(t, "generate", "-project", dir, "-v")
+	if code != 0 {
+		t.Fatalf("exit code = %d, stderr:\n%s", code, stderr)
+	}
+	for _, want := range []string{filepath.Join("out", "README.md"), filepath.Join("out", "cmd", "main.go"), "generated 2 files in out"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout missing %q:\n%s", want, stdout)
+		}
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "out", "README.md"))
+	if err != nil || string(content) != "# Shop\n" {
+		t.Errorf("README.md = %q, %v", content, err)
+	}
+
+	other := filepath.Join(t.TempDir(), "elsewhere")
+	if code, _, stderr := runWeft(t, "generate", "-project", filepath.Join(dir, "weft.yaml"), "-output", other); code != 0 {
+		t.Fatalf("exit code = %d, stderr:\n%s", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(other, "README.md")); err != nil {
+		t.Errorf("-output was not used: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "templates", "config.json.tmpl"), []byte(`{"name": "{{ .name }}",}`), 0o644)
+	if code, _, stderr := runWeft(t, "generate", "-project", dir, "-check-formats"); code != 1 || !strings.Contains(stderr, "config.json:1:17: output of template") {
+		t.Errorf("-check-formats: exit code = %d, stderr:\n%s", code, stderr)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"weft.yaml":          testProject,
+		"data.yaml":          "name: shop\n",
+		"templates/ok.tmpl":  "{{ .name }}\n",
+		"templates/bad.tmpl": "line one\n{{ if .name }}\n",
+		"templates/note.txt": "{{ not a template",
+	})
+
+	code, stdout, _ := runWeft(t, "validate", "-project", dir)
+	if code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	if !strings.Contains(stdout, filepath.Join("templates", "bad.tmpl")+":") || strings.Contains(stdout, "ok.tmpl") || strings.Contains(stdout, "note.txt") {
+		t.Errorf("stdout:\n%s", stdout)
+	}
+
+	os.Remove(filepath.Join(dir, "templates", "bad.tmpl"))
+	os.Remove(filepath.Join(dir, "data.yaml"))
+	code, stdout, _ = runWeft(t, "validate", "-project", dir)
+	if code != 1 || !strings.Contains(stdout, "failed to load data") {
+		t.Errorf("exit code = %d, stdout:\n%s", code, stdout)
+	}
+
+	code, stdout, _ = runWeft(t, "validate", "-project", dir, "-data=false")
+	if code != 0 || !strings.Contains(stdout, "1 templates ok") {
+		t.Errorf("exit code = %d, stdout:\n%s", code, stdout)
+	}
+
+	schema := filepath.Join(dir, "data.schema.json")
+	os.WriteFile(schema, []byte(`{"type": "object", "properties": {"title": {"type": "string"}}}`), 0o644)
+	code, stdout, _ = runWeft(t, "validate", "-project", dir, "-data=false", "-schema", schema)
+	if code != 1 || !strings.Contains(stdout, "ok.tmpl:1:4: Field 'name' in '.name' does not exist on object") {
+		t.Errorf("-schema: exit code = %d, stdout:\n%s", code, stdout)
+	}
+
+	code, stdout, _ = runWeft(t, "validate", "-project", dir, "-data=false", "-schema", schema, "-format", "sarif")
+	var log struct {
+		Runs []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &log); code != 1 || err != nil || len(log.Runs) != 1 || len(log.Runs[0].Results) == 0 || log.Runs[0].Results[0].RuleID != "unknown_field" {
+		t.Errorf("-format sarif: exit code = %d, err = %v, stdout:\n%s", code, err, stdout)
+	}
+
+	code, stdout, _ = runWeft(t, "validate", "-project", dir, "-data=false", "-format", "junit")
+	if code != 0 || !strings.Contains(stdout, `<testsuites name="template validation" tests="1" failures="0">`) {
+		t.Errorf("-format junit: exit code = %d, stdout:\n%s", code, stdout)
+	}
+
+	if code, _, stderr := runWeft(t, "validate", "-project", dir, "-format", "xml"); code != 2 || !strings.Contains(stderr, `unknown format "xml"`) {
+		t.Errorf("-format xml: exit code = %d, stderr:\n%s", code, stderr)
+	}
+}
+
+func TestValidateFix(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"weft.yaml":          testProject,
+		"templates/ok.tmpl":  "{{ .name }}\n",
+		"templates/bad.tmpl": "{{ .name }  \nend",
+	})
+
+	code, stdout, _ := runWeft(t, "validate", "-project", dir, "-data=false", "-fix")
+	if code != 0 {
+		t.Errorf("exit code = %d, stdout:\n%s", code, stdout)
+	}
+	bad := filepath.Join("templates", "bad.tmpl")
+	for _, want := range []string{bad + ":1: fixed: Added the missing brace of an action", bad + ":1: fixed: Removed trailing whitespace", bad + ":2: fixed: Added a final newline", "2 templates ok"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout missing %q:\n%s", want, stdout)
+		}
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, bad)); string(content) != "{{ .name }}\nend\n" {
+		t.Errorf("bad.tmpl = %q", content)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"weft.yaml":                testProject,
+		"data.yaml":                "name: shop\n",
+		"admin.yaml":               "name: shop\nadmin: true\n",
+		"templates/README.md.tmpl": "{{ if .admin }}admin{{ else }}user{{ end }}\n{{ with .error }}{{ . }}{{ end }}\n",
+	})
+
+	code, stdout, stderr := runWeft(t, "coverage", "-project", dir)
+	if code != 0 {
+		t.Fatalf("exit code = %d, stderr:\n%s", code, stderr)
+	}
+	for _, want := range []string{"README.md.tmpl: 2/4 (50.0%)", "README.md.tmpl:1:7: then of if never entered", "coverage: 50.0% of 4 branches and defines"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout missing %q:\n%s", want, stdout)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); !os.IsNotExist(err) {
+		t.Errorf("coverage wrote to the output directory: %v", err)
+	}
+
+	report := filepath.Join(t.TempDir(), "coverage.html")
+	code, stdout, stderr = runWeft(t, "coverage", "-project", dir, "-html", report, filepath.Join(dir, "data.yaml"), filepath.Join(dir, "admin.yaml"))
+	if code != 0 || !strings.Contains(stdout, "coverage: 75.0% of 4 branches and defines") {
+		t.Fatalf("exit code = %d, stdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+	}
+	if content, err := os.ReadFile(report); err != nil || !strings.Contains(string(content), "<h2 id=\"README.md.tmpl\">") {
+		t.Errorf("HTML report = %q, %v", content, err)
+	}
+}
+
+func TestREPL(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"weft.yaml":  testProject,
+		"data.yaml":  "name: shop\nendpoints: [get, list]\n",
+		"other.json": `{"name": "store"}`,
+	})
+	history := filepath.Join(t.TempDir(), "history")
+	defer func(in io.Reader) { stdin = in }(stdin)
+
+	stdin = strings.NewReader("{{ .endpoints | len }}\n.name | title\n.name | titel\n")
+	code, stdout, stderr := runWeft(t, "repl", "-project", dir, "-history", history)
+	if code != 0 {
+		t.Fatalf("exit code = %d, stderr:\n%s", code, stderr)
+	}
+	for _, want := range []string{"> 2\n", "> Shop\n", `function "titel" not defined (did you mean 'title'?)`} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout missing %q:\n%s", want, stdout)
+		}
+	}
+	if content, _ := os.ReadFile(history); !strings.HasPrefix(string(content), "{{ .endpoints | len }}\n") {
+		t.Errorf("history = %q", content)
+	}
+
+	stdin = strings.NewReader(".name\n")
+	if code, stdout, _ := runWeft(t, "repl", "-project", dir, "-history", "", "-data", filepath.Join(dir, "other.json")); code != 0 || !strings.Contains(stdout, "> store\n") {
+		t.Errorf("-data: exit code = %d, stdout:\n%s", code, stdout)
+	}
+}
+
+func TestFmt(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"weft.yaml":           testProject,
+		"templates/a.go.tmpl": "package a  \r\n\r\nvar x = 1\t\n\n\n",
+		"templates/b.go.tmpl": "package b\n",
+		"templates/notes.txt": "keep   \n",
+	})
+	a := filepath.Join(dir, "templates", "a.go.tmpl")
+
+	code, stdout, _ := runWeft(t, "fmt", "-project", dir, "-check")
+	if code != 1 || strings.TrimSpace(stdout) != a {
+		t.Errorf("-check: exit code = %d, stdout:\n%s", code, stdout)
+	}
+
+	if code, _, stderr := runWeft(t, "fmt", "-project", dir); code != 0 {
+		t.Fatalf("exit code = %d, stderr:\n%s", code, stderr)
+	}
+	content, _ := os.ReadFile(a)
+	if string(content) != "package a\n\nvar x = 1\n" {
+		t.Errorf("formatted = %q", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "templates", "notes.txt")); string(content) != "keep   \n" {
+		t.Errorf("non-template file was formatted: %q", content)
+	}
+
+	if code, stdout, _ := runWeft(t, "fmt", "-l", filepath.Join(dir, "templates")); code != 0 || stdout != "" {
+		t.Errorf("-l after formatting: exit code = %d, stdout:\n%s", code, stdout)
+	}
+}
+
+func TestDocs(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"weft.yaml": testProject + `  - name: codes
+    csv: codes.csv
+    keyBy: code
+`,
+		"templates/model.go.tmpl": "{{/* The model\n   for each table. */}}\npackage models\n",
+		"templates/plain.tmpl":    "text\n",
+	})
+
+	code, stdout, stderr := runWeft(t, "docs", "-project", dir)
+	if code != 0 {
+		t.Fatalf("exit code = %d, stderr:\n%s", code, stderr)
+	}
+	for _, want := range []string{
+		"| `templates/model.go.tmpl` | `model.go` | The model for each table. |",
+		"| `templates/plain.tmpl` | `plain` |  |",
+		"| top level | file `data.yaml` |",
+		"| `.codes` | CSV `codes.csv`, keyed by `code` |",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("docs missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestListFuncs(t *testing.T) {
+	code, stdout, _ := runWeft(t, "list-funcs", "-json", "-category", "string")
+	if code != 0 {
+		t.Fatalf("exit code = %d", code)
+	}
+	var funcs []funcInfo
+	if err := json.Unmarshal([]byte(stdout), &funcs); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, f := range funcs {
+		if f.Category != "string" {
+			t.Errorf("%s has category %q", f.Name, f.Category)
+		}
+		if f.Name == "snake" {
+			found = f.Signature == "(string) string" && f.Description != ""
+		}
+	}
+	if !found {
+		t.Errorf("snake missing or undescribed: %+v", funcs)
+	}
+
+	_, stdout, _ = runWeft(t, "list-funcs")
+	if !strings.Contains(stdout, "coalesce") || !strings.Contains(stdout, "(...any) any") {
+		t.Errorf("list-funcs:\n%s", stdout)
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{nil, 2, "Usage: weft <command>"},
+		{[]string{"help"}, 0, "Commands:"},
+		{[]string{"build"}, 2, `unknown command "build"`},
+		{[]string{"generate", "-nope"}, 2, "flag provided but not defined: -nope"},
+		{[]string{"generate", "extra"}, 2, `unexpected arguments ["extra"]`},
+		{[]string{"generate", "-project", "missing-dir/weft.yaml"}, 1, "failed to load project"},
+	}
+	for _, tt := range tests {
+		code, _, stderr := runWeft(t, tt.args...)
+		if code != tt.wantCode || !strings.Contains(stderr, tt.wantErr) {
+			t.Errorf("run(%q) = %d, stderr:\n%s\nwant %d and %q", tt.args, code, stderr, tt.wantCode, tt.wantErr)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shopgen")
+	code, stdout, stderr := runWeft(t, "init", "-module", "example.com/shopgen", dir)
+	if code != 0 {
+		t.Fatalf("exit code = %d, stderr:\n%s", code, stderr)
+	}
+	for _, want := range []string{"created main.go", "created " + filepath.Join("spec", "spec.go"), "cd " + dir, "go mod tidy"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout missing %q:\n%s", want, stdout)
+		}
+	}
+
+	code, _, stderr = runWeft(t, "init", dir)
+	if code != 1 || !strings.Contains(stderr, "refusing to overwrite") {
+		t.Errorf("second init: exit code = %d, stderr:\n%s", code, stderr)
+	}
+	if code, _, stderr := runWeft(t, "init", "-force", dir); code != 0 {
+		t.Errorf("init -force: exit code = %d, stderr:\n%s", code, stderr)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"weft.yaml":                testProject,
+		"data.yaml":                "name: shop\n",
+		"templates/README.md.tmpl": "# {{ .name }}\n",
+	})
+
+	code, stdout, _ := runWeft(t, "check", "-project", dir)
+	if code != 1 || !strings.Contains(stdout, filepath.Join("out", "README.md")+": missing") {
+		t.Errorf("before generate: exit code = %d, stdout:\n%s", code, stdout)
+	}
+
+	if code, _, stderr := runWeft(t, "generate", "-project", dir); code != 0 {
+		t.Fatalf("generate: exit code = %d, stderr:\n%s", code, stderr)
+	}
+	if code, stdout, _ := runWeft(t, "check", "-project", dir); code != 0 || !strings.Contains(stdout, "out is up to date") {
+		t.Errorf("after generate: exit code = %d, stdout:\n%s", code, stdout)
+	}
+
+	os.WriteFile(filepath.Join(dir, "data.yaml"), []byte("name: store\n"), 0o644)
+	code, stdout, _ = runWeft(t, "check", "-project", dir)
+	for _, want := range []string{filepath.Join("out", "README.md") + ":1: out of date (+1 -1 lines)", "1 generated files are out of date"} {
+		if code != 1 || !strings.Contains(stdout, want) {
+			t.Errorf("after changing data: exit code = %d, stdout missing %q:\n%s", code, want, stdout)
+		}
+	}
+}
+
+func TestPacks(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"weft.yaml": testProject + "packs:\n  - source: packs/readme\n",
+		"data.yaml": "name: shop\n",
+		"packs/readme/weft-pack.yaml": `name: readme
+version: 0.1.0
+data:
+  - path: name
+    type: string
+    description: project name
+funcs: [sha256]
+`,
+		"packs/readme/templates/README.md.tmpl": "# {{ .name }} {{ sha256 .name }}\n",
+		"templates/.keep":                       "",
+	})
+
+	if code, stdout, _ := runWeft(t, "validate", "-project", dir); code != 0 {
+		t.Fatalf("validate: exit code = %d, stdout:\n%s", code, stdout)
+	}
+	if code, _, stderr := runWeft(t, "generate", "-project", dir); code != 0 {
+		t.Fatalf("generate: exit code = %d, stderr:\n%s", code, stderr)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "out", "README.md")); !strings.HasPrefix(string(content), "# shop ") {
+		t.Errorf("README.md = %q", content)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "weft.lock")); !os.IsNotExist(err) {
+		t.Error("generate wrote a lock file for a local pack")
+	}
+	if code, stdout, _ := runWeft(t, "upgrade", "-project", dir); code != 0 || stdout != "all packs are up to date\n" {
+		t.Errorf("upgrade: exit code = %d, stdout:\n%s", code, stdout)
+	}
+	if code, _, stderr := runWeft(t, "upgrade", "-project", dir, "packs/other"); code != 1 || !strings.Contains(stderr, `no pack with source "packs/other"`) {
+		t.Errorf("upgrade of an unknown pack: exit code = %d, stderr:\n%s", code, stderr)
+	}
+
+	_, stdout, _ := runWeft(t, "docs", "-project", dir)
+	for _, want := range []string{"| `readme:README.md.tmpl` | `README.md` |", "## Pack readme 0.1.0", "| `.name` | string | project name |"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("docs missing %q:\n%s", want, stdout)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cpcf/weft/datasource"
+	"github.com/cpcf/weft/debug"
+)
+
+// stdin is the input of interactive commands.
+var stdin io.Reader = os.Stdin
+
+func runREPL(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("repl", stderr)
+	projectPath := projectFlag(flags)
+	dataFile := flags.String("data", "", "evaluate against this YAML, JSON, TOML, CSV or XLSX file instead of the project's data")
+	history := flags.String("history", defaultHistoryFile(), "file to keep the history in, or empty for none")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: weft repl [flags]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Evaluates template snippets such as {{ .API.Endpoints | len }} against the")
+		fmt.Fprintln(stderr, "project's data with the project's functions. Enter :help for the commands.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+
+	p, err := loadProject(*projectPath)
+	if err != nil {
+		return err
+	}
+	var data any
+	if *dataFile != "" {
+		data, err = datasource.File(*dataFile).Fetch(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to load data: %w", err)
+		}
+	} else if data, err = p.LoadData(context.Background()); err != nil {
+		return err
+	}
+
+	var opts []debug.REPLOption
+	if *history != "" {
+		opts = append(opts, debug.WithHistoryFile(*history))
+	}
+	repl, err := debug.NewREPL(data, p.FuncMap(), opts...)
+	if err != nil {
+		return err
+	}
+	return repl.Run(stdin, stdout)
+}
+
+// defaultHistoryFile returns the file the REPL keeps its history in by
+// default, or "" if there is no home directory.
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".weft_history")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/cpcf/weft/project"
+)
+
+func runUpgrade(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("upgrade", stderr)
+	projectPath := projectFlag(flags)
+	dryRun := flags.Bool("n", false, "print the upgrades without changing any files")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: weft upgrade [flags] [source ...]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Moves the project's remote packs, or only those with the given sources, to")
+		fmt.Fprintln(stderr, "their latest release, prints the files that changed in each pack and updates")
+		fmt.Fprintf(stderr, "%s and %s.\n", project.FileName, project.LockFileName)
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	p, err := loadProject(*projectPath)
+	if err != nil {
+		return err
+	}
+	upgrades, err := p.Upgrade(context.Background(), flags.Args())
+	if err != nil {
+		return err
+	}
+	if len(upgrades) == 0 {
+		fmt.Fprintln(stdout, "all packs are up to date")
+		return nil
+	}
+	for _, u := range upgrades {
+		from := u.From
+		if from == "" {
+			from = "(unpinned)"
+		}
+		fmt.Fprintf(stdout, "%s: %s -> %s\n", u.Source, from, u.To)
+		for _, c := range u.Changes {
+			fmt.Fprintf(stdout, "  %s\n", c)
+		}
+	}
+	if *dryRun {
+		return nil
+	}
+
+	if err := p.SavePackVersions(); err != nil {
+		return err
+	}
+	if _, err := p.WriteLock(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "updated %s and %s\n", project.FileName, project.LockFileName)
+	return nil
+}
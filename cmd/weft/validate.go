@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/cpcf/weft/debug"
+)
+
+func runValidate(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("validate", stderr)
+	projectPath := projectFlag(flags)
+	checkData := flags.Bool("data", true, "also check that every data source loads")
+	verbose := flags.Bool("v", false, "also print warnings")
+	schemaPath := flags.String("schema", "", "check the fields templates access against this JSON Schema of the data")
+	format := flags.String("format", "text", "output format: text, json, sarif or junit")
+	fix := flags.Bool("fix", false, "rewrite templates to fix what can be fixed mechanically, including whitespace as weft fmt does")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	writeReport, ok := reportWriters[*format]
+	if !ok && *format != "text" {
+		return fmt.Errorf("%w: unknown format %q, expected text, json, sarif or junit", errUsage, *format)
+	}
+	// Reports own stdout, so other problems go to stderr.
+	messages := stdout
+	if writeReport != nil {
+		messages = stderr
+	}
+
+	p, err := loadProject(*projectPath)
+	if err != nil {
+		return err
+	}
+	var schema []byte
+	if *schemaPath != "" {
+		if schema, err = os.ReadFile(*schemaPath); err != nil {
+			return fmt.Errorf("failed to read data schema: %w", err)
+		}
+	}
+
+	failed := false
+	all := make(map[string]debug.ValidationResult)
+	for _, dir := range p.TemplateDirs() {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fmt.Fprintf(messages, "%s: template directory not found\n", relative(p.Dir, dir))
+			failed = true
+			continue
+		}
+
+		validator := debug.NewTemplateValidator(debug.DirFS(dir), p.FuncMap(), nil)
+		if schema != nil {
+			if err := validator.SetDataSchema(schema); err != nil {
+				return err
+			}
+		}
+		if *fix {
+			validator.SetStrict(true)
+			if err := fixTemplates(validator, dir, func(fix debug.Fix) {
+				name := relative(p.Dir, filepath.Join(dir, fix.File))
+				fmt.Fprintf(messages, "%s:%d: fixed: %s\n", name, fix.Line, fix.Message)
+			}); err != nil {
+				return err
+			}
+		}
+		results := validator.ValidateDirectory(".")
+		paths := make([]string, 0, len(results))
+		for path := range results {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			result := results[path]
+			name := relative(p.Dir, filepath.Join(dir, path))
+			all[name] = result
+			if result.HasErrors() {
+				failed = true
+			}
+			if writeReport != nil {
+				continue
+			}
+			for _, e := range result.Errors {
+				fmt.Fprintln(stdout, formatIssue(name, "", e))
+			}
+			if *verbose {
+				for _, w := range result.Warnings {
+					fmt.Fprintln(stdout, formatIssue(name, "warning: ", w))
+				}
+			}
+		}
+	}
+
+	if *checkData {
+		if _, err := p.LoadData(context.Background()); err != nil {
+			fmt.Fprintln(messages, err)
+			failed = true
+		}
+	}
+
+	if writeReport != nil {
+		if err := writeReport(stdout, all); err != nil {
+			return err
+		}
+	}
+	if failed {
+		return errFailed
+	}
+	if writeReport == nil {
+		fmt.Fprintf(stdout, "%d templates ok\n", len(all))
+	}
+	return nil
+}
+
+// fixTemplates fixes each template in dir, calling report for every fix.
+func fixTemplates(validator *debug.TemplateValidator, dir string, report func(debug.Fix)) error {
+	return fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isTemplate(path) {
+			return nil
+		}
+		_, fixes, err := validator.ValidateAndFix(path)
+		for _, fix := range fixes {
+			report(fix)
+		}
+		return err
+	})
+}
+
+// reportWriters serialize validation results for the -format flag.
+var reportWriters = map[string]func(io.Writer, map[string]debug.ValidationResult) error{
+	"json":  debug.WriteJSONReport,
+	"sarif": debug.WriteSARIFReport,
+	"junit": debug.WriteJUnitReport,
+}
+
+// formatIssue formats a validation error as "file:line:column: message".
+func formatIssue(file, prefix string, e debug.ValidationError) string {
+	pos := file
+	if e.Line > 0 {
+		pos = fmt.Sprintf("%s:%d", pos, e.Line)
+		if e.Column > 0 {
+			pos = fmt.Sprintf("%s:%d", pos, e.Column)
+		}
+	}
+	line := fmt.Sprintf("%s: %s%s", pos, prefix, e.Message)
+	if e.Suggestion != "" {
+		line += " (" + e.Suggestion + ")"
+	}
+	return line
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// cueCommand is the cue executable run by LoadCUE.
+var cueCommand = "cue"
+
+// LoadCUE evaluates a CUE file, or the CUE package in a directory, and loads
+// the result into target with the same validation behaviour as LoadYAML.
+// CUE is evaluated by running `cue export --out json`, so the cue command
+// from cuelang.org must be on PATH; constraints, defaults and imports are
+// resolved by CUE itself and any evaluation error is returned. Fields are
+// matched using their `yaml` struct tags.
+func LoadCUE[T any](path string, target *T, opts ...Option) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path %q: %w", path, err)
+	}
+	info, err := os.Stat(absPath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("configuration file does not exist: %s", absPath)
+	} else if err != nil {
+		return fmt.Errorf("failed to read configuration file %q: %w", absPath, err)
+	}
+
+	dir, arg := filepath.Dir(absPath), filepath.Base(absPath)
+	if info.IsDir() {
+		dir, arg = absPath, "."
+	}
+	cmd := exec.Command(cueCommand, "export", "--out", "json", arg)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("loading CUE configuration requires the %s command from cuelang.org: %w", cueCommand, err)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("failed to evaluate CUE configuration: %s", msg)
+		}
+		return fmt.Errorf("failed to evaluate CUE configuration: %w", err)
+	}
+
+	node, err := parseJSON(out)
+	if err != nil {
+		return fmt.Errorf("failed to parse CUE configuration: %w", err)
+	}
+	o := newLoadOptions(opts)
+	o.recordSource(absPath)
+	return loadNode(node, target, "CUE", o)
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// applyDefaults adds the values of `default:"..."` struct tags to node for
+// every field of t whose key is missing, descending into nested structs,
+// lists and maps. Defaults are written as YAML, so `default:"[id, name]"`
+// sets a list. A null value counts as present and is left alone.
+func applyDefaults(node *yaml.Node, t reflect.Type) error {
+	return walkDefaults(node, t, "")
+}
+
+func walkDefaults(node *yaml.Node, t reflect.Type, path string) error {
+	node = resolveAlias(node)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	switch node.Kind {
+	case 0:
+		// An empty document still receives the defaults of a struct target.
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if err := walkDefaults(root, t, path); err != nil {
+			return err
+		}
+		if len(root.Content) > 0 {
+			*node = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+		}
+
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := walkDefaults(child, t, path); err != nil {
+				return err
+			}
+		}
+
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+		for i, item := range node.Content {
+			if err := walkDefaults(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+	case yaml.MappingNode:
+		switch t.Kind() {
+		case reflect.Map:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if err := walkDefaults(node.Content[i+1], t.Elem(), joinFieldPath(path, node.Content[i].Value)); err != nil {
+					return err
+				}
+			}
+		case reflect.Struct:
+			return structDefaults(node, t, path)
+		}
+	}
+	return nil
+}
+
+// structDefaults fills in the missing fields of a mapping decoded into
+// struct t.
+func structDefaults(node *yaml.Node, t reflect.Type, path string) error {
+	fields, _ := yamlFields(t)
+	present := make(map[string]bool)
+	collectKeys(node, present)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field, fieldPath := fields[name], joinFieldPath(path, name)
+		if present[name] {
+			if j := mappingIndex(node, name); j >= 0 {
+				if err := walkDefaults(node.Content[j+1], field.Type, fieldPath); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if def, ok := field.Tag.Lookup("default"); ok {
+			value, err := parseDefault(def, field.Type)
+			if err != nil {
+				return fmt.Errorf("invalid default for %s: %w", fieldPath, err)
+			}
+			if err := walkDefaults(value, field.Type, fieldPath); err != nil {
+				return err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+			continue
+		}
+
+		// A missing struct still gets the defaults of its own fields.
+		if field.Type.Kind() == reflect.Struct {
+			nested := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if err := walkDefaults(nested, field.Type, fieldPath); err != nil {
+				return err
+			}
+			if len(nested.Content) > 0 {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, nested)
+			}
+		}
+	}
+	return nil
+}
+
+// collectKeys records the keys of a mapping, including those brought in by
+// "<<" merge keys.
+func collectKeys(node *yaml.Node, keys map[string]bool) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i].Value; key == "<<" {
+				collectKeys(node.Content[i+1], keys)
+			} else {
+				keys[key] = true
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			collectKeys(item, keys)
+		}
+	}
+}
+
+// parseDefault parses a default tag as YAML and checks that it decodes into
+// a value of type t.
+func parseDefault(def string, t reflect.Type) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(def), &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: def}, nil
+	}
+	value := doc.Content[0]
+	if err := value.Decode(reflect.New(t).Interface()); err != nil {
+		return nil, fmt.Errorf("%q: %w", def, err)
+	}
+	return value, nil
+}
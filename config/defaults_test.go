@@ -0,0 +1,103 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type defaultsSpec struct {
+	Name     string        `yaml:"name"`
+	Port     int           `yaml:"port" default:"8080"`
+	Debug    bool          `yaml:"debug" default:"true"`
+	Timeout  time.Duration `yaml:"timeout" default:"30s"`
+	Tags     []string      `yaml:"tags" default:"[api, v1]"`
+	Database struct {
+		Driver string `yaml:"driver" default:"postgres"`
+		DSN    string `yaml:"dsn"`
+	} `yaml:"database"`
+	Endpoints []struct {
+		Path     string `yaml:"path"`
+		Method   string `yaml:"method" default:"GET"`
+		PageSize int    `yaml:"page_size" default:"50"`
+	} `yaml:"endpoints"`
+	Services map[string]struct {
+		Replicas int `yaml:"replicas" default:"1"`
+	} `yaml:"services"`
+	Cache *struct {
+		TTL int `yaml:"ttl" default:"60"`
+	} `yaml:"cache"`
+}
+
+func TestLoadDefaults(t *testing.T) {
+	var spec defaultsSpec
+	err := LoadYAMLFromString(`
+name: api
+debug: false
+endpoints:
+  - path: /users
+  - path: /orders
+    method: POST
+services:
+  web: {}
+  worker:
+    replicas: 3
+`, &spec)
+	if err != nil {
+		t.Fatalf("LoadYAMLFromString() error = %v", err)
+	}
+
+	if spec.Port != 8080 || spec.Timeout != 30*time.Second || strings.Join(spec.Tags, ",") != "api,v1" {
+		t.Errorf("top-level defaults not applied: %+v", spec)
+	}
+	if spec.Debug {
+		t.Error("explicit false was overridden by the default")
+	}
+	if spec.Database.Driver != "postgres" {
+		t.Errorf("Database.Driver = %q, want default for a missing nested struct", spec.Database.Driver)
+	}
+	if spec.Endpoints[0].Method != "GET" || spec.Endpoints[1].Method != "POST" || spec.Endpoints[1].PageSize != 50 {
+		t.Errorf("Endpoints = %+v, want defaults per list item", spec.Endpoints)
+	}
+	if spec.Services["web"].Replicas != 1 || spec.Services["worker"].Replicas != 3 {
+		t.Errorf("Services = %+v, want defaults per map value", spec.Services)
+	}
+	if spec.Cache != nil {
+		t.Errorf("Cache = %+v, want missing pointer left nil", spec.Cache)
+	}
+}
+
+func TestLoadDefaults_Formats(t *testing.T) {
+	var spec defaultsSpec
+	if err := LoadYAMLFromString("", &spec); err != nil || spec.Port != 8080 {
+		t.Errorf("empty document: port = %d, err = %v", spec.Port, err)
+	}
+
+	spec = defaultsSpec{}
+	if err := LoadTOMLFromString("port = 9000\n[cache]\n", &spec); err != nil {
+		t.Fatalf("LoadTOMLFromString() error = %v", err)
+	}
+	if spec.Port != 9000 || spec.Cache == nil || spec.Cache.TTL != 60 {
+		t.Errorf("TOML defaults: port = %d, cache = %+v", spec.Port, spec.Cache)
+	}
+
+	spec = defaultsSpec{}
+	if err := LoadJSONFromString(`{"port": null}`, &spec); err != nil || spec.Port != 0 {
+		t.Errorf("explicit null: port = %d, err = %v", spec.Port, err)
+	}
+
+	spec = defaultsSpec{}
+	if err := LoadYAMLFromString("base: &base {port: 1}\n<<: *base\n", &spec); err != nil || spec.Port != 1 {
+		t.Errorf("merge key: port = %d, err = %v", spec.Port, err)
+	}
+}
+
+func TestLoadDefaults_Invalid(t *testing.T) {
+	var spec struct {
+		Port int `yaml:"port" default:"eighty"`
+	}
+	err := LoadYAMLFromString("{}", &spec)
+	if err == nil || !strings.Contains(err.Error(), `invalid default for port: "eighty"`) {
+		t.Errorf("Expected invalid default error, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadDir loads every file in dir matching the glob pattern, in name order,
+// and aggregates them into target. An empty pattern matches all .yaml, .yml,
+// .json, .toml and .hcl files; patterns may name subdirectories, such as
+// "tables/*.yaml". Files are parsed in the format given by their extension.
+//
+// If T is a slice, each file is decoded into one element, so a directory
+// with one file per table loads into a []Table. Otherwise the files are
+// deep-merged as by LoadYAMLLayers, except that lists are appended unless
+// WithListMerge or WithListMergeFor choose another strategy, so files that
+// each contribute some tables assemble the full schema.
+func LoadDir[T any](dir, pattern string, target *T, opts ...Option) error {
+	paths, err := matchConfigFiles(dir, pattern)
+	if err != nil {
+		return err
+	}
+
+	o := newLoadOptions(append([]Option{WithListMerge(ListAppend)}, opts...))
+
+	slice := reflect.ValueOf(target).Elem()
+	if slice.Kind() == reflect.Slice {
+		elems := reflect.MakeSlice(slice.Type(), 0, len(paths))
+		for _, path := range paths {
+			f := formatForPath(path)
+			node, err := o.parseConfigFile(path, f)
+			if err != nil {
+				return fmt.Errorf("%s: %w", relPath(dir, path), err)
+			}
+			if node.Kind == 0 {
+				continue
+			}
+			elemType := slice.Type().Elem()
+			isPtr := elemType.Kind() == reflect.Pointer
+			if isPtr {
+				elemType = elemType.Elem()
+			}
+			elem := reflect.New(elemType)
+			if err := loadNode(node, elem.Interface(), f.name, o); err != nil {
+				return fmt.Errorf("%s: %w", relPath(dir, path), err)
+			}
+			if !isPtr {
+				elem = elem.Elem()
+			}
+			elems = reflect.Append(elems, elem)
+		}
+		slice.Set(elems)
+		return validate(target)
+	}
+
+	var merged *yaml.Node
+	for _, path := range paths {
+		node, err := o.parseConfigFile(path, formatForPath(path))
+		if err != nil {
+			return fmt.Errorf("%s: %w", relPath(dir, path), err)
+		}
+		merged = o.mergeDocuments(merged, node)
+	}
+	return loadNode(merged, target, "YAML", o)
+}
+
+// matchConfigFiles returns the regular files in dir matching pattern, in
+// name order.
+func matchConfigFiles(dir, pattern string) ([]string, error) {
+	if info, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("configuration directory does not exist: %s", dir)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("configuration path is not a directory: %s", dir)
+	}
+
+	glob := pattern
+	if glob == "" {
+		glob = "*"
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, glob))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	var paths []string
+	for _, path := range matches {
+		if pattern == "" {
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".yaml", ".yml", ".json", ".toml", ".hcl":
+			default:
+				continue
+			}
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		if pattern == "" {
+			return nil, fmt.Errorf("no configuration files in %s", dir)
+		}
+		return nil, fmt.Errorf("no configuration files in %s match %q", dir, pattern)
+	}
+	return paths, nil
+}
+
+func relPath(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type dirTable struct {
+	Name    string   `yaml:"name"`
+	Columns []string `yaml:"columns"`
+}
+
+func (t *dirTable) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("table name is required")
+	}
+	return nil
+}
+
+type dirSchema struct {
+	Database string     `yaml:"database"`
+	Tables   []dirTable `yaml:"tables"`
+}
+
+func TestLoadDir_Slice(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"orders.yaml": "name: orders\ncolumns: [id, total]\n",
+		"users.json":  `{"name": "users", "columns": ["id", "email"]}`,
+		"README.md":   "# tables\n",
+		"empty.yaml":  "",
+	})
+
+	var tables []dirTable
+	if err := LoadDir(dir, "", &tables); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	if len(tables) != 2 || tables[0].Name != "orders" || tables[1].Name != "users" {
+		t.Fatalf("tables = %+v, want orders and users in name order", tables)
+	}
+
+	var pointers []*dirTable
+	if err := LoadDir(dir, "*.yaml", &pointers); err != nil {
+		t.Fatalf("LoadDir(*.yaml) error = %v", err)
+	}
+	if len(pointers) != 1 || pointers[0].Name != "orders" {
+		t.Errorf("pointers = %+v, want only orders", pointers)
+	}
+}
+
+func TestLoadDir_Merged(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"schema.yaml": "database: shop\n",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "tables"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"tables/orders.yaml": "tables:\n  - name: orders\n",
+		"tables/users.yaml":  "tables:\n  - name: users\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var schema dirSchema
+	if err := LoadYAML(filepath.Join(dir, "schema.yaml"), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDir(dir, "tables/*.yaml", &schema); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	if schema.Database != "shop" || len(schema.Tables) != 2 || schema.Tables[1].Name != "users" {
+		t.Errorf("schema = %+v, want tables appended from every file", schema)
+	}
+
+	var replaced dirSchema
+	if err := LoadDir(filepath.Join(dir, "tables"), "", &replaced, WithListMerge(ListReplace)); err != nil {
+		t.Fatalf("LoadDir(ListReplace) error = %v", err)
+	}
+	if len(replaced.Tables) != 1 || replaced.Tables[0].Name != "users" {
+		t.Errorf("replaced = %+v, want the last file's tables", replaced)
+	}
+}
+
+func TestLoadDir_Errors(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"a.yaml": "name: a\n",
+		"b.yaml": "columns: [id]\n",
+		"c.yaml": "name: [\n",
+	})
+
+	tests := []struct {
+		dir, pattern string
+		want         string
+	}{
+		{dir, "[ab].yaml", "b.yaml: configuration validation failed: table name is required"},
+		{dir, "c.yaml", "c.yaml: failed to parse YAML configuration"},
+		{dir, "*.json", `match "*.json"`},
+		{filepath.Join(dir, "missing"), "", "configuration directory does not exist"},
+		{filepath.Join(dir, "a.yaml"), "", "not a directory"},
+	}
+	for _, tt := range tests {
+		var tables []dirTable
+		err := LoadDir(tt.dir, tt.pattern, &tables)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadDir(%s, %q) error = %v, want %q", filepath.Base(tt.dir), tt.pattern, err, tt.want)
+		}
+	}
+}
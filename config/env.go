@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MissingEnvPolicy controls what environment expansion does with references
+// to unset variables that have no default.
+type MissingEnvPolicy int
+
+const (
+	// MissingEnvEmpty replaces the reference with an empty string, like the
+	// shell (default).
+	MissingEnvEmpty MissingEnvPolicy = iota
+	// MissingEnvKeep leaves the reference in place.
+	MissingEnvKeep
+	// MissingEnvError fails loading and lists every missing variable.
+	MissingEnvError
+)
+
+// ErrMissingEnv is wrapped by errors for unset variables under
+// MissingEnvError.
+var ErrMissingEnv = errors.New("environment variable is not set")
+
+// expandEnvNodes expands references in every scalar value below node.
+// Mapping keys are left alone. A scalar that consists of a single reference
+// is re-typed from its expanded value, so "port: ${PORT}" decodes into an
+// int.
+func expandEnvNodes(node *yaml.Node, lookup func(string) (string, bool), missing MissingEnvPolicy) error {
+	var errs []error
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		switch n.Kind {
+		case yaml.ScalarNode:
+			value, whole, err := expandEnv(n.Value, lookup, missing)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("line %d, column %d: %w", n.Line, n.Column, err))
+				return
+			}
+			if value == n.Value {
+				return
+			}
+			n.Value = value
+			if whole {
+				n.Tag = ""
+				n.Style &^= yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle
+			}
+		case yaml.MappingNode:
+			for i := 1; i < len(n.Content); i += 2 {
+				walk(n.Content[i])
+			}
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, child := range n.Content {
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+	return errors.Join(errs...)
+}
+
+// expandEnv expands ${VAR} and ${VAR:-default} in s. "$${" is an escaped
+// literal "${". It also reports whether s was exactly one reference.
+func expandEnv(s string, lookup func(string) (string, bool), missing MissingEnvPolicy) (string, bool, error) {
+	if !strings.Contains(s, "${") {
+		return s, false, nil
+	}
+
+	var b strings.Builder
+	var missingNames []string
+	refs, literal := 0, false
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("${")
+			i += 3
+			literal = true
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			b.WriteByte(s[i])
+			i++
+			literal = true
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", false, fmt.Errorf("unterminated variable reference in %q", s)
+		}
+		ref := s[i+2 : i+end]
+		i += end + 1
+		refs++
+
+		name, def, hasDefault := strings.Cut(ref, ":-")
+		if !validEnvName(name) {
+			return "", false, fmt.Errorf("invalid variable name %q", name)
+		}
+		if value, ok := lookup(name); ok && (value != "" || !hasDefault) {
+			b.WriteString(value)
+			continue
+		}
+		switch {
+		case hasDefault:
+			b.WriteString(def)
+		case missing == MissingEnvKeep:
+			b.WriteString("${" + ref + "}")
+		case missing == MissingEnvError:
+			missingNames = append(missingNames, name)
+		}
+	}
+
+	if len(missingNames) > 0 {
+		return "", false, fmt.Errorf("%w: %s", ErrMissingEnv, strings.Join(missingNames, ", "))
+	}
+	return b.String(), refs == 1 && !literal, nil
+}
+
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c != '_' && !(c >= 'A' && c <= 'Z') && !(c >= 'a' && c <= 'z') && !(i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
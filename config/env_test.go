@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadYAML_EnvExpansion(t *testing.T) {
+	env := map[string]string{
+		"API_HOST": "api.example.com",
+		"API_PORT": "8443",
+		"EMPTY":    "",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	type Spec struct {
+		BaseURL string `yaml:"base_url"`
+		Port    int    `yaml:"port"`
+		Timeout int    `yaml:"timeout"`
+		Region  string `yaml:"region"`
+		Literal string `yaml:"literal"`
+		Quoted  int    `yaml:"quoted"`
+	}
+
+	yamlContent := `
+base_url: https://${API_HOST}:${API_PORT}/v1
+port: ${API_PORT}
+timeout: ${TIMEOUT:-30}
+region: ${EMPTY:-us-east-1}
+literal: $${NOT_EXPANDED}
+quoted: "${API_PORT}"
+`
+
+	var spec Spec
+	if err := LoadYAMLFromString(yamlContent, &spec, WithEnvExpansion(MissingEnvError), WithEnvLookup(lookup)); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if spec.BaseURL != "https://api.example.com:8443/v1" {
+		t.Errorf("Unexpected base URL %q", spec.BaseURL)
+	}
+	if spec.Port != 8443 || spec.Quoted != 8443 || spec.Timeout != 30 {
+		t.Errorf("Expected whole references to decode as ints, got %+v", spec)
+	}
+	if spec.Region != "us-east-1" {
+		t.Errorf("Expected default for empty variable, got %q", spec.Region)
+	}
+	if spec.Literal != "${NOT_EXPANDED}" {
+		t.Errorf("Expected escaped reference to be kept literally, got %q", spec.Literal)
+	}
+}
+
+func TestLoadYAML_EnvExpansionMissing(t *testing.T) {
+	lookup := func(string) (string, bool) { return "", false }
+	yamlContent := "name: ${NAME}\nurl: http://${HOST}/${PATH_PREFIX}\n"
+
+	var values map[string]string
+	err := LoadYAMLFromString(yamlContent, &values, WithEnvExpansion(MissingEnvError), WithEnvLookup(lookup))
+	if !errors.Is(err, ErrMissingEnv) {
+		t.Fatalf("Expected ErrMissingEnv, got %v", err)
+	}
+	for _, want := range []string{"line 1, column 7", "NAME", "line 2, column 6", "HOST, PATH_PREFIX"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got %v", want, err)
+		}
+	}
+
+	values = nil
+	if err := LoadYAMLFromString(yamlContent, &values, WithEnvExpansion(MissingEnvKeep), WithEnvLookup(lookup)); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if values["name"] != "${NAME}" {
+		t.Errorf("Expected MissingEnvKeep to keep the reference, got %q", values["name"])
+	}
+
+	values = nil
+	if err := LoadYAMLFromString(yamlContent, &values, WithEnvExpansion(MissingEnvEmpty), WithEnvLookup(lookup)); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if values["url"] != "http:///" {
+		t.Errorf("Expected MissingEnvEmpty to substitute empty strings, got %q", values["url"])
+	}
+
+	values = nil
+	if err := LoadYAMLFromString(yamlContent, &values); err != nil || values["name"] != "${NAME}" {
+		t.Errorf("Expected expansion to be off by default, got %q, %v", values["name"], err)
+	}
+}
+
+func TestExpandEnv_InvalidReferences(t *testing.T) {
+	lookup := func(string) (string, bool) { return "", false }
+	for _, s := range []string{"${UNTERMINATED", "${1BAD}", "${}"} {
+		if _, _, err := expandEnv(s, lookup, MissingEnvEmpty); err == nil {
+			t.Errorf("expandEnv(%q) expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/cpcf/weft/render"
+	"gopkg.in/yaml.v3"
+)
+
+// WithExpressions evaluates {{ ... }} template expressions in configuration
+// values after loading, with the rest of the configuration as data and the
+// render package's default functions:
+//
+//	name: billing
+//	package: "{{ .name | snake }}db"
+//
+// Expressions may refer to values that are themselves expressions, and to
+// struct tag defaults. A value that is exactly one expression is re-typed
+// from its result, so "replicas: '{{ .workers }}'" decodes into an int.
+// Write {{"{{"}} for a literal "{{".
+func WithExpressions() Option {
+	return func(o *loadOptions) {
+		o.expressions = true
+	}
+}
+
+// expression is a configuration value containing template actions.
+type expression struct {
+	node  *yaml.Node
+	path  string
+	tmpl  *template.Template
+	whole bool
+	quote yaml.Style
+	err   error
+}
+
+// evaluateExpressions replaces every scalar value below node that contains
+// template actions with its result. Because expressions can refer to each
+// other, all of them are re-evaluated against the updated configuration
+// until none changes; expressions that keep changing form a cycle.
+func evaluateExpressions(node *yaml.Node) error {
+	exprs, err := collectExpressions(node, "")
+	if err != nil || len(exprs) == 0 {
+		return err
+	}
+
+	for round := 0; ; round++ {
+		var data any
+		if err := decodeNode(node, &data); err != nil {
+			return err
+		}
+
+		var changed []string
+		for _, e := range exprs {
+			var b bytes.Buffer
+			if e.err = e.tmpl.Execute(&b, data); e.err != nil {
+				continue
+			}
+			if value := b.String(); value != e.node.Value {
+				e.node.Value = value
+				changed = append(changed, e.path)
+			}
+			if e.whole {
+				e.node.Tag = ""
+				e.node.Style &^= yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle
+			}
+		}
+
+		if len(changed) == 0 {
+			break
+		}
+		if round > len(exprs) {
+			return fmt.Errorf("expressions refer to each other in a cycle: %s", strings.Join(changed, ", "))
+		}
+	}
+
+	var errs []error
+	for _, e := range exprs {
+		if e.err != nil {
+			errs = append(errs, fmt.Errorf("line %d, column %d: %w", e.node.Line, e.node.Column, e.err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// collectExpressions parses the template in every scalar value below node
+// that contains "{{". Mapping keys are left alone.
+func collectExpressions(node *yaml.Node, path string) ([]*expression, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "{{") {
+			return nil, nil
+		}
+		tmpl, err := template.New(path).
+			Funcs(render.DefaultFuncMap()).
+			Option("missingkey=error").
+			Parse(node.Value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d, column %d: %w", node.Line, node.Column, err)
+		}
+		return []*expression{{node: node, path: path, tmpl: tmpl, whole: singleAction(tmpl)}}, nil
+
+	case yaml.MappingNode:
+		var exprs []*expression
+		var errs []error
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			found, err := collectExpressions(node.Content[i+1], joinFieldPath(path, node.Content[i].Value))
+			exprs = append(exprs, found...)
+			errs = append(errs, err)
+		}
+		return exprs, errors.Join(errs...)
+
+	case yaml.SequenceNode:
+		var exprs []*expression
+		var errs []error
+		for i, item := range node.Content {
+			found, err := collectExpressions(item, fmt.Sprintf("%s[%d]", path, i))
+			exprs = append(exprs, found...)
+			errs = append(errs, err)
+		}
+		return exprs, errors.Join(errs...)
+
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			return collectExpressions(node.Content[0], path)
+		}
+	}
+	return nil, nil
+}
+
+// singleAction reports whether a template consists of one action and
+// nothing else, such as "{{ .port }}".
+func singleAction(tmpl *template.Template) bool {
+	if tmpl.Tree == nil || len(tmpl.Tree.Root.Nodes) != 1 {
+		return false
+	}
+	_, ok := tmpl.Tree.Root.Nodes[0].(*parse.ActionNode)
+	return ok
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+type exprConfig struct {
+	Name     string   `yaml:"name"`
+	Package  string   `yaml:"package"`
+	Module   string   `yaml:"module"`
+	Port     int      `yaml:"port"`
+	Replicas int      `yaml:"replicas"`
+	Region   string   `yaml:"region" default:"eu-west-1"`
+	Bucket   string   `yaml:"bucket"`
+	Tables   []string `yaml:"tables"`
+	Literal  string   `yaml:"literal"`
+}
+
+func TestWithExpressions(t *testing.T) {
+	src := `
+name: OrderService
+module: "github.com/acme/{{ .package }}"
+package: "{{ .name | snake }}db"
+port: 8080
+replicas: "{{ .port }}"
+bucket: "{{ .package }}-{{ .region }}"
+tables:
+  - "{{ .name | plural | lower }}"
+literal: '{{"{{"}} .name }}'
+`
+	var cfg exprConfig
+	if err := LoadYAMLFromString(src, &cfg, WithExpressions()); err != nil {
+		t.Fatalf("LoadYAMLFromString() error = %v", err)
+	}
+
+	want := exprConfig{
+		Name:     "OrderService",
+		Package:  "order_servicedb",
+		Module:   "github.com/acme/order_servicedb",
+		Port:     8080,
+		Replicas: 8080,
+		Region:   "eu-west-1",
+		Bucket:   "order_servicedb-eu-west-1",
+		Tables:   []string{"orderservices"},
+		Literal:  "{{ .name }}",
+	}
+	if cfg.Name != want.Name || cfg.Package != want.Package || cfg.Module != want.Module ||
+		cfg.Replicas != want.Replicas || cfg.Bucket != want.Bucket || cfg.Literal != want.Literal ||
+		len(cfg.Tables) != 1 || cfg.Tables[0] != want.Tables[0] {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+}
+
+func TestWithExpressions_Disabled(t *testing.T) {
+	var cfg exprConfig
+	if err := LoadYAMLFromString(`package: "{{ .name }}"`, &cfg); err != nil {
+		t.Fatalf("LoadYAMLFromString() error = %v", err)
+	}
+	if cfg.Package != "{{ .name }}" {
+		t.Errorf("Package = %q, want the expression left alone", cfg.Package)
+	}
+}
+
+func TestWithExpressions_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"missing key", `package: "{{ .nmae }}"`, `line 1, column 10`},
+		{"syntax", `package: "{{ .name "`, `line 1, column 10`},
+		{"unknown function", `package: "{{ .name | shout }}"`, `function "shout" not defined`},
+		{"cycle", "name: \"{{ .package }}x\"\npackage: \"{{ .name }}y\"", "cycle: name, package"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg exprConfig
+			err := LoadYAMLFromString(tt.src, &cfg, WithExpressions())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
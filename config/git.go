@@ -0,0 +1,128 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// gitSource is a file in a git repository.
+type gitSource struct {
+	repo string
+	file string
+	ref  string
+}
+
+// isGitSource reports whether raw names a file in a git repository rather
+// than an HTTP document, using the same rules as template pack sources.
+func isGitSource(raw string) bool {
+	return strings.HasPrefix(raw, "git+") || strings.Contains(raw, ".git//")
+}
+
+// parseGitSource splits a source such as
+// "https://github.com/acme/specs.git//billing/api.yaml?ref=v1" into the
+// repository, the file and the ref.
+func parseGitSource(raw string) (*gitSource, error) {
+	source, query, _ := strings.Cut(strings.TrimPrefix(raw, "git+"), "?")
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration URL: %w", err)
+	}
+
+	start := 0
+	if i := strings.Index(source, "://"); i >= 0 {
+		start = i + 3
+	}
+	i := strings.Index(source[start:], "//")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid git configuration source %q: want repository//path/to/file", raw)
+	}
+	repo, file := source[:start+i], source[start+i+2:]
+	if !filepath.IsLocal(file) {
+		return nil, fmt.Errorf("invalid file %q in git configuration source", file)
+	}
+	return &gitSource{repo: repo, file: file, ref: values.Get("ref")}, nil
+}
+
+func (s *gitSource) String() string {
+	repo := s.repo
+	if u, err := url.Parse(repo); err == nil {
+		repo = u.Redacted()
+	}
+	return s.format(repo)
+}
+
+func (s *gitSource) location() string { return s.format(s.repo) }
+func (s *gitSource) path() string     { return s.file }
+
+func (s *gitSource) format(repo string) string {
+	if s.ref == "" {
+		return repo + "//" + s.file
+	}
+	return repo + "//" + s.file + "?ref=" + s.ref
+}
+
+// download fetches only the ref, without history, into a scratch
+// repository and reads the file from it.
+func (s *gitSource) download(r *remoteOptions) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "weft-config-git-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch configuration %s: %w", s, err)
+	}
+	defer os.RemoveAll(dir)
+
+	ref := s.ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// Headers are passed in the environment rather than as arguments so
+	// credentials do not show up in the process list.
+	var env []string
+	for _, name := range slices.Sorted(maps.Keys(r.headers)) {
+		for _, value := range r.headers[name] {
+			n := len(env) / 2
+			env = append(env,
+				fmt.Sprintf("GIT_CONFIG_KEY_%d=http.extraHeader", n),
+				fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s: %s", n, name, value))
+		}
+	}
+	if len(env) > 0 {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(env)/2))
+	}
+
+	var data []byte
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", "--", s.repo, ref},
+		{"show", "FETCH_HEAD:" + s.file},
+	} {
+		if data, err = runGit(dir, env, args...); err != nil {
+			return nil, fmt.Errorf("failed to fetch configuration %s: %w", s, err)
+		}
+	}
+	return data, nil
+}
+
+// runGit runs git in dir with env added to its environment and returns its
+// output.
+func runGit(dir string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
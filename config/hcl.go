@@ -0,0 +1,615 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// hclBlocksTag marks the sequence that collects the unlabeled blocks of one
+// type, so adaptHCLBlocks can unwrap it for struct fields.
+const hclBlocksTag = "!hcl/blocks"
+
+// parseHCL parses the literal subset of HCL native syntax into a yaml.Node
+// tree. Attributes become keys. Labeled blocks nest by label, so
+// `table "users" { ... }` becomes table.users; unlabeled blocks of one type
+// are collected into a list, which adaptHCLBlocks unwraps when the target
+// field is not a slice. Values may be strings, heredocs, numbers, booleans,
+// null, tuples and objects; references, function calls, operators and
+// ${...} interpolation are rejected, since there is no evaluation context.
+func parseHCL(data []byte) (*yaml.Node, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("HCL must be valid UTF-8")
+	}
+
+	p := &hclParser{src: string(data), line: 1, col: 1}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}
+	if err := p.parseBody(root, false); err != nil {
+		return nil, err
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Line: 1, Column: 1, Content: []*yaml.Node{root}}, nil
+}
+
+type hclParser struct {
+	src       string
+	pos       int
+	line, col int
+}
+
+func (p *hclParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d, column %d: %s", p.line, p.col, fmt.Sprintf(format, args...))
+}
+
+func (p *hclParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *hclParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *hclParser) advance(n int) {
+	for i := 0; i < n && !p.eof(); i++ {
+		if p.src[p.pos] == '\n' {
+			p.line++
+			p.col = 1
+		} else if p.src[p.pos]&0xC0 != 0x80 {
+			p.col++
+		}
+		p.pos++
+	}
+}
+
+func (p *hclParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+// skipSpace skips spaces, tabs and comments, and newlines too if newlines
+// is set. A line comment is skipped up to, but not including, its newline.
+func (p *hclParser) skipSpace(newlines bool) error {
+	for !p.eof() {
+		switch {
+		case p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\r':
+			p.advance(1)
+		case p.peek() == '\n' && newlines:
+			p.advance(1)
+		case p.peek() == '#' || p.hasPrefix("//"):
+			for !p.eof() && p.peek() != '\n' {
+				p.advance(1)
+			}
+		case p.hasPrefix("/*"):
+			end := strings.Index(p.src[p.pos+2:], "*/")
+			if end < 0 {
+				return p.errorf("unterminated comment")
+			}
+			p.advance(end + 4)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseBody parses attributes and blocks into mapping until the end of
+// input, or until a closing brace if nested is set.
+func (p *hclParser) parseBody(mapping *yaml.Node, nested bool) error {
+	for {
+		if err := p.skipSpace(true); err != nil {
+			return err
+		}
+		if p.eof() {
+			if nested {
+				return p.errorf("unterminated block, expected '}'")
+			}
+			return nil
+		}
+		if p.peek() == '}' {
+			if !nested {
+				return p.errorf("unexpected '}'")
+			}
+			p.advance(1)
+			return nil
+		}
+
+		line, col := p.line, p.col
+		name := p.scanIdentifier()
+		if name == "" {
+			return p.errorf("expected an attribute or block, found %q", p.peek())
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name, Line: line, Column: col}
+		if err := p.skipSpace(false); err != nil {
+			return err
+		}
+
+		if p.peek() == '=' {
+			p.advance(1)
+			value, err := p.parseExpression()
+			if err != nil {
+				return err
+			}
+			if mappingIndex(mapping, name) >= 0 {
+				return fmt.Errorf("line %d, column %d: duplicate attribute %q", line, col, name)
+			}
+			mapping.Content = append(mapping.Content, key, value)
+		} else if err := p.parseBlock(mapping, key); err != nil {
+			return err
+		}
+
+		if err := p.endItem(nested); err != nil {
+			return err
+		}
+	}
+}
+
+// endItem expects the rest of the line after an attribute or block to be
+// blank, or the closing brace of a one-line block.
+func (p *hclParser) endItem(nested bool) error {
+	if err := p.skipSpace(false); err != nil {
+		return err
+	}
+	switch {
+	case p.eof(), p.peek() == '\n':
+		return nil
+	case nested && p.peek() == '}':
+		return nil
+	}
+	return p.errorf("expected a newline, found %q", p.peek())
+}
+
+// parseBlock parses the labels and body of a block of type key and adds it
+// to mapping.
+func (p *hclParser) parseBlock(mapping, key *yaml.Node) error {
+	var labels []*yaml.Node
+	for p.peek() != '{' {
+		line, col := p.line, p.col
+		var label string
+		switch {
+		case p.peek() == '"':
+			s, err := p.parseQuoted()
+			if err != nil {
+				return err
+			}
+			label = s
+		default:
+			label = p.scanIdentifier()
+			if label == "" {
+				return p.errorf("expected '=', a block label or '{' after %q", key.Value)
+			}
+		}
+		labels = append(labels, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: label, Line: line, Column: col})
+		if err := p.skipSpace(false); err != nil {
+			return err
+		}
+	}
+
+	body := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: key.Line, Column: key.Column}
+	p.advance(1)
+	if err := p.parseBody(body, true); err != nil {
+		return err
+	}
+
+	if len(labels) == 0 {
+		blocks := mappingValue(mapping, key.Value)
+		if blocks == nil {
+			blocks = &yaml.Node{Kind: yaml.SequenceNode, Tag: hclBlocksTag, Line: key.Line, Column: key.Column}
+			mapping.Content = append(mapping.Content, key, blocks)
+		} else if blocks.Tag != hclBlocksTag {
+			return fmt.Errorf("line %d, column %d: block %q conflicts with an attribute or labeled block", key.Line, key.Column, key.Value)
+		}
+		blocks.Content = append(blocks.Content, body)
+		return nil
+	}
+
+	// Labeled blocks nest one mapping per label: type -> label -> ... -> body.
+	parent, path := mapping, append([]*yaml.Node{key}, labels...)
+	for i, k := range path {
+		existing := mappingValue(parent, k.Value)
+		if i == len(path)-1 {
+			if existing != nil {
+				return fmt.Errorf("line %d, column %d: duplicate block %s", key.Line, key.Column, blockName(path))
+			}
+			parent.Content = append(parent.Content, k, body)
+			return nil
+		}
+		if existing == nil {
+			existing = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: k.Line, Column: k.Column}
+			parent.Content = append(parent.Content, k, existing)
+		} else if existing.Kind != yaml.MappingNode || existing.Tag == hclBlocksTag {
+			return fmt.Errorf("line %d, column %d: block %q conflicts with an attribute or unlabeled block", k.Line, k.Column, k.Value)
+		}
+		parent = existing
+	}
+	return nil
+}
+
+func blockName(path []*yaml.Node) string {
+	parts := []string{path[0].Value}
+	for _, label := range path[1:] {
+		parts = append(parts, strconv.Quote(label.Value))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p *hclParser) scanIdentifier() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80 ||
+			p.pos > start && (c >= '0' && c <= '9' || c == '-') {
+			p.advance(1)
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *hclParser) parseExpression() (*yaml.Node, error) {
+	if err := p.skipSpace(false); err != nil {
+		return nil, err
+	}
+	line, col := p.line, p.col
+	scalar := func(tag, value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value, Line: line, Column: col}
+	}
+
+	switch c := p.peek(); {
+	case c == '"':
+		s, err := p.parseQuoted()
+		return scalar("!!str", s), err
+	case p.hasPrefix("<<"):
+		s, err := p.parseHeredoc()
+		return scalar("!!str", s), err
+	case c == '[':
+		return p.parseTuple()
+	case c == '{':
+		return p.parseObject()
+	case c == '-' || c >= '0' && c <= '9':
+		return p.parseNumber()
+	}
+
+	name := p.scanIdentifier()
+	switch name {
+	case "true", "false":
+		return scalar("!!bool", name), nil
+	case "null":
+		return scalar("!!null", "null"), nil
+	case "":
+		return nil, p.errorf("expected a value")
+	}
+	return nil, fmt.Errorf("line %d, column %d: unsupported expression %q: only literal values can be loaded", line, col, name)
+}
+
+func (p *hclParser) parseNumber() (*yaml.Node, error) {
+	line, col := p.line, p.col
+	start := p.pos
+	if p.peek() == '-' {
+		p.advance(1)
+	}
+	for !p.eof() && strings.IndexByte("0123456789.eE+-", p.peek()) >= 0 {
+		if (p.peek() == '+' || p.peek() == '-') && p.src[p.pos-1] != 'e' && p.src[p.pos-1] != 'E' {
+			break
+		}
+		p.advance(1)
+	}
+	token := p.src[start:p.pos]
+
+	tag := "!!int"
+	if _, err := strconv.ParseInt(token, 10, 64); err != nil {
+		if _, err := strconv.ParseFloat(token, 64); err != nil || strings.HasSuffix(token, ".") {
+			return nil, fmt.Errorf("line %d, column %d: invalid number %q", line, col, token)
+		}
+		tag = "!!float"
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: token, Line: line, Column: col}, nil
+}
+
+func (p *hclParser) parseTuple() (*yaml.Node, error) {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: p.line, Column: p.col}
+	p.advance(1)
+	for {
+		if err := p.skipSpace(true); err != nil {
+			return nil, err
+		}
+		if p.peek() == ']' {
+			p.advance(1)
+			return seq, nil
+		}
+		item, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		seq.Content = append(seq.Content, item)
+
+		if err := p.skipSpace(true); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.advance(1)
+		case ']':
+		default:
+			return nil, p.errorf("expected ',' or ']' in tuple")
+		}
+	}
+}
+
+func (p *hclParser) parseObject() (*yaml.Node, error) {
+	obj := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: p.line, Column: p.col}
+	p.advance(1)
+	for {
+		if err := p.skipSpace(true); err != nil {
+			return nil, err
+		}
+		if p.peek() == '}' {
+			p.advance(1)
+			return obj, nil
+		}
+
+		line, col := p.line, p.col
+		var name string
+		if p.peek() == '"' {
+			s, err := p.parseQuoted()
+			if err != nil {
+				return nil, err
+			}
+			name = s
+		} else if name = p.scanIdentifier(); name == "" {
+			return nil, p.errorf("expected an object key")
+		}
+		if err := p.skipSpace(false); err != nil {
+			return nil, err
+		}
+		if p.peek() != '=' && p.peek() != ':' {
+			return nil, p.errorf("expected '=' or ':' after object key %q", name)
+		}
+		p.advance(1)
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if mappingIndex(obj, name) >= 0 {
+			return nil, fmt.Errorf("line %d, column %d: duplicate object key %q", line, col, name)
+		}
+		obj.Content = append(obj.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name, Line: line, Column: col}, value)
+
+		if err := p.skipSpace(false); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',', '\n':
+			p.advance(1)
+		case '}':
+		default:
+			return nil, p.errorf("expected ',', a newline or '}' in object")
+		}
+	}
+}
+
+// parseQuoted parses a quoted string. Template sequences are rejected, and
+// their escaped forms $${ and %%{ produce a literal ${ and %{.
+func (p *hclParser) parseQuoted() (string, error) {
+	p.advance(1)
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		switch {
+		case p.peek() == '"':
+			p.advance(1)
+			return b.String(), nil
+		case p.peek() == '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			if err := p.templateChar(&b); err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// templateChar copies one character of string content to b, handling
+// template sequences.
+func (p *hclParser) templateChar(b *strings.Builder) error {
+	switch {
+	case p.hasPrefix("$${"), p.hasPrefix("%%{"):
+		b.WriteString(p.src[p.pos+1 : p.pos+3])
+		p.advance(3)
+	case p.hasPrefix("${"), p.hasPrefix("%{"):
+		return p.errorf("template sequence %s...} is not supported: only literal values can be loaded", p.src[p.pos:p.pos+2])
+	default:
+		b.WriteByte(p.peek())
+		p.advance(1)
+	}
+	return nil
+}
+
+func (p *hclParser) parseEscape(b *strings.Builder) error {
+	p.advance(1)
+	c := p.peek()
+	switch c {
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+1+size > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.src[p.pos+1:p.pos+1+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(code))
+		p.advance(1 + size)
+		return nil
+	default:
+		return p.errorf("invalid escape sequence \\%c", c)
+	}
+	p.advance(1)
+	return nil
+}
+
+// parseHeredoc parses <<MARKER and <<-MARKER strings. The indented form
+// removes the indentation common to all non-blank lines.
+func (p *hclParser) parseHeredoc() (string, error) {
+	p.advance(2)
+	indented := p.peek() == '-'
+	if indented {
+		p.advance(1)
+	}
+	marker := p.scanIdentifier()
+	if marker == "" {
+		return "", p.errorf("expected a heredoc marker")
+	}
+	if p.hasPrefix("\r\n") {
+		p.advance(2)
+	} else if p.peek() == '\n' {
+		p.advance(1)
+	} else {
+		return "", p.errorf("expected a newline after heredoc marker %s", marker)
+	}
+
+	var lines []string
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated heredoc, expected %s", marker)
+		}
+		end := strings.IndexByte(p.src[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		text := strings.TrimSuffix(p.src[p.pos:p.pos+end], "\r")
+		if strings.TrimSpace(text) == marker {
+			p.advance(len(strings.TrimRight(p.src[p.pos:p.pos+end], "\r")))
+			break
+		}
+
+		var b strings.Builder
+		line := &hclParser{src: text, line: p.line, col: 1}
+		for !line.eof() {
+			if err := line.templateChar(&b); err != nil {
+				return "", err
+			}
+		}
+		lines = append(lines, b.String())
+		p.advance(end + 1)
+	}
+
+	if indented {
+		indent := -1
+		for _, l := range lines {
+			if strings.TrimSpace(l) == "" {
+				continue
+			}
+			n := len(l) - len(strings.TrimLeft(l, " \t"))
+			if indent < 0 || n < indent {
+				indent = n
+			}
+		}
+		for i, l := range lines {
+			if len(l) >= indent && indent > 0 {
+				lines[i] = l[indent:]
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// adaptHCLBlocks replaces each list of unlabeled HCL blocks in node with its
+// only block when the target field is not a slice, so `database { ... }`
+// decodes into a struct while repeated `endpoint { ... }` blocks decode into
+// a slice. Documents from other formats are left unchanged.
+func adaptHCLBlocks(node *yaml.Node, t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := adaptHCLBlocks(child, t); err != nil {
+				return err
+			}
+		}
+
+	case yaml.SequenceNode:
+		var elem reflect.Type
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			elem = t.Elem()
+		}
+		for _, item := range node.Content {
+			if err := adaptHCLBlocks(item, orAny(elem)); err != nil {
+				return err
+			}
+		}
+
+	case yaml.MappingNode:
+		var fields map[string]reflect.StructField
+		if t.Kind() == reflect.Struct {
+			fields, _ = yamlFields(t)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			var ft reflect.Type
+			switch t.Kind() {
+			case reflect.Struct:
+				if f, ok := fields[key.Value]; ok {
+					ft = f.Type
+				}
+			case reflect.Map:
+				ft = t.Elem()
+			}
+			ft = orAny(ft)
+
+			if value.Tag == hclBlocksTag {
+				value.Tag = "!!seq"
+				base := ft
+				for base.Kind() == reflect.Pointer {
+					base = base.Elem()
+				}
+				if base.Kind() != reflect.Slice && base.Kind() != reflect.Array && base.Kind() != reflect.Interface {
+					if len(value.Content) > 1 {
+						return fmt.Errorf("line %d, column %d: block %q is defined more than once", value.Content[1].Line, value.Content[1].Column, key.Value)
+					}
+					value = value.Content[0]
+					node.Content[i+1] = value
+				}
+			}
+			if err := adaptHCLBlocks(value, ft); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+func orAny(t reflect.Type) reflect.Type {
+	if t == nil {
+		return anyType
+	}
+	return t
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type hclSpec struct {
+	Name     string            `yaml:"name"`
+	Port     int               `yaml:"port"`
+	Ratio    float64           `yaml:"ratio"`
+	Debug    bool              `yaml:"debug"`
+	Tags     []string          `yaml:"tags"`
+	Labels   map[string]string `yaml:"labels"`
+	Banner   string            `yaml:"banner"`
+	Database struct {
+		Driver string `yaml:"driver"`
+		DSN    string `yaml:"dsn"`
+	} `yaml:"database"`
+	Endpoints []struct {
+		Path   string `yaml:"path"`
+		Method string `yaml:"method"`
+	} `yaml:"endpoint"`
+	Tables map[string]struct {
+		Comment string   `yaml:"comment"`
+		Columns []string `yaml:"columns"`
+	} `yaml:"table"`
+}
+
+func TestLoadHCL(t *testing.T) {
+	content := `
+# Service definition
+name  = "users"
+port  = 8080
+ratio = 0.5 // inline comment
+debug = true
+tags  = ["a", "b",]
+labels = {
+  team = "core"
+  "app.kubernetes.io/name": "users"
+}
+banner = <<-EOT
+    Hello, $${name}
+      indented
+    EOT
+
+/* block comment */
+database {
+  driver = "postgres"
+  dsn    = "postgres://localhost/users"
+}
+
+endpoint { path = "/users" }
+endpoint {
+  path   = "/users/{id}"
+  method = "GET"
+}
+
+table "users" {
+  comment = "Registered users"
+  columns = ["id", "email"]
+}
+table "orders" {
+  columns = ["id"]
+}
+`
+	var spec hclSpec
+	if err := LoadHCLFromString(content, &spec); err != nil {
+		t.Fatalf("LoadHCLFromString() error = %v", err)
+	}
+
+	if spec.Name != "users" || spec.Port != 8080 || spec.Ratio != 0.5 || !spec.Debug {
+		t.Errorf("Unexpected attributes: %+v", spec)
+	}
+	if strings.Join(spec.Tags, ",") != "a,b" || spec.Labels["app.kubernetes.io/name"] != "users" || spec.Labels["team"] != "core" {
+		t.Errorf("Unexpected collections: tags=%v labels=%v", spec.Tags, spec.Labels)
+	}
+	if spec.Banner != "Hello, ${name}\n  indented\n" {
+		t.Errorf("Banner = %q", spec.Banner)
+	}
+	if spec.Database.Driver != "postgres" {
+		t.Errorf("Database = %+v, want a single block decoded into a struct", spec.Database)
+	}
+	if len(spec.Endpoints) != 2 || spec.Endpoints[1].Method != "GET" {
+		t.Errorf("Endpoints = %+v, want repeated blocks decoded into a slice", spec.Endpoints)
+	}
+	if spec.Tables["users"].Comment != "Registered users" || len(spec.Tables["orders"].Columns) != 1 {
+		t.Errorf("Tables = %+v, want labeled blocks keyed by label", spec.Tables)
+	}
+}
+
+func TestLoadHCL_File(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"base.hcl": "name = \"api\"\ndatabase {\n  driver = \"mysql\"\n}\n",
+		"prod.hcl": "database {\n  dsn = \"prod\"\n}\n",
+	})
+
+	var spec hclSpec
+	if err := LoadHCL(filepath.Join(dir, "base.hcl"), &spec); err != nil || spec.Database.Driver != "mysql" {
+		t.Fatalf("LoadHCL() = %+v, %v", spec.Database, err)
+	}
+
+	spec = hclSpec{}
+	err := LoadYAMLLayers([]string{filepath.Join(dir, "base.hcl"), filepath.Join(dir, "prod.hcl")}, &spec)
+	if err != nil {
+		t.Fatalf("LoadYAMLLayers() error = %v", err)
+	}
+	if spec.Name != "api" || spec.Database.DSN != "prod" || spec.Database.Driver != "mysql" {
+		t.Errorf("layered = %+v", spec)
+	}
+}
+
+func TestLoadHCL_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"reference", "name = var.name", `line 1, column 8: unsupported expression "var"`},
+		{"interpolation", `name = "${var.name}"`, "template sequence ${...} is not supported"},
+		{"duplicate attribute", "a = 1\na = 2", `line 2, column 1: duplicate attribute "a"`},
+		{"duplicate block", "table \"a\" {}\ntable \"a\" {}", `duplicate block table "a"`},
+		{"repeated single block", "database {}\ndatabase {}", `line 2, column 1: block "database" is defined more than once`},
+		{"unterminated block", "database {\n  a = 1\n", "unterminated block"},
+		{"missing newline", "a = 1 b = 2", "expected a newline"},
+		{"unterminated string", "a = \"abc\n", "unterminated string"},
+		{"bad number", "a = 1.", `invalid number "1."`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var spec hclSpec
+			err := LoadHCLFromString(tt.content, &spec)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadCUE(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of cue")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	defer func(cmd string) { cueCommand = cmd }(cueCommand)
+
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "cue")
+	script := "#!/bin/sh\n" +
+		"[ \"$1 $2 $3\" = \"export --out json\" ] || exit 2\n" +
+		"case \"$4\" in *bad.cue) echo 'port: conflicting values 80 and \"x\"' >&2; exit 1;; esac\n" +
+		"echo '{\"name\": \"from-cue\", \"port\": 9090}'\n"
+	if err := os.WriteFile(fake, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"spec.cue", "bad.cue"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package spec\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cueCommand = fake
+
+	var spec hclSpec
+	if err := LoadCUE(filepath.Join(dir, "spec.cue"), &spec); err != nil {
+		t.Fatalf("LoadCUE() error = %v", err)
+	}
+	if spec.Name != "from-cue" || spec.Port != 9090 {
+		t.Errorf("spec = %+v", spec)
+	}
+	if err := LoadCUE(dir, &spec); err != nil {
+		t.Errorf("LoadCUE(dir) error = %v", err)
+	}
+
+	err := LoadCUE(filepath.Join(dir, "bad.cue"), &spec)
+	if err == nil || !strings.Contains(err.Error(), "failed to evaluate CUE configuration: port: conflicting values") {
+		t.Errorf("Expected evaluation error, got %v", err)
+	}
+
+	cueCommand = filepath.Join(dir, "missing-cue")
+	if err := LoadCUE(filepath.Join(dir, "spec.cue"), &spec); err == nil || !strings.Contains(err.Error(), "cuelang.org") {
+		t.Errorf("Expected missing command error, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeTag marks a scalar whose value is the path of a file to include in
+// its place.
+const includeTag = "!include"
+
+// ErrIncludeCycle is returned when a file includes itself, directly or
+// through other files.
+var ErrIncludeCycle = errors.New("include cycle")
+
+// resolveIncludes replaces every node tagged !include in node with the
+// document of the file it names. Relative paths are resolved against dir,
+// and includes inside an included file against that file's directory.
+// chain holds the absolute paths of the files being included, outermost
+// first, to detect cycles.
+func (o *loadOptions) resolveIncludes(node *yaml.Node, dir string, chain []string) error {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode, yaml.MappingNode:
+		for i, child := range node.Content {
+			isKey := node.Kind == yaml.MappingNode && i%2 == 0
+			if !isKey && child.Kind == yaml.ScalarNode && child.Tag == includeTag {
+				included, err := o.includeFile(child, dir, chain)
+				if err != nil {
+					return err
+				}
+				node.Content[i] = included
+				continue
+			}
+			if err := o.resolveIncludes(child, dir, chain); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// includeFile loads the file named by the !include node ref and returns its
+// root node, with its own includes resolved.
+func (o *loadOptions) includeFile(ref *yaml.Node, dir string, chain []string) (*yaml.Node, error) {
+	if ref.Value == "" {
+		return nil, fmt.Errorf("line %d, column %d: %s needs a file path", ref.Line, ref.Column, includeTag)
+	}
+
+	path := ref.Value
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("line %d, column %d: failed to resolve include %q: %w", ref.Line, ref.Column, ref.Value, err)
+	}
+
+	for i, p := range chain {
+		if p == absPath {
+			cycle := make([]string, 0, len(chain)-i+1)
+			for _, c := range append(chain[i:], absPath) {
+				cycle = append(cycle, filepath.Base(c))
+			}
+			return nil, fmt.Errorf("line %d, column %d: %w: %s", ref.Line, ref.Column, ErrIncludeCycle, strings.Join(cycle, " -> "))
+		}
+	}
+
+	o.recordSource(absPath)
+	data, err := readConfigFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("line %d, column %d: %w", ref.Line, ref.Column, err)
+	}
+	f := formatForPath(absPath)
+	doc, err := o.parse(data, f)
+	if err != nil {
+		return nil, fmt.Errorf("line %d, column %d: failed to parse included %s file %s: %w", ref.Line, ref.Column, f.name, ref.Value, err)
+	}
+	if err := o.resolveIncludes(doc, filepath.Dir(absPath), append(chain[:len(chain):len(chain)], absPath)); err != nil {
+		return nil, fmt.Errorf("in %s: %w", ref.Value, err)
+	}
+
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: ref.Line, Column: ref.Column}, nil
+	}
+	return doc.Content[0], nil
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadYAMLIncludes(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"schema.yaml": `
+name: shop
+tables:
+  - !include tables/users.yaml
+  - !include tables/orders.json
+limits: !include limits.toml
+`,
+		"limits.toml": "max_rows = 100\n",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "tables"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"tables/users.yaml":  "name: users\ncolumns: !include ../columns/users.yaml\n",
+		"tables/orders.json": `{"name": "orders", "columns": ["id", "total"]}`,
+	}
+	if err := os.Mkdir(filepath.Join(dir, "columns"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files["columns/users.yaml"] = "[id, email]\n"
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var spec layeredSpec
+	if err := LoadYAML(filepath.Join(dir, "schema.yaml"), &spec); err != nil {
+		t.Fatalf("LoadYAML() error = %v", err)
+	}
+
+	if spec.Name != "shop" || len(spec.Tables) != 2 {
+		t.Fatalf("spec = %+v", spec)
+	}
+	if spec.Tables[0].Name != "users" || strings.Join(spec.Tables[0].Columns, ",") != "id,email" {
+		t.Errorf("Tables[0] = %+v, want users with columns resolved relative to the including file", spec.Tables[0])
+	}
+	if spec.Tables[1].Name != "orders" || strings.Join(spec.Tables[1].Columns, ",") != "id,total" {
+		t.Errorf("Tables[1] = %+v", spec.Tables[1])
+	}
+	if spec.Limits["max_rows"] != 100 {
+		t.Errorf("Limits = %v", spec.Limits)
+	}
+}
+
+func TestLoadYAMLIncludeCycle(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"a.yaml": "name: a\ntables:\n  - !include b.yaml\n",
+		"b.yaml": "name: b\ncolumns: !include c.yaml\n",
+		"c.yaml": "- !include a.yaml\n",
+	})
+
+	var spec layeredSpec
+	err := LoadYAML(filepath.Join(dir, "a.yaml"), &spec)
+	if !errors.Is(err, ErrIncludeCycle) {
+		t.Fatalf("LoadYAML() error = %v, want ErrIncludeCycle", err)
+	}
+	if !strings.Contains(err.Error(), "a.yaml -> b.yaml -> c.yaml -> a.yaml") {
+		t.Errorf("error %q does not show the include chain", err)
+	}
+
+	dir = writeLayers(t, map[string]string{"self.yaml": "name: !include self.yaml\n"})
+	if err := LoadYAML(filepath.Join(dir, "self.yaml"), &spec); !errors.Is(err, ErrIncludeCycle) {
+		t.Errorf("self include error = %v, want ErrIncludeCycle", err)
+	}
+}
+
+func TestLoadYAMLIncludeErrors(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"missing.yaml": "name: x\ntables: !include nope.yaml\n",
+		"bad.yaml":     "name: x\ntables: !include broken.yaml\n",
+		"broken.yaml":  "- name: [unclosed\n",
+		"empty.yaml":   "name: x\ntables: !include \"\"\n",
+	})
+
+	tests := map[string]string{
+		"missing.yaml": "line 2, column 9: configuration file does not exist",
+		"bad.yaml":     "line 2, column 9: failed to parse included YAML file broken.yaml",
+		"empty.yaml":   "!include needs a file path",
+	}
+	for file, want := range tests {
+		var spec layeredSpec
+		err := LoadYAML(filepath.Join(dir, file), &spec)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error = %v, want %q", file, err, want)
+		}
+	}
+}
+
+func TestLoadYAMLLayersIncludes(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"base.yaml":   "name: api\nlimits: !include limits.yaml\n",
+		"limits.yaml": "rps: 10\nburst: 20\n",
+		"prod.yaml":   "limits:\n  rps: 100\n",
+	})
+
+	var spec layeredSpec
+	err := LoadYAMLLayers([]string{filepath.Join(dir, "base.yaml"), filepath.Join(dir, "prod.yaml")}, &spec)
+	if err != nil {
+		t.Fatalf("LoadYAMLLayers() error = %v", err)
+	}
+	if spec.Limits["rps"] != 100 || spec.Limits["burst"] != 20 {
+		t.Errorf("Limits = %v, want included limits merged with the overlay", spec.Limits)
+	}
+}
@@ -0,0 +1,322 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	goformat "go/format"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"github.com/cpcf/weft/render"
+	"gopkg.in/yaml.v3"
+)
+
+// InferOption configures InferStruct.
+type InferOption func(*inferOptions)
+
+type inferOptions struct {
+	typeName string
+	pkg      string
+}
+
+// WithTypeName sets the name of the root type; the default is "Config".
+func WithTypeName(name string) InferOption {
+	return func(o *inferOptions) {
+		o.typeName = name
+	}
+}
+
+// WithPackage emits a package clause, and any imports the types need, so the
+// output is a complete Go file.
+func WithPackage(name string) InferOption {
+	return func(o *inferOptions) {
+		o.pkg = name
+	}
+}
+
+// InferStruct returns gofmt-formatted Go type definitions, with yaml tags,
+// that an example YAML or JSON configuration decodes into. Nested mappings
+// become named struct types: a key "database" becomes a Database type, and
+// the items of a list "tables" become a Table type with the fields of every
+// item. Mappings whose keys are not identifiers, such as paths or hosts,
+// become maps. Values that differ in type across the example become any.
+//
+// The output is a starting point for a spec package: review the names, add
+// validation and replace any with concrete types where the example was not
+// specific enough.
+func InferStruct(data []byte, opts ...InferOption) (string, error) {
+	o := &inferOptions{typeName: "Config"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if !token.IsIdentifier(o.typeName) {
+		return "", fmt.Errorf("invalid type name %q", o.typeName)
+	}
+
+	doc, err := parseYAML(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse YAML configuration: %w", err)
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return "", fmt.Errorf("configuration is empty")
+	}
+
+	inf := &inferrer{objects: make(map[string]*objectShape), rootName: o.typeName}
+	root := inf.shapeOf(doc.Content[0], o.typeName, true)
+
+	var b bytes.Buffer
+	if o.pkg != "" {
+		fmt.Fprintf(&b, "package %s\n\n", o.pkg)
+		if inf.usesTime {
+			b.WriteString("import \"time\"\n\n")
+		}
+	}
+	if root.kind != shapeObject || root.object.name != o.typeName {
+		fmt.Fprintf(&b, "type %s %s\n\n", o.typeName, root.goType())
+	}
+	for _, obj := range inf.order {
+		fmt.Fprintf(&b, "type %s struct {\n", obj.name)
+		for _, f := range obj.fields {
+			fmt.Fprintf(&b, "\t%s %s `yaml:%q`\n", f.name, f.shape.goType(), f.key)
+		}
+		b.WriteString("}\n\n")
+	}
+
+	out, err := goformat.Source(b.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to format inferred types: %w", err)
+	}
+	return string(out), nil
+}
+
+type shapeKind int
+
+const (
+	shapeUnknown shapeKind = iota // null or empty; unified with anything
+	shapeScalar
+	shapeList
+	shapeMap
+	shapeObject
+	shapeAny
+)
+
+type shape struct {
+	kind   shapeKind
+	scalar string // Go type of a scalar
+	elem   *shape // element of a list or value of a map
+	object *objectShape
+}
+
+type objectShape struct {
+	name   string
+	fields []*fieldShape
+}
+
+type fieldShape struct {
+	key   string
+	name  string
+	shape *shape
+}
+
+func (s *shape) goType() string {
+	switch s.kind {
+	case shapeScalar:
+		return s.scalar
+	case shapeList:
+		return "[]" + s.elem.goType()
+	case shapeMap:
+		return "map[string]" + s.elem.goType()
+	case shapeObject:
+		return s.object.name
+	default:
+		return "any"
+	}
+}
+
+type inferrer struct {
+	rootName string
+	objects  map[string]*objectShape
+	order    []*objectShape
+	usesTime bool
+}
+
+// shapeOf infers the shape of node. name is the type name to use if node is
+// an object.
+func (inf *inferrer) shapeOf(node *yaml.Node, name string, root bool) *shape {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!str", "!!binary":
+			return &shape{kind: shapeScalar, scalar: "string"}
+		case "!!int":
+			return &shape{kind: shapeScalar, scalar: "int"}
+		case "!!float":
+			return &shape{kind: shapeScalar, scalar: "float64"}
+		case "!!bool":
+			return &shape{kind: shapeScalar, scalar: "bool"}
+		case "!!timestamp":
+			inf.usesTime = true
+			return &shape{kind: shapeScalar, scalar: "time.Time"}
+		case "!!null":
+			return &shape{kind: shapeUnknown}
+		}
+		return &shape{kind: shapeAny}
+
+	case yaml.SequenceNode:
+		elem := &shape{kind: shapeUnknown}
+		for _, item := range node.Content {
+			elem = inf.unify(elem, inf.shapeOf(item, singularName(name), false))
+		}
+		return &shape{kind: shapeList, elem: elem}
+
+	case yaml.MappingNode:
+		if !root && !identifierKeys(node) {
+			value := &shape{kind: shapeUnknown}
+			for i := 1; i < len(node.Content); i += 2 {
+				value = inf.unify(value, inf.shapeOf(node.Content[i], singularName(name), false))
+			}
+			return &shape{kind: shapeMap, elem: value}
+		}
+
+		if !root && name == inf.rootName {
+			name += "Config"
+		}
+		obj := inf.object(name)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if key == "<<" {
+				continue
+			}
+			value := inf.shapeOf(node.Content[i+1], goName(key), false)
+			inf.addField(obj, key, value)
+		}
+		return &shape{kind: shapeObject, object: obj}
+	}
+	return &shape{kind: shapeAny}
+}
+
+// object returns the object type called name, creating it on first use.
+// Objects with the same name share one type with the union of their fields.
+func (inf *inferrer) object(name string) *objectShape {
+	if obj, ok := inf.objects[name]; ok {
+		return obj
+	}
+	obj := &objectShape{name: name}
+	inf.objects[name] = obj
+	inf.order = append(inf.order, obj)
+	return obj
+}
+
+func (inf *inferrer) addField(obj *objectShape, key string, value *shape) {
+	for _, f := range obj.fields {
+		if f.key == key {
+			f.shape = inf.unify(f.shape, value)
+			return
+		}
+	}
+
+	name := goName(key)
+	for n := 2; obj.hasField(name); n++ {
+		name = fmt.Sprintf("%s%d", goName(key), n)
+	}
+	obj.fields = append(obj.fields, &fieldShape{key: key, name: name, shape: value})
+}
+
+func (obj *objectShape) hasField(name string) bool {
+	for _, f := range obj.fields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unify returns a shape that values of both a and b decode into.
+func (inf *inferrer) unify(a, b *shape) *shape {
+	switch {
+	case a.kind == shapeUnknown:
+		return b
+	case b.kind == shapeUnknown:
+		return a
+	case a.kind != b.kind:
+		return &shape{kind: shapeAny}
+	}
+
+	switch a.kind {
+	case shapeScalar:
+		if a.scalar == b.scalar {
+			return a
+		}
+		if isNumeric(a.scalar) && isNumeric(b.scalar) {
+			return &shape{kind: shapeScalar, scalar: "float64"}
+		}
+		return &shape{kind: shapeAny}
+	case shapeList, shapeMap:
+		return &shape{kind: a.kind, elem: inf.unify(a.elem, b.elem)}
+	case shapeObject:
+		if a.object != b.object {
+			for _, f := range b.object.fields {
+				inf.addField(a.object, f.key, f.shape)
+			}
+		}
+		return a
+	}
+	return a
+}
+
+func isNumeric(goType string) bool {
+	return goType == "int" || goType == "float64"
+}
+
+// identifierKeys reports whether every key of a mapping could be a field
+// name, as opposed to data such as paths, hosts or versions.
+func identifierKeys(node *yaml.Node) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if key == "<<" {
+			continue
+		}
+		if key == "" || !unicode.IsLetter(rune(key[0])) && key[0] != '_' {
+			return false
+		}
+		for _, r := range key {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// goName converts a configuration key to an exported Go identifier, such as
+// "base_url" to "BaseURL".
+func goName(key string) string {
+	if name := render.GoName(key); name != "" {
+		return name
+	}
+	return "Field"
+}
+
+var singularize = render.DefaultFuncMap()["singular"].(func(string) string)
+
+// singularName returns the type name for the items of a list or map named
+// name, by singularizing its last word: "Tables" becomes "Table".
+func singularName(name string) string {
+	i := len(name) - 1
+	for i > 0 && !unicode.IsUpper(rune(name[i])) {
+		i--
+	}
+	// Keep trailing initialisms such as "IDs" intact.
+	last := name[i:]
+	if strings.HasSuffix(last, "s") && render.IsGoInitialism(strings.TrimSuffix(last, "s")) {
+		return name[:len(name)-1]
+	}
+	singular := singularize(last)
+	if singular == "" || strings.EqualFold(singular, last) && !strings.HasSuffix(last, "s") {
+		// Not a plural; name the items after the collection.
+		return name + "Item"
+	}
+	return name[:i] + strings.ToUpper(singular[:1]) + singular[1:]
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInferStruct(t *testing.T) {
+	src := `
+name: shop
+base_url: https://api.example.com
+port: 8080
+ratio: 1
+debug: true
+released: 2024-05-01T10:00:00Z
+user_ids: [1, 2]
+database:
+  driver: postgres
+  max_conns: 10
+tables:
+  - name: users
+    columns:
+      - {name: id, primary_key: true}
+  - name: orders
+    comment: Customer orders
+    columns:
+      - {name: total, precision: 2}
+routes:
+  /users: {method: GET}
+  /orders/{id}: {method: POST}
+notes: ~
+values: [1, 2.5]
+mixed: [1, a]
+`
+	got, err := InferStruct([]byte(src), WithTypeName("Spec"), WithPackage("spec"))
+	if err != nil {
+		t.Fatalf("InferStruct() error = %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "spec.go", got, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, got)
+	}
+
+	for _, want := range []string{
+		"package spec",
+		`import "time"`,
+		"type Spec struct {",
+		"BaseURL  string           `yaml:\"base_url\"`",
+		"Ratio    int ",
+		"Released time.Time ",
+		"UserIDs  []int ",
+		"Database Database ",
+		"Tables   []Table ",
+		"Routes   map[string]Route ",
+		"Notes    any ",
+		"Values   []float64 ",
+		"Mixed    []any ",
+		"type Database struct {",
+		"MaxConns int    `yaml:\"max_conns\"`",
+		"type Table struct {",
+		"Comment string   `yaml:\"comment\"`",
+		"Columns []Column `yaml:\"columns\"`",
+		"type Column struct {",
+		"PrimaryKey bool   `yaml:\"primary_key\"`",
+		"Precision  int    `yaml:\"precision\"`",
+		"type Route struct {",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestInferStruct_RoundTrip(t *testing.T) {
+	data, err := os.ReadFile("../examples/yaml-tutorial-guide/configs/ecommerce-schema.yaml")
+	if err != nil {
+		t.Skipf("example config not available: %v", err)
+	}
+	got, err := InferStruct(data, WithTypeName("DatabaseSchema"))
+	if err != nil {
+		t.Fatalf("InferStruct() error = %v", err)
+	}
+	for _, want := range []string{"type DatabaseSchema struct {", "Tables      []Table", "type Field struct {"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestInferStruct_Errors(t *testing.T) {
+	if _, err := InferStruct([]byte("")); err == nil {
+		t.Error("expected error for empty configuration")
+	}
+	if _, err := InferStruct([]byte("a: [")); err == nil || !strings.Contains(err.Error(), "failed to parse YAML") {
+		t.Errorf("expected parse error, got %v", err)
+	}
+	if _, err := InferStruct([]byte("a: 1"), WithTypeName("my-spec")); err == nil {
+		t.Error("expected error for an invalid type name")
+	}
+
+	got, err := InferStruct([]byte("- name: a\n- name: b\n"))
+	if err != nil || !strings.Contains(got, "type Config []ConfigItem") {
+		t.Errorf("list root = %q, %v", got, err)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"name":        "Name",
+		"base_url":    "BaseURL",
+		"primaryKey":  "PrimaryKey",
+		"HTTPServer":  "HTTPServer",
+		"api-version": "APIVersion",
+		"user_ids":    "UserIDs",
+		"2fa":         "X2fa",
+		"__":          "Field",
+	}
+	for in, want := range tests {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
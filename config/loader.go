@@ -2,9 +2,15 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,56 +21,304 @@ type Validator interface {
 	Validate() error
 }
 
+// format describes a configuration file format. Every format is parsed into
+// a yaml.Node so that struct `yaml` tags, line information and the rest of
+// the loading pipeline are shared.
+type format struct {
+	name  string
+	parse func(data []byte) (*yaml.Node, error)
+}
+
+var (
+	yamlFormat = format{name: "YAML", parse: parseYAML}
+	jsonFormat = format{name: "JSON", parse: parseJSON}
+	tomlFormat = format{name: "TOML", parse: parseTOML}
+	hclFormat  = format{name: "HCL", parse: parseHCL}
+)
+
 // LoadYAML loads any YAML configuration into the provided target struct.
 // The target must be a pointer to the struct you want to unmarshal into.
 // If the target implements the Validator interface, validation will be called.
-func LoadYAML[T any](path string, target *T) error {
+// A path of "-" reads the configuration from standard input.
+func LoadYAML[T any](path string, target *T, opts ...Option) error {
+	return loadFile(path, target, yamlFormat, opts)
+}
+
+// LoadYAMLFromString loads YAML configuration from a string instead of a file.
+// Useful for testing or when configuration comes from other sources.
+func LoadYAMLFromString[T any](yamlContent string, target *T, opts ...Option) error {
+	return loadBytes([]byte(yamlContent), target, yamlFormat, opts)
+}
+
+// LoadYAMLReader loads YAML configuration read from r. Includes are resolved
+// relative to the working directory.
+func LoadYAMLReader[T any](r io.Reader, target *T, opts ...Option) error {
+	return loadReader(r, target, yamlFormat, opts)
+}
+
+// LoadJSON loads a JSON configuration file into target, with the same
+// validation behaviour as LoadYAML. Fields are matched using their `yaml`
+// struct tags, so one struct can be loaded from any supported format.
+func LoadJSON[T any](path string, target *T, opts ...Option) error {
+	return loadFile(path, target, jsonFormat, opts)
+}
+
+// LoadJSONFromString loads JSON configuration from a string instead of a file.
+func LoadJSONFromString[T any](jsonContent string, target *T, opts ...Option) error {
+	return loadBytes([]byte(jsonContent), target, jsonFormat, opts)
+}
+
+// LoadJSONReader loads JSON configuration read from r.
+func LoadJSONReader[T any](r io.Reader, target *T, opts ...Option) error {
+	return loadReader(r, target, jsonFormat, opts)
+}
+
+// LoadTOML loads a TOML configuration file into target, with the same
+// validation behaviour as LoadYAML. Fields are matched using their `yaml`
+// struct tags, so one struct can be loaded from any supported format.
+func LoadTOML[T any](path string, target *T, opts ...Option) error {
+	return loadFile(path, target, tomlFormat, opts)
+}
+
+// LoadTOMLFromString loads TOML configuration from a string instead of a file.
+func LoadTOMLFromString[T any](tomlContent string, target *T, opts ...Option) error {
+	return loadBytes([]byte(tomlContent), target, tomlFormat, opts)
+}
+
+// LoadTOMLReader loads TOML configuration read from r.
+func LoadTOMLReader[T any](r io.Reader, target *T, opts ...Option) error {
+	return loadReader(r, target, tomlFormat, opts)
+}
+
+// LoadHCL loads an HCL configuration file into target, with the same
+// validation behaviour as LoadYAML. Only literal values are supported; see
+// parseHCL for how blocks map onto fields.
+func LoadHCL[T any](path string, target *T, opts ...Option) error {
+	return loadFile(path, target, hclFormat, opts)
+}
+
+// LoadHCLFromString loads HCL configuration from a string instead of a file.
+func LoadHCLFromString[T any](hclContent string, target *T, opts ...Option) error {
+	return loadBytes([]byte(hclContent), target, hclFormat, opts)
+}
+
+// LoadHCLReader loads HCL configuration read from r.
+func LoadHCLReader[T any](r io.Reader, target *T, opts ...Option) error {
+	return loadReader(r, target, hclFormat, opts)
+}
+
+// StdinPath is the path that makes file loaders read standard input, so
+// generators can take their configuration from a pipeline.
+const StdinPath = "-"
+
+// stdin is the reader used for StdinPath.
+var stdin io.Reader = os.Stdin
+
+// loadReader reads all of r and loads it into target.
+func loadReader[T any](r io.Reader, target *T, f format, opts []Option) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s configuration: %w", f.name, err)
+	}
+	return loadBytes(data, target, f, opts)
+}
+
+// loadFile reads the file at path and loads it into target. Includes are
+// resolved relative to the file.
+func loadFile[T any](path string, target *T, f format, opts []Option) error {
+	o := newLoadOptions(opts)
+	node, err := o.parseConfigFile(path, f)
+	if err != nil {
+		return err
+	}
+	return loadNode(node, target, f.name, o)
+}
+
+// parseConfigFile reads the file at path and parses it in format f,
+// resolving includes relative to the file. StdinPath reads standard input.
+func (o *loadOptions) parseConfigFile(path string, f format) (*yaml.Node, error) {
+	if path == StdinPath {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s configuration from stdin: %w", f.name, err)
+		}
+		return o.parseConfig(data, f, "")
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path %q: %w", path, err)
+	}
+	o.recordSource(absPath)
+	data, err := readConfigFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	return o.parseConfig(data, f, absPath)
+}
+
+// parseConfig parses data in format f and resolves its includes. source is
+// the absolute path data was read from; includes in configuration that did
+// not come from a file are resolved relative to the working directory.
+func (o *loadOptions) parseConfig(data []byte, f format, source string) (*yaml.Node, error) {
+	node, err := o.parse(data, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s configuration: %w", f.name, err)
+	}
+
+	dir, chain := ".", []string(nil)
+	if source != "" {
+		dir, chain = filepath.Dir(source), []string{source}
+	}
+	if err := o.resolveIncludes(node, dir, chain); err != nil {
+		return nil, fmt.Errorf("failed to parse %s configuration: %w", f.name, err)
+	}
+	return node, nil
+}
+
+// readConfigFile reads the configuration file at path.
+func readConfigFile(path string) ([]byte, error) {
 	// Handle relative paths by making them absolute
 	absPath, err := filepath.Abs(path)
 	if err != nil {
-		return fmt.Errorf("failed to resolve path %q: %w", path, err)
+		return nil, fmt.Errorf("failed to resolve path %q: %w", path, err)
 	}
 
 	// Check if file exists
 	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		return fmt.Errorf("configuration file does not exist: %s", absPath)
+		return nil, fmt.Errorf("configuration file does not exist: %s", absPath)
 	}
 
 	// Read the file
 	data, err := os.ReadFile(absPath)
 	if err != nil {
-		return fmt.Errorf("failed to read configuration file %q: %w", absPath, err)
+		return nil, fmt.Errorf("failed to read configuration file %q: %w", absPath, err)
+	}
+	return data, nil
+}
+
+// loadBytes parses data in format f, applies the options, decodes it into
+// target and validates it.
+func loadBytes[T any](data []byte, target *T, f format, opts []Option) error {
+	o := newLoadOptions(opts)
+	node, err := o.parseConfig(data, f, "")
+	if err != nil {
+		return err
 	}
+	return loadNode(node, target, f.name, o)
+}
 
-	// Parse YAML
-	if err := yaml.Unmarshal(data, target); err != nil {
-		return fmt.Errorf("failed to parse YAML configuration: %w", err)
+// loadNode applies the options to a parsed document, fills in struct tag
+// defaults, decodes it into target, which must be a pointer, and validates it.
+func loadNode(node *yaml.Node, target any, name string, o *loadOptions) error {
+	if err := o.transform(node); err != nil {
+		return err
 	}
 
-	// Validate if the target implements Validator interface
-	if validator, ok := any(target).(Validator); ok {
-		if err := validator.Validate(); err != nil {
-			return fmt.Errorf("configuration validation failed: %w", err)
+	if err := adaptHCLBlocks(node, reflect.TypeOf(target).Elem()); err != nil {
+		return fmt.Errorf("invalid %s configuration: %w", name, err)
+	}
+
+	if o.strict {
+		if err := checkKnownFields(node, reflect.TypeOf(target).Elem()); err != nil {
+			return fmt.Errorf("invalid %s configuration: %w", name, err)
 		}
 	}
 
-	return nil
+	if err := applyDefaults(node, reflect.TypeOf(target).Elem()); err != nil {
+		return err
+	}
+
+	if o.expressions {
+		if err := evaluateExpressions(node); err != nil {
+			return fmt.Errorf("invalid %s configuration: %w", name, err)
+		}
+	}
+
+	if err := decodeNode(node, target); err != nil {
+		return fmt.Errorf("failed to parse %s configuration: %w", name, err)
+	}
+
+	return validate(target)
 }
 
-// LoadYAMLFromString loads YAML configuration from a string instead of a file.
-// Useful for testing or when configuration comes from other sources.
-func LoadYAMLFromString[T any](yamlContent string, target *T) error {
-	// Parse YAML
-	if err := yaml.Unmarshal([]byte(yamlContent), target); err != nil {
-		return fmt.Errorf("failed to parse YAML configuration: %w", err)
+// formatForPath picks a format from the file extension, defaulting to YAML.
+func formatForPath(path string) format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return jsonFormat
+	case ".toml":
+		return tomlFormat
+	case ".hcl":
+		return hclFormat
+	default:
+		return yamlFormat
+	}
+}
+
+// decodeNode decodes a parsed document into target. Empty documents leave
+// target unchanged.
+func decodeNode(node *yaml.Node, target any) error {
+	if node.Kind == 0 {
+		return nil
 	}
+	return node.Decode(target)
+}
 
-	// Validate if the target implements Validator interface
-	if validator, ok := any(target).(Validator); ok {
+// validate calls Validate if target implements the Validator interface.
+func validate(target any) error {
+	if validator, ok := target.(Validator); ok {
 		if err := validator.Validate(); err != nil {
 			return fmt.Errorf("configuration validation failed: %w", err)
 		}
 	}
-
 	return nil
 }
+
+func parseYAML(data []byte) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// parseJSON checks that data is valid JSON, reporting the line and column of
+// syntax errors, and then parses it as YAML, of which JSON is a subset.
+func parseJSON(data []byte) (*yaml.Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		var syntaxErr *json.SyntaxError
+		switch {
+		case errors.As(err, &syntaxErr):
+			line, col := offsetPosition(data, int(syntaxErr.Offset))
+			return nil, fmt.Errorf("line %d, column %d: %w", line, col, err)
+		case errors.Is(err, io.EOF):
+			return &yaml.Node{}, nil
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			line, col := offsetPosition(data, len(data))
+			return nil, fmt.Errorf("line %d, column %d: unexpected end of input", line, col)
+		}
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		line, col := offsetPosition(data, int(dec.InputOffset()))
+		return nil, fmt.Errorf("line %d, column %d: unexpected data after top-level value", line, col)
+	}
+
+	return parseYAML(data)
+}
+
+// offsetPosition converts a byte offset to a 1-based line and column.
+func offsetPosition(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := offset - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
@@ -2,9 +2,13 @@ package config
 
 import (
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestConfig is a simple configuration struct for testing
@@ -210,3 +214,254 @@ func TestLoadYAMLFromString_InvalidYAML(t *testing.T) {
 		t.Fatal("Expected error for invalid YAML string, got nil")
 	}
 }
+
+// ServiceConfig exercises nested structures across formats
+type ServiceConfig struct {
+	Name     string    `yaml:"name"`
+	Port     int       `yaml:"port"`
+	Ratio    float64   `yaml:"ratio"`
+	Debug    bool      `yaml:"debug"`
+	Tags     []string  `yaml:"tags"`
+	Released time.Time `yaml:"released"`
+	Database struct {
+		Driver string `yaml:"driver"`
+		DSN    string `yaml:"dsn"`
+	} `yaml:"database"`
+	Endpoints []struct {
+		Path   string `yaml:"path"`
+		Method string `yaml:"method"`
+	} `yaml:"endpoints"`
+}
+
+func TestLoadJSON(t *testing.T) {
+	jsonContent := `{
+  "name": "users",
+  "port": 8080,
+  "ratio": 0.5,
+  "debug": true,
+  "tags": ["a", "b"],
+  "released": "2024-05-01T10:00:00Z",
+  "database": {"driver": "postgres", "dsn": "postgres://localhost/users"},
+  "endpoints": [{"path": "/users", "method": "GET"}]
+}`
+
+	configPath := filepath.Join(t.TempDir(), "service.json")
+	if err := os.WriteFile(configPath, []byte(jsonContent), 0o644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	var config ServiceConfig
+	if err := LoadJSON(configPath, &config); err != nil {
+		t.Fatalf("Failed to load JSON config: %v", err)
+	}
+	if config.Name != "users" || config.Port != 8080 || config.Ratio != 0.5 || !config.Debug {
+		t.Errorf("Unexpected scalar fields: %+v", config)
+	}
+	if config.Database.Driver != "postgres" || len(config.Endpoints) != 1 || config.Endpoints[0].Method != "GET" {
+		t.Errorf("Unexpected nested fields: %+v", config)
+	}
+	if !config.Released.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected released time: %v", config.Released)
+	}
+}
+
+func TestLoadJSON_Errors(t *testing.T) {
+	var config ServiceConfig
+	err := LoadJSONFromString("{\n  \"name\": \"users\",\n  \"port\": 80,,\n}", &config)
+	if err == nil || !strings.Contains(err.Error(), "failed to parse JSON configuration: line 3, column 15") {
+		t.Errorf("Expected JSON syntax error with position, got %v", err)
+	}
+
+	var validated ValidatedTestConfig
+	err = LoadJSONFromString(`{"name": "x", "version": "1"}`, &validated)
+	if err == nil || err.Error() != "configuration validation failed: required field is required" {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	tomlContent := `# Service definition
+name = "users"
+port = 8_080
+ratio = 5e-1
+debug = true
+tags = [
+  "a", # first
+  'b',
+]
+released = 2024-05-01T10:00:00Z
+
+[database]
+driver = "postgres"
+dsn = """
+postgres://localhost/\
+users"""
+
+[[endpoints]]
+path = "/users"
+method = "GET"
+
+[[endpoints]]
+path = "/users/{id}"
+method = 'GET'
+`
+
+	configPath := filepath.Join(t.TempDir(), "service.toml")
+	if err := os.WriteFile(configPath, []byte(tomlContent), 0o644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	var config ServiceConfig
+	if err := LoadTOML(configPath, &config); err != nil {
+		t.Fatalf("Failed to load TOML config: %v", err)
+	}
+	if config.Name != "users" || config.Port != 8080 || config.Ratio != 0.5 || !config.Debug {
+		t.Errorf("Unexpected scalar fields: %+v", config)
+	}
+	if strings.Join(config.Tags, ",") != "a,b" {
+		t.Errorf("Unexpected tags: %v", config.Tags)
+	}
+	if config.Database.DSN != "postgres://localhost/users" {
+		t.Errorf("Unexpected DSN: %q", config.Database.DSN)
+	}
+	if len(config.Endpoints) != 2 || config.Endpoints[1].Path != "/users/{id}" {
+		t.Errorf("Unexpected endpoints: %+v", config.Endpoints)
+	}
+	if !config.Released.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected released time: %v", config.Released)
+	}
+}
+
+func TestLoadTOML_Values(t *testing.T) {
+	var values map[string]any
+	err := LoadTOMLFromString(`
+hex = 0xff
+oct = 0o17
+bin = 0b101
+neg = -3
+inf = -inf
+date = 1979-05-27
+time = 07:32:00
+escaped = "tab\there \u00e9"
+literal = 'C:\path'
+point = { x = 1, y.z = 2 }
+a.b.c = "dotted"
+"quoted key" = 1
+`, &values)
+	if err != nil {
+		t.Fatalf("Failed to load TOML: %v", err)
+	}
+
+	checks := map[string]any{
+		"hex":        0xff,
+		"oct":        0o17,
+		"bin":        5,
+		"neg":        -3,
+		"time":       "07:32:00",
+		"escaped":    "tab\there é",
+		"literal":    `C:\path`,
+		"quoted key": 1,
+	}
+	for key, want := range checks {
+		if values[key] != want {
+			t.Errorf("%s = %#v, want %#v", key, values[key], want)
+		}
+	}
+	if f, ok := values["inf"].(float64); !ok || !math.IsInf(f, -1) {
+		t.Errorf("inf = %#v, want -Inf", values["inf"])
+	}
+	if d, ok := values["date"].(time.Time); !ok || d.Year() != 1979 {
+		t.Errorf("date = %#v, want 1979-05-27", values["date"])
+	}
+	point := values["point"].(map[string]any)
+	if point["x"] != 1 || point["y"].(map[string]any)["z"] != 2 {
+		t.Errorf("Unexpected inline table: %#v", point)
+	}
+	if values["a"].(map[string]any)["b"].(map[string]any)["c"] != "dotted" {
+		t.Errorf("Unexpected dotted key table: %#v", values["a"])
+	}
+}
+
+func TestLoadTOML_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"duplicate key", "a = 1\na = 2", "line 2, column 1: duplicate key \"a\""},
+		{"table redefined", "[a]\nx = 1\n[a]\ny = 2", "table \"a\" is already defined"},
+		{"inline table extended", "a = { x = 1 }\n[a]\ny = 2", "table \"a\" is already defined"},
+		{"invalid value", "a = 01", "line 1, column 5: invalid value \"01\""},
+		{"unterminated string", "a = \"abc\nb = 1", "unterminated string"},
+		{"missing equals", "a 1", "expected '=' after key \"a\""},
+		{"trailing garbage", "a = 1 2", "expected end of line"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var values map[string]any
+			err := LoadTOMLFromString(tt.content, &values)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadReaders(t *testing.T) {
+	var yamlConfig TestConfig
+	if err := LoadYAMLReader(strings.NewReader("name: from-reader\nversion: 1.0.0\n"), &yamlConfig); err != nil {
+		t.Fatalf("LoadYAMLReader() error = %v", err)
+	}
+	if yamlConfig.Name != "from-reader" || yamlConfig.Version != "1.0.0" {
+		t.Errorf("Unexpected YAML config: %+v", yamlConfig)
+	}
+
+	var jsonConfig TestConfig
+	if err := LoadJSONReader(strings.NewReader(`{"name": "json"}`), &jsonConfig); err != nil || jsonConfig.Name != "json" {
+		t.Errorf("LoadJSONReader() = %+v, %v", jsonConfig, err)
+	}
+
+	var tomlConfig TestConfig
+	if err := LoadTOMLReader(strings.NewReader(`name = "toml"`), &tomlConfig); err != nil || tomlConfig.Name != "toml" {
+		t.Errorf("LoadTOMLReader() = %+v, %v", tomlConfig, err)
+	}
+
+	var validated ValidatedTestConfig
+	err := LoadYAMLReader(strings.NewReader("name: x\n"), &validated)
+	if err == nil || !strings.Contains(err.Error(), "configuration validation failed") {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+}
+
+func TestLoadStdin(t *testing.T) {
+	defer func(r io.Reader) { stdin = r }(stdin)
+
+	stdin = strings.NewReader("name: piped\noptions:\n  debug: \"true\"\n")
+	var config TestConfig
+	if err := LoadYAML(StdinPath, &config); err != nil {
+		t.Fatalf("LoadYAML(-) error = %v", err)
+	}
+	if config.Name != "piped" || config.Options["debug"] != "true" {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+
+	stdin = strings.NewReader(`name = "piped-toml"`)
+	config = TestConfig{}
+	if err := LoadTOML(StdinPath, &config); err != nil || config.Name != "piped-toml" {
+		t.Errorf("LoadTOML(-) = %+v, %v", config, err)
+	}
+
+	overlay := filepath.Join(t.TempDir(), "overlay.yaml")
+	if err := os.WriteFile(overlay, []byte("version: 2.0.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdin = strings.NewReader("name: base\nversion: 1.0.0\n")
+	config = TestConfig{}
+	if err := LoadYAMLLayers([]string{StdinPath, overlay}, &config); err != nil {
+		t.Fatalf("LoadYAMLLayers() error = %v", err)
+	}
+	if config.Name != "base" || config.Version != "2.0.0" {
+		t.Errorf("Unexpected layered config: %+v", config)
+	}
+}
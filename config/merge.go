@@ -0,0 +1,223 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ListMerge is a strategy for combining a list in an overlay with the same
+// list in the layers below it.
+type ListMerge struct {
+	mode listMergeMode
+	key  string
+}
+
+type listMergeMode int
+
+const (
+	listReplace listMergeMode = iota
+	listAppend
+	listByKey
+)
+
+var (
+	// ListReplace replaces the lower list with the overlay's list (default).
+	ListReplace = ListMerge{mode: listReplace}
+	// ListAppend appends the overlay's items to the lower list.
+	ListAppend = ListMerge{mode: listAppend}
+)
+
+// ListMergeByKey merges lists of mappings by the value of key: items with a
+// matching key are deep-merged, others are appended.
+func ListMergeByKey(key string) ListMerge {
+	return ListMerge{mode: listByKey, key: key}
+}
+
+func (m ListMerge) String() string {
+	switch m.mode {
+	case listAppend:
+		return "append"
+	case listByKey:
+		return "merge by " + m.key
+	default:
+		return "replace"
+	}
+}
+
+// WithListMerge sets the strategy for every list when merging layers.
+func WithListMerge(strategy ListMerge) Option {
+	return func(o *loadOptions) {
+		o.listMerge = strategy
+	}
+}
+
+// WithListMergeFor sets the strategy for the list at path, a dot-separated
+// sequence of mapping keys in which "*" matches any key. List items do not
+// add a segment, so "tables.columns" is the columns list of every table.
+func WithListMergeFor(path string, strategy ListMerge) Option {
+	return func(o *loadOptions) {
+		if o.listMergeAt == nil {
+			o.listMergeAt = make(map[string]ListMerge)
+		}
+		o.listMergeAt[path] = strategy
+	}
+}
+
+// LoadYAMLLayers loads a base configuration and deep-merges each following
+// file over it before decoding into target and validating the result.
+// Mappings are merged key by key and scalars in later files override earlier
+// ones; lists are replaced unless WithListMerge or WithListMergeFor choose
+// another strategy. Files ending in .json or .toml are parsed as such, and
+// each file's includes are resolved relative to it.
+func LoadYAMLLayers[T any](paths []string, target *T, opts ...Option) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("no configuration files given")
+	}
+
+	o := newLoadOptions(opts)
+	var merged *yaml.Node
+	for _, path := range paths {
+		node, err := o.parseConfigFile(path, formatForPath(path))
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		merged = o.mergeDocuments(merged, node)
+	}
+
+	return loadNode(merged, target, "YAML", o)
+}
+
+// mergeDocuments merges the src document over dst.
+func (o *loadOptions) mergeDocuments(dst, src *yaml.Node) *yaml.Node {
+	if dst == nil || dst.Kind == 0 {
+		return src
+	}
+	if src.Kind == 0 {
+		return dst
+	}
+	return &yaml.Node{
+		Kind:    yaml.DocumentNode,
+		Line:    dst.Line,
+		Column:  dst.Column,
+		Content: []*yaml.Node{o.mergeNodes(dst.Content[0], src.Content[0], nil)},
+	}
+}
+
+// mergeNodes returns src merged over dst without modifying either.
+func (o *loadOptions) mergeNodes(dst, src *yaml.Node, path []string) *yaml.Node {
+	d, s := resolveAlias(dst), resolveAlias(src)
+
+	switch {
+	case d.Kind == yaml.MappingNode && s.Kind == yaml.MappingNode:
+		merged := *d
+		merged.Content = append([]*yaml.Node(nil), d.Content...)
+		for i := 0; i+1 < len(s.Content); i += 2 {
+			key, value := s.Content[i], s.Content[i+1]
+			if j := mappingIndex(&merged, key.Value); j >= 0 {
+				merged.Content[j+1] = o.mergeNodes(merged.Content[j+1], value, append(path, key.Value))
+				continue
+			}
+			merged.Content = append(merged.Content, key, value)
+		}
+		return &merged
+
+	case d.Kind == yaml.SequenceNode && s.Kind == yaml.SequenceNode:
+		if d.Tag == hclBlocksTag && s.Tag == hclBlocksTag && len(d.Content) == 1 && len(s.Content) == 1 {
+			// A block defined once in each HCL layer merges like a mapping.
+			merged := *s
+			merged.Content = []*yaml.Node{o.mergeNodes(d.Content[0], s.Content[0], path)}
+			return &merged
+		}
+		strategy := o.listStrategy(path)
+		switch strategy.mode {
+		case listAppend:
+			merged := *s
+			merged.Content = append(append([]*yaml.Node(nil), d.Content...), s.Content...)
+			return &merged
+		case listByKey:
+			merged := *s
+			merged.Content = append([]*yaml.Node(nil), d.Content...)
+			for _, item := range s.Content {
+				id, ok := itemKey(item, strategy.key)
+				if !ok {
+					merged.Content = append(merged.Content, item)
+					continue
+				}
+				matched := false
+				for j, existing := range merged.Content {
+					if existingID, ok := itemKey(existing, strategy.key); ok && existingID == id {
+						merged.Content[j] = o.mergeNodes(existing, item, path)
+						matched = true
+						break
+					}
+				}
+				if !matched {
+					merged.Content = append(merged.Content, item)
+				}
+			}
+			return &merged
+		}
+	}
+
+	return src
+}
+
+// listStrategy returns the list merge strategy for the list at path. When
+// several patterns match, the one with the fewest wildcards wins.
+func (o *loadOptions) listStrategy(path []string) ListMerge {
+	strategy, best, bestWild := o.listMerge, "", -1
+	for pattern, s := range o.listMergeAt {
+		if !matchKeyPath(strings.Split(pattern, "."), path) {
+			continue
+		}
+		wild := strings.Count(pattern, "*")
+		if bestWild < 0 || wild < bestWild || (wild == bestWild && pattern < best) {
+			strategy, best, bestWild = s, pattern, wild
+		}
+	}
+	return strategy
+}
+
+func matchKeyPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// itemKey returns the scalar value of key in a mapping item.
+func itemKey(item *yaml.Node, key string) (string, bool) {
+	item = resolveAlias(item)
+	if item.Kind != yaml.MappingNode {
+		return "", false
+	}
+	if j := mappingIndex(item, key); j >= 0 && item.Content[j+1].Kind == yaml.ScalarNode {
+		return item.Content[j+1].Value, true
+	}
+	return "", false
+}
+
+// mappingIndex returns the index of key in a mapping node's content, or -1.
+func mappingIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
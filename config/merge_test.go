@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type layeredSpec struct {
+	Name    string `yaml:"name"`
+	BaseURL string `yaml:"base_url"`
+	Debug   bool   `yaml:"debug"`
+	Tags    []string
+	Tables  []struct {
+		Name    string   `yaml:"name"`
+		Comment string   `yaml:"comment"`
+		Columns []string `yaml:"columns"`
+	} `yaml:"tables"`
+	Limits map[string]int `yaml:"limits"`
+}
+
+func writeLayers(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestLoadYAMLLayers(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"base.yaml": `
+name: api
+base_url: http://localhost:8080
+tags: [base]
+tables:
+  - name: users
+    comment: Registered users
+    columns: [id, email]
+  - name: orders
+    columns: [id]
+limits:
+  page: 50
+  burst: 10
+`,
+		"prod.yaml": `
+base_url: https://api.example.com
+tags: [prod]
+tables:
+  - name: users
+    columns: [created_at]
+  - name: audit
+    columns: [id]
+limits:
+  page: 100
+`,
+		"debug.json": `{"debug": true}`,
+	})
+	paths := []string{filepath.Join(dir, "base.yaml"), filepath.Join(dir, "prod.yaml"), filepath.Join(dir, "debug.json")}
+
+	var spec layeredSpec
+	err := LoadYAMLLayers(paths, &spec,
+		WithListMerge(ListAppend),
+		WithListMergeFor("tables", ListMergeByKey("name")),
+	)
+	if err != nil {
+		t.Fatalf("LoadYAMLLayers failed: %v", err)
+	}
+
+	if spec.Name != "api" || spec.BaseURL != "https://api.example.com" || !spec.Debug {
+		t.Errorf("Unexpected scalars: %+v", spec)
+	}
+	if spec.Limits["page"] != 100 || spec.Limits["burst"] != 10 {
+		t.Errorf("Expected maps to be deep-merged, got %v", spec.Limits)
+	}
+	if strings.Join(spec.Tags, ",") != "base,prod" {
+		t.Errorf("Expected appended tags, got %v", spec.Tags)
+	}
+	if len(spec.Tables) != 3 {
+		t.Fatalf("Expected 3 tables, got %+v", spec.Tables)
+	}
+	users := spec.Tables[0]
+	if users.Name != "users" || users.Comment != "Registered users" || strings.Join(users.Columns, ",") != "id,email,created_at" {
+		t.Errorf("Expected users table to be merged by name, got %+v", users)
+	}
+	if spec.Tables[2].Name != "audit" {
+		t.Errorf("Expected new table to be appended, got %+v", spec.Tables[2])
+	}
+
+	var replaced layeredSpec
+	if err := LoadYAMLLayers(paths[:2], &replaced); err != nil {
+		t.Fatalf("LoadYAMLLayers failed: %v", err)
+	}
+	if strings.Join(replaced.Tags, ",") != "prod" || len(replaced.Tables) != 2 || replaced.Tables[0].Comment != "" {
+		t.Errorf("Expected lists to be replaced by default, got %+v", replaced)
+	}
+}
+
+func TestLoadYAMLLayers_Errors(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"base.yaml": "name: api\n",
+		"bad.yaml":  "name: [unclosed\n",
+	})
+
+	var spec layeredSpec
+	if err := LoadYAMLLayers(nil, &spec); err == nil {
+		t.Error("Expected an error for no files")
+	}
+
+	err := LoadYAMLLayers([]string{filepath.Join(dir, "base.yaml"), filepath.Join(dir, "bad.yaml")}, &spec)
+	if err == nil || !strings.Contains(err.Error(), "bad.yaml") {
+		t.Errorf("Expected parse error naming the file, got %v", err)
+	}
+
+	err = LoadYAMLLayers([]string{filepath.Join(dir, "base.yaml"), filepath.Join(dir, "missing.yaml")}, &spec)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected missing file error, got %v", err)
+	}
+
+	var validated ValidatedTestConfig
+	err = LoadYAMLLayers([]string{filepath.Join(dir, "base.yaml")}, &validated)
+	if err == nil || !strings.Contains(err.Error(), "configuration validation failed") {
+		t.Errorf("Expected validation to run on the merged result, got %v", err)
+	}
+}
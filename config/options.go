@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Option configures how a configuration is loaded.
+type Option func(*loadOptions)
+
+type loadOptions struct {
+	expandEnv  bool
+	missingEnv MissingEnvPolicy
+	lookupEnv  func(name string) (string, bool)
+
+	listMerge   ListMerge
+	listMergeAt map[string]ListMerge
+
+	strict      bool
+	expressions bool
+
+	remote        remoteOptions
+	decrypter     Decrypter
+	secretHandler func(values ...string)
+
+	pollInterval time.Duration
+	// sources collects the files read while loading, when non-nil.
+	sources []string
+}
+
+func newLoadOptions(opts []Option) *loadOptions {
+	o := &loadOptions{lookupEnv: os.LookupEnv}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// transform applies the node rewrites enabled by the options to a parsed
+// document before it is decoded.
+func (o *loadOptions) transform(node *yaml.Node) error {
+	if o.expandEnv {
+		if err := expandEnvNodes(node, o.lookupEnv, o.missingEnv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithEnvExpansion expands ${VAR} and ${VAR:-default} references in
+// configuration values from the environment. missing controls what happens
+// when a variable without a default is unset.
+func WithEnvExpansion(missing MissingEnvPolicy) Option {
+	return func(o *loadOptions) {
+		o.expandEnv = true
+		o.missingEnv = missing
+	}
+}
+
+// WithEnvLookup replaces os.LookupEnv as the source of variables for
+// WithEnvExpansion, for example to expand from a map in tests.
+func WithEnvLookup(lookup func(name string) (string, bool)) Option {
+	return func(o *loadOptions) {
+		o.lookupEnv = lookup
+	}
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrChecksumMismatch is returned when fetched configuration does not match
+// the checksum pinned with WithChecksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// defaultHTTPClient fetches remote configuration unless WithHTTPClient
+// supplies another client.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// remoteOptions configures LoadYAMLURL.
+type remoteOptions struct {
+	client      *http.Client
+	headers     http.Header
+	checksum    string
+	cacheDir    string
+	cacheMaxAge time.Duration
+}
+
+// WithHeader adds an HTTP header to remote configuration requests, for
+// example an Authorization header for a private schema registry. Git
+// sources send it with git's http.extraHeader setting.
+func WithHeader(name, value string) Option {
+	return func(o *loadOptions) {
+		if o.remote.headers == nil {
+			o.remote.headers = make(http.Header)
+		}
+		o.remote.headers.Add(name, value)
+	}
+}
+
+// WithHTTPClient sets the client used to fetch remote configuration.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *loadOptions) {
+		o.remote.client = client
+	}
+}
+
+// WithChecksum pins the SHA-256 of remote configuration, given as hex with
+// an optional "sha256:" prefix. Loading fails with ErrChecksumMismatch if the
+// fetched document differs.
+func WithChecksum(sum string) Option {
+	return func(o *loadOptions) {
+		o.remote.checksum = strings.ToLower(strings.TrimPrefix(sum, "sha256:"))
+	}
+}
+
+// WithCache keeps fetched configuration in dir. A cached copy younger than
+// maxAge is used without a request, and an older one is used when the fetch
+// fails. With WithChecksum, a cached copy that matches the checksum is always
+// used.
+func WithCache(dir string, maxAge time.Duration) Option {
+	return func(o *loadOptions) {
+		o.remote.cacheDir = dir
+		o.remote.cacheMaxAge = maxAge
+	}
+}
+
+// LoadYAMLURL fetches configuration and loads it into target, with the same
+// validation behaviour as LoadYAML. rawURL is an HTTP or HTTPS URL, or a file
+// in a git repository:
+//
+//	https://schemas.example.com/billing/v3.yaml
+//	https://github.com/acme/specs.git//billing/api.yaml?ref=v1.4.0
+//	git+ssh://git@github.com/acme/specs//billing/api.yaml
+//
+// A "git+" prefix or a repository path ending in ".git" selects git, "//"
+// separates the repository from the file, and ref is the branch, tag or
+// commit to read, by default the repository's HEAD. Documents whose path
+// ends in .json or .toml are parsed as such. Remote documents cannot use
+// !include.
+func LoadYAMLURL[T any](rawURL string, target *T, opts ...Option) error {
+	var src remoteSource
+	if isGitSource(rawURL) {
+		git, err := parseGitSource(rawURL)
+		if err != nil {
+			return err
+		}
+		src = git
+	} else {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return fmt.Errorf("invalid configuration URL: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("unsupported configuration URL scheme %q", u.Scheme)
+		}
+		src = httpSource{u}
+	}
+
+	o := newLoadOptions(opts)
+	data, err := o.remote.fetch(src)
+	if err != nil {
+		return err
+	}
+
+	f := formatForPath(src.path())
+	node, err := o.parse(data, f)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s configuration %s: %w", f.name, src, err)
+	}
+	if ref := findInclude(node); ref != nil {
+		return fmt.Errorf("failed to parse %s configuration %s: line %d, column %d: %s is not supported in remote configuration",
+			f.name, src, ref.Line, ref.Column, includeTag)
+	}
+	return loadNode(node, target, f.name, o)
+}
+
+// remoteSource is a document LoadYAMLURL fetches. String returns its
+// location with any credentials redacted, for messages.
+type remoteSource interface {
+	fmt.Stringer
+	// location returns the unredacted location, which identifies the
+	// document in the cache.
+	location() string
+	// path returns the path of the document, whose extension selects its
+	// format.
+	path() string
+	download(r *remoteOptions) ([]byte, error)
+}
+
+// fetch returns the document at src, from the cache when allowed.
+func (r *remoteOptions) fetch(src remoteSource) ([]byte, error) {
+	if r.checksum != "" {
+		if sum, err := hex.DecodeString(r.checksum); err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("invalid checksum %q: want a hex SHA-256", r.checksum)
+		}
+	}
+
+	var cachePath string
+	var cached []byte
+	if r.cacheDir != "" {
+		cachePath = filepath.Join(r.cacheDir, r.cacheKey(src))
+		if info, err := os.Stat(cachePath); err == nil {
+			if data, err := os.ReadFile(cachePath); err == nil && r.verify(src, data) == nil {
+				if r.checksum != "" || time.Since(info.ModTime()) < r.cacheMaxAge {
+					return data, nil
+				}
+				cached = data
+			}
+		}
+	}
+
+	data, err := src.download(r)
+	if err != nil {
+		if cached != nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+	if err := r.verify(src, data); err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		if err := writeCache(cachePath, data); err != nil {
+			return nil, fmt.Errorf("failed to cache configuration %s: %w", src, err)
+		}
+	}
+	return data, nil
+}
+
+// cacheKey names the cache file of src. The request headers are part of the
+// key, so callers with different credentials never share a cached document.
+func (r *remoteOptions) cacheKey(src remoteSource) string {
+	h := sha256.New()
+	io.WriteString(h, src.location())
+	for _, name := range slices.Sorted(maps.Keys(r.headers)) {
+		for _, value := range r.headers[name] {
+			fmt.Fprintf(h, "\n%s: %s", name, value)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// httpSource is a document served over HTTP or HTTPS.
+type httpSource struct {
+	u *url.URL
+}
+
+func (s httpSource) String() string   { return s.u.Redacted() }
+func (s httpSource) location() string { return s.u.String() }
+func (s httpSource) path() string     { return s.u.Path }
+
+func (s httpSource) download(r *remoteOptions) ([]byte, error) {
+	u := s.u
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch configuration %s: %w", u.Redacted(), err)
+	}
+	for name, values := range r.headers {
+		req.Header[name] = values
+	}
+
+	client := r.client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch configuration %s: %w", u.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch configuration %s: %s", u.Redacted(), resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration %s: %w", u.Redacted(), err)
+	}
+	return data, nil
+}
+
+// verify checks data against the pinned checksum, if any.
+func (r *remoteOptions) verify(src remoteSource, data []byte) error {
+	if r.checksum == "" {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != r.checksum {
+		return fmt.Errorf("%w for %s: got sha256:%s, want sha256:%s", ErrChecksumMismatch, src, got, r.checksum)
+	}
+	return nil
+}
+
+// writeCache atomically replaces the cache file at path with data.
+func writeCache(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".weft-config-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// findInclude returns the first node tagged !include in node, or nil.
+func findInclude(node *yaml.Node) *yaml.Node {
+	if node.Tag == includeTag {
+		return node
+	}
+	for _, child := range node.Content {
+		if ref := findInclude(child); ref != nil {
+			return ref
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newConfigServer(t *testing.T, docs map[string]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(r.URL.Path, "/private/") && auth != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		doc, ok := docs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(doc))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoadYAMLURL(t *testing.T) {
+	server, _ := newConfigServer(t, map[string]string{
+		"/spec.yaml":         "name: remote\nversion: 1.0.0\n",
+		"/spec.json":         `{"name": "remote-json"}`,
+		"/private/spec.yaml": "name: private\n",
+	})
+
+	var config TestConfig
+	if err := LoadYAMLURL(server.URL+"/spec.yaml", &config); err != nil {
+		t.Fatalf("LoadYAMLURL() error = %v", err)
+	}
+	if config.Name != "remote" || config.Version != "1.0.0" {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+
+	config = TestConfig{}
+	if err := LoadYAMLURL(server.URL+"/spec.json", &config); err != nil || config.Name != "remote-json" {
+		t.Errorf("LoadYAMLURL(json) = %+v, %v", config, err)
+	}
+
+	err := LoadYAMLURL(server.URL+"/private/spec.yaml", &config)
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("Expected unauthorized error, got %v", err)
+	}
+	config = TestConfig{}
+	if err := LoadYAMLURL(server.URL+"/private/spec.yaml", &config, WithHeader("Authorization", "Bearer secret")); err != nil || config.Name != "private" {
+		t.Errorf("LoadYAMLURL(auth) = %+v, %v", config, err)
+	}
+
+	if err := LoadYAMLURL(server.URL+"/missing.yaml", &config); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+	if err := LoadYAMLURL("ftp://example.com/spec.yaml", &config); err == nil || !strings.Contains(err.Error(), "unsupported configuration URL scheme") {
+		t.Errorf("Expected scheme error, got %v", err)
+	}
+}
+
+func TestLoadYAMLURL_Checksum(t *testing.T) {
+	doc := "name: pinned\n"
+	server, _ := newConfigServer(t, map[string]string{"/spec.yaml": doc})
+
+	var config TestConfig
+	if err := LoadYAMLURL(server.URL+"/spec.yaml", &config, WithChecksum("sha256:"+sha256Hex(doc))); err != nil {
+		t.Fatalf("LoadYAMLURL() error = %v", err)
+	}
+
+	err := LoadYAMLURL(server.URL+"/spec.yaml", &config, WithChecksum(sha256Hex("name: other\n")))
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+
+	if err := LoadYAMLURL(server.URL+"/spec.yaml", &config, WithChecksum("abc")); err == nil || !strings.Contains(err.Error(), "invalid checksum") {
+		t.Errorf("Expected invalid checksum error, got %v", err)
+	}
+}
+
+func TestLoadYAMLURL_Cache(t *testing.T) {
+	docs := map[string]string{"/spec.yaml": "name: v1\n"}
+	server, requests := newConfigServer(t, docs)
+	cacheDir := t.TempDir()
+	url := server.URL + "/spec.yaml"
+
+	var config TestConfig
+	for i := 0; i < 2; i++ {
+		if err := LoadYAMLURL(url, &config, WithCache(cacheDir, time.Hour)); err != nil {
+			t.Fatalf("LoadYAMLURL() error = %v", err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1 with a fresh cache", got)
+	}
+
+	// An expired entry is refreshed.
+	docs["/spec.yaml"] = "name: v2\n"
+	if err := LoadYAMLURL(url, &config, WithCache(cacheDir, 0)); err != nil || config.Name != "v2" {
+		t.Errorf("LoadYAMLURL(expired) = %+v, %v", config, err)
+	}
+
+	// A stale entry is used when the server is unreachable.
+	server.Close()
+	config = TestConfig{}
+	if err := LoadYAMLURL(url, &config, WithCache(cacheDir, 0)); err != nil || config.Name != "v2" {
+		t.Errorf("LoadYAMLURL(offline) = %+v, %v", config, err)
+	}
+
+	// A pinned entry is used regardless of age.
+	config = TestConfig{}
+	if err := LoadYAMLURL(url, &config, WithCache(cacheDir, 0), WithChecksum(sha256Hex("name: v2\n"))); err != nil || config.Name != "v2" {
+		t.Errorf("LoadYAMLURL(pinned) = %+v, %v", config, err)
+	}
+}
+
+func TestLoadYAMLURL_CacheKeyIncludesHeaders(t *testing.T) {
+	server, requests := newConfigServer(t, map[string]string{"/private/spec.yaml": "name: private\n"})
+	cacheDir := t.TempDir()
+	url := server.URL + "/private/spec.yaml"
+
+	var config TestConfig
+	if err := LoadYAMLURL(url, &config, WithCache(cacheDir, time.Hour), WithHeader("Authorization", "Bearer secret")); err != nil {
+		t.Fatalf("LoadYAMLURL() error = %v", err)
+	}
+
+	// A caller without the credentials does not get the cached document.
+	config = TestConfig{}
+	err := LoadYAMLURL(url, &config, WithCache(cacheDir, time.Hour), WithHeader("Authorization", "Bearer other"))
+	if err == nil || !strings.Contains(err.Error(), "401") || config.Name != "" {
+		t.Errorf("Expected an unauthorized error, got %+v, %v", config, err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestLoadYAMLURL_RejectsIncludes(t *testing.T) {
+	server, _ := newConfigServer(t, map[string]string{"/spec.yaml": "name: !include /etc/passwd\n"})
+
+	var config TestConfig
+	err := LoadYAMLURL(server.URL+"/spec.yaml", &config)
+	if err == nil || !strings.Contains(err.Error(), "line 1, column 7: !include is not supported in remote configuration") {
+		t.Errorf("Expected include error, got %v", err)
+	}
+}
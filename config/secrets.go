@@ -0,0 +1,185 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Decrypter decrypts an encrypted configuration document. format is "yaml",
+// "json" or "toml". The returned plaintext is parsed in the same format.
+type Decrypter interface {
+	Decrypt(data []byte, format string) ([]byte, error)
+}
+
+// DecrypterFunc adapts a function to the Decrypter interface.
+type DecrypterFunc func(data []byte, format string) ([]byte, error)
+
+// Decrypt calls f(data, format).
+func (f DecrypterFunc) Decrypt(data []byte, format string) ([]byte, error) {
+	return f(data, format)
+}
+
+// WithDecrypter decrypts SOPS and age encrypted documents with d before they
+// are parsed, including included files. Documents that are not encrypted
+// are loaded as usual. WithSecretHandler receives the decrypted secrets.
+func WithDecrypter(d Decrypter) Option {
+	return func(o *loadOptions) {
+		o.decrypter = d
+	}
+}
+
+// WithSecretHandler calls handle with the secret values of each document
+// WithDecrypter decrypts: for SOPS the values that were encrypted, and for
+// age, which encrypts whole files, every string value. Pass debug.MarkSecret
+// to have debug helpers redact them:
+//
+//	config.WithSecretHandler(debug.MarkSecret)
+func WithSecretHandler(handle func(values ...string)) Option {
+	return func(o *loadOptions) {
+		o.secretHandler = handle
+	}
+}
+
+// CommandDecrypter returns a Decrypter that pipes the encrypted document
+// through an external command and reads the plaintext from its standard
+// output. "{format}" in args is replaced with the document format:
+//
+//	config.CommandDecrypter("sops", "decrypt", "--input-type", "{format}", "--output-type", "{format}")
+//	config.CommandDecrypter("age", "--decrypt", "--identity", "key.txt")
+func CommandDecrypter(name string, args ...string) Decrypter {
+	return DecrypterFunc(func(data []byte, format string) ([]byte, error) {
+		expanded := make([]string, len(args))
+		for i, arg := range args {
+			expanded[i] = strings.ReplaceAll(arg, "{format}", format)
+		}
+
+		cmd := exec.Command(name, expanded...)
+		cmd.Stdin = bytes.NewReader(data)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("%s: %w: %s", name, err, msg)
+			}
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return out, nil
+	})
+}
+
+var errEncrypted = errors.New("configuration is encrypted; load it with WithDecrypter")
+
+// parse parses data in format f, decrypting it first if it is encrypted and
+// a Decrypter is configured.
+func (o *loadOptions) parse(data []byte, f format) (*yaml.Node, error) {
+	if o.decrypter == nil {
+		if isAgeEncrypted(data) {
+			return nil, errEncrypted
+		}
+		node, err := f.parse(data)
+		if err == nil && isSOPSDocument(node) {
+			return nil, errEncrypted
+		}
+		return node, err
+	}
+
+	if isAgeEncrypted(data) {
+		node, err := o.decrypt(data, f)
+		if err != nil {
+			return nil, err
+		}
+		o.handleSecrets(ageSecrets(node, nil))
+		return node, nil
+	}
+
+	node, err := f.parse(data)
+	if err != nil || !isSOPSDocument(node) {
+		return node, err
+	}
+	decrypted, err := o.decrypt(data, f)
+	if err != nil {
+		return nil, err
+	}
+	o.handleSecrets(sopsSecrets(node, decrypted, nil))
+	return decrypted, nil
+}
+
+func (o *loadOptions) handleSecrets(values []string) {
+	if o.secretHandler != nil && len(values) > 0 {
+		o.secretHandler(values...)
+	}
+}
+
+func (o *loadOptions) decrypt(data []byte, f format) (*yaml.Node, error) {
+	plaintext, err := o.decrypter.Decrypt(data, strings.ToLower(f.name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt configuration: %w", err)
+	}
+	return f.parse(plaintext)
+}
+
+// isAgeEncrypted reports whether data is an age file, binary or armored.
+func isAgeEncrypted(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return bytes.HasPrefix(data, []byte("age-encryption.org/v1\n")) ||
+		bytes.HasPrefix(data, []byte("-----BEGIN AGE ENCRYPTED FILE-----"))
+}
+
+// isSOPSDocument reports whether node carries SOPS metadata: a top-level
+// "sops" mapping.
+func isSOPSDocument(node *yaml.Node) bool {
+	if node.Kind != yaml.DocumentNode || len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+		return false
+	}
+	root := node.Content[0]
+	i := mappingIndex(root, "sops")
+	return i >= 0 && root.Content[i+1].Kind == yaml.MappingNode
+}
+
+// ageSecrets appends the non-empty string values in node to secrets.
+// Booleans, numbers and nulls are not secret on their own.
+func ageSecrets(node *yaml.Node, secrets []string) []string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" && node.Value != "" {
+			secrets = append(secrets, node.Value)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			secrets = ageSecrets(node.Content[i], secrets)
+		}
+	default:
+		for _, child := range node.Content {
+			secrets = ageSecrets(child, secrets)
+		}
+	}
+	return secrets
+}
+
+// sopsSecrets appends the values of decrypted that were encrypted in
+// encrypted to secrets; SOPS can leave some keys in plaintext.
+func sopsSecrets(encrypted, decrypted *yaml.Node, secrets []string) []string {
+	switch {
+	case encrypted.Kind == yaml.ScalarNode && decrypted.Kind == yaml.ScalarNode:
+		if strings.HasPrefix(encrypted.Value, "ENC[") && decrypted.Value != "" {
+			secrets = append(secrets, decrypted.Value)
+		}
+	case encrypted.Kind == yaml.MappingNode && decrypted.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(encrypted.Content); i += 2 {
+			if j := mappingIndex(decrypted, encrypted.Content[i].Value); j >= 0 {
+				secrets = sopsSecrets(encrypted.Content[i+1], decrypted.Content[j+1], secrets)
+			}
+		}
+	case encrypted.Kind == decrypted.Kind:
+		for i := 0; i < len(encrypted.Content) && i < len(decrypted.Content); i++ {
+			secrets = sopsSecrets(encrypted.Content[i], decrypted.Content[i], secrets)
+		}
+	}
+	return secrets
+}
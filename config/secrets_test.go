@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type secretSpec struct {
+	Name     string   `yaml:"name"`
+	Host     string   `yaml:"host"`
+	Password string   `yaml:"password"`
+	APIKeys  []string `yaml:"api_keys"`
+}
+
+var sopsValue = regexp.MustCompile(`ENC\[AES256_GCM,data:([^,]*),[^\]]*\]`)
+
+// fakeSOPS decrypts documents in the shape SOPS writes, with base64 standing
+// in for the cipher.
+func fakeSOPS(data []byte, format string) ([]byte, error) {
+	if format != "yaml" {
+		return nil, errors.New("unexpected format " + format)
+	}
+	plain := sopsValue.ReplaceAllStringFunc(string(data), func(enc string) string {
+		decoded, _ := base64.StdEncoding.DecodeString(sopsValue.FindStringSubmatch(enc)[1])
+		return string(decoded)
+	})
+	plain, _, _ = strings.Cut(plain, "sops:")
+	return []byte(plain), nil
+}
+
+func sopsEncrypt(s string) string {
+	return "ENC[AES256_GCM,data:" + base64.StdEncoding.EncodeToString([]byte(s)) + ",iv:abc,tag:def,type:str]"
+}
+
+// collectSecrets returns a WithSecretHandler option appending to secrets.
+func collectSecrets(secrets *[]string) Option {
+	return WithSecretHandler(func(values ...string) {
+		*secrets = append(*secrets, values...)
+	})
+}
+
+func TestLoadSOPS(t *testing.T) {
+	doc := "name: billing\nhost: db.internal\npassword: " + sopsEncrypt("hunter2-sops") +
+		"\napi_keys:\n  - " + sopsEncrypt("key-one-sops") + "\nsops:\n  version: 3.9.0\n  age: []\n"
+
+	var spec secretSpec
+	var secrets []string
+	if err := LoadYAMLFromString(doc, &spec, WithDecrypter(DecrypterFunc(fakeSOPS)), collectSecrets(&secrets)); err != nil {
+		t.Fatalf("LoadYAMLFromString() error = %v", err)
+	}
+	if spec.Password != "hunter2-sops" || spec.APIKeys[0] != "key-one-sops" || spec.Host != "db.internal" {
+		t.Fatalf("spec = %+v", spec)
+	}
+
+	if want := []string{"hunter2-sops", "key-one-sops"}; !slices.Equal(secrets, want) {
+		t.Errorf("secrets = %q, want only the encrypted values %q", secrets, want)
+	}
+
+	if err := LoadYAMLFromString(doc, &spec); err == nil || !strings.Contains(err.Error(), "WithDecrypter") {
+		t.Errorf("Expected encrypted error without a decrypter, got %v", err)
+	}
+}
+
+func TestLoadAge(t *testing.T) {
+	plaintext := "name: payments\npassword: age-secret-value\nport: 5432\ntls: true\ntimeout: null\n"
+	armored := "-----BEGIN AGE ENCRYPTED FILE-----\n" + base64.StdEncoding.EncodeToString([]byte(plaintext)) + "\n-----END AGE ENCRYPTED FILE-----\n"
+	dir := writeLayers(t, map[string]string{
+		"spec.yaml":    "name: shop\nhost: !include secret.yaml\n",
+		"secret.yaml":  armored,
+		"payment.yaml": armored,
+	})
+
+	decrypter := DecrypterFunc(func(data []byte, format string) ([]byte, error) {
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		return base64.StdEncoding.DecodeString(strings.Join(lines[1:len(lines)-1], ""))
+	})
+
+	var spec secretSpec
+	var secrets []string
+	if err := LoadYAML(filepath.Join(dir, "payment.yaml"), &spec, WithDecrypter(decrypter), collectSecrets(&secrets)); err != nil {
+		t.Fatalf("LoadYAML() error = %v", err)
+	}
+	if spec.Password != "age-secret-value" {
+		t.Errorf("spec = %+v", spec)
+	}
+	if want := []string{"payments", "age-secret-value"}; !slices.Equal(secrets, want) {
+		t.Errorf("secrets = %q, want only the string values %q", secrets, want)
+	}
+
+	var nested struct {
+		Host secretSpec `yaml:"host"`
+	}
+	if err := LoadYAML(filepath.Join(dir, "spec.yaml"), &nested, WithDecrypter(decrypter)); err != nil {
+		t.Fatalf("LoadYAML(include) error = %v", err)
+	}
+	if nested.Host.Name != "payments" {
+		t.Errorf("included encrypted file not decrypted: %+v", nested)
+	}
+
+	failing := DecrypterFunc(func([]byte, string) ([]byte, error) { return nil, errors.New("no identity") })
+	if err := LoadYAML(filepath.Join(dir, "payment.yaml"), &spec, WithDecrypter(failing)); err == nil || !strings.Contains(err.Error(), "failed to decrypt configuration: no identity") {
+		t.Errorf("Expected decrypt error, got %v", err)
+	}
+}
+
+func TestCommandDecrypter(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	d := CommandDecrypter("sh", "-c", `cat >/dev/null; echo "name: {format}"`)
+	var spec secretSpec
+	if err := LoadYAMLFromString("-----BEGIN AGE ENCRYPTED FILE-----\nx\n", &spec, WithDecrypter(d)); err != nil {
+		t.Fatalf("LoadYAMLFromString() error = %v", err)
+	}
+	if spec.Name != "yaml" {
+		t.Errorf("Name = %q, want the format passed to the command", spec.Name)
+	}
+
+	d = CommandDecrypter("sh", "-c", "echo bad key >&2; exit 1")
+	err := LoadYAMLFromString("-----BEGIN AGE ENCRYPTED FILE-----\nx\n", &spec, WithDecrypter(d))
+	if err == nil || !strings.Contains(err.Error(), "bad key") {
+		t.Errorf("Expected command stderr in error, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cpcf/weft/render"
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownFieldError reports a configuration key that does not match any
+// field of the target type.
+type UnknownFieldError struct {
+	// Field is the unknown key.
+	Field string
+	// Path locates the mapping that contains the key, e.g. "tables[0]".
+	Path   string
+	Line   int
+	Column int
+	// Suggestion is the closest known field name, if any is close enough.
+	Suggestion string
+}
+
+func (e *UnknownFieldError) Error() string {
+	msg := fmt.Sprintf("line %d, column %d: unknown field %q", e.Line, e.Column, e.Field)
+	if e.Path != "" {
+		msg += " in " + e.Path
+	}
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", e.Suggestion)
+	}
+	return msg
+}
+
+// LoadYAMLStrict is like LoadYAML but fails when the file contains keys
+// that do not correspond to a field of T. Every unknown key is reported as
+// an *UnknownFieldError with its position and, where possible, the field
+// that was probably meant.
+func LoadYAMLStrict[T any](path string, target *T, opts ...Option) error {
+	return LoadYAML(path, target, append(opts, WithStrict())...)
+}
+
+// WithStrict makes any loader fail on keys that do not correspond to a field
+// of the target type.
+func WithStrict() Option {
+	return func(o *loadOptions) {
+		o.strict = true
+	}
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+)
+
+// checkKnownFields walks node alongside t and returns an error for every
+// mapping key that t has no field for.
+func checkKnownFields(node *yaml.Node, t reflect.Type) error {
+	var errs []error
+	walkKnownFields(node, t, "", &errs)
+	return errors.Join(errs...)
+}
+
+func walkKnownFields(node *yaml.Node, t reflect.Type, path string, errs *[]error) {
+	node = resolveAlias(node)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkKnownFields(child, t, path, errs)
+		}
+
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for i, item := range node.Content {
+			walkKnownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+
+	case yaml.MappingNode:
+		switch t.Kind() {
+		case reflect.Map:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walkKnownFields(node.Content[i+1], t.Elem(), joinFieldPath(path, node.Content[i].Value), errs)
+			}
+		case reflect.Struct:
+			fields, inlineMap := yamlFields(t)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				if key.Value == "<<" {
+					walkKnownFields(value, t, path, errs)
+					continue
+				}
+				if field, ok := fields[key.Value]; ok {
+					walkKnownFields(value, field.Type, joinFieldPath(path, key.Value), errs)
+					continue
+				}
+				if inlineMap != nil {
+					walkKnownFields(value, inlineMap.Elem(), joinFieldPath(path, key.Value), errs)
+					continue
+				}
+				*errs = append(*errs, &UnknownFieldError{
+					Field:      key.Value,
+					Path:       path,
+					Line:       key.Line,
+					Column:     key.Column,
+					Suggestion: suggestField(key.Value, fields),
+				})
+			}
+		}
+	}
+}
+
+// yamlFields returns the keys yaml.v3 decodes into struct t, with their
+// fields, following ",inline" structs. An inline map, which accepts any key,
+// is returned separately.
+func yamlFields(t reflect.Type) (map[string]reflect.StructField, reflect.Type) {
+	fields := make(map[string]reflect.StructField)
+	var inlineMap reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		tag := f.Tag.Get("yaml")
+		if tag == "" && !strings.Contains(string(f.Tag), ":") {
+			tag = string(f.Tag)
+		}
+		if tag == "-" {
+			continue
+		}
+		name, flags, _ := strings.Cut(tag, ",")
+
+		if strings.Contains(","+flags+",", ",inline,") {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			switch ft.Kind() {
+			case reflect.Map:
+				inlineMap = ft
+			case reflect.Struct:
+				inner, innerMap := yamlFields(ft)
+				for k, v := range inner {
+					fields[k] = v
+				}
+				if innerMap != nil {
+					inlineMap = innerMap
+				}
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields, inlineMap
+}
+
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// suggestField returns the known field closest to name: one that differs
+// only in case, underscores or hyphens, or otherwise the nearest by edit
+// distance within a third of the name's length.
+func suggestField(name string, fields map[string]reflect.StructField) string {
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	normalize := func(s string) string {
+		return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(s))
+	}
+	for _, field := range names {
+		if normalize(field) == normalize(name) {
+			return field
+		}
+	}
+
+	return render.ClosestName(name, names)
+}
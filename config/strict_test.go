@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type strictColumn struct {
+	Name       string `yaml:"name"`
+	Type       string `yaml:"type"`
+	PrimaryKey bool   `yaml:"primary_key"`
+	Nullable   bool
+}
+
+type strictMeta struct {
+	Owner string `yaml:"owner"`
+}
+
+type strictSchema struct {
+	strictMeta `yaml:",inline"`
+	Database   string `yaml:"database"`
+	Tables     []struct {
+		Name    string         `yaml:"name"`
+		Columns []strictColumn `yaml:"columns"`
+	} `yaml:"tables"`
+	Labels  map[string]strictMeta `yaml:"labels"`
+	Extra   map[string]any        `yaml:"extra"`
+	Ignored string                `yaml:"-"`
+}
+
+func TestLoadYAMLStrict(t *testing.T) {
+	yamlContent := `owner: platform
+database: postgres
+tables:
+  - name: users
+    columns:
+      - name: id
+        type: uuid
+        primarykey: true
+        nullable: false
+      - name: email
+        tpye: text
+labels:
+  team:
+    ownr: me
+extra:
+  anything: goes
+ignored: x
+`
+
+	configPath := filepath.Join(t.TempDir(), "schema.yaml")
+	if err := os.WriteFile(configPath, []byte(yamlContent), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	var schema strictSchema
+	err := LoadYAMLStrict(configPath, &schema)
+	if err == nil {
+		t.Fatal("Expected unknown fields to fail")
+	}
+
+	for _, want := range []string{
+		`line 8, column 9: unknown field "primarykey" in tables[0].columns[0] (did you mean "primary_key"?)`,
+		`line 11, column 9: unknown field "tpye" in tables[0].columns[1] (did you mean "type"?)`,
+		`line 14, column 5: unknown field "ownr" in labels.team (did you mean "owner"?)`,
+		`line 17, column 1: unknown field "ignored"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "anything") || strings.Contains(err.Error(), `"nullable"`) || strings.Contains(err.Error(), `"owner"`+":") {
+		t.Errorf("Expected known and free-form fields to be accepted, got:\n%v", err)
+	}
+
+	var fieldErr *UnknownFieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "primarykey" || fieldErr.Suggestion != "primary_key" {
+		t.Errorf("Expected an UnknownFieldError, got %#v", fieldErr)
+	}
+
+	if err := LoadYAML(configPath, &schema); err != nil {
+		t.Errorf("Expected non-strict loading to ignore unknown fields, got %v", err)
+	}
+}
+
+func TestWithStrict_OtherFormats(t *testing.T) {
+	var column strictColumn
+	err := LoadTOMLFromString("name = \"id\"\ncolour = \"red\"\n", &column, WithStrict())
+	if err == nil || !strings.Contains(err.Error(), `line 2, column 1: unknown field "colour"`) {
+		t.Errorf("Expected TOML unknown field error, got %v", err)
+	}
+
+	err = LoadJSONFromString(`{"name": "id", "primaryKey": true}`, &column, WithStrict())
+	if err == nil || !strings.Contains(err.Error(), `(did you mean "primary_key"?)`) {
+		t.Errorf("Expected JSON unknown field error with suggestion, got %v", err)
+	}
+
+	if err := LoadYAMLFromString("name: id\nwhatever: 1\n", &column, WithStrict()); err != nil && strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("Expected no suggestion for unrelated names, got %v", err)
+	}
+}
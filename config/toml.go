@@ -0,0 +1,693 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseTOML parses a TOML v1.0 document into a yaml.Node tree so it can be
+// decoded with the same struct tags as YAML. Offset and local date-times
+// become timestamps, local times become strings.
+func parseTOML(data []byte) (*yaml.Node, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("TOML must be valid UTF-8")
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}
+	p := &tomlParser{
+		src:      string(data),
+		line:     1,
+		col:      1,
+		root:     root,
+		current:  root,
+		explicit: make(map[*yaml.Node]bool),
+		frozen:   make(map[*yaml.Node]bool),
+		arrays:   make(map[*yaml.Node]bool),
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Line: 1, Column: 1, Content: []*yaml.Node{root}}, nil
+}
+
+type tomlParser struct {
+	src       string
+	pos       int
+	line, col int
+
+	root    *yaml.Node
+	current *yaml.Node
+	// explicit tables were defined by a [header]; frozen nodes are inline
+	// tables and arrays that cannot be extended; arrays hold [[tables]].
+	explicit map[*yaml.Node]bool
+	frozen   map[*yaml.Node]bool
+	arrays   map[*yaml.Node]bool
+}
+
+type tomlKey struct {
+	name      string
+	line, col int
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d, column %d: %s", p.line, p.col, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) advance(n int) {
+	for i := 0; i < n && !p.eof(); i++ {
+		if p.src[p.pos] == '\n' {
+			p.line++
+			p.col = 1
+		} else if p.src[p.pos]&0xC0 != 0x80 {
+			p.col++
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+// skipSpace skips spaces and tabs.
+func (p *tomlParser) skipSpace() {
+	for c := p.peek(); c == ' ' || c == '\t'; c = p.peek() {
+		p.advance(1)
+	}
+}
+
+// skipComment skips a comment up to, but not including, the newline.
+func (p *tomlParser) skipComment() {
+	if p.peek() != '#' {
+		return
+	}
+	for !p.eof() && p.peek() != '\n' {
+		p.advance(1)
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments.
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r', '\n':
+			p.advance(1)
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+// endLine expects the rest of the line to be blank or a comment.
+func (p *tomlParser) endLine() error {
+	p.skipSpace()
+	p.skipComment()
+	if p.hasPrefix("\r\n") {
+		p.advance(2)
+		return nil
+	}
+	if p.eof() || p.peek() == '\n' {
+		p.advance(1)
+		return nil
+	}
+	return p.errorf("expected end of line, found %q", p.peek())
+}
+
+func (p *tomlParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+
+		var err error
+		switch {
+		case p.hasPrefix("[["):
+			err = p.parseArrayTable()
+		case p.peek() == '[':
+			err = p.parseTable()
+		default:
+			err = p.parseKeyValue(p.current)
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.endLine(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) parseTable() error {
+	p.advance(1)
+	p.skipSpace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.peek() != ']' {
+		return p.errorf("expected ']' to close table header")
+	}
+	p.advance(1)
+
+	parent, err := p.descend(p.root, keys[:len(keys)-1], true)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if existing := mappingValue(parent, last.name); existing != nil {
+		if existing.Kind != yaml.MappingNode || p.explicit[existing] || p.frozen[existing] {
+			return fmt.Errorf("line %d, column %d: table %q is already defined", last.line, last.col, joinKeys(keys))
+		}
+		p.explicit[existing] = true
+		p.current = existing
+		return nil
+	}
+
+	table := newTOMLMapping(last)
+	p.explicit[table] = true
+	parent.Content = append(parent.Content, keyNode(last), table)
+	p.current = table
+	return nil
+}
+
+func (p *tomlParser) parseArrayTable() error {
+	p.advance(2)
+	p.skipSpace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if !p.hasPrefix("]]") {
+		return p.errorf("expected ']]' to close array of tables header")
+	}
+	p.advance(2)
+
+	parent, err := p.descend(p.root, keys[:len(keys)-1], true)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	table := newTOMLMapping(last)
+	p.explicit[table] = true
+
+	if existing := mappingValue(parent, last.name); existing != nil {
+		if !p.arrays[existing] {
+			return fmt.Errorf("line %d, column %d: %q is not an array of tables", last.line, last.col, joinKeys(keys))
+		}
+		existing.Content = append(existing.Content, table)
+	} else {
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: last.line, Column: last.col, Content: []*yaml.Node{table}}
+		p.arrays[seq] = true
+		parent.Content = append(parent.Content, keyNode(last), seq)
+	}
+	p.current = table
+	return nil
+}
+
+// descend walks from table through keys, creating intermediate tables as
+// needed. Arrays of tables resolve to their last element when headers is
+// true.
+func (p *tomlParser) descend(table *yaml.Node, keys []tomlKey, headers bool) (*yaml.Node, error) {
+	for _, key := range keys {
+		next := mappingValue(table, key.name)
+		switch {
+		case next == nil:
+			next = newTOMLMapping(key)
+			table.Content = append(table.Content, keyNode(key), next)
+		case headers && p.arrays[next]:
+			next = next.Content[len(next.Content)-1]
+		case next.Kind != yaml.MappingNode || p.frozen[next]:
+			return nil, fmt.Errorf("line %d, column %d: key %q is already defined as a value", key.line, key.col, key.name)
+		}
+		table = next
+	}
+	return table, nil
+}
+
+func (p *tomlParser) parseKeyValue(table *yaml.Node) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.peek() != '=' {
+		return p.errorf("expected '=' after key %q", joinKeys(keys))
+	}
+	p.advance(1)
+	p.skipSpace()
+
+	parent, err := p.descend(table, keys[:len(keys)-1], false)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if mappingValue(parent, last.name) != nil {
+		return fmt.Errorf("line %d, column %d: duplicate key %q", last.line, last.col, joinKeys(keys))
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	parent.Content = append(parent.Content, keyNode(last), value)
+	return nil
+}
+
+// parseKey parses a possibly dotted key.
+func (p *tomlParser) parseKey() ([]tomlKey, error) {
+	var keys []tomlKey
+	for {
+		key := tomlKey{line: p.line, col: p.col}
+		switch c := p.peek(); {
+		case c == '"':
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key.name = s
+		case c == '\'':
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key.name = s
+		default:
+			start := p.pos
+			for isBareKeyChar(p.peek()) {
+				p.advance(1)
+			}
+			if p.pos == start {
+				return nil, p.errorf("expected a key")
+			}
+			key.name = p.src[start:p.pos]
+		}
+		keys = append(keys, key)
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.advance(1)
+		p.skipSpace()
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (*yaml.Node, error) {
+	line, col := p.line, p.col
+	scalar := func(tag, value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value, Line: line, Column: col}
+	}
+
+	switch c := p.peek(); {
+	case p.hasPrefix(`"""`):
+		s, err := p.parseMultilineString(`"""`)
+		return scalar("!!str", s), err
+	case c == '"':
+		s, err := p.parseBasicString()
+		return scalar("!!str", s), err
+	case p.hasPrefix("'''"):
+		s, err := p.parseMultilineString("'''")
+		return scalar("!!str", s), err
+	case c == '\'':
+		s, err := p.parseLiteralString()
+		return scalar("!!str", s), err
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case p.hasPrefix("true") && !isBareKeyChar(p.peekAt(4)):
+		p.advance(4)
+		return scalar("!!bool", "true"), nil
+	case p.hasPrefix("false") && !isBareKeyChar(p.peekAt(5)):
+		p.advance(5)
+		return scalar("!!bool", "false"), nil
+	}
+
+	token := p.scanToken()
+	if token == "" {
+		return nil, p.errorf("expected a value")
+	}
+	tag, value, err := tomlScalar(token)
+	if err != nil {
+		return nil, fmt.Errorf("line %d, column %d: %w", line, col, err)
+	}
+	return scalar(tag, value), nil
+}
+
+func (p *tomlParser) peekAt(offset int) byte {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+offset]
+}
+
+// scanToken reads an unquoted number or date-time. A date followed by a
+// space and a time is read as one date-time.
+func (p *tomlParser) scanToken() string {
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.advance(1)
+	}
+	if tomlDate.MatchString(p.src[start:p.pos]) && p.peek() == ' ' && isDigit(p.peekAt(1)) {
+		p.advance(1)
+		for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+			p.advance(1)
+		}
+	}
+	return p.src[start:p.pos]
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+var (
+	tomlDate      = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	tomlDateTime  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?$`)
+	tomlOffsetDT  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})$`)
+	tomlLocalTime = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?$`)
+	tomlDecimal   = regexp.MustCompile(`^[+-]?(0|[1-9](_?\d)*)$`)
+	tomlFloat     = regexp.MustCompile(`^[+-]?(0|[1-9](_?\d)*)(\.\d(_?\d)*)?([eE][+-]?\d(_?\d)*)?$`)
+)
+
+// tomlScalar converts an unquoted TOML value to a YAML tag and value.
+func tomlScalar(token string) (string, string, error) {
+	switch {
+	case tomlOffsetDT.MatchString(token):
+		t, err := time.Parse(time.RFC3339Nano, strings.Replace(strings.ToUpper(token), " ", "T", 1))
+		if err != nil {
+			return "", "", fmt.Errorf("invalid date-time %q", token)
+		}
+		return "!!timestamp", t.Format(time.RFC3339Nano), nil
+	case tomlDateTime.MatchString(token):
+		t, err := time.Parse("2006-01-02T15:04:05.999999999", strings.Replace(strings.ToUpper(token), " ", "T", 1))
+		if err != nil {
+			return "", "", fmt.Errorf("invalid date-time %q", token)
+		}
+		return "!!timestamp", t.Format("2006-01-02T15:04:05.999999999"), nil
+	case tomlDate.MatchString(token):
+		if _, err := time.Parse(time.DateOnly, token); err != nil {
+			return "", "", fmt.Errorf("invalid date %q", token)
+		}
+		return "!!timestamp", token, nil
+	case tomlLocalTime.MatchString(token):
+		return "!!str", token, nil
+	}
+
+	switch strings.TrimLeft(token, "+-") {
+	case "inf":
+		if strings.HasPrefix(token, "-") {
+			return "!!float", "-.inf", nil
+		}
+		return "!!float", ".inf", nil
+	case "nan":
+		return "!!float", ".nan", nil
+	}
+
+	for prefix, base := range map[string]int{"0x": 16, "0o": 8, "0b": 2} {
+		if strings.HasPrefix(token, prefix) {
+			digits := token[2:]
+			if digits == "" || strings.HasPrefix(digits, "_") || strings.HasSuffix(digits, "_") || strings.Contains(digits, "__") {
+				return "", "", fmt.Errorf("invalid integer %q", token)
+			}
+			n, err := strconv.ParseUint(strings.ReplaceAll(digits, "_", ""), base, 64)
+			if err != nil || n > math.MaxInt64 {
+				return "", "", fmt.Errorf("invalid integer %q", token)
+			}
+			return "!!int", strconv.FormatUint(n, 10), nil
+		}
+	}
+
+	plain := strings.ReplaceAll(token, "_", "")
+	if tomlDecimal.MatchString(token) {
+		n, err := strconv.ParseInt(plain, 10, 64)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid integer %q", token)
+		}
+		return "!!int", strconv.FormatInt(n, 10), nil
+	}
+	if tomlFloat.MatchString(token) {
+		f, err := strconv.ParseFloat(plain, 64)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid float %q", token)
+		}
+		return "!!float", strconv.FormatFloat(f, 'g', -1, 64), nil
+	}
+	return "", "", fmt.Errorf("invalid value %q", token)
+}
+
+func (p *tomlParser) parseArray() (*yaml.Node, error) {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: p.line, Column: p.col}
+	p.frozen[seq] = true
+	p.advance(1)
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.advance(1)
+			return seq, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		seq.Content = append(seq.Content, value)
+
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.advance(1)
+		case ']':
+			p.advance(1)
+			return seq, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (*yaml.Node, error) {
+	table := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: p.line, Column: p.col}
+	p.advance(1)
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.advance(1)
+		p.frozen[table] = true
+		return table, nil
+	}
+	for {
+		p.skipSpace()
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.advance(1)
+		case '}':
+			p.advance(1)
+			p.freeze(table)
+			return table, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// freeze marks an inline table and the tables its dotted keys created as
+// complete.
+func (p *tomlParser) freeze(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	p.frozen[node] = true
+	for i := 1; i < len(node.Content); i += 2 {
+		p.freeze(node.Content[i])
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.advance(1)
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		switch c {
+		case '"':
+			p.advance(1)
+			return b.String(), nil
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.advance(1)
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.advance(1)
+	start := p.pos
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		if p.peek() == '\'' {
+			s := p.src[start:p.pos]
+			p.advance(1)
+			return s, nil
+		}
+		p.advance(1)
+	}
+}
+
+// parseMultilineString parses a multi-line basic or literal string, delimited
+// by three quotation marks or three apostrophes. A newline directly after
+// the opening delimiter is trimmed.
+func (p *tomlParser) parseMultilineString(delim string) (string, error) {
+	p.advance(3)
+	if p.hasPrefix("\r\n") {
+		p.advance(2)
+	} else if p.peek() == '\n' {
+		p.advance(1)
+	}
+
+	basic := delim == `"""`
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if p.hasPrefix(delim) {
+			// Up to two quotes may directly precede the closing delimiter.
+			n := 3
+			for n < 5 && p.peekAt(n) == delim[0] {
+				n++
+			}
+			b.WriteString(strings.Repeat(delim[:1], n-3))
+			p.advance(n)
+			return b.String(), nil
+		}
+		c := p.peek()
+		if basic && c == '\\' {
+			if p.lineEndingBackslash() {
+				continue
+			}
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte(c)
+		p.advance(1)
+	}
+}
+
+// lineEndingBackslash consumes a backslash at the end of a line together
+// with all following whitespace and newlines.
+func (p *tomlParser) lineEndingBackslash() bool {
+	i := p.pos + 1
+	for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t' || p.src[i] == '\r') {
+		i++
+	}
+	if i >= len(p.src) || p.src[i] != '\n' {
+		return false
+	}
+	p.advance(i - p.pos)
+	for c := p.peek(); c == ' ' || c == '\t' || c == '\r' || c == '\n'; c = p.peek() {
+		p.advance(1)
+	}
+	return true
+}
+
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	p.advance(1)
+	c := p.peek()
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+1+size > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.src[p.pos+1:p.pos+1+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(code))
+		p.advance(1 + size)
+		return nil
+	default:
+		return p.errorf("invalid escape sequence \\%c", c)
+	}
+	p.advance(1)
+	return nil
+}
+
+func newTOMLMapping(key tomlKey) *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: key.line, Column: key.col}
+}
+
+func keyNode(key tomlKey) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.name, Line: key.line, Column: key.col}
+}
+
+// mappingValue returns the value for key in a mapping node, or nil.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if i := mappingIndex(mapping, key); i >= 0 {
+		return mapping.Content[i+1]
+	}
+	return nil
+}
+
+func joinKeys(keys []tomlKey) string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.name
+	}
+	return strings.Join(names, ".")
+}
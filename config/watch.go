@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval is how often Watch checks for changes unless
+// WithPollInterval sets another interval.
+const DefaultPollInterval = 500 * time.Millisecond
+
+// WithPollInterval sets how often Watch checks the configuration files for
+// changes.
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *loadOptions) {
+		o.pollInterval = interval
+	}
+}
+
+// Watcher reloads a configuration file when it changes. Stop it with Close.
+type Watcher struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// Watch loads the configuration file at path into target and then reloads
+// and re-validates it whenever the file, or any file it includes, changes,
+// calling onChange with the new value. A reload that fails calls onChange
+// with the error and a nil value; the next change is loaded as usual.
+//
+// target only receives the initial configuration and is never written by
+// the watcher, so it is safe to read while watching. The format is chosen by
+// the file extension, as for LoadYAMLLayers. Files are polled every
+// DefaultPollInterval unless WithPollInterval is given.
+func Watch[T any](path string, target *T, onChange func(*T, error), opts ...Option) (*Watcher, error) {
+	if path == StdinPath {
+		return nil, fmt.Errorf("cannot watch standard input")
+	}
+
+	sources, err := loadWatched(path, target, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := newLoadOptions(opts).pollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	last := fingerprint(sources)
+	w := &Watcher{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+
+			current := fingerprint(sources)
+			if current == last {
+				continue
+			}
+
+			next := new(T)
+			loaded, err := loadWatched(path, next, opts)
+			if err != nil {
+				// Keep watching the files we know about; the file may be
+				// mid-write or temporarily broken.
+				last = current
+				onChange(nil, err)
+				continue
+			}
+			sources = loaded
+			last = fingerprint(sources)
+			onChange(next, nil)
+		}
+	}()
+	return w, nil
+}
+
+// Close stops the watcher and waits for any reload in progress to finish.
+func (w *Watcher) Close() error {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+	return nil
+}
+
+// loadWatched loads path into target and returns the absolute paths of the
+// files it read, including includes.
+func loadWatched[T any](path string, target *T, opts []Option) ([]string, error) {
+	o := newLoadOptions(opts)
+	o.sources = []string{}
+
+	f := formatForPath(path)
+	node, err := o.parseConfigFile(path, f)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadNode(node, target, f.name, o); err != nil {
+		return nil, err
+	}
+	return o.sources, nil
+}
+
+// recordSource notes that the file at absPath was read, when the loader is
+// tracking its inputs.
+func (o *loadOptions) recordSource(absPath string) {
+	if o.sources != nil {
+		o.sources = append(o.sources, filepath.Clean(absPath))
+	}
+}
+
+// fingerprint summarizes the modification time and size of files; a
+// missing file contributes a marker so its deletion and return are noticed.
+func fingerprint(paths []string) string {
+	var fp string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			fp += path + ":missing\n"
+			continue
+		}
+		fp += fmt.Sprintf("%s:%d:%d\n", path, info.ModTime().UnixNano(), info.Size())
+	}
+	return fp
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type watchEvent struct {
+	config *TestConfig
+	err    error
+}
+
+func writeWatched(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Set the time explicitly so changes are seen on coarse-grained filesystems.
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func nextEvent(t *testing.T, events <-chan watchEvent) watchEvent {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a reload")
+		return watchEvent{}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"config.yaml":  "name: v1\noptions: !include options.yaml\n",
+		"options.yaml": "debug: \"false\"\n",
+	})
+	path := filepath.Join(dir, "config.yaml")
+	base := time.Now().Add(-time.Hour)
+
+	events := make(chan watchEvent, 10)
+	var initial TestConfig
+	w, err := Watch(path, &initial, func(c *TestConfig, err error) {
+		events <- watchEvent{c, err}
+	}, WithPollInterval(5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer w.Close()
+
+	if initial.Name != "v1" || initial.Options["debug"] != "false" {
+		t.Fatalf("initial = %+v", initial)
+	}
+
+	writeWatched(t, path, "name: v2\noptions: !include options.yaml\n", base.Add(time.Minute))
+	if ev := nextEvent(t, events); ev.err != nil || ev.config.Name != "v2" {
+		t.Errorf("reload = %+v, %v", ev.config, ev.err)
+	}
+
+	writeWatched(t, filepath.Join(dir, "options.yaml"), "debug: \"true\"\n", base.Add(2*time.Minute))
+	if ev := nextEvent(t, events); ev.err != nil || ev.config.Options["debug"] != "true" {
+		t.Errorf("reload after include change = %+v, %v", ev.config, ev.err)
+	}
+
+	writeWatched(t, path, "name: [broken\n", base.Add(3*time.Minute))
+	if ev := nextEvent(t, events); ev.err == nil || ev.config != nil {
+		t.Errorf("Expected reload error, got %+v", ev.config)
+	}
+
+	writeWatched(t, path, "name: v3\n", base.Add(4*time.Minute))
+	if ev := nextEvent(t, events); ev.err != nil || ev.config.Name != "v3" {
+		t.Errorf("reload after fix = %+v, %v", ev.config, ev.err)
+	}
+
+	if initial.Name != "v1" {
+		t.Errorf("target was modified by the watcher: %+v", initial)
+	}
+
+	w.Close()
+	writeWatched(t, path, "name: v4\n", base.Add(5*time.Minute))
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case ev := <-events:
+		t.Errorf("reload after Close: %+v", ev)
+	default:
+	}
+}
+
+func TestWatch_Errors(t *testing.T) {
+	var config ValidatedTestConfig
+	dir := writeLayers(t, map[string]string{"invalid.yaml": "name: x\n"})
+
+	if _, err := Watch(filepath.Join(dir, "invalid.yaml"), &config, func(*ValidatedTestConfig, error) {}); err == nil || !strings.Contains(err.Error(), "validation failed") {
+		t.Errorf("Expected initial validation error, got %v", err)
+	}
+	if _, err := Watch(StdinPath, &config, func(*ValidatedTestConfig, error) {}); err == nil {
+		t.Error("Expected error watching stdin")
+	}
+}
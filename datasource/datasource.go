@@ -0,0 +1,143 @@
+// Package datasource assembles template data from several named sources,
+// replacing the glue every generator otherwise writes in main.go:
+//
+//	data, err := datasource.New().
+//		Add("", datasource.File("weft-data.yaml")).
+//		Add("api", datasource.OpenAPI("api/openapi.yaml")).
+//		Add("codes", datasource.CSV("data/error_codes.csv"), datasource.KeyBy("code")).
+//		Add("build", datasource.Env("BUILD_")).
+//		Load(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	err = eng.RenderDir(ctx, "templates", data)
+//
+// Sources are fetched concurrently and merged in the order they were added.
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Source produces one part of the template data.
+type Source interface {
+	Fetch(ctx context.Context) (any, error)
+}
+
+// SourceFunc adapts a function to a Source, so any loader can take part in a
+// pipeline:
+//
+//	datasource.SourceFunc(func(ctx context.Context) (any, error) {
+//		return loadPlugins(ctx)
+//	})
+type SourceFunc func(ctx context.Context) (any, error)
+
+// Fetch calls f.
+func (f SourceFunc) Fetch(ctx context.Context) (any, error) {
+	return f(ctx)
+}
+
+// Transform rewrites the value of a source before it is merged.
+type Transform func(value any) (any, error)
+
+// Pipeline is an ordered list of named sources. The zero value is ready to
+// use.
+type Pipeline struct {
+	entries []entry
+}
+
+type entry struct {
+	name       string
+	source     Source
+	transforms []Transform
+}
+
+// New returns an empty pipeline.
+func New() *Pipeline {
+	return &Pipeline{}
+}
+
+// Add appends a source whose value, after the transforms run in order, is
+// stored under name. A source with an empty name must produce a map, whose
+// keys are merged into the top level instead. Add returns p so calls can be
+// chained.
+func (p *Pipeline) Add(name string, source Source, transforms ...Transform) *Pipeline {
+	p.entries = append(p.entries, entry{name: name, source: source, transforms: transforms})
+	return p
+}
+
+// Load fetches every source and merges the results into one map. Maps are
+// merged deeply, so a later source can override a single nested key of an
+// earlier one; any other value replaces what was there. All failures are
+// reported, each naming its source.
+func (p *Pipeline) Load(ctx context.Context) (map[string]any, error) {
+	values := make([]any, len(p.entries))
+	errs := make([]error, len(p.entries))
+
+	var wg sync.WaitGroup
+	for i, e := range p.entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], errs[i] = e.fetch(ctx)
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]any)
+	for i, e := range p.entries {
+		if e.name != "" {
+			data[e.name] = merge(data[e.name], values[i])
+			continue
+		}
+		m, ok := values[i].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("source %d: top-level source produced %T, not a map", i+1, values[i])
+		}
+		data = merge(data, m).(map[string]any)
+	}
+	return data, nil
+}
+
+func (e entry) fetch(ctx context.Context) (any, error) {
+	label := fmt.Sprintf("source %q", e.name)
+	if e.name == "" {
+		label = "top-level source"
+	}
+
+	value, err := e.source.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", label, err)
+	}
+	for _, t := range e.transforms {
+		if value, err = t(value); err != nil {
+			return nil, fmt.Errorf("failed to transform %s: %w", label, err)
+		}
+	}
+	return value, nil
+}
+
+// merge returns src merged over dst. Nested maps are merged key by key; any
+// other src value replaces dst. Maps are copied rather than modified, so
+// sources can return values they keep using.
+func merge(dst, src any) any {
+	s, ok := src.(map[string]any)
+	if !ok {
+		return src
+	}
+	d, _ := dst.(map[string]any)
+	out := make(map[string]any, len(d)+len(s))
+	for k, v := range d {
+		out[k] = v
+	}
+	for k, v := range s {
+		out[k] = merge(out[k], v)
+	}
+	return out
+}
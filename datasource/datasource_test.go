@@ -0,0 +1,152 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPipeline_Load(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "base.yaml", `
+project: shop
+server:
+  host: localhost
+  port: 8080
+`)
+	override := writeFile(t, dir, "override.json", `{"server": {"port": 9090}}`)
+	codes := writeFile(t, dir, "codes.csv", "code,status\nE001,404\nE002,409\n")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("flags:\n  beta: true\n"))
+	}))
+	defer srv.Close()
+
+	t.Setenv("WEFT_TEST_VERSION", "1.2.3")
+
+	data, err := New().
+		Add("", File(base)).
+		Add("", File(override)).
+		Add("codes", File(codes), KeyBy("code")).
+		Add("beta", URL(srv.URL+"/flags.yaml"), Select("flags.beta")).
+		Add("build", Env("WEFT_TEST_")).
+		Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]any{
+		"project": "shop",
+		"server":  map[string]any{"host": "localhost", "port": 9090},
+		"codes": map[string]any{
+			"E001": map[string]any{"code": "E001", "status": 404},
+			"E002": map[string]any{"code": "E002", "status": 409},
+		},
+		"beta":  true,
+		"build": map[string]any{"VERSION": "1.2.3"},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Load() = %#v, want %#v", data, want)
+	}
+}
+
+func TestPipeline_MergeDoesNotModifySources(t *testing.T) {
+	defaults := map[string]any{"server": map[string]any{"port": 8080}}
+	p := New().
+		Add("", Value(defaults)).
+		Add("", Value(map[string]any{"server": map[string]any{"port": 9090}}))
+
+	for range 2 {
+		data, err := p.Load(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if port := data["server"].(map[string]any)["port"]; port != 9090 {
+			t.Errorf("port = %v, want 9090", port)
+		}
+	}
+	if port := defaults["server"].(map[string]any)["port"]; port != 8080 {
+		t.Errorf("source value was modified: port = %v", port)
+	}
+}
+
+func TestPipeline_Errors(t *testing.T) {
+	failing := SourceFunc(func(context.Context) (any, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	_, err := New().
+		Add("db", failing).
+		Add("", Value("not a map")).
+		Add("missing", File(filepath.Join(t.TempDir(), "missing.yaml"))).
+		Load(context.Background())
+	if err == nil {
+		t.Fatal("Load() error = nil")
+	}
+	for _, want := range []string{`failed to fetch source "db": connection refused`, `failed to fetch source "missing"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v, want it to contain %q", err, want)
+		}
+	}
+
+	_, err = New().Add("", Value([]any{1})).Load(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "top-level source produced []interface {}, not a map") {
+		t.Errorf("Load() error = %v", err)
+	}
+
+	_, err = New().Add("x", Value(map[string]any{"a": 1}), Select("a.b")).Load(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `failed to transform source "x": cannot select "b" from int`) {
+		t.Errorf("Load() error = %v", err)
+	}
+
+	if _, err := File("data.txt").Fetch(context.Background()); err == nil {
+		t.Error("File() accepted an unknown extension")
+	}
+}
+
+func TestKeyBy(t *testing.T) {
+	rows := []any{
+		map[string]any{"id": 1, "name": "a"},
+		map[string]any{"id": 2, "name": "b"},
+	}
+	got, err := KeyBy("id")(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := got.(map[string]any); len(m) != 2 || m["2"].(map[string]any)["name"] != "b" {
+		t.Errorf("KeyBy() = %#v", got)
+	}
+
+	tests := []struct {
+		name    string
+		value   any
+		wantErr string
+	}{
+		{"not a list", map[string]any{}, "need a list of maps"},
+		{"not maps", []any{1}, "item 0 is int"},
+		{"missing field", []any{map[string]any{"name": "a"}}, `item 0 has no "id"`},
+		{"duplicate", append(rows, map[string]any{"id": 1}), `duplicate id "1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := KeyBy("id")(tt.value); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("KeyBy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
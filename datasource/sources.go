@@ -0,0 +1,120 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cpcf/weft/config"
+	"github.com/cpcf/weft/importers/gosource"
+	"github.com/cpcf/weft/importers/openapi"
+	"github.com/cpcf/weft/importers/sqlschema"
+	"github.com/cpcf/weft/importers/tabular"
+)
+
+// Value is a source that always produces v.
+func Value(v any) Source {
+	return SourceFunc(func(context.Context) (any, error) {
+		return v, nil
+	})
+}
+
+// File loads a data file, choosing the loader by extension: .yaml, .yml,
+// .json, .toml, .hcl and .cue are loaded with the config package, and .csv
+// and .xlsx as tables with default options. opts apply to config formats;
+// use CSV or XLSX to configure tables.
+func File(path string, opts ...config.Option) Source {
+	return SourceFunc(func(context.Context) (any, error) {
+		var v any
+		var err error
+		switch ext := strings.ToLower(filepath.Ext(path)); ext {
+		case ".yaml", ".yml":
+			err = config.LoadYAML(path, &v, opts...)
+		case ".json":
+			err = config.LoadJSON(path, &v, opts...)
+		case ".toml":
+			err = config.LoadTOML(path, &v, opts...)
+		case ".hcl":
+			err = config.LoadHCL(path, &v, opts...)
+		case ".cue":
+			err = config.LoadCUE(path, &v, opts...)
+		case ".csv":
+			return tabular.LoadCSV(path)
+		case ".xlsx":
+			return tabular.LoadXLSX(path)
+		default:
+			return nil, fmt.Errorf("unsupported data file extension %q: %s", ext, path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	})
+}
+
+// URL fetches a YAML, JSON or TOML document over HTTP or HTTPS with
+// config.LoadYAMLURL, which also accepts the remote options such as
+// config.WithHeader and config.WithCache.
+func URL(rawURL string, opts ...config.Option) Source {
+	return SourceFunc(func(context.Context) (any, error) {
+		var v any
+		if err := config.LoadYAMLURL(rawURL, &v, opts...); err != nil {
+			return nil, err
+		}
+		return v, nil
+	})
+}
+
+// Env produces a map of the environment variables whose names start with
+// prefix, keyed by the rest of the name: with prefix "BUILD_", BUILD_VERSION
+// becomes "VERSION". Values are strings.
+func Env(prefix string) Source {
+	return SourceFunc(func(context.Context) (any, error) {
+		vars := make(map[string]any)
+		for _, kv := range os.Environ() {
+			name, value, _ := strings.Cut(kv, "=")
+			if key, ok := strings.CutPrefix(name, prefix); ok && key != "" {
+				vars[key] = value
+			}
+		}
+		return vars, nil
+	})
+}
+
+// CSV loads a CSV file as a list of rows; see the tabular package.
+func CSV(path string, opts ...tabular.Option) Source {
+	return SourceFunc(func(context.Context) (any, error) {
+		return tabular.LoadCSV(path, opts...)
+	})
+}
+
+// XLSX loads a worksheet as a list of rows; see the tabular package.
+func XLSX(path string, opts ...tabular.Option) Source {
+	return SourceFunc(func(context.Context) (any, error) {
+		return tabular.LoadXLSX(path, opts...)
+	})
+}
+
+// OpenAPI loads an OpenAPI 3 document as an *openapi.Spec.
+func OpenAPI(path string) Source {
+	return SourceFunc(func(context.Context) (any, error) {
+		return openapi.Load(path)
+	})
+}
+
+// SQLSchema loads a DDL dump as a *sqlschema.Schema. To introspect a live
+// database, wrap sqlschema.Introspect in a SourceFunc.
+func SQLSchema(path string, opts ...sqlschema.Option) Source {
+	return SourceFunc(func(context.Context) (any, error) {
+		return sqlschema.LoadDDL(path, opts...)
+	})
+}
+
+// GoPackage loads the Go package matching pattern as a *gosource.Package.
+func GoPackage(pattern string, opts ...gosource.Option) Source {
+	return SourceFunc(func(context.Context) (any, error) {
+		return gosource.LoadPackage(pattern, opts...)
+	})
+}
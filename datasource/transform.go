@@ -0,0 +1,60 @@
+package datasource
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Select keeps only the value at a dot-separated path of map keys, such as
+// "services.api", so a source can contribute part of a larger document.
+func Select(path string) Transform {
+	return func(value any) (any, error) {
+		for _, key := range strings.Split(path, ".") {
+			m, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("cannot select %q from %T", key, value)
+			}
+			if value, ok = m[key]; !ok {
+				return nil, fmt.Errorf("key %q not found", key)
+			}
+		}
+		return value, nil
+	}
+}
+
+// KeyBy turns a list of maps, such as the rows of a table, into a map keyed
+// by the string form of each row's field, so templates can look rows up with
+// index. Rows must have the field and its values must be unique.
+func KeyBy(field string) Transform {
+	return func(value any) (any, error) {
+		var rows []map[string]any
+		switch v := value.(type) {
+		case []map[string]any:
+			rows = v
+		case []any:
+			for i, item := range v {
+				row, ok := item.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("item %d is %T, not a map", i, item)
+				}
+				rows = append(rows, row)
+			}
+		default:
+			return nil, fmt.Errorf("cannot key %T by %q; need a list of maps", value, field)
+		}
+
+		keyed := make(map[string]any, len(rows))
+		for i, row := range rows {
+			k, ok := row[field]
+			if !ok || k == nil {
+				return nil, fmt.Errorf("item %d has no %q", i, field)
+			}
+			key := fmt.Sprint(k)
+			if _, dup := keyed[key]; dup {
+				return nil, fmt.Errorf("duplicate %s %q", field, key)
+			}
+			keyed[key] = row
+		}
+		return keyed, nil
+	}
+}
@@ -0,0 +1,177 @@
+package debug
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// parseFile parses content for the validator's checks. Functions are not
+// checked and comments are kept, so calls to unknown functions and ignore
+// directives are part of the tree. When content does not parse, the file
+// holds the tree of the lines before the error, if they parse once their
+// open blocks are closed, so a syntax error does not hide every other
+// finding; validateSyntax reports the error itself.
+func (tv *TemplateValidator) parseFile(templatePath, content string) *TemplateFile {
+	file := &TemplateFile{Path: templatePath, Content: content, tabWidth: tv.tabWidth}
+	tree, trees, err := parseTrees(templatePath, content)
+	if err != nil {
+		tree, trees = recoverTrees(templatePath, content, err)
+	}
+	file.Tree, file.Trees = tree, trees
+	return file
+}
+
+func parseTrees(templatePath, content string) (*parse.Tree, map[string]*parse.Tree, error) {
+	tree := parse.New(templatePath)
+	tree.Mode = parse.SkipFuncCheck | parse.ParseComments
+	trees := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(content, "", "", trees); err != nil {
+		return nil, nil, err
+	}
+	return tree, trees, nil
+}
+
+// errorLinePattern matches the name:line locations in parse errors.
+var errorLinePattern = regexp.MustCompile(`(\S+?):(\d+)\b`)
+
+// maxRecoveredBlocks bounds how many open blocks recoverTrees closes.
+const maxRecoveredBlocks = 10
+
+// recoverTrees parses the lines of content before the line err reports,
+// closing the blocks left open there. Positions in the trees are those of
+// content, as only text after them is changed.
+func recoverTrees(templatePath, content string, err error) (*parse.Tree, map[string]*parse.Tree) {
+	// Errors in unclosed actions are reported at the end of the template,
+	// naming the line the action started on as well; the earliest counts.
+	line := 0
+	for _, match := range errorLinePattern.FindAllStringSubmatch(err.Error(), -1) {
+		if match[1] != templatePath {
+			continue
+		}
+		if n, convErr := strconv.Atoi(match[2]); convErr == nil && n > 0 && (line == 0 || n < line) {
+			line = n
+		}
+	}
+	if line == 0 {
+		return nil, nil
+	}
+
+	end := 0
+	for i := 1; i < line && end < len(content); i++ {
+		next := strings.IndexByte(content[end:], '\n')
+		if next < 0 {
+			return nil, nil
+		}
+		end += next + 1
+	}
+	prefix := content[:end]
+	for range maxRecoveredBlocks + 1 {
+		if tree, trees, err := parseTrees(templatePath, prefix); err == nil {
+			return tree, trees
+		}
+		prefix += "{{end}}"
+	}
+	return nil, nil
+}
+
+// walk calls WalkNodes for the body of every template in the file, the file
+// itself and its defines, in the order they appear in the source.
+func (f *TemplateFile) walk(visit func(parse.Node) bool) {
+	trees := make([]*parse.Tree, 0, len(f.Trees))
+	for _, tree := range f.Trees {
+		if tree.Root != nil {
+			trees = append(trees, tree)
+		}
+	}
+	sort.Slice(trees, func(i, j int) bool { return trees[i].Root.Pos < trees[j].Root.Pos })
+	for _, tree := range trees {
+		WalkNodes(tree.Root, visit)
+	}
+}
+
+// actionStart returns the offset of the {{ that opens the action holding
+// the node at pos.
+func actionStart(content string, pos parse.Pos) int {
+	offset := min(int(pos), len(content))
+	if start := strings.LastIndex(content[:offset], "{{"); start >= 0 {
+		return start
+	}
+	return offset
+}
+
+// sourceOffset returns the offset of text, which ends at or after pos, in
+// content. Nodes for field chains such as .A.B.C are positioned partway
+// through the chain, so the source is searched for where it begins.
+func sourceOffset(content string, pos parse.Pos, text string) int {
+	end := min(int(pos)+len(text), len(content))
+	if start := strings.LastIndex(content[:end], text); start >= 0 {
+		return start
+	}
+	return int(pos)
+}
+
+// templateCall is a partial call, include or layout named in a template,
+// with the offset of the action that makes it. Kind is "template",
+// "include" or "extends".
+type templateCall struct {
+	kind   string
+	name   string
+	offset int
+}
+
+// calls returns the partial calls, includes and layouts in the file in
+// source order. Includes and layouts only count when named by a string
+// literal, as the engine cannot resolve any other. A file that does not
+// parse is scanned for them instead, so the templates it uses are still
+// known.
+func (f *TemplateFile) calls() []templateCall {
+	if f.Tree == nil {
+		return scanCalls(f.Content)
+	}
+
+	var calls []templateCall
+	f.walk(func(node parse.Node) bool {
+		switch n := node.(type) {
+		case *parse.TemplateNode:
+			calls = append(calls, templateCall{"template", n.Name, actionStart(f.Content, n.Pos)})
+		case *parse.CommandNode:
+			if len(n.Args) < 2 {
+				break
+			}
+			ident, ok := n.Args[0].(*parse.IdentifierNode)
+			if !ok || ident.Ident != "include" && ident.Ident != "extends" {
+				break
+			}
+			if name, ok := n.Args[1].(*parse.StringNode); ok {
+				calls = append(calls, templateCall{ident.Ident, name.Text, actionStart(f.Content, ident.Pos)})
+			}
+		}
+		return true
+	})
+	sort.SliceStable(calls, func(i, j int) bool { return calls[i].offset < calls[j].offset })
+	return calls
+}
+
+// scanCalls finds the calls in content that does not parse by matching the
+// usual forms of each action.
+func scanCalls(content string) []templateCall {
+	patterns := []struct {
+		kind    string
+		pattern *regexp.Regexp
+	}{
+		{"template", partialRefPattern},
+		{"include", includeRefPattern},
+		{"extends", extendsRefPattern},
+	}
+	var calls []templateCall
+	for _, p := range patterns {
+		for _, match := range p.pattern.FindAllStringSubmatchIndex(content, -1) {
+			calls = append(calls, templateCall{p.kind, content[match[2]:match[3]], match[0]})
+		}
+	}
+	sort.Slice(calls, func(i, j int) bool { return calls[i].offset < calls[j].offset })
+	return calls
+}
@@ -0,0 +1,134 @@
+package debug
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"text/template"
+)
+
+func TestTemplateValidator_ParseTreeChecks(t *testing.T) {
+	funcMap := template.FuncMap{"upper": strings.ToUpper}
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "pipeline",
+			content: "{{ .Name | lower | upper }}",
+			want:    []string{"1:12 Function 'lower' is not defined"},
+		},
+		{
+			name:    "nested and chained calls",
+			content: "{{ upper (camel .Name) }}\n{{ (snake .Name).Field }}",
+			want: []string{
+				"1:11 Function 'camel' is not defined",
+				"2:5 Function 'snake' is not defined",
+			},
+		},
+		{
+			name:    "control structures",
+			content: "{{ if isSet .A }}{{ else if isSet .B }}{{ end }}{{ range keys . }}{{ end }}",
+			want: []string{
+				"1:7 Function 'isSet' is not defined",
+				"1:29 Function 'isSet' is not defined",
+				"1:58 Function 'keys' is not defined",
+			},
+		},
+		{
+			name:    "block and define",
+			content: "{{ block \"title\" . }}{{ title .Name }}{{ end }}\n{{ define \"x\" }}{{ kebab . }}{{ end }}",
+			want: []string{
+				"1:25 Function 'title' is not defined",
+				"2:20 Function 'kebab' is not defined",
+			},
+		},
+		{
+			name:    "comments and fields",
+			content: "{{/* lower everything */}}\n{{- /* camel */ -}}\n{{ .Name }} {{ $x := .Name }}{{ $x }}",
+			want:    []string{},
+		},
+		{
+			name:    "deep access in conditions and variables",
+			content: "{{ if .A.B.C.D.E.F }}\n{{ with $x := . }}{{ upper $x.A.B.C.D.E.F }}{{ end }}{{ end }}",
+			want: []string{
+				"1:7 Variable access chain 'A.B.C.D.E.F' is very deep",
+				"2:28 Variable access chain '$x.A.B.C.D.E.F' is very deep",
+			},
+		},
+		{
+			name:    "trimmed partial calls",
+			content: "{{- template \"header\" . -}}\n  {{- if .A }}{{ template \"footer\" }}{{ end }}",
+			want: []string{
+				"1:1 Partial template 'header' not found",
+				"2:15 Partial template 'footer' not found",
+			},
+		},
+		{
+			name:    "syntax error",
+			content: "{{ lower .A }}\n{{ if .Name }}",
+			want:    []string{"1:4 Function 'lower' is not defined"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewTemplateValidator(fstest.MapFS{"t.tmpl": {Data: []byte(tt.content)}}, funcMap, nil)
+			result := validator.ValidateTemplate("t.tmpl")
+
+			got := []string{}
+			for _, finding := range append(result.Errors, result.Warnings...) {
+				if finding.Type == "syntax_error" {
+					continue
+				}
+				got = append(got, fmt.Sprintf("%d:%d %s", finding.Line, finding.Column, finding.Message))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanCalls(t *testing.T) {
+	content := "{{- template \"a\" . }}{{ include \"b\" }}\n{{-  extends \"c\" }}{{ template \"d\" -}}"
+	want := []templateCall{{"template", "a", 0}, {"include", "b", 21}, {"extends", "c", 39}, {"template", "d", 58}}
+	if got := scanCalls(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("scanCalls() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTemplateValidator_FunctionSuggestions(t *testing.T) {
+	funcMap := template.FuncMap{"upper": strings.ToUpper, "snake": strings.ToLower}
+	tests := []struct {
+		name        string
+		content     string
+		findingType string
+		suggestion  string
+	}{
+		{"function map", "{{ uper .Name }}", "unknown_function", "Did you mean 'upper'?"},
+		{"builtin", "{{ printff \"%s\" .Name }}", "unknown_function", "Did you mean 'printf'?"},
+		{"case", "{{ Snake .Name }}", "unknown_function", "Did you mean 'snake'?"},
+		{"nothing close", "{{ pluralize .Name }}", "unknown_function", "Check if 'pluralize' is spelled correctly or add it to the function map"},
+		{"syntax error", "{{ uper .Name }}", "syntax_error", "Did you mean 'upper'?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewTemplateValidator(fstest.MapFS{"t.tmpl": {Data: []byte(tt.content)}}, funcMap, nil)
+			result := validator.ValidateTemplate("t.tmpl")
+			for _, finding := range append(result.Errors, result.Warnings...) {
+				if finding.Type == tt.findingType {
+					if finding.Suggestion != tt.suggestion {
+						t.Errorf("Suggestion = %q, want %q", finding.Suggestion, tt.suggestion)
+					}
+					return
+				}
+			}
+			t.Errorf("no %s finding in %+v", tt.findingType, result)
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// breakFunc is the function templates instrumented for breakpoints call
+// before each action.
+const breakFunc = "weftBreak"
+
+// ErrExecutionAborted is returned by ExecuteWithDebug when a break handler
+// returns Abort.
+var ErrExecutionAborted = errors.New("template execution aborted by debugger")
+
+// Breakpoint pauses template execution. Set Line to pause before the first
+// action on that line of Template, or of any template if Template is "", or
+// set Define to pause on entering the define or block of that name.
+type Breakpoint struct {
+	Template string `json:"template,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Define   string `json:"define,omitempty"`
+}
+
+// StepAction tells a paused execution how to go on.
+type StepAction int
+
+const (
+	// Continue runs until the next breakpoint.
+	Continue StepAction = iota
+	// Step pauses again before the next action.
+	Step
+	// Abort stops the execution with ErrExecutionAborted.
+	Abort
+)
+
+// Frame is the state of an execution paused before an action.
+type Frame struct {
+	// Template is the file holding the action, and Line and Column its
+	// position, counting from 1.
+	Template string `json:"template"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	// Define is the define or block being executed, or "" for the
+	// template itself.
+	Define string `json:"define,omitempty"`
+	// Action is the source of the action about to run, such as
+	// "{{.Name}}" or "{{range .Items}}".
+	Action string `json:"action"`
+	// Dot is the value of dot, and Vars the variables in scope by name,
+	// including "$".
+	Dot  any            `json:"dot"`
+	Vars map[string]any `json:"vars"`
+	// Breakpoint is the breakpoint paused at, or the zero Breakpoint when
+	// stepping.
+	Breakpoint Breakpoint `json:"breakpoint"`
+}
+
+// AddBreakpoint adds a breakpoint to the executions of ExecuteWithDebug.
+// Breakpoints only pause when a handler is set with OnBreak.
+func (td *TemplateDebugger) AddBreakpoint(bp Breakpoint) {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+	td.breakpoints = append(td.breakpoints, bp)
+}
+
+// ClearBreakpoints removes every breakpoint.
+func (td *TemplateDebugger) ClearBreakpoints() {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+	td.breakpoints = nil
+}
+
+// OnBreak sets the function called when an execution pauses. The execution
+// waits until it returns, so it can inspect the frame, wait for a user or
+// hand the frame to another goroutine over a channel. It is called from the
+// goroutine running ExecuteWithDebug, so concurrent executions call it
+// concurrently.
+func (td *TemplateDebugger) OnBreak(handler func(*Frame) StepAction) {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+	td.onBreak = handler
+}
+
+// breakSession returns the breakpoints and handler of an execution, or nil
+// if it cannot pause.
+func (td *TemplateDebugger) breakSession() *breakSession {
+	td.mu.RLock()
+	defer td.mu.RUnlock()
+	if td.onBreak == nil || len(td.breakpoints) == 0 {
+		return nil
+	}
+	return &breakSession{breakpoints: slices.Clone(td.breakpoints), handler: td.onBreak}
+}
+
+// breakSession is the state of one execution with breakpoints.
+type breakSession struct {
+	breakpoints []Breakpoint
+	handler     func(*Frame) StepAction
+	points      []breakPoint
+	stepping    bool
+}
+
+// breakPoint is a place an instrumented template can pause.
+type breakPoint struct {
+	template     string
+	line, column int
+	define       string
+	// entry is true for the point entering a define, and first for the
+	// first point on its line.
+	entry  bool
+	first  bool
+	action string
+	vars   []string
+}
+
+// instrument returns a copy of tmpl that calls breakFunc before each action
+// and on entering each define.
+func (s *breakSession) instrument(tmpl *template.Template) (*template.Template, error) {
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	clone.Funcs(template.FuncMap{breakFunc: s.reached})
+
+	copies := make(map[*parse.Tree]*parse.Tree)
+	for _, t := range clone.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		if instrumented, ok := copies[t.Tree]; ok {
+			t.Tree = instrumented
+			continue
+		}
+		tree := t.Tree.Copy()
+		copies[t.Tree] = tree
+		t.Tree = tree
+		if err := s.instrumentTree(tree); err != nil {
+			return nil, err
+		}
+	}
+	s.markFirst()
+	return clone, nil
+}
+
+func (s *breakSession) instrumentTree(tree *parse.Tree) error {
+	define := ""
+	if tree.Name != tree.ParseName {
+		define = tree.Name
+	}
+
+	var visit func(list *parse.ListNode, vars []string) error
+	visit = func(list *parse.ListNode, vars []string) error {
+		if list == nil {
+			return nil
+		}
+		var nodes []parse.Node
+		for _, node := range list.Nodes {
+			switch n := node.(type) {
+			case *parse.TextNode, *parse.CommentNode:
+				nodes = append(nodes, node)
+				continue
+			case *parse.IfNode:
+				if err := visitBranch(&n.BranchNode, vars, visit); err != nil {
+					return err
+				}
+			case *parse.RangeNode:
+				if err := visitBranch(&n.BranchNode, vars, visit); err != nil {
+					return err
+				}
+			case *parse.WithNode:
+				if err := visitBranch(&n.BranchNode, vars, visit); err != nil {
+					return err
+				}
+			}
+
+			point := s.point(tree, node, define, vars)
+			call, err := breakCall(len(s.points), vars)
+			if err != nil {
+				return err
+			}
+			s.points = append(s.points, point)
+			nodes = append(nodes, call, node)
+
+			if action, ok := node.(*parse.ActionNode); ok && len(action.Pipe.Decl) > 0 && !action.Pipe.IsAssign {
+				vars = declare(vars, action.Pipe)
+			}
+		}
+		list.Nodes = nodes
+		return nil
+	}
+	if err := visit(tree.Root, nil); err != nil {
+		return err
+	}
+
+	if define != "" {
+		point := s.point(tree, tree.Root, define, nil)
+		point.entry = true
+		call, err := breakCall(len(s.points), nil)
+		if err != nil {
+			return err
+		}
+		s.points = append(s.points, point)
+		tree.Root.Nodes = slices.Insert(tree.Root.Nodes, 0, call)
+	}
+	return nil
+}
+
+// visitBranch visits the arms of an if, range or with action, in which the
+// variables its pipeline declares are in scope.
+func visitBranch(branch *parse.BranchNode, vars []string, visit func(*parse.ListNode, []string) error) error {
+	inner := declare(vars, branch.Pipe)
+	if err := visit(branch.List, inner); err != nil {
+		return err
+	}
+	return visit(branch.ElseList, inner)
+}
+
+// declare returns vars with the variables pipe declares added.
+func declare(vars []string, pipe *parse.PipeNode) []string {
+	vars = slices.Clip(vars)
+	for _, v := range pipe.Decl {
+		if name := v.Ident[0]; !slices.Contains(vars, name) {
+			vars = append(vars, name)
+		}
+	}
+	return vars
+}
+
+// point describes the point before node, before anything is inserted in
+// front of it.
+func (s *breakSession) point(tree *parse.Tree, node parse.Node, define string, vars []string) breakPoint {
+	p := breakPoint{template: tree.ParseName, define: define, action: actionSource(node, define), vars: vars}
+	p.line, p.column = nodePosition(tree, node)
+	return p
+}
+
+// nodePosition returns the line and column of node in tree, counting from 1.
+func nodePosition(tree *parse.Tree, node parse.Node) (line, column int) {
+	location, _ := tree.ErrorContext(node)
+	if i := strings.LastIndexByte(location, ':'); i >= 0 {
+		// ErrorContext counts columns from 0.
+		column, _ = strconv.Atoi(location[i+1:])
+		column++
+		location = location[:i]
+		if j := strings.LastIndexByte(location, ':'); j >= 0 {
+			line, _ = strconv.Atoi(location[j+1:])
+		}
+	}
+	return line, column
+}
+
+// actionSource returns the source of node, or only the action opening it
+// for if, range and with actions and defines.
+func actionSource(node parse.Node, define string) string {
+	switch n := node.(type) {
+	case *parse.IfNode:
+		return fmt.Sprintf("{{if %s}}", n.Pipe)
+	case *parse.RangeNode:
+		return fmt.Sprintf("{{range %s}}", n.Pipe)
+	case *parse.WithNode:
+		return fmt.Sprintf("{{with %s}}", n.Pipe)
+	case *parse.ListNode:
+		return fmt.Sprintf("{{define %q}}", define)
+	}
+	return node.String()
+}
+
+// markFirst marks the points that come first on their line, which line
+// breakpoints pause at.
+func (s *breakSession) markFirst() {
+	first := make(map[string]int)
+	for i, p := range s.points {
+		if p.entry {
+			continue
+		}
+		key := fmt.Sprintf("%s:%d", p.template, p.line)
+		if j, ok := first[key]; !ok || p.column < s.points[j].column {
+			first[key] = i
+		}
+	}
+	for _, i := range first {
+		s.points[i].first = true
+	}
+}
+
+// breakCall returns the action calling breakFunc for the point with the
+// given index, passing it dot and the variables in scope.
+func breakCall(index int, vars []string) (parse.Node, error) {
+	// The variables are declared in front of the call so that it parses;
+	// executed in place of the call they refer to the template's own.
+	var src strings.Builder
+	for _, v := range vars {
+		fmt.Fprintf(&src, "{{%s := 0}}", v)
+	}
+	fmt.Fprintf(&src, "{{%s %d . $", breakFunc, index)
+	for _, v := range vars {
+		src.WriteString(" " + v)
+	}
+	src.WriteString("}}")
+	return parseCall(breakFunc, src.String())
+}
+
+// parseCall parses src, which calls the function fn, and returns its last
+// node. Nodes are parsed rather than built so that they belong to a tree,
+// which printing them needs.
+func parseCall(fn, src string) (parse.Node, error) {
+	trees, err := parse.Parse(fn, src, "", "", map[string]any{fn: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to instrument template: %w", err)
+	}
+	nodes := trees[fn].Root.Nodes
+	return nodes[len(nodes)-1], nil
+}
+
+// reached is called by instrumented templates at the point with the given
+// index.
+func (s *breakSession) reached(index int, dot, root any, values ...any) (string, error) {
+	p := s.points[index]
+	bp, hit := s.breakpoint(p)
+	if !hit && !s.stepping {
+		return "", nil
+	}
+
+	frame := &Frame{
+		Template:   p.template,
+		Line:       p.line,
+		Column:     p.column,
+		Define:     p.define,
+		Action:     p.action,
+		Dot:        dot,
+		Vars:       map[string]any{"$": root},
+		Breakpoint: bp,
+	}
+	for i, name := range p.vars {
+		frame.Vars[name] = values[i]
+	}
+
+	switch s.handler(frame) {
+	case Step:
+		s.stepping = true
+	case Abort:
+		return "", ErrExecutionAborted
+	default:
+		s.stepping = false
+	}
+	return "", nil
+}
+
+// breakpoint returns the breakpoint set at p, if there is one.
+func (s *breakSession) breakpoint(p breakPoint) (Breakpoint, bool) {
+	for _, bp := range s.breakpoints {
+		switch {
+		case bp.Define != "":
+			if p.entry && bp.Define == p.define {
+				return bp, true
+			}
+		case p.first && bp.Line == p.line && (bp.Template == "" || bp.Template == p.template):
+			return bp, true
+		}
+	}
+	return Breakpoint{}, false
+}
@@ -0,0 +1,123 @@
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateDebugger_Breakpoints(t *testing.T) {
+	source := `{{ $title := .Title }}{{ range $i, $group := .Groups }}
+{{ range $group }}{{ template "item" . }}{{ end }}
+{{ end }}{{ $title }}
+{{ define "item" }}- {{ . }}{{ end }}`
+	tmpl := template.Must(template.New("list.tmpl").Parse(source))
+	data := map[string]any{"Title": "T", "Groups": [][]string{{"a", "b"}, {"c"}}}
+
+	tests := []struct {
+		name        string
+		breakpoints []Breakpoint
+		actions     []StepAction
+		want        []string
+		wantErr     error
+	}{
+		{
+			name:        "line",
+			breakpoints: []Breakpoint{{Template: "list.tmpl", Line: 2}},
+			want: []string{
+				`list.tmpl:2:10 {{range $group}} dot=[a b] vars=map[$:data $group:[a b] $i:0 $title:T]`,
+				`list.tmpl:2:10 {{range $group}} dot=[c] vars=map[$:data $group:[c] $i:1 $title:T]`,
+			},
+		},
+		{
+			name:        "other template",
+			breakpoints: []Breakpoint{{Template: "other.tmpl", Line: 2}},
+			want:        []string{},
+		},
+		{
+			name:        "define",
+			breakpoints: []Breakpoint{{Define: "item"}},
+			want: []string{
+				`list.tmpl:4:20 item {{define "item"}} dot=a vars=map[$:a]`,
+				`list.tmpl:4:20 item {{define "item"}} dot=b vars=map[$:b]`,
+				`list.tmpl:4:20 item {{define "item"}} dot=c vars=map[$:c]`,
+			},
+		},
+		{
+			name:        "step",
+			breakpoints: []Breakpoint{{Line: 3}},
+			actions:     []StepAction{Step},
+			want: []string{
+				`list.tmpl:3:13 {{$title}} dot=map[Groups:[[a b] [c]] Title:T] vars=map[$:data $title:T]`,
+			},
+		},
+		{
+			name:        "step into",
+			breakpoints: []Breakpoint{{Line: 2}},
+			actions:     []StepAction{Step, Step, Step, Abort},
+			want: []string{
+				`list.tmpl:2:10 {{range $group}} dot=[a b] vars=map[$:data $group:[a b] $i:0 $title:T]`,
+				`list.tmpl:2:31 {{template "item" .}} dot=a vars=map[$:data $group:[a b] $i:0 $title:T]`,
+				`list.tmpl:4:20 item {{define "item"}} dot=a vars=map[$:a]`,
+				`list.tmpl:4:25 item {{.}} dot=a vars=map[$:a]`,
+			},
+			wantErr: ErrExecutionAborted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			debugger := NewTemplateDebugger(NewDebugMode(WithLevel(LevelOff)))
+			for _, bp := range tt.breakpoints {
+				debugger.AddBreakpoint(bp)
+			}
+			got := []string{}
+			debugger.OnBreak(func(frame *Frame) StepAction {
+				define := ""
+				if frame.Define != "" {
+					define = frame.Define + " "
+				}
+				if root, ok := frame.Vars["$"].(map[string]any); ok && reflect.DeepEqual(root, data) {
+					frame.Vars["$"] = "data"
+				}
+				got = append(got, fmt.Sprintf("%s:%d:%d %s%s dot=%v vars=%v", frame.Template, frame.Line, frame.Column, define, frame.Action, frame.Dot, frame.Vars))
+				if len(got) <= len(tt.actions) {
+					return tt.actions[len(got)-1]
+				}
+				return Continue
+			})
+
+			output, err := debugger.ExecuteWithDebug("list", tmpl, data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExecuteWithDebug() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && output != "\n- a- b\n\n- c\nT\n" {
+				t.Errorf("output = %q", output)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pauses:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateDebugger_BreakpointsNeedHandler(t *testing.T) {
+	tmpl := template.Must(template.New("t").Parse("{{ .Name }}"))
+	debugger := NewTemplateDebugger(NewDebugMode(WithLevel(LevelOff)))
+	debugger.AddBreakpoint(Breakpoint{Line: 1})
+
+	output, err := debugger.ExecuteWithDebug("t", tmpl, map[string]string{"Name": "x"})
+	if err != nil || output != "x" {
+		t.Errorf("ExecuteWithDebug() = %q, %v", output, err)
+	}
+
+	paused := false
+	debugger.OnBreak(func(*Frame) StepAction { paused = true; return Continue })
+	debugger.ClearBreakpoints()
+	debugger.ExecuteWithDebug("t", tmpl, map[string]string{"Name": "y"})
+	if paused {
+		t.Error("Expected no pause after ClearBreakpoints")
+	}
+}
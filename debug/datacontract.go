@@ -0,0 +1,482 @@
+package debug
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/cpcf/weft/render"
+)
+
+// dataShape is what the validator knows about a value templates can reach:
+// the fields and methods it has, or the elements it holds.
+type dataShape struct {
+	kind shapeKind
+	name string
+	// fields lists the fields of objects, and the known keys of maps.
+	fields map[string]*dataShape
+	// methods maps each method of a Go type to the shape of its result.
+	methods map[string]*dataShape
+	// elem is the element of lists and the value of other map keys.
+	elem *dataShape
+}
+
+type shapeKind int
+
+const (
+	shapeUnknown shapeKind = iota
+	shapeObject
+	shapeMap
+	shapeList
+	shapeScalar
+)
+
+var unknownShape = &dataShape{kind: shapeUnknown}
+
+// field returns the shape of the field or method name, reporting false when
+// a template accessing it would fail.
+func (s *dataShape) field(name string) (*dataShape, bool) {
+	if s == nil || s.kind == shapeUnknown {
+		return unknownShape, true
+	}
+	if result, ok := s.methods[name]; ok {
+		return result, true
+	}
+	if field, ok := s.fields[name]; ok {
+		return field, true
+	}
+	if s.kind == shapeMap {
+		return s.elem, true
+	}
+	return nil, false
+}
+
+// element returns the shape of the values range visits.
+func (s *dataShape) element() *dataShape {
+	if s == nil || (s.kind != shapeList && s.kind != shapeMap) || s.elem == nil {
+		return unknownShape
+	}
+	return s.elem
+}
+
+// names returns the fields and methods of s, sorted.
+func (s *dataShape) names() []string {
+	names := make([]string, 0, len(s.fields)+len(s.methods))
+	for name := range s.fields {
+		names = append(names, name)
+	}
+	for name := range s.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetDataType makes the validator check the fields templates access against
+// t, the type of the data they are rendered with. Accessing a field or
+// method t does not have, as in {{ .Usr.Name }}, is reported as an
+// unknown_field error at the line of the access. Fields of interface or
+// map[string]any type are not checked further.
+func (tv *TemplateValidator) SetDataType(t reflect.Type) {
+	if t == nil {
+		tv.data, tv.dataKey = nil, ""
+		return
+	}
+	tv.data = shapeOfType(t, make(map[reflect.Type]*dataShape))
+	tv.dataKey = "type:" + t.PkgPath() + "." + t.String()
+}
+
+// SetDataSchema is SetDataType for data described by a JSON Schema
+// document. The properties of an object are taken as its complete list of
+// fields unless additionalProperties allows others. Local references to
+// definitions and $defs are followed; anyOf, oneOf and allOf are not
+// checked.
+func (tv *TemplateValidator) SetDataSchema(schema []byte) error {
+	var root map[string]any
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return fmt.Errorf("failed to parse data schema: %w", err)
+	}
+	shape, err := shapeOfSchema(root, root, make(map[string]*dataShape))
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(schema)
+	tv.data, tv.dataKey = shape, "schema:"+hex.EncodeToString(sum[:])
+	return nil
+}
+
+func shapeOfType(t reflect.Type, seen map[reflect.Type]*dataShape) *dataShape {
+	if shape, ok := seen[t]; ok {
+		return shape
+	}
+	if t.Kind() == reflect.Pointer {
+		// Templates follow pointers, so a pointer has the shape of its
+		// element.
+		shape := shapeOfType(t.Elem(), seen)
+		seen[t] = shape
+		return shape
+	}
+	if t.Kind() == reflect.Interface {
+		seen[t] = unknownShape
+		return unknownShape
+	}
+
+	shape := &dataShape{name: t.String()}
+	seen[t] = shape
+
+	methods := reflect.PointerTo(t)
+	for i := range methods.NumMethod() {
+		method := methods.Method(i)
+		if shape.methods == nil {
+			shape.methods = make(map[string]*dataShape)
+		}
+		shape.methods[method.Name] = unknownShape
+		if out := method.Type.NumOut(); out == 1 || (out == 2 && method.Type.Out(1) == reflect.TypeFor[error]()) {
+			shape.methods[method.Name] = shapeOfType(method.Type.Out(0), seen)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		shape.kind = shapeObject
+		shape.fields = make(map[string]*dataShape)
+		for _, field := range reflect.VisibleFields(t) {
+			if field.IsExported() && !field.Anonymous {
+				shape.fields[field.Name] = shapeOfType(field.Type, seen)
+			}
+		}
+	case reflect.Map:
+		// Fields of maps are looked up as string keys; other maps can
+		// only be ranged over.
+		shape.kind = shapeList
+		if t.Key().Kind() == reflect.String {
+			shape.kind = shapeMap
+		}
+		shape.elem = shapeOfType(t.Elem(), seen)
+	case reflect.Slice, reflect.Array, reflect.Chan:
+		shape.kind = shapeList
+		shape.elem = shapeOfType(t.Elem(), seen)
+	default:
+		shape.kind = shapeScalar
+	}
+	return shape
+}
+
+func shapeOfSchema(schema, root map[string]any, refs map[string]*dataShape) (*dataShape, error) {
+	if ref, ok := schema["$ref"].(string); ok {
+		if shape, ok := refs[ref]; ok {
+			return shape, nil
+		}
+		target, err := resolveSchemaRef(root, ref)
+		if err != nil {
+			return nil, err
+		}
+		shape := &dataShape{}
+		refs[ref] = shape
+		resolved, err := shapeOfSchema(target, root, refs)
+		if err != nil {
+			return nil, err
+		}
+		*shape = *resolved
+		return shape, nil
+	}
+
+	typ, _ := schema["type"].(string)
+	properties, hasProperties := schema["properties"].(map[string]any)
+	name := typ
+	if title, ok := schema["title"].(string); ok && title != "" {
+		name = title
+	}
+
+	switch {
+	case typ == "object" || hasProperties:
+		if name == "" {
+			name = "object"
+		}
+		shape := &dataShape{kind: shapeObject, name: name, fields: make(map[string]*dataShape)}
+		for property, propertySchema := range properties {
+			field := unknownShape
+			if propertySchema, ok := propertySchema.(map[string]any); ok {
+				var err error
+				if field, err = shapeOfSchema(propertySchema, root, refs); err != nil {
+					return nil, err
+				}
+			}
+			shape.fields[property] = field
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if additional {
+				shape.kind, shape.elem = shapeMap, unknownShape
+			}
+		case map[string]any:
+			elem, err := shapeOfSchema(additional, root, refs)
+			if err != nil {
+				return nil, err
+			}
+			shape.kind, shape.elem = shapeMap, elem
+		default:
+			if !hasProperties {
+				shape.kind, shape.elem = shapeMap, unknownShape
+			}
+		}
+		return shape, nil
+	case typ == "array":
+		shape := &dataShape{kind: shapeList, name: name, elem: unknownShape}
+		if items, ok := schema["items"].(map[string]any); ok {
+			elem, err := shapeOfSchema(items, root, refs)
+			if err != nil {
+				return nil, err
+			}
+			shape.elem = elem
+		}
+		return shape, nil
+	case typ == "string" || typ == "number" || typ == "integer" || typ == "boolean" || typ == "null":
+		return &dataShape{kind: shapeScalar, name: name}, nil
+	}
+	return unknownShape, nil
+}
+
+// resolveSchemaRef returns the schema a local reference such as
+// "#/$defs/User" points to.
+func resolveSchemaRef(root map[string]any, ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("failed to resolve data schema reference %q: only local references are supported", ref)
+	}
+	current := root
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+		next, ok := current[part].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("failed to resolve data schema reference %q", ref)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// validateDataContract reports the field accesses in file that the data
+// set with SetDataType or SetDataSchema does not have.
+func (tv *TemplateValidator) validateDataContract(file *TemplateFile, result *ValidationResult) {
+	if tv.data == nil || file.Tree == nil {
+		// Syntax errors are reported by validateSyntax.
+		return
+	}
+
+	checker := &contractChecker{
+		tv:       tv,
+		path:     file.Path,
+		content:  file.Content,
+		result:   result,
+		trees:    file.Trees,
+		checked:  make(map[string]bool),
+		reported: make(map[string]bool),
+	}
+	checker.checkTree(file.Tree, tv.data)
+}
+
+// contractChecker walks a template's parse tree, following the shape of dot
+// and of each variable through with, range and template actions.
+type contractChecker struct {
+	tv       *TemplateValidator
+	path     string
+	content  string
+	result   *ValidationResult
+	trees    map[string]*parse.Tree
+	checked  map[string]bool
+	reported map[string]bool
+	vars     []contractVar
+}
+
+type contractVar struct {
+	name  string
+	shape *dataShape
+}
+
+// checkTree checks a template invoked with dot, in a scope of its own.
+func (c *contractChecker) checkTree(tree *parse.Tree, dot *dataShape) {
+	saved := c.vars
+	c.vars = []contractVar{{name: "$", shape: dot}}
+	c.walk(tree.Root, dot)
+	c.vars = saved
+}
+
+func (c *contractChecker) walk(node parse.Node, dot *dataShape) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		c.declare(n.Pipe, c.pipe(n.Pipe, dot))
+	case *parse.IfNode:
+		mark := len(c.vars)
+		c.declare(n.Pipe, c.pipe(n.Pipe, dot))
+		c.walk(n.List, dot)
+		c.walk(n.ElseList, dot)
+		c.vars = c.vars[:mark]
+	case *parse.WithNode:
+		mark := len(c.vars)
+		value := c.pipe(n.Pipe, dot)
+		c.declare(n.Pipe, value)
+		c.walk(n.List, value)
+		c.walk(n.ElseList, dot)
+		c.vars = c.vars[:mark]
+	case *parse.RangeNode:
+		mark := len(c.vars)
+		collection := c.pipe(n.Pipe, dot)
+		elem := collection.element()
+		switch len(n.Pipe.Decl) {
+		case 1:
+			c.vars = append(c.vars, contractVar{n.Pipe.Decl[0].Ident[0], elem})
+		case 2:
+			c.vars = append(c.vars,
+				contractVar{n.Pipe.Decl[0].Ident[0], unknownShape},
+				contractVar{n.Pipe.Decl[1].Ident[0], elem})
+		}
+		c.walk(n.List, elem)
+		c.walk(n.ElseList, dot)
+		c.vars = c.vars[:mark]
+	case *parse.TemplateNode:
+		arg := unknownShape
+		if n.Pipe != nil {
+			arg = c.pipe(n.Pipe, dot)
+		}
+		// Templates defined in the same file are checked with the data of
+		// their first invocation.
+		if tree, ok := c.trees[n.Name]; ok && n.Name != c.path && !c.checked[n.Name] {
+			c.checked[n.Name] = true
+			c.checkTree(tree, arg)
+		}
+	}
+}
+
+// declare adds the variables a pipeline declares, or assigns, with value.
+func (c *contractChecker) declare(pipe *parse.PipeNode, value *dataShape) {
+	if pipe == nil || len(pipe.Decl) == 0 {
+		return
+	}
+	if pipe.IsAssign {
+		for i := len(c.vars) - 1; i >= 0; i-- {
+			if c.vars[i].name == pipe.Decl[0].Ident[0] {
+				// The variable may hold another shape from here on.
+				c.vars[i].shape = unknownShape
+				return
+			}
+		}
+		return
+	}
+	c.vars = append(c.vars, contractVar{pipe.Decl[0].Ident[0], value})
+}
+
+// pipe checks a pipeline and returns the shape of its result.
+func (c *contractChecker) pipe(pipe *parse.PipeNode, dot *dataShape) *dataShape {
+	if pipe == nil {
+		return unknownShape
+	}
+	result := unknownShape
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args[1:] {
+			c.node(arg, dot)
+		}
+		// Functions return unknown shapes; fields and methods, including
+		// methods called with arguments, return the shape they resolve to.
+		result = c.node(cmd.Args[0], dot)
+	}
+	return result
+}
+
+// node checks an argument and returns the shape of its value.
+func (c *contractChecker) node(node parse.Node, dot *dataShape) *dataShape {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return c.fields(dot, "", n.Ident, n)
+	case *parse.VariableNode:
+		return c.fields(c.variable(n.Ident[0]), n.Ident[0], n.Ident[1:], n)
+	case *parse.ChainNode:
+		return c.fields(c.node(n.Node, dot), "(...)", n.Field, n)
+	case *parse.PipeNode:
+		mark := len(c.vars)
+		defer func() { c.vars = c.vars[:mark] }()
+		return c.pipe(n, dot)
+	}
+	return unknownShape
+}
+
+func (c *contractChecker) variable(name string) *dataShape {
+	for i := len(c.vars) - 1; i >= 0; i-- {
+		if c.vars[i].name == name {
+			return c.vars[i].shape
+		}
+	}
+	return unknownShape
+}
+
+// fields follows names from shape, reporting the first that does not exist.
+func (c *contractChecker) fields(shape *dataShape, base string, names []string, node parse.Node) *dataShape {
+	for i, name := range names {
+		next, ok := shape.field(name)
+		if !ok {
+			c.report(node, base, names[:i+1], shape)
+			return unknownShape
+		}
+		shape = next
+	}
+	return shape
+}
+
+// report adds an unknown_field error for the last of names, accessed on
+// base, positioned at the dot before it.
+func (c *contractChecker) report(node parse.Node, base string, names []string, shape *dataShape) {
+	name := names[len(names)-1]
+	fields := "." + strings.Join(names, ".")
+	access := base + fields
+
+	// Node positions point into the middle of field chains, so the access
+	// is found in the source near the node instead.
+	offset := int(node.Position())
+	source := access
+	if base == "(...)" {
+		source = fields
+	}
+	if start := strings.LastIndex(c.content[:min(len(c.content), offset+len(source))], source); start >= 0 {
+		offset = start + len(source) - len(name) - 1
+	}
+	line, column := c.position(offset)
+
+	key := fmt.Sprintf("%d:%d:%s", line, column, access)
+	if c.reported[key] {
+		return
+	}
+	c.reported[key] = true
+
+	suggestion := fmt.Sprintf("Check the field name against the fields of %s", shape.name)
+	if match := render.ClosestName(name, shape.names()); match != "" {
+		suggestion = fmt.Sprintf("Did you mean '%s'?", match)
+	}
+	c.result.Valid = false
+	c.result.Errors = append(c.result.Errors, ValidationError{
+		Type:       "unknown_field",
+		Message:    fmt.Sprintf("Field '%s' in '%s' does not exist on %s", name, access, shape.name),
+		File:       c.path,
+		Line:       line,
+		Column:     column,
+		Suggestion: suggestion,
+	})
+}
+
+// position converts a byte offset in the template to a line and column.
+func (c *contractChecker) position(offset int) (int, int) {
+	position := positionAt(c.content, offset, c.tv.tabWidth)
+	return position.Line, position.Column
+}
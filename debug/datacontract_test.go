@@ -0,0 +1,129 @@
+package debug
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"text/template"
+	"time"
+)
+
+type contractUser struct {
+	Name    string
+	Email   *string
+	Created time.Time
+	Tags    []string
+	Manager *contractUser
+	Extra   map[string]any
+}
+
+func (u contractUser) DisplayName() string { return u.Name }
+
+type contractData struct {
+	User  contractUser
+	Users []contractUser
+	Meta  any
+}
+
+func TestTemplateValidator_DataType(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     []string
+	}{
+		{"valid", `{{ .User.Name }} {{ .User.DisplayName }} {{ .User.Created.Year }} {{ .User.Manager.Manager.Email }} {{ .User.Extra.anything.goes }} {{ .Meta.x.y }}`, nil},
+		{"unknown field", "line\n  {{ .Usr.Name }}", []string{"2:6 Field 'Usr' in '.Usr' does not exist on debug.contractData (Did you mean 'User'?)"}},
+		{"nested", `{{ .User.Manager.Nmae }}`, []string{"1:17 Field 'Nmae' in '.User.Manager.Nmae' does not exist on debug.contractUser (Did you mean 'Name'?)"}},
+		{"scalar", `{{ .User.Name.First }}`, []string{"1:14 Field 'First' in '.User.Name.First' does not exist on string (Check the field name against the fields of string)"}},
+		{"range", `{{ range .Users }}{{ .Name }}{{ .Age }}{{ end }}{{ range $i, $u := .Users }}{{ $u.Emial }}{{ end }}`, []string{
+			"1:33 Field 'Age' in '.Age' does not exist on debug.contractUser (Check the field name against the fields of debug.contractUser)",
+			"1:82 Field 'Emial' in '$u.Emial' does not exist on debug.contractUser (Did you mean 'Email'?)",
+		}},
+		{"with and variables", `{{ with .User }}{{ .Tags }}{{ .Tag }}{{ end }}{{ $m := .User.Manager }}{{ $m.Name }}{{ $.Userz }}`, []string{
+			"1:31 Field 'Tag' in '.Tag' does not exist on debug.contractUser (Did you mean 'Tags'?)",
+			"1:89 Field 'Userz' in '$.Userz' does not exist on debug.contractData (Did you mean 'User'?)",
+		}},
+		{"arguments", `{{ printf "%s" .User.Nam | upper }}{{ if eq (.User.Name) .User.Mail }}{{ end }}`, []string{
+			"1:21 Field 'Nam' in '.User.Nam' does not exist on debug.contractUser (Did you mean 'Name'?)",
+			"1:63 Field 'Mail' in '.User.Mail' does not exist on debug.contractUser (Did you mean 'Email'?)",
+		}},
+		{"defined template", `{{ define "user" }}{{ .Nme }}{{ end }}{{ template "user" .User }}`, []string{
+			"1:23 Field 'Nme' in '.Nme' does not exist on debug.contractUser (Did you mean 'Name'?)",
+		}},
+		{"function results are not checked", `{{ (index .Users 0).Anything }}{{ (.User).Nope }}`, []string{
+			"1:42 Field 'Nope' in '(...).Nope' does not exist on debug.contractUser (Check the field name against the fields of debug.contractUser)",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewTemplateValidator(fstest.MapFS{"t.tmpl": {Data: []byte(tt.template)}}, template.FuncMap{"upper": strings.ToUpper}, nil)
+			validator.SetDataType(reflect.TypeFor[contractData]())
+
+			result := validator.ValidateTemplate("t.tmpl")
+			var got []string
+			for _, e := range result.Errors {
+				if e.Type == "unknown_field" {
+					got = append(got, formatContractError(e))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if result.Valid != (len(tt.want) == 0) {
+				t.Errorf("Valid = %v with %d errors", result.Valid, len(got))
+			}
+		})
+	}
+}
+
+func TestTemplateValidator_DataSchema(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"service": {"$ref": "#/$defs/service"},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"anything": {}
+		},
+		"$defs": {
+			"service": {
+				"title": "Service",
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"ports": {"type": "array", "items": {"type": "object", "properties": {"number": {"type": "integer"}}}},
+					"parent": {"$ref": "#/$defs/service"}
+				}
+			}
+		}
+	}`
+	content := `{{ .service.name }}{{ .labels.team }}{{ .anything.at.all }}{{ .service.parent.parent.name }}
+{{ range .service.ports }}{{ .number }}{{ .numbr }}{{ end }}{{ .servce }}`
+
+	validator := NewTemplateValidator(fstest.MapFS{"t.tmpl": {Data: []byte(content)}}, nil, nil)
+	if err := validator.SetDataSchema([]byte(schema)); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range validator.ValidateTemplate("t.tmpl").Errors {
+		got = append(got, formatContractError(e))
+	}
+	want := []string{
+		"2:43 Field 'numbr' in '.numbr' does not exist on object (Did you mean 'number'?)",
+		"2:64 Field 'servce' in '.servce' does not exist on object (Did you mean 'service'?)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := validator.SetDataSchema([]byte(`{"properties": {"a": {"$ref": "other.json#/x"}}}`)); err == nil || !strings.Contains(err.Error(), "only local references are supported") {
+		t.Errorf("expected reference error, got %v", err)
+	}
+}
+
+func formatContractError(e ValidationError) string {
+	return fmt.Sprintf("%d:%d %s (%s)", e.Line, e.Column, e.Message, e.Suggestion)
+}
@@ -237,7 +237,7 @@ func testTemplateValidationErrors(t *testing.T) {
 			name:           "deep variable access",
 			templatePath:   "deep_access.tmpl",
 			expectValid:    true, // Warning, not error
-			expectWarnings: 2,    // Deep access + potentially unknown function warnings
+			expectWarnings: 1,    // Deep access
 		},
 		{
 			name:         "malformed template",
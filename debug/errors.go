@@ -60,6 +60,7 @@ func GetConfig() Config {
 
 type ErrorContext struct {
 	Operation    string         `json:"operation"`
+	RunID        string         `json:"run_id,omitempty"`
 	TemplatePath string         `json:"template_path,omitempty"`
 	OutputPath   string         `json:"output_path,omitempty"`
 	LineNumber   int            `json:"line_number,omitempty"`
@@ -160,6 +161,13 @@ func (ee *EnhancedError) WithTemplate(path string) *EnhancedError {
 	return ee
 }
 
+// WithRunID records the engine run the error belongs to, so it can be
+// correlated with that run's logs and generated files.
+func (ee *EnhancedError) WithRunID(id string) *EnhancedError {
+	ee.context.RunID = id
+	return ee
+}
+
 func (ee *EnhancedError) WithOutput(path string) *EnhancedError {
 	ee.context.OutputPath = path
 	return ee
@@ -208,6 +216,9 @@ func (ee *EnhancedError) FormatDetailed() string {
 func (ee *EnhancedError) writeBasicInfo(builder *strings.Builder) {
 	builder.WriteString(fmt.Sprintf("Error: %s\n", ee.originalError.Error()))
 	builder.WriteString(fmt.Sprintf("Operation: %s\n", ee.context.Operation))
+	if ee.context.RunID != "" {
+		builder.WriteString(fmt.Sprintf("Run: %s\n", ee.context.RunID))
+	}
 	builder.WriteString(fmt.Sprintf("Timestamp: %s\n", ee.context.Timestamp.Format(time.RFC3339)))
 }
 
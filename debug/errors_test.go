@@ -122,6 +122,18 @@ func TestEnhancedError_WithOutput(t *testing.T) {
 	}
 }
 
+func TestEnhancedError_WithRunID(t *testing.T) {
+	ee := NewEnhancedError(errors.New("test"), "operation").WithRunID("run-123")
+
+	if ee.context.RunID != "run-123" {
+		t.Errorf("Expected run ID run-123, got %s", ee.context.RunID)
+	}
+
+	if !strings.Contains(ee.FormatDetailed(), "Run: run-123") {
+		t.Error("Expected detailed format to include the run ID")
+	}
+}
+
 func TestEnhancedError_WithLine(t *testing.T) {
 	ee := NewEnhancedError(errors.New("test"), "operation")
 	lineNumber := 42
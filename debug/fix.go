@@ -0,0 +1,251 @@
+package debug
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// Fix is a change ValidateAndFix made to a template. Type is the type of
+// the finding it fixes.
+type Fix struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// WriteFS is a filesystem ValidateAndFix can write fixed templates to.
+type WriteFS interface {
+	fs.FS
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+}
+
+// DirFS returns a WriteFS for the tree of files rooted at dir, which reads
+// files as os.DirFS does.
+func DirFS(dir string) WriteFS {
+	return dirFS{FS: os.DirFS(dir), dir: dir}
+}
+
+type dirFS struct {
+	fs.FS
+	dir string
+}
+
+func (d dirFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.WriteFile(filepath.Join(d.dir, filepath.FromSlash(name)), data, perm)
+}
+
+// SetFunctionReplacements sets the functions templates should no longer
+// call, mapped to the function that replaces each one or to "" if none
+// does, as render.FunctionRegistry.Deprecated returns them. Calls to them
+// are reported as deprecated_function findings, and ValidateAndFix renames
+// those that have a replacement.
+func (tv *TemplateValidator) SetFunctionReplacements(replacements map[string]string) {
+	tv.replacements = replacements
+}
+
+// ValidateAndFix fixes the findings of the template at templatePath that
+// can be fixed mechanically, writes it back if anything changed and then
+// validates it. It fixes:
+//
+//   - calls to deprecated functions that have a replacement, renaming them
+//   - actions missing one of their braces, such as "{{ .Name }" or
+//     "{ .Name }}", when that balances every brace in the template
+//   - in strict mode, trailing whitespace and a missing final newline
+//
+// Nothing is fixed for rules that are off or where ignore directives cover
+// the rule. The validator's filesystem must be a WriteFS, such as one
+// returned by DirFS.
+func (tv *TemplateValidator) ValidateAndFix(templatePath string) (ValidationResult, []Fix, error) {
+	writable, ok := tv.fs.(WriteFS)
+	if !ok {
+		return ValidationResult{}, nil, fmt.Errorf("failed to fix template %s: template filesystem is read-only", templatePath)
+	}
+	if !isSecurePath(templatePath) {
+		return tv.ValidateTemplate(templatePath), nil, nil
+	}
+	info, err := fs.Stat(tv.fs, templatePath)
+	if err != nil {
+		return ValidationResult{}, nil, fmt.Errorf("failed to fix template %s: %w", templatePath, err)
+	}
+	content, err := fs.ReadFile(tv.fs, templatePath)
+	if err != nil {
+		return ValidationResult{}, nil, fmt.Errorf("failed to fix template %s: %w", templatePath, err)
+	}
+
+	original := string(content)
+	fixed, fixes := tv.fixContent(templatePath, original)
+	if fixed != original {
+		if err := writable.WriteFile(templatePath, []byte(fixed), info.Mode().Perm()); err != nil {
+			return ValidationResult{}, nil, fmt.Errorf("failed to write fixed template %s: %w", templatePath, err)
+		}
+	}
+	return tv.ValidateTemplate(templatePath), fixes, nil
+}
+
+// fixContent applies each fix in turn to content, returning the fixed
+// content and the fixes made.
+func (tv *TemplateValidator) fixContent(templatePath, content string) (string, []Fix) {
+	directives := parseIgnoreDirectives(content)
+	fixable := func(findingType string, line int) bool {
+		if tv.severity(findingType, tv.defaultSeverity(findingType)) == SeverityOff {
+			return false
+		}
+		return !directives.ignores(ValidationError{Type: findingType, Line: line})
+	}
+
+	var fixes []Fix
+	for _, fix := range []func(string, string, func(string, int) bool) (string, []Fix){
+		tv.fixDeprecatedFunctions,
+		tv.fixBraces,
+		tv.fixWhitespace,
+	} {
+		var made []Fix
+		content, made = fix(templatePath, content, fixable)
+		fixes = append(fixes, made...)
+	}
+	return content, fixes
+}
+
+// defaultSeverity returns the default severity of the rule with the given
+// ID, or SeverityOff if there is none.
+func (tv *TemplateValidator) defaultSeverity(id string) Severity {
+	for _, rule := range tv.rules {
+		if rule.ID() == id {
+			return rule.Severity()
+		}
+	}
+	return SeverityOff
+}
+
+// fixDeprecatedFunctions renames the calls to deprecated functions that
+// have a replacement.
+func (tv *TemplateValidator) fixDeprecatedFunctions(templatePath, content string, fixable func(string, int) bool) (string, []Fix) {
+	file := tv.parseFile(templatePath, content)
+	type rename struct {
+		offset   int
+		from, to string
+	}
+	var renames []rename
+	file.walk(func(node parse.Node) bool {
+		ident, ok := node.(*parse.IdentifierNode)
+		if !ok || tv.replacements[ident.Ident] == "" {
+			return true
+		}
+		offset := int(ident.Pos)
+		line, _ := file.Position(ident.Pos)
+		if strings.HasPrefix(content[offset:], ident.Ident) && fixable("deprecated_function", line) {
+			renames = append(renames, rename{offset, ident.Ident, tv.replacements[ident.Ident]})
+		}
+		return true
+	})
+	sort.Slice(renames, func(i, j int) bool { return renames[i].offset < renames[j].offset })
+
+	var fixes []Fix
+	var b strings.Builder
+	last := 0
+	for _, r := range renames {
+		b.WriteString(content[last:r.offset])
+		b.WriteString(r.to)
+		last = r.offset + len(r.from)
+		line, _ := file.Position(parse.Pos(r.offset))
+		fixes = append(fixes, Fix{
+			Type:    "deprecated_function",
+			Message: fmt.Sprintf("Replaced deprecated function '%s' with '%s'", r.from, r.to),
+			File:    templatePath,
+			Line:    line,
+		})
+	}
+	b.WriteString(content[last:])
+	return b.String(), fixes
+}
+
+var (
+	// missingCloseBrace matches an action closed by a single brace.
+	missingCloseBrace = regexp.MustCompile(`(^|[^{])(\{\{[^{}]*\})([^}]|$)`)
+	// missingOpenBrace matches an action opened by a single brace.
+	missingOpenBrace = regexp.MustCompile(`(^|[^{])(\{[^{}]+\}\})`)
+)
+
+// fixBraces adds the brace missing from actions such as "{{ .Name }" and
+// "{ .Name }}". Nothing is changed unless the template has unbalanced
+// braces and the fixes balance all of them, as a single brace is often
+// output.
+func (tv *TemplateValidator) fixBraces(templatePath, content string, fixable func(string, int) bool) (string, []Fix) {
+	var before ValidationResult
+	tv.validateBraceBalance(templatePath, content, &before)
+	if len(before.Errors) == 0 {
+		return content, nil
+	}
+
+	var fixes []Fix
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if !fixable("brace_mismatch", i+1) {
+			continue
+		}
+		fixed := missingCloseBrace.ReplaceAllString(line, "$1$2}$3")
+		fixed = missingOpenBrace.ReplaceAllString(fixed, "$1{$2")
+		if fixed != line {
+			lines[i] = fixed
+			fixes = append(fixes, Fix{
+				Type:    "brace_mismatch",
+				Message: "Added the missing brace of an action",
+				File:    templatePath,
+				Line:    i + 1,
+			})
+		}
+	}
+	fixed := strings.Join(lines, "\n")
+
+	var after ValidationResult
+	tv.validateBraceBalance(templatePath, fixed, &after)
+	if len(after.Errors) > 0 {
+		return content, nil
+	}
+	return fixed, fixes
+}
+
+// fixWhitespace removes trailing whitespace and ends the template with a
+// single newline, in strict mode.
+func (tv *TemplateValidator) fixWhitespace(templatePath, content string, fixable func(string, int) bool) (string, []Fix) {
+	if !tv.strict || content == "" {
+		return content, nil
+	}
+
+	var fixes []Fix
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed != line && fixable("whitespace_warning", i+1) {
+			lines[i] = trimmed
+			fixes = append(fixes, Fix{
+				Type:    "whitespace_warning",
+				Message: "Removed trailing whitespace",
+				File:    templatePath,
+				Line:    i + 1,
+			})
+		}
+	}
+	content = strings.Join(lines, "\n")
+
+	if !strings.HasSuffix(content, "\n") && fixable("whitespace_warning", len(lines)) {
+		content += "\n"
+		fixes = append(fixes, Fix{
+			Type:    "whitespace_warning",
+			Message: "Added a final newline",
+			File:    templatePath,
+			Line:    len(lines),
+		})
+	}
+	return content, fixes
+}
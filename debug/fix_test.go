@@ -0,0 +1,152 @@
+package debug
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"text/template"
+)
+
+func TestTemplateValidator_ValidateAndFix(t *testing.T) {
+	funcMap := template.FuncMap{"snake": strings.ToLower, "toSnake": strings.ToLower, "old": strings.ToLower}
+	tests := []struct {
+		name      string
+		content   string
+		strict    bool
+		setup     func(*TemplateValidator)
+		want      string
+		wantFixes []string
+	}{
+		{
+			name:      "trailing whitespace and final newline",
+			content:   "a  \n{{ .Name }}\t\nb",
+			strict:    true,
+			want:      "a\n{{ .Name }}\nb\n",
+			wantFixes: []string{"1 Removed trailing whitespace", "2 Removed trailing whitespace", "3 Added a final newline"},
+		},
+		{
+			name:    "whitespace outside strict mode",
+			content: "a  \nb",
+			want:    "a  \nb",
+		},
+		{
+			name:      "missing closing brace",
+			content:   "{{ .Name }\n{{ .Type }}",
+			want:      "{{ .Name }}\n{{ .Type }}",
+			wantFixes: []string{"1 Added the missing brace of an action"},
+		},
+		{
+			name:      "missing opening brace",
+			content:   "x := { .Name }}\n",
+			want:      "x := {{ .Name }}\n",
+			wantFixes: []string{"1 Added the missing brace of an action"},
+		},
+		{
+			name:    "braces in output",
+			content: "func() { return {{ .Name }} }\n",
+			want:    "func() { return {{ .Name }} }\n",
+		},
+		{
+			name:    "braces the fix cannot balance",
+			content: "{{ .Name }\n{{ if .A }\n{{\n",
+			want:    "{{ .Name }\n{{ if .A }\n{{\n",
+		},
+		{
+			name:    "deprecated functions",
+			content: "{{ toSnake .Name }} {{ .Type | toSnake | old }}\n",
+			setup: func(tv *TemplateValidator) {
+				tv.SetFunctionReplacements(map[string]string{"toSnake": "snake", "old": ""})
+			},
+			want:      "{{ snake .Name }} {{ .Type | snake | old }}\n",
+			wantFixes: []string{"1 Replaced deprecated function 'toSnake' with 'snake'", "1 Replaced deprecated function 'toSnake' with 'snake'"},
+		},
+		{
+			name:    "rule off",
+			content: "{{ toSnake .Name }}\n",
+			setup: func(tv *TemplateValidator) {
+				tv.SetFunctionReplacements(map[string]string{"toSnake": "snake"})
+				tv.SetRuleSeverity("deprecated_function", SeverityOff)
+			},
+			want: "{{ toSnake .Name }}\n",
+		},
+		{
+			name:      "ignore directives",
+			content:   "{{/* weft:ignore whitespace_warning */}}\na \nb \n",
+			strict:    true,
+			want:      "{{/* weft:ignore whitespace_warning */}}\na \nb\n",
+			wantFixes: []string{"3 Removed trailing whitespace"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "t.tmpl")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			validator := NewTemplateValidator(DirFS(dir), funcMap, nil)
+			validator.SetStrict(tt.strict)
+			if tt.setup != nil {
+				tt.setup(validator)
+			}
+
+			result, fixes, err := validator.ValidateAndFix("t.tmpl")
+			if err != nil {
+				t.Fatalf("ValidateAndFix() error = %v", err)
+			}
+			content, _ := os.ReadFile(path)
+			if string(content) != tt.want {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+			got := []string{}
+			for _, fix := range fixes {
+				if fix.File != "t.tmpl" {
+					t.Errorf("fix file = %q", fix.File)
+				}
+				got = append(got, fmt.Sprintf("%d %s", fix.Line, fix.Message))
+			}
+			if tt.wantFixes == nil {
+				tt.wantFixes = []string{}
+			}
+			if !reflect.DeepEqual(got, tt.wantFixes) {
+				t.Errorf("fixes = %q, want %q", got, tt.wantFixes)
+			}
+			for _, e := range result.Errors {
+				if e.Type == "brace_mismatch" && len(fixes) > 0 {
+					t.Errorf("brace mismatch left after fixing: %+v", e)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateValidator_ValidateAndFixReadOnly(t *testing.T) {
+	validator := NewTemplateValidator(fstest.MapFS{"t.tmpl": {Data: []byte("a \n")}}, nil, nil)
+	validator.SetStrict(true)
+	if _, _, err := validator.ValidateAndFix("t.tmpl"); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("expected a read-only error, got %v", err)
+	}
+}
+
+func TestTemplateValidator_DeprecatedFunctions(t *testing.T) {
+	content := "{{ toSnake .Name }}\n{{ old .Type }}"
+	validator := NewTemplateValidator(fstest.MapFS{"t.tmpl": {Data: []byte(content)}}, nil, nil)
+	validator.SetFunctionReplacements(map[string]string{"toSnake": "snake", "old": ""})
+
+	result := validator.ValidateTemplate("t.tmpl")
+	if len(result.Warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %+v", result.Warnings)
+	}
+	first, second := result.Warnings[0], result.Warnings[1]
+	if first.Type != "deprecated_function" || first.Line != 1 || first.Column != 4 || first.Suggestion != "Use 'snake' instead" {
+		t.Errorf("unexpected warning %+v", first)
+	}
+	if second.Line != 2 || second.Message != "Function 'old' is deprecated" {
+		t.Errorf("unexpected warning %+v", second)
+	}
+}
@@ -0,0 +1,133 @@
+package debug
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// templateEdge is a partial call, include or layout from one template file
+// to another.
+type templateEdge struct {
+	to     string
+	kind   string
+	offset int
+}
+
+// templateGraph resolves the edges between template files, reading each
+// file once.
+type templateGraph struct {
+	tv    *TemplateValidator
+	edges map[string][]templateEdge
+}
+
+func (tv *TemplateValidator) newTemplateGraph() *templateGraph {
+	return &templateGraph{tv: tv, edges: make(map[string][]templateEdge)}
+}
+
+// edgesOf returns the resolved references of the template at templatePath
+// with the given content. Calls to templates the file or its macros define
+// do not use a partial and are left out.
+func (g *templateGraph) edgesOf(templatePath, content string) []templateEdge {
+	if edges, ok := g.edges[templatePath]; ok {
+		return edges
+	}
+	defined := g.tv.definedTemplates(templatePath, content)
+	edges := []templateEdge{}
+	for _, reference := range g.tv.references(templatePath, content) {
+		kind, name, _ := strings.Cut(reference.name, ":")
+		if reference.path == "" || kind == "template" && defined[name] {
+			continue
+		}
+		edges = append(edges, templateEdge{to: reference.path, kind: kind, offset: reference.offset})
+	}
+	g.edges[templatePath] = edges
+	return edges
+}
+
+// edgesFrom is edgesOf for a file read from the validator's filesystem.
+func (g *templateGraph) edgesFrom(templatePath string) []templateEdge {
+	if edges, ok := g.edges[templatePath]; ok {
+		return edges
+	}
+	content, err := fs.ReadFile(g.tv.fs, templatePath)
+	if err != nil {
+		g.edges[templatePath] = nil
+		return nil
+	}
+	return g.edgesOf(templatePath, string(content))
+}
+
+// cycleFrom returns the shortest chain of references that starts with edge
+// out of templatePath and leads back to it, as the files along it, starting
+// and ending with templatePath, and whether every reference is a partial
+// call. It returns nil if edge does not lead back.
+func (g *templateGraph) cycleFrom(templatePath string, edge templateEdge) ([]string, bool) {
+	if edge.to == templatePath {
+		return []string{templatePath, templatePath}, edge.kind == "template"
+	}
+
+	type step struct {
+		from    string
+		partial bool
+	}
+	reached := map[string]step{edge.to: {templatePath, edge.kind == "template"}}
+	queue := []string{edge.to}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range g.edgesFrom(current) {
+			if next.to == templatePath {
+				cycle := []string{templatePath}
+				partial := next.kind == "template"
+				for node := current; node != templatePath; node = reached[node].from {
+					cycle = append(cycle, node)
+					partial = partial && reached[node].partial
+				}
+				cycle = append(cycle, templatePath)
+				slices.Reverse(cycle)
+				return cycle, partial
+			}
+			if _, ok := reached[next.to]; !ok {
+				reached[next.to] = step{current, next.kind == "template"}
+				queue = append(queue, next.to)
+			}
+		}
+	}
+	return nil, false
+}
+
+// validateCycles reports the chains of references that lead from file back
+// to itself: those made only of partial calls when partials is set, and
+// those with an include or layout otherwise.
+func (tv *TemplateValidator) validateCycles(file *TemplateFile, partials bool) []ValidationError {
+	graph := tv.newTemplateGraph()
+	var findings []ValidationError
+	reported := make(map[string]bool)
+	for _, edge := range graph.edgesOf(file.Path, file.Content) {
+		cycle, partial := graph.cycleFrom(file.Path, edge)
+		if cycle == nil || partial != partials {
+			continue
+		}
+		chain := strings.Join(cycle, " -> ")
+		if reported[chain] {
+			continue
+		}
+		reported[chain] = true
+
+		position := positionAt(file.Content, edge.offset, tv.tabWidth)
+		finding := ValidationError{
+			Message:    fmt.Sprintf("Circular include: %s", chain),
+			Line:       position.Line,
+			Column:     position.Column,
+			Suggestion: "Break the cycle; a file cannot include or extend itself, directly or through other files",
+		}
+		if partials {
+			finding.Message = fmt.Sprintf("Recursive partial: %s", chain)
+			finding.Suggestion = "Make sure the recursion ends, for example by calling the template inside an if"
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
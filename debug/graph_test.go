@@ -0,0 +1,73 @@
+package debug
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"text/template"
+)
+
+func TestTemplateValidator_Cycles(t *testing.T) {
+	testFS := fstest.MapFS{
+		"a.tmpl":                 {Data: []byte("A\n  {{ include \"b\" . }}")},
+		"b.tmpl":                 {Data: []byte("{{ include \"a\" . }}")},
+		"self.tmpl":              {Data: []byte("{{ include \"self\" . }}")},
+		"templates/main.tmpl":    {Data: []byte("{{ template \"tree\" . }}{{ template \"item\" . }}")},
+		"templates/_tree.tmpl":   {Data: []byte("{{ range .Children }}{{ template \"tree\" . }}{{ end }}")},
+		"templates/_item.tmpl":   {Data: []byte("{{ include \"templates/detail\" . }}")},
+		"templates/detail.tmpl":  {Data: []byte("{{ template \"item\" . }}")},
+		"templates/child.tmpl":   {Data: []byte("{{ extends \"base.tmpl\" }}")},
+		"templates/_base.tmpl":   {Data: []byte("{{ extends \"child.tmpl\" }}")},
+		"templates/_define.tmpl": {Data: []byte("{{ define \"row\" }}{{ template \"row\" . }}{{ end }}{{ template \"row\" . }}")},
+	}
+	validator := NewTemplateValidator(testFS, nil, nil)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"a.tmpl", []string{"circular_include 2:3 Circular include: a.tmpl -> b.tmpl -> a.tmpl"}},
+		{"self.tmpl", []string{"circular_include 1:1 Circular include: self.tmpl -> self.tmpl"}},
+		{"templates/main.tmpl", nil},
+		{"templates/_tree.tmpl", []string{"recursive_partial 1:22 Recursive partial: templates/_tree.tmpl -> templates/_tree.tmpl"}},
+		{"templates/_item.tmpl", []string{"circular_include 1:1 Circular include: templates/_item.tmpl -> templates/detail.tmpl -> templates/_item.tmpl"}},
+		{"templates/child.tmpl", []string{"circular_include 1:1 Circular include: templates/child.tmpl -> templates/_base.tmpl -> templates/child.tmpl"}},
+		{"templates/_define.tmpl", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := validator.ValidateTemplate(tt.path)
+			var got []string
+			for _, finding := range append(result.Errors, result.Warnings...) {
+				if finding.Type == "circular_include" || finding.Type == "recursive_partial" {
+					got = append(got, fmt.Sprintf("%s %d:%d %s", finding.Type, finding.Line, finding.Column, finding.Message))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if result := validator.ValidateTemplate("templates/_tree.tmpl"); !result.Valid || len(result.Warnings) != 1 {
+		t.Errorf("expected recursive partials to be a warning, got %+v", result)
+	}
+}
+
+func TestIncrementalValidator_TransitiveCycle(t *testing.T) {
+	testFS := fstest.MapFS{
+		"a.tmpl": {Data: []byte("{{ include \"b\" . }}")},
+		"b.tmpl": {Data: []byte("{{ include \"c\" . }}")},
+		"c.tmpl": {Data: []byte("c")},
+	}
+	iv := NewIncrementalValidator(NewTemplateValidator(testFS, template.FuncMap{"include": func(string, any) string { return "" }}, nil))
+	if result := iv.ValidateTemplate("a.tmpl"); !result.Valid {
+		t.Fatalf("expected a.tmpl to be valid, got %+v", result)
+	}
+
+	testFS["c.tmpl"] = &fstest.MapFile{Data: []byte("{{ include \"a\" . }}")}
+	if result := iv.ValidateTemplate("a.tmpl"); result.Valid {
+		t.Error("expected a change two includes away to invalidate the cached result")
+	}
+}
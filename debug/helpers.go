@@ -98,6 +98,9 @@ var sensitiveFieldPatterns = []string{
 
 // filterSensitiveData removes or masks sensitive data from debug output
 func filterSensitiveData(key string, value any) any {
+	if str, ok := value.(string); ok && IsSecret(str) {
+		return "[REDACTED]"
+	}
 	if key == "" || value == nil {
 		return value
 	}
@@ -155,7 +158,7 @@ func containsSensitiveFields(v reflect.Value) bool {
 			return true
 		}
 	}
-	return false
+	return hasSecrets() && containsSecret(v)
 }
 
 // sanitizeMapForDebug creates a sanitized copy of a map for debug output
@@ -238,6 +241,12 @@ func sanitizeValueForDebug(value any) any {
 		}
 		return sanitizeValueForDebug(v.Elem().Interface())
 
+	case reflect.String:
+		if IsSecret(v.String()) {
+			return "[REDACTED]"
+		}
+		return value
+
 	default:
 		return value
 	}
@@ -322,6 +331,9 @@ func formatValueByKind(v reflect.Value, value any, debugMode *DebugMode) string
 }
 
 func formatStringValue(v reflect.Value) string {
+	if IsSecret(v.String()) {
+		return "string: [REDACTED]"
+	}
 	return fmt.Sprintf("string(%d): %q", len(v.String()), v.String())
 }
 
@@ -342,10 +354,16 @@ func formatBoolValue(v reflect.Value) string {
 }
 
 func formatSliceArrayValue(v reflect.Value, value any) string {
+	if hasSecrets() && containsSecret(v) {
+		value = sanitizeValueForDebug(value)
+	}
 	return fmt.Sprintf("%s[%d]: %v", v.Type(), v.Len(), value)
 }
 
 func formatMapValue(v reflect.Value, value any) string {
+	if hasSecrets() && containsSecret(v) {
+		value = sanitizeValueForDebug(value)
+	}
 	return fmt.Sprintf("%s{%d keys}: %v", v.Type(), v.Len(), value)
 }
 
@@ -583,10 +601,12 @@ func debugContext(debugMode *DebugMode) func() map[string]any {
 }
 
 type TemplateDebugger struct {
-	debugMode  *DebugMode
-	templates  map[string]*template.Template
-	executions []TemplateExecution
-	mu         sync.RWMutex
+	debugMode   *DebugMode
+	templates   map[string]*template.Template
+	executions  []TemplateExecution
+	breakpoints []Breakpoint
+	onBreak     func(*Frame) StepAction
+	mu          sync.RWMutex
 }
 
 type TemplateExecution struct {
@@ -596,6 +616,8 @@ type TemplateExecution struct {
 	Data      map[string]any `json:"data"`
 	Error     string         `json:"error,omitempty"`
 	Output    string         `json:"output,omitempty"`
+	// Trace is the time spent in each action, recorded at trace level.
+	Trace *TraceNode `json:"trace,omitempty"`
 }
 
 func NewTemplateDebugger(debugMode *DebugMode) *TemplateDebugger {
@@ -616,8 +638,24 @@ func (td *TemplateDebugger) ExecuteWithDebug(name string, tmpl *template.Templat
 	// Generate cache key for potential caching (only for read-only operations)
 	cacheKey := fmt.Sprintf("%s:%p", name, data)
 
-	// Check cache for identical executions (optional optimization for read-only templates)
-	if cached := td.checkExecutionCache(cacheKey); cached != nil {
+	// Executions that can pause at breakpoints or are traced always run
+	session := td.breakSession()
+	var trace *traceSession
+	if session != nil {
+		instrumented, err := session.instrument(tmpl)
+		if err != nil {
+			return "", fmt.Errorf("failed to set breakpoints in template %s: %w", name, err)
+		}
+		tmpl = instrumented
+	} else if td.debugMode.IsEnabled(LevelTrace) {
+		trace = newTraceSession(name)
+		instrumented, err := trace.instrument(tmpl)
+		if err != nil {
+			return "", fmt.Errorf("failed to trace template %s: %w", name, err)
+		}
+		tmpl = instrumented
+	} else if cached := td.checkExecutionCache(cacheKey); cached != nil {
+		// Check cache for identical executions (optional optimization for read-only templates)
 		return cached.result, nil
 	}
 
@@ -641,10 +679,20 @@ func (td *TemplateDebugger) ExecuteWithDebug(name string, tmpl *template.Templat
 	}
 
 	var output strings.Builder
-	err := tmpl.Execute(&output, data)
+	var err error
+	if trace != nil {
+		execution.Trace, err = trace.execute(&output, tmpl, data)
+	} else {
+		err = tmpl.Execute(&output, data)
+	}
 
 	execution.Duration = time.Since(startTime)
 	execution.Output = output.String()
+	if execution.Trace != nil {
+		td.debugMode.Trace("template trace",
+			"name", name,
+			"trace", execution.Trace.String())
+	}
 
 	if err != nil {
 		execution.Error = err.Error()
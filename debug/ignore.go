@@ -0,0 +1,81 @@
+package debug
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ignorePattern matches weft:ignore and weft:ignore-file comments, with the
+// finding types they name.
+var ignorePattern = regexp.MustCompile(`{{-?\s*/\*\s*weft:(ignore-file|ignore)\b((?:[^*]|\*[^/])*)\*/\s*-?}}`)
+
+// ignoreDirectives are the findings a template asks not to be reported.
+// Each set of types holds the types ignored, or is empty to ignore every
+// type.
+type ignoreDirectives struct {
+	file  []map[string]bool
+	lines map[int][]map[string]bool
+}
+
+// parseIgnoreDirectives finds the ignore comments in content.
+//
+//	{{/* weft:ignore unknown_function */}}          ignores the next line
+//	{{/* weft:ignore-file deep_access, no_env */}}  ignores the whole file
+func parseIgnoreDirectives(content string) ignoreDirectives {
+	directives := ignoreDirectives{lines: make(map[int][]map[string]bool)}
+	for _, match := range ignorePattern.FindAllStringSubmatchIndex(content, -1) {
+		types := make(map[string]bool)
+		for _, name := range strings.FieldsFunc(content[match[4]:match[5]], func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		}) {
+			types[name] = true
+		}
+		if content[match[2]:match[3]] == "ignore-file" {
+			directives.file = append(directives.file, types)
+			continue
+		}
+		next := strings.Count(content[:match[1]], "\n") + 2
+		directives.lines[next] = append(directives.lines[next], types)
+	}
+	return directives
+}
+
+func (d ignoreDirectives) empty() bool {
+	return len(d.file) == 0 && len(d.lines) == 0
+}
+
+// ignores reports whether a directive covers the finding. Next-line
+// directives only cover findings with a line.
+func (d ignoreDirectives) ignores(finding ValidationError) bool {
+	matches := func(sets []map[string]bool) bool {
+		for _, types := range sets {
+			if len(types) == 0 || types[finding.Type] {
+				return true
+			}
+		}
+		return false
+	}
+	return matches(d.file) || (finding.Line > 0 && matches(d.lines[finding.Line]))
+}
+
+// applyIgnoreDirectives drops the findings for templatePath that content's
+// ignore comments cover.
+func applyIgnoreDirectives(templatePath, content string, result *ValidationResult) {
+	directives := parseIgnoreDirectives(content)
+	if directives.empty() {
+		return
+	}
+	keep := func(findings []ValidationError) []ValidationError {
+		kept := findings[:0]
+		for _, finding := range findings {
+			if (finding.File == "" || finding.File == templatePath) && directives.ignores(finding) {
+				continue
+			}
+			kept = append(kept, finding)
+		}
+		return kept
+	}
+	result.Errors = keep(result.Errors)
+	result.Warnings = keep(result.Warnings)
+	result.Valid = len(result.Errors) == 0
+}
@@ -0,0 +1,76 @@
+package debug
+
+import (
+	"testing"
+	"testing/fstest"
+	"text/template"
+)
+
+func TestTemplateValidator_IgnoreDirectives(t *testing.T) {
+	funcMap := template.FuncMap{"upper": func(s string) string { return s }}
+	tests := []struct {
+		name    string
+		content string
+		funcMap template.FuncMap
+		want    string
+	}{
+		{
+			name:    "no directives",
+			content: "{{ lower .A }}\n{{ upper .A }} }}",
+			funcMap: funcMap,
+			want:    "errors=[syntax_error brace_mismatch] warnings=[unknown_function]",
+		},
+		{
+			name:    "next line",
+			content: "{{/* weft:ignore unknown_function */}}\n{{ camel .A }}\n{{ snake .A }}",
+			funcMap: funcMap,
+			want:    "errors=[syntax_error] warnings=[unknown_function]",
+		},
+		{
+			name:    "next line other type",
+			content: "{{/* weft:ignore deep_access */}}\n{{ camel .A }}",
+			funcMap: funcMap,
+			want:    "errors=[syntax_error] warnings=[unknown_function]",
+		},
+		{
+			name:    "next line all types",
+			content: "{{ .A.B.C.D.E.F.G }}\n{{- /* weft:ignore */ -}}\n{{ .A.B.C.D.E.F.G }} }}",
+			want:    "errors=[] warnings=[deep_access]",
+		},
+		{
+			name:    "file",
+			content: "{{ .A.B.C.D.E.F.G }}\n{{/* weft:ignore-file deep_access, whitespace_warning */}}\n{{ .A.B.C.D.E.F.G }}",
+			want:    "errors=[] warnings=[]",
+		},
+		{
+			name:    "file errors",
+			content: "{{/* weft:ignore-file brace_mismatch */}}\n{{ upper .A }} }}",
+			funcMap: funcMap,
+			want:    "errors=[] warnings=[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewTemplateValidator(fstest.MapFS{"t.tmpl": {Data: []byte(tt.content)}}, tt.funcMap, nil)
+			result := validator.ValidateTemplate("t.tmpl")
+			if got := findingTypes(result); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if result.Valid != (len(result.Errors) == 0) {
+				t.Errorf("Valid = %v with errors %v", result.Valid, result.Errors)
+			}
+		})
+	}
+}
+
+func TestTemplateValidator_IgnoreDirectivesCustomRule(t *testing.T) {
+	content := "{{/* weft:ignore no_env */}}\n{{ env \"CI\" }}\n{{ env \"HOME\" }}"
+	validator := NewTemplateValidator(fstest.MapFS{"t.tmpl": {Data: []byte(content)}}, template.FuncMap{"env": func(string) string { return "" }}, nil)
+	validator.AddRule(noEnvRule)
+
+	result := validator.ValidateTemplate("t.tmpl")
+	if len(result.Errors) != 1 || result.Errors[0].Line != 3 {
+		t.Errorf("expected only the finding on line 3, got %+v", result.Errors)
+	}
+}
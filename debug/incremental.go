@@ -0,0 +1,316 @@
+package debug
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// IncrementalValidator wraps a TemplateValidator with a result cache for
+// watch mode and editor integrations. Each result is keyed by a hash of the
+// template's content, the content of the partials and includes it references,
+// and the validator's settings, so only changed templates and the templates
+// that depend on them are validated again.
+type IncrementalValidator struct {
+	validator *TemplateValidator
+
+	mu         sync.Mutex
+	entries    map[string]cachedValidation
+	dependents map[string]map[string]bool
+	hits       int64
+	misses     int64
+}
+
+type cachedValidation struct {
+	key          string
+	dependencies []string
+	unresolved   bool
+	result       ValidationResult
+}
+
+// IncrementalStats reports how effective the validation cache has been.
+type IncrementalStats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	// Parsed counts the template files parsed to find their references,
+	// which happens again only when a file changes.
+	Parsed int64 `json:"parsed"`
+}
+
+func NewIncrementalValidator(validator *TemplateValidator) *IncrementalValidator {
+	if validator.summaries == nil {
+		validator.summaries = newSummaryCache()
+	}
+	return &IncrementalValidator{
+		validator:  validator,
+		entries:    make(map[string]cachedValidation),
+		dependents: make(map[string]map[string]bool),
+	}
+}
+
+// ValidateTemplate returns the cached result for templatePath when neither
+// it nor its dependencies have changed, and validates it otherwise.
+func (iv *IncrementalValidator) ValidateTemplate(templatePath string) ValidationResult {
+	key, dependencies, unresolved, err := iv.cacheKey(templatePath)
+	if err != nil {
+		// Unreadable templates are never cached so the error is reported fresh.
+		iv.forget(templatePath)
+		return iv.validator.ValidateTemplate(templatePath)
+	}
+
+	iv.mu.Lock()
+	if entry, ok := iv.entries[templatePath]; ok && entry.key == key {
+		iv.hits++
+		iv.mu.Unlock()
+		return entry.result
+	}
+	iv.misses++
+	iv.mu.Unlock()
+
+	result := iv.validator.ValidateTemplate(templatePath)
+
+	iv.mu.Lock()
+	defer iv.mu.Unlock()
+	iv.unlinkLocked(templatePath)
+	iv.entries[templatePath] = cachedValidation{
+		key:          key,
+		dependencies: dependencies,
+		unresolved:   unresolved,
+		result:       result,
+	}
+	for _, dependency := range dependencies {
+		if iv.dependents[dependency] == nil {
+			iv.dependents[dependency] = make(map[string]bool)
+		}
+		iv.dependents[dependency][templatePath] = true
+	}
+
+	return result
+}
+
+// ValidateDirectory validates every template under templateDir, reusing
+// cached results for unchanged templates.
+func (iv *IncrementalValidator) ValidateDirectory(templateDir string) map[string]ValidationResult {
+	results := make(map[string]ValidationResult)
+
+	err := fs.WalkDir(iv.validator.fs, templateDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (!strings.HasSuffix(path, ".tmpl") && !strings.HasSuffix(path, ".tpl")) {
+			return nil
+		}
+		results[path] = iv.ValidateTemplate(path)
+		return nil
+	})
+
+	if err != nil && iv.validator.debugMode != nil {
+		iv.validator.debugMode.Error("Directory validation failed", "error", err, "directory", templateDir)
+	}
+	iv.validator.reportUnused(results)
+	iv.validator.summaries.sweep()
+
+	return results
+}
+
+// Revalidate validates the changed templates together with every template
+// that transitively references them, which is what a file watcher needs after
+// a save. Templates with unresolved references are always included, since a
+// new file may resolve them. Deleted templates are dropped from the cache and
+// omitted from the results.
+func (iv *IncrementalValidator) Revalidate(changed ...string) map[string]ValidationResult {
+	affected := make(map[string]bool)
+
+	iv.mu.Lock()
+	for path, entry := range iv.entries {
+		if entry.unresolved {
+			affected[path] = true
+		}
+	}
+	iv.mu.Unlock()
+
+	for _, path := range changed {
+		affected[path] = true
+		for _, dependent := range iv.Dependents(path) {
+			affected[dependent] = true
+		}
+	}
+
+	paths := slices.Sorted(maps.Keys(affected))
+	results := make(map[string]ValidationResult, len(paths))
+	for _, path := range paths {
+		if _, err := fs.Stat(iv.validator.fs, path); err != nil {
+			iv.forget(path)
+			continue
+		}
+		results[path] = iv.ValidateTemplate(path)
+	}
+
+	return results
+}
+
+// Dependents returns the cached templates that reference path directly or
+// through other partials and includes, sorted by path.
+func (iv *IncrementalValidator) Dependents(path string) []string {
+	iv.mu.Lock()
+	defer iv.mu.Unlock()
+
+	seen := make(map[string]bool)
+	queue := []string{path}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for dependent := range iv.dependents[current] {
+			if !seen[dependent] && dependent != path {
+				seen[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// Invalidate drops the cached result for templatePath.
+func (iv *IncrementalValidator) Invalidate(templatePath string) {
+	iv.forget(templatePath)
+}
+
+// Reset clears the cache and its statistics.
+func (iv *IncrementalValidator) Reset() {
+	iv.mu.Lock()
+	defer iv.mu.Unlock()
+	iv.entries = make(map[string]cachedValidation)
+	iv.dependents = make(map[string]map[string]bool)
+	iv.hits = 0
+	iv.misses = 0
+	iv.validator.summaries.reset()
+}
+
+func (iv *IncrementalValidator) Stats() IncrementalStats {
+	iv.mu.Lock()
+	defer iv.mu.Unlock()
+	return IncrementalStats{
+		Entries: len(iv.entries),
+		Hits:    iv.hits,
+		Misses:  iv.misses,
+		Parsed:  iv.validator.summaries.parsedCount(),
+	}
+}
+
+func (iv *IncrementalValidator) forget(templatePath string) {
+	iv.mu.Lock()
+	defer iv.mu.Unlock()
+	iv.unlinkLocked(templatePath)
+	delete(iv.entries, templatePath)
+}
+
+// unlinkLocked removes templatePath from the reverse dependency graph.
+func (iv *IncrementalValidator) unlinkLocked(templatePath string) {
+	entry, ok := iv.entries[templatePath]
+	if !ok {
+		return
+	}
+	for _, dependency := range entry.dependencies {
+		delete(iv.dependents[dependency], templatePath)
+		if len(iv.dependents[dependency]) == 0 {
+			delete(iv.dependents, dependency)
+		}
+	}
+}
+
+// cacheKey hashes everything a template's validation result depends on. It
+// also returns the resolved paths of the template's dependencies and whether
+// any reference could not be resolved.
+func (iv *IncrementalValidator) cacheKey(templatePath string) (string, []string, bool, error) {
+	tv := iv.validator
+	if tv.fs == nil || !isSecurePath(templatePath) {
+		return "", nil, false, fmt.Errorf("template %s cannot be cached", templatePath)
+	}
+
+	content, err := fs.ReadFile(tv.fs, templatePath)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "strict=%t tab=%d data=%s funcs=%s\n", tv.strict, tv.tabWidth, tv.dataKey, strings.Join(sortedFuncNames(tv), ","))
+	for _, rule := range tv.rules {
+		fmt.Fprintf(h, "rule=%s:%s\n", rule.ID(), tv.ruleSeverity(rule))
+	}
+	for _, name := range slices.Sorted(maps.Keys(tv.replacements)) {
+		fmt.Fprintf(h, "deprecated=%s:%s\n", name, tv.replacements[name])
+	}
+	h.Write(content)
+
+	// Dependencies are followed transitively, as cycles through them change
+	// the result. Only the template's own references count as unresolved.
+	var dependencies []string
+	unresolved := false
+	seen := map[string]bool{templatePath: true}
+	queue := []templateReference{}
+	for _, reference := range tv.references(templatePath, string(content)) {
+		unresolved = unresolved || reference.path == ""
+		queue = append(queue, reference)
+	}
+	for len(queue) > 0 {
+		reference := queue[0]
+		queue = queue[1:]
+		fmt.Fprintf(h, "\n%s=%s:", reference.name, reference.path)
+		if reference.path == "" || seen[reference.path] {
+			continue
+		}
+		seen[reference.path] = true
+		dependencies = append(dependencies, reference.path)
+		if dependency, err := fs.ReadFile(tv.fs, reference.path); err == nil {
+			h.Write(dependency)
+			queue = append(queue, tv.references(reference.path, string(dependency))...)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), dependencies, unresolved, nil
+}
+
+// templateReference is a partial, include or layout referenced by a
+// template, with its resolved path ("" when it cannot be found) and the
+// offset of the reference in the template.
+type templateReference struct {
+	name   string
+	path   string
+	offset int
+}
+
+func (tv *TemplateValidator) references(templatePath, content string) []templateReference {
+	var references []templateReference
+	for _, call := range tv.summary(templatePath, content).calls {
+		reference := templateReference{name: call.kind + ":" + call.name, offset: call.offset}
+		if isSecurePath(call.name) {
+			switch call.kind {
+			case "template":
+				reference.path = tv.resolvePartialPath(templatePath, call.name)
+			case "include":
+				reference.path = tv.resolveIncludePath(templatePath, call.name)
+			case "extends":
+				reference.path = tv.resolveLayoutPath(templatePath, call.name)
+			}
+		}
+		references = append(references, reference)
+	}
+	return references
+}
+
+func sortedFuncNames(tv *TemplateValidator) []string {
+	names := make([]string, 0, len(tv.funcMap))
+	for name := range tv.funcMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,139 @@
+package debug
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestIncrementalValidator_CachesUnchangedTemplates(t *testing.T) {
+	testFS := fstest.MapFS{
+		"templates/main.tmpl":    {Data: []byte(`{{template "header"}} {{.Name}}`)},
+		"templates/_header.tmpl": {Data: []byte(`Header`)},
+		"templates/other.tmpl":   {Data: []byte(`{{.Other}}`)},
+	}
+
+	iv := NewIncrementalValidator(NewTemplateValidator(testFS, nil, nil))
+
+	first := iv.ValidateDirectory("templates")
+	if len(first) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(first))
+	}
+	if stats := iv.Stats(); stats.Misses != 3 || stats.Hits != 0 {
+		t.Errorf("Expected 3 misses on first run, got %+v", stats)
+	}
+
+	iv.ValidateDirectory("templates")
+	if stats := iv.Stats(); stats.Hits != 3 {
+		t.Errorf("Expected 3 hits on unchanged second run, got %+v", stats)
+	}
+
+	// Changing a partial must invalidate the templates that use it.
+	testFS["templates/_header.tmpl"] = &fstest.MapFile{Data: []byte(`New header`)}
+	if result := iv.ValidateTemplate("templates/main.tmpl"); result.HasErrors() {
+		t.Errorf("Unexpected errors: %v", result.Errors)
+	}
+	if stats := iv.Stats(); stats.Misses != 4 {
+		t.Errorf("Expected dependency change to cause a miss, got %+v", stats)
+	}
+}
+
+func TestIncrementalValidator_Revalidate(t *testing.T) {
+	testFS := fstest.MapFS{
+		"main.tmpl":    {Data: []byte(`{{template "header"}}`)},
+		"_header.tmpl": {Data: []byte(`{{template "footer"}}`)},
+		"_footer.tmpl": {Data: []byte(`Footer`)},
+		"other.tmpl":   {Data: []byte(`{{.Other}}`)},
+		"broken.tmpl":  {Data: []byte(`{{template "missing"}}`)},
+	}
+
+	iv := NewIncrementalValidator(NewTemplateValidator(testFS, nil, nil))
+	iv.ValidateDirectory(".")
+
+	dependents := iv.Dependents("_footer.tmpl")
+	if len(dependents) != 2 || dependents[0] != "_header.tmpl" || dependents[1] != "main.tmpl" {
+		t.Errorf("Expected transitive dependents [_header.tmpl main.tmpl], got %v", dependents)
+	}
+
+	if !iv.ValidateTemplate("broken.tmpl").HasErrors() {
+		t.Fatal("Expected missing partial error")
+	}
+
+	testFS["_missing.tmpl"] = &fstest.MapFile{Data: []byte(`Now here`)}
+	results := iv.Revalidate("_footer.tmpl", "_missing.tmpl")
+
+	for _, path := range []string{"_footer.tmpl", "_header.tmpl", "main.tmpl", "broken.tmpl", "_missing.tmpl"} {
+		if _, ok := results[path]; !ok {
+			t.Errorf("Expected %s to be revalidated", path)
+		}
+	}
+	if _, ok := results["other.tmpl"]; ok {
+		t.Error("Expected unrelated template to be left alone")
+	}
+	if results["broken.tmpl"].HasErrors() {
+		t.Errorf("Expected newly created partial to resolve, got %v", results["broken.tmpl"].Errors)
+	}
+
+	delete(testFS, "_footer.tmpl")
+	results = iv.Revalidate("_footer.tmpl")
+	if _, ok := results["_footer.tmpl"]; ok {
+		t.Error("Expected deleted template to be omitted")
+	}
+	if !results["_header.tmpl"].HasErrors() {
+		t.Error("Expected dependent of deleted partial to report a missing partial")
+	}
+}
+
+func TestIncrementalValidator_SettingsChangeInvalidates(t *testing.T) {
+	testFS := fstest.MapFS{"a.tmpl": {Data: []byte("{{.Name}}  ")}}
+
+	validator := NewTemplateValidator(testFS, nil, nil)
+	iv := NewIncrementalValidator(validator)
+
+	if len(iv.ValidateTemplate("a.tmpl").Warnings) != 0 {
+		t.Fatal("Expected no warnings in non-strict mode")
+	}
+
+	validator.SetStrict(true)
+	if len(iv.ValidateTemplate("a.tmpl").Warnings) != 1 {
+		t.Error("Expected strict mode change to invalidate cached result")
+	}
+
+	iv.Reset()
+	if stats := iv.Stats(); stats.Entries != 0 || stats.Misses != 0 {
+		t.Errorf("Expected reset stats, got %+v", stats)
+	}
+}
+
+func TestIncrementalValidator_ParsesChangedTemplatesOnly(t *testing.T) {
+	testFS := fstest.MapFS{
+		"main.tmpl":    {Data: []byte(`{{template "header"}}{{define "unused"}}{{end}}`)},
+		"_header.tmpl": {Data: []byte(`Header`)},
+		"_old.tmpl":    {Data: []byte(`Old`)},
+	}
+
+	iv := NewIncrementalValidator(NewTemplateValidator(testFS, nil, nil))
+	first := iv.ValidateDirectory(".")
+	if stats := iv.Stats(); stats.Parsed != 3 {
+		t.Errorf("Expected each template to be parsed once, got %+v", stats)
+	}
+	if findingTypes(first["main.tmpl"]) != "errors=[] warnings=[unused_define]" || findingTypes(first["_old.tmpl"]) != "errors=[] warnings=[unused_partial]" {
+		t.Errorf("Unexpected results %+v", first)
+	}
+
+	second := iv.ValidateDirectory(".")
+	if stats := iv.Stats(); stats.Parsed != 3 {
+		t.Errorf("Expected no parsing for unchanged templates, got %+v", stats)
+	}
+	if findingTypes(second["main.tmpl"]) != findingTypes(first["main.tmpl"]) || findingTypes(second["_old.tmpl"]) != findingTypes(first["_old.tmpl"]) {
+		t.Errorf("Expected the same findings from cached summaries, got %+v", second)
+	}
+
+	testFS["main.tmpl"] = &fstest.MapFile{Data: []byte(`{{template "header"}}{{template "old"}}`)}
+	third := iv.ValidateDirectory(".")
+	if stats := iv.Stats(); stats.Parsed != 4 {
+		t.Errorf("Expected only the changed template to be parsed, got %+v", stats)
+	}
+	if findingTypes(third["main.tmpl"]) != "errors=[] warnings=[]" || findingTypes(third["_old.tmpl"]) != "errors=[] warnings=[]" {
+		t.Errorf("Unexpected results after change %+v", third)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"reflect"
 	"sync"
 	"time"
 )
@@ -44,13 +45,30 @@ func isValidDebugLevel(level DebugLevel) bool {
 	return level >= LevelOff && level <= LevelTrace
 }
 
+// LogFormat is the format DebugMode writes log records in.
+type LogFormat int
+
+const (
+	// TextFormat writes each record as a line of key=value pairs.
+	TextFormat LogFormat = iota
+	// JSONFormat writes each record as a line holding a JSON object with
+	// its time, level, message and attributes, for log pipelines that
+	// ingest JSON.
+	JSONFormat
+)
+
 type DebugMode struct {
 	level           DebugLevel
 	output          io.Writer
+	format          LogFormat
 	logger          *slog.Logger
 	enableProfiling bool
+	profileDir      string
+	foldedStacks    bool
+	limits          *logLimits
 	enableTracing   bool
 	enableMetrics   bool
+	runID           string
 	startTime       time.Time
 	mu              sync.RWMutex
 }
@@ -73,6 +91,14 @@ func WithOutput(output io.Writer) DebugOption {
 	}
 }
 
+// WithFormat sets the format log records are written in. The default is
+// TextFormat.
+func WithFormat(format LogFormat) DebugOption {
+	return func(dm *DebugMode) {
+		dm.format = format
+	}
+}
+
 func WithProfiling(enable bool) DebugOption {
 	return func(dm *DebugMode) {
 		dm.enableProfiling = enable
@@ -91,6 +117,13 @@ func WithMetrics(enable bool) DebugOption {
 	}
 }
 
+// WithRunID tags every log record with the given engine run ID.
+func WithRunID(id string) DebugOption {
+	return func(dm *DebugMode) {
+		dm.runID = id
+	}
+}
+
 func NewDebugMode(opts ...DebugOption) *DebugMode {
 	dm := &DebugMode{
 		level:     LevelInfo,
@@ -114,8 +147,30 @@ func (dm *DebugMode) setupLogger() {
 		AddSource: dm.level >= LevelDebug,
 	}
 
-	handler := slog.NewTextHandler(dm.output, opts)
+	var handler slog.Handler
+	if dm.format == JSONFormat {
+		handler = slog.NewJSONHandler(dm.output, opts)
+	} else {
+		handler = slog.NewTextHandler(dm.output, opts)
+	}
 	dm.logger = slog.New(handler)
+	if dm.runID != "" {
+		dm.logger = dm.logger.With("run_id", dm.runID)
+	}
+}
+
+// RunID returns the run ID log records are tagged with, or "" if none.
+func (dm *DebugMode) RunID() string {
+	return dm.runID
+}
+
+// NewError creates an EnhancedError tagged with the debug mode's run ID.
+func (dm *DebugMode) NewError(err error, operation string) *EnhancedError {
+	ee := NewEnhancedError(err, operation)
+	if ee != nil && dm.runID != "" {
+		ee.WithRunID(dm.runID)
+	}
+	return ee
 }
 
 func (dm *DebugMode) mapDebugLevelToSlogLevel() slog.Level {
@@ -152,31 +207,31 @@ func (dm *DebugMode) SetLevel(level DebugLevel) error {
 }
 
 func (dm *DebugMode) Error(msg string, args ...any) {
-	if dm.IsEnabled(LevelError) {
+	if dm.IsEnabled(LevelError) && dm.allow(LevelError, msg) {
 		dm.logger.Error(msg, args...)
 	}
 }
 
 func (dm *DebugMode) Warn(msg string, args ...any) {
-	if dm.IsEnabled(LevelWarn) {
+	if dm.IsEnabled(LevelWarn) && dm.allow(LevelWarn, msg) {
 		dm.logger.Warn(msg, args...)
 	}
 }
 
 func (dm *DebugMode) Info(msg string, args ...any) {
-	if dm.IsEnabled(LevelInfo) {
+	if dm.IsEnabled(LevelInfo) && dm.allow(LevelInfo, msg) {
 		dm.logger.Info(msg, args...)
 	}
 }
 
 func (dm *DebugMode) Debug(msg string, args ...any) {
-	if dm.IsEnabled(LevelDebug) {
+	if dm.IsEnabled(LevelDebug) && dm.allow(LevelDebug, msg) {
 		dm.logger.Debug(msg, args...)
 	}
 }
 
 func (dm *DebugMode) Trace(msg string, args ...any) {
-	if dm.IsEnabled(LevelTrace) {
+	if dm.IsEnabled(LevelTrace) && dm.allow(LevelTrace, msg) {
 		dm.logger.Debug("[TRACE] "+msg, args...)
 	}
 }
@@ -218,6 +273,8 @@ func sanitizeDataForLogging(data any) any {
 			// Use the more sophisticated filtering logic from helpers.go
 			if isSensitiveFieldName(k) {
 				sanitized[k] = "[REDACTED]"
+			} else if hasSecrets() && containsSecret(reflect.ValueOf(v)) {
+				sanitized[k] = sanitizeValueForDebug(v)
 			} else {
 				sanitized[k] = v
 			}
@@ -251,6 +308,10 @@ func (dm *DebugMode) LogError(operation string, err error, context map[string]an
 }
 
 func (dm *DebugMode) GetStats() DebugStats {
+	var sampledOut, rateLimited int64
+	if dm.limits != nil {
+		sampledOut, rateLimited = dm.limits.dropped()
+	}
 	return DebugStats{
 		Level:            dm.level,
 		StartTime:        dm.startTime,
@@ -258,6 +319,8 @@ func (dm *DebugMode) GetStats() DebugStats {
 		ProfilingEnabled: dm.enableProfiling,
 		TracingEnabled:   dm.enableTracing,
 		MetricsEnabled:   dm.enableMetrics,
+		SampledOut:       sampledOut,
+		RateLimited:      rateLimited,
 	}
 }
 
@@ -268,6 +331,10 @@ type DebugStats struct {
 	ProfilingEnabled bool          `json:"profiling_enabled"`
 	TracingEnabled   bool          `json:"tracing_enabled"`
 	MetricsEnabled   bool          `json:"metrics_enabled"`
+	// SampledOut and RateLimited count the records dropped by sampling and
+	// by the rate limit.
+	SampledOut  int64 `json:"sampled_out,omitempty"`
+	RateLimited int64 `json:"rate_limited,omitempty"`
 }
 
 func (ds DebugStats) String() string {
@@ -54,6 +54,41 @@ func TestWithOutput(t *testing.T) {
 	}
 }
 
+func TestWithFormat(t *testing.T) {
+	var buf bytes.Buffer
+	dm := NewDebugMode(WithFormat(JSONFormat), WithOutput(&buf), WithRunID("run-1"))
+	ctx := dm.NewContext("render")
+	ctx.SetAttribute("template", "api.go.tmpl")
+	ctx.Info("template executed", "size", 42)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]any{
+		"level":     "INFO",
+		"msg":       "template executed",
+		"operation": "render",
+		"template":  "api.go.tmpl",
+		"size":      float64(42),
+		"run_id":    "run-1",
+	} {
+		if record[key] != want {
+			t.Errorf("Expected %s=%v, got %v", key, want, record[key])
+		}
+	}
+	if _, ok := record["time"]; !ok {
+		t.Error("Expected a time field")
+	}
+
+	buf.Reset()
+	dm.SetLevel(LevelDebug)
+	dm.Debug("after level change")
+	if !json.Valid(buf.Bytes()) {
+		t.Errorf("Expected JSON after SetLevel, got %q", buf.String())
+	}
+}
+
 func TestWithProfiling(t *testing.T) {
 	dm := &DebugMode{}
 	opt := WithProfiling(true)
@@ -84,6 +119,25 @@ func TestWithMetrics(t *testing.T) {
 	}
 }
 
+func TestWithRunID(t *testing.T) {
+	var buf bytes.Buffer
+	dm := NewDebugMode(WithOutput(&buf), WithRunID("run-123"))
+
+	dm.Info("rendering")
+	if !strings.Contains(buf.String(), "run_id=run-123") {
+		t.Errorf("Expected log record to carry the run ID, got %q", buf.String())
+	}
+
+	if dm.RunID() != "run-123" {
+		t.Errorf("Expected RunID run-123, got %s", dm.RunID())
+	}
+
+	ee := dm.NewError(fmt.Errorf("failed"), "render")
+	if ee.GetContext().RunID != "run-123" {
+		t.Errorf("Expected error context run ID run-123, got %s", ee.GetContext().RunID)
+	}
+}
+
 func TestNewDebugMode(t *testing.T) {
 	t.Run("default configuration", func(t *testing.T) {
 		dm := NewDebugMode()
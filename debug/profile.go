@@ -0,0 +1,296 @@
+package debug
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"strings"
+)
+
+// WithProfileDir sets the directory Profile writes profiles to. The default
+// is the current directory.
+func WithProfileDir(dir string) DebugOption {
+	return func(dm *DebugMode) {
+		dm.profileDir = dir
+	}
+}
+
+// WithFoldedStacks makes Profile also write the CPU profile as folded
+// stacks, one "caller;callee count" line per stack, the input flame graph
+// tools such as flamegraph.pl and speedscope read.
+func WithFoldedStacks(enable bool) DebugOption {
+	return func(dm *DebugMode) {
+		dm.foldedStacks = enable
+	}
+}
+
+// Profile runs fn, such as a call to RenderDir, and when profiling is
+// enabled captures a CPU profile while it runs and a heap profile once it
+// returns. They are written to name.cpu.pprof and name.heap.pprof in the
+// profile directory, for go tool pprof, along with name.folded when folded
+// stacks are enabled. name must be a file name, not a path. The CPU
+// profiler is process wide, so Profile fails without running fn if another
+// profile is being captured. The error is fn's, or an error writing the
+// profiles.
+func (dm *DebugMode) Profile(name string, fn func() error) error {
+	if !dm.enableProfiling {
+		return fn()
+	}
+	if !filepath.IsLocal(name) || filepath.Base(name) != name {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	dir := dm.profileDir
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+
+	cpuPath := filepath.Join(dir, name+".cpu.pprof")
+	cpu, err := os.Create(cpuPath)
+	if err != nil {
+		return fmt.Errorf("failed to write CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		cpu.Close()
+		os.Remove(cpuPath)
+		return fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	runErr := fn()
+	pprof.StopCPUProfile()
+	if err := cpu.Close(); err != nil {
+		return errors.Join(runErr, fmt.Errorf("failed to write CPU profile: %w", err))
+	}
+
+	heapPath := filepath.Join(dir, name+".heap.pprof")
+	if err := writeHeapProfile(heapPath); err != nil {
+		return errors.Join(runErr, err)
+	}
+	args := []any{"cpu", cpuPath, "heap", heapPath}
+
+	if dm.foldedStacks {
+		foldedPath := filepath.Join(dir, name+".folded")
+		if err := writeFoldedStacks(foldedPath, cpuPath); err != nil {
+			return errors.Join(runErr, err)
+		}
+		args = append(args, "folded", foldedPath)
+	}
+	dm.Info("profiles written", args...)
+	return runErr
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+	// Collect garbage so the profile shows the live heap.
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+	return f.Close()
+}
+
+// writeFoldedStacks folds the CPU profile at cpuPath into path.
+func writeFoldedStacks(path, cpuPath string) error {
+	cpuProfile, err := os.ReadFile(cpuPath)
+	if err != nil {
+		return fmt.Errorf("failed to read CPU profile: %w", err)
+	}
+	stacks, err := foldStacks(cpuProfile)
+	if err != nil {
+		return fmt.Errorf("failed to fold CPU profile: %w", err)
+	}
+	var b strings.Builder
+	for _, stack := range slices.Sorted(maps.Keys(stacks)) {
+		fmt.Fprintf(&b, "%s %d\n", stack, stacks[stack])
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write folded stacks: %w", err)
+	}
+	return nil
+}
+
+// foldStacks reads a gzipped pprof CPU profile and returns its sample counts
+// by stack, each stack the function names from the root to the leaf joined
+// by ";". Only the parts of the profile format the stacks need are read.
+func foldStacks(profile []byte) (map[string]int64, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(profile))
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	type sample struct {
+		locations []uint64
+		count     int64
+	}
+	var (
+		samples   []sample
+		locations = make(map[uint64][]uint64) // location ID to function IDs, innermost first
+		functions = make(map[uint64]uint64)   // function ID to name string index
+		strs      []string
+	)
+	err = protoFields(data, func(field int, value uint64, msg []byte) error {
+		switch field {
+		case 2: // Profile.sample
+			var s sample
+			err := protoFields(msg, func(field int, value uint64, packed []byte) error {
+				switch field {
+				case 1: // Sample.location_id
+					ids, err := protoVarints(value, packed)
+					s.locations = append(s.locations, ids...)
+					return err
+				case 2: // Sample.value; the first is the sample count
+					values, err := protoVarints(value, packed)
+					if len(values) > 0 && s.count == 0 {
+						s.count = int64(values[0])
+					}
+					return err
+				}
+				return nil
+			})
+			samples = append(samples, s)
+			return err
+		case 4: // Profile.location
+			var id uint64
+			var funcs []uint64
+			err := protoFields(msg, func(field int, value uint64, line []byte) error {
+				switch field {
+				case 1: // Location.id
+					id = value
+				case 4: // Location.line
+					return protoFields(line, func(field int, value uint64, _ []byte) error {
+						if field == 1 { // Line.function_id
+							funcs = append(funcs, value)
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			locations[id] = funcs
+			return err
+		case 5: // Profile.function
+			var id, name uint64
+			err := protoFields(msg, func(field int, value uint64, _ []byte) error {
+				switch field {
+				case 1: // Function.id
+					id = value
+				case 2: // Function.name
+					name = value
+				}
+				return nil
+			})
+			functions[id] = name
+			return err
+		case 6: // Profile.string_table
+			strs = append(strs, string(msg))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stacks := make(map[string]int64)
+	for _, s := range samples {
+		var frames []string
+		// Samples list their locations leaf first, and locations their
+		// inlined functions innermost first.
+		for _, loc := range slices.Backward(s.locations) {
+			for _, fn := range slices.Backward(locations[loc]) {
+				if i := functions[fn]; i < uint64(len(strs)) {
+					frames = append(frames, strs[i])
+				}
+			}
+		}
+		if len(frames) > 0 {
+			stacks[strings.Join(frames, ";")] += s.count
+		}
+	}
+	return stacks, nil
+}
+
+// protoFields calls visit for each field of the protobuf message data with
+// its number and either its value, for varint fields, or its bytes, for
+// length-delimited ones. Fixed-size fields are skipped.
+func protoFields(data []byte, visit func(field int, value uint64, bytes []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("malformed profile")
+		}
+		data = data[n:]
+		field := int(key >> 3)
+
+		var value uint64
+		var msg []byte
+		switch key & 7 {
+		case 0: // varint
+			value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errors.New("malformed profile")
+			}
+			data = data[n:]
+		case 1: // 64-bit
+			if len(data) < 8 {
+				return errors.New("malformed profile")
+			}
+			data = data[8:]
+			continue
+		case 2: // length-delimited
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return errors.New("malformed profile")
+			}
+			msg = data[n : n+int(size)]
+			data = data[n+int(size):]
+		case 5: // 32-bit
+			if len(data) < 4 {
+				return errors.New("malformed profile")
+			}
+			data = data[4:]
+			continue
+		default:
+			return fmt.Errorf("malformed profile: unknown wire type %d", key&7)
+		}
+		if err := visit(field, value, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// protoVarints returns the values of a repeated varint field, which is
+// either a single value or, when packed, the bytes of several.
+func protoVarints(value uint64, packed []byte) ([]uint64, error) {
+	if packed == nil {
+		return []uint64{value}, nil
+	}
+	var values []uint64
+	for len(packed) > 0 {
+		v, n := binary.Uvarint(packed)
+		if n <= 0 {
+			return nil, errors.New("malformed profile")
+		}
+		values = append(values, v)
+		packed = packed[n:]
+	}
+	return values, nil
+}
@@ -0,0 +1,91 @@
+package debug
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// spin keeps a CPU busy for d so that a CPU profile has samples.
+func spin(d time.Duration) int {
+	n := 0
+	for start := time.Now(); time.Since(start) < d; {
+		n++
+	}
+	return n
+}
+
+func TestDebugMode_Profile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profiles")
+	var logs bytes.Buffer
+	dm := NewDebugMode(WithProfiling(true), WithProfileDir(dir), WithFoldedStacks(true), WithOutput(&logs))
+
+	runErr := errors.New("render failed")
+	err := dm.Profile("render", func() error {
+		spin(300 * time.Millisecond)
+		return runErr
+	})
+	if !errors.Is(err, runErr) {
+		t.Fatalf("Profile() error = %v, want %v", err, runErr)
+	}
+
+	for _, name := range []string{"render.cpu.pprof", "render.heap.pprof", "render.folded"} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.Size() == 0 {
+			t.Errorf("Expected %s to be written, got %v", name, err)
+		}
+	}
+
+	folded, _ := os.ReadFile(filepath.Join(dir, "render.folded"))
+	for line := range strings.Lines(string(folded)) {
+		stack, count, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if n, err := strconv.Atoi(count); !ok || err != nil || n < 1 || stack == "" {
+			t.Errorf("Malformed folded line %q", line)
+		}
+	}
+	if !strings.Contains(string(folded), "debug.spin") || !strings.Contains(string(folded), "debug.TestDebugMode_Profile.func1;") {
+		t.Errorf("Expected folded stacks through spin, got:\n%s", folded)
+	}
+	if !strings.Contains(logs.String(), "profiles written") {
+		t.Errorf("Expected the profiles to be logged, got:\n%s", logs.String())
+	}
+}
+
+func TestDebugMode_ProfileDisabled(t *testing.T) {
+	dir := t.TempDir()
+	dm := NewDebugMode(WithProfileDir(dir), WithOutput(&bytes.Buffer{}))
+
+	ran := false
+	if err := dm.Profile("render", func() error { ran = true; return nil }); err != nil || !ran {
+		t.Fatalf("Profile() = %v, ran = %v", err, ran)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected no profiles without profiling, got %v", entries)
+	}
+}
+
+func TestDebugMode_ProfileInvalidName(t *testing.T) {
+	dir := t.TempDir()
+	dm := NewDebugMode(WithProfiling(true), WithProfileDir(filepath.Join(dir, "profiles")), WithOutput(&bytes.Buffer{}))
+
+	for _, name := range []string{"../render", "a/render", "/tmp/render", ""} {
+		ran := false
+		err := dm.Profile(name, func() error { ran = true; return nil })
+		if err == nil || !strings.Contains(err.Error(), "invalid profile name") || ran {
+			t.Errorf("Profile(%q) = %v, ran = %v; want an invalid name error", name, err, ran)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected nothing written for invalid names, got %v", entries)
+	}
+}
+
+func TestFoldStacks_Malformed(t *testing.T) {
+	if _, err := foldStacks([]byte("not a profile")); err == nil {
+		t.Error("Expected an error for data that is not a profile")
+	}
+}
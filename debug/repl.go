@@ -0,0 +1,265 @@
+package debug
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/cpcf/weft/render"
+)
+
+// replName is the name snippets are parsed under.
+const replName = "repl"
+
+// REPL evaluates template snippets against fixed data, such as
+// "{{ .API.Endpoints | len }}" or, without braces, ".API.Endpoints | len".
+// Variables declared in one snippet stay defined in later ones; their
+// declarations are evaluated again before each snippet.
+type REPL struct {
+	data        any
+	funcs       template.FuncMap
+	prompt      string
+	history     []string
+	historyFile string
+	decls       []string
+}
+
+// REPLOption configures a REPL.
+type REPLOption func(*REPL)
+
+// WithPrompt sets the prompt Run prints before reading each line. The
+// default is "> ".
+func WithPrompt(prompt string) REPLOption {
+	return func(r *REPL) {
+		r.prompt = prompt
+	}
+}
+
+// WithHistoryFile keeps the history in the file at path: lines entered in
+// earlier sessions are loaded from it and each line entered is appended.
+func WithHistoryFile(path string) REPLOption {
+	return func(r *REPL) {
+		r.historyFile = path
+	}
+}
+
+// NewREPL returns a REPL evaluating snippets with data as dot and the
+// functions in funcs. It fails if the history file cannot be read.
+func NewREPL(data any, funcs template.FuncMap, opts ...REPLOption) (*REPL, error) {
+	r := &REPL{data: data, funcs: funcs, prompt: "> "}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.historyFile != "" {
+		content, err := os.ReadFile(r.historyFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		for line := range strings.SplitSeq(string(content), "\n") {
+			if line != "" {
+				r.history = append(r.history, line)
+			}
+		}
+	}
+	return r, nil
+}
+
+// History returns the lines entered, oldest first.
+func (r *REPL) History() []string {
+	return slices.Clone(r.history)
+}
+
+// EvalError is an error evaluating a snippet.
+type EvalError struct {
+	// Snippet is the template evaluated, with braces added if it had none.
+	Snippet string
+	// Column locates the error in Snippet, counting bytes from 1, or is 0
+	// if unknown.
+	Column int
+	// Suggestion names the function the snippet probably meant when it
+	// calls one that does not exist, or is "".
+	Suggestion string
+	Err        error
+}
+
+func (e *EvalError) Error() string {
+	msg := e.message()
+	if e.Suggestion != "" {
+		msg += " (" + e.Suggestion + ")"
+	}
+	return msg
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
+// replLocation matches the location text/template puts in front of errors
+// in snippets.
+var replLocation = regexp.MustCompile(`^template: ` + replName + `:\d+:(?:\d+:)?\s*`)
+
+// message returns the error without the location text/template puts in
+// front of it.
+func (e *EvalError) message() string {
+	return replLocation.ReplaceAllString(e.Err.Error(), "")
+}
+
+// Eval evaluates snippet and returns its output. A snippet without "{{" is
+// evaluated as a single action. Errors are EvalErrors.
+func (r *REPL) Eval(snippet string) (string, error) {
+	snippet = strings.TrimSpace(snippet)
+	if !strings.Contains(snippet, "{{") {
+		snippet = "{{ " + snippet + " }}"
+	}
+	prelude := strings.Join(r.decls, "")
+
+	tmpl, err := template.New(replName).Funcs(r.funcs).Parse(prelude + snippet)
+	if err != nil {
+		return "", r.evalError(snippet, len(prelude), err)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, r.data); err != nil {
+		return "", r.evalError(snippet, len(prelude), err)
+	}
+
+	// Keep the snippet's declarations and assignments for later snippets.
+	// Each kept one parses to a single node.
+	for _, node := range tmpl.Tree.Root.Nodes[len(r.decls):] {
+		if action, ok := node.(*parse.ActionNode); ok && len(action.Pipe.Decl) > 0 {
+			r.decls = append(r.decls, action.String())
+		}
+	}
+	return out.String(), nil
+}
+
+// executionColumn matches the byte offset text/template puts in execution
+// errors.
+var executionColumn = regexp.MustCompile(`^template: ` + replName + `:1:(\d+):`)
+
+func (r *REPL) evalError(snippet string, preludeLen int, err error) *EvalError {
+	evalErr := &EvalError{Snippet: snippet, Err: err}
+	if match := executionColumn.FindStringSubmatch(err.Error()); match != nil {
+		if offset, _ := strconv.Atoi(match[1]); offset >= preludeLen {
+			evalErr.Column = offset - preludeLen + 1
+		}
+	}
+	if match := undefinedFunctionPattern.FindStringSubmatch(err.Error()); match != nil {
+		candidates := append(slices.Clone(builtinFunctions), slices.Sorted(maps.Keys(r.funcs))...)
+		if name := render.ClosestName(match[1], candidates); name != "" {
+			evalErr.Suggestion = fmt.Sprintf("did you mean '%s'?", name)
+		}
+		if column := strings.Index(snippet, match[1]); column >= 0 {
+			evalErr.Column = column + 1
+		}
+	}
+	return evalErr
+}
+
+// Run reads lines from in and writes the output of each to out until in
+// ends or a line is ":quit". Besides snippets it accepts
+//
+//	:history    list the lines entered, numbered
+//	!n          evaluate line n of the history again
+//	:funcs [p]  list the functions, or those starting with p
+//	:help       list the commands
+//
+// Errors evaluating snippets are written to out with a caret under their
+// position; Run only returns errors reading in, writing out or writing
+// the history file.
+func (r *REPL) Run(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		if _, err := io.WriteString(out, r.prompt); err != nil {
+			return err
+		}
+		if !scanner.Scan() {
+			io.WriteString(out, "\n")
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+
+		if n, ok := strings.CutPrefix(line, "!"); ok {
+			i, err := strconv.Atoi(n)
+			if err != nil || i < 1 || i > len(r.history) {
+				fmt.Fprintf(out, "error: no history entry %s\n", n)
+				continue
+			}
+			line = r.history[i-1]
+			fmt.Fprintln(out, line)
+		}
+
+		switch command, arg, _ := strings.Cut(line, " "); command {
+		case "":
+			continue
+		case ":quit", ":q":
+			return nil
+		case ":help":
+			fmt.Fprintln(out, "Enter a template snippet such as {{ .Name | upper }}, or an action without braces.")
+			fmt.Fprintln(out, ":history    list the lines entered\n!n          evaluate line n of the history again\n:funcs [p]  list the functions, or those starting with p\n:quit       leave")
+			continue
+		case ":history":
+			for i, entry := range r.history {
+				fmt.Fprintf(out, "%4d  %s\n", i+1, entry)
+			}
+			continue
+		case ":funcs":
+			for _, name := range slices.Sorted(maps.Keys(r.funcs)) {
+				if strings.HasPrefix(name, strings.TrimSpace(arg)) {
+					fmt.Fprintln(out, name)
+				}
+			}
+			continue
+		}
+
+		if err := r.record(line); err != nil {
+			return err
+		}
+		output, err := r.Eval(line)
+		if err != nil {
+			writeEvalError(out, err)
+			continue
+		}
+		if output != "" && !strings.HasSuffix(output, "\n") {
+			output += "\n"
+		}
+		if _, err := io.WriteString(out, output); err != nil {
+			return err
+		}
+	}
+}
+
+// record adds line to the history, and to the history file if there is
+// one.
+func (r *REPL) record(line string) error {
+	r.history = append(r.history, line)
+	if r.historyFile == "" {
+		return nil
+	}
+	f, err := os.OpenFile(r.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return f.Close()
+}
+
+func writeEvalError(out io.Writer, err error) {
+	fmt.Fprintf(out, "error: %v\n", err)
+	var evalErr *EvalError
+	if errors.As(err, &evalErr) && evalErr.Column > 0 && !strings.Contains(evalErr.Snippet, "\n") {
+		fmt.Fprintf(out, "  %s\n  %s^\n", evalErr.Snippet, strings.Repeat(" ", evalErr.Column-1))
+	}
+}
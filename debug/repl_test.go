@@ -0,0 +1,91 @@
+package debug
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestREPL_Eval(t *testing.T) {
+	data := map[string]any{"API": map[string]any{"Endpoints": []string{"get", "list"}}, "Name": "shop"}
+	repl, err := NewREPL(data, template.FuncMap{"upper": strings.ToUpper})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		snippet    string
+		want       string
+		wantErr    string
+		wantColumn int
+	}{
+		{snippet: "{{ .API.Endpoints | len }}", want: "2"},
+		{snippet: ".Name | upper", want: "SHOP"},
+		{snippet: "{{ $first := index .API.Endpoints 0 }}", want: ""},
+		{snippet: "$first | upper", want: "GET"},
+		{snippet: "{{ $first = .Name }}{{ $first }}", want: "shop"},
+		{snippet: "$first", want: "shop"},
+		{snippet: ".Name | uper", wantErr: `function "uper" not defined (did you mean 'upper'?)`, wantColumn: 12},
+		{snippet: "index .API.Endpoints 5", wantErr: "error calling index: index out of range: 5", wantColumn: 4},
+		{snippet: "{{ if }}", wantErr: "missing value for if"},
+	}
+	for _, tt := range tests {
+		got, err := repl.Eval(tt.snippet)
+		if tt.wantErr == "" {
+			if err != nil || got != tt.want {
+				t.Errorf("Eval(%q) = %q, %v, want %q", tt.snippet, got, err, tt.want)
+			}
+			continue
+		}
+		var evalErr *EvalError
+		if !errors.As(err, &evalErr) || !strings.Contains(err.Error(), tt.wantErr) || strings.HasPrefix(err.Error(), "template:") {
+			t.Errorf("Eval(%q) error = %v, want %q", tt.snippet, err, tt.wantErr)
+			continue
+		}
+		if evalErr.Column != tt.wantColumn {
+			t.Errorf("Eval(%q) error column = %d, want %d", tt.snippet, evalErr.Column, tt.wantColumn)
+		}
+	}
+}
+
+func TestREPL_Run(t *testing.T) {
+	history := filepath.Join(t.TempDir(), "history")
+	os.WriteFile(history, []byte(".Name\n"), 0o600)
+	repl, err := NewREPL(map[string]any{"Name": "shop"}, template.FuncMap{"upper": strings.ToUpper, "lower": strings.ToLower}, WithHistoryFile(history), WithPrompt("weft> "))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	in := strings.NewReader(".Name | upper\n.Name.X\n:history\n!1\n:funcs up\n:quit\n.Name\n")
+	if err := repl.Run(in, &out); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := `weft> SHOP
+weft> error: executing "repl" at <.Name.X>: can't evaluate field X in type interface {}
+  {{ .Name.X }}
+          ^
+weft>    1  .Name
+   2  .Name | upper
+   3  .Name.X
+weft> .Name
+shop
+weft> upper
+weft> `
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	wantHistory := []string{".Name", ".Name | upper", ".Name.X", ".Name"}
+	if !reflect.DeepEqual(repl.History(), wantHistory) {
+		t.Errorf("History() = %q, want %q", repl.History(), wantHistory)
+	}
+	content, _ := os.ReadFile(history)
+	if string(content) != strings.Join(wantHistory, "\n")+"\n" {
+		t.Errorf("history file = %q", content)
+	}
+}
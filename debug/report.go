@@ -0,0 +1,311 @@
+package debug
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The report writers serialize the results of ValidateDirectory, or any set
+// of results keyed by template path, for CI systems. Templates are written
+// in path order so reports of the same templates are identical.
+
+// jsonReport is the stable JSON report format. Fields may be added but are
+// never renamed or removed within a version.
+type jsonReport struct {
+	Version   int                  `json:"version"`
+	Valid     bool                 `json:"valid"`
+	Summary   jsonSummary          `json:"summary"`
+	Templates []jsonTemplateResult `json:"templates"`
+}
+
+type jsonSummary struct {
+	Templates int `json:"templates"`
+	Errors    int `json:"errors"`
+	Warnings  int `json:"warnings"`
+}
+
+type jsonTemplateResult struct {
+	Path     string            `json:"path"`
+	Valid    bool              `json:"valid"`
+	Errors   []ValidationError `json:"errors"`
+	Warnings []ValidationError `json:"warnings"`
+}
+
+// WriteJSONReport writes results as a JSON document with a summary and the
+// findings of each template.
+func WriteJSONReport(w io.Writer, results map[string]ValidationResult) error {
+	report := jsonReport{Version: 1, Valid: true, Templates: []jsonTemplateResult{}}
+	for _, templatePath := range sortedResultPaths(results) {
+		result := results[templatePath]
+		entry := jsonTemplateResult{
+			Path:     filepath.ToSlash(templatePath),
+			Valid:    !result.HasErrors(),
+			Errors:   append([]ValidationError{}, result.Errors...),
+			Warnings: append([]ValidationError{}, result.Warnings...),
+		}
+		report.Valid = report.Valid && entry.Valid
+		report.Summary.Templates++
+		report.Summary.Errors += len(entry.Errors)
+		report.Summary.Warnings += len(entry.Warnings)
+		report.Templates = append(report.Templates, entry)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write JSON report: %w", err)
+	}
+	return nil
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// WriteSARIFReport writes results as a SARIF 2.1.0 log, as GitHub code
+// scanning and other static analysis dashboards accept. Each finding type
+// is a rule, errors have level error and warnings level warning. Template
+// paths are written as given, so make them relative to the repository root
+// for code scanning to link them.
+func WriteSARIFReport(w io.Writer, results map[string]ValidationResult) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "weft",
+			InformationURI: "https://github.com/cpcf/weft",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	ruleIndex := make(map[string]int)
+	for _, templatePath := range sortedResultPaths(results) {
+		result := results[templatePath]
+		for _, finding := range resultFindings(result) {
+			index, ok := ruleIndex[finding.Type]
+			if !ok {
+				index = len(run.Tool.Driver.Rules)
+				ruleIndex[finding.Type] = index
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: finding.Type})
+			}
+
+			location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(templatePath)}}
+			if finding.Line > 0 {
+				location.Region = &sarifRegion{StartLine: finding.Line, StartColumn: finding.Column}
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    finding.Type,
+				RuleIndex: index,
+				Level:     finding.level,
+				Message:   sarifMessage{Text: findingMessage(finding.ValidationError)},
+				Locations: []sarifLocation{{PhysicalLocation: location}},
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write SARIF report: %w", err)
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes results as JUnit XML, with a test case for each
+// template in a test suite for each directory. Templates with errors fail,
+// listing their errors; warnings are written as the case's output.
+func WriteJUnitReport(w io.Writer, results map[string]ValidationResult) error {
+	report := junitTestSuites{Name: "template validation"}
+	suites := make(map[string]int)
+
+	for _, templatePath := range sortedResultPaths(results) {
+		result := results[templatePath]
+		slashPath := filepath.ToSlash(templatePath)
+		dir := path.Dir(slashPath)
+
+		testCase := junitTestCase{Name: slashPath, ClassName: dir}
+		if result.HasErrors() {
+			lines := make([]string, len(result.Errors))
+			for i, e := range result.Errors {
+				lines[i] = findingLine(slashPath, e)
+			}
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d error(s)", len(result.Errors)),
+				Type:    "validation",
+				Text:    strings.Join(lines, "\n"),
+			}
+			if len(result.Errors) > 0 {
+				testCase.Failure.Type = result.Errors[0].Type
+			}
+		}
+		if len(result.Warnings) > 0 {
+			lines := make([]string, len(result.Warnings))
+			for i, warning := range result.Warnings {
+				lines[i] = findingLine(slashPath, warning)
+			}
+			testCase.SystemOut = strings.Join(lines, "\n")
+		}
+
+		index, ok := suites[dir]
+		if !ok {
+			index = len(report.Suites)
+			suites[dir] = index
+			report.Suites = append(report.Suites, junitTestSuite{Name: dir})
+		}
+		suite := &report.Suites[index]
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		report.Tests++
+		if testCase.Failure != nil {
+			suite.Failures++
+			report.Failures++
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	return nil
+}
+
+type reportFinding struct {
+	ValidationError
+	level string
+}
+
+// resultFindings returns the errors and then the warnings of result.
+func resultFindings(result ValidationResult) []reportFinding {
+	findings := make([]reportFinding, 0, len(result.Errors)+len(result.Warnings))
+	for _, e := range result.Errors {
+		findings = append(findings, reportFinding{e, "error"})
+	}
+	for _, warning := range result.Warnings {
+		findings = append(findings, reportFinding{warning, "warning"})
+	}
+	return findings
+}
+
+func sortedResultPaths(results map[string]ValidationResult) []string {
+	paths := make([]string, 0, len(results))
+	for templatePath := range results {
+		paths = append(paths, templatePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// findingMessage returns the finding's message followed by its suggestion.
+func findingMessage(e ValidationError) string {
+	if e.Suggestion == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, e.Suggestion)
+}
+
+// findingLine formats a finding as "file:line:column: message".
+func findingLine(file string, e ValidationError) string {
+	if e.Line > 0 {
+		file = fmt.Sprintf("%s:%d", file, e.Line)
+		if e.Column > 0 {
+			file = fmt.Sprintf("%s:%d", file, e.Column)
+		}
+	}
+	return fmt.Sprintf("%s: %s", file, findingMessage(e))
+}
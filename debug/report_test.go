@@ -0,0 +1,121 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func reportResults() map[string]ValidationResult {
+	return map[string]ValidationResult{
+		"templates/ok.tmpl": {Valid: true},
+		"templates/bad.tmpl": {
+			Valid: false,
+			Errors: []ValidationError{
+				{Type: "syntax_error", Message: "unexpected EOF", File: "templates/bad.tmpl"},
+				{Type: "brace_mismatch", Message: "Unclosed braces", File: "templates/bad.tmpl", Line: 2, Column: 3, Suggestion: "Add missing closing braces }}"},
+			},
+			Warnings: []ValidationError{{Type: "unknown_function", Message: "Function 'x' is not defined", File: "templates/bad.tmpl", Line: 1, Column: 4}},
+		},
+		"partials/_warn.tmpl": {
+			Valid:    true,
+			Warnings: []ValidationError{{Type: "unknown_function", Message: "Function 'y' is not defined", Line: 5}},
+		},
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSONReport(&buf, reportResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if report.Version != 1 || report.Valid || report.Summary != (jsonSummary{Templates: 3, Errors: 2, Warnings: 2}) {
+		t.Errorf("unexpected report header: %+v", report)
+	}
+	var paths []string
+	for _, entry := range report.Templates {
+		paths = append(paths, entry.Path)
+	}
+	if want := []string{"partials/_warn.tmpl", "templates/bad.tmpl", "templates/ok.tmpl"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	if !strings.Contains(buf.String(), `"errors": []`) {
+		t.Errorf("expected empty lists rather than null:\n%s", buf.String())
+	}
+
+	var again bytes.Buffer
+	WriteJSONReport(&again, reportResults())
+	if again.String() != buf.String() {
+		t.Error("expected the same report for the same results")
+	}
+}
+
+func TestWriteSARIFReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIFReport(&buf, reportResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	run := log.Runs[0]
+	if want := []sarifRule{{ID: "unknown_function"}, {ID: "syntax_error"}, {ID: "brace_mismatch"}}; !reflect.DeepEqual(run.Tool.Driver.Rules, want) {
+		t.Errorf("rules = %+v, want %+v", run.Tool.Driver.Rules, want)
+	}
+	if len(run.Results) != 4 {
+		t.Fatalf("expected 4 results, got %+v", run.Results)
+	}
+
+	braces := run.Results[2]
+	if braces.RuleID != "brace_mismatch" || braces.RuleIndex != 2 || braces.Level != "error" ||
+		braces.Message.Text != "Unclosed braces (Add missing closing braces }})" ||
+		braces.Locations[0].PhysicalLocation.ArtifactLocation.URI != "templates/bad.tmpl" ||
+		*braces.Locations[0].PhysicalLocation.Region != (sarifRegion{StartLine: 2, StartColumn: 3}) {
+		t.Errorf("unexpected result: %+v", braces)
+	}
+	if syntax := run.Results[1]; syntax.Locations[0].PhysicalLocation.Region != nil {
+		t.Errorf("expected no region without a line, got %+v", syntax.Locations[0])
+	}
+	if warning := run.Results[3]; warning.Level != "warning" || warning.RuleIndex != 0 {
+		t.Errorf("unexpected warning: %+v", warning)
+	}
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnitReport(&buf, reportResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="template validation" tests="3" failures="1">
+  <testsuite name="partials" tests="1" failures="0">
+    <testcase name="partials/_warn.tmpl" classname="partials">
+      <system-out>partials/_warn.tmpl:5: Function &#39;y&#39; is not defined</system-out>
+    </testcase>
+  </testsuite>
+  <testsuite name="templates" tests="2" failures="1">
+    <testcase name="templates/bad.tmpl" classname="templates">
+      <failure message="2 error(s)" type="syntax_error">templates/bad.tmpl: unexpected EOF&#xA;templates/bad.tmpl:2:3: Unclosed braces (Add missing closing braces }})</failure>
+      <system-out>templates/bad.tmpl:1:4: Function &#39;x&#39; is not defined</system-out>
+    </testcase>
+    <testcase name="templates/ok.tmpl" classname="templates"></testcase>
+  </testsuite>
+</testsuites>
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
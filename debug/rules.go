@@ -0,0 +1,237 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+	"text/template/parse"
+)
+
+// Severity is how the findings of a lint rule are reported.
+type Severity int
+
+const (
+	// SeverityOff disables a rule.
+	SeverityOff Severity = iota
+	// SeverityWarning reports findings as warnings.
+	SeverityWarning
+	// SeverityError reports findings as errors, which make a template invalid.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityOff:
+		return "off"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSeverity parses "off", "warning" or "error", as used in config files
+// and command line flags.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "off":
+		return SeverityOff, nil
+	case "warning", "warn":
+		return SeverityWarning, nil
+	case "error":
+		return SeverityError, nil
+	}
+	return SeverityOff, fmt.Errorf("unknown severity %q: expected off, warning or error", s)
+}
+
+// Rule is a lint check the TemplateValidator runs on every template. Its ID
+// is the Type of the findings it reports and the name used to override its
+// severity.
+type Rule interface {
+	ID() string
+	// Severity is the rule's default severity.
+	Severity() Severity
+	Check(file *TemplateFile) []ValidationError
+}
+
+// TemplateFile is a template as rules see it.
+type TemplateFile struct {
+	Path    string
+	Content string
+	// Tree is the template's parse tree and Trees holds it together with the
+	// templates it defines, by name. When the template does not parse they
+	// cover the lines before the syntax error, or are nil if those do not
+	// parse either. Functions are not checked when parsing, so calls to
+	// unknown functions still have a tree.
+	Tree  *parse.Tree
+	Trees map[string]*parse.Tree
+
+	tabWidth int
+}
+
+// Position converts a node position to a line and column, counted from 1
+// as the validator reports them.
+func (f *TemplateFile) Position(pos parse.Pos) (int, int) {
+	position := positionAt(f.Content, int(pos), f.tabWidth)
+	return position.Line, position.Column
+}
+
+// NewRule returns a Rule with the given ID and default severity that
+// reports the findings of check.
+func NewRule(id string, severity Severity, check func(file *TemplateFile) []ValidationError) Rule {
+	return &funcRule{id: id, severity: severity, check: check}
+}
+
+type funcRule struct {
+	id       string
+	severity Severity
+	check    func(file *TemplateFile) []ValidationError
+}
+
+func (r *funcRule) ID() string                                 { return r.id }
+func (r *funcRule) Severity() Severity                         { return r.severity }
+func (r *funcRule) Check(file *TemplateFile) []ValidationError { return r.check(file) }
+
+// WalkNodes calls visit for node and, depth first, every node below it,
+// skipping the nodes below any node for which visit returns false.
+func WalkNodes(node parse.Node, visit func(parse.Node) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			WalkNodes(child, visit)
+		}
+	case *parse.ActionNode:
+		walkPipe(n.Pipe, visit)
+	case *parse.PipeNode:
+		for _, variable := range n.Decl {
+			WalkNodes(variable, visit)
+		}
+		for _, cmd := range n.Cmds {
+			WalkNodes(cmd, visit)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			WalkNodes(arg, visit)
+		}
+	case *parse.ChainNode:
+		WalkNodes(n.Node, visit)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, visit)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, visit)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, visit)
+	case *parse.TemplateNode:
+		walkPipe(n.Pipe, visit)
+	}
+}
+
+func walkBranch(branch *parse.BranchNode, visit func(parse.Node) bool) {
+	walkPipe(branch.Pipe, visit)
+	if branch.List != nil {
+		WalkNodes(branch.List, visit)
+	}
+	if branch.ElseList != nil {
+		WalkNodes(branch.ElseList, visit)
+	}
+}
+
+func walkPipe(pipe *parse.PipeNode, visit func(parse.Node) bool) {
+	if pipe != nil {
+		WalkNodes(pipe, visit)
+	}
+}
+
+// builtinRules returns the rules every validator starts with, which report
+// findings through the validator's own checks.
+func (tv *TemplateValidator) builtinRules() []Rule {
+	return []Rule{
+		tv.checkRule("brace_mismatch", SeverityError, tv.validateBraceBalance),
+		tv.checkRule("whitespace_warning", SeverityWarning, tv.validateWhitespace),
+		NewRule("unknown_function", SeverityWarning, tv.validateFunctions),
+		NewRule("deep_access", SeverityWarning, tv.validateVariableAccess),
+		NewRule("deprecated_function", SeverityWarning, tv.validateDeprecatedFunctions),
+		NewRule("circular_include", SeverityError, func(file *TemplateFile) []ValidationError {
+			return tv.validateCycles(file, false)
+		}),
+		NewRule("recursive_partial", SeverityWarning, func(file *TemplateFile) []ValidationError {
+			return tv.validateCycles(file, true)
+		}),
+	}
+}
+
+// checkRule adapts a validator check to a Rule.
+func (tv *TemplateValidator) checkRule(id string, severity Severity, check func(templatePath, content string, result *ValidationResult)) Rule {
+	return NewRule(id, severity, func(file *TemplateFile) []ValidationError {
+		var result ValidationResult
+		check(file.Path, file.Content, &result)
+		return append(result.Errors, result.Warnings...)
+	})
+}
+
+// AddRule registers a rule to run on every template after the built-in
+// rules, replacing any rule with the same ID.
+func (tv *TemplateValidator) AddRule(rule Rule) {
+	for i, existing := range tv.rules {
+		if existing.ID() == rule.ID() {
+			tv.rules[i] = rule
+			return
+		}
+	}
+	tv.rules = append(tv.rules, rule)
+}
+
+// SetRuleSeverity overrides the severity of the rule with the given ID, or
+// of unused_partial findings, which ValidateDirectory reports. SeverityOff
+// disables it.
+func (tv *TemplateValidator) SetRuleSeverity(id string, severity Severity) {
+	if tv.severities == nil {
+		tv.severities = make(map[string]Severity)
+	}
+	tv.severities[id] = severity
+}
+
+func (tv *TemplateValidator) ruleSeverity(rule Rule) Severity {
+	return tv.severity(rule.ID(), rule.Severity())
+}
+
+// severity returns the severity set for findings of type id, or fallback.
+func (tv *TemplateValidator) severity(id string, fallback Severity) Severity {
+	if severity, ok := tv.severities[id]; ok {
+		return severity
+	}
+	return fallback
+}
+
+// validateRules runs the validator's rules and files their findings under
+// the rule's severity. Findings without a Type or File get the rule's ID and
+// the template's path.
+func (tv *TemplateValidator) validateRules(file *TemplateFile, result *ValidationResult) {
+	for _, rule := range tv.rules {
+		severity := tv.ruleSeverity(rule)
+		if severity == SeverityOff {
+			continue
+		}
+		for _, finding := range rule.Check(file) {
+			if finding.Type == "" {
+				finding.Type = rule.ID()
+			}
+			if finding.File == "" {
+				finding.File = file.Path
+			}
+			if severity == SeverityError {
+				result.Valid = false
+				result.Errors = append(result.Errors, finding)
+			} else {
+				result.Warnings = append(result.Warnings, finding)
+			}
+		}
+	}
+}
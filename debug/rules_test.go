@@ -0,0 +1,110 @@
+package debug
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"text/template"
+	"text/template/parse"
+)
+
+// noEnvRule reports calls to the env function.
+var noEnvRule = NewRule("no_env", SeverityError, func(file *TemplateFile) []ValidationError {
+	if file.Tree == nil {
+		return nil
+	}
+	var findings []ValidationError
+	WalkNodes(file.Tree.Root, func(node parse.Node) bool {
+		if ident, ok := node.(*parse.IdentifierNode); ok && ident.Ident == "env" {
+			line, column := file.Position(ident.Pos)
+			findings = append(findings, ValidationError{
+				Message: "Templates must not read the environment",
+				Line:    line,
+				Column:  column,
+			})
+		}
+		return true
+	})
+	return findings
+})
+
+func TestTemplateValidator_CustomRule(t *testing.T) {
+	content := "Name\n{{ if .Debug }}\n\t{{ env \"HOME\" | printf \"%s\" }}{{ end }}"
+	validator := NewTemplateValidator(fstest.MapFS{"t.tmpl": {Data: []byte(content)}}, template.FuncMap{"env": os.Getenv}, nil)
+	validator.AddRule(noEnvRule)
+
+	result := validator.ValidateTemplate("t.tmpl")
+	want := []ValidationError{{Type: "no_env", Message: "Templates must not read the environment", File: "t.tmpl", Line: 3, Column: 5}}
+	if !reflect.DeepEqual(result.Errors, want) || result.Valid {
+		t.Errorf("got %+v (valid %v), want %+v", result.Errors, result.Valid, want)
+	}
+
+	validator.SetRuleSeverity("no_env", SeverityWarning)
+	result = validator.ValidateTemplate("t.tmpl")
+	if len(result.Errors) != 0 || len(result.Warnings) != 1 || !result.Valid {
+		t.Errorf("expected the finding as a warning, got %+v", result)
+	}
+}
+
+func TestTemplateValidator_RuleSeverity(t *testing.T) {
+	testFS := fstest.MapFS{"t.tmpl": {Data: []byte("{{ missing .Name }} }}")}}
+
+	validator := NewTemplateValidator(testFS, template.FuncMap{}, nil)
+	result := validator.ValidateTemplate("t.tmpl")
+	if got := findingTypes(result); got != "errors=[syntax_error brace_mismatch] warnings=[unknown_function]" {
+		t.Errorf("defaults: got %s", got)
+	}
+
+	validator.SetRuleSeverity("unknown_function", SeverityError)
+	validator.SetRuleSeverity("brace_mismatch", SeverityOff)
+	result = validator.ValidateTemplate("t.tmpl")
+	if got := findingTypes(result); got != "errors=[syntax_error unknown_function] warnings=[]" {
+		t.Errorf("overrides: got %s", got)
+	}
+}
+
+func TestTemplateValidator_AddRuleReplacesBuiltin(t *testing.T) {
+	validator := NewTemplateValidator(fstest.MapFS{"t.tmpl": {Data: []byte("{{ .A.B.C.D.E.F.G }}")}}, nil, nil)
+	validator.AddRule(NewRule("deep_access", SeverityWarning, func(file *TemplateFile) []ValidationError { return nil }))
+
+	if result := validator.ValidateTemplate("t.tmpl"); len(result.Warnings) != 0 {
+		t.Errorf("expected the replaced rule to report nothing, got %+v", result.Warnings)
+	}
+}
+
+func TestIncrementalValidator_RuleSeverityChange(t *testing.T) {
+	validator := NewTemplateValidator(fstest.MapFS{"t.tmpl": {Data: []byte("{{ missing }}")}}, template.FuncMap{}, nil)
+	iv := NewIncrementalValidator(validator)
+
+	if result := iv.ValidateTemplate("t.tmpl"); len(result.Warnings) != 1 {
+		t.Fatalf("expected a warning, got %+v", result)
+	}
+	validator.SetRuleSeverity("unknown_function", SeverityError)
+	if got := findingTypes(iv.ValidateTemplate("t.tmpl")); got != "errors=[syntax_error unknown_function] warnings=[]" {
+		t.Errorf("expected the cached result to be replaced, got %s", got)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for input, want := range map[string]Severity{"off": SeverityOff, "Warning": SeverityWarning, "warn": SeverityWarning, " error ": SeverityError} {
+		if got, err := ParseSeverity(input); err != nil || got != want {
+			t.Errorf("ParseSeverity(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
+
+func findingTypes(result ValidationResult) string {
+	errs, warnings := []string{}, []string{}
+	for _, e := range result.Errors {
+		errs = append(errs, e.Type)
+	}
+	for _, w := range result.Warnings {
+		warnings = append(warnings, w.Type)
+	}
+	return fmt.Sprintf("errors=%v warnings=%v", errs, warnings)
+}
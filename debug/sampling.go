@@ -0,0 +1,112 @@
+package debug
+
+import (
+	"sync"
+	"time"
+)
+
+// WithSampling logs only every nth record of each message at level, such
+// as every 100th "template executed" record at LevelDebug. The first
+// record of each message is always logged. Records sampled out are counted
+// in the stats' SampledOut.
+func WithSampling(level DebugLevel, every int) DebugOption {
+	return func(dm *DebugMode) {
+		if every > 1 && isValidDebugLevel(level) {
+			dm.logLimits().every[level] = every
+		}
+	}
+}
+
+// WithRateLimit limits logging to perSecond records a second on average,
+// allowing bursts of up to burst records. Error records are never limited.
+// Records dropped are counted in the stats' RateLimited.
+func WithRateLimit(perSecond float64, burst int) DebugOption {
+	return func(dm *DebugMode) {
+		if perSecond > 0 && burst > 0 {
+			limits := dm.logLimits()
+			limits.rate = perSecond
+			limits.burst = float64(burst)
+			limits.tokens = float64(burst)
+		}
+	}
+}
+
+// logLimits samples and rate limits log records.
+type logLimits struct {
+	mu     sync.Mutex
+	every  map[DebugLevel]int
+	seen   map[sampleKey]int
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+
+	sampledOut  int64
+	rateLimited int64
+}
+
+type sampleKey struct {
+	level DebugLevel
+	msg   string
+}
+
+func newLogLimits() *logLimits {
+	return &logLimits{
+		every: make(map[DebugLevel]int),
+		seen:  make(map[sampleKey]int),
+		now:   time.Now,
+	}
+}
+
+// logLimits returns the debug mode's limits, creating them on first use.
+func (dm *DebugMode) logLimits() *logLimits {
+	if dm.limits == nil {
+		dm.limits = newLogLimits()
+	}
+	return dm.limits
+}
+
+// allow reports whether a record is logged under the sampling and rate
+// limits, if there are any.
+func (dm *DebugMode) allow(level DebugLevel, msg string) bool {
+	return dm.limits == nil || dm.limits.allow(level, msg)
+}
+
+// allow reports whether a record with msg at level is logged, counting it
+// against the sampling and rate limits.
+func (l *logLimits) allow(level DebugLevel, msg string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if every := l.every[level]; every > 1 {
+		key := sampleKey{level, msg}
+		n := l.seen[key]
+		l.seen[key] = n + 1
+		if n%every != 0 {
+			l.sampledOut++
+			return false
+		}
+	}
+
+	if l.rate == 0 || level == LevelError {
+		return true
+	}
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		l.rateLimited++
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+func (l *logLimits) dropped() (sampledOut, rateLimited int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sampledOut, l.rateLimited
+}
@@ -0,0 +1,70 @@
+package debug
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithSampling(t *testing.T) {
+	var buf bytes.Buffer
+	dm := NewDebugMode(WithLevel(LevelDebug), WithOutput(&buf), WithSampling(LevelDebug, 3))
+
+	for i := range 7 {
+		dm.LogTemplateExecution("t.tmpl", nil, time.Duration(i))
+		dm.Debug("file written")
+	}
+	dm.Info("render started")
+	dm.Info("render started")
+
+	if got := strings.Count(buf.String(), "template executed"); got != 3 {
+		t.Errorf("Expected 3 of 7 template executed records, got %d", got)
+	}
+	if got := strings.Count(buf.String(), "file written"); got != 3 {
+		t.Errorf("Expected messages to be sampled separately, got %d file written records", got)
+	}
+	if got := strings.Count(buf.String(), "render started"); got != 2 {
+		t.Errorf("Expected other levels not to be sampled, got %d records", got)
+	}
+	if stats := dm.GetStats(); stats.SampledOut != 8 || stats.RateLimited != 0 {
+		t.Errorf("Expected 8 records sampled out, got %+v", stats)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	var buf bytes.Buffer
+	dm := NewDebugMode(WithLevel(LevelInfo), WithOutput(&buf), WithRateLimit(2, 3))
+	now := time.Unix(0, 0)
+	dm.limits.now = func() time.Time { return now }
+
+	for range 5 {
+		dm.Info("tick")
+	}
+	dm.Error("failed")
+	if got := strings.Count(buf.String(), "tick"); got != 3 {
+		t.Errorf("Expected a burst of 3 records, got %d", got)
+	}
+	if !strings.Contains(buf.String(), "failed") {
+		t.Error("Expected error records not to be rate limited")
+	}
+
+	// A second refills two tokens.
+	now = now.Add(time.Second)
+	for range 5 {
+		dm.Info("tock")
+	}
+	if got := strings.Count(buf.String(), "tock"); got != 2 {
+		t.Errorf("Expected 2 records after a second, got %d", got)
+	}
+	if stats := dm.GetStats(); stats.RateLimited != 5 {
+		t.Errorf("Expected 5 records rate limited, got %d", stats.RateLimited)
+	}
+}
+
+func TestLimitOptionsIgnoreInvalidValues(t *testing.T) {
+	dm := NewDebugMode(WithSampling(LevelDebug, 1), WithSampling(DebugLevel(99), 5), WithRateLimit(0, 10))
+	if dm.limits != nil {
+		t.Errorf("Expected no limits, got %+v", dm.limits)
+	}
+}
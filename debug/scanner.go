@@ -0,0 +1,171 @@
+package debug
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Position is a 1-based line and column in template source. Columns count
+// runes, with tabs advancing to the next tab stop when a tab width is set.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// templateScanner walks template source rune by rune while tracking
+// rune-accurate positions.
+type templateScanner struct {
+	content  string
+	offset   int
+	line     int
+	col      int
+	tabWidth int
+}
+
+func newTemplateScanner(content string, tabWidth int) *templateScanner {
+	if tabWidth < 1 {
+		tabWidth = 1
+	}
+	return &templateScanner{
+		content:  content,
+		line:     1,
+		col:      1,
+		tabWidth: tabWidth,
+	}
+}
+
+func (s *templateScanner) done() bool {
+	return s.offset >= len(s.content)
+}
+
+func (s *templateScanner) pos() Position {
+	return Position{Line: s.line, Column: s.col}
+}
+
+func (s *templateScanner) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(s.content[s.offset:], prefix)
+}
+
+func (s *templateScanner) peek() rune {
+	if s.done() {
+		return utf8.RuneError
+	}
+	r, _ := utf8.DecodeRuneInString(s.content[s.offset:])
+	return r
+}
+
+// advance consumes one rune.
+func (s *templateScanner) advance() {
+	if s.done() {
+		return
+	}
+
+	r, size := utf8.DecodeRuneInString(s.content[s.offset:])
+	s.offset += size
+
+	switch r {
+	case '\n':
+		s.line++
+		s.col = 1
+	case '\t':
+		s.col += s.tabWidth - (s.col-1)%s.tabWidth
+	default:
+		s.col++
+	}
+}
+
+// skip consumes n runes.
+func (s *templateScanner) skip(n int) {
+	for range n {
+		s.advance()
+	}
+}
+
+// skipSpaces consumes spaces, tabs and newlines.
+func (s *templateScanner) skipSpaces() {
+	for !s.done() && strings.ContainsRune(" \t\r\n", s.peek()) {
+		s.advance()
+	}
+}
+
+// atCommentStart reports whether the scanner, positioned just after "{{",
+// is at the start of a template comment ("/*" or "- /*").
+func (s *templateScanner) atCommentStart() bool {
+	rest := s.content[s.offset:]
+	if strings.HasPrefix(rest, "- ") {
+		rest = strings.TrimLeft(rest[1:], " \t\r\n")
+	}
+	return strings.HasPrefix(rest, "/*")
+}
+
+// skipComment consumes a template comment and its closing delimiter. It
+// reports whether the action was closed; an unterminated comment or a comment
+// followed by anything other than "}}" leaves the action open.
+func (s *templateScanner) skipComment() bool {
+	for !s.hasPrefix("/*") {
+		s.advance()
+	}
+	s.skip(2)
+
+	for !s.done() && !s.hasPrefix("*/") {
+		s.advance()
+	}
+	if s.done() {
+		return false
+	}
+	s.skip(2)
+
+	s.skipSpaces()
+	if s.hasPrefix("-") {
+		s.advance()
+		s.skipSpaces()
+	}
+	if s.hasPrefix("}}") {
+		s.skip(2)
+		return true
+	}
+	return false
+}
+
+// skipQuoted consumes a quoted string, raw string or character constant
+// starting at the current rune. Interpreted strings and character constants
+// end at the end of the line if unterminated; raw strings may span lines.
+func (s *templateScanner) skipQuoted() {
+	quote := s.peek()
+	s.advance()
+
+	for !s.done() {
+		r := s.peek()
+		switch {
+		case r == quote:
+			s.advance()
+			return
+		case r == '\\' && quote != '`':
+			s.skip(2)
+		case r == '\n' && quote != '`':
+			return
+		default:
+			s.advance()
+		}
+	}
+}
+
+// displayColumn returns the 1-based column just after text, using the same
+// rules as templateScanner.
+func displayColumn(text string, tabWidth int) int {
+	s := newTemplateScanner(text, tabWidth)
+	for !s.done() {
+		s.advance()
+	}
+	return s.col
+}
+
+// positionAt returns the position of the byte offset in content.
+func positionAt(content string, offset, tabWidth int) Position {
+	offset = min(max(offset, 0), len(content))
+	lineStart := strings.LastIndex(content[:offset], "\n") + 1
+	return Position{
+		Line:   strings.Count(content[:offset], "\n") + 1,
+		Column: displayColumn(content[lineStart:offset], tabWidth),
+	}
+}
@@ -0,0 +1,87 @@
+package debug
+
+import (
+	"reflect"
+	"sync"
+)
+
+// secretRegistry holds values that must never appear in debug output, such
+// as credentials decrypted from configuration, whatever field they end up
+// in.
+type secretRegistry struct {
+	mu     sync.RWMutex
+	values map[string]struct{}
+}
+
+var globalSecrets = &secretRegistry{values: make(map[string]struct{})}
+
+// MarkSecret registers values that debug helpers and trace logging redact
+// wherever they appear. Empty values are ignored.
+func MarkSecret(values ...string) {
+	globalSecrets.mu.Lock()
+	defer globalSecrets.mu.Unlock()
+	for _, v := range values {
+		if v != "" {
+			globalSecrets.values[v] = struct{}{}
+		}
+	}
+}
+
+// IsSecret reports whether value was registered with MarkSecret.
+func IsSecret(value string) bool {
+	globalSecrets.mu.RLock()
+	defer globalSecrets.mu.RUnlock()
+	_, ok := globalSecrets.values[value]
+	return ok
+}
+
+// ResetSecrets forgets every value registered with MarkSecret.
+func ResetSecrets() {
+	globalSecrets.mu.Lock()
+	defer globalSecrets.mu.Unlock()
+	globalSecrets.values = make(map[string]struct{})
+}
+
+func hasSecrets() bool {
+	globalSecrets.mu.RLock()
+	defer globalSecrets.mu.RUnlock()
+	return len(globalSecrets.values) > 0
+}
+
+// containsSecret reports whether v holds a registered secret anywhere in its
+// strings, slices, maps, structs or pointers.
+func containsSecret(v reflect.Value) bool {
+	return containsSecretDepth(v, 0)
+}
+
+func containsSecretDepth(v reflect.Value, depth int) bool {
+	if !v.IsValid() || depth > 32 {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return IsSecret(v.String())
+	case reflect.Pointer, reflect.Interface:
+		return !v.IsNil() && containsSecretDepth(v.Elem(), depth+1)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if containsSecretDepth(v.Index(i), depth+1) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if containsSecretDepth(iter.Value(), depth+1) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() && containsSecretDepth(v.Field(i), depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
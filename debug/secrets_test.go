@@ -0,0 +1,55 @@
+package debug
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkSecret(t *testing.T) {
+	defer ResetSecrets()
+	MarkSecret("s3cr3t-value", "")
+
+	if !IsSecret("s3cr3t-value") || IsSecret("") || IsSecret("public") {
+		t.Fatal("IsSecret does not reflect the registered values")
+	}
+
+	dm := NewDebugMode(WithLevel(LevelDebug))
+	type endpoint struct {
+		Host  string
+		Token string
+		Value string
+	}
+	inputs := map[string]any{
+		"string": "s3cr3t-value",
+		"slice":  []string{"a", "s3cr3t-value"},
+		"map":    map[string]any{"host": "db", "dsn": "s3cr3t-value"},
+		"struct": endpoint{Host: "db", Value: "s3cr3t-value"},
+		"ptr":    &endpoint{Value: "s3cr3t-value"},
+	}
+	for name, input := range inputs {
+		for fn, format := range map[string]func(any) string{
+			"debug":       debugValue(dm),
+			"debugJSON":   debugJSON(dm),
+			"debugPretty": debugPretty(dm),
+		} {
+			out := format(input)
+			if strings.Contains(out, "s3cr3t-value") {
+				t.Errorf("%s(%s) leaked the secret: %s", fn, name, out)
+			}
+		}
+	}
+
+	if got := debugValue(dm)("public"); got != `string(6): "public"` {
+		t.Errorf("debug(public) = %q", got)
+	}
+
+	sanitized := sanitizeDataForLogging(map[string]any{"dsn": "s3cr3t-value", "name": "users"}).(map[string]any)
+	if sanitized["dsn"] != "[REDACTED]" || sanitized["name"] != "users" {
+		t.Errorf("sanitizeDataForLogging() = %v", sanitized)
+	}
+
+	ResetSecrets()
+	if IsSecret("s3cr3t-value") {
+		t.Error("ResetSecrets did not forget the value")
+	}
+}
@@ -0,0 +1,112 @@
+package debug
+
+import (
+	"crypto/sha256"
+	"slices"
+	"sync"
+	"text/template/parse"
+)
+
+// templateSummary is what validating a directory needs to know about a
+// template beyond its own result: what it references, defines and calls.
+type templateSummary struct {
+	calls []templateCall
+	// defines holds the names of the templates the file defines, sorted,
+	// and called the names it calls other than from a define to itself.
+	// Both are empty when the file does not parse.
+	defines []string
+	called  map[string]bool
+}
+
+func summarize(file *TemplateFile) *templateSummary {
+	summary := &templateSummary{calls: file.calls(), called: make(map[string]bool)}
+	if file.Tree == nil {
+		return summary
+	}
+	for name, tree := range file.Trees {
+		if name != file.Path {
+			summary.defines = append(summary.defines, name)
+		}
+		WalkNodes(tree.Root, func(node parse.Node) bool {
+			if call, ok := node.(*parse.TemplateNode); ok && call.Name != name {
+				summary.called[call.Name] = true
+			}
+			return true
+		})
+	}
+	slices.Sort(summary.defines)
+	return summary
+}
+
+// summary returns the summary of the template at templatePath with the
+// given content, from the validator's summary cache when it has one.
+func (tv *TemplateValidator) summary(templatePath, content string) *templateSummary {
+	if tv.summaries == nil {
+		return summarize(tv.parseFile(templatePath, content))
+	}
+	return tv.summaries.get(templatePath, content, func() *templateSummary {
+		return summarize(tv.parseFile(templatePath, content))
+	})
+}
+
+// summaryCache holds template summaries by a hash of the template's path
+// and content, so files that have not changed are not parsed again. Entries
+// not used between two sweeps are dropped.
+type summaryCache struct {
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*summaryEntry
+	parsed  int64
+}
+
+type summaryEntry struct {
+	summary *templateSummary
+	used    bool
+}
+
+func newSummaryCache() *summaryCache {
+	return &summaryCache{entries: make(map[[sha256.Size]byte]*summaryEntry)}
+}
+
+func (c *summaryCache) get(templatePath, content string, compute func() *templateSummary) *templateSummary {
+	key := sha256.Sum256([]byte(templatePath + "\x00" + content))
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok {
+		entry.used = true
+		c.mu.Unlock()
+		return entry.summary
+	}
+	c.parsed++
+	c.mu.Unlock()
+
+	summary := compute()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = &summaryEntry{summary: summary, used: true}
+	return summary
+}
+
+// sweep drops the entries not used since the previous sweep.
+func (c *summaryCache) sweep() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if !entry.used {
+			delete(c.entries, key)
+			continue
+		}
+		entry.used = false
+	}
+}
+
+func (c *summaryCache) parsedCount() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.parsed
+}
+
+func (c *summaryCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[[sha256.Size]byte]*summaryEntry)
+	c.parsed = 0
+}
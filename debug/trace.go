@@ -0,0 +1,293 @@
+package debug
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// Functions templates instrumented for tracing call around each action.
+const (
+	traceEnterFunc = "weftTraceEnter"
+	traceExitFunc  = "weftTraceExit"
+)
+
+// TraceNode is the time an execution spent in one action and in the
+// actions run inside it: the body of an if, range or with, or the define a
+// template action calls. An action run more than once in the same place,
+// such as one in the body of a range, is a single node holding the number
+// of runs and their total time.
+type TraceNode struct {
+	// Template is the file holding the action, and Line and Column its
+	// position, counting from 1. The root node of a trace is the template
+	// executed and has no position.
+	Template string `json:"template"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	// Define is the define or block holding the action, or "" for the
+	// template itself.
+	Define string `json:"define,omitempty"`
+	// Action is the source of the action, such as "{{.Name | upper}}", or
+	// only the action opening it for if, range and with actions.
+	Action string `json:"action,omitempty"`
+	// Funcs names the functions the action's pipeline calls.
+	Funcs    []string      `json:"funcs,omitempty"`
+	Count    int           `json:"count"`
+	Duration time.Duration `json:"duration"`
+	Children []*TraceNode  `json:"children,omitempty"`
+
+	point int
+}
+
+// Self returns the time spent in the node itself rather than its children.
+func (n *TraceNode) Self() time.Duration {
+	self := n.Duration
+	for _, child := range n.Children {
+		self -= child.Duration
+	}
+	return max(self, 0)
+}
+
+// Slowest returns up to limit nodes below n, slowest first by their own
+// time, to show where an execution spent its time.
+func (n *TraceNode) Slowest(limit int) []*TraceNode {
+	var nodes []*TraceNode
+	var collect func(*TraceNode)
+	collect = func(node *TraceNode) {
+		for _, child := range node.Children {
+			nodes = append(nodes, child)
+			collect(child)
+		}
+	}
+	collect(n)
+	slices.SortStableFunc(nodes, func(a, b *TraceNode) int {
+		return cmp.Compare(b.Self(), a.Self())
+	})
+	return nodes[:min(limit, len(nodes))]
+}
+
+// String returns the trace as an indented tree, one node per line.
+func (n *TraceNode) String() string {
+	var b strings.Builder
+	n.write(&b, 0)
+	return b.String()
+}
+
+func (n *TraceNode) write(w io.Writer, depth int) {
+	indent := strings.Repeat("  ", depth)
+	if n.Line == 0 {
+		fmt.Fprintf(w, "%s%s total=%v\n", indent, n.Template, n.Duration)
+	} else {
+		define := ""
+		if n.Define != "" {
+			define = n.Define + " "
+		}
+		fmt.Fprintf(w, "%s%s:%d:%d %s%s count=%d total=%v self=%v\n",
+			indent, n.Template, n.Line, n.Column, define, n.Action, n.Count, n.Duration, n.Self())
+	}
+	for _, child := range n.Children {
+		child.write(w, depth+1)
+	}
+}
+
+// traceSession is the state of one traced execution.
+type traceSession struct {
+	points []tracePoint
+	root   *TraceNode
+	stack  []traceFrame
+}
+
+// tracePoint is an action an instrumented template times.
+type tracePoint struct {
+	template     string
+	line, column int
+	define       string
+	action       string
+	funcs        []string
+}
+
+// traceFrame is an action being run.
+type traceFrame struct {
+	node  *TraceNode
+	start time.Time
+}
+
+func newTraceSession(name string) *traceSession {
+	return &traceSession{root: &TraceNode{Template: name, Count: 1, point: -1}}
+}
+
+// instrument returns a copy of tmpl that calls traceEnterFunc before and
+// traceExitFunc after each action.
+func (s *traceSession) instrument(tmpl *template.Template) (*template.Template, error) {
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	clone.Funcs(template.FuncMap{traceEnterFunc: s.enter, traceExitFunc: s.exit})
+
+	copies := make(map[*parse.Tree]*parse.Tree)
+	for _, t := range clone.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		if instrumented, ok := copies[t.Tree]; ok {
+			t.Tree = instrumented
+			continue
+		}
+		tree := t.Tree.Copy()
+		copies[t.Tree] = tree
+		t.Tree = tree
+		if err := s.instrumentTree(tree); err != nil {
+			return nil, err
+		}
+	}
+	return clone, nil
+}
+
+func (s *traceSession) instrumentTree(tree *parse.Tree) error {
+	define := ""
+	if tree.Name != tree.ParseName {
+		define = tree.Name
+	}
+
+	var visit func(list *parse.ListNode) error
+	visit = func(list *parse.ListNode) error {
+		if list == nil {
+			return nil
+		}
+		var nodes []parse.Node
+		for _, node := range list.Nodes {
+			var pipe *parse.PipeNode
+			switch n := node.(type) {
+			case *parse.TextNode, *parse.CommentNode:
+				nodes = append(nodes, node)
+				continue
+			case *parse.ActionNode:
+				pipe = n.Pipe
+			case *parse.TemplateNode:
+				pipe = n.Pipe
+			case *parse.IfNode:
+				pipe = n.Pipe
+				if err := visitArms(&n.BranchNode, visit); err != nil {
+					return err
+				}
+			case *parse.RangeNode:
+				pipe = n.Pipe
+				if err := visitArms(&n.BranchNode, visit); err != nil {
+					return err
+				}
+			case *parse.WithNode:
+				pipe = n.Pipe
+				if err := visitArms(&n.BranchNode, visit); err != nil {
+					return err
+				}
+			}
+
+			index := len(s.points)
+			line, column := nodePosition(tree, node)
+			s.points = append(s.points, tracePoint{
+				template: tree.ParseName,
+				line:     line,
+				column:   column,
+				define:   define,
+				action:   actionSource(node, define),
+				funcs:    pipeFuncs(pipe),
+			})
+			enter, err := parseCall(traceEnterFunc, fmt.Sprintf("{{%s %d}}", traceEnterFunc, index))
+			if err != nil {
+				return err
+			}
+			exit, err := parseCall(traceExitFunc, fmt.Sprintf("{{%s %d}}", traceExitFunc, index))
+			if err != nil {
+				return err
+			}
+			nodes = append(nodes, enter, node, exit)
+		}
+		list.Nodes = nodes
+		return nil
+	}
+	return visit(tree.Root)
+}
+
+// visitArms visits the arms of an if, range or with action.
+func visitArms(branch *parse.BranchNode, visit func(*parse.ListNode) error) error {
+	if err := visit(branch.List); err != nil {
+		return err
+	}
+	return visit(branch.ElseList)
+}
+
+// pipeFuncs returns the names of the functions pipe calls, in order.
+func pipeFuncs(pipe *parse.PipeNode) []string {
+	var funcs []string
+	if pipe == nil {
+		return nil
+	}
+	WalkNodes(pipe, func(node parse.Node) bool {
+		if ident, ok := node.(*parse.IdentifierNode); ok && !slices.Contains(funcs, ident.Ident) {
+			funcs = append(funcs, ident.Ident)
+		}
+		return true
+	})
+	return funcs
+}
+
+// execute runs the instrumented tmpl and returns its trace, which covers
+// the actions run before any error.
+func (s *traceSession) execute(w io.Writer, tmpl *template.Template, data any) (*TraceNode, error) {
+	s.stack = []traceFrame{{node: s.root, start: time.Now()}}
+	err := tmpl.Execute(w, data)
+	s.close(0)
+	return s.root, err
+}
+
+// enter is called by instrumented templates before the action with the
+// given index.
+func (s *traceSession) enter(index int) string {
+	parent := s.stack[len(s.stack)-1].node
+	i := slices.IndexFunc(parent.Children, func(child *TraceNode) bool { return child.point == index })
+	if i < 0 {
+		p := s.points[index]
+		parent.Children = append(parent.Children, &TraceNode{
+			Template: p.template,
+			Line:     p.line,
+			Column:   p.column,
+			Define:   p.define,
+			Action:   p.action,
+			Funcs:    p.funcs,
+			point:    index,
+		})
+		i = len(parent.Children) - 1
+	}
+	node := parent.Children[i]
+	node.Count++
+	s.stack = append(s.stack, traceFrame{node: node, start: time.Now()})
+	return ""
+}
+
+// exit is called by instrumented templates after the action with the given
+// index. Actions entered since and not exited were left by a break or
+// continue, and end here too.
+func (s *traceSession) exit(index int) string {
+	for i := len(s.stack) - 1; i > 0; i-- {
+		if s.stack[i].node.point == index {
+			s.close(i)
+			break
+		}
+	}
+	return ""
+}
+
+// close ends the frames from depth up.
+func (s *traceSession) close(depth int) {
+	now := time.Now()
+	for _, frame := range s.stack[depth:] {
+		frame.node.Duration += now.Sub(frame.start)
+	}
+	s.stack = s.stack[:depth]
+}
@@ -0,0 +1,75 @@
+package debug
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// traceShape returns the nodes of a trace as "location action count=n",
+// indented by depth, leaving out the times.
+func traceShape(node *TraceNode, depth int) []string {
+	shape := []string{fmt.Sprintf("%s%s:%d:%d %s count=%d funcs=%v", strings.Repeat("  ", depth), node.Template, node.Line, node.Column, node.Action, node.Count, node.Funcs)}
+	for _, child := range node.Children {
+		if child.Duration > node.Duration {
+			shape = append(shape, "child slower than parent: "+child.Action)
+		}
+		shape = append(shape, traceShape(child, depth+1)...)
+	}
+	return shape
+}
+
+func TestTemplateDebugger_Trace(t *testing.T) {
+	source := `{{ $n := len .Items }}{{ range .Items }}{{ if eq . 2 }}{{ break }}{{ end }}{{ template "item" . }}{{ end }}
+{{ define "item" }}{{ . | printf "%03d" }}{{ end }}`
+	tmpl := template.Must(template.New("list.tmpl").Parse(source))
+
+	var logs bytes.Buffer
+	debugger := NewTemplateDebugger(NewDebugMode(WithLevel(LevelTrace), WithOutput(&logs)))
+	output, err := debugger.ExecuteWithDebug("list", tmpl, map[string]any{"Items": []int{1, 2, 3}})
+	if err != nil || output != "001\n" {
+		t.Fatalf("ExecuteWithDebug() = %q, %v", output, err)
+	}
+
+	executions := debugger.GetExecutions()
+	trace := executions[len(executions)-1].Trace
+	if trace == nil {
+		t.Fatal("Expected a trace at trace level")
+	}
+	want := []string{
+		`list:0:0  count=1 funcs=[]`,
+		`  list.tmpl:1:4 {{$n := len .Items}} count=1 funcs=[len]`,
+		`  list.tmpl:1:32 {{range .Items}} count=1 funcs=[]`,
+		`    list.tmpl:1:47 {{if eq . 2}} count=2 funcs=[eq]`,
+		`      list.tmpl:1:59 {{break}} count=1 funcs=[]`,
+		`    list.tmpl:1:88 {{template "item" .}} count=1 funcs=[]`,
+		`      list.tmpl:2:23 {{. | printf "%03d"}} count=1 funcs=[printf]`,
+	}
+	if got := traceShape(trace, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("trace:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if slowest := trace.Slowest(2); len(slowest) != 2 || slowest[0].Self() < slowest[1].Self() {
+		t.Errorf("Slowest(2) = %v", slowest)
+	}
+	if !strings.Contains(trace.String(), "list.tmpl:2:23 item {{. | printf \"%03d\"}} count=1 total=") {
+		t.Errorf("String() = %s", trace)
+	}
+	if !strings.Contains(logs.String(), "template trace") {
+		t.Errorf("Expected the trace to be logged, got:\n%s", logs.String())
+	}
+}
+
+func TestTemplateDebugger_TraceOnlyAtTraceLevel(t *testing.T) {
+	tmpl := template.Must(template.New("t").Parse("{{ .Name }}"))
+	debugger := NewTemplateDebugger(NewDebugMode(WithLevel(LevelDebug), WithOutput(&bytes.Buffer{})))
+	if _, err := debugger.ExecuteWithDebug("t", tmpl, map[string]string{"Name": "trace-level"}); err != nil {
+		t.Fatal(err)
+	}
+	if trace := debugger.GetExecutions()[0].Trace; trace != nil {
+		t.Errorf("Expected no trace below trace level, got:\n%s", trace)
+	}
+}
@@ -0,0 +1,160 @@
+package debug
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// reportUnused adds findings for the dead code in a directory of templates
+// to its results: partials and layouts no entry template uses, and defines
+// that neither entry templates nor the partials and macros they use call.
+func (tv *TemplateValidator) reportUnused(results map[string]ValidationResult) {
+	unused := tv.unusedPartials(results)
+	tv.reportUnusedPartials(results, unused)
+	tv.reportUnusedDefines(results, unused)
+}
+
+// isPartialFile reports whether templatePath is a partial or layout, which
+// is only rendered through other templates, rather than a macro file or a
+// template of its own.
+func isPartialFile(templatePath string) bool {
+	return strings.HasPrefix(path.Base(templatePath), "_") && path.Base(path.Dir(templatePath)) != "_macros"
+}
+
+// unusedPartials returns the partials and layouts in results that no entry
+// template, one that is not a partial, uses directly or through other
+// partials.
+func (tv *TemplateValidator) unusedPartials(results map[string]ValidationResult) map[string]bool {
+	graph := tv.newTemplateGraph()
+	used := make(map[string]bool)
+	var queue []string
+	for templatePath := range results {
+		if !isPartialFile(templatePath) {
+			queue = append(queue, templatePath)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range graph.edgesFrom(current) {
+			if !used[edge.to] {
+				used[edge.to] = true
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+
+	unused := make(map[string]bool)
+	for templatePath := range results {
+		if isPartialFile(templatePath) && !used[templatePath] {
+			unused[templatePath] = true
+		}
+	}
+	return unused
+}
+
+// reportUnusedPartials adds an unused_partial finding to the results of the
+// unused partials.
+func (tv *TemplateValidator) reportUnusedPartials(results map[string]ValidationResult, unused map[string]bool) {
+	severity := tv.severity("unused_partial", SeverityWarning)
+	if severity == SeverityOff {
+		return
+	}
+
+	for templatePath := range unused {
+		name := strings.TrimPrefix(path.Base(templatePath), "_")
+		name = strings.TrimSuffix(strings.TrimSuffix(name, ".tmpl"), ".tpl")
+		finding := ValidationError{
+			Type:       "unused_partial",
+			Message:    fmt.Sprintf("Partial '%s' is not used by any template", path.Base(templatePath)),
+			File:       templatePath,
+			Suggestion: fmt.Sprintf("Remove it, or call it with {{ template \"%s\" . }}", name),
+		}
+		if content, err := fs.ReadFile(tv.fs, templatePath); err == nil && parseIgnoreDirectives(string(content)).ignores(finding) {
+			continue
+		}
+		addFinding(results, templatePath, severity, finding)
+	}
+}
+
+// reportUnusedDefines adds an unused_define finding to the results of the
+// templates with define blocks that no template in results calls, other
+// than the unused partials. Calls a define makes to itself do not count,
+// and a block counts as a call to itself.
+func (tv *TemplateValidator) reportUnusedDefines(results map[string]ValidationResult, unused map[string]bool) {
+	severity := tv.severity("unused_define", SeverityWarning)
+	if severity == SeverityOff {
+		return
+	}
+
+	type definition struct {
+		path string
+		name string
+	}
+	var definitions []definition
+	called := make(map[string]bool)
+	contents := make(map[string]string)
+
+	for templatePath := range results {
+		content, err := fs.ReadFile(tv.fs, templatePath)
+		if err != nil {
+			continue
+		}
+		contents[templatePath] = string(content)
+		summary := tv.summary(templatePath, string(content))
+		for _, name := range summary.defines {
+			definitions = append(definitions, definition{templatePath, name})
+		}
+		if !unused[templatePath] {
+			maps.Copy(called, summary.called)
+		}
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		if definitions[i].path != definitions[j].path {
+			return definitions[i].path < definitions[j].path
+		}
+		return definitions[i].name < definitions[j].name
+	})
+
+	for _, d := range definitions {
+		if called[d.name] {
+			continue
+		}
+		content := contents[d.path]
+		finding := ValidationError{
+			Type:       "unused_define",
+			Message:    fmt.Sprintf("Template '%s' is defined but never called", d.name),
+			File:       d.path,
+			Suggestion: fmt.Sprintf("Remove the define, or call it with {{ template \"%s\" . }}", d.name),
+		}
+		for _, match := range definePattern.FindAllStringSubmatchIndex(content, -1) {
+			if content[match[2]:match[3]] == d.name {
+				position := positionAt(content, match[0], tv.tabWidth)
+				finding.Line, finding.Column = position.Line, position.Column
+				break
+			}
+		}
+		if parseIgnoreDirectives(content).ignores(finding) {
+			continue
+		}
+		addFinding(results, d.path, severity, finding)
+	}
+}
+
+// addFinding adds a finding to the result for templatePath. Results may be
+// shared with a cache, so their slices are copied.
+func addFinding(results map[string]ValidationResult, templatePath string, severity Severity, finding ValidationError) {
+	result := results[templatePath]
+	if severity == SeverityError {
+		result.Valid = false
+		result.Errors = append(slices.Clip(result.Errors), finding)
+	} else {
+		result.Warnings = append(slices.Clip(result.Warnings), finding)
+	}
+	results[templatePath] = result
+}
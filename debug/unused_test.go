@@ -0,0 +1,86 @@
+package debug
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTemplateValidator_UnusedPartials(t *testing.T) {
+	testFS := fstest.MapFS{
+		"templates/main.tmpl":         {Data: []byte("{{ extends \"base.tmpl\" }}{{ define \"body\" }}{{ template \"header\" . }}{{ end }}")},
+		"templates/_base.tmpl":        {Data: []byte("{{ block \"body\" . }}{{ end }}")},
+		"templates/_header.tmpl":      {Data: []byte("{{ template \"nav\" . }}")},
+		"templates/_nav.tmpl":         {Data: []byte("nav")},
+		"templates/_unused.tmpl":      {Data: []byte("{{ template \"orphan\" . }}")},
+		"templates/_orphan.tmpl":      {Data: []byte("orphan")},
+		"templates/_kept.tmpl":        {Data: []byte("{{/* weft:ignore-file unused_partial */}}")},
+		"templates/_macros/text.tmpl": {Data: []byte("{{ define \"text\" }}{{ end }}")},
+	}
+	validator := NewTemplateValidator(testFS, nil, nil)
+
+	unused := func(results map[string]ValidationResult) []string {
+		var paths []string
+		for _, path := range sortedResultPaths(results) {
+			for _, warning := range results[path].Warnings {
+				if warning.Type == "unused_partial" {
+					paths = append(paths, path)
+				}
+			}
+		}
+		return paths
+	}
+
+	results := validator.ValidateDirectory("templates")
+	if got, want := unused(results), []string{"templates/_orphan.tmpl", "templates/_unused.tmpl"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unused partials = %v, want %v", got, want)
+	}
+	if w := results["templates/_unused.tmpl"].Warnings; len(w) != 1 || w[0].Message != "Partial '_unused.tmpl' is not used by any template" || w[0].Suggestion != `Remove it, or call it with {{ template "unused" . }}` {
+		t.Errorf("unexpected finding: %+v", w)
+	}
+
+	iv := NewIncrementalValidator(validator)
+	iv.ValidateDirectory("templates")
+	if got := unused(iv.ValidateDirectory("templates")); len(got) != 2 {
+		t.Errorf("expected cached results to report unused partials once, got %v", got)
+	}
+
+	validator.SetRuleSeverity("unused_partial", SeverityOff)
+	if got := unused(validator.ValidateDirectory("templates")); len(got) != 0 {
+		t.Errorf("expected no unused partials when disabled, got %v", got)
+	}
+}
+
+func TestTemplateValidator_UnusedDefines(t *testing.T) {
+	testFS := fstest.MapFS{
+		"templates/main.tmpl":  {Data: []byte("{{ define \"used\" }}{{ end }}\n{{ define \"unused\" }}{{ end }}\n{{ template \"used\" . }}{{ template \"macro\" . }}{{ block \"own\" . }}{{ end }}")},
+		"templates/page.tmpl":  {Data: []byte("{{ extends \"base.tmpl\" }}{{ define \"body\" }}{{ end }}")},
+		"templates/_base.tmpl": {Data: []byte("{{ block \"body\" . }}{{ end }}")},
+		"templates/_macros/m.tmpl": {Data: []byte("{{ define \"macro\" }}{{ template \"helper\" . }}{{ end }}\n" +
+			"{{ define \"helper\" }}{{ end }}\n" +
+			"{{ define \"self\" }}{{ template \"self\" . }}{{ end }}\n" +
+			"{{/* weft:ignore unused_define */}}\n{{ define \"kept\" }}{{ end }}\n" +
+			"{{ define \"deadOnly\" }}{{ end }}")},
+		"templates/_dead.tmpl": {Data: []byte("{{ template \"deadOnly\" . }}")},
+	}
+	validator := NewTemplateValidator(testFS, nil, nil)
+	results := validator.ValidateDirectory("templates")
+
+	var got []string
+	for _, path := range sortedResultPaths(results) {
+		for _, warning := range results[path].Warnings {
+			if warning.Type == "unused_define" {
+				got = append(got, fmt.Sprintf("%s:%d:%d %s", path, warning.Line, warning.Column, warning.Message))
+			}
+		}
+	}
+	want := []string{
+		"templates/_macros/m.tmpl:6:1 Template 'deadOnly' is defined but never called",
+		"templates/_macros/m.tmpl:3:1 Template 'self' is defined but never called",
+		"templates/main.tmpl:2:1 Template 'unused' is defined but never called",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -3,11 +3,15 @@ package debug
 import (
 	"fmt"
 	"io/fs"
+	"maps"
 	"path/filepath"
 	"regexp"
 	"slices"
 	"strings"
 	"text/template"
+	"text/template/parse"
+
+	"github.com/cpcf/weft/render"
 )
 
 type ValidationResult struct {
@@ -26,20 +30,43 @@ type ValidationError struct {
 	Suggestion string `json:"suggestion,omitempty"`
 }
 
+var (
+	partialRefPattern = regexp.MustCompile(`{{-?\s*template\s+"([^"]+)"`)
+	includeRefPattern = regexp.MustCompile(`{{-?\s*include\s+"([^"]+)"`)
+	extendsRefPattern = regexp.MustCompile(`{{-?\s*extends\s+"([^"]+)"`)
+	definePattern     = regexp.MustCompile(`{{-?\s*(?:define|block)\s+"([^"]+)"`)
+)
+
 type TemplateValidator struct {
 	fs        fs.FS
 	funcMap   template.FuncMap
 	strict    bool
+	tabWidth  int
 	debugMode *DebugMode
+	// data describes the render data, when set with SetDataType or
+	// SetDataSchema, and dataKey identifies it in cache keys.
+	data    *dataShape
+	dataKey string
+	// rules run on every template, with severities overriding their
+	// defaults by rule ID.
+	rules      []Rule
+	severities map[string]Severity
+	// replacements maps deprecated functions to their replacements.
+	replacements map[string]string
+	// summaries caches what templates reference and define, when set by
+	// an IncrementalValidator.
+	summaries *summaryCache
 }
 
 func NewTemplateValidator(templateFS fs.FS, funcMap template.FuncMap, debugMode *DebugMode) *TemplateValidator {
-	return &TemplateValidator{
+	tv := &TemplateValidator{
 		fs:        templateFS,
 		funcMap:   funcMap,
 		strict:    false,
 		debugMode: debugMode,
 	}
+	tv.rules = tv.builtinRules()
+	return tv
 }
 
 // isSecurePath checks if a path is safe from traversal attacks
@@ -75,6 +102,12 @@ func (tv *TemplateValidator) SetStrict(strict bool) {
 	tv.strict = strict
 }
 
+// SetTabWidth sets how many columns a tab advances to when reporting
+// positions. The default of 1 counts a tab as a single character.
+func (tv *TemplateValidator) SetTabWidth(width int) {
+	tv.tabWidth = width
+}
+
 func (tv *TemplateValidator) ValidateTemplate(templatePath string) ValidationResult {
 	result := ValidationResult{
 		Valid:    true,
@@ -118,11 +151,14 @@ func (tv *TemplateValidator) ValidateTemplate(templatePath string) ValidationRes
 
 	templateContent := string(content)
 
+	file := tv.parseFile(templatePath, templateContent)
+
 	tv.validateSyntax(templatePath, templateContent, &result)
-	tv.validateFunctions(templatePath, templateContent, &result)
-	tv.validateVariableAccess(templatePath, templateContent, &result)
-	tv.validatePartials(templatePath, templateContent, &result)
-	tv.validateIncludes(templatePath, templateContent, &result)
+	tv.validateRules(file, &result)
+	tv.validateDataContract(file, &result)
+	tv.validatePartials(file, &result)
+	tv.validateIncludes(file, &result)
+	applyIgnoreDirectives(templatePath, templateContent, &result)
 
 	return result
 }
@@ -149,45 +185,57 @@ func (tv *TemplateValidator) validateSyntax(templatePath, content string, result
 			Suggestion: tv.suggestSyntaxFix(errorMsg),
 		})
 	}
-
-	tv.validateBraceBalance(templatePath, content, result)
-	tv.validateWhitespace(templatePath, content, result)
 }
 
 func (tv *TemplateValidator) validateBraceBalance(templatePath, content string, result *ValidationResult) {
-	lines := strings.Split(content, "\n")
-	openBraces := 0
-
-	for lineNum, line := range lines {
-		for i := 0; i < len(line); i++ {
-			if i+1 < len(line) && line[i] == '{' && line[i+1] == '{' {
-				openBraces++
-				i++ // skip the next character to avoid double counting
-			} else if i+1 < len(line) && line[i] == '}' && line[i+1] == '}' {
-				openBraces--
-				if openBraces < 0 {
-					result.Valid = false
-					result.Errors = append(result.Errors, ValidationError{
-						Type:       "brace_mismatch",
-						Message:    "Unmatched closing braces }}",
-						File:       templatePath,
-						Line:       lineNum + 1,
-						Column:     i + 1,
-						Suggestion: "Check for missing opening braces {{",
-					})
-					openBraces = 0
+	scanner := newTemplateScanner(content, tv.tabWidth)
+	var open []Position
+
+	for !scanner.done() {
+		switch {
+		case scanner.hasPrefix("{{"):
+			start := scanner.pos()
+			scanner.skip(2)
+			if scanner.atCommentStart() {
+				// Braces inside {{/* comments */}} are not template actions.
+				if !scanner.skipComment() {
+					open = append(open, start)
 				}
-				i++ // skip the next character to avoid double counting
+				continue
+			}
+			open = append(open, start)
+		case scanner.hasPrefix("}}"):
+			if len(open) == 0 {
+				position := scanner.pos()
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Type:       "brace_mismatch",
+					Message:    "Unmatched closing braces }}",
+					File:       templatePath,
+					Line:       position.Line,
+					Column:     position.Column,
+					Suggestion: "Check for missing opening braces {{",
+				})
+			} else {
+				open = open[:len(open)-1]
 			}
+			scanner.skip(2)
+		case len(open) > 0 && strings.ContainsRune("\"'`", scanner.peek()):
+			// Braces inside string literals within an action are literal text.
+			scanner.skipQuoted()
+		default:
+			scanner.advance()
 		}
 	}
 
-	if openBraces > 0 {
+	if len(open) > 0 {
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
 			Type:       "brace_mismatch",
-			Message:    fmt.Sprintf("Unclosed braces: %d opening braces without matching closing braces", openBraces),
+			Message:    fmt.Sprintf("Unclosed braces: %d opening braces without matching closing braces", len(open)),
 			File:       templatePath,
+			Line:       open[0].Line,
+			Column:     open[0].Column,
 			Suggestion: "Add missing closing braces }}",
 		})
 	}
@@ -197,87 +245,128 @@ func (tv *TemplateValidator) validateWhitespace(templatePath, content string, re
 	if tv.strict {
 		lines := strings.Split(content, "\n")
 		for lineNum, line := range lines {
-			if strings.HasSuffix(line, " ") || strings.HasSuffix(line, "\t") {
+			trimmed := strings.TrimRight(line, " \t")
+			if len(trimmed) != len(line) {
 				result.Warnings = append(result.Warnings, ValidationError{
 					Type:    "whitespace_warning",
 					Message: "Line has trailing whitespace",
 					File:    templatePath,
 					Line:    lineNum + 1,
+					Column:  displayColumn(trimmed, tv.tabWidth),
 				})
 			}
 		}
 	}
 }
 
-func (tv *TemplateValidator) validateFunctions(templatePath, content string, result *ValidationResult) {
-	functionPattern := regexp.MustCompile(`{{\s*([^}\s]+)`)
-	matches := functionPattern.FindAllStringSubmatch(content, -1)
+// validateFunctions reports the calls to functions that are neither
+// builtins nor in the function map. Without a function map nothing is
+// reported, as any function could be provided at render time.
+func (tv *TemplateValidator) validateFunctions(file *TemplateFile) []ValidationError {
+	if tv.funcMap == nil {
+		return nil
+	}
 
-	for _, match := range matches {
-		if len(match) < 2 {
-			continue
+	var findings []ValidationError
+	file.walk(func(node parse.Node) bool {
+		ident, ok := node.(*parse.IdentifierNode)
+		if !ok || tv.isBuiltinFunction(ident.Ident) {
+			return true
 		}
-
-		funcName := strings.Split(match[1], " ")[0]
-		funcName = strings.Split(funcName, "|")[0]
-
-		if tv.isControlStructure(funcName) {
-			continue
+		if _, exists := tv.funcMap[ident.Ident]; exists {
+			return true
 		}
+		line, column := file.Position(ident.Pos)
+		findings = append(findings, ValidationError{
+			Type:       "unknown_function",
+			Message:    fmt.Sprintf("Function '%s' is not defined", ident.Ident),
+			Line:       line,
+			Column:     column,
+			Suggestion: tv.suggestFunction(ident.Ident),
+		})
+		return true
+	})
+	return findings
+}
 
-		if tv.isBuiltinFunction(funcName) {
-			continue
+// validateDeprecatedFunctions reports the calls to functions set as
+// deprecated with SetFunctionReplacements.
+func (tv *TemplateValidator) validateDeprecatedFunctions(file *TemplateFile) []ValidationError {
+	var findings []ValidationError
+	file.walk(func(node parse.Node) bool {
+		ident, ok := node.(*parse.IdentifierNode)
+		if !ok {
+			return true
 		}
-
-		if tv.funcMap != nil {
-			if _, exists := tv.funcMap[funcName]; !exists {
-				result.Warnings = append(result.Warnings, ValidationError{
-					Type:       "unknown_function",
-					Message:    fmt.Sprintf("Function '%s' is not defined", funcName),
-					File:       templatePath,
-					Suggestion: fmt.Sprintf("Check if '%s' is spelled correctly or add it to the function map", funcName),
-				})
-			}
+		replacement, deprecated := tv.replacements[ident.Ident]
+		if !deprecated {
+			return true
 		}
-	}
-}
-
-func (tv *TemplateValidator) validateVariableAccess(templatePath, content string, result *ValidationResult) {
-	variablePattern := regexp.MustCompile(`{{\s*\.([^}\s|]+)`)
-	matches := variablePattern.FindAllStringSubmatch(content, -1)
-
-	for _, match := range matches {
-		if len(match) < 2 {
-			continue
+		line, column := file.Position(ident.Pos)
+		finding := ValidationError{
+			Type:       "deprecated_function",
+			Message:    fmt.Sprintf("Function '%s' is deprecated", ident.Ident),
+			Line:       line,
+			Column:     column,
+			Suggestion: "Stop calling it; it will be removed",
 		}
-
-		varPath := match[1]
-		if strings.Contains(varPath, " ") {
-			continue
+		if replacement != "" {
+			finding.Suggestion = fmt.Sprintf("Use '%s' instead", replacement)
 		}
+		findings = append(findings, finding)
+		return true
+	})
+	return findings
+}
 
-		parts := strings.Split(varPath, ".")
-		if len(parts) > 5 {
-			result.Warnings = append(result.Warnings, ValidationError{
-				Type:       "deep_access",
-				Message:    fmt.Sprintf("Variable access chain '%s' is very deep", varPath),
-				File:       templatePath,
-				Suggestion: "Consider simplifying the data structure or using intermediate variables",
-			})
+// validateVariableAccess reports field chains more than five fields deep,
+// on dot or on a variable, wherever they appear in an action.
+func (tv *TemplateValidator) validateVariableAccess(file *TemplateFile) []ValidationError {
+	var findings []ValidationError
+	file.walk(func(node parse.Node) bool {
+		var varPath, text string
+		switch n := node.(type) {
+		case *parse.FieldNode:
+			if len(n.Ident) <= 5 {
+				return true
+			}
+			varPath = strings.Join(n.Ident, ".")
+			text = "." + varPath
+		case *parse.VariableNode:
+			if len(n.Ident)-1 <= 5 {
+				return true
+			}
+			varPath = strings.Join(n.Ident, ".")
+			text = varPath
+		default:
+			return true
 		}
-	}
+
+		// Point at the start of the chain.
+		position := positionAt(file.Content, sourceOffset(file.Content, node.Position(), text), tv.tabWidth)
+		findings = append(findings, ValidationError{
+			Type:       "deep_access",
+			Message:    fmt.Sprintf("Variable access chain '%s' is very deep", varPath),
+			Line:       position.Line,
+			Column:     position.Column,
+			Suggestion: "Consider simplifying the data structure or using intermediate variables",
+		})
+		return true
+	})
+	return findings
 }
 
-func (tv *TemplateValidator) validatePartials(templatePath, content string, result *ValidationResult) {
-	partialPattern := regexp.MustCompile(`{{\s*template\s+"([^"]+)"`)
-	matches := partialPattern.FindAllStringSubmatch(content, -1)
+// validatePartials reports the partial calls that name neither a template
+// the file or its macros define nor a partial file.
+func (tv *TemplateValidator) validatePartials(file *TemplateFile, result *ValidationResult) {
+	defined := tv.definedTemplates(file.Path, file.Content)
 
-	for _, match := range matches {
-		if len(match) < 2 {
+	for _, call := range file.calls() {
+		if call.kind != "template" || defined[call.name] {
 			continue
 		}
-
-		partialName := match[1]
+		partialName := call.name
+		position := positionAt(file.Content, call.offset, tv.tabWidth)
 
 		// Security check: validate partial name for traversal attacks
 		if !isSecurePath(partialName) {
@@ -285,35 +374,37 @@ func (tv *TemplateValidator) validatePartials(templatePath, content string, resu
 			result.Errors = append(result.Errors, ValidationError{
 				Type:       "security_error",
 				Message:    fmt.Sprintf("Partial template name '%s' contains unsafe path characters", partialName),
-				File:       templatePath,
+				File:       file.Path,
+				Line:       position.Line,
+				Column:     position.Column,
 				Suggestion: "Use safe relative paths without '..' or absolute references",
 			})
 			continue
 		}
 
-		partialPath := tv.resolvePartialPath(templatePath, partialName)
+		partialPath := tv.resolvePartialPath(file.Path, partialName)
 		if partialPath == "" {
 			result.Valid = false
 			result.Errors = append(result.Errors, ValidationError{
 				Type:       "missing_partial",
 				Message:    fmt.Sprintf("Partial template '%s' not found", partialName),
-				File:       templatePath,
+				File:       file.Path,
+				Line:       position.Line,
+				Column:     position.Column,
 				Suggestion: fmt.Sprintf("Create partial file or check the name '%s'", partialName),
 			})
 		}
 	}
 }
 
-func (tv *TemplateValidator) validateIncludes(templatePath, content string, result *ValidationResult) {
-	includePattern := regexp.MustCompile(`{{\s*include\s+"([^"]+)"`)
-	matches := includePattern.FindAllStringSubmatch(content, -1)
-
-	for _, match := range matches {
-		if len(match) < 2 {
+// validateIncludes reports the includes that name no file.
+func (tv *TemplateValidator) validateIncludes(file *TemplateFile, result *ValidationResult) {
+	for _, call := range file.calls() {
+		if call.kind != "include" {
 			continue
 		}
-
-		includePath := match[1]
+		includePath := call.name
+		position := positionAt(file.Content, call.offset, tv.tabWidth)
 
 		// Security check: validate include path for traversal attacks
 		if !isSecurePath(includePath) {
@@ -321,33 +412,70 @@ func (tv *TemplateValidator) validateIncludes(templatePath, content string, resu
 			result.Errors = append(result.Errors, ValidationError{
 				Type:       "security_error",
 				Message:    fmt.Sprintf("Include path '%s' contains unsafe path characters", includePath),
-				File:       templatePath,
+				File:       file.Path,
+				Line:       position.Line,
+				Column:     position.Column,
 				Suggestion: "Use safe relative paths without '..' or absolute references",
 			})
 			continue
 		}
 
-		resolvedPath := tv.resolveIncludePath(templatePath, includePath)
+		resolvedPath := tv.resolveIncludePath(file.Path, includePath)
 		if resolvedPath == "" {
 			result.Valid = false
 			result.Errors = append(result.Errors, ValidationError{
 				Type:       "missing_include",
 				Message:    fmt.Sprintf("Include file '%s' not found", includePath),
-				File:       templatePath,
+				File:       file.Path,
+				Line:       position.Line,
+				Column:     position.Column,
 				Suggestion: fmt.Sprintf("Create include file or check the path '%s'", includePath),
 			})
 		}
 	}
 }
 
-func (tv *TemplateValidator) resolvePartialPath(templatePath, partialName string) string {
-	baseDir := filepath.Dir(templatePath)
+// definedTemplates returns the names of the templates defined by content and
+// by the macro files in the _macros directories of the template's directory
+// and its parents, none of which need a partial.
+func (tv *TemplateValidator) definedTemplates(templatePath, content string) map[string]bool {
+	defined := make(map[string]bool)
+	sources := []string{content}
+	for dir := filepath.Dir(templatePath); ; dir = filepath.Dir(dir) {
+		macroDir := filepath.ToSlash(filepath.Join(dir, "_macros"))
+		entries, _ := fs.ReadDir(tv.fs, macroDir)
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if macros, err := fs.ReadFile(tv.fs, macroDir+"/"+entry.Name()); err == nil {
+				sources = append(sources, string(macros))
+			}
+		}
+		if dir == "." || dir == "/" {
+			break
+		}
+	}
+	for _, source := range sources {
+		for _, match := range definePattern.FindAllStringSubmatch(source, -1) {
+			defined[match[1]] = true
+		}
+	}
+	return defined
+}
 
-	candidates := []string{
-		filepath.Join(baseDir, "_"+partialName+".tmpl"),
-		filepath.Join(baseDir, "_"+partialName+".tpl"),
-		"_" + partialName + ".tmpl",
-		"_" + partialName + ".tpl",
+// resolvePartialPath looks for the partial in the template's directory and
+// then each of its parents, as the engine does.
+func (tv *TemplateValidator) resolvePartialPath(templatePath, partialName string) string {
+	var candidates []string
+	for dir := filepath.Dir(templatePath); ; dir = filepath.Dir(dir) {
+		candidates = append(candidates,
+			filepath.Join(dir, "_"+partialName+".tmpl"),
+			filepath.Join(dir, "_"+partialName+".tpl"),
+		)
+		if dir == "." || dir == "/" {
+			break
+		}
 	}
 
 	for _, candidate := range candidates {
@@ -363,6 +491,23 @@ func (tv *TemplateValidator) resolvePartialPath(templatePath, partialName string
 	return ""
 }
 
+// resolveLayoutPath looks for a layout in the template's directory and then
+// the root, with and without a leading underscore, as the engine does.
+func (tv *TemplateValidator) resolveLayoutPath(templatePath, layout string) string {
+	for _, dir := range []string{filepath.Dir(templatePath), "."} {
+		p := filepath.Join(dir, layout)
+		for _, candidate := range []string{p, filepath.Join(filepath.Dir(p), "_"+filepath.Base(p))} {
+			if !isSecurePath(candidate) {
+				continue
+			}
+			if info, err := fs.Stat(tv.fs, filepath.ToSlash(candidate)); err == nil && !info.IsDir() {
+				return filepath.ToSlash(candidate)
+			}
+		}
+	}
+	return ""
+}
+
 func (tv *TemplateValidator) resolveIncludePath(templatePath, includePath string) string {
 	baseDir := filepath.Dir(templatePath)
 
@@ -391,23 +536,25 @@ func (tv *TemplateValidator) resolveIncludePath(templatePath, includePath string
 	return ""
 }
 
-func (tv *TemplateValidator) isControlStructure(name string) bool {
-	controlStructures := []string{
-		"if", "else", "else if", "end",
-		"range", "with", "define", "block",
-	}
-
-	return slices.Contains(controlStructures, name)
+// builtinFunctions are the functions text/template predefines.
+var builtinFunctions = []string{
+	"and", "or", "not", "eq", "ne", "lt", "le", "gt", "ge",
+	"printf", "print", "println", "len", "index", "slice",
+	"call", "html", "js", "urlquery",
 }
 
 func (tv *TemplateValidator) isBuiltinFunction(name string) bool {
-	builtins := []string{
-		"and", "or", "not", "eq", "ne", "lt", "le", "gt", "ge",
-		"printf", "print", "println", "len", "index", "slice",
-		"call", "html", "js", "urlquery",
-	}
+	return slices.Contains(builtinFunctions, name)
+}
 
-	return slices.Contains(builtins, name)
+// suggestFunction suggests what to call instead of the undefined function
+// name: the closest builtin or function map entry if one is close enough.
+func (tv *TemplateValidator) suggestFunction(name string) string {
+	candidates := append(slices.Clone(builtinFunctions), slices.Sorted(maps.Keys(tv.funcMap))...)
+	if match := render.ClosestName(name, candidates); match != "" {
+		return fmt.Sprintf("Did you mean '%s'?", match)
+	}
+	return fmt.Sprintf("Check if '%s' is spelled correctly or add it to the function map", name)
 }
 
 func (tv *TemplateValidator) extractLineColumn(errorMsg string) (int, int) {
@@ -434,7 +581,15 @@ func (tv *TemplateValidator) extractLineColumn(errorMsg string) (int, int) {
 	return 0, 0
 }
 
+// undefinedFunctionPattern matches the parse error for a call to a function
+// that is not defined.
+var undefinedFunctionPattern = regexp.MustCompile(`function "([^"]+)" not defined`)
+
 func (tv *TemplateValidator) suggestSyntaxFix(errorMsg string) string {
+	if match := undefinedFunctionPattern.FindStringSubmatch(errorMsg); match != nil {
+		return tv.suggestFunction(match[1])
+	}
+
 	errorMsg = strings.ToLower(errorMsg)
 
 	if strings.Contains(errorMsg, "unexpected") {
@@ -480,6 +635,7 @@ func (tv *TemplateValidator) ValidateDirectory(templateDir string) map[string]Va
 	if err != nil && tv.debugMode != nil {
 		tv.debugMode.Error("Directory validation failed", "error", err, "directory", templateDir)
 	}
+	tv.reportUnused(results)
 
 	return results
 }
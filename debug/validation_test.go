@@ -213,6 +213,94 @@ func TestTemplateValidator_ValidateBraceBalance(t *testing.T) {
 	}
 }
 
+func TestTemplateValidator_ValidateBraceBalance_Positions(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		tabWidth     int
+		expectErrors int
+		line         int
+		column       int
+	}{
+		{
+			name:         "commented-out braces",
+			content:      "{{/* {{ .Old }} }} */}}\n{{- /* }} */ -}}{{.Name}}",
+			expectErrors: 0,
+		},
+		{
+			name:         "braces in raw string",
+			content:      "{{ printf `}}\n{{` }}",
+			expectErrors: 0,
+		},
+		{
+			name:         "braces in quoted string",
+			content:      `{{ printf "}}" }}{{ print "\"}}" }}`,
+			expectErrors: 0,
+		},
+		{
+			name:         "multi-byte characters before closing braces",
+			content:      "héllo wörld }}",
+			expectErrors: 1,
+			line:         1,
+			column:       13,
+		},
+		{
+			name:         "tabs expanded to tab stops",
+			content:      "ok\n\t\t}}",
+			tabWidth:     4,
+			expectErrors: 1,
+			line:         2,
+			column:       9,
+		},
+		{
+			name:         "unterminated comment",
+			content:      "x {{/* never closed",
+			expectErrors: 1,
+			line:         1,
+			column:       3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewTemplateValidator(fstest.MapFS{}, nil, nil)
+			validator.SetTabWidth(test.tabWidth)
+
+			result := ValidationResult{Valid: true}
+			validator.validateBraceBalance("test.tmpl", test.content, &result)
+
+			if len(result.Errors) != test.expectErrors {
+				t.Fatalf("Expected %d errors, got %v", test.expectErrors, result.Errors)
+			}
+			if test.expectErrors == 0 {
+				return
+			}
+			if result.Errors[0].Line != test.line || result.Errors[0].Column != test.column {
+				t.Errorf("Expected position %d:%d, got %d:%d",
+					test.line, test.column, result.Errors[0].Line, result.Errors[0].Column)
+			}
+		})
+	}
+}
+
+func TestTemplateValidator_ValidateWhitespace_Columns(t *testing.T) {
+	validator := NewTemplateValidator(fstest.MapFS{}, nil, nil)
+	validator.SetStrict(true)
+
+	result := ValidationResult{Valid: true}
+	validator.validateWhitespace("test.tmpl", "ünïcode  \n\tx\t", &result)
+
+	if len(result.Warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %v", result.Warnings)
+	}
+	if result.Warnings[0].Column != 8 {
+		t.Errorf("Expected column 8 for multi-byte line, got %d", result.Warnings[0].Column)
+	}
+	if result.Warnings[1].Column != 3 {
+		t.Errorf("Expected column 3 for tabbed line, got %d", result.Warnings[1].Column)
+	}
+}
+
 func TestTemplateValidator_ValidateWhitespace(t *testing.T) {
 	content := "{{.Name}}  \nHello\t\n{{.Age}}"
 
@@ -400,6 +488,12 @@ func TestTemplateValidator_ValidatePartials(t *testing.T) {
 		"missing_partial.tmpl": &fstest.MapFile{
 			Data: []byte(`{{template "nonexistent"}}`),
 		},
+		"templates/api/user.tmpl": &fstest.MapFile{
+			Data: []byte(`{{template "header"}}{{template "goStruct"}}{{define "local"}}{{end}}{{template "local"}}`),
+		},
+		"templates/_macros/go.tmpl": &fstest.MapFile{
+			Data: []byte(`{{define "goStruct"}}type T struct{}{{end}}`),
+		},
 	}
 
 	tests := []struct {
@@ -414,6 +508,12 @@ func TestTemplateValidator_ValidatePartials(t *testing.T) {
 			expectErrors: 0, // both header and footer should be found
 			expectValid:  true,
 		},
+		{
+			name:         "parent partials, macros and defines",
+			templatePath: "templates/api/user.tmpl",
+			expectErrors: 0,
+			expectValid:  true,
+		},
 		{
 			name:         "missing partial",
 			templatePath: "missing_partial.tmpl",
@@ -607,29 +707,6 @@ func TestTemplateValidator_ResolveIncludePath(t *testing.T) {
 	}
 }
 
-func TestTemplateValidator_IsControlStructure(t *testing.T) {
-	validator := NewTemplateValidator(fstest.MapFS{}, nil, nil)
-
-	controlStructures := []string{"if", "else", "else if", "end", "range", "with", "define", "block"}
-	nonControlStructures := []string{"printf", "upper", "len", "myFunc", "unknown"}
-
-	for _, cs := range controlStructures {
-		t.Run("control_"+cs, func(t *testing.T) {
-			if !validator.isControlStructure(cs) {
-				t.Errorf("Expected '%s' to be recognized as control structure", cs)
-			}
-		})
-	}
-
-	for _, ncs := range nonControlStructures {
-		t.Run("non_control_"+ncs, func(t *testing.T) {
-			if validator.isControlStructure(ncs) {
-				t.Errorf("Expected '%s' to NOT be recognized as control structure", ncs)
-			}
-		})
-	}
-}
-
 func TestTemplateValidator_IsBuiltinFunction(t *testing.T) {
 	validator := NewTemplateValidator(fstest.MapFS{}, nil, nil)
 
@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultBackupDir is the directory, relative to the output root, that
+// BackupDirectory mode stores previous file contents in.
+const DefaultBackupDir = ".weft-backup"
+
+// backupTimestampFormat is fixed-width so backups sort chronologically.
+const backupTimestampFormat = "20060102T150405.000000000"
+
+type BackupMode int
+
+const (
+	// BackupDisabled overwrites files without keeping their previous content.
+	BackupDisabled BackupMode = iota
+	// BackupDirectory copies the previous content into a timestamped file
+	// under the backup directory, mirroring the output tree.
+	BackupDirectory
+	// BackupSibling copies the previous content to <file>.bak next to the file.
+	BackupSibling
+)
+
+// BackupOptions configures how files are backed up before being overwritten.
+type BackupOptions struct {
+	Mode BackupMode
+	// Dir is the backup directory for BackupDirectory mode. Relative paths are
+	// resolved against the output root. Defaults to DefaultBackupDir.
+	Dir string
+	// Retention is the number of backups kept per file in BackupDirectory
+	// mode; older backups are removed. Zero keeps every backup.
+	Retention int
+}
+
+func (b BackupOptions) enabled() bool {
+	return b.Mode != BackupDisabled
+}
+
+// backupExisting saves the current content of outputPath before it is
+// replaced with content. Missing files and unchanged content are skipped.
+// It returns the path of the backup, or "" if none was written.
+func (r *Renderer) backupExisting(ctx Context, outputPath string, content []byte) (string, error) {
+	if !r.backup.enabled() {
+		return "", nil
+	}
+
+	existing, err := os.ReadFile(outputPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read existing file %s: %w", outputPath, err)
+	}
+
+	if bytes.Equal(existing, content) {
+		return "", nil
+	}
+
+	return r.writeBackup(ctx, outputPath, func(backupPath string) error {
+		return os.WriteFile(backupPath, existing, 0o644)
+	})
+}
+
+// backupFile saves the current content of outputPath without reading it into
+// memory, for streamed outputs whose new content is never held in full. The
+// backup is written even if the content ends up unchanged.
+func (r *Renderer) backupFile(ctx Context, outputPath string) (string, error) {
+	if !r.backup.enabled() {
+		return "", nil
+	}
+
+	if _, err := os.Stat(outputPath); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read existing file %s: %w", outputPath, err)
+	}
+
+	return r.writeBackup(ctx, outputPath, func(backupPath string) error {
+		return copyFile(outputPath, backupPath)
+	})
+}
+
+// writeBackup resolves the backup path for outputPath, stores the previous
+// content there with write and prunes old backups.
+func (r *Renderer) writeBackup(ctx Context, outputPath string, write func(backupPath string) error) (string, error) {
+	var backupPath string
+	switch r.backup.Mode {
+	case BackupSibling:
+		backupPath = outputPath + ".bak"
+	case BackupDirectory:
+		backupPath = filepath.Join(r.backupDir(ctx), backupRelPath(ctx.OutputRoot, outputPath)) +
+			"." + time.Now().UTC().Format(backupTimestampFormat) + ".bak"
+	default:
+		return "", fmt.Errorf("unknown backup mode %d", r.backup.Mode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	if err := write(backupPath); err != nil {
+		return "", fmt.Errorf("failed to write backup %s: %w", backupPath, err)
+	}
+
+	if r.backup.Mode == BackupDirectory && r.backup.Retention > 0 {
+		if err := pruneBackups(filepath.Dir(backupPath), filepath.Base(outputPath), r.backup.Retention); err != nil {
+			return backupPath, err
+		}
+	}
+
+	return backupPath, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func (r *Renderer) backupDir(ctx Context) string {
+	dir := r.backup.Dir
+	if dir == "" {
+		dir = DefaultBackupDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(ctx.OutputRoot, dir)
+}
+
+// backupRelPath returns outputPath relative to the output root, falling back
+// to the file name for paths outside it.
+func backupRelPath(outputRoot, outputPath string) string {
+	rel, err := filepath.Rel(outputRoot, outputPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Base(outputPath)
+	}
+	return rel
+}
+
+// pruneBackups removes the oldest backups of fileName in dir so that at most
+// keep remain.
+func pruneBackups(dir, fileName string, keep int) error {
+	prefix := fileName + "."
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isBackupOf(entry.Name(), prefix) {
+			continue
+		}
+		backups = append(backups, entry.Name())
+	}
+
+	if len(backups) <= keep {
+		return nil
+	}
+
+	// Timestamps are fixed-width, so lexical order is chronological.
+	sort.Strings(backups)
+	for _, old := range backups[:len(backups)-keep] {
+		if err := os.Remove(filepath.Join(dir, old)); err != nil {
+			return fmt.Errorf("failed to remove old backup %s: %w", old, err)
+		}
+	}
+
+	return nil
+}
+
+func isBackupOf(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".bak") {
+		return false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".bak")
+	_, err := time.Parse(backupTimestampFormat, stamp)
+	return err == nil
+}
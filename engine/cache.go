@@ -17,11 +17,13 @@ type cacheKey struct {
 type TemplateCache struct {
 	mu        sync.RWMutex
 	templates map[cacheKey]*template.Template
+	funcs     template.FuncMap
 }
 
 func NewTemplateCache() *TemplateCache {
 	return &TemplateCache{
 		templates: make(map[cacheKey]*template.Template),
+		funcs:     render.DefaultFuncMap(),
 	}
 }
 
@@ -50,12 +52,7 @@ func (c *TemplateCache) Get(fsys fs.FS, path string) (*template.Template, error)
 		return tmpl, nil
 	}
 
-	content, err := fs.ReadFile(fsys, path)
-	if err != nil {
-		return nil, err
-	}
-
-	tmpl, err := template.New(path).Funcs(render.DefaultFuncMap()).Parse(string(content))
+	tmpl, err := render.ParseTemplate(fsys, path, c.funcMap())
 	if err != nil {
 		return nil, err
 	}
@@ -86,3 +83,11 @@ func (c *TemplateCache) getFSIdentifier(fsys fs.FS) (string, error) {
 	// For other types, use type name (less efficient but safe)
 	return fmt.Sprintf("%T:%p", fsys, fsys), nil
 }
+
+// funcMap returns the functions templates are parsed with.
+func (c *TemplateCache) funcMap() template.FuncMap {
+	if c.funcs != nil {
+		return c.funcs
+	}
+	return render.DefaultFuncMap()
+}
@@ -1,6 +1,9 @@
 package engine
 
-import "io/fs"
+import (
+	"context"
+	"io/fs"
+)
 
 // Context encapsulates the execution context for template rendering
 type Context struct {
@@ -11,6 +14,14 @@ type Context struct {
 	// PackagePath is the Go package path for the generated code (future use)
 	// This will be used for import resolution and package declarations
 	PackagePath string
+	// RunID correlates the logs, errors and reports of one render run. The
+	// engine generates one when it is empty; set it to propagate an ID from
+	// a calling system.
+	RunID string
+	// TraceContext holds the span the run's telemetry spans are children
+	// of, to trace a run as part of a calling system's work. It is only
+	// used with WithTelemetry.
+	TraceContext context.Context
 }
 
 func NewContext(tmplFS fs.FS, outputRoot, packagePath string) Context {
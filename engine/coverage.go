@@ -0,0 +1,388 @@
+package engine
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+// coverFunc is the function instrumented templates call on entering an arm
+// or define.
+const coverFunc = "weftCoverage"
+
+// CoveragePoint is an arm of an if, range or with action, or a define, and
+// how often the templates rendered since coverage started entered it.
+type CoveragePoint struct {
+	// Template is the file holding the action or define.
+	Template string `json:"template"`
+	// Line and Column locate the action or define, counting from 1.
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// Kind is "if", "range", "with" or "define".
+	Kind string `json:"kind"`
+	// Arm is "then" or "else" for actions and "" for defines. The else arm
+	// of a range is entered when it has nothing to iterate over.
+	Arm string `json:"arm,omitempty"`
+	// Implicit is true for else arms the template does not write, which
+	// are entered when the condition is false.
+	Implicit bool `json:"implicit,omitempty"`
+	// Name is the name of a define.
+	Name string `json:"name,omitempty"`
+	Hits int    `json:"hits"`
+}
+
+// Description describes the point, such as "else of if", "if condition
+// false" for an implicit else or `define "header"`.
+func (p CoveragePoint) Description() string {
+	switch {
+	case p.Kind == "define":
+		return fmt.Sprintf("define %q", p.Name)
+	case p.Kind == "range" && p.Arm == "then":
+		return "range body"
+	case p.Kind == "range" && p.Implicit:
+		return "range over nothing"
+	case p.Kind == "with" && p.Implicit:
+		return "with value empty"
+	case p.Implicit:
+		return "if condition false"
+	}
+	return fmt.Sprintf("%s of %s", p.Arm, p.Kind)
+}
+
+// CoverageReport is the coverage of the templates rendered since the engine
+// was created WithCoverage or since Engine.ResetCoverage. Templates that
+// were never rendered do not appear in it.
+type CoverageReport struct {
+	// Points are ordered by template and position.
+	Points []CoveragePoint `json:"points"`
+	// Sources holds the source of each template with points, by path.
+	Sources map[string]string `json:"-"`
+}
+
+// Covered returns the number of points entered at least once.
+func (r *CoverageReport) Covered() int {
+	n := 0
+	for _, p := range r.Points {
+		if p.Hits > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Percent returns the percentage of points entered at least once, or 100 if
+// there are none.
+func (r *CoverageReport) Percent() float64 {
+	if len(r.Points) == 0 {
+		return 100
+	}
+	return 100 * float64(r.Covered()) / float64(len(r.Points))
+}
+
+// Uncovered returns the points that were never entered.
+func (r *CoverageReport) Uncovered() []CoveragePoint {
+	var uncovered []CoveragePoint
+	for _, p := range r.Points {
+		if p.Hits == 0 {
+			uncovered = append(uncovered, p)
+		}
+	}
+	return uncovered
+}
+
+// WriteText writes the coverage of each template followed by the points
+// never entered, one per line as "template:line:column: description".
+func (r *CoverageReport) WriteText(w io.Writer) error {
+	for _, file := range r.files() {
+		if _, err := fmt.Fprintf(w, "%s: %d/%d (%.1f%%)\n", file.Template, file.Covered, len(file.Points), file.Percent); err != nil {
+			return err
+		}
+	}
+	for _, p := range r.Uncovered() {
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: %s never entered\n", p.Template, p.Line, p.Column, p.Description()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteHTML writes a standalone HTML page showing the source of each
+// template with the lines holding points that were entered and those that
+// were never entered highlighted.
+func (r *CoverageReport) WriteHTML(w io.Writer) error {
+	return coverageHTML.Execute(w, map[string]any{
+		"Covered": r.Covered(),
+		"Total":   len(r.Points),
+		"Percent": r.Percent(),
+		"Files":   r.files(),
+	})
+}
+
+type coverageFile struct {
+	Template string
+	Points   []CoveragePoint
+	Covered  int
+	Percent  float64
+	Lines    []coverageLine
+}
+
+type coverageLine struct {
+	Number int
+	Text   string
+	// Class is "covered", "uncovered", "partial" or "".
+	Class  string
+	Points []CoveragePoint
+}
+
+// files groups the points by template.
+func (r *CoverageReport) files() []coverageFile {
+	var files []coverageFile
+	for _, p := range r.Points {
+		if len(files) == 0 || files[len(files)-1].Template != p.Template {
+			files = append(files, coverageFile{Template: p.Template})
+		}
+		file := &files[len(files)-1]
+		file.Points = append(file.Points, p)
+		if p.Hits > 0 {
+			file.Covered++
+		}
+	}
+
+	for i := range files {
+		file := &files[i]
+		file.Percent = 100 * float64(file.Covered) / float64(len(file.Points))
+		byLine := make(map[int][]CoveragePoint)
+		for _, p := range file.Points {
+			byLine[p.Line] = append(byLine[p.Line], p)
+		}
+		for n, text := range strings.Split(strings.TrimSuffix(r.Sources[file.Template], "\n"), "\n") {
+			line := coverageLine{Number: n + 1, Text: text, Points: byLine[n+1]}
+			for _, p := range line.Points {
+				switch {
+				case line.Class == "":
+					line.Class = "uncovered"
+					if p.Hits > 0 {
+						line.Class = "covered"
+					}
+				case (line.Class == "covered") != (p.Hits > 0):
+					line.Class = "partial"
+				}
+			}
+			file.Lines = append(file.Lines, line)
+		}
+	}
+	return files
+}
+
+var coverageHTML = htmltemplate.Must(htmltemplate.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Template coverage</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table.source { border-collapse: collapse; font-family: monospace; white-space: pre; }
+table.source td { padding: 0 0.5em; vertical-align: top; }
+td.number { color: #888; text-align: right; }
+tr.covered td.text { background: #dfd; }
+tr.uncovered td.text { background: #fdd; }
+tr.partial td.text { background: #ffc; }
+td.points { color: #555; font-family: sans-serif; white-space: normal; }
+</style>
+</head>
+<body>
+<h1>Template coverage: {{ .Covered }}/{{ .Total }} ({{ printf "%.1f" .Percent }}%)</h1>
+<ul>
+{{- range .Files }}
+<li><a href="#{{ .Template }}">{{ .Template }}</a>: {{ .Covered }}/{{ len .Points }} ({{ printf "%.1f" .Percent }}%)</li>
+{{- end }}
+</ul>
+{{- range .Files }}
+<h2 id="{{ .Template }}">{{ .Template }}</h2>
+<table class="source">
+{{- range .Lines }}
+<tr{{ if .Class }} class="{{ .Class }}"{{ end }}><td class="number">{{ .Number }}</td><td class="text">{{ .Text }}</td><td class="points">
+{{- range $i, $p := .Points }}{{ if $i }}; {{ end }}{{ $p.Description }}: {{ $p.Hits }}{{ end -}}
+</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
+
+// coverageLog records the points of the templates rendered with coverage
+// and how often each was entered.
+type coverageLog struct {
+	mu      sync.Mutex
+	points  map[string]*CoveragePoint
+	sources map[string]string
+}
+
+func newCoverageLog() *coverageLog {
+	return &coverageLog{points: make(map[string]*CoveragePoint), sources: make(map[string]string)}
+}
+
+func (l *coverageLog) hit(key string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if p := l.points[key]; p != nil {
+		p.Hits++
+	}
+	return ""
+}
+
+func (l *coverageLog) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.points = make(map[string]*CoveragePoint)
+	l.sources = make(map[string]string)
+}
+
+func (l *coverageLog) report() *CoverageReport {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	report := &CoverageReport{Points: make([]CoveragePoint, 0, len(l.points)), Sources: make(map[string]string)}
+	for _, p := range l.points {
+		report.Points = append(report.Points, *p)
+		report.Sources[p.Template] = l.sources[p.Template]
+	}
+	slices.SortFunc(report.Points, func(a, b CoveragePoint) int {
+		if c := strings.Compare(a.Template, b.Template); c != 0 {
+			return c
+		}
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column - b.Column
+		}
+		return strings.Compare(a.Arm, b.Arm)
+	})
+	return report
+}
+
+// funcs returns a copy of funcs with the function instrumented templates
+// call.
+func (l *coverageLog) funcs(funcs template.FuncMap) template.FuncMap {
+	bound := make(template.FuncMap, len(funcs)+1)
+	maps.Copy(bound, funcs)
+	bound[coverFunc] = l.hit
+	return bound
+}
+
+// instrument replaces the parse trees of tmpl, which must not share them
+// with a cached template, with copies that call coverFunc on entering each
+// arm and define, and registers their points.
+func (l *coverageLog) instrument(fsys fs.FS, tmpl *template.Template) {
+	copies := make(map[*parse.Tree]*parse.Tree)
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		if instrumented, ok := copies[t.Tree]; ok {
+			t.Tree = instrumented
+			continue
+		}
+		tree := t.Tree.Copy()
+		copies[t.Tree] = tree
+		t.Tree = tree
+		l.instrumentTree(fsys, tree)
+	}
+}
+
+func (l *coverageLog) instrumentTree(fsys fs.FS, tree *parse.Tree) {
+	if tree.Name != tree.ParseName {
+		tree.Root.Nodes = slices.Insert(tree.Root.Nodes, 0, l.point(fsys, tree, tree.Root, CoveragePoint{Kind: "define", Name: tree.Name}))
+	}
+
+	var visit func(list *parse.ListNode)
+	visit = func(list *parse.ListNode) {
+		if list == nil {
+			return
+		}
+		for _, node := range list.Nodes {
+			var branch *parse.BranchNode
+			var kind string
+			switch n := node.(type) {
+			case *parse.IfNode:
+				branch, kind = &n.BranchNode, "if"
+			case *parse.RangeNode:
+				branch, kind = &n.BranchNode, "range"
+			case *parse.WithNode:
+				branch, kind = &n.BranchNode, "with"
+			default:
+				continue
+			}
+			visit(branch.List)
+			visit(branch.ElseList)
+
+			branch.List.Nodes = slices.Insert(branch.List.Nodes, 0, l.point(fsys, tree, node, CoveragePoint{Kind: kind, Arm: "then"}))
+			if branch.ElseList == nil {
+				branch.ElseList = &parse.ListNode{NodeType: parse.NodeList, Pos: branch.Pos}
+				branch.ElseList.Nodes = append(branch.ElseList.Nodes, l.point(fsys, tree, node, CoveragePoint{Kind: kind, Arm: "else", Implicit: true}))
+			} else if !isElseChain(branch.ElseList) {
+				branch.ElseList.Nodes = slices.Insert(branch.ElseList.Nodes, 0, l.point(fsys, tree, branch.ElseList, CoveragePoint{Kind: kind, Arm: "else"}))
+			}
+		}
+	}
+	visit(tree.Root)
+}
+
+// isElseChain reports whether elseList holds nothing but an if, range or
+// with action, such as that of an {{ else if }}, whose own arms cover
+// entering it.
+func isElseChain(elseList *parse.ListNode) bool {
+	if len(elseList.Nodes) != 1 {
+		return false
+	}
+	switch elseList.Nodes[0].(type) {
+	case *parse.IfNode, *parse.RangeNode, *parse.WithNode:
+		return true
+	}
+	return false
+}
+
+// point registers the point p at node of tree and returns the action that
+// records entering it.
+func (l *coverageLog) point(fsys fs.FS, tree *parse.Tree, node parse.Node, p CoveragePoint) parse.Node {
+	location, _ := tree.ErrorContext(node)
+	p.Template = tree.ParseName
+	if i := strings.LastIndexByte(location, ':'); i >= 0 {
+		// ErrorContext counts columns from 0.
+		p.Column, _ = strconv.Atoi(location[i+1:])
+		p.Column++
+		location = location[:i]
+		if j := strings.LastIndexByte(location, ':'); j >= 0 {
+			p.Line, _ = strconv.Atoi(location[j+1:])
+		}
+	}
+	key := fmt.Sprintf("%s:%d:%d:%s:%s:%s", p.Template, p.Line, p.Column, p.Kind, p.Arm, p.Name)
+
+	l.mu.Lock()
+	if _, ok := l.points[key]; !ok {
+		l.points[key] = &p
+	}
+	if _, ok := l.sources[p.Template]; !ok {
+		if content, err := fs.ReadFile(fsys, p.Template); err == nil {
+			l.sources[p.Template] = string(content)
+		}
+	}
+	l.mu.Unlock()
+
+	// Parsing the action rather than building it gives its nodes the tree
+	// they need to print themselves in error messages.
+	trees, err := parse.Parse(coverFunc, fmt.Sprintf("{{ %s %q }}", coverFunc, key), "", "", map[string]any{coverFunc: true})
+	if err != nil {
+		panic(err)
+	}
+	return trees[coverFunc].Root.Nodes[0]
+}
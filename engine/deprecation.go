@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"log/slog"
+	"sync"
+	"text/template"
+)
+
+// deprecations warns the first time a template calls a deprecated
+// function, so template packs can be moved off old helpers.
+type deprecations struct {
+	// replacements maps each deprecated function to the function to use
+	// instead, or to "" if there is none.
+	replacements map[string]string
+	warned       sync.Map
+}
+
+// wrap returns a copy of funcs in which each deprecated function logs a
+// warning naming templatePath when it is first called.
+func (d *deprecations) wrap(funcs template.FuncMap, templatePath string, logger *slog.Logger) template.FuncMap {
+	return observeCalls(funcs, func(name string) func() {
+		if _, ok := d.replacements[name]; !ok {
+			return nil
+		}
+		return func() { d.warn(name, templatePath, logger) }
+	})
+}
+
+func (d *deprecations) warn(name, templatePath string, logger *slog.Logger) {
+	if _, warned := d.warned.LoadOrStore(name, true); warned {
+		return
+	}
+	if replacement := d.replacements[name]; replacement != "" {
+		logger.Warn("template calls deprecated function", "function", name, "template", templatePath, "replacement", replacement)
+		return
+	}
+	logger.Warn("template calls deprecated function", "function", name, "template", templatePath)
+}
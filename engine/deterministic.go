@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"io/fs"
+	"os"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/cpcf/weft/render"
+)
+
+// deterministicSettings pins every input that otherwise varies between runs
+// so identical inputs produce byte-identical output.
+type deterministicSettings struct {
+	enabled bool
+	seed    uint64
+	now     time.Time
+}
+
+// resolve fills in the pinned time when none was given, honouring the
+// SOURCE_DATE_EPOCH convention used by reproducible builds.
+func (d *deterministicSettings) resolve() {
+	if !d.enabled || !d.now.IsZero() {
+		return
+	}
+
+	d.now = time.Unix(0, 0).UTC()
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		if seconds, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+			d.now = time.Unix(seconds, 0).UTC()
+		}
+	}
+}
+
+// funcs returns base with its non-deterministic functions replaced by
+// seeded equivalents. Each template path gets its own seed, so output does
+// not depend on the order templates are rendered in.
+func (d deterministicSettings) funcs(base template.FuncMap, templatePath string) template.FuncMap {
+	return render.DeterministicFuncMap(base, render.SeedFor(d.seed, templatePath), d.now)
+}
+
+// bindFuncs returns a copy of tmpl that calls funcs instead of the functions
+// it was parsed with. Files included by the template are rendered with the
+// same functions.
+func bindFuncs(tmpl *template.Template, funcs template.FuncMap, fsys fs.FS, templatePath string) (*template.Template, error) {
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return render.BindInclude(clone.Funcs(funcs), fsys, templatePath, funcs), nil
+}
+
+func (d deterministicSettings) clock() func() time.Time {
+	if d.enabled {
+		now := d.now
+		return func() time.Time { return now }
+	}
+	return time.Now
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"sync"
+)
+
+// ErrEmptyOutput is returned for templates whose rendered output is empty or
+// whitespace-only when the EmptyError policy applies.
+var ErrEmptyOutput = errors.New("template rendered empty output")
+
+type EmptyOutputPolicy int
+
+const (
+	// EmptyWrite writes the empty output like any other file (default).
+	EmptyWrite EmptyOutputPolicy = iota
+	// EmptySkip does not write the file. An existing file is left untouched.
+	EmptySkip
+	// EmptyError fails the template with ErrEmptyOutput.
+	EmptyError
+)
+
+func (p EmptyOutputPolicy) String() string {
+	switch p {
+	case EmptyWrite:
+		return "write"
+	case EmptySkip:
+		return "skip"
+	case EmptyError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// EmptyOutputRule overrides the empty output policy for templates whose path
+// matches Pattern. Patterns use path.Match syntax and are matched against the
+// full template path, or against the file name when they contain no slash.
+type EmptyOutputRule struct {
+	Pattern string
+	Policy  EmptyOutputPolicy
+}
+
+// EmptyOutputOptions configures how templates that render nothing but
+// whitespace are handled. The first matching rule wins; otherwise Default
+// applies.
+type EmptyOutputOptions struct {
+	Default EmptyOutputPolicy
+	Rules   []EmptyOutputRule
+}
+
+// policyFor returns the policy that applies to templatePath.
+func (o EmptyOutputOptions) policyFor(templatePath string) EmptyOutputPolicy {
+	for _, rule := range o.Rules {
+		target := templatePath
+		if !strings.Contains(rule.Pattern, "/") {
+			target = path.Base(templatePath)
+		}
+		if matched, _ := path.Match(rule.Pattern, target); matched {
+			return rule.Policy
+		}
+	}
+	return o.Default
+}
+
+// EmptyOutput records a template that rendered empty output and what the
+// engine did about it.
+type EmptyOutput struct {
+	TemplatePath string            `json:"template_path"`
+	OutputPath   string            `json:"output_path"`
+	Policy       EmptyOutputPolicy `json:"policy"`
+	RunID        string            `json:"run_id,omitempty"`
+}
+
+// emptyOutputLog collects empty outputs across a render call.
+type emptyOutputLog struct {
+	mu      sync.Mutex
+	entries []EmptyOutput
+}
+
+func (l *emptyOutputLog) add(entry EmptyOutput) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+func (l *emptyOutputLog) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = nil
+}
+
+func (l *emptyOutputLog) list() []EmptyOutput {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := make([]EmptyOutput, len(l.entries))
+	copy(entries, l.entries)
+	return entries
+}
+
+func isEmptyOutput(content []byte) bool {
+	return len(strings.TrimSpace(string(content))) == 0
+}
@@ -2,10 +2,16 @@
 package engine
 
 import (
+	"context"
 	"io"
 	"log/slog"
+	"maps"
+	"slices"
+	"text/template"
+	"time"
 
 	"github.com/cpcf/weft/postprocess"
+	"github.com/cpcf/weft/render"
 )
 
 type Engine struct {
@@ -15,6 +21,21 @@ type Engine struct {
 	renderer       *Renderer
 	cache          *TemplateCache
 	postprocessors *postprocess.Chain
+	backup         BackupOptions
+	emptyOutput    EmptyOutputOptions
+	funcs          template.FuncMap
+	sandbox        render.SandboxProfile
+	deprecated     map[string]string
+	deterministic  deterministicSettings
+	streamAbove    int64
+	runs           *runTracker
+	guard          overwriteGuard
+	verifyGo       bool
+	idempotent     bool
+	trackUsage     bool
+	coverage       bool
+	checkFormats   bool
+	telemetry      Telemetry
 }
 
 type FailureMode int
@@ -25,6 +46,19 @@ const (
 	BestEffort
 )
 
+func (m FailureMode) String() string {
+	switch m {
+	case FailFast:
+		return "fail-fast"
+	case FailAtEnd:
+		return "fail-at-end"
+	case BestEffort:
+		return "best-effort"
+	default:
+		return "unknown"
+	}
+}
+
 func New(opts ...Option) *Engine {
 	e := &Engine{
 		logger:         slog.Default(),
@@ -32,19 +66,117 @@ func New(opts ...Option) *Engine {
 		failMode:       FailFast,
 		cache:          NewTemplateCache(),
 		postprocessors: postprocess.NewChain(),
+		runs:           &runTracker{},
 	}
 
 	for _, opt := range opts {
 		opt(e)
 	}
 
+	if e.funcs != nil {
+		funcs := render.DefaultFuncMap()
+		maps.Copy(funcs, e.funcs)
+		e.cache.funcs = funcs
+	}
+	e.cache.funcs = e.sandbox.Restrict(e.cache.funcs)
+	e.deterministic.resolve()
+	e.postprocessors.SetErrorHandler(func(err *postprocess.StageError) {
+		e.logger.Warn("post-processor failed, keeping previous content", "stage", err.Stage, "path", err.FilePath, "error", err.Err)
+	})
+
 	e.renderer = NewRenderer(e.logger, e.cache, e.postprocessors)
+	e.renderer.deterministic = e.deterministic
+	e.renderer.deprecations = &deprecations{replacements: e.deprecated}
+	if e.trackUsage {
+		e.renderer.usage.enabled = true
+		e.renderer.usage.available = slices.Sorted(maps.Keys(e.cache.funcMap()))
+	}
+	if e.coverage {
+		e.renderer.coverage = newCoverageLog()
+	}
+	e.renderer.backup = e.backup
+	e.renderer.emptyOutput = e.emptyOutput
+	e.renderer.streamAbove = e.streamAbove
+	e.renderer.guard = e.guard
+	e.renderer.checkFormats = e.checkFormats
+	if e.telemetry != nil {
+		e.renderer.telemetry = e.telemetry
+		e.postprocessors.SetStageHook(stageHook(e.telemetry))
+	}
+	e.renderer.options = e.processorOptions()
 
 	return e
 }
 
 func (e *Engine) RenderDir(ctx Context, templateDir string, data any) error {
-	return e.renderer.RenderDir(ctx, e.failMode, templateDir, data)
+	if err := e.renderer.RenderDir(e.beginRun(ctx), e.failMode, templateDir, data); err != nil {
+		return err
+	}
+	return e.verify()
+}
+
+// processorOptions describes the engine configuration to post-processors.
+func (e *Engine) processorOptions() map[string]any {
+	return map[string]any{
+		"failure_mode":    e.failMode.String(),
+		"deterministic":   e.deterministic.enabled,
+		"sandbox":         e.sandbox.String(),
+		"backup":          e.backup.Mode != BackupDisabled,
+		"stream_above":    e.streamAbove,
+		"overwrite_guard": e.guard.enabled,
+		"verify_go":       e.verifyGo,
+		"idempotent":      e.idempotent,
+		"check_formats":   e.checkFormats,
+	}
+}
+
+// verify runs the post-generation checks enabled by options.
+func (e *Engine) verify() error {
+	if e.idempotent {
+		issues, err := e.renderer.CheckIdempotency()
+		if err != nil {
+			return err
+		}
+		if len(issues) > 0 {
+			return &IdempotencyError{Issues: issues}
+		}
+	}
+
+	if e.verifyGo {
+		diagnostics, err := e.VerifyGo(context.Background())
+		if err != nil {
+			return err
+		}
+		if len(diagnostics) > 0 {
+			return &GoVerificationError{Diagnostics: diagnostics}
+		}
+	}
+	return nil
+}
+
+// CheckIdempotency renders every file from the most recent run again in
+// memory and reports those whose content differs from what is on disk,
+// together with the nondeterministic functions their templates use. Use it
+// to gate CI on generated code being up to date and reproducible.
+func (e *Engine) CheckIdempotency() ([]IdempotencyIssue, error) {
+	return e.renderer.CheckIdempotency()
+}
+
+// beginRun assigns a run ID to ctx if it has none and records it as the
+// engine's most recent run.
+func (e *Engine) beginRun(ctx Context) Context {
+	if ctx.RunID == "" {
+		ctx.RunID = e.deterministic.runID(ctx.OutputRoot)
+	}
+	e.runs.set(ctx.RunID)
+	return ctx
+}
+
+// RunID returns the ID of the most recent RenderDir, RenderTree or
+// RenderPipeline call, or "" before the first run. Record it in manifests
+// and reports to correlate them with the run's logs and errors.
+func (e *Engine) RunID() string {
+	return e.runs.get()
 }
 
 func (e *Engine) SetOutput(w io.Writer) {
@@ -62,3 +194,76 @@ func (e *Engine) AddPostProcessor(processor postprocess.Processor) {
 func (e *Engine) AddPostProcessorFunc(fn func(filePath string, content []byte) ([]byte, error)) {
 	e.postprocessors.AddFunc(fn)
 }
+
+// AddPostProcessorStage adds a pipeline stage that only sees matching files,
+// can be ordered relative to other named stages and may be marked non-fatal.
+func (e *Engine) AddPostProcessorStage(stage postprocess.Stage) error {
+	return e.postprocessors.AddStage(stage)
+}
+
+// RenderTree expands a declared file tree against data and renders each file
+// node, honouring the engine's failure mode.
+func (e *Engine) RenderTree(ctx Context, tree *Tree, data any) error {
+	if err := e.renderer.RenderTree(e.beginRun(ctx), e.failMode, tree, data); err != nil {
+		return err
+	}
+	return e.verify()
+}
+
+// RenderPipeline runs the passes of pipeline, feeding the results of
+// template passes to the passes that take them as inputs, and honouring the
+// engine's failure mode for directory passes.
+func (e *Engine) RenderPipeline(ctx Context, pipeline *Pipeline, data any) error {
+	if err := e.renderer.RenderPipeline(e.beginRun(ctx), e.failMode, pipeline, data); err != nil {
+		return err
+	}
+	return e.verify()
+}
+
+// GeneratedFiles lists the files written during the most recent render and
+// the templates they came from.
+func (e *Engine) GeneratedFiles() []GeneratedFile {
+	return e.renderer.GeneratedFiles()
+}
+
+// EmptyOutputs reports the templates that rendered empty or whitespace-only
+// output during the most recent render and the policy applied to each.
+func (e *Engine) EmptyOutputs() []EmptyOutput {
+	return e.renderer.EmptyOutputs()
+}
+
+// FunctionUsage reports, for every function templates can call, how often
+// each template called it during the most recent render, most called first.
+// Functions with no calls are helpers no template used. It is empty unless
+// the engine was created WithFunctionUsage.
+func (e *Engine) FunctionUsage() []FunctionUsage {
+	if !e.renderer.usage.enabled {
+		return nil
+	}
+	return e.renderer.usage.list()
+}
+
+// Coverage reports which arms of if, range and with actions and which
+// defines the templates rendered since the engine was created, or since
+// ResetCoverage, entered, across every render in between. It is nil unless
+// the engine was created WithCoverage.
+func (e *Engine) Coverage() *CoverageReport {
+	if e.renderer.coverage == nil {
+		return nil
+	}
+	return e.renderer.coverage.report()
+}
+
+// ResetCoverage discards the coverage recorded so far.
+func (e *Engine) ResetCoverage() {
+	if e.renderer.coverage != nil {
+		e.renderer.coverage.reset()
+	}
+}
+
+// Clock returns the engine's source of the current time. In deterministic
+// mode it always returns the pinned time, so custom processors and writers
+// that stamp output can stay reproducible.
+func (e *Engine) Clock() func() time.Time {
+	return e.deterministic.clock()
+}
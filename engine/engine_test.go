@@ -1,13 +1,21 @@
 package engine
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cpcf/weft/postprocess"
+	"github.com/cpcf/weft/render"
 	gogentest "github.com/cpcf/weft/testing"
 )
 
@@ -250,3 +258,1230 @@ func TestConcurrentRenderer_Stop_RaceCondition(t *testing.T) {
 
 	t.Logf("Test completed successfully. Completed %d out of %d submitted tasks", completedTasks, len(resultChans))
 }
+
+func TestEngineRenderTree(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/file.go.tmpl", []byte("// {{.Name}}\n"))
+	memFS.WriteFile("tree.yaml", []byte(`nodes:
+  - path: "{{ .Name }}"
+    each: Packages
+    children:
+      - path: "{{ .Name | snake }}.go"
+        each: Files
+        template: templates/file.go.tmpl
+`))
+
+	tempDir := t.TempDir()
+
+	tree, err := LoadTree(memFS, "tree.yaml")
+	if err != nil {
+		t.Fatalf("LoadTree failed: %v", err)
+	}
+
+	type file struct{ Name string }
+	type pkg struct {
+		Name  string
+		Files []file
+	}
+	data := map[string]any{
+		"Packages": []pkg{
+			{Name: "models", Files: []file{{Name: "UserAccount"}, {Name: "Order"}}},
+			{Name: "handlers", Files: []file{{Name: "Health"}}},
+		},
+	}
+
+	eng := New(WithOutputRoot(tempDir))
+	if err := eng.RenderTree(NewContext(memFS, tempDir, "example"), tree, data); err != nil {
+		t.Fatalf("RenderTree failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"models/user_account.go": "// UserAccount\n",
+		"models/order.go":        "// Order\n",
+		"handlers/health.go":     "// Health\n",
+	}
+	for path, want := range expected {
+		content, err := os.ReadFile(filepath.Join(tempDir, path))
+		if err != nil {
+			t.Errorf("Expected %s to be generated: %v", path, err)
+			continue
+		}
+		if string(content) != want {
+			t.Errorf("%s: expected %q, got %q", path, want, string(content))
+		}
+	}
+}
+
+func TestEngineRenderTreeErrors(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/file.tmpl", []byte("{{.}}"))
+
+	ctx := NewContext(memFS, t.TempDir(), "example")
+
+	t.Run("PathEscape", func(t *testing.T) {
+		tree := &Tree{Nodes: []TreeNode{{Path: "../{{.}}", Each: "Names", Template: "templates/file.tmpl"}}}
+		err := New().RenderTree(ctx, tree, map[string]any{"Names": []string{"x"}})
+		if err == nil || !strings.Contains(err.Error(), "escapes") {
+			t.Errorf("Expected path escape error, got %v", err)
+		}
+	})
+
+	t.Run("MissingField", func(t *testing.T) {
+		tree := &Tree{Nodes: []TreeNode{{Path: "{{.}}", Each: "Missing", Template: "templates/file.tmpl"}}}
+		err := New(WithFailureMode(FailAtEnd)).RenderTree(ctx, tree, map[string]any{})
+		if _, ok := err.(*MultiError); !ok {
+			t.Errorf("Expected MultiError, got %T: %v", err, err)
+		}
+	})
+
+	t.Run("FileWithChildren", func(t *testing.T) {
+		tree := &Tree{Nodes: []TreeNode{{Path: "a", Template: "templates/file.tmpl", Children: []TreeNode{{Path: "b"}}}}}
+		if err := tree.Validate(); err == nil {
+			t.Error("Expected validation error for file node with children")
+		}
+	})
+}
+
+func TestEngineBackup(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/file.txt.tmpl", []byte("{{.Value}}"))
+
+	render := func(t *testing.T, eng *Engine, dir, value string) {
+		t.Helper()
+		ctx := NewContext(memFS, dir, "example")
+		if err := eng.RenderDir(ctx, "templates", map[string]any{"Value": value}); err != nil {
+			t.Fatalf("RenderDir failed: %v", err)
+		}
+	}
+
+	t.Run("Directory", func(t *testing.T) {
+		tempDir := t.TempDir()
+		eng := New(WithBackup(BackupOptions{Mode: BackupDirectory, Retention: 2}))
+
+		for _, value := range []string{"v1", "v2", "v3", "v4", "v4"} {
+			render(t, eng, tempDir, value)
+		}
+
+		backupDir := filepath.Join(tempDir, DefaultBackupDir, "templates")
+		entries, err := os.ReadDir(backupDir)
+		if err != nil {
+			t.Fatalf("Failed to read backup dir: %v", err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 retained backups, got %d", len(entries))
+		}
+
+		latest, err := os.ReadFile(filepath.Join(backupDir, entries[1].Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(latest) != "v3" {
+			t.Errorf("Expected newest backup to hold v3, got %q", string(latest))
+		}
+	})
+
+	t.Run("Sibling", func(t *testing.T) {
+		tempDir := t.TempDir()
+		eng := New(WithBackup(BackupOptions{Mode: BackupSibling}))
+
+		render(t, eng, tempDir, "manual")
+		render(t, eng, tempDir, "generated")
+
+		backup, err := os.ReadFile(filepath.Join(tempDir, "templates", "file.txt.bak"))
+		if err != nil {
+			t.Fatalf("Expected sibling backup: %v", err)
+		}
+		if string(backup) != "manual" {
+			t.Errorf("Expected backup to hold previous content, got %q", string(backup))
+		}
+	})
+}
+
+func TestEngineStreaming(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/seed.sql.tmpl", []byte("{{range .Rows}}INSERT INTO t VALUES ({{.}});\n{{end}}"))
+	memFS.WriteFile("templates/small.txt.tmpl", []byte("small"))
+
+	rows := make([]int, 1000)
+	for i := range rows {
+		rows[i] = i
+	}
+
+	tempDir := t.TempDir()
+	eng := New(WithStreaming(1024), WithBackup(BackupOptions{Mode: BackupSibling}))
+	eng.AddPostProcessorFunc(func(filePath string, content []byte) ([]byte, error) {
+		return append([]byte("-- processed\n"), content...), nil
+	})
+
+	ctx := NewContext(memFS, tempDir, "example")
+	for range 2 {
+		if err := eng.RenderDir(ctx, "templates", map[string]any{"Rows": rows}); err != nil {
+			t.Fatalf("RenderDir failed: %v", err)
+		}
+	}
+
+	seedPath := filepath.Join(tempDir, "templates", "seed.sql")
+	seed, err := os.ReadFile(seedPath)
+	if err != nil {
+		t.Fatalf("Failed to read streamed output: %v", err)
+	}
+	if !strings.HasPrefix(string(seed), "INSERT INTO t VALUES (0);") || !strings.HasSuffix(string(seed), "(999);\n") {
+		t.Errorf("Streamed output is incomplete: %d bytes", len(seed))
+	}
+
+	info, err := os.Stat(seedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("Expected mode 0644, got %v", info.Mode().Perm())
+	}
+
+	if _, err := os.Stat(seedPath + ".bak"); err != nil {
+		t.Errorf("Expected backup of streamed output: %v", err)
+	}
+
+	small, err := os.ReadFile(filepath.Join(tempDir, "templates", "small.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(small) != "-- processed\nsmall" {
+		t.Errorf("Expected small output to be post-processed, got %q", string(small))
+	}
+
+	entries, err := os.ReadDir(filepath.Join(tempDir, "templates"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("Temporary file left behind: %s", entry.Name())
+		}
+	}
+}
+
+func TestEngineStreamingLeavesNoDirectoryOnFailure(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/db/seed/seed.sql.tmpl", []byte("{{range .Rows}}INSERT INTO t VALUES ({{.}});\n{{end}}{{ index .Rows 5000 }}"))
+
+	tempDir := t.TempDir()
+	eng := New(WithStreaming(1024))
+	err := eng.RenderDir(NewContext(memFS, tempDir, "example"), "templates", map[string]any{"Rows": make([]int, 1000)})
+	if err == nil {
+		t.Fatal("Expected the template to fail")
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected a failed streamed render to leave nothing behind, got %v", entries)
+	}
+}
+
+func TestEngineTemplateErrorLocation(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("a/client.go.tmpl", []byte("package client\n\n// {{ .Name }}\n{{ template \"method\" . }}\n"))
+	memFS.WriteFile("a/_method.tmpl", []byte("func a() {}\n\nfunc b() {}\n\tx := {{ .Spec.Path }}\n\nfunc c() {}\n\nfunc d() {}\n"))
+	memFS.WriteFile("b/inc.txt.tmpl", []byte("{{ include \"license\" . }}"))
+	memFS.WriteFile("includes/license.tmpl", []byte("Copyright\n{{ index .Years 3 }}"))
+
+	eng := New()
+	err := eng.RenderDir(NewContext(memFS, t.TempDir(), "example"), "a", map[string]any{"Name": "x", "Spec": nil})
+
+	var tmplErr *TemplateError
+	if !errors.As(err, &tmplErr) {
+		t.Fatalf("Expected TemplateError, got %v", err)
+	}
+	if tmplErr.Template != "a/_method.tmpl" || tmplErr.Line != 4 || tmplErr.Column != 15 {
+		t.Errorf("got location %s:%d:%d", tmplErr.Template, tmplErr.Line, tmplErr.Column)
+	}
+	wantExcerpt := "  1 | func a() {}\n  2 | \n  3 | func b() {}\n> 4 | \tx := {{ .Spec.Path }}\n    | \t             ^\n  5 | \n  6 | func c() {}\n  7 | "
+	if tmplErr.Excerpt != wantExcerpt {
+		t.Errorf("excerpt:\n%s\nwant:\n%s", tmplErr.Excerpt, wantExcerpt)
+	}
+	if !strings.Contains(err.Error(), "failed to execute template a/client.go.tmpl: a/_method.tmpl:4:15: executing \"method\" at <.Spec.Path>") {
+		t.Errorf("unexpected message: %v", err)
+	}
+
+	err = eng.RenderDir(NewContext(memFS, t.TempDir(), "example"), "b", map[string]any{"Years": []int{2024}})
+	if !errors.As(err, &tmplErr) || tmplErr.Template != "includes/license.tmpl" || tmplErr.Line != 2 || !strings.Contains(tmplErr.Excerpt, "> 2 | {{ index .Years 3 }}") {
+		t.Errorf("Expected the include's location, got %v", err)
+	}
+}
+
+type suggestionService struct {
+	Name    string
+	Methods []suggestionMethod
+}
+
+type suggestionMethod struct {
+	Name string
+}
+
+func (m suggestionMethod) Path() string { return "/" + m.Name }
+
+func TestEngineErrorSuggestions(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("funcs/a.txt.tmpl", []byte("{{ .Name | pascl }}"))
+	memFS.WriteFile("fields/a.txt.tmpl", []byte("{{ range .Methods }}{{ .Nmae }}{{ end }}"))
+	memFS.WriteFile("methods/a.txt.tmpl", []byte("{{ range .Methods }}{{ .path }}{{ end }}"))
+	memFS.WriteFile("far/a.txt.tmpl", []byte("{{ .Description }}"))
+	data := suggestionService{Name: "svc", Methods: []suggestionMethod{{Name: "get"}}}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"funcs", `did you mean "pascal"?`},
+		{"fields", `did you mean "Name"?`},
+		{"methods", `did you mean "Path"?`},
+		{"far", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			err := New().RenderDir(NewContext(memFS, t.TempDir(), "example"), tt.dir, data)
+			var tmplErr *TemplateError
+			if !errors.As(err, &tmplErr) {
+				t.Fatalf("Expected TemplateError, got %v", err)
+			}
+			if tmplErr.Suggestion != tt.want {
+				t.Errorf("Suggestion = %q, want %q", tmplErr.Suggestion, tt.want)
+			}
+			if tt.want != "" && !strings.Contains(err.Error(), "; "+tt.want) {
+				t.Errorf("Expected the suggestion in the message, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEngineRunID(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/ok.txt.tmpl", []byte("ok"))
+	memFS.WriteFile("templates/bad.txt.tmpl", []byte("{{.Name"))
+
+	tempDir := t.TempDir()
+	ctx := NewContext(memFS, tempDir, "example")
+
+	eng := New(WithFailureMode(FailAtEnd))
+	err := eng.RenderDir(ctx, "templates", nil)
+
+	var multiErr *MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("Expected MultiError, got %v", err)
+	}
+	if eng.RunID() == "" || multiErr.RunID != eng.RunID() || multiErr.Errors[0].RunID != eng.RunID() {
+		t.Errorf("Expected errors to carry run ID %q, got %q", eng.RunID(), multiErr.RunID)
+	}
+
+	previous := eng.RunID()
+	eng.RenderDir(ctx, "templates", nil)
+	if eng.RunID() == previous {
+		t.Error("Expected a new run ID for each run")
+	}
+
+	ctx.RunID = "external-id"
+	eng.RenderDir(ctx, "templates", nil)
+	if eng.RunID() != "external-id" {
+		t.Errorf("Expected caller-supplied run ID, got %q", eng.RunID())
+	}
+
+	ctx.RunID = ""
+	first := New(WithDeterministicSeed(7), WithFailureMode(BestEffort))
+	second := New(WithDeterministicSeed(7), WithFailureMode(BestEffort))
+	first.RenderDir(ctx, "templates", nil)
+	second.RenderDir(ctx, "templates", nil)
+	if first.RunID() != second.RunID() {
+		t.Errorf("Expected deterministic run IDs to match, got %q and %q", first.RunID(), second.RunID())
+	}
+}
+
+func TestEngineProcessorContext(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/hello.txt.tmpl", []byte("Hello {{.Name}}"))
+
+	tempDir := t.TempDir()
+	ctx := NewContext(memFS, tempDir, "example")
+
+	var got *postprocess.FileInfo
+	eng := New(WithFailureMode(FailAtEnd))
+	eng.AddPostProcessor(postprocess.ContextProcessorFunc(func(info *postprocess.FileInfo, content []byte) ([]byte, error) {
+		got = info
+		return content, nil
+	}))
+
+	data := map[string]any{"Name": "World", "Version": 2}
+	if err := eng.RenderDir(ctx, "templates", data); err != nil {
+		t.Fatalf("RenderDir failed: %v", err)
+	}
+
+	if got == nil {
+		t.Fatal("Expected context processor to be called")
+	}
+	if got.TemplatePath != "templates/hello.txt.tmpl" || got.Path != filepath.Join(tempDir, "templates", "hello.txt") {
+		t.Errorf("Unexpected paths: template %q, output %q", got.TemplatePath, got.Path)
+	}
+	if strings.Join(got.DataKeys, ",") != "Name,Version" {
+		t.Errorf("Expected data keys Name,Version, got %v", got.DataKeys)
+	}
+	if got.RunID != eng.RunID() || got.Options["failure_mode"] != "fail-at-end" || got.Logger == nil {
+		t.Errorf("Expected run ID, options and logger, got %+v", got)
+	}
+}
+
+func TestEngineIdempotencyCheck(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/stable.txt.tmpl", []byte("Hello {{.Name}}\nBye\n"))
+	memFS.WriteFile("templates/stamp.txt.tmpl", []byte("Generated at {{now.UnixNano}}\n"))
+
+	tempDir := t.TempDir()
+	ctx := NewContext(memFS, tempDir, "example")
+	data := map[string]any{"Name": "World"}
+
+	eng := New(WithIdempotencyCheck())
+	err := eng.RenderDir(ctx, "templates", data)
+
+	var idemErr *IdempotencyError
+	if !errors.As(err, &idemErr) {
+		t.Fatalf("Expected IdempotencyError, got %v", err)
+	}
+	if len(idemErr.Issues) != 1 {
+		t.Fatalf("Expected one issue, got %+v", idemErr.Issues)
+	}
+	issue := idemErr.Issues[0]
+	if issue.TemplatePath != "templates/stamp.txt.tmpl" || issue.Line != 1 || strings.Join(issue.Suspects, ",") != "now (time)" {
+		t.Errorf("Unexpected issue: %+v", issue)
+	}
+
+	deterministic := New(WithIdempotencyCheck(), WithDeterministicSeed(1))
+	if err := deterministic.RenderDir(ctx, "templates", data); err != nil {
+		t.Errorf("Expected deterministic run to be idempotent, got %v", err)
+	}
+
+	stable := filepath.Join(tempDir, "templates", "stable.txt")
+	os.WriteFile(stable, []byte("Hello World\nEdited\n"), 0o644)
+	issues, err := deterministic.CheckIdempotency()
+	if err != nil {
+		t.Fatalf("CheckIdempotency failed: %v", err)
+	}
+	if len(issues) != 1 || issues[0].OutputPath != stable || issues[0].Line != 2 || len(issues[0].Suspects) != 0 {
+		t.Errorf("Expected edited file to differ at line 2, got %+v", issues)
+	}
+}
+
+func TestEngineOutputFormatCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		template string
+		want     string
+	}{
+		{"valid json", "ok.json.tmpl", `{"items": [{{ range $i, $n := .Names }}{{ if $i }}, {{ end }}"{{ $n }}"{{ end }}]}`, ""},
+		{"trailing comma", "bad.json.tmpl", "{\n  \"items\": [{{ range .Names }}\"{{ . }}\",{{ end }}]\n}\n", "bad.json:2:21: output of template templates/bad.json.tmpl is not valid json"},
+		{"yaml", "bad.yaml.tmpl", "items:\n{{ range .Names }}- {{ . }}\n{{ end }}  - : x: y\n", "bad.yaml:4:1: output of template templates/bad.yaml.tmpl is not valid yaml"},
+		{"go", "bad.go.tmpl", "package main\n\nfunc {{ index .Names 0 }}() {\n", "bad.go:4:1: output of template templates/bad.go.tmpl is not valid go"},
+		{"sql", "bad.sql.tmpl", "CREATE TABLE t (\n{{ range .Names }}  {{ . }} TEXT,\n{{ end }});\n", "bad.sql:3:9: output of template templates/bad.sql.tmpl is not valid sql: trailing comma before )"},
+		{"declared format", "config.tmpl", "{{/* weft:format json */}}{ {{ .Names }} }", "config:1:3: output of template templates/config.tmpl is not valid json"},
+		{"declared text", "notes.json.tmpl", "{{/* weft:format text */}}{ not json", ""},
+		{"unchecked extension", "notes.txt.tmpl", "{ not json", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memFS := gogentest.NewMemoryFS()
+			memFS.WriteFile("templates/"+tt.path, []byte(tt.template))
+			tempDir := t.TempDir()
+			ctx := NewContext(memFS, tempDir, "example")
+
+			err := New(WithOutputFormatCheck()).RenderDir(ctx, "templates", map[string]any{"Names": []string{"a", "b"}})
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("RenderFile() error = %v", err)
+				}
+				return
+			}
+			var formatErr *OutputFormatError
+			if !errors.As(err, &formatErr) {
+				t.Fatalf("Expected OutputFormatError, got %v", err)
+			}
+			want := filepath.Join(tempDir, "templates", tt.want)
+			if got := formatErr.Error(); !strings.HasPrefix(got, want) || !strings.Contains(got, "templates/"+tt.path) {
+				t.Errorf("error = %q, want prefix %q", got, want)
+			}
+			if _, statErr := os.Stat(formatErr.OutputPath); !os.IsNotExist(statErr) {
+				t.Errorf("Expected no output to be written, got %v", statErr)
+			}
+		})
+	}
+
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/bad.json.tmpl", []byte("{,}"))
+	if err := New().RenderDir(NewContext(memFS, t.TempDir(), "example"), "templates", nil); err != nil {
+		t.Errorf("Expected no check without the option, got %v", err)
+	}
+}
+
+func TestEngineOverwriteGuard(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/generated.go.tmpl", []byte("// Code generated by test. DO NOT EDIT.\n\npackage {{.Package}}\n"))
+	memFS.WriteFile("templates/plain.txt.tmpl", []byte("plain"))
+
+	tempDir := t.TempDir()
+	ctx := NewContext(memFS, tempDir, "example")
+	eng := New(WithOverwriteGuard(""), WithFailureMode(FailAtEnd))
+	data := map[string]any{"Package": "one"}
+
+	if err := eng.RenderDir(ctx, "templates", data); err != nil {
+		t.Fatalf("First render should create new files: %v", err)
+	}
+
+	handWritten := filepath.Join(tempDir, "templates", "plain.txt")
+	if err := os.WriteFile(handWritten, []byte("hand-written"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data["Package"] = "two"
+	err := eng.RenderDir(ctx, "templates", data)
+	if !errors.Is(err, ErrUnmanagedFile) {
+		t.Fatalf("Expected ErrUnmanagedFile, got %v", err)
+	}
+
+	content, _ := os.ReadFile(handWritten)
+	if string(content) != "hand-written" {
+		t.Errorf("Expected hand-written file to be preserved, got %q", string(content))
+	}
+
+	generated, _ := os.ReadFile(filepath.Join(tempDir, "templates", "generated.go"))
+	if !strings.Contains(string(generated), "package two") {
+		t.Errorf("Expected generated file to be regenerated, got %q", string(generated))
+	}
+}
+
+func TestEngineGoVerification(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("gen/ok.go.tmpl", []byte("package gen\n\nfunc OK() int { return 1 }\n"))
+	memFS.WriteFile("gen/bad.go.tmpl", []byte("package gen\n\nfunc Bad() int { return {{.Value}} }\n"))
+
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module example.com/gen\n\ngo 1.21\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := NewContext(memFS, tempDir, "example.com/gen")
+	eng := New(WithGoVerification())
+
+	if err := eng.RenderDir(ctx, "gen", map[string]any{"Value": "2"}); err != nil {
+		t.Fatalf("Expected valid code to verify: %v", err)
+	}
+
+	err := eng.RenderDir(ctx, "gen", map[string]any{"Value": `"two"`})
+	var verifyErr *GoVerificationError
+	if !errors.As(err, &verifyErr) {
+		t.Fatalf("Expected GoVerificationError, got %v", err)
+	}
+
+	d := verifyErr.Diagnostics[0]
+	if d.TemplatePath != "gen/bad.go.tmpl" || d.Line != 3 || filepath.Base(d.File) != "bad.go" {
+		t.Errorf("Unexpected diagnostic: %+v", d)
+	}
+}
+
+func TestEngineEmptyOutput(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/always.go.tmpl", []byte("package {{.Package}}\n"))
+	memFS.WriteFile("templates/optional.go.tmpl", []byte("{{if .Enabled}}package {{.Package}}{{end}}\n  \n"))
+	memFS.WriteFile("templates/required.txt.tmpl", []byte("{{if .Enabled}}required{{end}}"))
+
+	data := map[string]any{"Package": "main", "Enabled": false}
+
+	t.Run("Skip", func(t *testing.T) {
+		tempDir := t.TempDir()
+		eng := New(WithEmptyOutput(EmptyOutputOptions{Default: EmptySkip}))
+
+		if err := eng.RenderDir(NewContext(memFS, tempDir, "example"), "templates", data); err != nil {
+			t.Fatalf("RenderDir failed: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(tempDir, "templates", "optional.go")); !os.IsNotExist(err) {
+			t.Error("Expected empty output to be skipped")
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, "templates", "always.go")); err != nil {
+			t.Errorf("Expected non-empty output to be written: %v", err)
+		}
+
+		empties := eng.EmptyOutputs()
+		if len(empties) != 2 {
+			t.Fatalf("Expected 2 empty outputs reported, got %d", len(empties))
+		}
+		for _, empty := range empties {
+			if empty.Policy != EmptySkip {
+				t.Errorf("Expected skip policy for %s, got %s", empty.TemplatePath, empty.Policy)
+			}
+		}
+	})
+
+	t.Run("GlobOverride", func(t *testing.T) {
+		tempDir := t.TempDir()
+		eng := New(
+			WithFailureMode(FailAtEnd),
+			WithEmptyOutput(EmptyOutputOptions{
+				Default: EmptySkip,
+				Rules:   []EmptyOutputRule{{Pattern: "*.txt.tmpl", Policy: EmptyError}},
+			}),
+		)
+
+		err := eng.RenderDir(NewContext(memFS, tempDir, "example"), "templates", data)
+		multiErr, ok := err.(*MultiError)
+		if !ok {
+			t.Fatalf("Expected MultiError, got %T: %v", err, err)
+		}
+		if len(multiErr.Errors) != 1 || !errors.Is(multiErr.Errors[0], ErrEmptyOutput) {
+			t.Errorf("Expected a single ErrEmptyOutput, got %v", multiErr)
+		}
+	})
+
+	t.Run("WriteByDefault", func(t *testing.T) {
+		tempDir := t.TempDir()
+		eng := New()
+
+		if err := eng.RenderDir(NewContext(memFS, tempDir, "example"), "templates", data); err != nil {
+			t.Fatalf("RenderDir failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, "templates", "optional.go")); err != nil {
+			t.Errorf("Expected empty output to be written by default: %v", err)
+		}
+	})
+}
+
+func TestEngineDeterministic(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/a.txt.tmpl", []byte(`{{uuid}} {{randInt 0 1000000}} {{randString 12}} {{randHex 8}} {{randBase64 8}} {{now.Unix}}`))
+	memFS.WriteFile("templates/b.txt.tmpl", []byte(`{{uuid}} {{shuffle (list)}}`))
+
+	renderOnce := func(t *testing.T, opts ...Option) map[string]string {
+		t.Helper()
+		tempDir := t.TempDir()
+		funcs := render.ExtendedFuncMap()
+		funcs["list"] = func() []int { return []int{1, 2, 3, 4, 5, 6, 7, 8} }
+		eng := New(append([]Option{WithFuncMap(funcs)}, opts...)...)
+		if err := eng.RenderDir(NewContext(memFS, tempDir, "example"), "templates", nil); err != nil {
+			t.Fatalf("RenderDir failed: %v", err)
+		}
+
+		outputs := make(map[string]string)
+		for _, name := range []string{"a.txt", "b.txt"} {
+			content, err := os.ReadFile(filepath.Join(tempDir, "templates", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputs[name] = string(content)
+		}
+		return outputs
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	first := renderOnce(t, WithDeterministic())
+	second := renderOnce(t, WithDeterministic())
+	for name := range first {
+		if first[name] != second[name] {
+			t.Errorf("%s differs between runs:\n%s\n%s", name, first[name], second[name])
+		}
+	}
+
+	if !strings.HasSuffix(first["a.txt"], " 1700000000") {
+		t.Errorf("Expected now to be pinned to SOURCE_DATE_EPOCH, got %q", first["a.txt"])
+	}
+
+	reseeded := renderOnce(t, WithDeterministicSeed(42))
+	if reseeded["a.txt"] == first["a.txt"] {
+		t.Error("Expected a different seed to produce different output")
+	}
+
+	pinned := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	eng := New(WithPinnedTime(pinned))
+	if got := eng.Clock()(); !got.Equal(pinned) {
+		t.Errorf("Expected clock to return pinned time, got %v", got)
+	}
+
+	pinnedFS := gogentest.NewMemoryFS()
+	pinnedFS.WriteFile("templates/migration.txt.tmpl", []byte(`{{ fixedNow | dateFormat "migration" }} {{ fixedNow | dateAdd "30d" | dateFormat "date" }}`))
+	tempDir := t.TempDir()
+	if err := eng.RenderDir(NewContext(pinnedFS, tempDir, "example"), "templates", nil); err != nil {
+		t.Fatalf("RenderDir failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "templates", "migration.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if got := string(content); got != "20240102030405 2024-02-01" {
+		t.Errorf("Expected fixedNow to return the pinned time, got %q", got)
+	}
+
+	tree := &Tree{Nodes: []TreeNode{{Path: `{{ fixedNow | dateFormat "migration" }}_init.txt`, Template: "templates/migration.txt.tmpl"}}}
+	if err := eng.RenderTree(NewContext(pinnedFS, tempDir, "example"), tree, nil); err != nil {
+		t.Fatalf("RenderTree failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "20240102030405_init.txt")); err != nil {
+		t.Errorf("Expected tree paths to use the pinned time: %v", err)
+	}
+}
+
+func TestEngineSandboxProfile(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/ok.txt.tmpl", []byte(`{{ snake "UserID" }} {{ sha256 "" | len }}`))
+	memFS.WriteFile("env/leak.txt.tmpl", []byte(`{{ env "HOME" }}`))
+
+	tempDir := t.TempDir()
+	ctx := NewContext(memFS, tempDir, "example")
+	eng := New(WithFuncMap(render.ExtendedFuncMap()), WithSandboxProfile(render.Strict))
+
+	if err := eng.RenderDir(ctx, "templates", nil); err != nil {
+		t.Fatalf("RenderDir failed: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(tempDir, "templates", "ok.txt")); string(content) != "user_id 64" {
+		t.Errorf("Expected allowed functions to work, got %q", content)
+	}
+	if err := eng.RenderDir(ctx, "env", nil); err == nil || !strings.Contains(err.Error(), `function "env" not defined`) {
+		t.Errorf("Expected env to be unavailable, got %v", err)
+	}
+
+	registry := render.NewFunctionRegistry()
+	registry.RegisterExtended()
+	registry.Register("sys.hasEnv", func(string) bool { return true }, render.WithUnqualifiedAlias())
+	funcs := registry.SandboxedFuncMap(render.Strict)
+	for _, name := range []string{"env", "hasEnv", "sys"} {
+		if _, ok := funcs[name]; ok {
+			t.Errorf("Expected Strict to remove %s", name)
+		}
+	}
+	if _, ok := funcs["md5"]; !ok {
+		t.Error("Expected Strict to keep md5")
+	}
+}
+
+func TestEngineDeprecationWarnings(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/a.txt.tmpl", []byte(`{{ toSnake "UserID" }}`))
+	memFS.WriteFile("templates/b.txt.tmpl", []byte(`{{ toSnake "OrderID" }} {{ join .Names "," }}`))
+
+	registry := render.NewFunctionRegistry()
+	registry.Register("toSnake", render.DefaultFuncMap()["snake"], render.WithReplacement("snake"))
+	registry.Register("join", strings.Join, render.WithDeprecated())
+
+	var logs strings.Builder
+	tempDir := t.TempDir()
+	eng := New(WithLogger(slog.New(slog.NewTextHandler(&logs, nil))), WithFunctionRegistry(registry))
+	if err := eng.RenderDir(NewContext(memFS, tempDir, "example"), "templates", map[string]any{"Names": []string{"x", "y"}}); err != nil {
+		t.Fatalf("RenderDir failed: %v", err)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(tempDir, "templates", "b.txt")); string(content) != "order_id x,y" {
+		t.Errorf("Expected deprecated functions to keep working, got %q", content)
+	}
+	if n := strings.Count(logs.String(), "function=toSnake"); n != 1 {
+		t.Errorf("Expected one warning for toSnake, got %d:\n%s", n, logs.String())
+	}
+	if !strings.Contains(logs.String(), "template=templates/a.txt.tmpl replacement=snake") {
+		t.Errorf("Expected the warning to name the template and replacement, got:\n%s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "function=join template=templates/b.txt.tmpl\n") {
+		t.Errorf("Expected a warning for join, got:\n%s", logs.String())
+	}
+}
+
+func TestEngineFunctionUsage(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/a.txt.tmpl", []byte(`{{ range .Names }}{{ snake . }}{{ end }}`))
+	memFS.WriteFile("templates/b.txt.tmpl", []byte(`{{ snake "X" }}{{ camel "y_z" }}`))
+
+	tempDir := t.TempDir()
+	ctx := NewContext(memFS, tempDir, "example")
+	eng := New(WithFunctionUsage(), WithIdempotencyCheck())
+	data := map[string]any{"Names": []string{"A", "B", "C"}}
+	for range 2 {
+		if err := eng.RenderDir(ctx, "templates", data); err != nil {
+			t.Fatalf("RenderDir failed: %v", err)
+		}
+	}
+
+	usage := eng.FunctionUsage()
+	if len(usage) != len(render.DefaultFuncMap()) {
+		t.Errorf("Expected every function to be reported, got %d", len(usage))
+	}
+	want := []FunctionUsage{
+		{Function: "snake", Calls: 4, Templates: map[string]int{"templates/a.txt.tmpl": 3, "templates/b.txt.tmpl": 1}},
+		{Function: "camel", Calls: 1, Templates: map[string]int{"templates/b.txt.tmpl": 1}},
+		{Function: "abs"},
+	}
+	for i, w := range want {
+		if got := usage[i]; got.Function != w.Function || got.Calls != w.Calls || !maps.Equal(got.Templates, w.Templates) {
+			t.Errorf("usage[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+
+	if New().FunctionUsage() != nil {
+		t.Error("Expected no usage without WithFunctionUsage")
+	}
+}
+
+func TestEngineCoverage(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/a.txt.tmpl", []byte(`{{ if .Admin }}admin{{ else if .Guest }}guest{{ else }}user{{ end }}
+{{ range .Items }}{{ template "item" . }}{{ else }}none{{ end }}
+{{ with .Error }}{{ . }}{{ end }}
+{{ define "item" }}- {{ . }}{{ end }}
+{{ define "unused" }}{{ end }}
+`))
+	memFS.WriteFile("templates/b.txt.tmpl", []byte(`{{ template "footer" }}`))
+	memFS.WriteFile("templates/_footer.tmpl", []byte(`{{ if true }}footer{{ end }}`))
+
+	tempDir := t.TempDir()
+	ctx := NewContext(memFS, tempDir, "example")
+	eng := New(WithCoverage(), WithIdempotencyCheck())
+	for _, data := range []map[string]any{
+		{"Admin": true, "Items": []string{"x"}},
+		{"Guest": true},
+	} {
+		if err := eng.RenderDir(ctx, "templates", data); err != nil {
+			t.Fatalf("RenderDir failed: %v", err)
+		}
+	}
+	if content, _ := os.ReadFile(filepath.Join(tempDir, "templates", "a.txt")); string(content) != "guest\nnone\n\n\n\n" {
+		t.Errorf("Unexpected output %q", content)
+	}
+
+	report := eng.Coverage()
+	got := []string{}
+	for _, p := range report.Points {
+		got = append(got, fmt.Sprintf("%s:%d:%d %s %d", p.Template, p.Line, p.Column, p.Description(), p.Hits))
+	}
+	want := []string{
+		"templates/_footer.tmpl:1:1 define \"footer\" 2",
+		"templates/_footer.tmpl:1:7 if condition false 0",
+		"templates/_footer.tmpl:1:7 then of if 2",
+		"templates/a.txt.tmpl:1:7 then of if 1",
+		"templates/a.txt.tmpl:1:32 then of if 1",
+		"templates/a.txt.tmpl:1:56 else of if 0",
+		"templates/a.txt.tmpl:2:10 range body 1",
+		"templates/a.txt.tmpl:2:52 else of range 1",
+		"templates/a.txt.tmpl:3:9 with value empty 2",
+		"templates/a.txt.tmpl:3:9 then of with 0",
+		"templates/a.txt.tmpl:4:20 define \"item\" 1",
+		"templates/a.txt.tmpl:5:22 define \"unused\" 0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("points:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var text strings.Builder
+	if err := report.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "templates/a.txt.tmpl: 6/9 (66.7%)\n") || !strings.Contains(text.String(), "templates/a.txt.tmpl:3:9: then of with never entered\n") {
+		t.Errorf("Unexpected text report:\n%s", text.String())
+	}
+	var html strings.Builder
+	if err := report.WriteHTML(&html); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.String(), `<tr class="partial"><td class="number">3</td>`) {
+		t.Errorf("Expected line 3 to be partly covered:\n%s", html.String())
+	}
+
+	eng.ResetCoverage()
+	if len(eng.Coverage().Points) != 0 {
+		t.Error("Expected no points after ResetCoverage")
+	}
+	if New().Coverage() != nil {
+		t.Error("Expected no coverage without WithCoverage")
+	}
+}
+
+type telemetrySpanKey struct{}
+
+type telemetrySpan struct {
+	name, parent, template string
+	ended                  bool
+	err                    error
+}
+
+// recordingTelemetry records spans with the name of their parent span.
+type recordingTelemetry struct {
+	spans  []*telemetrySpan
+	counts map[string]int64
+}
+
+func (rt *recordingTelemetry) StartSpan(parent context.Context, name string, attrs ...slog.Attr) (context.Context, func(error)) {
+	span := &telemetrySpan{name: name}
+	if p, ok := parent.Value(telemetrySpanKey{}).(*telemetrySpan); ok {
+		span.parent = p.name
+	}
+	for _, attr := range attrs {
+		if attr.Key == "weft.template" {
+			span.template = attr.Value.String()
+		}
+	}
+	rt.spans = append(rt.spans, span)
+	return context.WithValue(parent, telemetrySpanKey{}, span), func(err error) {
+		span.ended, span.err = true, err
+	}
+}
+
+func (rt *recordingTelemetry) Count(ctx context.Context, name string, n int64, attrs ...slog.Attr) {
+	rt.counts[name] += n
+}
+
+func TestEngineTelemetry(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/a.txt.tmpl", []byte("{{ .Name }}"))
+	memFS.WriteFile("templates/b.txt.tmpl", []byte(`{{ template "missing" }}`))
+
+	telemetry := &recordingTelemetry{counts: make(map[string]int64)}
+	eng := New(WithTelemetry(telemetry), WithFailureMode(FailAtEnd))
+	if err := eng.AddPostProcessorStage(postprocess.Stage{Name: "trim", Processor: postprocess.ProcessorFunc(func(_ string, content []byte) ([]byte, error) {
+		return []byte(strings.TrimSpace(string(content))), nil
+	})}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := NewContext(memFS, t.TempDir(), "example")
+	ctx.TraceContext = context.WithValue(context.Background(), telemetrySpanKey{}, &telemetrySpan{name: "request"})
+	err := eng.RenderDir(ctx, "templates", map[string]any{"Name": "shop"})
+	if err == nil {
+		t.Fatal("Expected b.txt.tmpl to fail")
+	}
+
+	got := []string{}
+	for _, span := range telemetry.spans {
+		if !span.ended {
+			t.Errorf("Span %s was not ended", span.name)
+		}
+		got = append(got, fmt.Sprintf("%s<%s %s err=%t", span.name, span.parent, span.template, span.err != nil))
+	}
+	want := []string{
+		"weft.render_dir<request  err=true",
+		"weft.execute_template<weft.render_dir templates/a.txt.tmpl err=false",
+		"weft.postprocess<weft.execute_template templates/a.txt.tmpl err=false",
+		"weft.execute_template<weft.render_dir templates/b.txt.tmpl err=true",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("spans:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	wantCounts := map[string]int64{MetricFilesGenerated: 1, MetricErrors: 1}
+	if !reflect.DeepEqual(telemetry.counts, wantCounts) {
+		t.Errorf("counts = %v, want %v", telemetry.counts, wantCounts)
+	}
+}
+
+func TestEngineLayouts(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/_base.go.tmpl", []byte(`// Code generated by weft. DO NOT EDIT.
+
+package {{ .Package }}
+{{ block "imports" . }}
+import "fmt"
+{{ end }}
+{{ block "body" . }}{{ end }}
+// {{ block "footer" . }}end of {{ .Name }}{{ end }}
+`))
+	memFS.WriteFile("templates/_service.go.tmpl", []byte(`{{ extends "base.go.tmpl" }}
+{{ define "body" }}
+type {{ .Name }}Service struct{}
+{{ template "methods" . }}
+{{ end }}
+{{ define "methods" }}{{ end }}
+`))
+	memFS.WriteFile("templates/api/user.go.tmpl", []byte(`{{ extends "../service.go.tmpl" }}
+{{/* Only the blocks this file changes. */}}
+{{ define "imports" }}{{ end }}
+{{ define "methods" }}
+func (s *{{ .Name }}Service) Get() {}
+{{ end }}
+`))
+
+	tempDir := t.TempDir()
+	eng := New()
+	data := map[string]any{"Package": "api", "Name": "User"}
+	if err := eng.RenderDir(NewContext(memFS, tempDir, "example"), "templates", data); err != nil {
+		t.Fatalf("RenderDir failed: %v", err)
+	}
+
+	files := eng.GeneratedFiles()
+	if len(files) != 1 {
+		t.Fatalf("Expected layouts to be skipped, generated %+v", files)
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "templates", "api", "user.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"DO NOT EDIT", "package api", "type UserService struct{}", "func (s *UserService) Get() {}", "// end of User"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Output missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(string(content), `import "fmt"`) {
+		t.Errorf("Expected the imports block to be overridden:\n%s", content)
+	}
+
+	errorCases := map[string]string{
+		"templates/missing.tmpl": `{{ extends "nope.tmpl" }}`,
+		"templates/late.tmpl":    "text\n{{ extends \"_base.go.tmpl\" }}",
+		"templates/stray.tmpl":   "{{ extends \"_base.go.tmpl\" }}\nstray text\n",
+		"templates/loop.tmpl":    `{{ extends "loop.tmpl" }}`,
+	}
+	wantErrors := map[string]string{
+		"templates/missing.tmpl": `layout "nope.tmpl" extended by templates/missing.tmpl not found`,
+		"templates/late.tmpl":    "extends must be the first action",
+		"templates/stray.tmpl":   "may only contain define blocks",
+		"templates/loop.tmpl":    "extends itself",
+	}
+	for path, content := range errorCases {
+		errFS := gogentest.NewMemoryFS()
+		errFS.WriteFile("templates/_base.go.tmpl", []byte("base\n"))
+		errFS.WriteFile(path, []byte(content))
+		err := New().RenderDir(NewContext(errFS, t.TempDir(), "example"), "templates", nil)
+		if err == nil || !strings.Contains(err.Error(), wantErrors[path]) {
+			t.Errorf("%s: error = %v, want %q", path, err, wantErrors[path])
+		}
+	}
+}
+
+func TestEnginePartialsAndIncludes(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/_header.tmpl", []byte("// {{ .Name }} {{ template \"tag\" . }}\n"))
+	memFS.WriteFile("templates/models/_field.tmpl", []byte("\t{{ pascal .name }} {{ .type }}\n"))
+	memFS.WriteFile("_tag.tmpl", []byte("[{{ .Package }}]"))
+	memFS.WriteFile("includes/license.tmpl", []byte("// Copyright {{ . }} {{ now.UnixNano }}\n"))
+	memFS.WriteFile("templates/models/model.go.tmpl", []byte(`{{ include "license" .Year -}}
+{{ template "header" . -}}
+type {{ .Name }} struct {
+{{ range .Fields }}{{ template "field" . }}{{ end -}}
+}
+`))
+
+	data := map[string]any{
+		"Package": "models",
+		"Name":    "User",
+		"Year":    2024,
+		"Fields": []map[string]any{
+			{"name": "id", "type": "int"},
+			{"name": "email", "type": "string"},
+		},
+	}
+	render := func(eng *Engine) string {
+		t.Helper()
+		tempDir := t.TempDir()
+		if err := eng.RenderDir(NewContext(memFS, tempDir, "example"), "templates", data); err != nil {
+			t.Fatalf("RenderDir failed: %v", err)
+		}
+		if files := eng.GeneratedFiles(); len(files) != 1 {
+			t.Fatalf("Expected partials to be skipped, generated %+v", files)
+		}
+		content, err := os.ReadFile(filepath.Join(tempDir, "templates", "models", "model.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	content := render(New())
+	for _, want := range []string{"// Copyright 2024 ", "// User [models]\n", "\tId int\n\tEmail string\n}"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Output missing %q:\n%s", want, content)
+		}
+	}
+
+	if first, second := render(New(WithDeterministic())), render(New(WithDeterministic())); first != second {
+		t.Errorf("Expected included files to use deterministic functions:\n%s\n%s", first, second)
+	}
+
+	errorCases := map[string]string{
+		"missing": `{{ include "nope" . }}`,
+		"loop":    `{{ include "templates/loop.tmpl" . }}`,
+	}
+	wantErrors := map[string]string{
+		"missing": `include "nope" from templates/missing.tmpl not found`,
+		"loop":    "circular include: templates/loop.tmpl -> templates/loop.tmpl",
+	}
+	for name, content := range errorCases {
+		errFS := gogentest.NewMemoryFS()
+		errFS.WriteFile("templates/"+name+".tmpl", []byte(content))
+		err := New().RenderDir(NewContext(errFS, t.TempDir(), "example"), "templates", nil)
+		if err == nil || !strings.Contains(err.Error(), wantErrors[name]) {
+			t.Errorf("%s: error = %v, want %q", name, err, wantErrors[name])
+		}
+	}
+}
+
+func TestEngineMacros(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("templates/_macros/go.tmpl", []byte(`{{/* Go snippets shared by every template. */}}
+{{ define "goStruct" }}type {{ .Name }} struct {
+{{ range .Fields }}{{ template "goField" . }}{{ end }}}
+{{ end }}
+{{ define "goField" }}	{{ pascal .name }} {{ .type }}
+{{ end }}
+`))
+	memFS.WriteFile("templates/_macros/query.tmpl", []byte(`{{ define "queryParams" }}{{ range $i, $f := .Fields }}{{ if $i }}&{{ end }}{{ $f.name }}={{ "{" }}{{ $f.name }}{{ "}" }}{{ end }}{{ end }}`))
+	memFS.WriteFile("templates/api/_macros/go.tmpl", []byte(`{{ define "goField" }}	{{ pascal .name }} {{ .type }} `+"`json:\"{{ .name }}\"`"+`
+{{ end }}`))
+	memFS.WriteFile("templates/model.go.tmpl", []byte(`{{ template "goStruct" . }}`))
+	memFS.WriteFile("templates/api/dto.go.tmpl", []byte(`{{ template "goStruct" . }}// {{ template "queryParams" . }}
+`))
+	memFS.WriteFile("templates/api/local.go.tmpl", []byte(`{{ define "goField" }}	// {{ .name }}
+{{ end }}{{ template "goStruct" . }}`))
+
+	tempDir := t.TempDir()
+	eng := New()
+	data := map[string]any{
+		"Name": "User",
+		"Fields": []map[string]any{
+			{"name": "id", "type": "int"},
+			{"name": "email", "type": "string"},
+		},
+	}
+	if err := eng.RenderDir(NewContext(memFS, tempDir, "example"), "templates", data); err != nil {
+		t.Fatalf("RenderDir failed: %v", err)
+	}
+	if files := eng.GeneratedFiles(); len(files) != 3 {
+		t.Fatalf("Expected macro files to be skipped, generated %+v", files)
+	}
+
+	want := map[string]string{
+		"templates/model.go":     "type User struct {\n\tId int\n\tEmail string\n}\n",
+		"templates/api/dto.go":   "type User struct {\n\tId int `json:\"id\"`\n\tEmail string `json:\"email\"`\n}\n// id={id}&email={email}\n",
+		"templates/api/local.go": "type User struct {\n\t// id\n\t// email\n}\n",
+	}
+	for path, expected := range want {
+		content, err := os.ReadFile(filepath.Join(tempDir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != expected {
+			t.Errorf("%s = %q, want %q", path, content, expected)
+		}
+	}
+
+	errorCases := map[string]string{
+		"templates/_macros/bad.tmpl": "{{ define \"a\" }}a{{ end }}\nstray",
+		"templates/_macros/dup.tmpl": `{{ define "goField" }}{{ end }}`,
+	}
+	wantErrors := map[string]string{
+		"templates/_macros/bad.tmpl": "a macro file may only contain define blocks",
+		"templates/_macros/dup.tmpl": `macro "goField" is defined in both templates/_macros/dup.tmpl and templates/_macros/go.tmpl`,
+	}
+	for path, content := range errorCases {
+		errFS := gogentest.NewMemoryFS()
+		errFS.WriteFile("templates/_macros/go.tmpl", []byte(`{{ define "goField" }}{{ end }}`))
+		errFS.WriteFile("templates/model.go.tmpl", []byte("model"))
+		errFS.WriteFile(path, []byte(content))
+		err := New().RenderDir(NewContext(errFS, t.TempDir(), "example"), "templates", nil)
+		if err == nil || !strings.Contains(err.Error(), wantErrors[path]) {
+			t.Errorf("%s: error = %v, want %q", path, err, wantErrors[path])
+		}
+	}
+}
+
+func TestEngineRenderPipeline(t *testing.T) {
+	memFS := gogentest.NewMemoryFS()
+	memFS.WriteFile("pipeline.yaml", []byte(`passes:
+  - name: code
+    dir: templates
+    inputs: [model, config]
+  - name: model
+    template: model.yaml.tmpl
+    output: model.yaml
+  - name: config
+    template: config.json.tmpl
+`))
+	memFS.WriteFile("model.yaml.tmpl", []byte(`types:
+{{- range .Tables }}
+  - name: {{ pascal .name }}
+    table: {{ .name }}
+{{- end }}
+`))
+	memFS.WriteFile("config.json.tmpl", []byte(`{"package": "{{ .Package }}"}`))
+	memFS.WriteFile("templates/types.go.tmpl", []byte(`package {{ .config.package }}
+{{ range .model.types }}
+type {{ .name }} struct{} // {{ .table }}
+{{- end }}
+`))
+
+	pipeline, err := LoadPipeline(memFS, "pipeline.yaml")
+	if err != nil {
+		t.Fatalf("LoadPipeline failed: %v", err)
+	}
+	order, err := pipeline.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, pass := range order {
+		names = append(names, pass.Name)
+	}
+	if got := strings.Join(names, ","); got != "model,config,code" {
+		t.Errorf("Order = %s, want model,config,code", got)
+	}
+
+	tempDir := t.TempDir()
+	eng := New()
+	data := map[string]any{
+		"Package": "store",
+		"Tables":  []map[string]any{{"name": "users"}, {"name": "orders"}},
+	}
+	if err := eng.RenderPipeline(NewContext(memFS, tempDir, "example"), pipeline, data); err != nil {
+		t.Fatalf("RenderPipeline failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "templates", "types.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package store\n\ntype Users struct{} // users\ntype Orders struct{} // orders\n"
+	if string(content) != want {
+		t.Errorf("types.go = %q, want %q", content, want)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "model.yaml")); err != nil {
+		t.Errorf("Expected intermediate output to be written: %v", err)
+	}
+	if files := eng.GeneratedFiles(); len(files) != 2 {
+		t.Errorf("Expected 2 generated files, got %+v", files)
+	}
+
+	invalid := map[string]string{
+		"cycle":     "passes:\n  - {name: a, template: a.tmpl, inputs: [b]}\n  - {name: b, template: b.tmpl, inputs: [a]}\n",
+		"unknown":   "passes:\n  - {name: a, dir: templates, inputs: [nope]}\n",
+		"dir input": "passes:\n  - {name: a, dir: templates}\n  - {name: b, dir: templates, inputs: [a]}\n",
+		"both":      "passes:\n  - {name: a, dir: templates, template: a.tmpl}\n",
+		"duplicate": "passes:\n  - {name: a, dir: templates}\n  - {name: a, dir: other}\n",
+	}
+	wantErrors := map[string]string{
+		"cycle":     "passes form a cycle: a, b",
+		"unknown":   `pass a: unknown input "nope"`,
+		"dir input": "input a renders files and has no result",
+		"both":      "exactly one of template and dir is required",
+		"duplicate": `duplicate pass name "a"`,
+	}
+	for name, content := range invalid {
+		errFS := gogentest.NewMemoryFS()
+		errFS.WriteFile("pipeline.yaml", []byte(content))
+		_, err := LoadPipeline(errFS, "pipeline.yaml")
+		if err == nil || !strings.Contains(err.Error(), wantErrors[name]) {
+			t.Errorf("%s: error = %v, want %q", name, err, wantErrors[name])
+		}
+	}
+
+	badFS := gogentest.NewMemoryFS()
+	badFS.WriteFile("model.yaml.tmpl", []byte("not: [valid"))
+	bad := &Pipeline{Passes: []Pass{{Name: "model", Template: "model.yaml.tmpl"}}}
+	err = New().RenderPipeline(NewContext(badFS, t.TempDir(), "example"), bad, nil)
+	if err == nil || !strings.Contains(err.Error(), "pass model: failed to decode output of model.yaml.tmpl") {
+		t.Errorf("Expected decode error, got %v", err)
+	}
+}
@@ -2,13 +2,18 @@ package engine
 
 import (
 	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type GenerationError struct {
 	Path    string
 	Message string
 	Err     error
+	RunID   string
 }
 
 func (e *GenerationError) Error() string {
@@ -24,6 +29,7 @@ func (e *GenerationError) Unwrap() error {
 
 type MultiError struct {
 	Errors []*GenerationError
+	RunID  string
 }
 
 func (m *MultiError) Error() string {
@@ -46,9 +52,116 @@ func (m *MultiError) Add(path, message string, err error) {
 		Path:    path,
 		Message: message,
 		Err:     err,
+		RunID:   m.RunID,
 	})
 }
 
+// Unwrap returns the individual errors so errors.Is and errors.As can match
+// any of them.
+func (m *MultiError) Unwrap() []error {
+	errs := make([]error, len(m.Errors))
+	for i, err := range m.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
 func (m *MultiError) HasErrors() bool {
 	return len(m.Errors) > 0
 }
+
+// TemplateError is a template parse or execution error located in the
+// template source, which may be a partial, layout, macro file or included
+// file rather than the template being rendered.
+type TemplateError struct {
+	// Template is the path of the file holding the failing action.
+	Template string
+	// Line and Column locate the action, counting from 1. Columns count
+	// runes.
+	Line   int
+	Column int
+	// Excerpt shows up to three lines either side of Line, numbered, with
+	// the failing line marked.
+	Excerpt string
+	// Suggestion names the function or field the template probably meant
+	// when the error is about one that does not exist, or is "".
+	Suggestion string
+	Err        error
+}
+
+func (e *TemplateError) Error() string {
+	msg := e.message()
+	if e.Suggestion != "" {
+		msg += "; " + e.Suggestion
+	}
+	return fmt.Sprintf("%s:%d:%d: %s\n%s", e.Template, e.Line, e.Column, msg, e.Excerpt)
+}
+
+// message returns the underlying error without the location text/template
+// puts in front of it.
+func (e *TemplateError) message() string {
+	msg := e.Err.Error()
+	if loc := executionLocation.FindStringSubmatchIndex(msg); loc != nil && loc[0] == 0 && msg[loc[2]:loc[3]] == e.Template {
+		return strings.TrimPrefix(msg[loc[1]:], " ")
+	}
+	return msg
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// executionLocation matches the locations text/template puts in execution
+// errors, "template: name:line:byte:" or "template: name:line:".
+var executionLocation = regexp.MustCompile(`template: ([^:\s]+):(\d+):(?:(\d+):)?`)
+
+// locateError returns err as a TemplateError when it names a location in a
+// file of fsys, and err unchanged otherwise. Errors from included files
+// contain the locations of each include; the innermost is used.
+func locateError(fsys fs.FS, err error) error {
+	matches := executionLocation.FindAllStringSubmatch(err.Error(), -1)
+	if len(matches) == 0 {
+		return err
+	}
+	match := matches[len(matches)-1]
+	content, readErr := fs.ReadFile(fsys, match[1])
+	if readErr != nil {
+		return err
+	}
+	lines := strings.Split(string(content), "\n")
+	line, _ := strconv.Atoi(match[2])
+	if line < 1 || line > len(lines) {
+		return err
+	}
+	offset, _ := strconv.Atoi(match[3])
+	source := lines[line-1]
+	offset = min(offset, len(source))
+
+	var excerpt strings.Builder
+	width := len(strconv.Itoa(min(line+3, len(lines))))
+	for n := max(1, line-3); n <= min(line+3, len(lines)); n++ {
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		fmt.Fprintf(&excerpt, "%s %*d | %s\n", marker, width, n, strings.TrimRight(lines[n-1], "\r"))
+		if n == line && match[3] != "" {
+			// Keep tabs so the caret lines up with the source.
+			caret := strings.Map(func(r rune) rune {
+				if r == '\t' {
+					return r
+				}
+				return ' '
+			}, source[:offset])
+			fmt.Fprintf(&excerpt, "  %*s | %s^\n", width, "", caret)
+		}
+	}
+
+	return &TemplateError{
+		Template: match[1],
+		Line:     line,
+		Column:   utf8.RuneCountInString(source[:offset]) + 1,
+		Excerpt:  strings.TrimRight(excerpt.String(), "\n"),
+		Err:      err,
+	}
+}
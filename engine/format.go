@@ -0,0 +1,266 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormatError is returned when the output of a template does not
+// parse in the format the template declares.
+type OutputFormatError struct {
+	TemplatePath string `json:"template_path"`
+	OutputPath   string `json:"output_path"`
+	Format       string `json:"format"`
+	// Line and Column locate the error in the output, or are 0 if unknown.
+	Line   int   `json:"line"`
+	Column int   `json:"column"`
+	Err    error `json:"-"`
+}
+
+func (e *OutputFormatError) Error() string {
+	location := e.OutputPath
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, e.Line)
+		if e.Column > 0 {
+			location = fmt.Sprintf("%s:%d", location, e.Column)
+		}
+	}
+	return fmt.Sprintf("%s: output of template %s is not valid %s: %v", location, e.TemplatePath, e.Format, e.Err)
+}
+
+func (e *OutputFormatError) Unwrap() error {
+	return e.Err
+}
+
+// outputFormats maps the extensions of output files to the format their
+// content is checked in.
+var outputFormats = map[string]string{
+	".go":   "go",
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".sql":  "sql",
+}
+
+// formatDirective matches a {{/* weft:format json */}} comment.
+var formatDirective = regexp.MustCompile(`{{-?\s*/\*\s*weft:format\s+(\S+?)\s*\*/\s*-?}}`)
+
+// declaredFormat returns the format the template source declares with a
+// weft:format comment, or else the one its output path implies. It returns
+// "" for output that is not checked, including templates that declare the
+// format "text".
+func declaredFormat(source []byte, outputPath string) string {
+	if match := formatDirective.FindSubmatch(source); match != nil {
+		if format := strings.ToLower(string(match[1])); format != "text" {
+			return format
+		}
+		return ""
+	}
+	return outputFormats[strings.ToLower(filepath.Ext(outputPath))]
+}
+
+// checkFormat checks that content rendered from templatePath parses in the
+// template's format, when WithOutputFormatCheck is set.
+func (r *Renderer) checkFormat(ctx Context, templatePath, outputPath string, content []byte) error {
+	if !r.checkFormats {
+		return nil
+	}
+	source, err := fs.ReadFile(ctx.TmplFS, templatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template %s: %w", templatePath, err)
+	}
+	format := declaredFormat(source, outputPath)
+	if format == "" {
+		return nil
+	}
+
+	offset, err := parseOutput(format, outputPath, content)
+	if err == nil {
+		return nil
+	}
+	formatErr := &OutputFormatError{TemplatePath: templatePath, OutputPath: outputPath, Format: format, Err: err}
+	if offset >= 0 {
+		formatErr.Line, formatErr.Column = outputPosition(content, offset)
+	}
+	return formatErr
+}
+
+// parseOutput parses content in format, returning the offset of the first
+// error, or -1 if it has none.
+func parseOutput(format, outputPath string, content []byte) (int, error) {
+	switch format {
+	case "go":
+		fset := token.NewFileSet()
+		_, err := parser.ParseFile(fset, outputPath, content, parser.SkipObjectResolution)
+		var list scanner.ErrorList
+		if errors.As(err, &list) && len(list) > 0 {
+			return list[0].Pos.Offset, errors.New(list[0].Msg)
+		}
+		return -1, err
+	case "json":
+		return parseJSON(content)
+	case "yaml":
+		return parseYAML(content)
+	case "sql":
+		return parseSQL(content)
+	}
+	return -1, fmt.Errorf("unknown output format %q, expected go, json, yaml, sql or text", format)
+}
+
+func parseJSON(content []byte) (int, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return int(syntaxErr.Offset) - 1, err
+		}
+		return len(content), err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return int(dec.InputOffset()), errors.New("unexpected data after top-level value")
+	}
+	return -1, nil
+}
+
+// yamlLinePattern matches the line yaml.v3 reports errors on.
+var yamlLinePattern = regexp.MustCompile(`^yaml: line (\d+): `)
+
+func parseYAML(content []byte) (int, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if err == io.EOF {
+			return -1, nil
+		}
+		if err != nil {
+			match := yamlLinePattern.FindStringSubmatch(err.Error())
+			if match == nil {
+				return -1, err
+			}
+			var line int
+			fmt.Sscanf(match[1], "%d", &line)
+			return lineOffset(content, line), errors.New(strings.TrimPrefix(err.Error(), match[0]))
+		}
+	}
+}
+
+// parseSQL checks the structure a SQL statement needs whatever its dialect:
+// terminated strings, quoted identifiers and comments, balanced
+// parentheses, and no comma before a closing parenthesis or the end of a
+// statement.
+func parseSQL(content []byte) (int, error) {
+	src := string(content)
+	var open []int
+	comma := -1
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := quotedEnd(src, i)
+			if end < 0 {
+				return i, errors.New("unterminated quoted string or identifier")
+			}
+			i = end
+			comma = -1
+			continue
+		case strings.HasPrefix(src[i:], "--"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			i += end
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return i, errors.New("unterminated comment")
+			}
+			i += end + 3
+			continue
+		case c == '(':
+			open = append(open, i)
+		case c == ')':
+			if comma >= 0 {
+				return comma, errors.New("trailing comma before )")
+			}
+			if len(open) == 0 {
+				return i, errors.New("unmatched )")
+			}
+			open = open[:len(open)-1]
+		case c == ';':
+			if comma >= 0 {
+				return comma, errors.New("trailing comma at end of statement")
+			}
+			if len(open) > 0 {
+				return open[len(open)-1], errors.New("unclosed (")
+			}
+		}
+		switch {
+		case c == ',':
+			comma = i
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			comma = -1
+		}
+	}
+	if comma >= 0 {
+		return comma, errors.New("trailing comma at end of statement")
+	}
+	if len(open) > 0 {
+		return open[len(open)-1], errors.New("unclosed (")
+	}
+	return -1, nil
+}
+
+// quotedEnd returns the offset of the quote that ends the string or quoted
+// identifier starting at start, or -1 if it is not terminated. A doubled
+// quote is an escaped one.
+func quotedEnd(src string, start int) int {
+	quote := src[start]
+	for i := start + 1; i < len(src); i++ {
+		if src[i] != quote {
+			continue
+		}
+		if i+1 < len(src) && src[i+1] == quote {
+			i++
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// outputPosition converts an offset in content to a line and column,
+// counted from 1.
+func outputPosition(content []byte, offset int) (int, int) {
+	offset = min(max(offset, 0), len(content))
+	line := bytes.Count(content[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(content[:offset], '\n')
+	return line, column
+}
+
+// lineOffset returns the offset of the start of line in content.
+func lineOffset(content []byte, line int) int {
+	offset := 0
+	for ; line > 1; line-- {
+		next := bytes.IndexByte(content[offset:], '\n')
+		if next < 0 {
+			return len(content)
+		}
+		offset += next + 1
+	}
+	return offset
+}
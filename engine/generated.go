@@ -0,0 +1,55 @@
+package engine
+
+import "sync"
+
+// GeneratedFile records a file written by the engine and the template it was
+// rendered from.
+type GeneratedFile struct {
+	TemplatePath string `json:"template_path"`
+	OutputPath   string `json:"output_path"`
+}
+
+// generatedEntry is a generated file together with the inputs needed to
+// render it again.
+type generatedEntry struct {
+	GeneratedFile
+	ctx      Context
+	data     any
+	streamed bool
+}
+
+// generatedLog collects generated files across a render call.
+type generatedLog struct {
+	mu      sync.Mutex
+	entries []generatedEntry
+}
+
+func (l *generatedLog) add(entry generatedEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+func (l *generatedLog) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = nil
+}
+
+func (l *generatedLog) list() []GeneratedFile {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	files := make([]GeneratedFile, len(l.entries))
+	for i, entry := range l.entries {
+		files[i] = entry.GeneratedFile
+	}
+	return files
+}
+
+func (l *generatedLog) inputs() []generatedEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := make([]generatedEntry, len(l.entries))
+	copy(entries, l.entries)
+	return entries
+}
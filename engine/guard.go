@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DefaultGeneratedMarker is the text that identifies generated files. It
+// matches the headers written by processors.AddGeneratedHeader and the Go
+// "Code generated ... DO NOT EDIT." convention.
+const DefaultGeneratedMarker = "Code generated"
+
+// guardScanLimit is how much of an existing file is searched for the marker.
+const guardScanLimit = 16 * 1024
+
+// ErrUnmanagedFile is returned when the overwrite guard refuses to replace an
+// existing file that does not carry the generated-file marker.
+var ErrUnmanagedFile = errors.New("refusing to overwrite file without generated marker")
+
+// overwriteGuard protects hand-written files that collide with generated
+// paths.
+type overwriteGuard struct {
+	enabled bool
+	marker  string
+}
+
+// check returns ErrUnmanagedFile if outputPath exists and its first
+// guardScanLimit bytes do not contain the marker.
+func (g overwriteGuard) check(outputPath string) error {
+	if !g.enabled {
+		return nil
+	}
+
+	file, err := os.Open(outputPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read existing file %s: %w", outputPath, err)
+	}
+	defer file.Close()
+
+	head, err := io.ReadAll(io.LimitReader(file, guardScanLimit))
+	if err != nil {
+		return fmt.Errorf("failed to read existing file %s: %w", outputPath, err)
+	}
+
+	marker := g.marker
+	if marker == "" {
+		marker = DefaultGeneratedMarker
+	}
+	if !bytes.Contains(head, []byte(marker)) {
+		return fmt.Errorf("%s: %w", outputPath, ErrUnmanagedFile)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// IdempotencyIssue is a generated file whose content on disk differs from a
+// second rendering of its template with the same inputs.
+type IdempotencyIssue struct {
+	TemplatePath string `json:"template_path"`
+	OutputPath   string `json:"output_path"`
+	// Line is the first line that differs, or 0 if the file is missing.
+	Line int `json:"line"`
+	// Suspects lists functions used by the template whose output varies
+	// between runs, such as "now (time)".
+	Suspects []string `json:"suspects,omitempty"`
+}
+
+func (i IdempotencyIssue) String() string {
+	msg := fmt.Sprintf("%s: output differs on re-render", i.OutputPath)
+	if i.Line > 0 {
+		msg = fmt.Sprintf("%s:%d: output differs on re-render", i.OutputPath, i.Line)
+	}
+	msg += fmt.Sprintf(" (from template %s)", i.TemplatePath)
+	if len(i.Suspects) > 0 {
+		return msg + "; uses " + strings.Join(i.Suspects, ", ")
+	}
+	return msg + "; check custom functions and data built from map iteration"
+}
+
+// IdempotencyError is returned when generated files are not reproduced
+// byte-for-byte by a second rendering.
+type IdempotencyError struct {
+	Issues []IdempotencyIssue
+}
+
+func (e *IdempotencyError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		msgs[i] = issue.String()
+	}
+	return fmt.Sprintf("generated output is not reproducible:\n%s", strings.Join(msgs, "\n"))
+}
+
+// nondeterministicFuncs maps template functions whose output varies between
+// runs to the kind of input they depend on.
+var nondeterministicFuncs = map[string]string{
+	"now":         "time",
+	"fixedNow":    "time",
+	"uuid":        "random",
+	"genPassword": "random",
+	"randInt":     "random",
+	"randString":  "random",
+	"randHex":     "random",
+	"randBase64":  "random",
+	"fakeName":    "random",
+	"fakeEmail":   "random",
+	"fakeAddress": "random",
+	"shuffle":     "random",
+	"env":         "environment",
+	"expandEnv":   "environment",
+	"hasEnv":      "environment",
+}
+
+// CheckIdempotency renders every file from the most recent RenderDir or
+// RenderTree call again in memory and reports those whose content differs
+// from what is on disk. Post-processors run again for the second pass.
+func (r *Renderer) CheckIdempotency() ([]IdempotencyIssue, error) {
+	var issues []IdempotencyIssue
+	for _, entry := range r.generated.inputs() {
+		same, line, err := r.rerender(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to re-render %s: %w", entry.TemplatePath, err)
+		}
+		if same {
+			continue
+		}
+
+		tmpl, err := r.cache.Get(entry.ctx.TmplFS, entry.TemplatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get template %s: %w", entry.TemplatePath, err)
+		}
+		issues = append(issues, IdempotencyIssue{
+			TemplatePath: entry.TemplatePath,
+			OutputPath:   entry.OutputPath,
+			Line:         line,
+			Suspects:     r.nondeterministicCalls(tmpl),
+		})
+	}
+	return issues, nil
+}
+
+// rerender renders entry again and compares it with the file on disk. It
+// returns whether they match and, if not, the first line that differs.
+func (r *Renderer) rerender(entry generatedEntry) (bool, int, error) {
+	tmpl, err := r.prepareTemplate(entry.ctx, entry.TemplatePath, false)
+	if err != nil {
+		return false, 0, err
+	}
+
+	f, err := os.Open(entry.OutputPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	defer f.Close()
+
+	cw := &compareWriter{r: bufio.NewReader(f)}
+	if entry.streamed {
+		// Streamed outputs are compared as they render and are not
+		// post-processed.
+		if err := tmpl.Execute(cw, entry.data); err != nil {
+			return false, 0, err
+		}
+	} else {
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, entry.data); err != nil {
+			return false, 0, err
+		}
+		content := r.postprocess(entry.ctx, entry.TemplatePath, entry.OutputPath, entry.data, []byte(buf.String()), r.loggerFor(entry.ctx))
+		cw.Write(content)
+	}
+
+	if cw.equal() {
+		return true, 0, nil
+	}
+	return false, cw.line + 1, nil
+}
+
+// nondeterministicCalls returns the functions used by tmpl and its associated
+// templates whose output varies between runs. Time and random functions are
+// omitted in deterministic mode, where they are pinned.
+func (r *Renderer) nondeterministicCalls(tmpl *template.Template) []string {
+	found := make(map[string]bool)
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		walkTemplate(t.Tree.Root, func(name string) {
+			kind, ok := nondeterministicFuncs[name]
+			if !ok || (r.deterministic.enabled && kind != "environment") {
+				return
+			}
+			found[fmt.Sprintf("%s (%s)", name, kind)] = true
+		})
+	}
+
+	suspects := make([]string, 0, len(found))
+	for s := range found {
+		suspects = append(suspects, s)
+	}
+	sort.Strings(suspects)
+	return suspects
+}
+
+// walkTemplate calls visit with the name of every function identifier in the
+// parse tree rooted at node.
+func walkTemplate(node parse.Node, visit func(name string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplate(child, visit)
+		}
+	case *parse.ActionNode:
+		walkTemplate(n.Pipe, visit)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkTemplate(cmd, visit)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTemplate(arg, visit)
+		}
+	case *parse.IdentifierNode:
+		visit(n.Ident)
+	case *parse.ChainNode:
+		walkTemplate(n.Node, visit)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, visit)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, visit)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, visit)
+	case *parse.TemplateNode:
+		walkTemplate(n.Pipe, visit)
+	}
+}
+
+func walkBranch(n *parse.BranchNode, visit func(name string)) {
+	walkTemplate(n.Pipe, visit)
+	walkTemplate(n.List, visit)
+	walkTemplate(n.ElseList, visit)
+}
+
+// compareWriter compares everything written to it with the content of r,
+// counting the complete lines that matched before the first difference.
+type compareWriter struct {
+	r    *bufio.Reader
+	buf  []byte
+	line int
+	diff bool
+}
+
+func (c *compareWriter) Write(p []byte) (int, error) {
+	if c.diff {
+		return len(p), nil
+	}
+	if cap(c.buf) < len(p) {
+		c.buf = make([]byte, len(p))
+	}
+	n, _ := io.ReadFull(c.r, c.buf[:len(p)])
+	for i, b := range p {
+		if i >= n || c.buf[i] != b {
+			c.diff = true
+			break
+		}
+		if b == '\n' {
+			c.line++
+		}
+	}
+	return len(p), nil
+}
+
+// equal reports whether the written content matched the whole reader.
+func (c *compareWriter) equal() bool {
+	if c.diff {
+		return false
+	}
+	_, err := c.r.ReadByte()
+	return err == io.EOF
+}
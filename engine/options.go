@@ -1,6 +1,13 @@
 package engine
 
-import "log/slog"
+import (
+	"log/slog"
+	"maps"
+	"text/template"
+	"time"
+
+	"github.com/cpcf/weft/render"
+)
 
 type Option func(*Engine)
 
@@ -21,3 +28,167 @@ func WithFailureMode(mode FailureMode) Option {
 		e.failMode = mode
 	}
 }
+
+// WithBackup saves the previous content of generated files before they are
+// overwritten, so manual edits can be recovered after an unintended run.
+func WithBackup(opts BackupOptions) Option {
+	return func(e *Engine) {
+		e.backup = opts
+	}
+}
+
+// WithEmptyOutput sets the policy for templates whose output is empty or
+// whitespace-only, such as conditional templates whose condition is false.
+func WithEmptyOutput(opts EmptyOutputOptions) Option {
+	return func(e *Engine) {
+		e.emptyOutput = opts
+	}
+}
+
+// WithStreaming writes outputs larger than threshold bytes straight to disk
+// through a buffered writer instead of holding them in memory, which keeps
+// memory flat when generating very large files such as SQL seed data.
+// Outputs at or below the threshold are rendered as usual. Post-processors
+// are not applied to streamed outputs. A threshold of zero disables streaming.
+func WithStreaming(threshold int64) Option {
+	return func(e *Engine) {
+		e.streamAbove = threshold
+	}
+}
+
+// WithOverwriteGuard refuses to overwrite existing files that do not contain
+// marker, protecting hand-written files whose paths collide with generated
+// ones. An empty marker uses DefaultGeneratedMarker. New files are always
+// written, so generated output must include the marker (for example via
+// processors.AddGeneratedHeader) to be regenerated later.
+func WithOverwriteGuard(marker string) Option {
+	return func(e *Engine) {
+		e.guard = overwriteGuard{enabled: true, marker: marker}
+	}
+}
+
+// WithGoVerification runs "go vet" over the generated Go packages after each
+// successful render and fails with a *GoVerificationError that attributes
+// compile and vet errors to their templates. The output must be inside a Go
+// module.
+func WithGoVerification() Option {
+	return func(e *Engine) {
+		e.verifyGo = true
+	}
+}
+
+// WithIdempotencyCheck renders every generated file a second time in memory
+// after each successful render and fails with an *IdempotencyError when the
+// output differs from what was written, naming the time, random and
+// environment functions the offending templates use. Combine it with
+// WithDeterministicSeed to make such templates reproducible.
+func WithIdempotencyCheck() Option {
+	return func(e *Engine) {
+		e.idempotent = true
+	}
+}
+
+// WithOutputFormatCheck fails with an *OutputFormatError when the output of
+// a template does not parse in its format, before post-processors run, so
+// mistakes such as trailing commas in generated JSON surface while
+// developing templates rather than in the tools that consume the output.
+// Templates declare their format with a comment such as
+// {{/* weft:format json */}}; otherwise .go, .json, .yaml, .yml and .sql
+// outputs are checked as Go, JSON, YAML and SQL. "text" turns the check off
+// for a template. Streamed outputs are not checked.
+func WithOutputFormatCheck() Option {
+	return func(e *Engine) {
+		e.checkFormats = true
+	}
+}
+
+// WithFuncMap makes additional functions available to templates, on top of
+// render.DefaultFuncMap. Functions with the same name replace the defaults.
+func WithFuncMap(funcs template.FuncMap) Option {
+	return func(e *Engine) {
+		if e.funcs == nil {
+			e.funcs = make(template.FuncMap)
+		}
+		maps.Copy(e.funcs, funcs)
+	}
+}
+
+// WithFunctionRegistry makes the functions in registry available to
+// templates, as WithFuncMap does, and logs a warning the first time a
+// template calls one registered as deprecated, naming the template and the
+// replacement.
+func WithFunctionRegistry(registry *render.FunctionRegistry) Option {
+	return func(e *Engine) {
+		WithFuncMap(registry.GetFuncMap())(e)
+		if e.deprecated == nil {
+			e.deprecated = make(map[string]string)
+		}
+		maps.Copy(e.deprecated, registry.Deprecated())
+	}
+}
+
+// WithFunctionUsage counts the template function calls each template makes,
+// reported by Engine.FunctionUsage, so template pack maintainers can find
+// unused helpers and the ones worth optimising. Counting adds a small cost to
+// every call.
+func WithFunctionUsage() Option {
+	return func(e *Engine) {
+		e.trackUsage = true
+	}
+}
+
+// WithCoverage records which arms of if, range and with actions and which
+// defines the rendered templates enter, reported by Engine.Coverage, so a
+// corpus of test data can be checked to exercise every branch of a template
+// pack. Files rendered with include are not instrumented.
+func WithCoverage() Option {
+	return func(e *Engine) {
+		e.coverage = true
+	}
+}
+
+// WithSandboxProfile restricts the functions templates may call, such as
+// render.Strict to keep templates contributed by other teams away from
+// environment variables and host paths. Functions added with WithFuncMap are
+// restricted too.
+func WithSandboxProfile(profile render.SandboxProfile) Option {
+	return func(e *Engine) {
+		e.sandbox = profile
+	}
+}
+
+// WithTelemetry reports render runs to telemetry: each RenderDir,
+// RenderTree and RenderPipeline call, template rendered and post-processor
+// stage run is a span, and the files generated and errors are counted.
+func WithTelemetry(telemetry Telemetry) Option {
+	return func(e *Engine) {
+		e.telemetry = telemetry
+	}
+}
+
+// WithDeterministic makes output byte-stable across runs with identical
+// inputs: "now" returns a pinned time and random functions such as "uuid" and
+// "randInt" are seeded per template. The pinned time is taken from the
+// SOURCE_DATE_EPOCH environment variable when set, and the Unix epoch
+// otherwise.
+func WithDeterministic() Option {
+	return func(e *Engine) {
+		e.deterministic.enabled = true
+	}
+}
+
+// WithDeterministicSeed enables deterministic mode with the given seed.
+func WithDeterministicSeed(seed uint64) Option {
+	return func(e *Engine) {
+		e.deterministic.enabled = true
+		e.deterministic.seed = seed
+	}
+}
+
+// WithPinnedTime enables deterministic mode with t as the current time.
+func WithPinnedTime(t time.Time) Option {
+	return func(e *Engine) {
+		e.deterministic.enabled = true
+		e.deterministic.now = t
+	}
+}
@@ -0,0 +1,231 @@
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pipeline declares rendering passes where the output of one pass is the
+// input data of others, such as rendering an intermediate model and then
+// generating code from it. Passes run in dependency order, whatever order
+// they are declared in.
+//
+// Example pipeline.yaml:
+//
+//	passes:
+//	  - name: model
+//	    template: model/model.yaml.tmpl
+//	  - name: code
+//	    dir: templates
+//	    inputs: [model]
+type Pipeline struct {
+	Passes []Pass `yaml:"passes" json:"passes"`
+}
+
+// Pass is one step of a Pipeline. It either renders a single template to an
+// intermediate document, which is decoded as YAML (or JSON) to become the
+// pass's result, or renders a directory of templates to files like
+// RenderDir.
+//
+// A pass without inputs renders the data given to the pipeline. A pass with
+// one input renders that input's result; with several, it renders a map
+// from each input's name to its result.
+type Pass struct {
+	// Name identifies the pass in other passes' inputs.
+	Name string `yaml:"name" json:"name"`
+	// Template is the template that renders the pass's intermediate document.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+	// Dir is the directory of templates the pass renders to files.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Inputs names the passes whose results this pass renders.
+	Inputs []string `yaml:"inputs,omitempty" json:"inputs,omitempty"`
+	// Output optionally writes the intermediate document of a template pass
+	// to this path beneath the output root, for inspection or to commit.
+	Output string `yaml:"output,omitempty" json:"output,omitempty"`
+}
+
+// LoadPipeline reads a YAML pipeline declaration from fsys.
+func LoadPipeline(fsys fs.FS, path string) (*Pipeline, error) {
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pipeline %s: %w", path, err)
+	}
+
+	var pipeline Pipeline
+	if err := yaml.Unmarshal(content, &pipeline); err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline %s: %w", path, err)
+	}
+
+	if err := pipeline.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid pipeline %s: %w", path, err)
+	}
+
+	return &pipeline, nil
+}
+
+// Validate checks that passes are named uniquely, render either a template
+// or a directory, and only take template passes as inputs without forming a
+// cycle.
+func (p *Pipeline) Validate() error {
+	_, err := p.Order()
+	return err
+}
+
+// Order returns the passes in the order they run: every pass after its
+// inputs, and otherwise in declaration order.
+func (p *Pipeline) Order() ([]Pass, error) {
+	byName := make(map[string]Pass, len(p.Passes))
+	for i, pass := range p.Passes {
+		location := fmt.Sprintf("passes[%d]", i)
+		if strings.TrimSpace(pass.Name) == "" {
+			return nil, fmt.Errorf("%s: name is required", location)
+		}
+		if _, ok := byName[pass.Name]; ok {
+			return nil, fmt.Errorf("%s: duplicate pass name %q", location, pass.Name)
+		}
+		if (pass.Template == "") == (pass.Dir == "") {
+			return nil, fmt.Errorf("pass %s: exactly one of template and dir is required", pass.Name)
+		}
+		if pass.Output != "" && pass.Dir != "" {
+			return nil, fmt.Errorf("pass %s: output is only valid for template passes", pass.Name)
+		}
+		byName[pass.Name] = pass
+	}
+	for _, pass := range p.Passes {
+		for _, input := range pass.Inputs {
+			dep, ok := byName[input]
+			if !ok {
+				return nil, fmt.Errorf("pass %s: unknown input %q", pass.Name, input)
+			}
+			if dep.Dir != "" {
+				return nil, fmt.Errorf("pass %s: input %s renders files and has no result", pass.Name, input)
+			}
+		}
+	}
+
+	var order []Pass
+	done := make(map[string]bool, len(p.Passes))
+	for len(order) < len(p.Passes) {
+		progressed := false
+		for _, pass := range p.Passes {
+			if done[pass.Name] || slices.ContainsFunc(pass.Inputs, func(input string) bool { return !done[input] }) {
+				continue
+			}
+			order = append(order, pass)
+			done[pass.Name] = true
+			progressed = true
+		}
+		if !progressed {
+			var blocked []string
+			for _, pass := range p.Passes {
+				if !done[pass.Name] {
+					blocked = append(blocked, pass.Name)
+				}
+			}
+			return nil, fmt.Errorf("passes form a cycle: %s", strings.Join(blocked, ", "))
+		}
+	}
+	return order, nil
+}
+
+// inputData returns the data pass renders, given the pipeline's data and
+// the results of the passes that have run.
+func (pass Pass) inputData(data any, results map[string]any) any {
+	switch len(pass.Inputs) {
+	case 0:
+		return data
+	case 1:
+		return results[pass.Inputs[0]]
+	}
+	inputs := make(map[string]any, len(pass.Inputs))
+	for _, input := range pass.Inputs {
+		inputs[input] = results[input]
+	}
+	return inputs
+}
+
+// RenderPipeline runs the passes of pipeline in dependency order. Directory
+// passes handle errors according to failMode; a template pass that fails
+// stops the pipeline, as the passes after it depend on its result.
+func (r *Renderer) RenderPipeline(ctx Context, failMode FailureMode, pipeline *Pipeline, data any) (err error) {
+	ctx, end := r.startSpan(ctx, SpanRenderPipeline)
+	defer func() { end(err) }()
+
+	order, err := pipeline.Order()
+	if err != nil {
+		return err
+	}
+
+	multiErr := MultiError{RunID: ctx.RunID}
+	r.empties.reset()
+	r.generated.reset()
+	r.usage.reset()
+
+	results := make(map[string]any)
+	for _, pass := range order {
+		passData := pass.inputData(data, results)
+		if pass.Dir != "" {
+			if err := r.renderDirFiles(ctx, failMode, pass.Dir, passData, &multiErr); err != nil {
+				return fmt.Errorf("pass %s: %w", pass.Name, err)
+			}
+			continue
+		}
+
+		result, err := r.renderPass(ctx, pass, passData)
+		if err != nil {
+			return fmt.Errorf("pass %s: %w", pass.Name, err)
+		}
+		results[pass.Name] = result
+	}
+
+	if multiErr.HasErrors() && failMode != BestEffort {
+		return &multiErr
+	}
+
+	return nil
+}
+
+// renderPass renders the intermediate document of a template pass, writes
+// it if the pass has an output, and returns it decoded.
+func (r *Renderer) renderPass(ctx Context, pass Pass, data any) (result any, err error) {
+	attrs := []slog.Attr{slog.String("weft.template", pass.Template), slog.String("weft.pass", pass.Name)}
+	ctx, end := r.startSpan(ctx, SpanExecuteTemplate, attrs...)
+	defer func() {
+		end(err)
+		if err != nil {
+			r.count(ctx, MetricErrors, 1, attrs...)
+		}
+	}()
+
+	r.loggerFor(ctx).Debug("rendering pipeline pass", "pass", pass.Name, "template", pass.Template)
+
+	tmpl, err := r.prepare(ctx, pass.Template)
+	if err != nil {
+		return nil, err
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %w", pass.Template, r.explainError(ctx, err, data))
+	}
+	content := []byte(buf.String())
+
+	if pass.Output != "" {
+		outputPath, err := joinTreePath(ctx.OutputRoot, pass.Output)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output: %w", err)
+		}
+		if err := r.commit(ctx, pass.Template, outputPath, data, content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := yaml.Unmarshal(content, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode output of %s: %w", pass.Template, err)
+	}
+	return result, nil
+}
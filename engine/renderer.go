@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"io/fs"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 
 	"github.com/cpcf/weft/postprocess"
 )
@@ -15,6 +17,19 @@ type Renderer struct {
 	logger         *slog.Logger
 	cache          *TemplateCache
 	postprocessors *postprocess.Chain
+	backup         BackupOptions
+	emptyOutput    EmptyOutputOptions
+	empties        *emptyOutputLog
+	generated      *generatedLog
+	deterministic  deterministicSettings
+	deprecations   *deprecations
+	usage          *usageLog
+	coverage       *coverageLog
+	streamAbove    int64
+	guard          overwriteGuard
+	checkFormats   bool
+	options        map[string]any
+	telemetry      Telemetry
 }
 
 func NewRenderer(logger *slog.Logger, cache *TemplateCache, postprocessors *postprocess.Chain) *Renderer {
@@ -22,13 +37,36 @@ func NewRenderer(logger *slog.Logger, cache *TemplateCache, postprocessors *post
 		logger:         logger,
 		cache:          cache,
 		postprocessors: postprocessors,
+		empties:        &emptyOutputLog{},
+		generated:      &generatedLog{},
+		usage:          &usageLog{},
 	}
 }
 
-func (r *Renderer) RenderDir(ctx Context, failMode FailureMode, templateDir string, data any) error {
-	var multiErr MultiError
+func (r *Renderer) RenderDir(ctx Context, failMode FailureMode, templateDir string, data any) (err error) {
+	ctx, end := r.startSpan(ctx, SpanRenderDir, slog.String("weft.template_dir", templateDir))
+	defer func() { end(err) }()
 
-	err := fs.WalkDir(ctx.TmplFS, templateDir, func(path string, d fs.DirEntry, err error) error {
+	multiErr := MultiError{RunID: ctx.RunID}
+	r.empties.reset()
+	r.generated.reset()
+	r.usage.reset()
+
+	if err := r.renderDirFiles(ctx, failMode, templateDir, data, &multiErr); err != nil {
+		return err
+	}
+
+	if multiErr.HasErrors() && failMode != BestEffort {
+		return &multiErr
+	}
+
+	return nil
+}
+
+// renderDirFiles renders every template beneath templateDir, adding errors
+// to multiErr unless failMode is FailFast.
+func (r *Renderer) renderDirFiles(ctx Context, failMode FailureMode, templateDir string, data any, multiErr *MultiError) error {
+	return fs.WalkDir(ctx.TmplFS, templateDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			if failMode == FailFast {
 				return err
@@ -38,10 +76,13 @@ func (r *Renderer) RenderDir(ctx Context, failMode FailureMode, templateDir stri
 		}
 
 		if d.IsDir() {
+			if path != templateDir && isPartial(path) {
+				return fs.SkipDir
+			}
 			return nil
 		}
 
-		if !strings.HasSuffix(path, ".tmpl") {
+		if !strings.HasSuffix(path, ".tmpl") || isPartial(path) {
 			return nil
 		}
 
@@ -53,58 +94,231 @@ func (r *Renderer) RenderDir(ctx Context, failMode FailureMode, templateDir stri
 		}
 		return nil
 	})
+}
+
+// isPartial reports whether a template or directory, named with a leading
+// underscore, holds layouts, partials or macros, which are used by other
+// templates rather than rendered to files of their own.
+func isPartial(templatePath string) bool {
+	return strings.HasPrefix(filepath.Base(templatePath), "_")
+}
+
+func (r *Renderer) renderFile(ctx Context, templatePath string, data any) error {
+	return r.renderTo(ctx, templatePath, r.resolveOutputPath(ctx, templatePath), data)
+}
+
+// renderTo renders templatePath from the context filesystem and writes the
+// result to outputPath.
+func (r *Renderer) renderTo(ctx Context, templatePath, outputPath string, data any) (err error) {
+	attrs := []slog.Attr{slog.String("weft.template", templatePath), slog.String("weft.output", outputPath)}
+	ctx, end := r.startSpan(ctx, SpanExecuteTemplate, attrs...)
+	defer func() {
+		end(err)
+		if err != nil {
+			r.count(ctx, MetricErrors, 1, attrs...)
+		}
+	}()
+
+	logger := r.loggerFor(ctx)
+	logger.Debug("rendering template", "path", templatePath)
+
+	tmpl, err := r.prepare(ctx, templatePath)
 	if err != nil {
 		return err
 	}
 
-	if multiErr.HasErrors() && failMode != BestEffort {
-		return &multiErr
+	var content []byte
+	if r.streamAbove > 0 {
+		sw := newSpillWriter(outputPath, r.streamAbove)
+		if err := tmpl.Execute(sw, data); err != nil {
+			sw.abort()
+			return fmt.Errorf("failed to execute template %s: %w", templatePath, r.explainError(ctx, err, data))
+		}
+		if sw.spilled() {
+			return r.commitStreamed(ctx, templatePath, outputPath, data, sw)
+		}
+		content = sw.bytes()
+	} else {
+		// Render template to buffer first
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("failed to execute template %s: %w", templatePath, r.explainError(ctx, err, data))
+		}
+		content = []byte(buf.String())
 	}
 
-	return nil
+	return r.commit(ctx, templatePath, outputPath, data, content)
 }
 
-func (r *Renderer) renderFile(ctx Context, templatePath string, data any) error {
-	r.logger.Debug("rendering template", "path", templatePath)
+// commit post-processes content rendered from templatePath and writes it to
+// outputPath, applying the empty output policy, overwrite guard and backups.
+func (r *Renderer) commit(ctx Context, templatePath, outputPath string, data any, content []byte) error {
+	logger := r.loggerFor(ctx)
+	if isEmptyOutput(content) {
+		policy := r.emptyOutput.policyFor(templatePath)
+		r.empties.add(EmptyOutput{
+			TemplatePath: templatePath,
+			OutputPath:   outputPath,
+			Policy:       policy,
+			RunID:        ctx.RunID,
+		})
+		logger.Info("template rendered empty output", "template", templatePath, "output", outputPath, "policy", policy)
 
-	tmpl, err := r.cache.Get(ctx.TmplFS, templatePath)
+		switch policy {
+		case EmptySkip:
+			return nil
+		case EmptyError:
+			return fmt.Errorf("%s: %w", templatePath, ErrEmptyOutput)
+		}
+	}
+
+	if err := r.checkFormat(ctx, templatePath, outputPath, content); err != nil {
+		return err
+	}
+
+	content = r.postprocess(ctx, templatePath, outputPath, data, content, logger)
+
+	if err := r.guard.check(outputPath); err != nil {
+		return err
+	}
+
+	backupPath, err := r.backupExisting(ctx, outputPath, content)
 	if err != nil {
-		return fmt.Errorf("failed to get template %s: %w", templatePath, err)
+		return fmt.Errorf("failed to back up %s: %w", outputPath, err)
+	}
+	if backupPath != "" {
+		logger.Debug("backed up existing file", "path", outputPath, "backup", backupPath)
 	}
 
-	outputPath := r.resolveOutputPath(ctx, templatePath)
 	if err := r.ensureOutputDir(outputPath); err != nil {
 		return err
 	}
 
-	// Render template to buffer first
-	var buf strings.Builder
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return fmt.Errorf("failed to execute template %s: %w", templatePath, err)
+	// Write the final content to file
+	if err := os.WriteFile(outputPath, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write output file %s: %w", outputPath, err)
 	}
 
-	content := []byte(buf.String())
+	r.generated.add(generatedEntry{
+		GeneratedFile: GeneratedFile{TemplatePath: templatePath, OutputPath: outputPath},
+		ctx:           ctx,
+		data:          data,
+	})
+	r.count(ctx, MetricFilesGenerated, 1, slog.String("weft.template", templatePath))
+	logger.Info("rendered template", "template", templatePath, "output", outputPath)
+	return nil
+}
 
-	// Apply post-processing if any processors are configured
-	if r.postprocessors.HasProcessors() {
-		processed, err := r.postprocessors.Process(outputPath, content)
+// prepare returns the template for templatePath, bound to deterministic
+// functions when enabled, to functions that warn about deprecated calls
+// when there are any, and to functions that count calls when usage is
+// tracked, and instruments it when coverage is recorded.
+func (r *Renderer) prepare(ctx Context, templatePath string) (*template.Template, error) {
+	return r.prepareTemplate(ctx, templatePath, true)
+}
+
+// prepareTemplate is prepare with call counting and coverage controlled by
+// track, so that rendering a template again for checks does not count twice.
+func (r *Renderer) prepareTemplate(ctx Context, templatePath string, track bool) (*template.Template, error) {
+	tmpl, err := r.cache.Get(ctx.TmplFS, templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template %s: %w", templatePath, r.explainError(ctx, err, nil))
+	}
+
+	funcs := r.cache.funcMap()
+	bound := false
+	if r.deterministic.enabled {
+		funcs, bound = r.deterministic.funcs(funcs, templatePath), true
+	}
+	if r.deprecations != nil && len(r.deprecations.replacements) > 0 {
+		funcs, bound = r.deprecations.wrap(funcs, templatePath, r.loggerFor(ctx)), true
+	}
+	if track && r.usage.enabled {
+		funcs, bound = r.usage.track(funcs, templatePath), true
+	}
+	if track && r.coverage != nil {
+		funcs, bound = r.coverage.funcs(funcs), true
+	}
+	if bound {
+		tmpl, err = bindFuncs(tmpl, funcs, ctx.TmplFS, templatePath)
 		if err != nil {
-			r.logger.Error("post-processing failed", "path", outputPath, "error", err)
-			// Continue with unprocessed content rather than failing
-		} else {
-			content = processed
+			return nil, fmt.Errorf("failed to prepare template %s: %w", templatePath, err)
 		}
 	}
+	if track && r.coverage != nil {
+		r.coverage.instrument(ctx.TmplFS, tmpl)
+	}
+	return tmpl, nil
+}
 
-	// Write the final content to file
-	if err := os.WriteFile(outputPath, content, 0o644); err != nil {
+// postprocess applies the post-processors to content. If processing fails
+// the unprocessed content is returned.
+func (r *Renderer) postprocess(ctx Context, templatePath, outputPath string, data any, content []byte, logger *slog.Logger) []byte {
+	if !r.postprocessors.HasProcessors() {
+		return content
+	}
+
+	processed, err := r.postprocessors.ProcessFile(r.fileInfo(ctx, templatePath, outputPath, data, logger), content)
+	if err != nil {
+		logger.Error("post-processing failed", "path", outputPath, "error", err)
+		// Continue with unprocessed content rather than failing
+		return content
+	}
+	return processed
+}
+
+// commitStreamed moves output that was streamed to a temporary file into
+// place. Post-processors are skipped because the content is never held in
+// memory.
+func (r *Renderer) commitStreamed(ctx Context, templatePath, outputPath string, data any, sw *spillWriter) error {
+	logger := r.loggerFor(ctx)
+	if r.postprocessors.HasProcessors() {
+		logger.Warn("skipping post-processing for streamed output", "path", outputPath)
+	}
+
+	if err := r.guard.check(outputPath); err != nil {
+		sw.abort()
+		return err
+	}
+
+	backupPath, err := r.backupFile(ctx, outputPath)
+	if err != nil {
+		sw.abort()
+		return fmt.Errorf("failed to back up %s: %w", outputPath, err)
+	}
+	if backupPath != "" {
+		logger.Debug("backed up existing file", "path", outputPath, "backup", backupPath)
+	}
+
+	if err := sw.commit(); err != nil {
 		return fmt.Errorf("failed to write output file %s: %w", outputPath, err)
 	}
 
-	r.logger.Info("rendered template", "template", templatePath, "output", outputPath)
+	r.generated.add(generatedEntry{
+		GeneratedFile: GeneratedFile{TemplatePath: templatePath, OutputPath: outputPath},
+		ctx:           ctx,
+		data:          data,
+		streamed:      true,
+	})
+	r.count(ctx, MetricFilesGenerated, 1, slog.String("weft.template", templatePath))
+	logger.Info("rendered template", "template", templatePath, "output", outputPath, "streamed", true)
 	return nil
 }
 
+// fileInfo describes an output file to post-processors.
+func (r *Renderer) fileInfo(ctx Context, templatePath, outputPath string, data any, logger *slog.Logger) *postprocess.FileInfo {
+	return &postprocess.FileInfo{
+		Path:         outputPath,
+		TemplatePath: templatePath,
+		RunID:        ctx.RunID,
+		Data:         data,
+		DataKeys:     postprocess.DataKeys(data),
+		Options:      maps.Clone(r.options),
+		Logger:       logger.With("template", templatePath, "path", outputPath),
+		Context:      ctx.TraceContext,
+	}
+}
+
 func (r *Renderer) resolveOutputPath(ctx Context, templatePath string) string {
 	outputName := strings.TrimSuffix(filepath.Base(templatePath), ".tmpl")
 	outputDir := filepath.Join(ctx.OutputRoot, filepath.Dir(templatePath))
@@ -115,3 +329,15 @@ func (r *Renderer) ensureOutputDir(outputPath string) error {
 	dir := filepath.Dir(outputPath)
 	return os.MkdirAll(dir, 0o755)
 }
+
+// GeneratedFiles returns the files written during the most recent RenderDir
+// or RenderTree call.
+func (r *Renderer) GeneratedFiles() []GeneratedFile {
+	return r.generated.list()
+}
+
+// EmptyOutputs returns the templates that rendered empty output during the
+// most recent RenderDir or RenderTree call.
+func (r *Renderer) EmptyOutputs() []EmptyOutput {
+	return r.empties.list()
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// NewRunID returns a random identifier for a render run.
+func NewRunID() string {
+	return uuid.NewString()
+}
+
+// runID derives the ID for a run writing to outputRoot. In deterministic mode
+// the ID is a name-based UUID of the seed, pinned time and output root, so
+// reproducible runs also produce reproducible provenance.
+func (d deterministicSettings) runID(outputRoot string) string {
+	if !d.enabled {
+		return NewRunID()
+	}
+	name := fmt.Sprintf("weft:%d:%d:%s", d.seed, d.now.UnixNano(), outputRoot)
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
+
+// runTracker remembers the ID of the most recent run.
+type runTracker struct {
+	mu   sync.Mutex
+	last string
+}
+
+func (t *runTracker) set(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = id
+}
+
+func (t *runTracker) get() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.last
+}
+
+// loggerFor returns the renderer's logger annotated with the run ID of ctx.
+func (r *Renderer) loggerFor(ctx Context) *slog.Logger {
+	if ctx.RunID == "" {
+		return r.logger
+	}
+	return r.logger.With("run_id", ctx.RunID)
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// streamBufferSize is the size of the buffered writer used once output has
+// spilled to disk.
+const streamBufferSize = 64 * 1024
+
+// spillWriter collects template output in memory until it grows past
+// threshold bytes, then moves it to a temporary file in the destination's
+// nearest existing directory and streams everything after that through a
+// buffered writer. Small outputs
+// therefore take the normal in-memory path, while very large outputs never
+// have to fit in memory.
+type spillWriter struct {
+	threshold  int64
+	outputPath string
+
+	buf  bytes.Buffer
+	file *os.File
+	w    *bufio.Writer
+}
+
+func newSpillWriter(outputPath string, threshold int64) *spillWriter {
+	return &spillWriter{threshold: threshold, outputPath: outputPath}
+}
+
+func (s *spillWriter) Write(p []byte) (int, error) {
+	if s.file == nil {
+		if int64(s.buf.Len()+len(p)) <= s.threshold {
+			return s.buf.Write(p)
+		}
+		if err := s.spill(); err != nil {
+			return 0, err
+		}
+	}
+	return s.w.Write(p)
+}
+
+// spill creates the temporary file and moves the buffered output into it.
+// The destination's missing directories are only created by commit, so
+// output that is never committed leaves none behind.
+func (s *spillWriter) spill() error {
+	file, err := os.CreateTemp(existingDir(filepath.Dir(s.outputPath)), "."+filepath.Base(s.outputPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %w", s.outputPath, err)
+	}
+
+	s.file = file
+	s.w = bufio.NewWriterSize(file, streamBufferSize)
+	if _, err := s.w.Write(s.buf.Bytes()); err != nil {
+		return err
+	}
+	s.buf = bytes.Buffer{}
+	return nil
+}
+
+// spilled reports whether the output was moved to a temporary file.
+func (s *spillWriter) spilled() bool {
+	return s.file != nil
+}
+
+// bytes returns the in-memory output of a writer that has not spilled.
+func (s *spillWriter) bytes() []byte {
+	return s.buf.Bytes()
+}
+
+// commit flushes the temporary file, creates the destination's directory
+// and moves the file into place.
+func (s *spillWriter) commit() error {
+	if err := s.w.Flush(); err != nil {
+		s.abort()
+		return err
+	}
+	if err := s.file.Chmod(0o644); err != nil {
+		s.abort()
+		return err
+	}
+	if err := s.file.Close(); err != nil {
+		os.Remove(s.file.Name())
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.outputPath), 0o755); err != nil {
+		os.Remove(s.file.Name())
+		return err
+	}
+	if err := os.Rename(s.file.Name(), s.outputPath); err != nil {
+		os.Remove(s.file.Name())
+		return err
+	}
+	return nil
+}
+
+// abort discards the temporary file, if any.
+func (s *spillWriter) abort() {
+	if s.file == nil {
+		return
+	}
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
+
+// existingDir returns dir or its nearest ancestor that exists. A temporary
+// file created there is on the same file system as dir once it is created,
+// so it can be renamed into it.
+func existingDir(dir string) string {
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+
+	"github.com/cpcf/weft/render"
+)
+
+var (
+	// undefinedFunction matches the parse error for a call to a function
+	// that is not defined.
+	undefinedFunction = regexp.MustCompile(`function "([^"]+)" not defined`)
+	// unknownField matches the execution error for a field or method the
+	// data does not have.
+	unknownField = regexp.MustCompile(`can't evaluate field (\w+) in type (\S+)`)
+)
+
+// builtinFuncs are the functions text/template predefines.
+var builtinFuncs = []string{
+	"and", "or", "not", "eq", "ne", "lt", "le", "gt", "ge",
+	"printf", "print", "println", "len", "index", "slice",
+	"call", "html", "js", "urlquery",
+}
+
+// explainError locates err in the template source as locateError does and
+// suggests the function or field a template probably meant when err
+// reports one that does not exist. data is what the template was executed
+// with, or nil for parse errors.
+func (r *Renderer) explainError(ctx Context, err error, data any) error {
+	located := locateError(ctx.TmplFS, err)
+	templateErr, ok := located.(*TemplateError)
+	if !ok {
+		return located
+	}
+	msg := err.Error()
+	if match := undefinedFunction.FindStringSubmatch(msg); match != nil {
+		candidates := append(slices.Clone(builtinFuncs), slices.Sorted(maps.Keys(r.cache.funcMap()))...)
+		templateErr.Suggestion = didYouMean(match[1], candidates)
+	} else if match := unknownField.FindStringSubmatch(msg); match != nil {
+		templateErr.Suggestion = didYouMean(match[1], fieldNames(data, match[2]))
+	}
+	return templateErr
+}
+
+// didYouMean returns a suggestion naming the candidate closest to name, or
+// "" if none is close enough to be a likely typo.
+func didYouMean(name string, candidates []string) string {
+	candidates = slices.DeleteFunc(slices.Clone(candidates), func(candidate string) bool {
+		return candidate == name
+	})
+	best := render.ClosestName(name, candidates)
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("did you mean %q?", best)
+}
+
+// fieldNames returns the exported fields and methods of the type named
+// typeName, found by searching data for a value of that type. It returns
+// nil if data holds no such value.
+func fieldNames(data any, typeName string) []string {
+	typ := findType(reflect.ValueOf(data), typeName, 0)
+	if typ == nil {
+		return nil
+	}
+	var names []string
+	if typ.Kind() == reflect.Struct {
+		for _, field := range reflect.VisibleFields(typ) {
+			if field.IsExported() && !field.Anonymous {
+				names = append(names, field.Name)
+			}
+		}
+	}
+	if typ.Kind() != reflect.Pointer && typ.Kind() != reflect.Interface {
+		typ = reflect.PointerTo(typ)
+	}
+	for i := range typ.NumMethod() {
+		names = append(names, typ.Method(i).Name)
+	}
+	return names
+}
+
+// findType searches v, up to a few levels deep and a few elements into each
+// collection, for a value whose type is named typeName.
+func findType(v reflect.Value, typeName string, depth int) reflect.Type {
+	const maxDepth, maxElements = 8, 16
+	if !v.IsValid() || depth > maxDepth {
+		return nil
+	}
+	if v.Type().String() == typeName {
+		return v.Type()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			return findType(v.Elem(), typeName, depth+1)
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				if typ := findType(v.Field(i), typeName, depth+1); typ != nil {
+					return typ
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range min(v.Len(), maxElements) {
+			if typ := findType(v.Index(i), typeName, depth+1); typ != nil {
+				return typ
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for n := 0; n < maxElements && iter.Next(); n++ {
+			if typ := findType(iter.Value(), typeName, depth+1); typ != nil {
+				return typ
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/cpcf/weft/postprocess"
+)
+
+// Telemetry receives the spans and counters of render runs, for export to a
+// tracing and metrics system such as OpenTelemetry. The engine does not
+// depend on any such system; an adapter implements Telemetry with it.
+type Telemetry interface {
+	// StartSpan starts a span named name, a child of the span in parent if
+	// there is one, and returns a context holding it along with a function
+	// ending it with the error of the operation it covers, or nil.
+	StartSpan(parent context.Context, name string, attrs ...slog.Attr) (context.Context, func(err error))
+	// Count adds n to the counter named name.
+	Count(ctx context.Context, name string, n int64, attrs ...slog.Attr)
+}
+
+// Span names.
+const (
+	// SpanRenderDir covers a RenderDir call, SpanRenderTree a RenderTree call
+	// and SpanRenderPipeline a RenderPipeline call.
+	SpanRenderDir      = "weft.render_dir"
+	SpanRenderTree     = "weft.render_tree"
+	SpanRenderPipeline = "weft.render_pipeline"
+	// SpanExecuteTemplate covers rendering one template, from executing it
+	// to writing its output.
+	SpanExecuteTemplate = "weft.execute_template"
+	// SpanPostProcess covers one post-processor stage run on one file.
+	SpanPostProcess = "weft.postprocess"
+)
+
+// Counter names.
+const (
+	// MetricFilesGenerated counts the files written.
+	MetricFilesGenerated = "weft.files_generated"
+	// MetricErrors counts the templates that failed to render and the
+	// post-processor stages that failed.
+	MetricErrors = "weft.errors"
+)
+
+// startSpan starts a span for an operation in ctx and returns ctx with the
+// span as the parent of the operation's own spans. Without telemetry it
+// only returns ctx.
+func (r *Renderer) startSpan(ctx Context, name string, attrs ...slog.Attr) (Context, func(error)) {
+	if r.telemetry == nil {
+		return ctx, func(error) {}
+	}
+	parent := ctx.TraceContext
+	if parent == nil {
+		parent = context.Background()
+	}
+	attrs = append(attrs, slog.String("weft.run_id", ctx.RunID))
+	var end func(error)
+	ctx.TraceContext, end = r.telemetry.StartSpan(parent, name, attrs...)
+	return ctx, end
+}
+
+// count adds n to a counter when telemetry is enabled.
+func (r *Renderer) count(ctx Context, name string, n int64, attrs ...slog.Attr) {
+	if r.telemetry == nil {
+		return
+	}
+	parent := ctx.TraceContext
+	if parent == nil {
+		parent = context.Background()
+	}
+	r.telemetry.Count(parent, name, n, attrs...)
+}
+
+// stageHook returns the post-processor stage hook reporting each stage run
+// as a span, and failures to the error counter.
+func stageHook(telemetry Telemetry) func(info *postprocess.FileInfo, stage string) func(error) {
+	return func(info *postprocess.FileInfo, stage string) func(error) {
+		parent := info.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+		attrs := []slog.Attr{
+			slog.String("weft.stage", stage),
+			slog.String("weft.template", info.TemplatePath),
+			slog.String("weft.output", info.Path),
+			slog.String("weft.run_id", info.RunID),
+		}
+		ctx, end := telemetry.StartSpan(parent, SpanPostProcess, attrs...)
+		return func(err error) {
+			end(err)
+			if err != nil {
+				telemetry.Count(ctx, MetricErrors, 1, attrs...)
+			}
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cpcf/weft/render"
+)
+
+// Tree declares a file tree that is expanded against hierarchical data in a
+// single walk. Template packs typically ship it as a YAML file next to their
+// templates and load it with LoadTree.
+//
+// Example tree.yaml:
+//
+//	nodes:
+//	  - path: "{{ .Name }}"
+//	    each: Packages
+//	    children:
+//	      - path: "{{ .Name | snake }}.go"
+//	        each: Files
+//	        template: templates/file.go.tmpl
+type Tree struct {
+	Nodes []TreeNode `yaml:"nodes" json:"nodes"`
+}
+
+// TreeNode is a single directory or file in a Tree.
+type TreeNode struct {
+	// Path is a template for the node's name, expanded against the node's data
+	// and joined to the parent node's path. It may contain slashes.
+	Path string `yaml:"path" json:"path"`
+	// Template is the template used to render the node's file. Nodes without a
+	// template are directories and only contribute to their children's paths.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+	// Each optionally names a dotted field path, relative to the parent's data,
+	// whose elements the node is expanded once for. Without it the node is
+	// expanded once with the parent's data.
+	Each string `yaml:"each,omitempty" json:"each,omitempty"`
+	// Children are expanded beneath this node with the node's data.
+	Children []TreeNode `yaml:"children,omitempty" json:"children,omitempty"`
+}
+
+// LoadTree reads a YAML tree declaration from fsys.
+func LoadTree(fsys fs.FS, path string) (*Tree, error) {
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree %s: %w", path, err)
+	}
+
+	var tree Tree
+	if err := yaml.Unmarshal(content, &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse tree %s: %w", path, err)
+	}
+
+	if err := tree.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid tree %s: %w", path, err)
+	}
+
+	return &tree, nil
+}
+
+// Validate checks that every node has a path and that file nodes are leaves.
+func (t *Tree) Validate() error {
+	return validateTreeNodes(t.Nodes, "")
+}
+
+func validateTreeNodes(nodes []TreeNode, parent string) error {
+	for i, node := range nodes {
+		location := fmt.Sprintf("%snodes[%d]", parent, i)
+		if strings.TrimSpace(node.Path) == "" {
+			return fmt.Errorf("%s: path is required", location)
+		}
+		if node.Template != "" && len(node.Children) > 0 {
+			return fmt.Errorf("%s: file node %q cannot have children", location, node.Path)
+		}
+		if err := validateTreeNodes(node.Children, location+"."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderTree expands tree against data and renders every file node beneath
+// the context's output root. Errors are handled according to failMode.
+func (r *Renderer) RenderTree(ctx Context, failMode FailureMode, tree *Tree, data any) (err error) {
+	ctx, end := r.startSpan(ctx, SpanRenderTree)
+	defer func() { end(err) }()
+
+	if err := tree.Validate(); err != nil {
+		return err
+	}
+
+	multiErr := MultiError{RunID: ctx.RunID}
+	r.empties.reset()
+	r.generated.reset()
+	r.usage.reset()
+	for _, node := range tree.Nodes {
+		if err := r.expandTreeNode(ctx, failMode, node, ctx.OutputRoot, data, &multiErr); err != nil {
+			return err
+		}
+	}
+
+	if multiErr.HasErrors() && failMode != BestEffort {
+		return &multiErr
+	}
+
+	return nil
+}
+
+func (r *Renderer) expandTreeNode(ctx Context, failMode FailureMode, node TreeNode, parentDir string, data any, multiErr *MultiError) error {
+	fail := func(path, message string, err error) error {
+		if failMode == FailFast {
+			return fmt.Errorf("%s: %s: %w", path, message, err)
+		}
+		multiErr.Add(path, message, err)
+		return nil
+	}
+
+	items := []any{data}
+	if node.Each != "" {
+		value, err := lookupField(data, node.Each)
+		if err != nil {
+			return fail(node.Path, "each lookup failed", err)
+		}
+		items = treeItems(value)
+	}
+
+	pathTmpl, err := template.New(node.Path).Funcs(r.pathFuncs(node.Path)).Option("missingkey=error").Parse(node.Path)
+	if err != nil {
+		return fail(node.Path, "invalid path template", err)
+	}
+
+	for _, item := range items {
+		var name strings.Builder
+		if err := pathTmpl.Execute(&name, item); err != nil {
+			if err := fail(node.Path, "path expansion failed", err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		outputPath, err := joinTreePath(parentDir, name.String())
+		if err != nil {
+			if err := fail(node.Path, "invalid expanded path", err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if node.Template != "" {
+			if err := r.renderTo(ctx, node.Template, outputPath, item); err != nil {
+				if err := fail(outputPath, "render failed", err); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for _, child := range node.Children {
+			if err := r.expandTreeNode(ctx, failMode, child, outputPath, item, multiErr); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// pathFuncs returns the functions for expanding a path template: those
+// templates are parsed with, so that fixedNow agrees with file contents, made
+// deterministic when enabled.
+func (r *Renderer) pathFuncs(path string) template.FuncMap {
+	funcs := r.cache.funcMap()
+	if r.deterministic.enabled {
+		funcs = render.DeterministicFuncMap(funcs, render.SeedFor(r.deterministic.seed, path), r.deterministic.now)
+	}
+	return funcs
+}
+
+// joinTreePath joins an expanded node name to its parent directory, rejecting
+// names that are empty or would escape the parent.
+func joinTreePath(parentDir, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("path expanded to an empty name")
+	}
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("path %q must be relative", name)
+	}
+
+	cleaned := filepath.Clean(filepath.FromSlash(name))
+	if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q escapes its parent directory", name)
+	}
+
+	return filepath.Join(parentDir, cleaned), nil
+}
+
+// treeItems returns the elements a node is expanded for. Slices and arrays
+// yield their elements, maps yield their values ordered by key, nil yields
+// nothing and any other value is expanded once.
+func treeItems(value any) []any {
+	if value == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, v.Index(i).Interface())
+		}
+		return items
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		items := make([]any, 0, len(keys))
+		for _, key := range keys {
+			items = append(items, v.MapIndex(key).Interface())
+		}
+		return items
+	default:
+		return []any{value}
+	}
+}
+
+// lookupField resolves a dotted field path such as "Module.Packages" against
+// maps with string keys and struct fields, dereferencing pointers on the way.
+func lookupField(data any, path string) (any, error) {
+	current := reflect.ValueOf(data)
+
+	for _, part := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		for current.Kind() == reflect.Pointer || current.Kind() == reflect.Interface {
+			if current.IsNil() {
+				return nil, fmt.Errorf("nil value before %q in %q", part, path)
+			}
+			current = current.Elem()
+		}
+
+		switch current.Kind() {
+		case reflect.Map:
+			if current.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("cannot look up %q in map with %s keys", part, current.Type().Key())
+			}
+			value := current.MapIndex(reflect.ValueOf(part).Convert(current.Type().Key()))
+			if !value.IsValid() {
+				return nil, fmt.Errorf("key %q not found in %q", part, path)
+			}
+			current = value
+		case reflect.Struct:
+			field, ok := current.Type().FieldByName(part)
+			if !ok || !field.IsExported() {
+				return nil, fmt.Errorf("field %q not found in %q", part, path)
+			}
+			current = current.FieldByIndex(field.Index)
+		default:
+			return nil, fmt.Errorf("cannot look up %q in %s", part, current.Kind())
+		}
+	}
+
+	if !current.IsValid() {
+		return nil, nil
+	}
+	return current.Interface(), nil
+}
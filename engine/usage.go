@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"text/template"
+)
+
+// FunctionUsage reports how often templates called a template function
+// during the most recent render.
+type FunctionUsage struct {
+	Function string `json:"function"`
+	Calls    int    `json:"calls"`
+	// Templates maps each template that called the function to the number
+	// of calls it made.
+	Templates map[string]int `json:"templates,omitempty"`
+}
+
+// usageLog counts function calls per template across a render call.
+type usageLog struct {
+	enabled   bool
+	mu        sync.Mutex
+	available []string
+	calls     map[string]map[string]int
+}
+
+func (l *usageLog) record(function, templatePath string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.calls == nil {
+		l.calls = make(map[string]map[string]int)
+	}
+	if l.calls[function] == nil {
+		l.calls[function] = make(map[string]int)
+	}
+	l.calls[function][templatePath]++
+}
+
+func (l *usageLog) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = nil
+}
+
+// list returns the usage of every available function, most called first.
+func (l *usageLog) list() []FunctionUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	usage := make([]FunctionUsage, 0, len(l.available))
+	for _, function := range l.available {
+		u := FunctionUsage{Function: function}
+		for templatePath, n := range l.calls[function] {
+			if u.Templates == nil {
+				u.Templates = make(map[string]int)
+			}
+			u.Templates[templatePath] = n
+			u.Calls += n
+		}
+		usage = append(usage, u)
+	}
+	sort.SliceStable(usage, func(i, j int) bool {
+		if usage[i].Calls != usage[j].Calls {
+			return usage[i].Calls > usage[j].Calls
+		}
+		return usage[i].Function < usage[j].Function
+	})
+	return usage
+}
+
+// track returns a copy of funcs that records each call made by templatePath.
+func (l *usageLog) track(funcs template.FuncMap, templatePath string) template.FuncMap {
+	return observeCalls(funcs, func(name string) func() {
+		return func() { l.record(name, templatePath) }
+	})
+}
+
+// observeCalls returns a copy of funcs in which each function for which
+// observe returns a callback runs it before every call.
+func observeCalls(funcs template.FuncMap, observe func(name string) func()) template.FuncMap {
+	wrapped := make(template.FuncMap, len(funcs))
+	for name, fn := range funcs {
+		wrapped[name] = fn
+		callback := observe(name)
+		if callback == nil {
+			continue
+		}
+		fnValue := reflect.ValueOf(fn)
+		wrapped[name] = reflect.MakeFunc(fnValue.Type(), func(args []reflect.Value) []reflect.Value {
+			callback()
+			if fnValue.Type().IsVariadic() {
+				return fnValue.CallSlice(args)
+			}
+			return fnValue.Call(args)
+		}).Interface()
+	}
+	return wrapped
+}
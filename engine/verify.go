@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GoDiagnostic is a compile or vet error in a generated Go file, attributed
+// to the template that produced the file.
+type GoDiagnostic struct {
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	Column       int    `json:"column,omitempty"`
+	Message      string `json:"message"`
+	TemplatePath string `json:"template_path,omitempty"`
+}
+
+func (d GoDiagnostic) String() string {
+	pos := fmt.Sprintf("%s:%d", d.File, d.Line)
+	if d.Column > 0 {
+		pos += ":" + strconv.Itoa(d.Column)
+	}
+	if d.TemplatePath != "" {
+		return fmt.Sprintf("%s: %s (from template %s)", pos, d.Message, d.TemplatePath)
+	}
+	return fmt.Sprintf("%s: %s", pos, d.Message)
+}
+
+// GoVerificationError is returned when generated Go code fails to compile or
+// vet.
+type GoVerificationError struct {
+	Diagnostics []GoDiagnostic
+}
+
+func (e *GoVerificationError) Error() string {
+	msgs := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		msgs[i] = d.String()
+	}
+	return fmt.Sprintf("generated Go code failed verification:\n%s", strings.Join(msgs, "\n"))
+}
+
+var goDiagnosticPattern = regexp.MustCompile(`^(?:vet: )?(\S+\.go):(\d+)(?::(\d+))?: (.+)$`)
+
+// VerifyGo runs "go vet" over every package that received generated Go files
+// in the most recent run and returns the problems found, each attributed to
+// its originating template. The packages must be inside a Go module and the
+// go command must be on PATH.
+func (e *Engine) VerifyGo(ctx context.Context) ([]GoDiagnostic, error) {
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		return nil, fmt.Errorf("go verification requires the go command: %w", err)
+	}
+
+	templates := make(map[string]string)
+	dirSet := make(map[string]bool)
+	for _, file := range e.renderer.GeneratedFiles() {
+		if filepath.Ext(file.OutputPath) != ".go" {
+			continue
+		}
+		abs, err := filepath.Abs(file.OutputPath)
+		if err != nil {
+			return nil, err
+		}
+		templates[abs] = file.TemplatePath
+		dirSet[filepath.Dir(abs)] = true
+	}
+
+	dirs := make([]string, 0, len(dirSet))
+	for dir := range dirSet {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var diagnostics []GoDiagnostic
+	for _, dir := range dirs {
+		var output bytes.Buffer
+		cmd := exec.CommandContext(ctx, goCmd, "vet", ".")
+		cmd.Dir = dir
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+
+		runErr := cmd.Run()
+		found := parseGoDiagnostics(dir, output.String(), templates)
+		if runErr != nil && len(found) == 0 {
+			return diagnostics, fmt.Errorf("go vet failed in %s: %w: %s", dir, runErr, strings.TrimSpace(output.String()))
+		}
+		diagnostics = append(diagnostics, found...)
+	}
+
+	return diagnostics, nil
+}
+
+// parseGoDiagnostics extracts file:line:col diagnostics from go vet output,
+// resolving file names against dir.
+func parseGoDiagnostics(dir, output string, templates map[string]string) []GoDiagnostic {
+	var diagnostics []GoDiagnostic
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		match := goDiagnosticPattern.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+
+		file := match[1]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		line, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+
+		diagnostics = append(diagnostics, GoDiagnostic{
+			File:         file,
+			Line:         line,
+			Column:       column,
+			Message:      match[4],
+			TemplatePath: templates[file],
+		})
+	}
+
+	return diagnostics
+}
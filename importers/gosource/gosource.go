@@ -0,0 +1,481 @@
+// Package gosource imports the declarations of existing Go packages as
+// template data, for generators that emit mocks, functional options or
+// builders for the types in a package.
+//
+//	pkg, err := gosource.LoadPackage("./store")
+//	if err != nil {
+//		return err
+//	}
+//	err = eng.RenderDir(ctx, "templates/mocks", pkg)
+//
+// Types are written as they would be inside the loaded package: its own
+// types are unqualified and other packages are qualified by name, with the
+// import paths listed in Package.Imports.
+package gosource
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Package is an imported Go package.
+type Package struct {
+	Name string
+	Path string
+	Doc  string
+	// Imports lists the packages referred to by the types of the
+	// extracted declarations, sorted by path.
+	Imports    []Import
+	Structs    []*Struct
+	Interfaces []*Interface
+	// Funcs lists the package-level functions.
+	Funcs []*Func
+}
+
+// Import is an imported package.
+type Import struct {
+	Name string
+	Path string
+}
+
+// Struct is a named struct type.
+type Struct struct {
+	Name       string
+	Doc        string
+	TypeParams []*TypeParam
+	Fields     []*Field
+	// Methods lists the methods declared on the type in source order.
+	Methods []*Func
+}
+
+// Field is a struct field.
+type Field struct {
+	Name     string
+	Type     string
+	Doc      string
+	Tag      string // the raw struct tag, without backquotes
+	Embedded bool
+	Exported bool
+}
+
+// Interface is a named interface type.
+type Interface struct {
+	Name       string
+	Doc        string
+	TypeParams []*TypeParam
+	// Embeds lists the explicitly embedded interfaces and constraints.
+	Embeds []string
+	// Methods is the complete method set, including the methods of
+	// embedded interfaces, sorted by name.
+	Methods []*Func
+}
+
+// TypeParam is a type parameter of a generic type.
+type TypeParam struct {
+	Name       string
+	Constraint string
+}
+
+// Func is a function or method.
+type Func struct {
+	Name string
+	Doc  string
+	// Receiver is the receiver type name of a method, without "*".
+	Receiver        string
+	PointerReceiver bool
+	// Params are named "argN" when the declaration leaves them unnamed.
+	// The type of a final variadic parameter is written as "...T".
+	Params   []*Var
+	Results  []*Var
+	Variadic bool
+}
+
+// Var is a parameter or result.
+type Var struct {
+	Name string
+	Type string
+}
+
+// TagValue returns the value of key in the field's struct tag, such as
+// "name" for `json:"name,omitempty"` and key "json".
+func (f *Field) TagValue(key string) string {
+	value, _ := reflect.StructTag(f.Tag).Lookup(key)
+	name, _, _ := strings.Cut(value, ",")
+	return name
+}
+
+// Signature returns the parameters and results as they appear in a
+// declaration, such as "(ctx context.Context, id string) (*User, error)".
+func (f *Func) Signature() string {
+	var b strings.Builder
+	b.WriteString("(")
+	for i, p := range f.Params {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(p.Name + " " + p.Type)
+	}
+	b.WriteString(")")
+
+	switch {
+	case len(f.Results) == 1 && f.Results[0].Name == "":
+		b.WriteString(" " + f.Results[0].Type)
+	case len(f.Results) > 0:
+		b.WriteString(" (")
+		for i, r := range f.Results {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			if r.Name != "" {
+				b.WriteString(r.Name + " ")
+			}
+			b.WriteString(r.Type)
+		}
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// Args returns the parameter names as a call would pass them, such as
+// "ctx, ids...".
+func (f *Func) Args() string {
+	names := make([]string, len(f.Params))
+	for i, p := range f.Params {
+		names[i] = p.Name
+		if f.Variadic && i == len(f.Params)-1 {
+			names[i] += "..."
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// Struct returns the struct called name, or nil.
+func (p *Package) Struct(name string) *Struct {
+	for _, s := range p.Structs {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Interface returns the interface called name, or nil.
+func (p *Package) Interface(name string) *Interface {
+	for _, i := range p.Interfaces {
+		if i.Name == name {
+			return i
+		}
+	}
+	return nil
+}
+
+// Option configures Load.
+type Option func(*options)
+
+type options struct {
+	dir        string
+	tags       []string
+	unexported bool
+}
+
+// WithDir sets the directory in which patterns are resolved; the default is
+// the working directory.
+func WithDir(dir string) Option {
+	return func(o *options) {
+		o.dir = dir
+	}
+}
+
+// WithBuildTags sets the build tags used to select files.
+func WithBuildTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithUnexported includes unexported types, fields, functions and methods.
+// By default only the exported API is imported.
+func WithUnexported() Option {
+	return func(o *options) {
+		o.unexported = true
+	}
+}
+
+// Load imports the packages matching patterns, such as "./store" or
+// "example.com/app/...". It needs the go command.
+func Load(patterns []string, opts ...Option) ([]*Package, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// Dependencies are type-checked from source rather than export data,
+	// which does not depend on the export format of the installed toolchain.
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+		Dir: o.dir,
+	}
+	if len(o.tags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(o.tags, ",")}
+	}
+	loaded, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Go packages: %w", err)
+	}
+
+	var errs []string
+	packages.Visit(loaded, nil, func(p *packages.Package) {
+		for _, e := range p.Errors {
+			errs = append(errs, e.Error())
+		}
+	})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to load Go packages: %s", strings.Join(errs, "; "))
+	}
+
+	var pkgs []*Package
+	for _, p := range loaded {
+		pkgs = append(pkgs, newExtractor(p, o).extract())
+	}
+	return pkgs, nil
+}
+
+// LoadPackage imports the single package matching pattern.
+func LoadPackage(pattern string, opts ...Option) (*Package, error) {
+	pkgs, err := Load([]string{pattern}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("pattern %q matched %d packages, want 1", pattern, len(pkgs))
+	}
+	return pkgs[0], nil
+}
+
+// extractor builds a Package from a loaded package.
+type extractor struct {
+	pkg     *packages.Package
+	opts    *options
+	imports map[string]string // path to name
+	out     *Package
+}
+
+func newExtractor(p *packages.Package, o *options) *extractor {
+	return &extractor{
+		pkg:     p,
+		opts:    o,
+		imports: make(map[string]string),
+		out:     &Package{Name: p.Name, Path: p.PkgPath},
+	}
+}
+
+func (e *extractor) extract() *Package {
+	structs := make(map[string]*Struct)
+	var methods []*ast.FuncDecl
+
+	files := append([]*ast.File(nil), e.pkg.Syntax...)
+	sort.Slice(files, func(i, j int) bool {
+		return e.pkg.Fset.File(files[i].Pos()).Name() < e.pkg.Fset.File(files[j].Pos()).Name()
+	})
+	for _, file := range files {
+		if file.Doc != nil && e.out.Doc == "" {
+			e.out.Doc = strings.TrimSpace(file.Doc.Text())
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					ts := spec.(*ast.TypeSpec)
+					if !e.include(ts.Name.Name) {
+						continue
+					}
+					doc := ts.Doc
+					if doc == nil && len(decl.Specs) == 1 {
+						doc = decl.Doc
+					}
+					e.typeSpec(ts, doc, structs)
+				}
+			case *ast.FuncDecl:
+				if !e.include(decl.Name.Name) {
+					continue
+				}
+				if decl.Recv != nil {
+					methods = append(methods, decl)
+					continue
+				}
+				if fn, ok := e.pkg.TypesInfo.Defs[decl.Name].(*types.Func); ok {
+					e.out.Funcs = append(e.out.Funcs, e.function(fn, decl.Doc))
+				}
+			}
+		}
+	}
+
+	for _, decl := range methods {
+		fn, ok := e.pkg.TypesInfo.Defs[decl.Name].(*types.Func)
+		if !ok {
+			continue
+		}
+		m := e.function(fn, decl.Doc)
+		if s := structs[m.Receiver]; s != nil {
+			s.Methods = append(s.Methods, m)
+		}
+	}
+
+	for path, name := range e.imports {
+		e.out.Imports = append(e.out.Imports, Import{Name: name, Path: path})
+	}
+	sort.Slice(e.out.Imports, func(i, j int) bool { return e.out.Imports[i].Path < e.out.Imports[j].Path })
+	return e.out
+}
+
+func (e *extractor) include(name string) bool {
+	return e.opts.unexported || token.IsExported(name)
+}
+
+func (e *extractor) typeSpec(ts *ast.TypeSpec, doc *ast.CommentGroup, structs map[string]*Struct) {
+	obj, ok := e.pkg.TypesInfo.Defs[ts.Name].(*types.TypeName)
+	if !ok || obj.IsAlias() {
+		return
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return
+	}
+
+	switch underlying := named.Underlying().(type) {
+	case *types.Struct:
+		s := &Struct{Name: ts.Name.Name, Doc: docText(doc), TypeParams: e.typeParams(named.TypeParams())}
+		astFields := ts.Type.(*ast.StructType).Fields.List
+		for i := 0; i < underlying.NumFields(); i++ {
+			v := underlying.Field(i)
+			if !e.include(v.Name()) && !v.Embedded() {
+				continue
+			}
+			f := &Field{
+				Name:     v.Name(),
+				Type:     e.typeString(v.Type()),
+				Tag:      underlying.Tag(i),
+				Embedded: v.Embedded(),
+				Exported: v.Exported(),
+			}
+			if af := astField(astFields, v.Pos()); af != nil {
+				f.Doc = docText(af.Doc)
+				if f.Doc == "" {
+					f.Doc = docText(af.Comment)
+				}
+			}
+			s.Fields = append(s.Fields, f)
+		}
+		structs[s.Name] = s
+		e.out.Structs = append(e.out.Structs, s)
+
+	case *types.Interface:
+		iface := &Interface{Name: ts.Name.Name, Doc: docText(doc), TypeParams: e.typeParams(named.TypeParams())}
+		for i := 0; i < underlying.NumEmbeddeds(); i++ {
+			iface.Embeds = append(iface.Embeds, e.typeString(underlying.EmbeddedType(i)))
+		}
+		astMethods := ts.Type.(*ast.InterfaceType).Methods.List
+		for i := 0; i < underlying.NumMethods(); i++ {
+			fn := underlying.Method(i)
+			if !e.include(fn.Name()) {
+				continue
+			}
+			var mdoc *ast.CommentGroup
+			if af := astField(astMethods, fn.Pos()); af != nil {
+				mdoc = af.Doc
+			}
+			iface.Methods = append(iface.Methods, e.function(fn, mdoc))
+		}
+		e.out.Interfaces = append(e.out.Interfaces, iface)
+	}
+}
+
+// astField returns the field declared at pos, to find its comments.
+func astField(fields []*ast.Field, pos token.Pos) *ast.Field {
+	for _, f := range fields {
+		for _, name := range f.Names {
+			if name.Pos() == pos {
+				return f
+			}
+		}
+		if len(f.Names) == 0 && f.Type.Pos() <= pos && pos < f.Type.End() {
+			return f
+		}
+	}
+	return nil
+}
+
+func (e *extractor) function(fn *types.Func, doc *ast.CommentGroup) *Func {
+	sig := fn.Type().(*types.Signature)
+	f := &Func{Name: fn.Name(), Doc: docText(doc), Variadic: sig.Variadic()}
+
+	if recv := sig.Recv(); recv != nil {
+		t := recv.Type()
+		if ptr, ok := t.(*types.Pointer); ok {
+			f.PointerReceiver = true
+			t = ptr.Elem()
+		}
+		if named, ok := t.(*types.Named); ok {
+			f.Receiver = named.Obj().Name()
+		}
+	}
+
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		v := params.At(i)
+		name := v.Name()
+		if name == "" || name == "_" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		typ := e.typeString(v.Type())
+		if f.Variadic && i == params.Len()-1 {
+			typ = "..." + strings.TrimPrefix(typ, "[]")
+		}
+		f.Params = append(f.Params, &Var{Name: name, Type: typ})
+	}
+	results := sig.Results()
+	for i := 0; i < results.Len(); i++ {
+		v := results.At(i)
+		f.Results = append(f.Results, &Var{Name: v.Name(), Type: e.typeString(v.Type())})
+	}
+	return f
+}
+
+func (e *extractor) typeParams(list *types.TypeParamList) []*TypeParam {
+	var params []*TypeParam
+	for i := 0; i < list.Len(); i++ {
+		tp := list.At(i)
+		params = append(params, &TypeParam{Name: tp.Obj().Name(), Constraint: e.typeString(tp.Constraint())})
+	}
+	return params
+}
+
+// typeString writes t relative to the loaded package and records the
+// packages it refers to.
+func (e *extractor) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p.Path() == e.pkg.PkgPath {
+			return ""
+		}
+		e.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+func docText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	return strings.TrimSpace(doc.Text())
+}
@@ -0,0 +1,187 @@
+package gosource
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const storeSource = `// Package store persists users.
+package store
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// User is an account.
+type User struct {
+	// ID is the primary key.
+	ID      string    ` + "`json:\"id\" db:\"user_id\"`" + `
+	Name    string    // display name
+	Created time.Time ` + "`json:\"created,omitempty\"`" + `
+	secret  string
+	Base
+}
+
+// Base holds common fields.
+type Base struct{ Version int }
+
+// Store loads and saves users.
+type Store interface {
+	io.Closer
+	// Get returns the user with id.
+	Get(ctx context.Context, id string) (*User, error)
+	Save(context.Context, *User) error
+	find(name string) []User
+}
+
+// Cache is a generic cache.
+type Cache[K comparable, V any] struct {
+	items map[K]V
+}
+
+// Put stores v under k.
+func (c *Cache[K, V]) Put(k K, v V) {}
+
+// Tags joins tags.
+func Tags(sep string, tags ...string) (joined string, n int) { return "", 0 }
+
+func (u User) Display() string { return u.Name }
+
+func helper() {}
+
+type Alias = User
+`
+
+func writeModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module example.com/app\n\ngo 1.22\n",
+		"store/store.go": storeSource,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadPackage(t *testing.T) {
+	pkg, err := LoadPackage("./store", WithDir(writeModule(t)))
+	if err != nil {
+		t.Fatalf("LoadPackage() error = %v", err)
+	}
+
+	if pkg.Name != "store" || pkg.Path != "example.com/app/store" || pkg.Doc != "Package store persists users." {
+		t.Errorf("package = %q %q %q", pkg.Name, pkg.Path, pkg.Doc)
+	}
+
+	var names []string
+	for _, s := range pkg.Structs {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "User,Base,Cache" {
+		t.Errorf("structs = %v", names)
+	}
+
+	user := pkg.Struct("User")
+	if user.Doc != "User is an account." {
+		t.Errorf("User doc = %q", user.Doc)
+	}
+	var fields []string
+	for _, f := range user.Fields {
+		fields = append(fields, f.Name+" "+f.Type)
+	}
+	if got := strings.Join(fields, ", "); got != "ID string, Name string, Created time.Time, Base Base" {
+		t.Errorf("User fields = %s", got)
+	}
+	id := user.Fields[0]
+	if id.Doc != "ID is the primary key." || id.TagValue("json") != "id" || id.TagValue("db") != "user_id" {
+		t.Errorf("ID field = %+v", id)
+	}
+	if user.Fields[1].Doc != "display name" || !user.Fields[3].Embedded {
+		t.Errorf("fields = %+v, %+v", user.Fields[1], user.Fields[3])
+	}
+	if len(user.Methods) != 1 || user.Methods[0].Name != "Display" || user.Methods[0].PointerReceiver {
+		t.Errorf("User methods = %+v", user.Methods)
+	}
+
+	cache := pkg.Struct("Cache")
+	if len(cache.TypeParams) != 2 || cache.TypeParams[0].Constraint != "comparable" || len(cache.Fields) != 0 {
+		t.Errorf("Cache = %+v", cache)
+	}
+	if put := cache.Methods[0]; put.Receiver != "Cache" || !put.PointerReceiver || put.Signature() != "(k K, v V)" {
+		t.Errorf("Put = %+v, signature %q", put, put.Signature())
+	}
+
+	store := pkg.Interface("Store")
+	if store == nil || len(store.Embeds) != 1 || store.Embeds[0] != "io.Closer" {
+		t.Fatalf("Store = %+v", store)
+	}
+	var methods []string
+	for _, m := range store.Methods {
+		methods = append(methods, m.Name+m.Signature())
+	}
+	want := "Close() error, Get(ctx context.Context, id string) (*User, error), Save(arg0 context.Context, arg1 *User) error"
+	if got := strings.Join(methods, ", "); got != want {
+		t.Errorf("Store methods = %s\nwant %s", got, want)
+	}
+	if store.Methods[1].Doc != "Get returns the user with id." || store.Methods[1].Args() != "ctx, id" {
+		t.Errorf("Get = %+v", store.Methods[1])
+	}
+
+	if len(pkg.Funcs) != 1 {
+		t.Fatalf("funcs = %+v", pkg.Funcs)
+	}
+	tags := pkg.Funcs[0]
+	if tags.Signature() != "(sep string, tags ...string) (joined string, n int)" || tags.Args() != "sep, tags..." {
+		t.Errorf("Tags signature = %q, args = %q", tags.Signature(), tags.Args())
+	}
+
+	var imports []string
+	for _, imp := range pkg.Imports {
+		imports = append(imports, imp.Path)
+	}
+	if got := strings.Join(imports, ","); got != "context,io,time" {
+		t.Errorf("imports = %s", got)
+	}
+}
+
+func TestLoadUnexported(t *testing.T) {
+	pkg, err := LoadPackage("./store", WithDir(writeModule(t)), WithUnexported())
+	if err != nil {
+		t.Fatalf("LoadPackage() error = %v", err)
+	}
+	if f := pkg.Struct("User").Fields[3]; f.Name != "secret" || f.Exported {
+		t.Errorf("field 3 = %+v", f)
+	}
+	if len(pkg.Interface("Store").Methods) != 4 || len(pkg.Funcs) != 2 {
+		t.Errorf("unexported methods or funcs missing")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := writeModule(t)
+	if _, err := LoadPackage("./missing", WithDir(dir)); err == nil {
+		t.Error("expected error for a missing package")
+	}
+	if _, err := LoadPackage("./...", WithDir(dir)); err != nil {
+		t.Errorf("single package module: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "store", "broken.go"), []byte("package store\n\nvar x int = \"s\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPackage("./store", WithDir(dir)); err == nil || !strings.Contains(err.Error(), "failed to load Go packages") {
+		t.Errorf("expected type error, got %v", err)
+	}
+}
@@ -0,0 +1,559 @@
+// Package openapi imports OpenAPI 3 documents into a Go model for code
+// generation.
+//
+// The model resolves every $ref, including references to other files, and
+// merges allOf compositions, so templates can range over endpoints and
+// schemas without knowing OpenAPI:
+//
+//	spec, err := openapi.Load("petstore.yaml")
+//	if err != nil {
+//		return err
+//	}
+//	err = eng.RenderDir(ctx, "templates", spec)
+package openapi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is an imported OpenAPI document.
+type Spec struct {
+	OpenAPI     string // the document's OpenAPI version, such as "3.0.3"
+	Title       string
+	Version     string
+	Description string
+	Servers     []Server
+	// Endpoints lists the operations in document order.
+	Endpoints []*Endpoint
+	// Schemas lists the component schemas and the named inline object
+	// schemas, sorted by name.
+	Schemas []*Schema
+	// SecuritySchemes lists the component security schemes, sorted by name.
+	SecuritySchemes []*SecurityScheme
+	// Security is the default security of every endpoint.
+	Security []SecurityRequirement
+}
+
+// Server is a base URL of the API.
+type Server struct {
+	URL         string
+	Description string
+	Variables   map[string]ServerVariable
+}
+
+// ServerVariable is a substitution variable in a server URL.
+type ServerVariable struct {
+	Default     string
+	Enum        []string
+	Description string
+}
+
+// Endpoint is an operation: one method on one path.
+type Endpoint struct {
+	// Name is the operationId as an exported Go identifier, or one
+	// derived from the method and path, such as "GetPetsByID".
+	Name        string
+	OperationID string
+	Method      string // upper case, such as "GET"
+	Path        string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	// Parameters includes the parameters declared on the path.
+	Parameters  []*Parameter
+	RequestBody *RequestBody
+	// Responses are sorted by status code, with "default" last.
+	Responses []*Response
+	// Security is the operation's security, or the spec's default. An
+	// empty, non-nil slice means the operation is unauthenticated.
+	Security []SecurityRequirement
+}
+
+// Parameter is a path, query, header or cookie parameter.
+type Parameter struct {
+	Name        string
+	In          string // "path", "query", "header" or "cookie"
+	Description string
+	Required    bool
+	Deprecated  bool
+	Schema      *Schema
+}
+
+// RequestBody is the body of a request.
+type RequestBody struct {
+	Description string
+	Required    bool
+	// ContentType is the preferred media type, JSON if there is one, and
+	// Schema its schema.
+	ContentType string
+	Schema      *Schema
+	// Content maps every media type to its schema.
+	Content map[string]*Schema
+}
+
+// Response is a response to an operation.
+type Response struct {
+	Status      string // a status code, a range such as "2XX", or "default"
+	Description string
+	ContentType string
+	Schema      *Schema
+	Content     map[string]*Schema
+}
+
+// SecurityScheme is a way of authenticating requests.
+type SecurityScheme struct {
+	Name             string
+	Type             string // "apiKey", "http", "oauth2", "openIdConnect" or "mutualTLS"
+	Description      string
+	Scheme           string // for http: "bearer", "basic", ...
+	BearerFormat     string
+	In               string // for apiKey: "header", "query" or "cookie"
+	ParamName        string // for apiKey: the header, query or cookie name
+	OpenIDConnectURL string
+	Flows            []OAuthFlow
+}
+
+// OAuthFlow is an OAuth 2 flow of an oauth2 security scheme.
+type OAuthFlow struct {
+	Kind             string // "implicit", "password", "clientCredentials" or "authorizationCode"
+	AuthorizationURL string
+	TokenURL         string
+	RefreshURL       string
+	Scopes           map[string]string
+}
+
+// SecurityRequirement maps the names of security schemes that must all be
+// satisfied to the scopes they need.
+type SecurityRequirement map[string][]string
+
+// Schema returns the schema called name, or nil.
+func (s *Spec) Schema(name string) *Schema {
+	for _, schema := range s.Schemas {
+		if schema.Name == name {
+			return schema
+		}
+	}
+	return nil
+}
+
+// SuccessResponse returns the first 2xx response, or nil.
+func (e *Endpoint) SuccessResponse() *Response {
+	for _, r := range e.Responses {
+		if strings.HasPrefix(r.Status, "2") {
+			return r
+		}
+	}
+	return nil
+}
+
+// Load imports the OpenAPI 3 document at path, in YAML or JSON. Relative
+// references to other files resolve against the directory of path.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI document: %w", err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := parse(data, abs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return spec, nil
+}
+
+// Parse imports an OpenAPI 3 document in YAML or JSON. Relative references
+// to other files resolve against the working directory.
+func Parse(data []byte) (*Spec, error) {
+	return parse(data, "")
+}
+
+// importer holds the state of one import.
+type importer struct {
+	main    *document
+	docs    map[string]*document
+	schemas map[string]*Schema // by reference key
+	names   map[string]bool    // schema names in use
+	spec    *Spec
+}
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+func parse(data []byte, path string) (*Spec, error) {
+	root, err := parseDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: not a mapping")
+	}
+	version := stringValue(root, "openapi")
+	if !strings.HasPrefix(version, "3.") {
+		if swagger := stringValue(root, "swagger"); swagger != "" {
+			return nil, fmt.Errorf("swagger %s documents are not supported; convert them to OpenAPI 3", swagger)
+		}
+		return nil, fmt.Errorf("not an OpenAPI 3 document: openapi is %q", version)
+	}
+
+	main := &document{path: path, root: root}
+	im := &importer{
+		main:    main,
+		docs:    map[string]*document{path: main},
+		schemas: make(map[string]*Schema),
+		names:   make(map[string]bool),
+		spec:    &Spec{OpenAPI: version},
+	}
+	if err := im.importSpec(); err != nil {
+		return nil, err
+	}
+	return im.spec, nil
+}
+
+func (im *importer) importSpec() error {
+	root := im.main.root
+	info := mappingValue(root, "info")
+	im.spec.Title = stringValue(info, "title")
+	im.spec.Version = stringValue(info, "version")
+	im.spec.Description = stringValue(info, "description")
+
+	if servers := mappingValue(root, "servers"); servers != nil {
+		for _, node := range servers.Content {
+			server := Server{URL: stringValue(node, "url"), Description: stringValue(node, "description")}
+			if vars := mappingValue(node, "variables"); vars != nil {
+				server.Variables = make(map[string]ServerVariable)
+				for i := 0; i+1 < len(vars.Content); i += 2 {
+					v := vars.Content[i+1]
+					sv := ServerVariable{Default: stringValue(v, "default"), Description: stringValue(v, "description")}
+					if enum := mappingValue(v, "enum"); enum != nil {
+						for _, e := range enum.Content {
+							sv.Enum = append(sv.Enum, e.Value)
+						}
+					}
+					server.Variables[vars.Content[i].Value] = sv
+				}
+			}
+			im.spec.Servers = append(im.spec.Servers, server)
+		}
+	}
+
+	components := mappingValue(root, "components")
+	schemas := mappingValue(components, "schemas")
+	if schemas != nil {
+		// Reserve component names so inline schemas don't take them.
+		for i := 0; i+1 < len(schemas.Content); i += 2 {
+			im.names[schemas.Content[i].Value] = true
+		}
+		for i := 0; i+1 < len(schemas.Content); i += 2 {
+			ref := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Value: "$ref"},
+				{Kind: yaml.ScalarNode, Value: "#/components/schemas/" + escapePointer(schemas.Content[i].Value)},
+			}}
+			if _, err := im.schema(im.main, ref, ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	if schemes := mappingValue(components, "securitySchemes"); schemes != nil {
+		for i := 0; i+1 < len(schemes.Content); i += 2 {
+			scheme, err := im.securityScheme(schemes.Content[i].Value, schemes.Content[i+1])
+			if err != nil {
+				return err
+			}
+			im.spec.SecuritySchemes = append(im.spec.SecuritySchemes, scheme)
+		}
+		sort.Slice(im.spec.SecuritySchemes, func(i, j int) bool {
+			return im.spec.SecuritySchemes[i].Name < im.spec.SecuritySchemes[j].Name
+		})
+	}
+	im.spec.Security = securityRequirements(mappingValue(root, "security"))
+
+	if paths := mappingValue(root, "paths"); paths != nil {
+		for i := 0; i+1 < len(paths.Content); i += 2 {
+			if err := im.pathItem(paths.Content[i].Value, paths.Content[i+1]); err != nil {
+				return err
+			}
+		}
+	}
+
+	sort.SliceStable(im.spec.Schemas, func(i, j int) bool {
+		return im.spec.Schemas[i].Name < im.spec.Schemas[j].Name
+	})
+	return nil
+}
+
+func (im *importer) pathItem(path string, node *yaml.Node) error {
+	doc, item, _, err := im.deref(im.main, node)
+	if err != nil {
+		return err
+	}
+
+	var shared []*Parameter
+	if params := mappingValue(item, "parameters"); params != nil {
+		if shared, err = im.parameters(doc, params, exportedName(path)); err != nil {
+			return err
+		}
+	}
+
+	for _, method := range methods {
+		op := mappingValue(item, method)
+		if op == nil {
+			continue
+		}
+
+		e := &Endpoint{
+			OperationID: stringValue(op, "operationId"),
+			Method:      strings.ToUpper(method),
+			Path:        path,
+			Summary:     stringValue(op, "summary"),
+			Description: stringValue(op, "description"),
+			Deprecated:  boolValue(op, "deprecated"),
+			Security:    im.spec.Security,
+		}
+		e.Name = exportedName(e.OperationID)
+		if e.Name == "" {
+			e.Name = endpointName(method, path)
+		}
+		if tags := mappingValue(op, "tags"); tags != nil {
+			for _, t := range tags.Content {
+				e.Tags = append(e.Tags, t.Value)
+			}
+		}
+		if security := mappingValue(op, "security"); security != nil {
+			e.Security = securityRequirements(security)
+		}
+
+		e.Parameters = append(e.Parameters, shared...)
+		if params := mappingValue(op, "parameters"); params != nil {
+			own, err := im.parameters(doc, params, e.Name)
+			if err != nil {
+				return err
+			}
+			// Operation parameters override path parameters with the
+			// same name and location.
+			for _, p := range own {
+				if i := indexParameter(e.Parameters, p); i >= 0 {
+					e.Parameters[i] = p
+				} else {
+					e.Parameters = append(e.Parameters, p)
+				}
+			}
+		}
+
+		if body := mappingValue(op, "requestBody"); body != nil {
+			if e.RequestBody, err = im.requestBody(doc, body, e.Name+"Request"); err != nil {
+				return err
+			}
+		}
+		if responses := mappingValue(op, "responses"); responses != nil {
+			if e.Responses, err = im.responses(doc, responses, e.Name); err != nil {
+				return err
+			}
+		}
+		im.spec.Endpoints = append(im.spec.Endpoints, e)
+	}
+	return nil
+}
+
+func (im *importer) parameters(doc *document, list *yaml.Node, hint string) ([]*Parameter, error) {
+	var params []*Parameter
+	for _, node := range list.Content {
+		pdoc, pnode, _, err := im.deref(doc, node)
+		if err != nil {
+			return nil, err
+		}
+		p := &Parameter{
+			Name:        stringValue(pnode, "name"),
+			In:          stringValue(pnode, "in"),
+			Description: stringValue(pnode, "description"),
+			Required:    boolValue(pnode, "required"),
+			Deprecated:  boolValue(pnode, "deprecated"),
+		}
+		schema := mappingValue(pnode, "schema")
+		if schema == nil {
+			// Parameters may use content instead of schema.
+			_, schema = preferredContent(mappingValue(pnode, "content"))
+		}
+		if schema != nil {
+			if p.Schema, err = im.schema(pdoc, schema, hint+exportedName(p.Name)); err != nil {
+				return nil, err
+			}
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+func indexParameter(params []*Parameter, p *Parameter) int {
+	for i, q := range params {
+		if q.Name == p.Name && q.In == p.In {
+			return i
+		}
+	}
+	return -1
+}
+
+func (im *importer) requestBody(doc *document, node *yaml.Node, hint string) (*RequestBody, error) {
+	doc, node, _, err := im.deref(doc, node)
+	if err != nil {
+		return nil, err
+	}
+	body := &RequestBody{
+		Description: stringValue(node, "description"),
+		Required:    boolValue(node, "required"),
+	}
+	body.ContentType, body.Schema, body.Content, err = im.content(doc, mappingValue(node, "content"), hint)
+	return body, err
+}
+
+func (im *importer) responses(doc *document, node *yaml.Node, name string) ([]*Response, error) {
+	var responses []*Response
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		status := node.Content[i].Value
+		rdoc, rnode, _, err := im.deref(doc, node.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+
+		hint := name + "Response"
+		if !strings.HasPrefix(status, "2") {
+			hint = name + exportedName(status) + "Response"
+		}
+		r := &Response{Status: status, Description: stringValue(rnode, "description")}
+		if r.ContentType, r.Schema, r.Content, err = im.content(rdoc, mappingValue(rnode, "content"), hint); err != nil {
+			return nil, err
+		}
+		responses = append(responses, r)
+	}
+
+	sort.SliceStable(responses, func(i, j int) bool {
+		a, b := responses[i].Status, responses[j].Status
+		if a == "default" || b == "default" {
+			return b == "default" && a != "default"
+		}
+		return a < b
+	})
+	return responses, nil
+}
+
+// content builds the schemas of a content map and picks the preferred one.
+func (im *importer) content(doc *document, node *yaml.Node, hint string) (string, *Schema, map[string]*Schema, error) {
+	if node == nil {
+		return "", nil, nil, nil
+	}
+	preferred, _ := preferredContent(node)
+	content := make(map[string]*Schema)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		mediaType := node.Content[i].Value
+		schemaNode := mappingValue(node.Content[i+1], "schema")
+		if schemaNode == nil {
+			content[mediaType] = nil
+			continue
+		}
+		schema, err := im.schema(doc, schemaNode, hint)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		content[mediaType] = schema
+	}
+	return preferred, content[preferred], content, nil
+}
+
+// preferredContent returns the media type to use from a content map, the
+// first JSON type if there is one, and its schema node.
+func preferredContent(node *yaml.Node) (string, *yaml.Node) {
+	if node == nil || len(node.Content) == 0 {
+		return "", nil
+	}
+	best := 0
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		mediaType := node.Content[i].Value
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			best = i
+			break
+		}
+	}
+	return node.Content[best].Value, mappingValue(node.Content[best+1], "schema")
+}
+
+func (im *importer) securityScheme(name string, node *yaml.Node) (*SecurityScheme, error) {
+	_, node, _, err := im.deref(im.main, node)
+	if err != nil {
+		return nil, err
+	}
+	scheme := &SecurityScheme{
+		Name:             name,
+		Type:             stringValue(node, "type"),
+		Description:      stringValue(node, "description"),
+		Scheme:           strings.ToLower(stringValue(node, "scheme")),
+		BearerFormat:     stringValue(node, "bearerFormat"),
+		In:               stringValue(node, "in"),
+		ParamName:        stringValue(node, "name"),
+		OpenIDConnectURL: stringValue(node, "openIdConnectUrl"),
+	}
+	if flows := mappingValue(node, "flows"); flows != nil {
+		for i := 0; i+1 < len(flows.Content); i += 2 {
+			f := flows.Content[i+1]
+			flow := OAuthFlow{
+				Kind:             flows.Content[i].Value,
+				AuthorizationURL: stringValue(f, "authorizationUrl"),
+				TokenURL:         stringValue(f, "tokenUrl"),
+				RefreshURL:       stringValue(f, "refreshUrl"),
+				Scopes:           make(map[string]string),
+			}
+			if scopes := mappingValue(f, "scopes"); scopes != nil {
+				for j := 0; j+1 < len(scopes.Content); j += 2 {
+					flow.Scopes[scopes.Content[j].Value] = scopes.Content[j+1].Value
+				}
+			}
+			scheme.Flows = append(scheme.Flows, flow)
+		}
+	}
+	return scheme, nil
+}
+
+func securityRequirements(node *yaml.Node) []SecurityRequirement {
+	if node == nil {
+		return nil
+	}
+	requirements := []SecurityRequirement{}
+	for _, item := range node.Content {
+		req := make(SecurityRequirement)
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			scopes := []string{}
+			for _, scope := range item.Content[i+1].Content {
+				scopes = append(scopes, scope.Value)
+			}
+			req[item.Content[i].Value] = scopes
+		}
+		requirements = append(requirements, req)
+	}
+	return requirements
+}
+
+// endpointName derives a name for an operation without an operationId:
+// "get /pets/{petId}" becomes "GetPetsByPetID".
+func endpointName(method, path string) string {
+	name := exportedName(method)
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name += "By" + exportedName(strings.Trim(segment, "{}"))
+		} else {
+			name += exportedName(segment)
+		}
+	}
+	return name
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
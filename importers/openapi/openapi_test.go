@@ -0,0 +1,345 @@
+package openapi
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.2.0
+servers:
+  - url: https://{region}.example.com/v1
+    variables:
+      region: {default: eu, enum: [eu, us]}
+security:
+  - bearerAuth: []
+paths:
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetID'
+    get:
+      operationId: get-pet
+      tags: [pets]
+      responses:
+        default:
+          $ref: '#/components/responses/Error'
+        '404':
+          description: Not found
+        '200':
+          description: A pet
+          content:
+            application/xml:
+              schema: {$ref: '#/components/schemas/Pet'}
+            application/json:
+              schema: {$ref: '#/components/schemas/Pet'}
+    delete:
+      security: []
+      parameters:
+        - {name: petId, in: path, required: true, description: Override, schema: {type: integer, format: int32}}
+      responses:
+        '204': {description: Deleted}
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string}
+                tags: {type: array, items: {type: string}}
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: '#/components/schemas/Pet'}
+components:
+  parameters:
+    PetID:
+      name: petId
+      in: path
+      required: true
+      schema: {type: string}
+  responses:
+    Error:
+      description: Error
+      content:
+        application/json:
+          schema:
+            type: object
+            properties:
+              message: {type: string}
+  securitySchemes:
+    bearerAuth: {type: http, scheme: Bearer, bearerFormat: JWT}
+    apiKey: {type: apiKey, in: header, name: X-API-Key}
+  schemas:
+    Pet:
+      allOf:
+        - $ref: '#/components/schemas/Base'
+        - type: object
+          required: [kind]
+          properties:
+            kind: {type: string, enum: [cat, dog]}
+            owner:
+              type: object
+              properties:
+                name: {type: string}
+            parent: {$ref: '#/components/schemas/Pet'}
+            born: {type: string, format: date-time, nullable: true}
+            labels: {type: object, additionalProperties: {type: string}}
+    Base:
+      type: object
+      required: [id]
+      properties:
+        id: {type: integer, format: int64}
+    Animal:
+      oneOf:
+        - $ref: '#/components/schemas/Pet'
+        - {type: object, properties: {wild: {type: boolean}}}
+      discriminator:
+        propertyName: kind
+        mapping:
+          pet: '#/components/schemas/Pet'
+          base: Base
+`
+
+func TestParse(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if spec.Title != "Petstore" || spec.Version != "1.2.0" || spec.OpenAPI != "3.0.3" {
+		t.Errorf("info = %q %q %q", spec.Title, spec.Version, spec.OpenAPI)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0].Variables["region"].Default != "eu" {
+		t.Errorf("servers = %+v", spec.Servers)
+	}
+
+	var names []string
+	for _, e := range spec.Endpoints {
+		names = append(names, e.Method+" "+e.Path+" "+e.Name)
+	}
+	want := []string{"GET /pets/{petId} GetPet", "DELETE /pets/{petId} DeletePetsByPetID", "POST /pets CreatePet"}
+	if strings.Join(names, ", ") != strings.Join(want, ", ") {
+		t.Errorf("endpoints = %v, want %v", names, want)
+	}
+
+	get, del, create := spec.Endpoints[0], spec.Endpoints[1], spec.Endpoints[2]
+	if len(get.Parameters) != 1 || get.Parameters[0].Schema.GoType() != "string" || !get.Parameters[0].Required {
+		t.Errorf("get parameters = %+v", get.Parameters)
+	}
+	if len(del.Parameters) != 1 || del.Parameters[0].Description != "Override" || del.Parameters[0].Schema.GoType() != "int32" {
+		t.Errorf("delete parameters = %+v", del.Parameters)
+	}
+
+	var statuses []string
+	for _, r := range get.Responses {
+		statuses = append(statuses, r.Status)
+	}
+	if strings.Join(statuses, ",") != "200,404,default" {
+		t.Errorf("statuses = %v", statuses)
+	}
+	ok := get.SuccessResponse()
+	if ok == nil || ok.ContentType != "application/json" || ok.Schema != spec.Schema("Pet") || len(ok.Content) != 2 {
+		t.Errorf("success response = %+v", ok)
+	}
+	if errSchema := get.Responses[2].Schema; errSchema == nil || errSchema.Name != "GetPetDefaultResponse" {
+		t.Errorf("default response schema = %+v", errSchema)
+	}
+
+	if len(get.Security) != 1 || get.Security[0]["bearerAuth"] == nil {
+		t.Errorf("get security = %v", get.Security)
+	}
+	if del.Security == nil || len(del.Security) != 0 {
+		t.Errorf("delete security = %v, want empty", del.Security)
+	}
+
+	body := create.RequestBody
+	if body == nil || !body.Required || body.Schema.Name != "CreatePetRequest" {
+		t.Fatalf("request body = %+v", body)
+	}
+	if p := body.Schema.Property("name"); p == nil || !p.Required {
+		t.Errorf("request name property = %+v", p)
+	}
+	if got := body.Schema.Property("tags").Schema.GoType(); got != "[]string" {
+		t.Errorf("tags type = %q", got)
+	}
+	if got := create.SuccessResponse().Schema.GoType(); got != "[]Pet" {
+		t.Errorf("create response type = %q", got)
+	}
+}
+
+func TestParseSchemas(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var names []string
+	for _, s := range spec.Schemas {
+		names = append(names, s.Name)
+	}
+	want := "Animal,AnimalOption2,Base,CreatePetRequest,GetPetDefaultResponse,Pet,PetOwner"
+	if strings.Join(names, ",") != want {
+		t.Errorf("schemas = %v, want %s", names, want)
+	}
+
+	pet := spec.Schema("Pet")
+	var props []string
+	for _, p := range pet.Properties {
+		props = append(props, p.Name)
+	}
+	if strings.Join(props, ",") != "id,kind,owner,parent,born,labels" {
+		t.Errorf("Pet properties = %v", props)
+	}
+	if pet.Type != "object" || strings.Join(pet.Required, ",") != "id,kind" {
+		t.Errorf("Pet type = %q, required = %v", pet.Type, pet.Required)
+	}
+
+	tests := map[string]string{
+		"id":     "int64",
+		"kind":   "string",
+		"owner":  "PetOwner",
+		"parent": "Pet",
+		"born":   "time.Time",
+		"labels": "map[string]string",
+	}
+	for name, goType := range tests {
+		if got := pet.Property(name).Schema.GoType(); got != goType {
+			t.Errorf("Pet.%s type = %q, want %q", name, got, goType)
+		}
+	}
+	if pet.Property("parent").Schema != pet {
+		t.Error("recursive reference does not point back to Pet")
+	}
+	if !pet.Property("born").Schema.Nullable || len(pet.Property("kind").Schema.Enum) != 2 {
+		t.Error("nullable or enum not imported")
+	}
+
+	animal := spec.Schema("Animal")
+	if len(animal.OneOf) != 2 || animal.OneOf[0] != pet || animal.GoType() != "Animal" {
+		t.Errorf("Animal oneOf = %+v", animal.OneOf)
+	}
+	d := animal.Discriminator
+	if d == nil || d.PropertyName != "kind" || d.Mapping["pet"] != pet || d.Mapping["base"] != spec.Schema("Base") {
+		t.Errorf("discriminator = %+v", d)
+	}
+
+	if len(spec.SecuritySchemes) != 2 {
+		t.Fatalf("security schemes = %+v", spec.SecuritySchemes)
+	}
+	apiKey, bearer := spec.SecuritySchemes[0], spec.SecuritySchemes[1]
+	if apiKey.In != "header" || apiKey.ParamName != "X-API-Key" || bearer.Scheme != "bearer" || bearer.BearerFormat != "JWT" {
+		t.Errorf("security schemes = %+v, %+v", apiKey, bearer)
+	}
+}
+
+func TestLoadExternalRefs(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("api.yaml", `
+openapi: 3.1.0
+info: {title: Users, version: "1"}
+paths:
+  /users:
+    $ref: paths/users.yaml
+`)
+	write("paths/users.yaml", `
+get:
+  responses:
+    '200':
+      description: OK
+      content:
+        application/json:
+          schema: {$ref: '../schemas.json#/User'}
+`)
+	write("schemas.json", `{"User": {"type": "object", "properties": {"email": {"type": ["string", "null"]}}}}`)
+
+	spec, err := Load(filepath.Join(dir, "api.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(spec.Endpoints) != 1 || spec.Endpoints[0].Name != "GetUsers" {
+		t.Fatalf("endpoints = %+v", spec.Endpoints)
+	}
+	user := spec.Endpoints[0].SuccessResponse().Schema
+	if user == nil || user.Name != "User" || spec.Schema("User") != user {
+		t.Fatalf("user schema = %+v", user)
+	}
+	email := user.Property("email").Schema
+	if email.Type != "string" || !email.Nullable {
+		t.Errorf("email = %+v", email)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"swagger", "swagger: '2.0'\ninfo: {}", "swagger 2.0 documents are not supported"},
+		{"not openapi", "title: x", "not an OpenAPI 3 document"},
+		{"syntax", "openapi: [", "failed to parse OpenAPI document"},
+		{
+			"unresolved",
+			"openapi: 3.0.0\npaths:\n  /a:\n    get:\n      responses:\n        '200': {$ref: '#/components/responses/Missing'}",
+			`line 6, column 16: unresolved reference "#/components/responses/Missing"`,
+		},
+		{
+			"circular",
+			"openapi: 3.0.0\ncomponents:\n  schemas:\n    A: {$ref: '#/components/schemas/B'}\n    B: {$ref: '#/components/schemas/A'}",
+			"is circular",
+		},
+		{
+			"missing file",
+			"openapi: 3.0.0\ncomponents:\n  schemas:\n    A: {$ref: 'missing.yaml'}",
+			"failed to read referenced file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	tests := map[string]string{
+		"pet_id":       "PetID",
+		"list-pets":    "ListPets",
+		"getPetById":   "GetPetByID",
+		"HTTPResponse": "HTTPResponse",
+		"404":          "X404",
+		"":             "",
+	}
+	for in, want := range tests {
+		if got := exportedName(in); got != want {
+			t.Errorf("exportedName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxRefDepth bounds chains of references to references.
+const maxRefDepth = 32
+
+// document is a parsed OpenAPI file. path is empty for documents parsed from
+// memory; their relative references resolve against the working directory.
+type document struct {
+	path string
+	root *yaml.Node
+}
+
+// location returns "line L, column C" for node, prefixed with the file name
+// for documents other than the one being imported.
+func (im *importer) location(doc *document, node *yaml.Node) string {
+	if doc != im.main && doc.path != "" {
+		return fmt.Sprintf("%s: line %d, column %d", filepath.Base(doc.path), node.Line, node.Column)
+	}
+	return fmt.Sprintf("line %d, column %d", node.Line, node.Column)
+}
+
+// deref follows $ref in node, if any, and returns the referenced node, the
+// document it is in and a key that identifies it across the import. The key
+// of a node that is not a reference is empty.
+func (im *importer) deref(doc *document, node *yaml.Node) (*document, *yaml.Node, string, error) {
+	key := ""
+	for depth := 0; ; depth++ {
+		ref, ok := refOf(node)
+		if !ok {
+			return doc, node, key, nil
+		}
+		if depth == maxRefDepth {
+			return nil, nil, "", fmt.Errorf("%s: reference %q is circular", im.location(doc, node), ref)
+		}
+
+		file, fragment, _ := strings.Cut(ref, "#")
+		target := doc
+		if file != "" {
+			loaded, err := im.load(doc, file)
+			if err != nil {
+				return nil, nil, "", fmt.Errorf("%s: %w", im.location(doc, node), err)
+			}
+			target = loaded
+		}
+
+		resolved, err := lookupPointer(target.root, fragment)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("%s: unresolved reference %q: %w", im.location(doc, node), ref, err)
+		}
+		doc, node, key = target, resolved, target.path+"#"+fragment
+	}
+}
+
+// refOf returns the value of node's $ref key.
+func refOf(node *yaml.Node) (string, bool) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return "", false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "$ref" {
+			return node.Content[i+1].Value, true
+		}
+	}
+	return "", false
+}
+
+// load parses the document that file, relative to from, refers to.
+func (im *importer) load(from *document, file string) (*document, error) {
+	if u, err := url.Parse(file); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		return nil, fmt.Errorf("remote reference %q is not supported", file)
+	}
+
+	path := file
+	if !filepath.IsAbs(path) && from.path != "" {
+		path = filepath.Join(filepath.Dir(from.path), file)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if doc, ok := im.docs[abs]; ok {
+		return doc, nil
+	}
+
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read referenced file: %w", err)
+	}
+	root, err := parseDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse referenced file %s: %w", file, err)
+	}
+	doc := &document{path: abs, root: root}
+	im.docs[abs] = doc
+	return doc, nil
+}
+
+// parseDocument parses YAML or JSON and returns the root node.
+func parseDocument(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, fmt.Errorf("document is empty")
+	}
+	return doc.Content[0], nil
+}
+
+// lookupPointer resolves a JSON pointer fragment, such as
+// "/components/schemas/Pet", against root.
+func lookupPointer(root *yaml.Node, fragment string) (*yaml.Node, error) {
+	fragment, err := url.PathUnescape(fragment)
+	if err != nil {
+		return nil, err
+	}
+	if fragment == "" {
+		return root, nil
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		return nil, fmt.Errorf("fragment must be a JSON pointer")
+	}
+
+	node := root
+	for _, token := range strings.Split(fragment[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch node.Kind {
+		case yaml.MappingNode:
+			next := mappingValue(node, token)
+			if next == nil {
+				return nil, fmt.Errorf("%q not found", token)
+			}
+			node = next
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return nil, fmt.Errorf("index %q out of range", token)
+			}
+			node = node.Content[i]
+		default:
+			return nil, fmt.Errorf("%q not found", token)
+		}
+	}
+	return node, nil
+}
+
+// pointerName returns the last token of a JSON pointer fragment, the name
+// of a component.
+func pointerName(fragment string) string {
+	fragment, _ = url.PathUnescape(fragment)
+	name := fragment[strings.LastIndex(fragment, "/")+1:]
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// stringValue returns the string value of key in a mapping node.
+func stringValue(node *yaml.Node, key string) string {
+	if v := mappingValue(node, key); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}
+
+// boolValue returns the boolean value of key in a mapping node.
+func boolValue(node *yaml.Node, key string) bool {
+	b, _ := strconv.ParseBool(stringValue(node, key))
+	return b
+}
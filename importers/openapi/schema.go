@@ -0,0 +1,417 @@
+package openapi
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is a resolved JSON Schema. References are replaced by the schema
+// they point to, so two properties that refer to the same component share
+// one *Schema, and recursive schemas form cycles.
+type Schema struct {
+	// Name is the component name for schemas under components/schemas and
+	// a generated name, such as "PetOwner", for inline object schemas with
+	// properties. It is empty for other inline schemas.
+	Name        string
+	Type        string // "object", "array", "string", "integer", "number", "boolean" or empty
+	Format      string
+	Description string
+	Nullable    bool
+	ReadOnly    bool
+	WriteOnly   bool
+	Deprecated  bool
+	Enum        []any
+	Default     any
+
+	Pattern   string
+	Minimum   *float64
+	Maximum   *float64
+	MinLength *int
+	MaxLength *int
+	MinItems  *int
+	MaxItems  *int
+
+	// Items is the element schema of an array.
+	Items *Schema
+	// Properties are the properties of an object in document order,
+	// including those inherited through allOf.
+	Properties []*Property
+	// Required lists the names of the required properties, including
+	// those required by allOf members.
+	Required []string
+	// AdditionalProperties is the value schema of a map-like object.
+	AdditionalProperties *Schema
+
+	AllOf         []*Schema
+	OneOf         []*Schema
+	AnyOf         []*Schema
+	Discriminator *Discriminator
+}
+
+// Property is a named property of an object schema.
+type Property struct {
+	Name     string
+	Schema   *Schema
+	Required bool
+}
+
+// Discriminator selects the oneOf or anyOf variant by a property value.
+type Discriminator struct {
+	PropertyName string
+	// Mapping maps property values to variants.
+	Mapping map[string]*Schema
+}
+
+// GoName returns the schema name as an exported Go identifier.
+func (s *Schema) GoName() string {
+	return exportedName(s.Name)
+}
+
+// GoType returns the Go type for values of the schema: the GoName of named
+// schemas, slices and maps of element types, time.Time for date-time
+// strings and any for schemas without a single type, such as oneOf.
+func (s *Schema) GoType() string {
+	if s == nil {
+		return "any"
+	}
+	if s.Name != "" {
+		return s.GoName()
+	}
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		return s.AllOf[0].GoType()
+	}
+
+	switch s.Type {
+	case "array":
+		return "[]" + s.Items.GoType()
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "map[string]" + s.AdditionalProperties.GoType()
+		}
+		return "map[string]any"
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "time.Time"
+		case "byte", "binary":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		if s.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if s.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	}
+	return "any"
+}
+
+// Property returns the property called name, or nil.
+func (s *Schema) Property(name string) *Property {
+	for _, p := range s.Properties {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// schema builds the schema for node. hint names the schema if it turns out
+// to be an inline object with properties.
+func (im *importer) schema(doc *document, node *yaml.Node, hint string) (*Schema, error) {
+	return im.buildSchema(doc, node, hint, true)
+}
+
+// buildSchema builds the schema for node, adding inline objects with
+// properties to the spec's schemas when hoist is set.
+func (im *importer) buildSchema(doc *document, node *yaml.Node, hint string, hoist bool) (*Schema, error) {
+	target, resolved, key, err := im.deref(doc, node)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		if s, ok := im.schemas[key]; ok {
+			return s, nil
+		}
+		_, fragment, _ := strings.Cut(key, "#")
+		name := pointerName(fragment)
+		if name == "" {
+			// The reference is to a whole file, such as "pet.yaml".
+			name = exportedName(strings.TrimSuffix(filepath.Base(target.path), filepath.Ext(target.path)))
+		}
+		// Register before filling so recursive references find it.
+		s := &Schema{Name: name}
+		im.schemas[key] = s
+		if target != im.main || fragment != "/components/schemas/"+escapePointer(name) {
+			s.Name = im.uniqueName(name)
+		}
+		im.spec.Schemas = append(im.spec.Schemas, s)
+		return s, im.fillSchema(s, target, resolved, s.Name)
+	}
+
+	s := &Schema{}
+	if err := im.fillSchema(s, target, resolved, hint); err != nil {
+		return nil, err
+	}
+	if hoist && len(s.Properties) > 0 && hint != "" {
+		s.Name = im.uniqueName(hint)
+		im.spec.Schemas = append(im.spec.Schemas, s)
+	}
+	return s, nil
+}
+
+func (im *importer) fillSchema(s *Schema, doc *document, node *yaml.Node, hint string) error {
+	if node.Kind != yaml.MappingNode {
+		// true and false are valid schemas in OpenAPI 3.1.
+		return nil
+	}
+
+	switch typ := mappingValue(node, "type"); {
+	case typ == nil:
+	case typ.Kind == yaml.SequenceNode:
+		var types []string
+		for _, t := range typ.Content {
+			if t.Value == "null" {
+				s.Nullable = true
+			} else {
+				types = append(types, t.Value)
+			}
+		}
+		if len(types) == 1 {
+			s.Type = types[0]
+		}
+	default:
+		s.Type = typ.Value
+	}
+
+	s.Format = stringValue(node, "format")
+	s.Description = stringValue(node, "description")
+	s.Pattern = stringValue(node, "pattern")
+	s.Nullable = s.Nullable || boolValue(node, "nullable")
+	s.ReadOnly = boolValue(node, "readOnly")
+	s.WriteOnly = boolValue(node, "writeOnly")
+	s.Deprecated = boolValue(node, "deprecated")
+	s.Minimum = floatValue(node, "minimum")
+	s.Maximum = floatValue(node, "maximum")
+	s.MinLength = intValue(node, "minLength")
+	s.MaxLength = intValue(node, "maxLength")
+	s.MinItems = intValue(node, "minItems")
+	s.MaxItems = intValue(node, "maxItems")
+
+	if v := mappingValue(node, "enum"); v != nil {
+		if err := v.Decode(&s.Enum); err != nil {
+			return fmt.Errorf("%s: invalid enum: %w", im.location(doc, v), err)
+		}
+	}
+	if v := mappingValue(node, "default"); v != nil {
+		if err := v.Decode(&s.Default); err != nil {
+			return fmt.Errorf("%s: invalid default: %w", im.location(doc, v), err)
+		}
+	}
+
+	var err error
+	if v := mappingValue(node, "items"); v != nil {
+		if s.Items, err = im.schema(doc, v, hint+"Item"); err != nil {
+			return err
+		}
+		if s.Type == "" {
+			s.Type = "array"
+		}
+	}
+	if v := mappingValue(node, "additionalProperties"); v != nil && v.Kind == yaml.MappingNode {
+		if s.AdditionalProperties, err = im.schema(doc, v, hint+"Value"); err != nil {
+			return err
+		}
+	}
+
+	// Inline allOf members are merged into s rather than named; oneOf and
+	// anyOf variants become types of their own.
+	if v := mappingValue(node, "allOf"); v != nil {
+		for _, item := range v.Content {
+			member, err := im.buildSchema(doc, item, hint, false)
+			if err != nil {
+				return err
+			}
+			s.AllOf = append(s.AllOf, member)
+		}
+	}
+	for _, c := range []struct {
+		key  string
+		list *[]*Schema
+	}{{"oneOf", &s.OneOf}, {"anyOf", &s.AnyOf}} {
+		v := mappingValue(node, c.key)
+		if v == nil {
+			continue
+		}
+		for i, item := range v.Content {
+			member, err := im.schema(doc, item, hint+"Option"+strconv.Itoa(i+1))
+			if err != nil {
+				return err
+			}
+			*c.list = append(*c.list, member)
+		}
+	}
+
+	// allOf members contribute their properties first, so a schema that
+	// extends a base lists the base's properties before its own.
+	for _, member := range s.AllOf {
+		for _, p := range member.Properties {
+			s.addProperty(p.Name, p.Schema)
+		}
+		s.Required = appendMissing(s.Required, member.Required...)
+		if member.Type == "object" && s.Type == "" {
+			s.Type = "object"
+		}
+	}
+
+	if v := mappingValue(node, "properties"); v != nil {
+		for i := 0; i+1 < len(v.Content); i += 2 {
+			name := v.Content[i].Value
+			prop, err := im.schema(doc, v.Content[i+1], hint+exportedName(name))
+			if err != nil {
+				return err
+			}
+			s.addProperty(name, prop)
+		}
+		if s.Type == "" {
+			s.Type = "object"
+		}
+	}
+	if v := mappingValue(node, "required"); v != nil {
+		for _, name := range v.Content {
+			s.Required = appendMissing(s.Required, name.Value)
+		}
+	}
+	for _, p := range s.Properties {
+		p.Required = slices.Contains(s.Required, p.Name)
+	}
+
+	if v := mappingValue(node, "discriminator"); v != nil {
+		s.Discriminator = &Discriminator{PropertyName: stringValue(v, "propertyName")}
+		if mapping := mappingValue(v, "mapping"); mapping != nil {
+			s.Discriminator.Mapping = make(map[string]*Schema)
+			for i := 0; i+1 < len(mapping.Content); i += 2 {
+				ref := mapping.Content[i+1].Value
+				if !strings.Contains(ref, "#") && !strings.Contains(ref, "/") {
+					// A bare name refers to a component schema.
+					ref = "#/components/schemas/" + ref
+				}
+				ptr := &yaml.Node{Kind: yaml.MappingNode, Line: mapping.Content[i+1].Line, Column: mapping.Content[i+1].Column,
+					Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "$ref"}, {Kind: yaml.ScalarNode, Value: ref}}}
+				variant, err := im.schema(doc, ptr, "")
+				if err != nil {
+					return err
+				}
+				s.Discriminator.Mapping[mapping.Content[i].Value] = variant
+			}
+		}
+	}
+	return nil
+}
+
+// addProperty adds a property or replaces the schema of an existing one.
+func (s *Schema) addProperty(name string, schema *Schema) {
+	if p := s.Property(name); p != nil {
+		p.Schema = schema
+		return
+	}
+	s.Properties = append(s.Properties, &Property{Name: name, Schema: schema})
+}
+
+// uniqueName returns name, or name with a numeric suffix if a schema is
+// already called name.
+func (im *importer) uniqueName(name string) string {
+	unique := name
+	for n := 2; im.names[unique]; n++ {
+		unique = fmt.Sprintf("%s%d", name, n)
+	}
+	im.names[unique] = true
+	return unique
+}
+
+func appendMissing(list []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func floatValue(node *yaml.Node, key string) *float64 {
+	f, err := strconv.ParseFloat(stringValue(node, key), 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+func intValue(node *yaml.Node, key string) *int {
+	i, err := strconv.Atoi(stringValue(node, key))
+	if err != nil {
+		return nil
+	}
+	return &i
+}
+
+// commonInitialisms are written in upper case in Go names.
+var commonInitialisms = map[string]bool{
+	"API": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "JWT": true, "SQL": true, "TLS": true, "TTL": true,
+	"UI": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// exportedName converts an OpenAPI name, such as "pet_id" or "list-pets",
+// to an exported Go identifier: "PetID", "ListPets".
+func exportedName(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])):
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	out := b.String()
+	if out != "" && unicode.IsDigit(rune(out[0])) {
+		out = "X" + out
+	}
+	return out
+}
@@ -0,0 +1,697 @@
+package sqlschema
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadDDL imports the schema from a SQL file, such as the output of
+// pg_dump --schema-only, mysqldump --no-data or sqlite3 .schema.
+func LoadDDL(path string, opts ...Option) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DDL file: %w", err)
+	}
+	return ParseDDL(string(data), opts...)
+}
+
+// ParseDDL imports the schema from SQL DDL. It understands CREATE TABLE,
+// ALTER TABLE ... ADD CONSTRAINT, CREATE UNIQUE INDEX and COMMENT ON in the
+// Postgres, MySQL and SQLite dialects, and ignores other statements.
+func ParseDDL(ddl string, opts ...Option) (*Schema, error) {
+	o := newOptions(opts)
+	tokens, err := tokenize(ddl)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ddlParser{schema: &Schema{Database: Database{Driver: string(o.dialect)}}}
+	for _, stmt := range splitStatements(tokens) {
+		if err := p.statement(stmt); err != nil {
+			return nil, fmt.Errorf("line %d: %w", stmt[0].line, err)
+		}
+	}
+
+	var tables []Table
+	for _, t := range p.schema.Tables {
+		if o.includes(t.Name) {
+			tables = append(tables, t)
+		}
+	}
+	p.schema.Tables = tables
+	return p.schema, nil
+}
+
+type tokenKind int
+
+const (
+	tokenIdent  tokenKind = iota // a bare or quoted identifier or keyword
+	tokenString                  // a 'string' literal, with its quotes
+	tokenNumber
+	tokenPunct
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	quoted bool // a quoted identifier; never a keyword
+	line   int
+}
+
+// is reports whether t is the unquoted keyword kw, case-insensitively.
+func (t token) is(kw string) bool {
+	return t.kind == tokenIdent && !t.quoted && strings.EqualFold(t.text, kw)
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "--") || c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '\'':
+			j := i + 1
+			for ; j < len(src); j++ {
+				if src[j] == '\\' {
+					j++
+				} else if src[j] == '\'' {
+					if j+1 < len(src) && src[j+1] == '\'' {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: src[i : j+1], line: line})
+			line += strings.Count(src[i:j], "\n")
+			i = j + 1
+		case strings.HasPrefix(src[i:], "[]"):
+			tokens = append(tokens, token{kind: tokenPunct, text: "[]", line: line})
+			i += 2
+		case c == '$' && dollarTag(src[i:]) != "":
+			// A Postgres dollar-quoted string, such as a function body.
+			tag := dollarTag(src[i:])
+			end := strings.Index(src[i+len(tag):], tag)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			j := i + len(tag) + end + len(tag)
+			tokens = append(tokens, token{kind: tokenString, text: src[i:j], line: line})
+			line += strings.Count(src[i:j], "\n")
+			i = j
+		case c == '"' || c == '`' || c == '[':
+			closer := map[byte]byte{'"': '"', '`': '`', '[': ']'}[c]
+			end := strings.IndexByte(src[i+1:], closer)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated identifier", line)
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[i+1 : i+1+end], quoted: true, line: line})
+			i += end + 2
+		case isIdentByte(c):
+			j := i
+			for j < len(src) && (isIdentByte(src[j]) || src[j] >= '0' && src[j] <= '9' || src[j] == '$') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[i:j], line: line})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[i:j], line: line})
+			i = j
+		case strings.HasPrefix(src[i:], "::"):
+			tokens = append(tokens, token{kind: tokenPunct, text: "::", line: line})
+			i += 2
+		default:
+			tokens = append(tokens, token{kind: tokenPunct, text: string(c), line: line})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// dollarTag returns the opening tag of a dollar-quoted string at the start
+// of s, such as "$$" or "$body$", or "".
+func dollarTag(s string) string {
+	for j := 1; j < len(s); j++ {
+		if s[j] == '$' {
+			return s[:j+1]
+		}
+		if !isIdentByte(s[j]) && !(j > 1 && s[j] >= '0' && s[j] <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// splitStatements splits tokens at top-level semicolons.
+func splitStatements(tokens []token) [][]token {
+	var stmts [][]token
+	start, depth := 0, 0
+	for i, t := range tokens {
+		switch {
+		case t.text == "(" && t.kind == tokenPunct:
+			depth++
+		case t.text == ")" && t.kind == tokenPunct:
+			depth--
+		case t.text == ";" && t.kind == tokenPunct && depth == 0:
+			if i > start {
+				stmts = append(stmts, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		stmts = append(stmts, tokens[start:])
+	}
+	return stmts
+}
+
+type ddlParser struct {
+	schema *Schema
+}
+
+func (p *ddlParser) statement(stmt []token) error {
+	c := &cursor{tokens: stmt}
+	switch {
+	case c.accept("CREATE"):
+		c.skipWords("OR", "REPLACE", "GLOBAL", "LOCAL", "TEMPORARY", "TEMP", "UNLOGGED")
+		if c.accept("TABLE") {
+			return p.createTable(c)
+		}
+		if c.accept("UNIQUE") {
+			return p.createUniqueIndex(c)
+		}
+	case c.accept("ALTER"):
+		if c.accept("TABLE") {
+			return p.alterTable(c)
+		}
+	case c.accept("COMMENT"):
+		if c.accept("ON") {
+			return p.comment(c)
+		}
+	}
+	return nil
+}
+
+func (p *ddlParser) createTable(c *cursor) error {
+	c.skipWords("IF", "NOT", "EXISTS")
+	name, err := c.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if !c.acceptPunct("(") {
+		// CREATE TABLE ... AS SELECT and the like define no columns.
+		return nil
+	}
+
+	p.schema.Tables = append(p.schema.Tables, Table{Name: name})
+	table := &p.schema.Tables[len(p.schema.Tables)-1]
+	for _, def := range c.list() {
+		d := &cursor{tokens: def}
+		if isTableConstraint(d) {
+			if err := p.tableConstraint(table, d); err != nil {
+				return fmt.Errorf("table %s: %w", name, err)
+			}
+			continue
+		}
+		field, err := p.column(table, d)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", name, err)
+		}
+		table.Fields = append(table.Fields, field)
+	}
+
+	// MySQL: ) ENGINE=InnoDB COMMENT='...'
+	for !c.done() {
+		if c.accept("COMMENT") {
+			c.acceptPunct("=")
+			table.Description = unquote(c.next().text)
+			continue
+		}
+		c.next()
+	}
+	return nil
+}
+
+func isTableConstraint(c *cursor) bool {
+	t := c.peek()
+	for _, kw := range []string{"CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "KEY", "INDEX", "FULLTEXT", "SPATIAL", "EXCLUDE"} {
+		if t.is(kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnKeywords end a column's type.
+var columnKeywords = []string{
+	"NOT", "NULL", "PRIMARY", "UNIQUE", "REFERENCES", "CHECK", "CONSTRAINT",
+	"DEFAULT", "COMMENT", "COLLATE", "GENERATED", "AUTO_INCREMENT",
+	"AUTOINCREMENT", "ON", "CHARSET",
+}
+
+func (p *ddlParser) column(table *Table, c *cursor) (Field, error) {
+	nameTok := c.next()
+	if nameTok.kind != tokenIdent {
+		return Field{}, fmt.Errorf("expected column name, got %q", nameTok.text)
+	}
+	f := Field{Name: nameTok.text}
+
+	var typ []string
+	for !c.done() && !c.peekAny(columnKeywords...) && !(c.peek().is("CHARACTER") && c.peekAt(1).is("SET")) {
+		if c.acceptPunct("(") {
+			typ = append(typ, "("+c.joinUntilClose()+")")
+			continue
+		}
+		typ = append(typ, c.next().text)
+	}
+	f.SQLType = strings.ReplaceAll(strings.Join(typ, " "), " (", "(")
+	f.SQLType = strings.ReplaceAll(f.SQLType, " []", "[]")
+	f.SQLType = strings.ReplaceAll(f.SQLType, " . ", ".")
+	f.Type, f.MaxLength = fieldType(f.SQLType)
+
+	for !c.done() {
+		switch {
+		case c.accept("NOT"):
+			if c.accept("NULL") {
+				f.Required = true
+			}
+		case c.accept("PRIMARY"):
+			c.accept("KEY")
+			f.PrimaryKey, f.Required = true, true
+		case c.accept("UNIQUE"):
+			c.accept("KEY")
+			f.Unique = true
+		case c.accept("REFERENCES"):
+			ref, cols, err := c.reference()
+			if err != nil {
+				return Field{}, err
+			}
+			f.ForeignKey = foreignKey(ref, cols, p.schema.Table(ref))
+		case c.accept("DEFAULT"):
+			f.DefaultValue = defaultValue(c.expression())
+		case c.accept("COMMENT"):
+			f.Description = unquote(c.next().text)
+		default:
+			c.next()
+		}
+	}
+	return f, nil
+}
+
+func (p *ddlParser) tableConstraint(table *Table, c *cursor) error {
+	if c.accept("CONSTRAINT") {
+		c.next()
+	}
+	switch {
+	case c.accept("PRIMARY"):
+		c.accept("KEY")
+		for _, col := range c.columns() {
+			if f := table.Field(col); f != nil {
+				f.PrimaryKey, f.Required = true, true
+			}
+		}
+	case c.accept("UNIQUE"):
+		if !c.accept("KEY") {
+			c.accept("INDEX")
+		}
+		if c.peek().kind == tokenIdent {
+			c.next() // index name
+		}
+		if cols := c.columns(); len(cols) == 1 {
+			if f := table.Field(cols[0]); f != nil {
+				f.Unique = true
+			}
+		}
+	case c.accept("FOREIGN"):
+		c.accept("KEY")
+		if c.peek().kind == tokenIdent {
+			c.next() // MySQL index name
+		}
+		cols := c.columns()
+		if !c.accept("REFERENCES") {
+			return fmt.Errorf("expected REFERENCES in foreign key")
+		}
+		ref, refCols, err := c.reference()
+		if err != nil {
+			return err
+		}
+		if len(cols) == 1 {
+			if f := table.Field(cols[0]); f != nil {
+				f.ForeignKey = foreignKey(ref, refCols, p.schema.Table(ref))
+			}
+		}
+	}
+	return nil
+}
+
+func (p *ddlParser) alterTable(c *cursor) error {
+	c.skipWords("IF", "EXISTS", "ONLY")
+	name, err := c.qualifiedName()
+	if err != nil {
+		return err
+	}
+	table := p.schema.Table(name)
+	if table == nil {
+		return nil
+	}
+	for _, action := range splitTopLevel(c.rest()) {
+		a := &cursor{tokens: action}
+		if !a.accept("ADD") {
+			continue
+		}
+		if isTableConstraint(a) {
+			if err := p.tableConstraint(table, a); err != nil {
+				return fmt.Errorf("table %s: %w", name, err)
+			}
+			continue
+		}
+		a.accept("COLUMN")
+		a.skipWords("IF", "NOT", "EXISTS")
+		field, err := p.column(table, a)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", name, err)
+		}
+		table.Fields = append(table.Fields, field)
+	}
+	return nil
+}
+
+func (p *ddlParser) createUniqueIndex(c *cursor) error {
+	if !c.accept("INDEX") {
+		return nil
+	}
+	c.skipWords("CONCURRENTLY", "IF", "NOT", "EXISTS")
+	if !c.peek().is("ON") {
+		c.next() // index name
+	}
+	if !c.accept("ON") {
+		return nil
+	}
+	c.accept("ONLY")
+	name, err := c.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if c.accept("USING") {
+		c.next()
+	}
+	table := p.schema.Table(name)
+	if cols := c.columns(); table != nil && len(cols) == 1 {
+		if f := table.Field(cols[0]); f != nil {
+			f.Unique = true
+		}
+	}
+	return nil
+}
+
+// comment handles Postgres COMMENT ON TABLE and COMMENT ON COLUMN.
+func (p *ddlParser) comment(c *cursor) error {
+	switch {
+	case c.accept("TABLE"):
+		name, err := c.qualifiedName()
+		if err != nil {
+			return err
+		}
+		if c.accept("IS") {
+			if table := p.schema.Table(name); table != nil {
+				table.Description = unquote(c.next().text)
+			}
+		}
+	case c.accept("COLUMN"):
+		parts := c.nameParts()
+		if len(parts) < 2 || !c.accept("IS") {
+			return nil
+		}
+		if table := p.schema.Table(parts[len(parts)-2]); table != nil {
+			if f := table.Field(parts[len(parts)-1]); f != nil {
+				f.Description = unquote(c.next().text)
+			}
+		}
+	}
+	return nil
+}
+
+// foreignKey formats a reference as "table.field". A reference without
+// columns refers to the primary key of table, when it is known.
+func foreignKey(table string, cols []string, ref *Table) string {
+	if len(cols) == 1 {
+		return table + "." + cols[0]
+	}
+	if len(cols) == 0 && ref != nil {
+		for _, f := range ref.Fields {
+			if f.PrimaryKey {
+				return table + "." + f.Name
+			}
+		}
+	}
+	if len(cols) == 0 {
+		return table + ".id"
+	}
+	return ""
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
+
+// cursor walks the tokens of one statement.
+type cursor struct {
+	tokens []token
+	pos    int
+}
+
+func (c *cursor) done() bool { return c.pos >= len(c.tokens) }
+
+func (c *cursor) peek() token { return c.peekAt(0) }
+
+func (c *cursor) peekAt(n int) token {
+	if c.pos+n >= len(c.tokens) {
+		return token{kind: tokenPunct}
+	}
+	return c.tokens[c.pos+n]
+}
+
+func (c *cursor) peekAny(keywords ...string) bool {
+	for _, kw := range keywords {
+		if c.peek().is(kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cursor) next() token {
+	t := c.peek()
+	if !c.done() {
+		c.pos++
+	}
+	return t
+}
+
+func (c *cursor) rest() []token {
+	rest := c.tokens[c.pos:]
+	c.pos = len(c.tokens)
+	return rest
+}
+
+func (c *cursor) accept(kw string) bool {
+	if c.peek().is(kw) {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+func (c *cursor) acceptPunct(p string) bool {
+	if t := c.peek(); t.kind == tokenPunct && t.text == p {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+func (c *cursor) skipWords(words ...string) {
+	for c.peekAny(words...) {
+		c.pos++
+	}
+}
+
+// nameParts reads a dotted name such as public.users.id.
+func (c *cursor) nameParts() []string {
+	var parts []string
+	for c.peek().kind == tokenIdent {
+		parts = append(parts, c.next().text)
+		if !c.acceptPunct(".") {
+			break
+		}
+	}
+	return parts
+}
+
+// qualifiedName reads a possibly schema-qualified name and returns the
+// unqualified part.
+func (c *cursor) qualifiedName() (string, error) {
+	parts := c.nameParts()
+	if len(parts) == 0 {
+		return "", fmt.Errorf("expected a name, got %q", c.peek().text)
+	}
+	return parts[len(parts)-1], nil
+}
+
+// list reads a parenthesized, comma-separated list after its opening
+// parenthesis has been consumed, returning the tokens of each item.
+func (c *cursor) list() [][]token {
+	start, depth := c.pos, 0
+	for ; !c.done(); c.pos++ {
+		t := c.tokens[c.pos]
+		if t.kind != tokenPunct {
+			continue
+		}
+		if t.text == "(" {
+			depth++
+		} else if t.text == ")" {
+			if depth == 0 {
+				items := splitTopLevel(c.tokens[start:c.pos])
+				c.pos++
+				return items
+			}
+			depth--
+		}
+	}
+	return splitTopLevel(c.tokens[start:])
+}
+
+// columns reads a parenthesized list of column names. Index expressions and
+// options such as "name DESC" or "name(10)" keep only the name.
+func (c *cursor) columns() []string {
+	if !c.acceptPunct("(") {
+		return nil
+	}
+	var cols []string
+	for _, item := range c.list() {
+		if len(item) > 0 && item[0].kind == tokenIdent {
+			cols = append(cols, item[0].text)
+		}
+	}
+	return cols
+}
+
+// reference reads "table (col, ...)" after REFERENCES and skips any
+// referential actions.
+func (c *cursor) reference() (string, []string, error) {
+	table, err := c.qualifiedName()
+	if err != nil {
+		return "", nil, err
+	}
+	cols := c.columns()
+	for c.peekAny("MATCH", "ON", "DEFERRABLE", "INITIALLY", "NOT") {
+		if c.peek().is("NOT") && !c.peekAt(1).is("DEFERRABLE") {
+			break
+		}
+		c.next()
+		c.skipWords("FULL", "PARTIAL", "SIMPLE", "DELETE", "UPDATE", "CASCADE", "RESTRICT",
+			"SET", "NULL", "DEFAULT", "NO", "ACTION", "DEFERRABLE", "DEFERRED", "IMMEDIATE")
+	}
+	return table, cols, nil
+}
+
+// expression reads a DEFAULT expression up to the next column keyword.
+func (c *cursor) expression() string {
+	var b strings.Builder
+	for !c.done() && !c.peekAny(columnKeywords...) {
+		t := c.next()
+		switch {
+		case t.kind == tokenPunct && t.text == "(":
+			b.WriteString("(" + c.joinUntilClose() + ")")
+		case t.kind == tokenPunct:
+			b.WriteString(t.text)
+		default:
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "::") && !strings.HasSuffix(b.String(), "(") {
+				b.WriteByte(' ')
+			}
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
+
+// joinUntilClose reads up to the parenthesis closing one already consumed
+// and returns the tokens in between as text.
+func (c *cursor) joinUntilClose() string {
+	var parts []string
+	depth := 0
+	for !c.done() {
+		t := c.next()
+		if t.kind == tokenPunct && t.text == ")" {
+			if depth == 0 {
+				break
+			}
+			depth--
+		} else if t.kind == tokenPunct && t.text == "(" {
+			depth++
+		}
+		parts = append(parts, t.text)
+	}
+	s := strings.Join(parts, " ")
+	s = strings.ReplaceAll(s, " , ", ",")
+	s = strings.ReplaceAll(s, "( ", "(")
+	s = strings.ReplaceAll(s, " )", ")")
+	return strings.ReplaceAll(s, " :: ", "::")
+}
+
+// splitTopLevel splits tokens at commas outside parentheses.
+func splitTopLevel(tokens []token) [][]token {
+	var items [][]token
+	start, depth := 0, 0
+	for i, t := range tokens {
+		if t.kind != tokenPunct {
+			continue
+		}
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				items = append(items, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(tokens) {
+		items = append(items, tokens[start:])
+	}
+	return items
+}
@@ -0,0 +1,225 @@
+package sqlschema
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const pgDump = `
+--
+-- PostgreSQL database dump
+--
+SET statement_timeout = 0;
+
+CREATE TYPE public.role AS ENUM ('admin', 'customer');
+
+CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    -- don't forget; this has semicolons
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$;
+
+CREATE TABLE public.users (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    email character varying(255) NOT NULL,
+    role public.role DEFAULT 'customer'::public.role NOT NULL,
+    status character varying(20) DEFAULT 'active'::character varying,
+    tags text[],
+    is_active boolean DEFAULT true NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+COMMENT ON TABLE public.users IS 'Registered users';
+COMMENT ON COLUMN public.users.email IS 'Login e-mail';
+
+CREATE TABLE public.orders (
+    id integer NOT NULL,
+    user_id uuid NOT NULL,
+    total numeric(10,2) DEFAULT 0.00 NOT NULL
+);
+
+CREATE SEQUENCE public.orders_id_seq AS integer START WITH 1;
+ALTER TABLE ONLY public.orders ALTER COLUMN id SET DEFAULT nextval('public.orders_id_seq'::regclass);
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
+CREATE UNIQUE INDEX users_email_key ON public.users USING btree (email);
+ALTER TABLE ONLY public.orders
+    ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+`
+
+func TestParseDDL_Postgres(t *testing.T) {
+	schema, err := ParseDDL(pgDump, WithDialect(Postgres))
+	if err != nil {
+		t.Fatalf("ParseDDL() error = %v", err)
+	}
+	if schema.Database.Driver != "postgres" || len(schema.Tables) != 2 {
+		t.Fatalf("schema = %+v", schema)
+	}
+
+	users := schema.Table("users")
+	if users.Description != "Registered users" {
+		t.Errorf("users description = %q", users.Description)
+	}
+	tests := []Field{
+		{Name: "id", Type: "uuid", Required: true, PrimaryKey: true, DefaultValue: "gen_random_uuid()", SQLType: "uuid"},
+		{Name: "email", Type: "string", Description: "Login e-mail", Required: true, Unique: true, MaxLength: 255, SQLType: "character varying(255)"},
+		{Name: "role", Type: "string", Required: true, DefaultValue: "customer", SQLType: "public.role"},
+		{Name: "status", Type: "string", DefaultValue: "active", MaxLength: 20, SQLType: "character varying(20)"},
+		{Name: "tags", Type: "json", SQLType: "text[]"},
+		{Name: "is_active", Type: "boolean", Required: true, DefaultValue: "true", SQLType: "boolean"},
+		{Name: "created_at", Type: "timestamp", Required: true, DefaultValue: "now()", SQLType: "timestamp with time zone"},
+	}
+	for i, want := range tests {
+		if i >= len(users.Fields) {
+			t.Fatalf("users has %d fields, want %d", len(users.Fields), len(tests))
+		}
+		if got := users.Fields[i]; got != want {
+			t.Errorf("users field %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	orders := schema.Table("orders")
+	if f := orders.Field("id"); !f.PrimaryKey || f.Type != "integer" {
+		t.Errorf("orders.id = %+v", f)
+	}
+	if f := orders.Field("user_id"); f.ForeignKey != "users.id" {
+		t.Errorf("orders.user_id foreign key = %q", f.ForeignKey)
+	}
+	if f := orders.Field("total"); f.Type != "decimal" || f.DefaultValue != "0.00" {
+		t.Errorf("orders.total = %+v", f)
+	}
+}
+
+func TestParseDDL_MySQL(t *testing.T) {
+	ddl := "DROP TABLE IF EXISTS `posts`;\n" +
+		"/*!40101 SET character_set_client = utf8 */;\n" +
+		"CREATE TABLE `posts` (\n" +
+		"  `id` int unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `author_id` int unsigned NOT NULL,\n" +
+		"  `slug` varchar(120) CHARACTER SET utf8mb4 NOT NULL COMMENT 'URL slug',\n" +
+		"  `published` tinyint(1) NOT NULL DEFAULT '0',\n" +
+		"  `body` longtext,\n" +
+		"  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `posts_slug` (`slug`),\n" +
+		"  KEY `posts_author` (`author_id`),\n" +
+		"  CONSTRAINT `posts_author_fk` FOREIGN KEY (`author_id`) REFERENCES `authors` (`id`) ON DELETE CASCADE\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Blog posts';\n"
+
+	schema, err := ParseDDL(ddl, WithDialect(MySQL))
+	if err != nil {
+		t.Fatalf("ParseDDL() error = %v", err)
+	}
+	posts := schema.Table("posts")
+	if posts == nil || posts.Description != "Blog posts" || len(posts.Fields) != 6 {
+		t.Fatalf("posts = %+v", posts)
+	}
+
+	want := map[string]Field{
+		"id":         {Name: "id", Type: "integer", Required: true, PrimaryKey: true, SQLType: "int unsigned"},
+		"author_id":  {Name: "author_id", Type: "integer", Required: true, ForeignKey: "authors.id", SQLType: "int unsigned"},
+		"slug":       {Name: "slug", Type: "string", Description: "URL slug", Required: true, Unique: true, MaxLength: 120, SQLType: "varchar(120)"},
+		"published":  {Name: "published", Type: "boolean", Required: true, DefaultValue: "0", SQLType: "tinyint(1)"},
+		"body":       {Name: "body", Type: "text", SQLType: "longtext"},
+		"updated_at": {Name: "updated_at", Type: "timestamp", DefaultValue: "CURRENT_TIMESTAMP", SQLType: "datetime"},
+	}
+	for _, f := range posts.Fields {
+		if f != want[f.Name] {
+			t.Errorf("field %s = %+v, want %+v", f.Name, f, want[f.Name])
+		}
+	}
+}
+
+func TestParseDDL_SQLite(t *testing.T) {
+	ddl := `
+CREATE TABLE authors (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL UNIQUE);
+CREATE TABLE IF NOT EXISTS "comments" (
+  id INTEGER PRIMARY KEY,
+  author_id INTEGER REFERENCES authors,
+  body TEXT NOT NULL CHECK (length(body) > 0)
+);
+CREATE INDEX comments_author ON comments (author_id);
+`
+	schema, err := ParseDDL(ddl, WithTables("comments"))
+	if err != nil {
+		t.Fatalf("ParseDDL() error = %v", err)
+	}
+	if len(schema.Tables) != 1 || schema.Tables[0].Name != "comments" {
+		t.Fatalf("tables = %+v", schema.Tables)
+	}
+	comments := schema.Tables[0]
+	if f := comments.Field("author_id"); f.ForeignKey != "authors.id" || f.Required {
+		t.Errorf("author_id = %+v", f)
+	}
+	if f := comments.Field("body"); f.Type != "text" || !f.Required {
+		t.Errorf("body = %+v", f)
+	}
+}
+
+func TestLoadDDL_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(path, []byte("CREATE TABLE t (id bigint PRIMARY KEY, name varchar(50));"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := LoadDDL(path, WithDialect(Postgres))
+	if err != nil {
+		t.Fatalf("LoadDDL() error = %v", err)
+	}
+
+	out, err := yaml.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"driver: postgres", "type: bigint", "primaryKey: true", "maxLength: 50"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("YAML missing %q:\n%s", want, out)
+		}
+	}
+
+	if _, err := LoadDDL(filepath.Join(t.TempDir(), "missing.sql")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+	if _, err := ParseDDL("CREATE TABLE t (note text DEFAULT 'unterminated);"); err == nil || !strings.Contains(err.Error(), "unterminated string") {
+		t.Errorf("expected unterminated string error, got %v", err)
+	}
+}
+
+func TestFieldType(t *testing.T) {
+	tests := map[string]struct {
+		typ    string
+		length int
+	}{
+		"VARCHAR(64)":                 {"string", 64},
+		"character(2)":                {"string", 2},
+		"text":                        {"text", 0},
+		"int8":                        {"bigint", 0},
+		"bigint unsigned":             {"bigint", 0},
+		"smallint":                    {"integer", 0},
+		"int(11)":                     {"integer", 0},
+		"tinyint(4)":                  {"integer", 0},
+		"numeric(12,4)":               {"decimal", 0},
+		"double precision":            {"float", 0},
+		"timestamp(6) with time zone": {"timestamp", 0},
+		"date":                        {"date", 0},
+		"jsonb":                       {"json", 0},
+		"integer[]":                   {"json", 0},
+		"mood":                        {"string", 0},
+	}
+	for in, want := range tests {
+		typ, length := fieldType(in)
+		if typ != want.typ || length != want.length {
+			t.Errorf("fieldType(%q) = %q, %d; want %q, %d", in, typ, length, want.typ, want.length)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package sqlschema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Introspect imports the schema of a live database. db must be opened with
+// a driver for dialect; this package does not import any drivers:
+//
+//	db, err := sql.Open("pgx", dsn)
+//	...
+//	schema, err := sqlschema.Introspect(ctx, db, sqlschema.Postgres)
+//
+// Postgres and MySQL are read from information_schema, including table and
+// column comments. SQLite is read from the DDL stored in sqlite_master.
+func Introspect(ctx context.Context, db *sql.DB, dialect Dialect, opts ...Option) (*Schema, error) {
+	o := newOptions(opts)
+	o.dialect = dialect
+
+	var schema *Schema
+	var err error
+	switch dialect {
+	case Postgres, MySQL:
+		schema, err = introspectInformationSchema(ctx, db, o)
+	case SQLite:
+		schema, err = introspectSQLite(ctx, db, o)
+	default:
+		return nil, fmt.Errorf("unsupported dialect %q", dialect)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to introspect %s database: %w", dialect, err)
+	}
+	return schema, nil
+}
+
+// informationSchemaQueries are the queries for one dialect. Each takes the
+// schema name as its only argument.
+type informationSchemaQueries struct {
+	database    string // the current database name; no arguments
+	tables      string // table name, comment
+	columns     string // table, column, data type, udt name, nullable, default, max length, comment
+	constraints string // table, column, constraint type, referenced table, referenced column, constraint name
+}
+
+var postgresQueries = informationSchemaQueries{
+	database: `SELECT current_database()`,
+	tables: `SELECT t.table_name, COALESCE(obj_description(format('%I.%I', t.table_schema, t.table_name)::regclass, 'pg_class'), '')
+		FROM information_schema.tables t
+		WHERE t.table_schema = $1 AND t.table_type = 'BASE TABLE'
+		ORDER BY t.table_name`,
+	columns: `SELECT c.table_name, c.column_name, c.data_type, c.udt_name, c.is_nullable,
+			COALESCE(c.column_default, ''), COALESCE(c.character_maximum_length, 0),
+			COALESCE(col_description(format('%I.%I', c.table_schema, c.table_name)::regclass, c.ordinal_position::int), '')
+		FROM information_schema.columns c
+		WHERE c.table_schema = $1
+		ORDER BY c.table_name, c.ordinal_position`,
+	constraints: `SELECT tc.table_name, kcu.column_name, tc.constraint_type,
+			COALESCE(ccu.table_name, ''), COALESCE(ccu.column_name, ''), tc.constraint_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_name = tc.constraint_name AND kcu.constraint_schema = tc.constraint_schema
+		LEFT JOIN information_schema.constraint_column_usage ccu
+			ON tc.constraint_type = 'FOREIGN KEY'
+			AND ccu.constraint_name = tc.constraint_name AND ccu.constraint_schema = tc.constraint_schema
+		WHERE tc.table_schema = $1 AND tc.constraint_type IN ('PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY')`,
+}
+
+var mysqlQueries = informationSchemaQueries{
+	database: `SELECT COALESCE(DATABASE(), '')`,
+	tables: `SELECT table_name, table_comment
+		FROM information_schema.tables
+		WHERE table_schema = ? AND table_type = 'BASE TABLE'
+		ORDER BY table_name`,
+	columns: `SELECT table_name, column_name, column_type, '', is_nullable,
+			COALESCE(column_default, ''), COALESCE(character_maximum_length, 0), column_comment
+		FROM information_schema.columns
+		WHERE table_schema = ?
+		ORDER BY table_name, ordinal_position`,
+	constraints: `SELECT kcu.table_name, kcu.column_name, tc.constraint_type,
+			COALESCE(kcu.referenced_table_name, ''), COALESCE(kcu.referenced_column_name, ''), tc.constraint_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema
+			AND kcu.table_name = tc.table_name
+		WHERE tc.table_schema = ? AND tc.constraint_type IN ('PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY')`,
+}
+
+func introspectInformationSchema(ctx context.Context, db *sql.DB, o *options) (*Schema, error) {
+	q := postgresQueries
+	if o.dialect == MySQL {
+		q = mysqlQueries
+	}
+
+	schema := &Schema{Database: Database{Driver: string(o.dialect)}}
+	if err := db.QueryRowContext(ctx, q.database).Scan(&schema.Database.Name); err != nil {
+		return nil, err
+	}
+	name := o.schema
+	if name == "" {
+		name = "public"
+		if o.dialect == MySQL {
+			name = schema.Database.Name
+		}
+	}
+
+	tables := make(map[string]*Table)
+	err := queryRows(ctx, db, q.tables, name, func(scan func(...any) error) error {
+		var t Table
+		if err := scan(&t.Name, &t.Description); err != nil {
+			return err
+		}
+		if o.includes(t.Name) {
+			schema.Tables = append(schema.Tables, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range schema.Tables {
+		tables[schema.Tables[i].Name] = &schema.Tables[i]
+	}
+
+	err = queryRows(ctx, db, q.columns, name, func(scan func(...any) error) error {
+		var tableName, dataType, udtName, nullable, def string
+		var maxLength int
+		var f Field
+		if err := scan(&tableName, &f.Name, &dataType, &udtName, &nullable, &def, &maxLength, &f.Description); err != nil {
+			return err
+		}
+		table := tables[tableName]
+		if table == nil {
+			return nil
+		}
+		f.SQLType = columnType(dataType, udtName, maxLength)
+		f.Type, f.MaxLength = fieldType(f.SQLType)
+		f.Required = nullable == "NO"
+		f.DefaultValue = defaultValue(def)
+		table.Fields = append(table.Fields, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type constraint struct {
+		table, typ, refTable string
+		columns, refColumns  []string
+	}
+	constraints := make(map[string]*constraint)
+	var order []string
+	err = queryRows(ctx, db, q.constraints, name, func(scan func(...any) error) error {
+		var tableName, column, typ, refTable, refColumn, constraintName string
+		if err := scan(&tableName, &column, &typ, &refTable, &refColumn, &constraintName); err != nil {
+			return err
+		}
+		key := tableName + "." + constraintName
+		c := constraints[key]
+		if c == nil {
+			c = &constraint{table: tableName, typ: typ, refTable: refTable}
+			constraints[key] = c
+			order = append(order, key)
+		}
+		c.columns = appendMissing(c.columns, column)
+		if refColumn != "" {
+			c.refColumns = appendMissing(c.refColumns, refColumn)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(order)
+	for _, key := range order {
+		c := constraints[key]
+		table := tables[c.table]
+		if table == nil {
+			continue
+		}
+		switch c.typ {
+		case "PRIMARY KEY":
+			for _, col := range c.columns {
+				if f := table.Field(col); f != nil {
+					f.PrimaryKey, f.Required = true, true
+				}
+			}
+		case "UNIQUE":
+			if f := table.Field(c.columns[0]); f != nil && len(c.columns) == 1 {
+				f.Unique = true
+			}
+		case "FOREIGN KEY":
+			if f := table.Field(c.columns[0]); f != nil && len(c.columns) == 1 && len(c.refColumns) == 1 {
+				f.ForeignKey = c.refTable + "." + c.refColumns[0]
+			}
+		}
+	}
+	return schema, nil
+}
+
+// columnType builds a declared column type from information_schema: Postgres
+// reports "character varying" with a separate length, "ARRAY" with the
+// element type in udt_name ("_text") and enums as "USER-DEFINED".
+func columnType(dataType, udtName string, maxLength int) string {
+	switch {
+	case dataType == "ARRAY":
+		return strings.TrimPrefix(udtName, "_") + "[]"
+	case dataType == "USER-DEFINED":
+		return udtName
+	case maxLength > 0 && !strings.Contains(dataType, "("):
+		return fmt.Sprintf("%s(%d)", dataType, maxLength)
+	}
+	return dataType
+}
+
+func introspectSQLite(ctx context.Context, db *sql.DB, o *options) (*Schema, error) {
+	var ddl []string
+	err := queryRows(ctx, db, `SELECT sql FROM sqlite_master
+		WHERE type IN ('table', 'index') AND sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+		ORDER BY CASE type WHEN 'table' THEN 0 ELSE 1 END, name`, nil, func(scan func(...any) error) error {
+		var stmt string
+		if err := scan(&stmt); err != nil {
+			return err
+		}
+		ddl = append(ddl, stmt)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := ParseDDL(strings.Join(ddl, ";\n"), WithDialect(SQLite))
+	if err != nil {
+		return nil, err
+	}
+	var tables []Table
+	for _, t := range schema.Tables {
+		if o.includes(t.Name) {
+			tables = append(tables, t)
+		}
+	}
+	schema.Tables = tables
+	return schema, nil
+}
+
+// queryRows runs query, with arg if it is not nil, and calls fn for each row.
+func queryRows(ctx context.Context, db *sql.DB, query string, arg any, fn func(scan func(...any) error) error) error {
+	var args []any
+	if arg != nil {
+		args = append(args, arg)
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := fn(rows.Scan); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func appendMissing(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
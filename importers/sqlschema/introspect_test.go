@@ -0,0 +1,174 @@
+package sqlschema
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeDB answers queries whose text contains a key with canned rows.
+type fakeDB struct {
+	results map[string][][]driver.Value
+	args    []any
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	for _, arg := range args {
+		c.db.args = append(c.db.args, arg.Value)
+	}
+	for key, rows := range c.db.results {
+		if strings.Contains(query, key) {
+			return &fakeRows{rows: rows}, nil
+		}
+	}
+	return nil, errors.New("unexpected query: " + query)
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+	pos  int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+func TestIntrospect_Postgres(t *testing.T) {
+	fake := &fakeDB{results: map[string][][]driver.Value{
+		"current_database()": {{"shop"}},
+		"information_schema.tables": {
+			{"orders", "Customer orders"},
+			{"users", ""},
+		},
+		"information_schema.columns": {
+			{"orders", "id", "integer", "int4", "NO", "nextval('orders_id_seq'::regclass)", int64(0), ""},
+			{"orders", "user_id", "uuid", "uuid", "NO", "", int64(0), "Buyer"},
+			{"orders", "labels", "ARRAY", "_text", "YES", "", int64(0), ""},
+			{"users", "id", "uuid", "uuid", "NO", "gen_random_uuid()", int64(0), ""},
+			{"users", "email", "character varying", "varchar", "NO", "", int64(255), ""},
+			{"users", "state", "USER-DEFINED", "user_state", "NO", "'active'::user_state", int64(0), ""},
+		},
+		"table_constraints": {
+			{"orders", "id", "PRIMARY KEY", "", "", "orders_pkey"},
+			{"orders", "user_id", "FOREIGN KEY", "users", "id", "orders_user_id_fkey"},
+			{"users", "id", "PRIMARY KEY", "", "", "users_pkey"},
+			{"users", "email", "UNIQUE", "", "", "users_email_key"},
+		},
+	}}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+
+	schema, err := Introspect(context.Background(), db, Postgres)
+	if err != nil {
+		t.Fatalf("Introspect() error = %v", err)
+	}
+	if schema.Database.Driver != "postgres" || schema.Database.Name != "shop" || len(schema.Tables) != 2 {
+		t.Fatalf("schema = %+v", schema)
+	}
+	if fake.args[0] != "public" {
+		t.Errorf("schema argument = %v, want public", fake.args[0])
+	}
+
+	orders, users := schema.Table("orders"), schema.Table("users")
+	if orders.Description != "Customer orders" {
+		t.Errorf("orders description = %q", orders.Description)
+	}
+	tests := []struct {
+		got, want Field
+	}{
+		{*orders.Field("id"), Field{Name: "id", Type: "integer", Required: true, PrimaryKey: true, SQLType: "integer"}},
+		{*orders.Field("user_id"), Field{Name: "user_id", Type: "uuid", Description: "Buyer", Required: true, ForeignKey: "users.id", SQLType: "uuid"}},
+		{*orders.Field("labels"), Field{Name: "labels", Type: "json", SQLType: "text[]"}},
+		{*users.Field("email"), Field{Name: "email", Type: "string", Required: true, Unique: true, MaxLength: 255, SQLType: "character varying(255)"}},
+		{*users.Field("state"), Field{Name: "state", Type: "string", Required: true, DefaultValue: "active", SQLType: "user_state"}},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("field = %+v, want %+v", tt.got, tt.want)
+		}
+	}
+}
+
+func TestIntrospect_MySQL(t *testing.T) {
+	fake := &fakeDB{results: map[string][][]driver.Value{
+		"DATABASE()":                 {{"blog"}},
+		"information_schema.tables":  {{"posts", "Posts"}, {"audit", ""}},
+		"information_schema.columns": {{"posts", "id", "bigint unsigned", "", "NO", "", int64(0), ""}},
+		"table_constraints":          {{"posts", "id", "PRIMARY KEY", "", "", "PRIMARY"}},
+	}}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+
+	schema, err := Introspect(context.Background(), db, MySQL, WithTables("posts"))
+	if err != nil {
+		t.Fatalf("Introspect() error = %v", err)
+	}
+	if fake.args[0] != "blog" {
+		t.Errorf("schema argument = %v, want the current database", fake.args[0])
+	}
+	if len(schema.Tables) != 1 {
+		t.Fatalf("tables = %+v", schema.Tables)
+	}
+	if f := schema.Tables[0].Field("id"); f.Type != "bigint" || !f.PrimaryKey {
+		t.Errorf("posts.id = %+v", f)
+	}
+}
+
+func TestIntrospect_SQLite(t *testing.T) {
+	fake := &fakeDB{results: map[string][][]driver.Value{
+		"sqlite_master": {
+			{"CREATE TABLE tags (id INTEGER PRIMARY KEY, label TEXT NOT NULL)"},
+			{"CREATE UNIQUE INDEX tags_label ON tags (label)"},
+		},
+	}}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+
+	schema, err := Introspect(context.Background(), db, SQLite)
+	if err != nil {
+		t.Fatalf("Introspect() error = %v", err)
+	}
+	tags := schema.Table("tags")
+	if schema.Database.Driver != "sqlite" || tags == nil || !tags.Field("label").Unique {
+		t.Errorf("schema = %+v", schema)
+	}
+}
+
+func TestIntrospect_Errors(t *testing.T) {
+	db := sql.OpenDB(&fakeDB{})
+	defer db.Close()
+
+	if _, err := Introspect(context.Background(), db, "oracle"); err == nil || !strings.Contains(err.Error(), "unsupported dialect") {
+		t.Errorf("expected unsupported dialect error, got %v", err)
+	}
+	if _, err := Introspect(context.Background(), db, Postgres); err == nil || !strings.Contains(err.Error(), "failed to introspect postgres database") {
+		t.Errorf("expected query error, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+// Package sqlschema imports database schemas, from a live database or a DDL
+// dump, into the DatabaseSchema model used by the yaml-tutorial-guide
+// example, so code can be generated from an existing database instead of a
+// hand-written specification.
+//
+// The types mirror the example's spec package field for field, with the
+// same yaml tags, so an imported schema can be written out as a YAML
+// specification or converted directly:
+//
+//	schema, err := sqlschema.LoadDDL("dump.sql", sqlschema.WithDialect(sqlschema.Postgres))
+//	if err != nil {
+//		return err
+//	}
+//	schema.Name, schema.Version, schema.Package = "Shop", "1.0.0", "shop"
+//	out, err := yaml.Marshal(schema)
+package sqlschema
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect is a SQL dialect.
+type Dialect string
+
+// Supported dialects. The dialect is also used as the database driver name.
+const (
+	Postgres Dialect = "postgres"
+	MySQL    Dialect = "mysql"
+	SQLite   Dialect = "sqlite"
+)
+
+// Schema is an imported database schema. Importers fill in Database.Driver
+// and Tables; the metadata is left for the caller.
+type Schema struct {
+	Name        string   `yaml:"name"`
+	Version     string   `yaml:"version"`
+	Description string   `yaml:"description"`
+	Package     string   `yaml:"package"`
+	Database    Database `yaml:"database"`
+	Tables      []Table  `yaml:"tables"`
+}
+
+// Database describes the database the schema was imported from.
+type Database struct {
+	Driver string `yaml:"driver"`
+	Host   string `yaml:"host"`
+	Port   int    `yaml:"port"`
+	Name   string `yaml:"name"`
+}
+
+// Table is a database table.
+type Table struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description"`
+	Fields      []Field `yaml:"fields"`
+}
+
+// Field is a table column. Type is one of the spec's portable types:
+// string, text, integer, bigint, decimal, float, boolean, date, timestamp,
+// uuid or json. SQLType keeps the column type as declared.
+type Field struct {
+	Name         string `yaml:"name"`
+	Type         string `yaml:"type"`
+	Description  string `yaml:"description,omitempty"`
+	Required     bool   `yaml:"required,omitempty"`
+	PrimaryKey   bool   `yaml:"primaryKey,omitempty"`
+	Unique       bool   `yaml:"unique,omitempty"`
+	ForeignKey   string `yaml:"foreignKey,omitempty"`
+	DefaultValue string `yaml:"defaultValue,omitempty"`
+	MaxLength    int    `yaml:"maxLength,omitempty"`
+	SQLType      string `yaml:"sqlType,omitempty"`
+}
+
+// Table returns the table called name, or nil.
+func (s *Schema) Table(name string) *Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+	return nil
+}
+
+// Field returns the field called name, or nil.
+func (t *Table) Field(name string) *Field {
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
+// Option configures an import.
+type Option func(*options)
+
+type options struct {
+	dialect Dialect
+	schema  string
+	tables  map[string]bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithDialect sets the dialect of a DDL dump. It is recorded as the
+// database driver; the parser itself accepts all supported dialects.
+func WithDialect(d Dialect) Option {
+	return func(o *options) {
+		o.dialect = d
+	}
+}
+
+// WithSchema sets the schema to introspect: the Postgres schema (default
+// "public") or the MySQL database (default: the connection's database).
+func WithSchema(name string) Option {
+	return func(o *options) {
+		o.schema = name
+	}
+}
+
+// WithTables limits the import to the named tables.
+func WithTables(names ...string) Option {
+	return func(o *options) {
+		if o.tables == nil {
+			o.tables = make(map[string]bool)
+		}
+		for _, name := range names {
+			o.tables[name] = true
+		}
+	}
+}
+
+func (o *options) includes(table string) bool {
+	return o.tables == nil || o.tables[table]
+}
+
+// fieldType maps a SQL column type, such as "character varying(255)", to a
+// spec field type and the maximum length of string types.
+func fieldType(sqlType string) (string, int) {
+	t := strings.ToLower(strings.TrimSpace(sqlType))
+	base, args, _ := strings.Cut(t, "(")
+	base = strings.TrimSpace(base)
+	args, rest, _ := strings.Cut(args, ")")
+	if strings.HasSuffix(base, "[]") || strings.HasSuffix(rest, "[]") {
+		return "json", 0
+	}
+
+	switch base {
+	case "varchar", "character varying", "nvarchar", "char", "character", "nchar", "bpchar", "citext":
+		n, _ := strconv.Atoi(strings.TrimSpace(args))
+		return "string", n
+	case "text", "tinytext", "mediumtext", "longtext", "clob", "ntext":
+		return "text", 0
+	case "bigint", "int8", "bigserial", "serial8":
+		return "bigint", 0
+	case "int", "integer", "int4", "int2", "smallint", "mediumint", "serial", "serial4", "smallserial", "serial2":
+		return "integer", 0
+	case "tinyint":
+		if strings.TrimSpace(args) == "1" {
+			return "boolean", 0
+		}
+		return "integer", 0
+	case "decimal", "numeric", "money", "dec":
+		return "decimal", 0
+	case "real", "float", "float4", "float8", "double", "double precision":
+		return "float", 0
+	case "bool", "boolean", "bit":
+		return "boolean", 0
+	case "date":
+		return "date", 0
+	case "timestamp", "timestamptz", "datetime", "timestamp with time zone", "timestamp without time zone":
+		return "timestamp", 0
+	case "uuid", "uniqueidentifier":
+		return "uuid", 0
+	case "json", "jsonb":
+		return "json", 0
+	}
+
+	switch {
+	case strings.HasPrefix(base, "timestamp"):
+		return "timestamp", 0
+	case strings.HasPrefix(base, "double"):
+		return "float", 0
+	case strings.HasPrefix(base, "int"), strings.HasSuffix(base, " unsigned") && strings.Contains(base, "int"):
+		if strings.HasPrefix(base, "bigint") {
+			return "bigint", 0
+		}
+		return "integer", 0
+	case strings.Contains(base, "char"):
+		return "string", 0
+	}
+	// Unknown types, such as enums and domains, are treated as strings.
+	return "string", 0
+}
+
+// defaultValue simplifies a column default for the spec: string literals are
+// unquoted and casts such as "::character varying" are dropped.
+func defaultValue(expr string) string {
+	expr = strings.TrimSpace(expr)
+	for strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	if strings.HasPrefix(strings.ToLower(expr), "nextval(") {
+		// Sequence defaults are how serial columns are implemented.
+		return ""
+	}
+	if i := strings.LastIndex(expr, "::"); i > 0 && !strings.ContainsAny(expr[i:], "'()") {
+		expr = strings.TrimSpace(expr[:i])
+	}
+	if len(expr) >= 2 && expr[0] == '\'' && expr[len(expr)-1] == '\'' {
+		return strings.ReplaceAll(expr[1:len(expr)-1], "''", "'")
+	}
+	return expr
+}
@@ -0,0 +1,290 @@
+// Package tabular loads CSV and XLSX files into rows for templates, so lookup
+// tables such as error codes or feature flags can be maintained in a
+// spreadsheet.
+//
+// The first row names the columns. Each following row becomes a
+// map[string]any keyed by column name, with values typed by column: a
+// column whose values are all integers holds ints, and so on. Empty cells
+// are nil.
+package tabular
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnType is the type of the values in a column.
+type ColumnType string
+
+// Column types, from the most to the least specific.
+const (
+	Bool   ColumnType = "bool"    // true or false, in any case
+	Int    ColumnType = "int"     // integers without leading zeros
+	Float  ColumnType = "float64" // numbers
+	Time   ColumnType = "time"    // RFC 3339 timestamps and YYYY-MM-DD dates
+	String ColumnType = "string"
+)
+
+// Option configures loading.
+type Option func(*options)
+
+type options struct {
+	comma   rune
+	sheet   string
+	types   map[string]ColumnType
+	noInfer bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{comma: ','}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithComma sets the CSV field delimiter; the default is ','.
+func WithComma(r rune) Option {
+	return func(o *options) {
+		o.comma = r
+	}
+}
+
+// WithSheet selects the XLSX worksheet by name; the default is the first.
+func WithSheet(name string) Option {
+	return func(o *options) {
+		o.sheet = name
+	}
+}
+
+// WithColumnType fixes the type of a column instead of inferring it. Loading
+// fails if a value cannot be converted.
+func WithColumnType(column string, t ColumnType) Option {
+	return func(o *options) {
+		if o.types == nil {
+			o.types = make(map[string]ColumnType)
+		}
+		o.types[column] = t
+	}
+}
+
+// WithoutTypeInference loads every value as a string, except columns set
+// with WithColumnType.
+func WithoutTypeInference() Option {
+	return func(o *options) {
+		o.noInfer = true
+	}
+}
+
+// LoadCSV loads the CSV file at path.
+func LoadCSV(path string, opts ...Option) ([]map[string]any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer f.Close()
+
+	rows, err := ReadCSV(f, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rows, nil
+}
+
+// ReadCSV loads CSV from r.
+func ReadCSV(r io.Reader, opts ...Option) ([]map[string]any, error) {
+	o := newOptions(opts)
+	cr := csv.NewReader(r)
+	cr.Comma = o.comma
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var records [][]string
+	var lines []int
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	if len(records) > 0 {
+		// Spreadsheet exports often start with a byte order mark.
+		records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+	}
+	return buildRows(records, lines, o)
+}
+
+// buildRows types the cells of records, whose first record is the header.
+// lines holds the row number of each record, for errors.
+func buildRows(records [][]string, lines []int, o *options) ([]map[string]any, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no header row")
+	}
+
+	header := records[0]
+	for len(header) > 0 && strings.TrimSpace(header[len(header)-1]) == "" {
+		header = header[:len(header)-1]
+	}
+	seen := make(map[string]bool)
+	columns := make([]string, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("column %d has no name", i+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		seen[name] = true
+		columns[i] = name
+	}
+
+	var data [][]string
+	var dataLines []int
+	for i, record := range records[1:] {
+		if blank(record) {
+			continue
+		}
+		for j := len(columns); j < len(record); j++ {
+			if strings.TrimSpace(record[j]) != "" {
+				return nil, fmt.Errorf("row %d: value %q has no column", lines[i+1], record[j])
+			}
+		}
+		data = append(data, record)
+		dataLines = append(dataLines, lines[i+1])
+	}
+
+	types := make([]ColumnType, len(columns))
+	for j, name := range columns {
+		switch t, ok := o.types[name]; {
+		case ok:
+			types[j] = t
+		case o.noInfer:
+			types[j] = String
+		default:
+			types[j] = inferColumn(data, j)
+		}
+	}
+
+	rows := make([]map[string]any, 0, len(data))
+	for i, record := range data {
+		row := make(map[string]any, len(columns))
+		for j, name := range columns {
+			cell := ""
+			if j < len(record) {
+				cell = strings.TrimSpace(record[j])
+			}
+			if cell == "" {
+				row[name] = nil
+				continue
+			}
+			value, err := convert(cell, types[j])
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column %q: %w", dataLines[i], name, err)
+			}
+			row[name] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func blank(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// inferColumn returns the most specific type that every non-empty value in
+// column j converts to.
+func inferColumn(data [][]string, j int) ColumnType {
+	candidates := []ColumnType{Bool, Int, Float, Time}
+	found := false
+	for _, record := range data {
+		if j >= len(record) {
+			continue
+		}
+		cell := strings.TrimSpace(record[j])
+		if cell == "" {
+			continue
+		}
+		found = true
+		kept := candidates[:0]
+		for _, t := range candidates {
+			if (t == Int || t == Float) && leadingZero(cell) {
+				continue
+			}
+			if _, err := convert(cell, t); err == nil {
+				kept = append(kept, t)
+			}
+		}
+		candidates = kept
+		if len(candidates) == 0 {
+			return String
+		}
+	}
+	if !found {
+		return String
+	}
+	return candidates[0]
+}
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// convert parses cell as type t.
+func convert(cell string, t ColumnType) (any, error) {
+	switch t {
+	case Bool:
+		switch strings.ToLower(cell) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not a boolean", cell)
+	case Int:
+		n, err := strconv.Atoi(cell)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", cell)
+		}
+		return n, nil
+	case Float:
+		f, err := strconv.ParseFloat(cell, 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("%q is not a number", cell)
+		}
+		return f, nil
+	case Time:
+		for _, layout := range timeLayouts {
+			if ts, err := time.Parse(layout, cell); err == nil {
+				return ts, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a date or time", cell)
+	case String:
+		return cell, nil
+	}
+	return nil, fmt.Errorf("unknown column type %q", t)
+}
+
+// leadingZero reports whether a number is written with a leading zero, such
+// as "007", which marks a code rather than a number when inferring types.
+func leadingZero(cell string) bool {
+	digits := strings.TrimLeft(cell, "+-")
+	return len(digits) > 1 && digits[0] == '0' && digits[1] != '.'
+}
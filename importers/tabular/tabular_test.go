@@ -0,0 +1,227 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const errorCodes = "\ufeffcode,name,status,retryable,weight,since\n" +
+	"007,NotFound,404,false,0.5,2024-01-02\n" +
+	"012, Conflict ,409,TRUE,1,2024-03-04T10:00:00Z\n" +
+	",,,,,\n" +
+	"100,Internal,500,,2.25,\n"
+
+func TestReadCSV(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader(errorCodes))
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+
+	want := map[string]any{
+		"code":      "007",
+		"name":      "NotFound",
+		"status":    404,
+		"retryable": false,
+		"weight":    0.5,
+		"since":     time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("rows[0] = %#v, want %#v", rows[0], want)
+	}
+	if rows[1]["name"] != "Conflict" || rows[1]["retryable"] != true || rows[1]["weight"] != 1.0 {
+		t.Errorf("rows[1] = %#v", rows[1])
+	}
+	if v, ok := rows[2]["retryable"]; !ok || v != nil {
+		t.Errorf("empty cell = %#v, want nil", v)
+	}
+	if rows[2]["code"] != "100" {
+		t.Errorf("code column = %#v, want a string column", rows[2]["code"])
+	}
+}
+
+func TestReadCSV_Options(t *testing.T) {
+	t.Run("comma", func(t *testing.T) {
+		rows, err := ReadCSV(strings.NewReader("flag;enabled\nbeta;true\n"), WithComma(';'))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rows[0]["flag"] != "beta" || rows[0]["enabled"] != true {
+			t.Errorf("rows[0] = %#v", rows[0])
+		}
+	})
+
+	t.Run("column type", func(t *testing.T) {
+		rows, err := ReadCSV(strings.NewReader("code,status\n007,404\n"),
+			WithColumnType("code", Int), WithColumnType("status", String))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rows[0]["code"] != 7 || rows[0]["status"] != "404" {
+			t.Errorf("rows[0] = %#v", rows[0])
+		}
+	})
+
+	t.Run("without inference", func(t *testing.T) {
+		rows, err := ReadCSV(strings.NewReader("status,weight\n404,0.5\n"),
+			WithoutTypeInference(), WithColumnType("weight", Float))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rows[0]["status"] != "404" || rows[0]["weight"] != 0.5 {
+			t.Errorf("rows[0] = %#v", rows[0])
+		}
+	})
+}
+
+func TestReadCSV_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    []Option
+		wantErr string
+	}{
+		{"empty", "", nil, "no header row"},
+		{"duplicate column", "a,b,a\n1,2,3\n", nil, `duplicate column "a"`},
+		{"unnamed column", "a,,b\n1,2,3\n", nil, "column 2 has no name"},
+		{"extra value", "a,b\n1,2,3\n", nil, `row 2: value "3" has no column`},
+		{"conversion", "a,b\n1,2\n\n3,x\n", []Option{WithColumnType("b", Int)}, `row 4, column "b": "x" is not an integer`},
+		{"malformed", "a,b\n\"1,2\n", nil, "failed to parse CSV"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadCSV(strings.NewReader(tt.input), tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadCSV() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.csv")
+	if err := os.WriteFile(path, []byte("flag,enabled\nbeta,yes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := LoadCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0]["enabled"] != "yes" {
+		t.Errorf("enabled = %#v, want a string", rows[0]["enabled"])
+	}
+
+	_, err = LoadCSV(path, WithColumnType("enabled", Bool))
+	if err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("LoadCSV() error = %v, want it to name the file", err)
+	}
+}
+
+// buildXLSX returns a minimal workbook with the given worksheets, in order.
+func buildXLSX(t *testing.T, sheets map[string]string, order ...string) []byte {
+	t.Helper()
+	var workbook, rels strings.Builder
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	files := map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>code</t></si><si><t>name</t></si><si><r><t>Not</t></r><r><t>Found</t></r></si></sst>`,
+		"xl/styles.xml":        `<styleSheet><numFmts><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd\ hh:mm"/><numFmt numFmtId="165" formatCode="&quot;day&quot;0"/></numFmts><cellXfs><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/><xf numFmtId="165"/></cellXfs></styleSheet>`,
+	}
+	for i, name := range order {
+		id := string(rune('1' + i))
+		workbook.WriteString(`<sheet name="` + name + `" sheetId="` + id + `" r:id="rId` + id + `"/>`)
+		rels.WriteString(`<Relationship Id="rId` + id + `" Target="worksheets/sheet` + id + `.xml"/>`)
+		files["xl/worksheets/sheet"+id+".xml"] = `<worksheet><sheetData>` + sheets[name] + `</sheetData></worksheet>`
+	}
+	workbook.WriteString(`</sheets></workbook>`)
+	rels.WriteString(`</Relationships>`)
+	files["xl/workbook.xml"] = workbook.String()
+	files["xl/_rels/workbook.xml.rels"] = rels.String()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadXLSX(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"Notes": `<row r="1"><c r="A1" t="inlineStr"><is><t>note</t></is></c></row>`,
+		"Codes": `<row r="2"><c r="A2" t="s"><v>0</v></c><c r="B2" t="s"><v>1</v></c>` +
+			`<c r="C2" t="inlineStr"><is><t>retryable</t></is></c><c r="D2" t="inlineStr"><is><t>since</t></is></c>` +
+			`<c r="E2" t="inlineStr"><is><t>at</t></is></c><c r="F2" t="inlineStr"><is><t>count</t></is></c></row>` +
+			`<row r="3"><c r="A3"><v>404</v></c><c r="B3" t="s"><v>2</v></c><c r="C3" t="b"><v>0</v></c>` +
+			`<c r="D3" s="1"><v>45293</v></c><c r="E3" s="2"><v>45293.5</v></c><c r="F3" s="3"><v>3</v></c></row>` +
+			`<row r="5"><c r="A5"><v>409</v></c><c r="C5" t="b"><v>1</v></c></row>`,
+	}, "Notes", "Codes")
+
+	rows, err := ReadXLSX(data, WithSheet("Codes"))
+	if err != nil {
+		t.Fatalf("ReadXLSX() error = %v", err)
+	}
+	want := []map[string]any{
+		{
+			"code":      404,
+			"name":      "NotFound",
+			"retryable": false,
+			"since":     time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			"at":        time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+			"count":     3,
+		},
+		{"code": 409, "name": nil, "retryable": true, "since": nil, "at": nil, "count": nil},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("ReadXLSX() = %#v, want %#v", rows, want)
+	}
+
+	rows, err = ReadXLSX(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("first sheet has %d rows, want 0", len(rows))
+	}
+
+	if _, err := ReadXLSX(data, WithSheet("Missing")); err == nil || !strings.Contains(err.Error(), `sheet "Missing" not found`) {
+		t.Errorf("ReadXLSX() error = %v", err)
+	}
+	if _, err := ReadXLSX([]byte("code,name\n")); err == nil {
+		t.Error("ReadXLSX() accepted CSV data")
+	}
+}
+
+func TestIsDateFormat(t *testing.T) {
+	tests := map[string]bool{
+		"yyyy-mm-dd":      true,
+		"h:mm AM/PM":      true,
+		"0.00":            false,
+		`"days"0`:         false,
+		`[Red]0.00`:       false,
+		`#,##0\ "m"`:      false,
+		`[$-409]d-mmm-yy`: true,
+	}
+	for code, want := range tests {
+		if got := isDateFormat(code); got != want {
+			t.Errorf("isDateFormat(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadXLSX loads a worksheet of the XLSX workbook at path. Cells formatted
+// as dates are read as times; formulas are read as their cached values.
+func LoadXLSX(path string, opts ...Option) ([]map[string]any, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX file: %w", err)
+	}
+	defer zr.Close()
+
+	rows, err := readWorkbook(&zr.Reader, newOptions(opts))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rows, nil
+}
+
+// ReadXLSX loads a worksheet of the XLSX workbook in data.
+func ReadXLSX(data []byte, opts ...Option) ([]map[string]any, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX data: %w", err)
+	}
+	return readWorkbook(zr, newOptions(opts))
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+	Properties struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxText is rich text: either a single t element or runs of them.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Style  int      `xml:"s,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readWorkbook(zr *zip.Reader, o *options) ([]map[string]any, error) {
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := readXML(files, "xl/workbook.xml", &workbook, true); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := readXML(files, "xl/_rels/workbook.xml.rels", &rels, true); err != nil {
+		return nil, err
+	}
+	var shared xlsxSharedStrings
+	if err := readXML(files, "xl/sharedStrings.xml", &shared, false); err != nil {
+		return nil, err
+	}
+	var styles xlsxStyles
+	if err := readXML(files, "xl/styles.xml", &styles, false); err != nil {
+		return nil, err
+	}
+
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no sheets")
+	}
+	sheet := workbook.Sheets[0]
+	if o.sheet != "" {
+		found := false
+		for _, s := range workbook.Sheets {
+			if s.Name == o.sheet {
+				sheet, found = s, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("sheet %q not found", o.sheet)
+		}
+	}
+	target := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == sheet.RID {
+			target = rel.Target
+		}
+	}
+	if target == "" {
+		return nil, fmt.Errorf("sheet %q has no worksheet part", sheet.Name)
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var ws xlsxSheet
+	if err := readXML(files, target, &ws, true); err != nil {
+		return nil, err
+	}
+
+	dateStyles := dateStyles(styles)
+	var records [][]string
+	for _, row := range ws.Rows {
+		r := row.R - 1
+		if r < 0 {
+			r = len(records)
+		}
+		for len(records) <= r {
+			records = append(records, nil)
+		}
+		for _, c := range row.Cells {
+			col := len(records[r])
+			if c.Ref != "" {
+				var err error
+				if col, err = columnIndex(c.Ref); err != nil {
+					return nil, fmt.Errorf("sheet %q: %w", sheet.Name, err)
+				}
+			}
+			value, err := cellValue(c.Type, c.Value, c.Inline, shared, dateStyles[c.Style], workbook.Properties.Date1904)
+			if err != nil {
+				return nil, fmt.Errorf("sheet %q, cell %s: %w", sheet.Name, c.Ref, err)
+			}
+			for len(records[r]) <= col {
+				records[r] = append(records[r], "")
+			}
+			records[r][col] = value
+		}
+	}
+	lines := make([]int, len(records))
+	for i := range lines {
+		lines[i] = i + 1
+	}
+	for len(records) > 0 && blank(records[0]) {
+		// Leading empty rows are not part of the table.
+		records, lines = records[1:], lines[1:]
+	}
+
+	rows, err := buildRows(records, lines, o)
+	if err != nil {
+		return nil, fmt.Errorf("sheet %q: %w", sheet.Name, err)
+	}
+	return rows, nil
+}
+
+// readXML decodes the part called name into v. Missing optional parts are
+// left empty.
+func readXML(files map[string]*zip.File, name string, v any, required bool) error {
+	f := files[name]
+	if f == nil {
+		if required {
+			return fmt.Errorf("missing %s; not an XLSX workbook", name)
+		}
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, 256<<20)).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// columnIndex returns the zero-based column of a cell reference like "AB12".
+func columnIndex(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+	if i == 0 || col > 1<<14 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
+
+// dateStyles reports which cell styles format numbers as dates: the
+// built-in date formats and custom formats with date or time codes.
+func dateStyles(styles xlsxStyles) map[int]bool {
+	custom := make(map[int]bool)
+	for _, f := range styles.NumFmts {
+		custom[f.ID] = isDateFormat(f.Code)
+	}
+	dates := make(map[int]bool)
+	for i, xf := range styles.CellXfs {
+		id := xf.NumFmtID
+		if (id >= 14 && id <= 22) || (id >= 45 && id <= 47) || custom[id] {
+			dates[i] = true
+		}
+	}
+	return dates
+}
+
+// isDateFormat reports whether a number format code contains date or time
+// codes outside quoted text, brackets and escapes.
+func isDateFormat(code string) bool {
+	quoted, bracket := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			bracket = true
+		case c == ']':
+			bracket = false
+		case bracket:
+		case c == '\\' || c == '_' || c == '*':
+			i++
+		case strings.IndexByte("ymdhsYMDHS", c) >= 0:
+			return true
+		}
+	}
+	return false
+}
+
+// cellValue returns the text of a cell. Date serials are written in a layout
+// that convert parses as a time.
+func cellValue(typ, v string, inline xlsxText, shared xlsxSharedStrings, date, date1904 bool) (string, error) {
+	switch typ {
+	case "s":
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 || i >= len(shared.Items) {
+			return "", fmt.Errorf("invalid shared string index %q", v)
+		}
+		return shared.Items[i].String(), nil
+	case "inlineStr":
+		return inline.String(), nil
+	case "b":
+		if v == "1" {
+			return "true", nil
+		}
+		return "false", nil
+	case "e":
+		return "", fmt.Errorf("formula error %s", v)
+	case "", "n":
+		if v == "" || !date {
+			return v, nil
+		}
+		serial, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid date %q", v)
+		}
+		t := serialTime(serial, date1904)
+		if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+			return t.Format("2006-01-02"), nil
+		}
+		return t.Format("2006-01-02T15:04:05"), nil
+	}
+	// Formula strings ("str") and dates stored as text ("d").
+	return v, nil
+}
+
+// serialTime converts a spreadsheet date serial, the number of days since the
+// epoch, to a UTC time rounded to the second.
+func serialTime(serial float64, date1904 bool) time.Time {
+	// The 1900 epoch is 1899-12-30 rather than 12-31 because spreadsheets
+	// count 1900 as a leap year.
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	seconds := math.Round(serial * 24 * 60 * 60)
+	return epoch.Add(time.Duration(seconds) * time.Second)
+}
@@ -0,0 +1,96 @@
+package postprocess
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+)
+
+// FileInfo carries render metadata for the file being processed, so
+// processors can make data-aware decisions and emit diagnostics that are
+// attributable to a template and run.
+type FileInfo struct {
+	// Path is the output path of the file.
+	Path string
+	// TemplatePath is the template the file was rendered from, if known.
+	TemplatePath string
+	// RunID identifies the generation run that produced the file.
+	RunID string
+	// Data is the value the template was executed with.
+	Data any
+	// DataKeys are the top-level keys or exported fields of Data, sorted.
+	DataKeys []string
+	// Options describes the engine configuration, keyed by option name.
+	Options map[string]any
+	// Logger is scoped to the run. Use Log to get a non-nil logger.
+	Logger *slog.Logger
+	// Context carries the telemetry span of the file's render, if any.
+	Context context.Context
+}
+
+// Log returns the file's logger, or a logger that discards output when none
+// is set.
+func (f *FileInfo) Log() *slog.Logger {
+	if f.Logger == nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return f.Logger
+}
+
+// ContextProcessor is implemented by processors that need render metadata.
+// Chains call ProcessFile instead of ProcessContent when a processor
+// implements it.
+type ContextProcessor interface {
+	Processor
+	// ProcessFile processes the content of the file described by info.
+	ProcessFile(info *FileInfo, content []byte) ([]byte, error)
+}
+
+// ContextProcessorFunc is a function adapter that implements ContextProcessor.
+type ContextProcessorFunc func(info *FileInfo, content []byte) ([]byte, error)
+
+// ProcessContent implements the Processor interface with only the file path
+// available.
+func (f ContextProcessorFunc) ProcessContent(filePath string, content []byte) ([]byte, error) {
+	return f(&FileInfo{Path: filePath}, content)
+}
+
+// ProcessFile implements the ContextProcessor interface.
+func (f ContextProcessorFunc) ProcessFile(info *FileInfo, content []byte) ([]byte, error) {
+	return f(info, content)
+}
+
+// DataKeys returns the sorted top-level keys of a map with string keys, or
+// the exported field names of a struct. Pointers are followed; other values
+// have no keys.
+func DataKeys(data any) []string {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	var keys []string
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				keys = append(keys, t.Field(i).Name)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
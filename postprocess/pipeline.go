@@ -0,0 +1,187 @@
+package postprocess
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Matcher reports whether a stage applies to the file at filePath.
+type Matcher func(filePath string) bool
+
+// MatchGlob matches files against path.Match patterns. Patterns without a
+// slash are matched against the file name, others against the whole
+// slash-separated path.
+func MatchGlob(patterns ...string) Matcher {
+	return func(filePath string) bool {
+		slashed := filepath.ToSlash(filePath)
+		for _, pattern := range patterns {
+			target := slashed
+			if !strings.Contains(pattern, "/") {
+				target = path.Base(slashed)
+			}
+			if matched, _ := path.Match(pattern, target); matched {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchExt matches files by extension, case-insensitively. Extensions include
+// the leading dot, e.g. ".go".
+func MatchExt(exts ...string) Matcher {
+	return func(filePath string) bool {
+		ext := filepath.Ext(filePath)
+		for _, want := range exts {
+			if strings.EqualFold(ext, want) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not inverts a matcher.
+func Not(m Matcher) Matcher {
+	return func(filePath string) bool {
+		return !m(filePath)
+	}
+}
+
+// Stage is a processor in a pipeline together with the files it applies to,
+// its position relative to other stages and how its failures are handled.
+type Stage struct {
+	// Name identifies the stage in ordering constraints and errors.
+	Name string
+	// Processor transforms matching files.
+	Processor Processor
+	// Match selects the files the stage applies to; a file is processed if
+	// any matcher accepts it. No matchers means every file.
+	Match []Matcher
+	// After lists stages that must run before this one.
+	After []string
+	// Before lists stages that must run after this one.
+	Before []string
+	// Fatal stops processing of the file when the stage fails. Otherwise the
+	// stage's changes are discarded and the pipeline continues.
+	Fatal bool
+}
+
+func (s Stage) matches(filePath string) bool {
+	if len(s.Match) == 0 {
+		return true
+	}
+	for _, m := range s.Match {
+		if m(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Stage) label(index int) string {
+	if s.Name != "" {
+		return fmt.Sprintf("processor %q", s.Name)
+	}
+	return fmt.Sprintf("processor %d", index)
+}
+
+// StageError describes a failed pipeline stage.
+type StageError struct {
+	Stage    string
+	FilePath string
+	Fatal    bool
+	Err      error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("%s failed for %s: %v", e.Stage, e.FilePath, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// AddStage adds a stage to the pipeline. Named stages must have unique names.
+func (c *Chain) AddStage(stage Stage) error {
+	if stage.Processor == nil {
+		return fmt.Errorf("stage %q has no processor", stage.Name)
+	}
+	if stage.Name != "" {
+		for _, existing := range c.stages {
+			if existing.Name == stage.Name {
+				return fmt.Errorf("duplicate stage name %q", stage.Name)
+			}
+		}
+	}
+	c.stages = append(c.stages, stage)
+	return nil
+}
+
+// Validate checks that every ordering constraint refers to a known stage and
+// that the constraints are not circular.
+func (c *Chain) Validate() error {
+	_, err := c.order()
+	return err
+}
+
+// order returns stage indexes sorted so that ordering constraints are met.
+// Stages without constraints between them keep the order they were added in.
+func (c *Chain) order() ([]int, error) {
+	n := len(c.stages)
+	byName := make(map[string]int, n)
+	for i, stage := range c.stages {
+		if stage.Name != "" {
+			byName[stage.Name] = i
+		}
+	}
+
+	successors := make([][]int, n)
+	inDegree := make([]int, n)
+	addEdge := func(from, to int) {
+		successors[from] = append(successors[from], to)
+		inDegree[to]++
+	}
+
+	for i, stage := range c.stages {
+		for _, name := range stage.After {
+			j, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("%s must run after unknown stage %q", stage.label(i), name)
+			}
+			addEdge(j, i)
+		}
+		for _, name := range stage.Before {
+			j, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("%s must run before unknown stage %q", stage.label(i), name)
+			}
+			addEdge(i, j)
+		}
+	}
+
+	order := make([]int, 0, n)
+	done := make([]bool, n)
+	for len(order) < n {
+		next := -1
+		for i := range n {
+			if !done[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, fmt.Errorf("post-processor ordering constraints are circular")
+		}
+
+		done[next] = true
+		order = append(order, next)
+		for _, j := range successors[next] {
+			inDegree[j]--
+		}
+	}
+
+	return order, nil
+}
@@ -20,8 +20,6 @@
 //	eng.AddPostProcessor(myCustomProcessor)
 package postprocess
 
-import "fmt"
-
 // Processor defines the interface for content post-processors.
 // Implementations should be stateless and safe for concurrent use.
 type Processor interface {
@@ -40,54 +38,109 @@ func (f ProcessorFunc) ProcessContent(filePath string, content []byte) ([]byte,
 	return f(filePath, content)
 }
 
-// Chain manages and executes multiple post-processors in sequence.
-// Processors are applied in the order they were added.
+// Chain manages and executes multiple post-processors as a pipeline.
+// Processors run in the order they were added unless stages declare ordering
+// constraints, and each stage only sees the files its matchers select.
 type Chain struct {
-	processors []Processor
+	stages  []Stage
+	onError func(*StageError)
+	onStage func(*FileInfo, string) func(error)
 }
 
 // NewChain creates a new empty processor chain.
 func NewChain() *Chain {
 	return &Chain{
-		processors: make([]Processor, 0),
+		stages: make([]Stage, 0),
 	}
 }
 
-// Add adds a processor to the end of the chain.
+// Add adds a processor to the end of the chain. It applies to every file and
+// its failures are fatal.
 func (c *Chain) Add(processor Processor) {
-	c.processors = append(c.processors, processor)
+	c.stages = append(c.stages, Stage{Processor: processor, Fatal: true})
 }
 
 // AddFunc adds a function as a processor to the end of the chain.
 func (c *Chain) AddFunc(fn func(filePath string, content []byte) ([]byte, error)) {
-	c.processors = append(c.processors, ProcessorFunc(fn))
+	c.Add(ProcessorFunc(fn))
 }
 
-// Process runs all processors in sequence on the given content.
-// If any processor fails, processing stops and the error is returned.
+// Process runs the stages that match filePath in pipeline order.
+// If a fatal stage fails, processing stops and the error is returned; failures
+// of non-fatal stages are reported to the error handler and the stage's
+// changes are discarded.
 func (c *Chain) Process(filePath string, content []byte) ([]byte, error) {
+	return c.ProcessFile(&FileInfo{Path: filePath}, content)
+}
+
+// ProcessFile is like Process but passes render metadata to processors that
+// implement ContextProcessor.
+func (c *Chain) ProcessFile(info *FileInfo, content []byte) ([]byte, error) {
+	order, err := c.order()
+	if err != nil {
+		return nil, err
+	}
+
+	filePath := info.Path
 	result := content
-	for i, processor := range c.processors {
-		processed, err := processor.ProcessContent(filePath, result)
+	for _, i := range order {
+		stage := c.stages[i]
+		if !stage.matches(filePath) {
+			continue
+		}
+
+		var done func(error)
+		if c.onStage != nil {
+			done = c.onStage(info, stage.label(i))
+		}
+		var processed []byte
+		if cp, ok := stage.Processor.(ContextProcessor); ok {
+			processed, err = cp.ProcessFile(info, result)
+		} else {
+			processed, err = stage.Processor.ProcessContent(filePath, result)
+		}
+		if done != nil {
+			done(err)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("processor %d failed for %s: %w", i, filePath, err)
+			stageErr := &StageError{Stage: stage.label(i), FilePath: filePath, Fatal: stage.Fatal, Err: err}
+			if stage.Fatal {
+				return nil, stageErr
+			}
+			if c.onError != nil {
+				c.onError(stageErr)
+			}
+			continue
 		}
 		result = processed
 	}
 	return result, nil
 }
 
+// SetErrorHandler sets the function that is called when a non-fatal stage
+// fails. Without a handler such failures are silently skipped.
+func (c *Chain) SetErrorHandler(handler func(*StageError)) {
+	c.onError = handler
+}
+
+// SetStageHook sets a function that is called before each stage runs on a
+// file, with the file and the stage's label. The function it returns is
+// called with the stage's error, or nil, once the stage finishes.
+func (c *Chain) SetStageHook(hook func(info *FileInfo, stage string) func(err error)) {
+	c.onStage = hook
+}
+
 // HasProcessors returns true if the chain contains any processors.
 func (c *Chain) HasProcessors() bool {
-	return len(c.processors) > 0
+	return len(c.stages) > 0
 }
 
 // Len returns the number of processors in the chain.
 func (c *Chain) Len() int {
-	return len(c.processors)
+	return len(c.stages)
 }
 
 // Clear removes all processors from the chain.
 func (c *Chain) Clear() {
-	c.processors = c.processors[:0]
+	c.stages = c.stages[:0]
 }
@@ -132,6 +132,75 @@ func TestChain_Process(t *testing.T) {
 	}
 }
 
+func TestChain_Stages(t *testing.T) {
+	chain := NewChain()
+	var failures []*StageError
+	chain.SetErrorHandler(func(err *StageError) {
+		failures = append(failures, err)
+	})
+
+	stages := []Stage{
+		{Name: "header", Processor: &mockProcessor{name: "H"}, After: []string{"format"}},
+		{Name: "format", Processor: &mockProcessor{name: "F"}, Match: []Matcher{MatchExt(".go")}},
+		{Name: "lint", Processor: &mockProcessor{
+			transform: func(string, []byte) ([]byte, error) { return nil, errors.New("lint failed") },
+		}, Match: []Matcher{MatchGlob("*.go")}},
+		{Name: "sql", Processor: &mockProcessor{name: "S"}, Match: []Matcher{MatchGlob("db/*.sql")}, Before: []string{"format"}},
+	}
+	for _, stage := range stages {
+		if err := chain.AddStage(stage); err != nil {
+			t.Fatalf("AddStage failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+		failures int
+	}{
+		{"main.go", "H:F:hello", 1},
+		{"db/schema.sql", "H:S:hello", 0},
+		{"other/schema.sql", "H:hello", 0},
+	}
+
+	for _, tt := range tests {
+		failures = nil
+		result, err := chain.Process(tt.path, []byte("hello"))
+		if err != nil {
+			t.Fatalf("Process(%s) failed: %v", tt.path, err)
+		}
+		if string(result) != tt.expected {
+			t.Errorf("Process(%s) = %q, expected %q", tt.path, string(result), tt.expected)
+		}
+		if len(failures) != tt.failures {
+			t.Errorf("Process(%s) reported %d non-fatal failures, expected %d", tt.path, len(failures), tt.failures)
+		}
+	}
+
+	if err := chain.AddStage(Stage{Name: "format", Processor: &mockProcessor{}}); err == nil {
+		t.Error("Expected duplicate stage name to be rejected")
+	}
+}
+
+func TestChain_StageOrderingErrors(t *testing.T) {
+	chain := NewChain()
+	chain.AddStage(Stage{Name: "a", Processor: &mockProcessor{name: "A"}, After: []string{"b"}})
+	chain.AddStage(Stage{Name: "b", Processor: &mockProcessor{name: "B"}, After: []string{"a"}})
+
+	if err := chain.Validate(); err == nil || !strings.Contains(err.Error(), "circular") {
+		t.Errorf("Expected circular ordering error, got %v", err)
+	}
+	if _, err := chain.Process("x.txt", []byte("x")); err == nil {
+		t.Error("Expected Process to fail with circular constraints")
+	}
+
+	chain = NewChain()
+	chain.AddStage(Stage{Name: "a", Processor: &mockProcessor{name: "A"}, After: []string{"missing"}})
+	if err := chain.Validate(); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected unknown stage error, got %v", err)
+	}
+}
+
 func TestChain_Clear(t *testing.T) {
 	chain := NewChain()
 	chain.Add(&mockProcessor{name: "test1"})
@@ -167,3 +236,61 @@ func TestProcessorFunc(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expected, string(result))
 	}
 }
+
+func TestChain_ProcessFile(t *testing.T) {
+	var seen *FileInfo
+	chain := NewChain()
+	chain.Add(ContextProcessorFunc(func(info *FileInfo, content []byte) ([]byte, error) {
+		seen = info
+		return append(content, []byte(" from "+info.TemplatePath)...), nil
+	}))
+	chain.Add(&mockProcessor{name: "plain"})
+
+	info := &FileInfo{Path: "out.txt", TemplatePath: "out.txt.tmpl"}
+	result, err := chain.ProcessFile(info, []byte("hello"))
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if string(result) != "plain:hello from out.txt.tmpl" {
+		t.Errorf("Unexpected result %q", string(result))
+	}
+	if seen != info {
+		t.Error("Expected context processor to receive the file info")
+	}
+
+	if _, err := chain.Process("other.txt", []byte("x")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if seen.Path != "other.txt" || seen.Log() == nil {
+		t.Errorf("Expected Process to pass a path-only file info, got %+v", seen)
+	}
+}
+
+func TestDataKeys(t *testing.T) {
+	type spec struct {
+		Name    string
+		Version int
+		private bool
+	}
+
+	tests := []struct {
+		name string
+		data any
+		want string
+	}{
+		{"map", map[string]any{"b": 1, "a": 2}, "a,b"},
+		{"struct", spec{}, "Name,Version"},
+		{"pointer", &spec{}, "Name,Version"},
+		{"nil pointer", (*spec)(nil), ""},
+		{"scalar", 42, ""},
+		{"non-string keys", map[int]string{1: "x"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(DataKeys(tt.data), ","); got != tt.want {
+				t.Errorf("DataKeys() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
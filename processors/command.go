@@ -0,0 +1,90 @@
+package processors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Command is a post-processor that pipes content through an external tool,
+// such as prettier, on stdin and uses its stdout as the new content. When the
+// tool is not installed, Policy decides whether the file is left unchanged or
+// processing fails.
+//
+// Example usage:
+//
+//	prettier := processors.NewCommand("prettier", "--stdin-filepath", "{file}")
+//	prettier.Policy = processors.MissingToolFail
+type Command struct {
+	// Tool is the registry name of the external tool.
+	Tool string
+	// Args are passed to the tool. The placeholder "{file}" is replaced with
+	// the path of the file being processed.
+	Args []string
+	// Policy applies when the tool is missing (default: MissingToolWarn).
+	Policy MissingToolPolicy
+	// Timeout bounds each invocation (default: 30s).
+	Timeout time.Duration
+	// Registry detects the tool (default: DefaultTools).
+	Registry *ToolRegistry
+	// Logger receives warnings for missing tools (default: slog.Default()).
+	Logger *slog.Logger
+}
+
+// NewCommand creates a processor that runs tool with args.
+func NewCommand(tool string, args ...string) *Command {
+	return &Command{
+		Tool:    tool,
+		Args:    args,
+		Timeout: 30 * time.Second,
+	}
+}
+
+// ProcessContent implements the postprocess.Processor interface.
+func (c *Command) ProcessContent(filePath string, content []byte) ([]byte, error) {
+	registry := c.Registry
+	if registry == nil {
+		registry = DefaultTools
+	}
+	logger := c.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	ok, err := registry.Require(c.Tool, c.Policy, logger.Warn)
+	if err != nil || !ok {
+		return content, err
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = strings.ReplaceAll(arg, "{file}", filePath)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, registry.Detect(c.Tool).Path, args...)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return nil, fmt.Errorf("%s failed: %w", c.Tool, err)
+		}
+		return nil, fmt.Errorf("%s failed: %w: %s", c.Tool, err, msg)
+	}
+
+	return stdout.Bytes(), nil
+}
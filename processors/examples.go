@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+
+	"github.com/cpcf/weft/postprocess"
 )
 
 // TrimWhitespace is a processor that trims trailing whitespace from all lines.
@@ -103,7 +106,10 @@ func (a *AddGeneratedHeader) generateHeader(filePath string) string {
 }
 
 // RegexReplace is a processor that applies regex replacements to file content.
-// It's useful for custom transformations that can't be easily expressed otherwise.
+// It's useful for last-mile tweaks to generated output, such as rewriting
+// import paths for vendored modules, without modifying upstream templates.
+// Additional rules can be added with AddRule, and Matches reports which rules
+// changed which files.
 type RegexReplace struct {
 	// Pattern is the regular expression to match
 	Pattern *regexp.Regexp
@@ -111,10 +117,35 @@ type RegexReplace struct {
 	Replacement string
 	// FilePattern optionally limits processing to files matching this pattern
 	FilePattern *regexp.Regexp
+	// Matchers optionally limit the first rule to files accepted by any matcher
+	Matchers []postprocess.Matcher
+	// Rules are applied in order after the first rule
+	Rules []RegexRule
+
+	mu      sync.Mutex
+	matches []RegexMatch
+}
+
+// RegexRule is a single replacement rule.
+type RegexRule struct {
+	// Name identifies the rule in match reports (default: the pattern)
+	Name        string
+	Pattern     *regexp.Regexp
+	Replacement string
+	// Matchers optionally limit the rule to files accepted by any matcher
+	Matchers []postprocess.Matcher
 }
 
-// NewRegexReplace creates a new regex replacement processor.
-func NewRegexReplace(pattern, replacement string) (*RegexReplace, error) {
+// RegexMatch records how many replacements a rule made in a file.
+type RegexMatch struct {
+	Rule     string `json:"rule"`
+	FilePath string `json:"file_path"`
+	Count    int    `json:"count"`
+}
+
+// NewRegexReplace creates a new regex replacement processor. When matchers
+// are given, the rule only applies to files accepted by one of them.
+func NewRegexReplace(pattern, replacement string, matchers ...postprocess.Matcher) (*RegexReplace, error) {
 	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return nil, err
@@ -123,9 +154,20 @@ func NewRegexReplace(pattern, replacement string) (*RegexReplace, error) {
 	return &RegexReplace{
 		Pattern:     re,
 		Replacement: replacement,
+		Matchers:    matchers,
 	}, nil
 }
 
+// AddRule appends a replacement rule that runs after the existing ones.
+func (r *RegexReplace) AddRule(pattern, replacement string, matchers ...postprocess.Matcher) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	r.Rules = append(r.Rules, RegexRule{Pattern: re, Replacement: replacement, Matchers: matchers})
+	return nil
+}
+
 // WithFilePattern adds a file pattern filter to the processor.
 func (r *RegexReplace) WithFilePattern(filePattern string) (*RegexReplace, error) {
 	re, err := regexp.Compile(filePattern)
@@ -136,14 +178,69 @@ func (r *RegexReplace) WithFilePattern(filePattern string) (*RegexReplace, error
 	return r, nil
 }
 
-// ProcessContent applies the regex replacement to the file content.
+// ProcessContent applies the regex replacements to the file content.
 func (r *RegexReplace) ProcessContent(filePath string, content []byte) ([]byte, error) {
 	// Check file pattern if specified
 	if r.FilePattern != nil && !r.FilePattern.MatchString(filePath) {
 		return content, nil
 	}
 
-	// Apply replacement
-	result := r.Pattern.ReplaceAll(content, []byte(r.Replacement))
+	result := content
+	if r.Pattern != nil {
+		result = r.apply(RegexRule{Pattern: r.Pattern, Replacement: r.Replacement, Matchers: r.Matchers}, filePath, result)
+	}
+	for _, rule := range r.Rules {
+		result = r.apply(rule, filePath, result)
+	}
 	return result, nil
 }
+
+func (r *RegexReplace) apply(rule RegexRule, filePath string, content []byte) []byte {
+	if !matchesAny(rule.Matchers, filePath) {
+		return content
+	}
+
+	count := len(rule.Pattern.FindAllIndex(content, -1))
+	if count == 0 {
+		return content
+	}
+
+	name := rule.Name
+	if name == "" {
+		name = rule.Pattern.String()
+	}
+	r.mu.Lock()
+	r.matches = append(r.matches, RegexMatch{Rule: name, FilePath: filePath, Count: count})
+	r.mu.Unlock()
+
+	return rule.Pattern.ReplaceAll(content, []byte(rule.Replacement))
+}
+
+// Matches reports, in processing order, every rule that made replacements
+// and the file it changed.
+func (r *RegexReplace) Matches() []RegexMatch {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	matches := make([]RegexMatch, len(r.matches))
+	copy(matches, r.matches)
+	return matches
+}
+
+// ResetMatches clears the match report.
+func (r *RegexReplace) ResetMatches() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.matches = nil
+}
+
+func matchesAny(matchers []postprocess.Matcher, filePath string) bool {
+	if len(matchers) == 0 {
+		return true
+	}
+	for _, m := range matchers {
+		if m(filePath) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,10 @@
 package processors
 
 import (
+	"regexp"
 	"testing"
+
+	"github.com/cpcf/weft/postprocess"
 )
 
 func TestTrimWhitespace_ProcessContent(t *testing.T) {
@@ -176,3 +179,48 @@ func TestRegexReplace_WithFilePattern(t *testing.T) {
 		})
 	}
 }
+
+func TestRegexReplace_Rules(t *testing.T) {
+	processor, err := NewRegexReplace(`"github.com/upstream/(\w+)"`, `"example.com/vendor/$1"`, postprocess.MatchExt(".go"))
+	if err != nil {
+		t.Fatalf("NewRegexReplace() error = %v", err)
+	}
+	if err := processor.AddRule(`v1\.0`, "v1.1"); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+	processor.Rules = append(processor.Rules, RegexRule{
+		Name:        "never",
+		Pattern:     regexp.MustCompile(`unused`),
+		Replacement: "x",
+	})
+
+	result, err := processor.ProcessContent("main.go", []byte(`import "github.com/upstream/lib" // v1.0, v1.0`))
+	if err != nil {
+		t.Fatalf("ProcessContent() error = %v", err)
+	}
+	if want := `import "example.com/vendor/lib" // v1.1, v1.1`; string(result) != want {
+		t.Errorf("ProcessContent() = %q, want %q", string(result), want)
+	}
+
+	result, _ = processor.ProcessContent("README.md", []byte(`"github.com/upstream/lib" v1.0`))
+	if want := `"github.com/upstream/lib" v1.1`; string(result) != want {
+		t.Errorf("Expected matcher to limit the first rule, got %q", string(result))
+	}
+
+	matches := processor.Matches()
+	if len(matches) != 3 {
+		t.Fatalf("Expected 3 matches, got %+v", matches)
+	}
+	if matches[1].Rule != `v1\.0` || matches[1].Count != 2 || matches[1].FilePath != "main.go" {
+		t.Errorf("Unexpected match record: %+v", matches[1])
+	}
+
+	processor.ResetMatches()
+	if len(processor.Matches()) != 0 {
+		t.Error("Expected ResetMatches to clear the report")
+	}
+
+	if err := processor.AddRule(`(`, ""); err == nil {
+		t.Error("Expected invalid pattern to be rejected")
+	}
+}
@@ -16,7 +16,9 @@ import (
 // Example usage:
 //
 //	eng := engine.New()
-//	eng.AddPostProcessor(processors.NewGoImports())
+//	eng.AddPostProcessor(processors.NewGoImports(
+//		processors.WithLocalPrefix("github.com/example/project"),
+//	))
 type GoImports struct {
 	// TabWidth sets the tab width for formatting (default: 8)
 	TabWidth int
@@ -26,16 +28,64 @@ type GoImports struct {
 	AllErrors bool
 	// Comments determines whether to update comments (default: true)
 	Comments bool
+	// FormatOnly formats and sorts imports without adding missing or
+	// removing unused ones (default: false)
+	FormatOnly bool
+	// LocalPrefixes are import path prefixes grouped separately from
+	// third-party imports, like goimports -local
+	LocalPrefixes []string
+	// GroupOrder is the order of import groups (default: standard library,
+	// third-party, local). Groups not listed follow in the default order.
+	GroupOrder []ImportGroup
+	// RemoveUnusedAliases drops named imports whose name is never
+	// referenced, even when FormatOnly is set (default: false)
+	RemoveUnusedAliases bool
+}
+
+// GoImportsOption configures a GoImports processor.
+type GoImportsOption func(*GoImports)
+
+// WithLocalPrefix groups imports starting with any of prefixes after
+// third-party imports, like goimports -local.
+func WithLocalPrefix(prefixes ...string) GoImportsOption {
+	return func(g *GoImports) {
+		g.LocalPrefixes = append(g.LocalPrefixes, prefixes...)
+	}
+}
+
+// WithImportOrder sets the order of import groups.
+func WithImportOrder(groups ...ImportGroup) GoImportsOption {
+	return func(g *GoImports) {
+		g.GroupOrder = groups
+	}
+}
+
+// WithRemoveUnusedAliases drops named imports whose name is never referenced.
+func WithRemoveUnusedAliases() GoImportsOption {
+	return func(g *GoImports) {
+		g.RemoveUnusedAliases = true
+	}
+}
+
+// WithFormatOnly disables adding missing and removing unused imports.
+func WithFormatOnly() GoImportsOption {
+	return func(g *GoImports) {
+		g.FormatOnly = true
+	}
 }
 
 // NewGoImports creates a new Go imports processor with sensible defaults.
-func NewGoImports() *GoImports {
-	return &GoImports{
+func NewGoImports(opts ...GoImportsOption) *GoImports {
+	g := &GoImports{
 		TabWidth:  8,
 		TabIndent: true,
 		AllErrors: false,
 		Comments:  true,
 	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
 }
 
 // ProcessContent implements the postprocess.Processor interface.
@@ -47,11 +97,12 @@ func (g *GoImports) ProcessContent(filePath string, content []byte) ([]byte, err
 
 	// Configure goimports options
 	options := &imports.Options{
-		Fragment:  false,
-		AllErrors: g.AllErrors,
-		Comments:  g.Comments,
-		TabIndent: g.TabIndent,
-		TabWidth:  g.TabWidth,
+		Fragment:   false,
+		AllErrors:  g.AllErrors,
+		FormatOnly: g.FormatOnly,
+		Comments:   g.Comments,
+		TabIndent:  g.TabIndent,
+		TabWidth:   g.TabWidth,
 	}
 
 	// Try goimports first for full import management
@@ -65,7 +116,7 @@ func (g *GoImports) ProcessContent(filePath string, content []byte) ([]byte, err
 		return formatted, nil
 	}
 
-	return formatted, nil
+	return g.organizeImports(filePath, formatted)
 }
 
 // isGoFile checks if the file path represents a Go source file.
@@ -108,3 +108,111 @@ func TestGoImports_isGoFile(t *testing.T) {
 		})
 	}
 }
+
+func TestGoImports_ImportGrouping(t *testing.T) {
+	input := `package main
+
+import (
+	"fmt"
+	"github.com/example/project/internal/store"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3" // config files
+	"os"
+)
+
+var (
+	_ = fmt.Sprint
+	_ = os.Exit
+	_ = store.Open
+	_ = uuid.New
+	_ = yaml.Marshal
+)
+`
+
+	tests := []struct {
+		name string
+		opts []GoImportsOption
+		want string
+	}{
+		{
+			name: "local prefix",
+			opts: []GoImportsOption{WithFormatOnly(), WithLocalPrefix("github.com/example/project")},
+			want: `import (
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3" // config files
+
+	"github.com/example/project/internal/store"
+)`,
+		},
+		{
+			name: "custom order",
+			opts: []GoImportsOption{WithFormatOnly(), WithLocalPrefix("github.com/example/"), WithImportOrder(ImportLocal, ImportStdlib)},
+			want: `import (
+	"github.com/example/project/internal/store"
+
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3" // config files
+)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewGoImports(tt.opts...).ProcessContent("main.go", []byte(input))
+			if err != nil {
+				t.Fatalf("ProcessContent() error = %v", err)
+			}
+			if !strings.Contains(string(result), tt.want) {
+				t.Errorf("ProcessContent() =\n%s\nwant import block:\n%s", result, tt.want)
+			}
+
+			again, err := NewGoImports(tt.opts...).ProcessContent("main.go", result)
+			if err != nil || string(again) != string(result) {
+				t.Errorf("Expected grouping to be idempotent, got:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestGoImports_RemoveUnusedAliases(t *testing.T) {
+	input := `package main
+
+import (
+	_ "embed"
+	str "strings"
+	yml "gopkg.in/yaml.v3"
+	"fmt"
+)
+
+func main() { fmt.Println(str.ToUpper("x")) }
+`
+
+	result, err := NewGoImports(WithFormatOnly(), WithRemoveUnusedAliases()).ProcessContent("main.go", []byte(input))
+	if err != nil {
+		t.Fatalf("ProcessContent() error = %v", err)
+	}
+
+	got := string(result)
+	if strings.Contains(got, "yml") {
+		t.Errorf("Expected unused aliased import to be removed, got:\n%s", got)
+	}
+	for _, want := range []string{`_ "embed"`, `str "strings"`, `"fmt"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %s to be kept, got:\n%s", want, got)
+		}
+	}
+
+	kept, err := NewGoImports(WithFormatOnly()).ProcessContent("main.go", []byte(input))
+	if err != nil {
+		t.Fatalf("ProcessContent() error = %v", err)
+	}
+	if !strings.Contains(string(kept), "yml") {
+		t.Error("Expected FormatOnly to keep imports without WithRemoveUnusedAliases")
+	}
+}
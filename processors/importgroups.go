@@ -0,0 +1,237 @@
+package processors
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// ImportGroup is a class of imports that GoImports keeps together, separated
+// from other groups by a blank line.
+type ImportGroup int
+
+const (
+	// ImportStdlib is the standard library.
+	ImportStdlib ImportGroup = iota
+	// ImportThirdParty is every import that is neither standard nor local.
+	ImportThirdParty
+	// ImportLocal is imports matching GoImports.LocalPrefixes.
+	ImportLocal
+)
+
+func (g ImportGroup) String() string {
+	switch g {
+	case ImportStdlib:
+		return "stdlib"
+	case ImportThirdParty:
+		return "third-party"
+	case ImportLocal:
+		return "local"
+	default:
+		return "unknown"
+	}
+}
+
+var defaultGroupOrder = []ImportGroup{ImportStdlib, ImportThirdParty, ImportLocal}
+
+// organizeImports applies alias removal and grouping to goimports output.
+func (g *GoImports) organizeImports(filePath string, src []byte) ([]byte, error) {
+	regroup := len(g.LocalPrefixes) > 0 || len(g.GroupOrder) > 0
+	if !regroup && !g.RemoveUnusedAliases {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+
+	if g.RemoveUnusedAliases && removeUnusedAliases(fset, file) {
+		var buf bytes.Buffer
+		if err := format.Node(&buf, fset, file); err != nil {
+			return nil, fmt.Errorf("failed to format %s: %w", filePath, err)
+		}
+		src = buf.Bytes()
+		if file, err = parser.ParseFile(fset, filePath, src, parser.ParseComments); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+		}
+	}
+
+	if !regroup {
+		return src, nil
+	}
+
+	grouped, ok := g.regroupImports(fset, file, src)
+	if !ok {
+		return src, nil
+	}
+	formatted, err := format.Source(grouped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", filePath, err)
+	}
+	return formatted, nil
+}
+
+// removeUnusedAliases deletes named imports whose name is not used in a
+// selector expression. Blank and dot imports are kept.
+func removeUnusedAliases(fset *token.FileSet, file *ast.File) bool {
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	removed := false
+	for _, imp := range append([]*ast.ImportSpec(nil), file.Imports...) {
+		if imp.Name == nil || imp.Name.Name == "_" || imp.Name.Name == "." || used[imp.Name.Name] {
+			continue
+		}
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if astutil.DeleteNamedImport(fset, file, imp.Name.Name, path) {
+			removed = true
+		}
+	}
+	return removed
+}
+
+// importUnit is an import spec together with its comments.
+type importUnit struct {
+	group ImportGroup
+	path  string
+	name  string
+	text  string
+}
+
+// regroupImports rewrites the file's import block into groups in the
+// configured order, sorted by path within each group. It reports false and
+// leaves the file alone when the imports are not in a single parenthesised
+// declaration, include cgo's "C", or have comments not attached to a spec.
+func (g *GoImports) regroupImports(fset *token.FileSet, file *ast.File, src []byte) ([]byte, bool) {
+	var decl *ast.GenDecl
+	for _, d := range file.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			break
+		}
+		if decl != nil {
+			return nil, false
+		}
+		decl = gd
+	}
+	if decl == nil || !decl.Lparen.IsValid() || len(decl.Specs) == 0 {
+		return nil, false
+	}
+
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	attached := make(map[*ast.CommentGroup]bool)
+	units := make([]importUnit, 0, len(decl.Specs))
+	for _, spec := range decl.Specs {
+		imp := spec.(*ast.ImportSpec)
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || path == "C" {
+			return nil, false
+		}
+
+		start, end := imp.Pos(), imp.End()
+		if imp.Doc != nil {
+			start = imp.Doc.Pos()
+			attached[imp.Doc] = true
+		}
+		if imp.Comment != nil {
+			end = imp.Comment.End()
+			attached[imp.Comment] = true
+		}
+
+		name := ""
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		units = append(units, importUnit{
+			group: g.classifyImport(path),
+			path:  path,
+			name:  name,
+			text:  string(src[offset(start):offset(end)]),
+		})
+	}
+
+	for _, cg := range file.Comments {
+		if cg.Pos() > decl.Lparen && cg.End() < decl.Rparen && !attached[cg] {
+			return nil, false
+		}
+	}
+
+	rank := g.groupRanks()
+	sort.SliceStable(units, func(i, j int) bool {
+		if units[i].group != units[j].group {
+			return rank[units[i].group] < rank[units[j].group]
+		}
+		if units[i].path != units[j].path {
+			return units[i].path < units[j].path
+		}
+		return units[i].name < units[j].name
+	})
+
+	var block bytes.Buffer
+	block.WriteString("(\n")
+	for i, u := range units {
+		if i > 0 && u.group != units[i-1].group {
+			block.WriteString("\n")
+		}
+		block.WriteString("\t" + u.text + "\n")
+	}
+	block.WriteString(")")
+
+	var out bytes.Buffer
+	out.Write(src[:offset(decl.Lparen)])
+	out.Write(block.Bytes())
+	out.Write(src[offset(decl.Rparen)+1:])
+	return out.Bytes(), true
+}
+
+// classifyImport returns the group of an import path. Like goimports, paths
+// whose first element has no dot are treated as standard library.
+func (g *GoImports) classifyImport(path string) ImportGroup {
+	for _, prefix := range g.LocalPrefixes {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			continue
+		}
+		if strings.HasPrefix(path, prefix) || path == strings.TrimSuffix(prefix, "/") {
+			return ImportLocal
+		}
+	}
+
+	first, _, _ := strings.Cut(path, "/")
+	if !strings.Contains(first, ".") {
+		return ImportStdlib
+	}
+	return ImportThirdParty
+}
+
+// groupRanks maps each group to its position in the configured order.
+func (g *GoImports) groupRanks() map[ImportGroup]int {
+	rank := make(map[ImportGroup]int)
+	for _, group := range append(append([]ImportGroup(nil), g.GroupOrder...), defaultGroupOrder...) {
+		if _, ok := rank[group]; !ok {
+			rank[group] = len(rank)
+		}
+	}
+	return rank
+}
@@ -0,0 +1,199 @@
+package processors
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// CommentStyle describes how to turn text into a comment in a file format.
+// Line styles prefix every line with Line; block styles wrap the text in
+// Start and End.
+type CommentStyle struct {
+	Line  string
+	Start string
+	End   string
+}
+
+// Comment formats text as a comment in this style.
+func (c CommentStyle) Comment(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+
+	var b strings.Builder
+	if c.Line != "" {
+		for _, line := range lines {
+			if line == "" {
+				b.WriteString(c.Line + "\n")
+				continue
+			}
+			b.WriteString(c.Line + " " + line + "\n")
+		}
+		return b.String()
+	}
+
+	b.WriteString(c.Start + "\n")
+	for _, line := range lines {
+		b.WriteString(line + "\n")
+	}
+	b.WriteString(c.End + "\n")
+	return b.String()
+}
+
+var (
+	slashComment = CommentStyle{Line: "//"}
+	hashComment  = CommentStyle{Line: "#"}
+	dashComment  = CommentStyle{Line: "--"}
+	htmlComment  = CommentStyle{Start: "<!--", End: "-->"}
+	cssComment   = CommentStyle{Start: "/*", End: "*/"}
+)
+
+// DefaultCommentStyles maps file extensions to their comment style.
+var DefaultCommentStyles = map[string]CommentStyle{
+	".go":    slashComment,
+	".java":  slashComment,
+	".js":    slashComment,
+	".jsx":   slashComment,
+	".ts":    slashComment,
+	".tsx":   slashComment,
+	".rs":    slashComment,
+	".c":     slashComment,
+	".h":     slashComment,
+	".cpp":   slashComment,
+	".cs":    slashComment,
+	".kt":    slashComment,
+	".swift": slashComment,
+	".proto": slashComment,
+	".py":    hashComment,
+	".rb":    hashComment,
+	".sh":    hashComment,
+	".yaml":  hashComment,
+	".yml":   hashComment,
+	".toml":  hashComment,
+	".tf":    hashComment,
+	".sql":   dashComment,
+	".lua":   dashComment,
+	".html":  htmlComment,
+	".xml":   htmlComment,
+	".md":    htmlComment,
+	".vue":   htmlComment,
+	".svg":   htmlComment,
+	".css":   cssComment,
+	".scss":  cssComment,
+}
+
+// LicenseHeader is a post-processor that prepends a license header rendered
+// from a template, commented in the style of each file type. Files whose
+// extension has no known comment style are left unchanged, as are files that
+// already start with the header.
+//
+// The template can use any key from Vars, plus Year (the current year unless
+// set in Vars), File (the path being processed) and Ext (its extension).
+//
+// Example usage:
+//
+//	header, err := processors.NewLicenseHeader(
+//		"SPDX-License-Identifier: {{.License}}\nCopyright {{.Year}} {{.Author}}",
+//		map[string]any{"License": "Apache-2.0", "Author": "Example Corp"},
+//	)
+type LicenseHeader struct {
+	// Vars are the template variables.
+	Vars map[string]any
+	// Styles overrides or extends DefaultCommentStyles by extension.
+	Styles map[string]CommentStyle
+	// Clock supplies the current year (default: time.Now). Set it to
+	// engine.Clock() to keep deterministic runs reproducible.
+	Clock func() time.Time
+
+	tmpl *template.Template
+}
+
+// NewLicenseHeader creates a license header processor from templateText.
+func NewLicenseHeader(templateText string, vars map[string]any) (*LicenseHeader, error) {
+	tmpl, err := template.New("license").Option("missingkey=error").Parse(templateText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid license header template: %w", err)
+	}
+
+	return &LicenseHeader{
+		Vars: vars,
+		tmpl: tmpl,
+	}, nil
+}
+
+// ProcessContent prepends the license header to the file content.
+func (l *LicenseHeader) ProcessContent(filePath string, content []byte) ([]byte, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	style, ok := l.styleFor(ext)
+	if !ok {
+		return content, nil
+	}
+
+	text, err := l.render(filePath, ext)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return content, nil
+	}
+	header := style.Comment(text)
+
+	// Keep lines that must stay first, such as shebangs and XML declarations.
+	prefix, body := splitPreamble(content)
+	if bytes.HasPrefix(body, []byte(header)) {
+		return content, nil
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(content) + len(header) + 1)
+	out.Write(prefix)
+	out.WriteString(header)
+	out.WriteString("\n")
+	out.Write(body)
+	return out.Bytes(), nil
+}
+
+func (l *LicenseHeader) styleFor(ext string) (CommentStyle, bool) {
+	if style, ok := l.Styles[ext]; ok {
+		return style, true
+	}
+	style, ok := DefaultCommentStyles[ext]
+	return style, ok
+}
+
+func (l *LicenseHeader) render(filePath, ext string) (string, error) {
+	clock := l.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	data := map[string]any{
+		"Year": clock().Year(),
+		"File": filePath,
+		"Ext":  ext,
+	}
+	maps.Copy(data, l.Vars)
+
+	var buf strings.Builder
+	if err := l.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render license header for %s: %w", filePath, err)
+	}
+	return buf.String(), nil
+}
+
+// splitPreamble separates a leading shebang or XML declaration line from the
+// rest of the content.
+func splitPreamble(content []byte) ([]byte, []byte) {
+	if !bytes.HasPrefix(content, []byte("#!")) && !bytes.HasPrefix(content, []byte("<?xml")) {
+		return nil, content
+	}
+
+	end := bytes.IndexByte(content, '\n')
+	if end < 0 {
+		return append(content, '\n'), nil
+	}
+	return content[:end+1], content[end+1:]
+}
@@ -0,0 +1,93 @@
+package processors
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLicenseHeader_ProcessContent(t *testing.T) {
+	header, err := NewLicenseHeader(
+		"SPDX-License-Identifier: {{.License}}\nCopyright {{.Year}} {{.Author}}",
+		map[string]any{"License": "MIT", "Author": "Example Corp"},
+	)
+	if err != nil {
+		t.Fatalf("NewLicenseHeader() error = %v", err)
+	}
+	header.Clock = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		filePath string
+		input    string
+		want     string
+	}{
+		{
+			name:     "line comments for go",
+			filePath: "main.go",
+			input:    "package main\n",
+			want:     "// SPDX-License-Identifier: MIT\n// Copyright 2024 Example Corp\n\npackage main\n",
+		},
+		{
+			name:     "hash comments keep shebang first",
+			filePath: "run.sh",
+			input:    "#!/bin/sh\necho hi\n",
+			want:     "#!/bin/sh\n# SPDX-License-Identifier: MIT\n# Copyright 2024 Example Corp\n\necho hi\n",
+		},
+		{
+			name:     "block comments for html",
+			filePath: "index.html",
+			input:    "<html></html>\n",
+			want:     "<!--\nSPDX-License-Identifier: MIT\nCopyright 2024 Example Corp\n-->\n\n<html></html>\n",
+		},
+		{
+			name:     "unknown extension unchanged",
+			filePath: "data.bin",
+			input:    "raw",
+			want:     "raw",
+		},
+		{
+			name:     "existing header unchanged",
+			filePath: "schema.sql",
+			input:    "-- SPDX-License-Identifier: MIT\n-- Copyright 2024 Example Corp\n\nSELECT 1;\n",
+			want:     "-- SPDX-License-Identifier: MIT\n-- Copyright 2024 Example Corp\n\nSELECT 1;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := header.ProcessContent(tt.filePath, []byte(tt.input))
+			if err != nil {
+				t.Fatalf("ProcessContent() error = %v", err)
+			}
+
+			if string(result) != tt.want {
+				t.Errorf("ProcessContent() = %q, want %q", string(result), tt.want)
+			}
+		})
+	}
+}
+
+func TestLicenseHeader_Errors(t *testing.T) {
+	if _, err := NewLicenseHeader("{{.Unclosed", nil); err == nil {
+		t.Error("Expected parse error")
+	}
+
+	header, err := NewLicenseHeader("Copyright {{.Author}}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := header.ProcessContent("main.go", []byte("package main\n")); err == nil || !strings.Contains(err.Error(), "main.go") {
+		t.Errorf("Expected missing variable error, got %v", err)
+	}
+
+	header.Styles = map[string]CommentStyle{".go": {Line: ";;"}}
+	header.Vars = map[string]any{"Author": "Me"}
+	result, err := header.ProcessContent("main.go", []byte("package main\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(result), ";; Copyright Me\n") {
+		t.Errorf("Expected style override, got %q", string(result))
+	}
+}
@@ -0,0 +1,315 @@
+package processors
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// DefaultTOCMarker marks where Markdown inserts the table of contents.
+const DefaultTOCMarker = "<!-- toc -->"
+
+// Markdown is a post-processor for generated Markdown documents. It injects a
+// table of contents at a marker, rewrites intra-document links to the
+// canonical anchors of their headings and reports links to anchors that do
+// not exist.
+//
+// Anchors follow GitHub's rules: headings are lower-cased, punctuation is
+// dropped, spaces become hyphens and duplicates get a numeric suffix. A
+// heading can set its own anchor with a trailing {#id}. Only ATX headings
+// ("## Title") are recognised, and fenced code blocks are skipped.
+//
+// Example usage:
+//
+//	eng.AddPostProcessor(processors.NewMarkdown())
+type Markdown struct {
+	// TOCMarker is the line replaced by the table of contents (default:
+	// DefaultTOCMarker). A previously generated table, up to the matching
+	// closing marker, is replaced on later runs. Empty disables the TOC.
+	TOCMarker string
+	// MinLevel and MaxLevel bound the heading levels listed in the table of
+	// contents (default: 2 and 3)
+	MinLevel int
+	MaxLevel int
+	// CheckLinks fails processing when a "#fragment" link does not resolve
+	// to a heading (default: true)
+	CheckLinks bool
+}
+
+// NewMarkdown creates a Markdown processor with sensible defaults.
+func NewMarkdown() *Markdown {
+	return &Markdown{
+		TOCMarker:  DefaultTOCMarker,
+		MinLevel:   2,
+		MaxLevel:   3,
+		CheckLinks: true,
+	}
+}
+
+var (
+	atxHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	headingID     = regexp.MustCompile(`[ \t]*\{#([^}\s]+)\}$`)
+	fragmentLink  = regexp.MustCompile(`\]\(#([^)\s]*)\)`)
+	fragmentRef   = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:[ \t]*#(\S*)`)
+	codeSpan      = regexp.MustCompile("`+[^`]*`+")
+	inlineLink    = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	emphasisChars = strings.NewReplacer("`", "", "*", "", "~~", "")
+)
+
+type markdownHeading struct {
+	level  int
+	text   string
+	anchor string
+}
+
+// ProcessContent processes .md and .markdown files and leaves other files
+// unchanged.
+func (m *Markdown) ProcessContent(filePath string, content []byte) ([]byte, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext != ".md" && ext != ".markdown" {
+		return content, nil
+	}
+
+	lines := strings.Split(string(content), "\n")
+	lines = m.stripTOC(lines)
+
+	inFence := fenceTracker()
+	var headings []markdownHeading
+	seen := make(map[string]int)
+	for _, line := range lines {
+		if inFence(line) {
+			continue
+		}
+		match := atxHeading.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		text := match[2]
+		anchor := ""
+		if id := headingID.FindStringSubmatch(text); id != nil {
+			anchor = id[1]
+			text = strings.TrimSpace(text[:len(text)-len(id[0])])
+		} else {
+			anchor = headingSlug(text)
+			if n := seen[anchor]; n > 0 {
+				anchor = fmt.Sprintf("%s-%d", anchor, n)
+			}
+		}
+		seen[anchor]++
+		headings = append(headings, markdownHeading{level: len(match[1]), text: plainHeadingText(text), anchor: anchor})
+	}
+
+	resolve := anchorResolver(headings)
+	var broken []string
+	inFence = fenceTracker()
+	for i, line := range lines {
+		if inFence(line) {
+			continue
+		}
+		lines[i] = rewriteFragments(line, resolve, func(fragment string) {
+			broken = append(broken, fmt.Sprintf("%s:%d: link to missing anchor #%s", filePath, i+1, fragment))
+		})
+	}
+	if m.CheckLinks && len(broken) > 0 {
+		return nil, fmt.Errorf("broken intra-document links:\n%s", strings.Join(broken, "\n"))
+	}
+
+	lines = m.insertTOC(lines, headings)
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// tocEnd returns the closing marker for the table of contents.
+func (m *Markdown) tocEnd() string {
+	if strings.HasPrefix(m.TOCMarker, "<!--") {
+		return "<!-- /" + strings.TrimSpace(strings.TrimPrefix(m.TOCMarker, "<!--"))
+	}
+	return m.TOCMarker
+}
+
+// stripTOC removes a previously generated table of contents, keeping the
+// opening marker.
+func (m *Markdown) stripTOC(lines []string) []string {
+	if m.TOCMarker == "" {
+		return lines
+	}
+	end := m.tocEnd()
+	for i, line := range lines {
+		if strings.TrimSpace(line) != m.TOCMarker {
+			continue
+		}
+		for j := i + 1; j < len(lines); j++ {
+			if strings.TrimSpace(lines[j]) == end {
+				return append(lines[:i+1], lines[j+1:]...)
+			}
+		}
+		return lines
+	}
+	return lines
+}
+
+// insertTOC writes the table of contents after the first marker line.
+func (m *Markdown) insertTOC(lines []string, headings []markdownHeading) []string {
+	if m.TOCMarker == "" {
+		return lines
+	}
+
+	inFence := fenceTracker()
+	for i, line := range lines {
+		if inFence(line) || strings.TrimSpace(line) != m.TOCMarker {
+			continue
+		}
+
+		minLevel, maxLevel := m.MinLevel, m.MaxLevel
+		if minLevel <= 0 {
+			minLevel = 1
+		}
+		if maxLevel <= 0 {
+			maxLevel = 6
+		}
+
+		toc := []string{line}
+		for _, h := range headings {
+			if h.level < minLevel || h.level > maxLevel {
+				continue
+			}
+			indent := strings.Repeat("  ", h.level-minLevel)
+			toc = append(toc, fmt.Sprintf("%s- [%s](#%s)", indent, h.text, h.anchor))
+		}
+		toc = append(toc, m.tocEnd())
+
+		out := make([]string, 0, len(lines)+len(toc))
+		out = append(out, lines[:i]...)
+		out = append(out, toc...)
+		return append(out, lines[i+1:]...)
+	}
+	return lines
+}
+
+// fenceTracker returns a function that reports whether a line is part of a
+// fenced code block, including the fences themselves.
+func fenceTracker() func(line string) bool {
+	fence := ""
+	return func(line string) bool {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1])) == "" {
+				fence = ""
+			}
+			return true
+		}
+		for _, marker := range []string{"```", "~~~"} {
+			if strings.HasPrefix(trimmed, marker) {
+				fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, marker[:1]))]
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// headingSlug returns the GitHub-style anchor for heading text.
+func headingSlug(text string) string {
+	text = strings.ToLower(plainHeadingText(text))
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// plainHeadingText strips inline links and emphasis from heading text.
+func plainHeadingText(text string) string {
+	text = inlineLink.ReplaceAllString(text, "$1")
+	return strings.TrimSpace(emphasisChars.Replace(text))
+}
+
+// anchorResolver maps link fragments to heading anchors. Fragments that
+// differ from an anchor only in case, encoding or punctuation resolve to
+// that anchor when the match is unambiguous.
+func anchorResolver(headings []markdownHeading) func(fragment string) (string, bool) {
+	exact := make(map[string]bool)
+	loose := make(map[string][]string)
+	for _, h := range headings {
+		exact[h.anchor] = true
+		key := looseAnchorKey(h.anchor)
+		loose[key] = append(loose[key], h.anchor)
+	}
+
+	return func(fragment string) (string, bool) {
+		if exact[fragment] {
+			return fragment, true
+		}
+		decoded, err := url.PathUnescape(fragment)
+		if err != nil {
+			decoded = fragment
+		}
+		if slug := headingSlug(decoded); exact[slug] {
+			return slug, true
+		}
+		if candidates := loose[looseAnchorKey(decoded)]; len(candidates) == 1 {
+			return candidates[0], true
+		}
+		return "", false
+	}
+}
+
+func looseAnchorKey(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// rewriteFragments replaces "#fragment" link targets in line with their
+// canonical anchors and calls broken for targets that do not resolve. Links
+// inside code spans are ignored.
+func rewriteFragments(line string, resolve func(string) (string, bool), broken func(string)) string {
+	if m := fragmentRef.FindStringSubmatchIndex(line); m != nil {
+		return replaceFragment(line, m[2], m[3], resolve, broken)
+	}
+
+	spans := codeSpan.FindAllStringIndex(line, -1)
+	inCode := func(pos int) bool {
+		for _, s := range spans {
+			if pos >= s[0] && pos < s[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	matches := fragmentLink.FindAllStringSubmatchIndex(line, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+		if inCode(m[0]) {
+			continue
+		}
+		line = replaceFragment(line, m[2], m[3], resolve, broken)
+	}
+	return line
+}
+
+func replaceFragment(line string, start, end int, resolve func(string) (string, bool), broken func(string)) string {
+	fragment := line[start:end]
+	if fragment == "" {
+		return line
+	}
+	anchor, ok := resolve(fragment)
+	if !ok {
+		broken(fragment)
+		return line
+	}
+	return line[:start] + anchor + line[end:]
+}
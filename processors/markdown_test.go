@@ -0,0 +1,79 @@
+package processors
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdown_ProcessContent(t *testing.T) {
+	input := "# API Reference\n\n<!-- toc -->\n\nSee [the users API](#Users-API) and [errors](#errors).\n\n## Users API\n\n### `GET /users`\n\n```\n## Not a heading\n[skip](#nowhere)\n```\n\n## Errors\n\n## Errors\n\n## Pagination {#paging}\n\nUse `[x](#ignored)` and [paging](#paging).\n"
+
+	processor := NewMarkdown()
+	result, err := processor.ProcessContent("api.md", []byte(input))
+	if err != nil {
+		t.Fatalf("ProcessContent() error = %v", err)
+	}
+
+	got := string(result)
+	wantTOC := "<!-- toc -->\n- [Users API](#users-api)\n  - [GET /users](#get-users)\n- [Errors](#errors)\n- [Errors](#errors-1)\n- [Pagination](#paging)\n<!-- /toc -->\n"
+	if !strings.Contains(got, wantTOC) {
+		t.Errorf("Expected table of contents:\n%s\ngot:\n%s", wantTOC, got)
+	}
+	if !strings.Contains(got, "[the users API](#users-api)") {
+		t.Errorf("Expected link to be normalized to the heading anchor, got:\n%s", got)
+	}
+	if strings.Contains(got, "Not a heading](") {
+		t.Error("Expected headings in code blocks to be ignored")
+	}
+
+	again, err := processor.ProcessContent("api.md", result)
+	if err != nil {
+		t.Fatalf("ProcessContent() on own output error = %v", err)
+	}
+	if string(again) != got {
+		t.Errorf("Expected processing to be idempotent, got:\n%s", again)
+	}
+}
+
+func TestMarkdown_BrokenLinks(t *testing.T) {
+	input := "# Doc\n\n## Setup\n\nSee [install](#install).\n\n[ref]: #missing\n"
+
+	_, err := NewMarkdown().ProcessContent("doc.md", []byte(input))
+	if err == nil {
+		t.Fatal("Expected broken links to fail")
+	}
+	for _, want := range []string{"doc.md:5: link to missing anchor #install", "doc.md:7: link to missing anchor #missing"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got %v", want, err)
+		}
+	}
+
+	processor := NewMarkdown()
+	processor.CheckLinks = false
+	if _, err := processor.ProcessContent("doc.md", []byte(input)); err != nil {
+		t.Errorf("Expected CheckLinks=false to ignore broken links, got %v", err)
+	}
+}
+
+func TestMarkdown_NonMarkdownUnchanged(t *testing.T) {
+	input := "[broken](#nowhere)"
+	result, err := NewMarkdown().ProcessContent("notes.txt", []byte(input))
+	if err != nil || string(result) != input {
+		t.Errorf("Expected non-Markdown file to be unchanged, got %q, %v", result, err)
+	}
+}
+
+func TestHeadingSlug(t *testing.T) {
+	tests := map[string]string{
+		"Getting Started":          "getting-started",
+		"What's new in v2.0?":      "whats-new-in-v20",
+		"`Config.Load` method":     "configload-method",
+		"[Links](http://x) & more": "links--more",
+		"snake_case-name":          "snake_case-name",
+	}
+	for text, want := range tests {
+		if got := headingSlug(text); got != want {
+			t.Errorf("headingSlug(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package processors
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SQLDialect selects dialect-specific quoting, comment and keyword rules.
+type SQLDialect string
+
+const (
+	SQLPostgres SQLDialect = "postgres"
+	SQLMySQL    SQLDialect = "mysql"
+	SQLSQLite   SQLDialect = "sqlite"
+)
+
+// KeywordCase controls how SQL keywords are cased.
+type KeywordCase int
+
+const (
+	// KeywordUpper upper-cases keywords (default).
+	KeywordUpper KeywordCase = iota
+	// KeywordLower lower-cases keywords.
+	KeywordLower
+	// KeywordPreserve leaves keywords as written.
+	KeywordPreserve
+)
+
+// SQLFormat is a post-processor that formats generated .sql files: keywords
+// are cased consistently, each major clause starts on its own line, column
+// definitions in CREATE TABLE and rows in VALUES get one line each, and
+// statements are separated by a blank line. Strings, quoted identifiers and
+// comments are preserved verbatim.
+//
+// Example usage:
+//
+//	eng.AddPostProcessor(processors.NewSQLFormat(processors.SQLPostgres))
+type SQLFormat struct {
+	// Dialect selects quoting and keyword rules (default: postgres)
+	Dialect SQLDialect
+	// KeywordCase controls keyword casing (default: KeywordUpper)
+	KeywordCase KeywordCase
+	// Indent is the indentation string (default: two spaces)
+	Indent string
+}
+
+// NewSQLFormat creates a SQL formatting processor for dialect.
+func NewSQLFormat(dialect SQLDialect) *SQLFormat {
+	return &SQLFormat{
+		Dialect:     dialect,
+		KeywordCase: KeywordUpper,
+		Indent:      "  ",
+	}
+}
+
+// ProcessContent formats .sql files and leaves other files unchanged.
+func (s *SQLFormat) ProcessContent(filePath string, content []byte) ([]byte, error) {
+	if strings.ToLower(filepath.Ext(filePath)) != ".sql" {
+		return content, nil
+	}
+	if strings.TrimSpace(string(content)) == "" {
+		return content, nil
+	}
+
+	tokens := tokenizeSQL(string(content), s.Dialect)
+	return []byte(s.format(tokens)), nil
+}
+
+type sqlTokenKind int
+
+const (
+	sqlWord sqlTokenKind = iota
+	sqlQuoted
+	sqlNumber
+	sqlPunct
+	sqlLineComment
+	sqlBlockComment
+)
+
+type sqlToken struct {
+	kind          sqlTokenKind
+	text          string
+	spaceBefore   bool
+	newlineBefore bool
+}
+
+// upper returns the upper-cased text of word tokens, and "" otherwise.
+func (t sqlToken) upper() string {
+	if t.kind != sqlWord {
+		return ""
+	}
+	return strings.ToUpper(t.text)
+}
+
+var (
+	sqlOperators  = []string{"->>", "::", "<=", ">=", "<>", "!=", "||", "->", "=>"}
+	dollarQuoteRe = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+)
+
+func tokenizeSQL(src string, dialect SQLDialect) []sqlToken {
+	var tokens []sqlToken
+	space, newline := false, false
+
+	emit := func(kind sqlTokenKind, text string) {
+		tokens = append(tokens, sqlToken{kind: kind, text: text, spaceBefore: space, newlineBefore: newline})
+		space, newline = false, false
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		rest := src[i:]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			space = true
+			newline = newline || c == '\n'
+			i++
+
+		case strings.HasPrefix(rest, "--") || (c == '#' && dialect == SQLMySQL):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			emit(sqlLineComment, strings.TrimRight(rest[:end], " \t\r"))
+			i += end
+
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 4
+			}
+			emit(sqlBlockComment, rest[:end])
+			i += end
+
+		case c == '\'':
+			n := quotedLength(rest, '\'', dialect == SQLMySQL)
+			emit(sqlQuoted, rest[:n])
+			i += n
+
+		case c == '"':
+			n := quotedLength(rest, '"', dialect == SQLMySQL)
+			emit(sqlQuoted, rest[:n])
+			i += n
+
+		case c == '`' && (dialect == SQLMySQL || dialect == SQLSQLite):
+			n := quotedLength(rest, '`', false)
+			emit(sqlQuoted, rest[:n])
+			i += n
+
+		case c == '[' && dialect == SQLSQLite:
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			emit(sqlQuoted, rest[:end+1])
+			i += end + 1
+
+		case c == '$' && dialect != SQLMySQL && dollarQuoteRe.MatchString(rest):
+			tag := dollarQuoteRe.FindString(rest)
+			end := strings.Index(rest[len(tag):], tag)
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 2 * len(tag)
+			}
+			emit(sqlQuoted, rest[:end])
+			i += end
+
+		case c >= '0' && c <= '9':
+			n := 1
+			for n < len(rest) && (isDigit(rest[n]) || rest[n] == '.' ||
+				((rest[n] == 'e' || rest[n] == 'E') && n+1 < len(rest) && (isDigit(rest[n+1]) || rest[n+1] == '-' || rest[n+1] == '+')) ||
+				((rest[n] == '-' || rest[n] == '+') && (rest[n-1] == 'e' || rest[n-1] == 'E'))) {
+				n++
+			}
+			emit(sqlNumber, rest[:n])
+			i += n
+
+		case isSQLWordStart(rest):
+			n := 0
+			for n < len(rest) {
+				r, size := utf8.DecodeRuneInString(rest[n:])
+				if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$' || r == '@') {
+					break
+				}
+				n += size
+			}
+			emit(sqlWord, rest[:n])
+			i += n
+
+		default:
+			op := string(c)
+			for _, candidate := range sqlOperators {
+				if strings.HasPrefix(rest, candidate) {
+					op = candidate
+					break
+				}
+			}
+			emit(sqlPunct, op)
+			i += len(op)
+		}
+	}
+
+	return tokens
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isSQLWordStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLetter(r) || r == '_' || r == '@' || r == '$'
+}
+
+// quotedLength returns the length of the quoted token at the start of s,
+// handling doubled quotes and, optionally, backslash escapes.
+func quotedLength(s string, quote byte, backslash bool) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case backslash && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// sqlClauseStarts are keywords that begin a new line at statement level.
+var sqlClauseStarts = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "GROUP": true, "ORDER": true,
+	"HAVING": true, "LIMIT": true, "OFFSET": true, "UNION": true, "INTERSECT": true,
+	"EXCEPT": true, "VALUES": true, "SET": true, "RETURNING": true, "JOIN": true,
+	"LEFT": true, "RIGHT": true, "INNER": true, "FULL": true, "CROSS": true,
+	"NATURAL": true, "WINDOW": true,
+}
+
+// sqlJoinModifiers precede JOIN without starting a new line themselves.
+var sqlJoinModifiers = map[string]bool{
+	"LEFT": true, "RIGHT": true, "INNER": true, "FULL": true, "CROSS": true,
+	"NATURAL": true, "OUTER": true,
+}
+
+var sqlKeywords = wordSet(`ADD ALL ALTER AND ANY AS ASC BEGIN BETWEEN BIGINT BOOLEAN BY CASCADE CASE
+CHECK COLUMN COMMIT CONFLICT CONSTRAINT CREATE CROSS CURRENT_DATE CURRENT_TIMESTAMP DATABASE DATE
+DEFAULT DELETE DESC DISTINCT DO DROP ELSE END EXCEPT EXISTS FALSE FOREIGN FROM FULL GRANT GROUP
+HAVING IF IN INDEX INNER INSERT INTEGER INT INTERSECT INTO IS JOIN KEY LEFT LIKE LIMIT NATURAL NOT
+NOTHING NULL OFFSET ON OR ORDER OUTER PRIMARY REFERENCES RENAME RESTRICT RIGHT ROLLBACK SCHEMA
+SELECT SET SMALLINT TABLE TEXT THEN TIMESTAMP TO TRANSACTION TRIGGER TRUE UNION UNIQUE UPDATE
+USING VALUES VARCHAR CHAR VIEW WHEN WHERE WINDOW WITH REAL NUMERIC DECIMAL FLOAT DOUBLE PRECISION
+BLOB TIME INTERVAL ACTION NO FUNCTION PROCEDURE RETURNS LANGUAGE`)
+
+var sqlDialectKeywords = map[SQLDialect]map[string]bool{
+	SQLPostgres: wordSet(`RETURNING SERIAL BIGSERIAL SMALLSERIAL JSONB JSON UUID BYTEA ILIKE
+TIMESTAMPTZ EXTENSION SEQUENCE OWNED COMMENT CONCURRENTLY MATERIALIZED TYPE ENUM`),
+	SQLMySQL: wordSet(`AUTO_INCREMENT ENGINE UNSIGNED CHARSET COLLATE TINYINT MEDIUMINT
+LONGTEXT MEDIUMTEXT DATETIME ENUM JSON SHOW DESCRIBE`),
+	SQLSQLite: wordSet(`AUTOINCREMENT PRAGMA WITHOUT ROWID VACUUM ATTACH DETACH GLOB REPLACE
+ABORT FAIL IGNORE STRICT`),
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+func (s *SQLFormat) isKeyword(upper string) bool {
+	return sqlKeywords[upper] || sqlDialectKeywords[s.Dialect][upper]
+}
+
+// sqlWriter accumulates formatted SQL.
+type sqlWriter struct {
+	b         strings.Builder
+	indent    string
+	lineStart bool
+}
+
+func (w *sqlWriter) newline(level int) {
+	w.b.WriteString("\n")
+	w.b.WriteString(strings.Repeat(w.indent, level))
+	w.lineStart = true
+}
+
+func (w *sqlWriter) write(text string, space bool) {
+	if space && !w.lineStart {
+		w.b.WriteString(" ")
+	}
+	w.b.WriteString(text)
+	w.lineStart = false
+}
+
+func (s *SQLFormat) format(tokens []sqlToken) string {
+	indent := s.Indent
+	if indent == "" {
+		indent = "  "
+	}
+	w := &sqlWriter{indent: indent, lineStart: true}
+
+	depth := 0
+	clause := "" // current statement-level clause keyword
+	statementStart := true
+	blockDepth := -1     // paren depth whose contents are laid out one item per line
+	createTable := false // inside a CREATE TABLE statement
+	var prev sqlToken
+
+	skip := -1
+	for i, tok := range tokens {
+		if i == skip {
+			continue
+		}
+		upper := tok.upper()
+		space := tok.spaceBefore
+
+		if statementStart && i > 0 && tok.kind != sqlPunct {
+			w.b.WriteString("\n")
+			w.lineStart = true
+		}
+
+		switch {
+		case tok.kind == sqlLineComment:
+			if !w.lineStart {
+				w.write(tok.text, true)
+			} else {
+				w.write(tok.text, false)
+			}
+			w.newline(max(depth, 0))
+			prev = tok
+			continue
+
+		case tok.kind == sqlWord && depth == 0 && !statementStart && sqlClauseStarts[upper] &&
+			!(upper == "JOIN" && sqlJoinModifiers[prev.upper()]) &&
+			!(sqlJoinModifiers[upper] && sqlJoinModifiers[prev.upper()]):
+			w.newline(0)
+			space = false
+
+		case tok.kind == sqlWord && depth == 0 && (upper == "AND" || upper == "OR") &&
+			(clause == "WHERE" || clause == "HAVING"):
+			w.newline(1)
+			space = false
+
+		case tok.kind == sqlPunct && (tok.text == "," || tok.text == ";" || tok.text == ")" || tok.text == "."):
+			space = false
+
+		case prev.kind == sqlPunct && (prev.text == "(" || prev.text == "."):
+			space = false
+
+		case prev.kind == sqlPunct && prev.text == ",":
+			space = true
+
+		case tok.kind == sqlPunct && tok.text == "::", prev.kind == sqlPunct && prev.text == "::":
+			space = false
+		}
+
+		if tok.kind == sqlPunct && tok.text == ")" && depth == blockDepth {
+			w.newline(depth - 1)
+			blockDepth = -1
+		}
+
+		text := tok.text
+		if tok.kind == sqlWord && s.isKeyword(upper) {
+			switch s.KeywordCase {
+			case KeywordUpper:
+				text = upper
+			case KeywordLower:
+				text = strings.ToLower(text)
+			}
+		}
+		w.write(text, space)
+
+		if tok.kind == sqlWord {
+			if statementStart {
+				createTable = false
+			}
+			if depth == 0 && (sqlClauseStarts[upper] || statementStart) {
+				clause = upper
+			}
+			if upper == "TABLE" && prev.upper() == "CREATE" {
+				createTable = true
+			}
+			statementStart = false
+		}
+
+		switch {
+		case tok.kind == sqlPunct && tok.text == "(":
+			depth++
+			if depth == 1 && createTable && blockDepth < 0 && clause == "CREATE" {
+				blockDepth = 1
+				w.newline(1)
+			}
+		case tok.kind == sqlPunct && tok.text == ")":
+			depth--
+		case tok.kind == sqlPunct && tok.text == "," && depth == blockDepth:
+			w.newline(depth)
+		case tok.kind == sqlPunct && tok.text == "," && depth == 0 && clause == "VALUES":
+			w.newline(1)
+		case tok.kind == sqlPunct && tok.text == ";":
+			depth = 0
+			blockDepth = -1
+			clause = ""
+			createTable = false
+			statementStart = true
+			// Keep a trailing comment on the statement's last line.
+			if i+1 < len(tokens) && tokens[i+1].kind == sqlLineComment && !tokens[i+1].newlineBefore {
+				w.write(tokens[i+1].text, true)
+				skip = i + 1
+			}
+			w.b.WriteString("\n")
+			w.lineStart = true
+		}
+
+		if tok.kind != sqlBlockComment {
+			prev = tok
+		}
+	}
+
+	out := strings.TrimRight(w.b.String(), " \n")
+	return trimTrailingSpaces(out) + "\n"
+}
+
+func trimTrailingSpaces(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
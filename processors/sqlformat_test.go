@@ -0,0 +1,80 @@
+package processors
+
+import "testing"
+
+func TestSQLFormat_ProcessContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor *SQLFormat
+		filePath  string
+		input     string
+		want      string
+	}{
+		{
+			name:      "create table columns on separate lines",
+			processor: NewSQLFormat(SQLPostgres),
+			filePath:  "001_users.sql",
+			input:     "create table users (id serial primary key, name varchar(255) not null, check (length(name) > 0)); -- users",
+			want:      "CREATE TABLE users (\n  id SERIAL PRIMARY KEY,\n  name VARCHAR(255) NOT NULL,\n  CHECK (length(name) > 0)\n); -- users\n",
+		},
+		{
+			name:      "clauses and conditions",
+			processor: NewSQLFormat(SQLPostgres),
+			filePath:  "query.sql",
+			input:     "select u.id, count(*) from users u left outer join orders o on o.user_id = u.id where u.id > 1 and u.name like 'a''b' group by u.id order by u.id desc limit 10;",
+			want:      "SELECT u.id, count(*)\nFROM users u\nLEFT OUTER JOIN orders o ON o.user_id = u.id\nWHERE u.id > 1\n  AND u.name LIKE 'a''b'\nGROUP BY u.id\nORDER BY u.id DESC\nLIMIT 10;\n",
+		},
+		{
+			name:      "values rows and statement separation",
+			processor: NewSQLFormat(SQLSQLite),
+			filePath:  "seed.sql",
+			input:     "insert into [users] (id, name) values (1, 'a'), (2, 'b');\n\n\ndelete from users where id = 3;",
+			want:      "INSERT INTO [users] (id, name)\nVALUES (1, 'a'),\n  (2, 'b');\n\nDELETE\nFROM users\nWHERE id = 3;\n",
+		},
+		{
+			name:      "mysql quoting and keywords",
+			processor: NewSQLFormat(SQLMySQL),
+			filePath:  "schema.sql",
+			input:     "CREATE TABLE `order` (id int unsigned auto_increment, note text default 'it\\'s') engine=InnoDB;",
+			want:      "CREATE TABLE `order` (\n  id INT UNSIGNED AUTO_INCREMENT,\n  note TEXT DEFAULT 'it\\'s'\n) ENGINE=InnoDB;\n",
+		},
+		{
+			name:      "postgres dollar quoting preserved",
+			processor: NewSQLFormat(SQLPostgres),
+			filePath:  "fn.sql",
+			input:     "create function f() returns int as $$ select  1 $$ language sql;",
+			want:      "CREATE FUNCTION f() RETURNS INT AS $$ select  1 $$ LANGUAGE sql;\n",
+		},
+		{
+			name:      "lower case keywords",
+			processor: &SQLFormat{Dialect: SQLPostgres, KeywordCase: KeywordLower},
+			filePath:  "q.sql",
+			input:     "SELECT 1 FROM t",
+			want:      "select 1\nfrom t\n",
+		},
+		{
+			name:      "ignores other files",
+			processor: NewSQLFormat(SQLPostgres),
+			filePath:  "main.go",
+			input:     "select 1",
+			want:      "select 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.processor.ProcessContent(tt.filePath, []byte(tt.input))
+			if err != nil {
+				t.Fatalf("ProcessContent() error = %v", err)
+			}
+			if string(result) != tt.want {
+				t.Errorf("ProcessContent() = %q, want %q", string(result), tt.want)
+			}
+
+			again, _ := tt.processor.ProcessContent(tt.filePath, result)
+			if string(again) != string(result) {
+				t.Errorf("ProcessContent() is not idempotent: %q", string(again))
+			}
+		})
+	}
+}
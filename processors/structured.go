@@ -0,0 +1,191 @@
+package processors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSONFormat is a post-processor that validates generated JSON files and
+// re-emits them canonically indented with sorted object keys. Malformed JSON
+// fails with the line and column of the error.
+type JSONFormat struct {
+	// Indent is the indentation string (default: two spaces)
+	Indent string
+	// SortKeys orders object keys alphabetically (default: true)
+	SortKeys bool
+}
+
+// NewJSONFormat creates a JSON formatting processor with sensible defaults.
+func NewJSONFormat() *JSONFormat {
+	return &JSONFormat{
+		Indent:   "  ",
+		SortKeys: true,
+	}
+}
+
+// ProcessContent formats .json files and leaves other files unchanged.
+func (j *JSONFormat) ProcessContent(filePath string, content []byte) ([]byte, error) {
+	if strings.ToLower(filepath.Ext(filePath)) != ".json" {
+		return content, nil
+	}
+
+	if !j.SortKeys {
+		var out bytes.Buffer
+		if err := json.Indent(&out, content, "", j.Indent); err != nil {
+			return nil, jsonError(filePath, content, err)
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, jsonError(filePath, content, err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		line, col := offsetPosition(content, int(dec.InputOffset()))
+		return nil, fmt.Errorf("%s:%d:%d: invalid JSON: unexpected data after top-level value", filePath, line, col)
+	}
+
+	// encoding/json writes map keys in sorted order.
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", j.Indent)
+	if err := enc.Encode(value); err != nil {
+		return nil, fmt.Errorf("%s: failed to encode JSON: %w", filePath, err)
+	}
+	return out.Bytes(), nil
+}
+
+// jsonError adds the line and column of a JSON syntax error.
+func jsonError(filePath string, content []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, col := offsetPosition(content, int(syntaxErr.Offset))
+		return fmt.Errorf("%s:%d:%d: invalid JSON: %w", filePath, line, col, err)
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		line, col := offsetPosition(content, len(content))
+		return fmt.Errorf("%s:%d:%d: invalid JSON: unexpected end of input", filePath, line, col)
+	}
+	return fmt.Errorf("%s: invalid JSON: %w", filePath, err)
+}
+
+// offsetPosition converts a byte offset to a 1-based line and column.
+func offsetPosition(content []byte, offset int) (int, int) {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := offset - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// YAMLFormat is a post-processor that validates generated YAML files and
+// re-emits them with consistent indentation and, optionally, sorted mapping
+// keys. Comments and multiple documents are preserved. Malformed YAML fails
+// with the line reported by the parser.
+type YAMLFormat struct {
+	// Indent is the number of spaces per level (default: 2)
+	Indent int
+	// SortKeys orders mapping keys alphabetically (default: true)
+	SortKeys bool
+}
+
+// NewYAMLFormat creates a YAML formatting processor with sensible defaults.
+func NewYAMLFormat() *YAMLFormat {
+	return &YAMLFormat{
+		Indent:   2,
+		SortKeys: true,
+	}
+}
+
+// ProcessContent formats .yaml and .yml files and leaves other files unchanged.
+func (y *YAMLFormat) ProcessContent(filePath string, content []byte) ([]byte, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext != ".yaml" && ext != ".yml" {
+		return content, nil
+	}
+
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid YAML: %w", filePath, err)
+		}
+		if y.SortKeys {
+			sortYAMLKeys(&doc)
+		}
+		docs = append(docs, &doc)
+	}
+
+	if len(docs) == 0 {
+		return content, nil
+	}
+
+	indent := y.Indent
+	if indent <= 0 {
+		indent = 2
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(indent)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("%s: failed to encode YAML: %w", filePath, err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("%s: failed to encode YAML: %w", filePath, err)
+	}
+	return out.Bytes(), nil
+}
+
+// sortYAMLKeys sorts mapping keys recursively. Mappings with merge keys are
+// left in place because their order is significant.
+func sortYAMLKeys(node *yaml.Node) {
+	for _, child := range node.Content {
+		sortYAMLKeys(child)
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	type pair struct{ key, value *yaml.Node }
+	pairs := make([]pair, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "<<" {
+			return
+		}
+		pairs = append(pairs, pair{node.Content[i], node.Content[i+1]})
+	}
+
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return pairs[a].key.Value < pairs[b].key.Value
+	})
+
+	for i, p := range pairs {
+		node.Content[2*i] = p.key
+		node.Content[2*i+1] = p.value
+	}
+}
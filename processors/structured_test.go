@@ -0,0 +1,116 @@
+package processors
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONFormat_ProcessContent(t *testing.T) {
+	processor := NewJSONFormat()
+
+	tests := []struct {
+		name     string
+		filePath string
+		input    string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "sorts keys and indents",
+			filePath: "config.json",
+			input:    `{"b": 1, "a": {"d": [1, 2], "c": "<x>"}}`,
+			want:     "{\n  \"a\": {\n    \"c\": \"<x>\",\n    \"d\": [\n      1,\n      2\n    ]\n  },\n  \"b\": 1\n}\n",
+		},
+		{
+			name:     "preserves number precision",
+			filePath: "big.json",
+			input:    `{"n": 12345678901234567890}`,
+			want:     "{\n  \"n\": 12345678901234567890\n}\n",
+		},
+		{
+			name:     "reports line and column",
+			filePath: "broken.json",
+			input:    "{\n  \"a\": 1,\n  \"b\": ,\n}",
+			wantErr:  "broken.json:3:",
+		},
+		{
+			name:     "rejects trailing data",
+			filePath: "double.json",
+			input:    `{} {}`,
+			wantErr:  "unexpected data",
+		},
+		{
+			name:     "ignores other files",
+			filePath: "main.go",
+			input:    "not json",
+			want:     "not json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processor.ProcessContent(tt.filePath, []byte(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ProcessContent() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessContent() error = %v", err)
+			}
+			if string(result) != tt.want {
+				t.Errorf("ProcessContent() = %q, want %q", string(result), tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLFormat_ProcessContent(t *testing.T) {
+	processor := NewYAMLFormat()
+
+	tests := []struct {
+		name     string
+		filePath string
+		input    string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "sorts keys and normalizes indentation",
+			filePath: "config.yaml",
+			input:    "zeta: 1\nalpha:\n    # nested\n    b: 2\n    a: [1, 2]\n",
+			want:     "alpha:\n  a: [1, 2]\n  # nested\n  b: 2\nzeta: 1\n",
+		},
+		{
+			name:     "keeps multiple documents",
+			filePath: "multi.yml",
+			input:    "b: 1\na: 2\n---\nc: 3\n",
+			want:     "a: 2\nb: 1\n---\nc: 3\n",
+		},
+		{
+			name:     "reports line of malformed yaml",
+			filePath: "broken.yaml",
+			input:    "a: 1\nb:\n  - x\n - y\n",
+			wantErr:  "line 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processor.ProcessContent(tt.filePath, []byte(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), tt.filePath) {
+					t.Fatalf("ProcessContent() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessContent() error = %v", err)
+			}
+			if string(result) != tt.want {
+				t.Errorf("ProcessContent() = %q, want %q", string(result), tt.want)
+			}
+		})
+	}
+}
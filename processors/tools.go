@@ -0,0 +1,248 @@
+package processors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// ErrToolMissing is returned by processors whose external tool is not
+// installed when their MissingToolPolicy is MissingToolFail.
+var ErrToolMissing = errors.New("required tool not found")
+
+// MissingToolPolicy controls what a processor does when the external tool it
+// depends on is not available.
+type MissingToolPolicy int
+
+const (
+	// MissingToolWarn leaves content unchanged and logs a warning once per
+	// tool (default).
+	MissingToolWarn MissingToolPolicy = iota
+	// MissingToolSkip leaves content unchanged silently.
+	MissingToolSkip
+	// MissingToolFail fails processing with ErrToolMissing.
+	MissingToolFail
+)
+
+func (p MissingToolPolicy) String() string {
+	switch p {
+	case MissingToolWarn:
+		return "warn"
+	case MissingToolSkip:
+		return "skip"
+	case MissingToolFail:
+		return "fail"
+	default:
+		return "unknown"
+	}
+}
+
+// Tool describes an optional external program.
+type Tool struct {
+	// Name identifies the tool in the registry.
+	Name string
+	// Command is the executable looked up on PATH. Defaults to Name.
+	Command string
+	// VersionArgs are passed to Command to print its version. No arguments
+	// means the version is not queried.
+	VersionArgs []string
+	// Purpose is a short description shown in diagnostics.
+	Purpose string
+}
+
+func (t Tool) command() string {
+	if t.Command == "" {
+		return t.Name
+	}
+	return t.Command
+}
+
+// ToolStatus is the detected state of a tool.
+type ToolStatus struct {
+	Name      string `json:"name"`
+	Command   string `json:"command"`
+	Purpose   string `json:"purpose,omitempty"`
+	Available bool   `json:"available"`
+	Path      string `json:"path,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// versionTimeout bounds how long a tool may take to report its version.
+const versionTimeout = 5 * time.Second
+
+// ToolRegistry detects optional external tools and caches the results, so
+// processors can check for a tool on every file without repeated PATH
+// lookups.
+type ToolRegistry struct {
+	mu       sync.Mutex
+	tools    map[string]Tool
+	statuses map[string]ToolStatus
+	warned   map[string]bool
+
+	lookPath func(file string) (string, error)
+	version  func(path string, args []string) (string, error)
+}
+
+// NewToolRegistry creates a registry that knows about goimports, prettier,
+// git and docker.
+func NewToolRegistry() *ToolRegistry {
+	r := &ToolRegistry{
+		tools:    make(map[string]Tool),
+		statuses: make(map[string]ToolStatus),
+		warned:   make(map[string]bool),
+		lookPath: exec.LookPath,
+		version:  commandVersion,
+	}
+
+	r.Register(Tool{Name: "goimports", Purpose: "Go import management"})
+	r.Register(Tool{Name: "prettier", VersionArgs: []string{"--version"}, Purpose: "JavaScript, TypeScript, CSS and Markdown formatting"})
+	r.Register(Tool{Name: "git", VersionArgs: []string{"--version"}, Purpose: "repository metadata and change detection"})
+	r.Register(Tool{Name: "docker", VersionArgs: []string{"--version"}, Purpose: "containerised formatters and checks"})
+
+	return r
+}
+
+// DefaultTools is the registry used by processors that are not given one.
+var DefaultTools = NewToolRegistry()
+
+// Register adds or replaces a tool and clears its cached status.
+func (r *ToolRegistry) Register(tool Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools[tool.Name] = tool
+	delete(r.statuses, tool.Name)
+}
+
+// Detect returns the status of the named tool, detecting it on first use.
+// Unregistered names are looked up as commands of the same name.
+func (r *ToolRegistry) Detect(name string) ToolStatus {
+	r.mu.Lock()
+	if status, ok := r.statuses[name]; ok {
+		r.mu.Unlock()
+		return status
+	}
+	tool, ok := r.tools[name]
+	if !ok {
+		tool = Tool{Name: name}
+	}
+	lookPath, version := r.lookPath, r.version
+	r.mu.Unlock()
+
+	status := ToolStatus{Name: tool.Name, Command: tool.command(), Purpose: tool.Purpose}
+	path, err := lookPath(tool.command())
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Available = true
+		status.Path = path
+		if len(tool.VersionArgs) > 0 {
+			if v, err := version(path, tool.VersionArgs); err == nil {
+				status.Version = v
+			}
+		}
+	}
+
+	r.mu.Lock()
+	r.statuses[name] = status
+	r.mu.Unlock()
+
+	return status
+}
+
+// Available reports whether the named tool is installed.
+func (r *ToolRegistry) Available(name string) bool {
+	return r.Detect(name).Available
+}
+
+// Require applies policy for a tool that a processor needs. It returns
+// (true, nil) when the tool is available, (false, nil) when the processor
+// should leave content unchanged, and ErrToolMissing under MissingToolFail.
+func (r *ToolRegistry) Require(name string, policy MissingToolPolicy, warn func(msg string, args ...any)) (bool, error) {
+	status := r.Detect(name)
+	if status.Available {
+		return true, nil
+	}
+
+	switch policy {
+	case MissingToolFail:
+		return false, fmt.Errorf("%w: %s", ErrToolMissing, status.Command)
+	case MissingToolWarn:
+		r.mu.Lock()
+		first := !r.warned[name]
+		r.warned[name] = true
+		r.mu.Unlock()
+		if first && warn != nil {
+			warn("optional tool not found, skipping", "tool", name, "command", status.Command)
+		}
+	}
+	return false, nil
+}
+
+// Refresh clears all cached detection results.
+func (r *ToolRegistry) Refresh() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = make(map[string]ToolStatus)
+	r.warned = make(map[string]bool)
+}
+
+// Doctor detects every registered tool and returns their statuses sorted by
+// name.
+func (r *ToolRegistry) Doctor() []ToolStatus {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	statuses := make([]ToolStatus, 0, len(names))
+	for _, name := range names {
+		statuses = append(statuses, r.Detect(name))
+	}
+	return statuses
+}
+
+// WriteDoctorReport writes a human-readable table of tool statuses to w.
+func (r *ToolRegistry) WriteDoctorReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tSTATUS\tVERSION\tPATH")
+	for _, status := range r.Doctor() {
+		state := "missing"
+		if status.Available {
+			state = "ok"
+		}
+		version := status.Version
+		if version == "" {
+			version = "-"
+		}
+		path := status.Path
+		if path == "" {
+			path = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", status.Name, state, version, path)
+	}
+	return tw.Flush()
+}
+
+// commandVersion runs path with args and returns the first line of output.
+func commandVersion(path string, args []string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, args...).Output()
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line), nil
+}
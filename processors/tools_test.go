@@ -0,0 +1,128 @@
+package processors
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func newTestRegistry(installed map[string]string) (*ToolRegistry, *int) {
+	lookups := 0
+	r := NewToolRegistry()
+	r.lookPath = func(file string) (string, error) {
+		lookups++
+		if path, ok := installed[file]; ok {
+			return path, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	r.version = func(path string, args []string) (string, error) {
+		return "v1.2.3", nil
+	}
+	return r, &lookups
+}
+
+func TestToolRegistry_DetectCaches(t *testing.T) {
+	r, lookups := newTestRegistry(map[string]string{"git": "/usr/bin/git"})
+
+	for range 3 {
+		status := r.Detect("git")
+		if !status.Available || status.Path != "/usr/bin/git" || status.Version != "v1.2.3" {
+			t.Fatalf("Unexpected status: %+v", status)
+		}
+	}
+	if *lookups != 1 {
+		t.Errorf("Expected 1 lookup, got %d", *lookups)
+	}
+
+	r.Refresh()
+	r.Detect("git")
+	if *lookups != 2 {
+		t.Errorf("Expected Refresh to clear the cache, got %d lookups", *lookups)
+	}
+
+	if r.Available("prettier") {
+		t.Error("Expected prettier to be missing")
+	}
+}
+
+func TestToolRegistry_Require(t *testing.T) {
+	r, _ := newTestRegistry(nil)
+
+	var warnings int
+	warn := func(msg string, args ...any) { warnings++ }
+
+	for range 2 {
+		ok, err := r.Require("prettier", MissingToolWarn, warn)
+		if ok || err != nil {
+			t.Errorf("Expected skip with warning, got ok=%v err=%v", ok, err)
+		}
+	}
+	if warnings != 1 {
+		t.Errorf("Expected a single warning, got %d", warnings)
+	}
+
+	if _, err := r.Require("docker", MissingToolSkip, warn); err != nil || warnings != 1 {
+		t.Errorf("Expected silent skip, got err=%v warnings=%d", err, warnings)
+	}
+
+	if _, err := r.Require("docker", MissingToolFail, warn); !errors.Is(err, ErrToolMissing) {
+		t.Errorf("Expected ErrToolMissing, got %v", err)
+	}
+}
+
+func TestToolRegistry_WriteDoctorReport(t *testing.T) {
+	r, _ := newTestRegistry(map[string]string{"git": "/usr/bin/git"})
+	r.Register(Tool{Name: "sqlfmt", Purpose: "SQL formatting"})
+
+	var buf bytes.Buffer
+	if err := r.WriteDoctorReport(&buf); err != nil {
+		t.Fatalf("WriteDoctorReport failed: %v", err)
+	}
+
+	report := buf.String()
+	for _, want := range []string{"TOOL", "docker", "git", "goimports", "prettier", "sqlfmt", "/usr/bin/git", "missing"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q:\n%s", want, report)
+		}
+	}
+
+	statuses := r.Doctor()
+	if len(statuses) != 5 || statuses[0].Name != "docker" {
+		t.Errorf("Expected 5 statuses sorted by name, got %+v", statuses)
+	}
+}
+
+func TestCommand_ProcessContent(t *testing.T) {
+	catPath, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat not available")
+	}
+
+	r, _ := newTestRegistry(map[string]string{"cat": catPath})
+
+	cmd := NewCommand("cat")
+	cmd.Registry = r
+	result, err := cmd.ProcessContent("file.txt", []byte("hello"))
+	if err != nil {
+		t.Fatalf("ProcessContent failed: %v", err)
+	}
+	if string(result) != "hello" {
+		t.Errorf("Expected content piped through tool, got %q", string(result))
+	}
+
+	missing := NewCommand("prettier", "--stdin-filepath", "{file}")
+	missing.Registry = r
+	missing.Policy = MissingToolSkip
+	result, err = missing.ProcessContent("app.js", []byte("x"))
+	if err != nil || string(result) != "x" {
+		t.Errorf("Expected unchanged content when tool is missing, got %q, %v", string(result), err)
+	}
+
+	missing.Policy = MissingToolFail
+	if _, err := missing.ProcessContent("app.js", []byte("x")); !errors.Is(err, ErrToolMissing) {
+		t.Errorf("Expected ErrToolMissing, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package processors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/cpcf/weft/render"
+)
+
+// WASM is a post-processor that runs a WebAssembly module built for WASI,
+// piping content through its stdin and stdout like Command. Runtime
+// sandboxes the module, so a third-party processor can only reach the
+// directories and environment variables it grants. The sandbox is that of
+// the external runtime Runtime starts; see render.WASMRuntime for what
+// weft relies on it for.
+//
+// Example usage:
+//
+//	rt := render.WASMRuntime{Name: "wasmtime"}
+//	eng.AddPostProcessor(processors.NewWASM(rt, "acme-headers.wasm", "{file}"))
+type WASM struct {
+	// Runtime runs the module and grants its capabilities.
+	Runtime render.WASMRuntime
+	// Module is the path of the .wasm file.
+	Module string
+	// Args are passed to the module. The placeholder "{file}" is replaced
+	// with the path of the file being processed.
+	Args []string
+	// Timeout bounds each invocation (default: 30s).
+	Timeout time.Duration
+}
+
+// NewWASM creates a processor that runs module with args under runtime.
+func NewWASM(runtime render.WASMRuntime, module string, args ...string) *WASM {
+	return &WASM{
+		Runtime: runtime,
+		Module:  module,
+		Args:    args,
+		Timeout: 30 * time.Second,
+	}
+}
+
+// ProcessContent implements the postprocess.Processor interface.
+func (w *WASM) ProcessContent(filePath string, content []byte) ([]byte, error) {
+	args := make([]string, len(w.Args))
+	for i, arg := range w.Args {
+		args[i] = strings.ReplaceAll(arg, "{file}", filePath)
+	}
+	command, cmdArgs, err := w.Runtime.Command(w.Module, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", w.Module, err)
+	}
+
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command, cmdArgs...)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return nil, fmt.Errorf("%s failed: %w", w.Module, err)
+		}
+		return nil, fmt.Errorf("%s failed: %w: %s", w.Module, err, msg)
+	}
+
+	return stdout.Bytes(), nil
+}
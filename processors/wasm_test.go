@@ -0,0 +1,46 @@
+package processors
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cpcf/weft/render"
+)
+
+func TestWASM(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	runtime := filepath.Join(dir, "wazero")
+	argsFile := filepath.Join(dir, "args")
+	// The fake runtime records its arguments and upper-cases its input, or
+	// fails for files named bad.txt.
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > '" + argsFile + "'\n" +
+		"case \"$*\" in *bad.txt*) echo 'header: parse error' >&2; exit 3;; esac\ntr a-z A-Z\n"
+	if err := os.WriteFile(runtime, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := render.WASMRuntime{Name: "wazero", Path: runtime, Dirs: map[string]string{"/licenses": "licenses"}}
+	w := NewWASM(rt, "headers.wasm", "--file", "{file}")
+	result, err := w.ProcessContent("main.go", []byte("package main\n"))
+	if err != nil || string(result) != "PACKAGE MAIN\n" {
+		t.Fatalf("ProcessContent() = %q, %v", result, err)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if want := "run\n-mount=licenses:/licenses\nheaders.wasm\n--file\nmain.go\n"; string(args) != want {
+		t.Errorf("runtime args = %q, want %q", args, want)
+	}
+
+	if _, err := w.ProcessContent("bad.txt", nil); err == nil || !strings.Contains(err.Error(), "headers.wasm failed: exit status 3: header: parse error") {
+		t.Errorf("Expected the module's error, got %v", err)
+	}
+	w.Runtime.Name = "v8"
+	if _, err := w.ProcessContent("main.go", nil); err == nil || !strings.Contains(err.Error(), `unknown WASM runtime "v8"`) {
+		t.Errorf("Expected an unknown runtime error, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/cpcf/weft/engine"
+)
+
+// Drift is a generated file whose committed content differs from what the
+// templates produce now.
+type Drift struct {
+	// Path is the file's path relative to the output directory.
+	Path string
+	// Missing reports that the file has not been generated at all.
+	Missing bool
+	// Line is the first line that differs, or 0 if the file is missing.
+	Line int
+	// Added and Removed estimate the lines regeneration would add and
+	// remove; lines that only moved are counted as both.
+	Added, Removed int
+}
+
+func (d Drift) String() string {
+	if d.Missing {
+		return fmt.Sprintf("%s: missing (+%d lines)", d.Path, d.Added)
+	}
+	return fmt.Sprintf("%s:%d: out of date (+%d -%d lines)", d.Path, d.Line, d.Added, d.Removed)
+}
+
+// Check renders the project into a temporary directory, with an engine built
+// by Engine from opts, and compares the result with the output directory. It
+// returns the files that are missing or out of date, in output order; an
+// empty result means the committed output is up to date. Files in the output
+// directory that the templates no longer produce are not reported.
+func (p *Project) Check(ctx context.Context, opts ...engine.Option) ([]Drift, error) {
+	tmp, err := os.MkdirTemp("", "weft-check-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	scratch := *p
+	scratch.Output = tmp
+	eng, err := scratch.Engine(opts...)
+	if err != nil {
+		return nil, err
+	}
+	files, err := scratch.Generate(ctx, eng)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []Drift
+	for _, f := range files {
+		rel, err := filepath.Rel(tmp, f.OutputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", f.OutputPath, err)
+		}
+		want, err := os.ReadFile(f.OutputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read regenerated %s: %w", rel, err)
+		}
+		got, err := os.ReadFile(filepath.Join(p.OutputDir(), rel))
+		if errors.Is(err, fs.ErrNotExist) {
+			drifts = append(drifts, Drift{Path: rel, Missing: true, Added: len(splitLines(want))})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		if bytes.Equal(got, want) {
+			continue
+		}
+		d := Drift{Path: rel}
+		d.Line, d.Added, d.Removed = compareLines(got, want)
+		drifts = append(drifts, d)
+	}
+	return drifts, nil
+}
+
+// compareLines returns the first line where got and want differ and the
+// number of lines only in want and only in got. Between the common prefix
+// and suffix, lines are compared as multisets, which is exact for additions
+// and removals; a region that was only reordered counts as all changed.
+func compareLines(got, want []byte) (first, added, removed int) {
+	gotLines, wantLines := splitLines(got), splitLines(want)
+	for first < len(gotLines) && first < len(wantLines) && gotLines[first] == wantLines[first] {
+		first++
+	}
+	gotLines, wantLines = gotLines[first:], wantLines[first:]
+	for len(gotLines) > 0 && len(wantLines) > 0 && gotLines[len(gotLines)-1] == wantLines[len(wantLines)-1] {
+		gotLines, wantLines = gotLines[:len(gotLines)-1], wantLines[:len(wantLines)-1]
+	}
+
+	counts := make(map[string]int)
+	for _, line := range gotLines {
+		counts[line]++
+	}
+	for _, line := range wantLines {
+		counts[line]--
+	}
+	for _, n := range counts {
+		if n > 0 {
+			removed += n
+		} else {
+			added -= n
+		}
+	}
+	if added == 0 && removed == 0 {
+		added, removed = len(wantLines), len(gotLines)
+	}
+	return first + 1, added, removed
+}
+
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = string(line)
+	}
+	return result
+}
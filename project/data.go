@@ -0,0 +1,135 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cpcf/weft/config"
+	"github.com/cpcf/weft/datasource"
+	"github.com/cpcf/weft/importers/gosource"
+	"github.com/cpcf/weft/importers/tabular"
+)
+
+// DataSource declares one source of template data. Exactly one of the
+// source fields must be set; see the datasource package for what each
+// produces.
+type DataSource struct {
+	// Name is the top-level key the data is stored under. Without a name,
+	// the source must produce a map, which is merged into the top level.
+	Name string `yaml:"name,omitempty"`
+
+	File    string            `yaml:"file,omitempty"`
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Env     string            `yaml:"env,omitempty"`
+	CSV     string            `yaml:"csv,omitempty"`
+	XLSX    string            `yaml:"xlsx,omitempty"`
+	Sheet   string            `yaml:"sheet,omitempty"`
+	OpenAPI string            `yaml:"openapi,omitempty"`
+	SQL     string            `yaml:"sql,omitempty"`
+	Go      string            `yaml:"go,omitempty"`
+
+	// Select keeps only the value at a dot-separated path.
+	Select string `yaml:"select,omitempty"`
+	// KeyBy turns a list of rows into a map keyed by this field.
+	KeyBy string `yaml:"keyBy,omitempty"`
+}
+
+// kinds returns the source fields that are set.
+func (d DataSource) kinds() []string {
+	var kinds []string
+	for _, k := range []struct {
+		name  string
+		value string
+	}{
+		{"file", d.File}, {"url", d.URL}, {"env", d.Env}, {"csv", d.CSV}, {"xlsx", d.XLSX},
+		{"openapi", d.OpenAPI}, {"sql", d.SQL}, {"go", d.Go},
+	} {
+		if k.value != "" {
+			kinds = append(kinds, k.name)
+		}
+	}
+	return kinds
+}
+
+func (d DataSource) validate() error {
+	switch kinds := d.kinds(); len(kinds) {
+	case 0:
+		return errors.New("one of file, url, env, csv, xlsx, openapi, sql or go is required")
+	case 1:
+	default:
+		return fmt.Errorf("only one source is allowed, got %s", strings.Join(kinds, ", "))
+	}
+	if d.Sheet != "" && d.XLSX == "" {
+		return errors.New("sheet requires xlsx")
+	}
+	if len(d.Headers) > 0 && d.URL == "" {
+		return errors.New("headers requires url")
+	}
+	return nil
+}
+
+// source builds the datasource for d.
+func (p *Project) source(d DataSource) datasource.Source {
+	switch {
+	case d.File != "":
+		return datasource.File(p.Path(d.File))
+	case d.URL != "":
+		var opts []config.Option
+		for name, value := range d.Headers {
+			opts = append(opts, config.WithHeader(name, value))
+		}
+		return datasource.URL(d.URL, opts...)
+	case d.Env != "":
+		return datasource.Env(d.Env)
+	case d.CSV != "":
+		return datasource.CSV(p.Path(d.CSV))
+	case d.XLSX != "":
+		var opts []tabular.Option
+		if d.Sheet != "" {
+			opts = append(opts, tabular.WithSheet(d.Sheet))
+		}
+		return datasource.XLSX(p.Path(d.XLSX), opts...)
+	case d.OpenAPI != "":
+		return datasource.OpenAPI(p.Path(d.OpenAPI))
+	case d.SQL != "":
+		return datasource.SQLSchema(p.Path(d.SQL))
+	default:
+		return datasource.GoPackage(d.Go, gosource.WithDir(p.Dir))
+	}
+}
+
+// Pipeline returns the data pipeline declared by the project.
+func (p *Project) Pipeline() *datasource.Pipeline {
+	pipeline := datasource.New()
+	for _, d := range p.Data {
+		var transforms []datasource.Transform
+		if d.Select != "" {
+			transforms = append(transforms, datasource.Select(d.Select))
+		}
+		if d.KeyBy != "" {
+			transforms = append(transforms, datasource.KeyBy(d.KeyBy))
+		}
+		pipeline.Add(d.Name, p.source(d), transforms...)
+	}
+	return pipeline
+}
+
+// LoadData fetches and merges the project's data sources and checks the
+// result against the data its resolved packs require.
+func (p *Project) LoadData(ctx context.Context) (map[string]any, error) {
+	data, err := p.Pipeline().Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load data: %w", err)
+	}
+	var errs []error
+	for _, pk := range p.packs {
+		errs = append(errs, pk.CheckData(data))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid data: %w", err)
+	}
+	return data, nil
+}
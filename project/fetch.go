@@ -0,0 +1,270 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PackRef locates a template pack. Source is one of:
+//
+//	./packs/rest-client                               a local directory
+//	https://github.com/acme/packs.git//rest-client    a git repository
+//	git+ssh://git@github.com/acme/packs//rest-client  (git+ forces git)
+//	oci://ghcr.io/acme/packs/rest-client              an OCI registry
+//
+// A "//" after a git repository selects a subdirectory. Version pins the
+// pack: a tag or commit for git, a tag or "sha256:" digest for OCI. For a
+// local pack, or any pack whose version looks like "v1.2.3", the manifest's
+// version must match.
+type PackRef struct {
+	Source  string `yaml:"source"`
+	Version string `yaml:"version,omitempty"`
+}
+
+func (r PackRef) String() string {
+	if r.Version == "" {
+		return r.Source
+	}
+	return r.Source + "@" + r.Version
+}
+
+// PackOption configures FetchPack.
+type PackOption func(*packOptions)
+
+type packOptions struct {
+	cacheDir string
+	dir      string
+	client   *http.Client
+}
+
+// WithCacheDir sets where remote packs are stored; the default is
+// weft/packs in the user cache directory.
+func WithCacheDir(dir string) PackOption {
+	return func(o *packOptions) {
+		o.cacheDir = dir
+	}
+}
+
+// WithPackDir resolves relative local sources against dir instead of the
+// working directory.
+func WithPackDir(dir string) PackOption {
+	return func(o *packOptions) {
+		o.dir = dir
+	}
+}
+
+// WithHTTPClient sets the client used to pull from OCI registries.
+func WithHTTPClient(client *http.Client) PackOption {
+	return func(o *packOptions) {
+		o.client = client
+	}
+}
+
+type packKind int
+
+const (
+	localPack packKind = iota
+	gitPack
+	ociPack
+)
+
+func (r PackRef) kind() packKind {
+	switch {
+	case strings.HasPrefix(r.Source, "oci://"):
+		return ociPack
+	case strings.HasPrefix(r.Source, "git+"), strings.HasPrefix(r.Source, "git@"),
+		strings.HasSuffix(r.Source, ".git"), strings.Contains(r.Source, ".git//"):
+		return gitPack
+	}
+	return localPack
+}
+
+func newPackOptions(opts []PackOption) *packOptions {
+	o := &packOptions{client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// FetchPack loads the pack ref points to, downloading remote packs into the
+// cache. Pinned remote packs are reused from the cache; unpinned ones are
+// fetched again every time.
+func FetchPack(ctx context.Context, ref PackRef, opts ...PackOption) (*Pack, error) {
+	return fetchPack(ctx, ref, "", newPackOptions(opts))
+}
+
+// fetchPack fetches ref, or the exact revision of it when one is given: a
+// git commit or an OCI manifest digest.
+func fetchPack(ctx context.Context, ref PackRef, revision string, o *packOptions) (*Pack, error) {
+	if ref.Source == "" {
+		return nil, errors.New("pack source is required")
+	}
+
+	pinned := ref
+	if revision != "" {
+		pinned.Version = revision
+	}
+	var dir string
+	var err error
+	switch ref.kind() {
+	case localPack:
+		dir = ref.Source
+		if o.dir != "" && !filepath.IsAbs(dir) {
+			dir = filepath.Join(o.dir, dir)
+		}
+	case gitPack:
+		dir, revision, err = fetchGit(ctx, pinned, o)
+	case ociPack:
+		dir, revision, err = fetchOCI(ctx, pinned, o)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pack %s: %w", ref, err)
+	}
+
+	pk, err := LoadPack(dir)
+	if err != nil {
+		return nil, fmt.Errorf("pack %s: %w", ref, err)
+	}
+	if err := checkVersion(ref, pk); err != nil {
+		return nil, err
+	}
+	pk.Ref = ref
+	pk.Revision = revision
+	return pk, nil
+}
+
+var semverRef = regexp.MustCompile(`^v?\d+(\.\d+){0,2}([-+].*)?$`)
+
+func checkVersion(ref PackRef, pk *Pack) error {
+	if ref.Version == "" || pk.Version == "" {
+		return nil
+	}
+	if ref.kind() != localPack && !semverRef.MatchString(ref.Version) {
+		return nil
+	}
+	if strings.TrimPrefix(ref.Version, "v") != strings.TrimPrefix(pk.Version, "v") {
+		return fmt.Errorf("pack %s: manifest version is %s", ref, pk.Version)
+	}
+	return nil
+}
+
+func (o *packOptions) cachePath(kind string, key ...string) (string, error) {
+	root := o.cacheDir
+	if root == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate cache directory: %w", err)
+		}
+		root = filepath.Join(dir, "weft", "packs")
+	}
+	sum := sha256.Sum256([]byte(strings.Join(key, "\x00")))
+	return filepath.Join(root, kind, hex.EncodeToString(sum[:8])), nil
+}
+
+// splitSubdir splits "repo//sub/dir" into the repository and subdirectory,
+// ignoring the "//" of a URL scheme.
+func splitSubdir(source string) (string, string) {
+	start := 0
+	if i := strings.Index(source, "://"); i >= 0 {
+		start = i + 3
+	}
+	if i := strings.Index(source[start:], "//"); i >= 0 {
+		return source[:start+i], source[start+i+2:]
+	}
+	return source, ""
+}
+
+// gitRepo splits a git pack source into the repository URL and the
+// subdirectory of the pack.
+func gitRepo(source string) (string, string, error) {
+	repo, subdir := splitSubdir(strings.TrimPrefix(source, "git+"))
+	if repo == "" || strings.HasPrefix(repo, "-") {
+		return "", "", fmt.Errorf("invalid repository %q", repo)
+	}
+	if subdir != "" && !filepath.IsLocal(subdir) {
+		return "", "", fmt.Errorf("invalid subdirectory %q", subdir)
+	}
+	return repo, subdir, nil
+}
+
+// fetchGit checks out ref into the cache and returns the pack directory and
+// the commit.
+func fetchGit(ctx context.Context, ref PackRef, o *packOptions) (string, string, error) {
+	repo, subdir, err := gitRepo(ref.Source)
+	if err != nil {
+		return "", "", err
+	}
+	if strings.HasPrefix(ref.Version, "-") {
+		return "", "", fmt.Errorf("invalid version %q", ref.Version)
+	}
+	dest, err := o.cachePath("git", repo, ref.Version)
+	if err != nil {
+		return "", "", err
+	}
+	_, statErr := os.Stat(filepath.Join(dest, ".git"))
+	if ref.Version == "" || statErr != nil {
+		if err := cloneGit(ctx, repo, ref.Version, dest); err != nil {
+			return "", "", err
+		}
+	}
+	commit, err := runGit(ctx, dest, "rev-parse", "HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(dest, subdir), commit, nil
+}
+
+func cloneGit(ctx context.Context, repo, version, dest string) error {
+	tmp := dest + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		return err
+	}
+	if version == "" {
+		version = "HEAD"
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", "--", repo, version},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	} {
+		if _, err := runGit(ctx, tmp, args...); err != nil {
+			os.RemoveAll(tmp)
+			return err
+		}
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// runGit runs git in dir and returns its trimmed output.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
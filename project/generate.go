@@ -0,0 +1,96 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"text/template"
+
+	"github.com/cpcf/weft/engine"
+	"github.com/cpcf/weft/render"
+)
+
+func (p *Project) failureMode() (engine.FailureMode, error) {
+	for _, mode := range []engine.FailureMode{engine.FailFast, engine.FailAtEnd, engine.BestEffort} {
+		if p.FailureMode == mode.String() {
+			return mode, nil
+		}
+	}
+	if p.FailureMode == "" {
+		return engine.FailFast, nil
+	}
+	return 0, fmt.Errorf("unknown failure mode %q; use fail-fast, fail-at-end or best-effort", p.FailureMode)
+}
+
+// Engine creates an engine writing to the project's output root, with its
+// failure mode and processors and the functions and processors of its packs.
+// opts are applied after the project settings.
+func (p *Project) Engine(opts ...engine.Option) (*engine.Engine, error) {
+	if err := p.checkPacksResolved(); err != nil {
+		return nil, err
+	}
+	mode, err := p.failureMode()
+	if err != nil {
+		return nil, err
+	}
+	settings := []engine.Option{
+		engine.WithOutputRoot(p.OutputDir()),
+		engine.WithFailureMode(mode),
+	}
+	if len(p.packs) > 0 {
+		settings = append(settings, engine.WithFuncMap(p.packFuncs()))
+	}
+	eng := engine.New(append(settings, opts...)...)
+
+	procs := p.Processors
+	for _, pk := range p.packs {
+		procs = append(procs[:len(procs):len(procs)], pk.Processors...)
+	}
+	for _, proc := range procs {
+		processor, err := proc.build()
+		if err != nil {
+			return nil, fmt.Errorf("processor %q: %w", proc.Name, err)
+		}
+		eng.AddPostProcessor(processor)
+	}
+	return eng, nil
+}
+
+// FuncMap returns the functions available to the project's templates:
+// render.DefaultFuncMap and those its resolved packs ask for.
+func (p *Project) FuncMap() template.FuncMap {
+	funcs := render.DefaultFuncMap()
+	maps.Copy(funcs, p.packFuncs())
+	return funcs
+}
+
+func (p *Project) packFuncs() template.FuncMap {
+	funcs := make(template.FuncMap)
+	for _, pk := range p.packs {
+		maps.Copy(funcs, pk.FuncMap())
+	}
+	return funcs
+}
+
+// Generate loads the project's data and renders every template directory
+// with eng, which should come from Engine. It returns the files written.
+func (p *Project) Generate(ctx context.Context, eng *engine.Engine) ([]engine.GeneratedFile, error) {
+	if err := p.checkPacksResolved(); err != nil {
+		return nil, err
+	}
+	data, err := p.LoadData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var generated []engine.GeneratedFile
+	for _, dir := range p.TemplateDirs() {
+		ectx := engine.NewContext(os.DirFS(dir), p.OutputDir(), p.Package)
+		if err := eng.RenderDir(ectx, ".", data); err != nil {
+			return generated, fmt.Errorf("failed to render %s: %w", dir, err)
+		}
+		generated = append(generated, eng.GeneratedFiles()...)
+	}
+	return generated, nil
+}
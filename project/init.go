@@ -0,0 +1,315 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// InitOption configures Init.
+type InitOption func(*initOptions)
+
+type initOptions struct {
+	module string
+	name   string
+	force  bool
+}
+
+// WithModule sets the Go module path of the new project; the default is the
+// generator name.
+func WithModule(path string) InitOption {
+	return func(o *initOptions) {
+		o.module = path
+	}
+}
+
+// WithName sets the generator name, used in generated file headers; the
+// default is the base name of the directory.
+func WithName(name string) InitOption {
+	return func(o *initOptions) {
+		o.name = name
+	}
+}
+
+// WithOverwrite lets Init replace existing files.
+func WithOverwrite() InitOption {
+	return func(o *initOptions) {
+		o.force = true
+	}
+}
+
+// Init scaffolds a generator project in dir, laid out like the
+// yaml-tutorial-guide example:
+//
+//	go.mod
+//	main.go                 loads the spec and renders the templates
+//	spec/spec.go            the specification types, with validation
+//	configs/example.yaml    an example specification
+//	templates/models.go.tmpl
+//	README.md
+//
+// It returns the paths of the files it wrote. Init fails without writing
+// anything if one of the files exists, unless WithOverwrite is given.
+func Init(dir string, opts ...InitOption) ([]string, error) {
+	o := &initOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	if o.name == "" {
+		o.name = filepath.Base(abs)
+	}
+	if !validName.MatchString(o.name) {
+		return nil, fmt.Errorf("invalid generator name %q: use letters, digits, '-' and '_'", o.name)
+	}
+	if o.module == "" {
+		o.module = o.name
+	}
+
+	data := map[string]string{"Name": o.name, "Module": o.module}
+	files := make(map[string][]byte, len(scaffold))
+	for name, text := range scaffold {
+		tmpl := template.Must(template.New(name).Delims("[[", "]]").Parse(text))
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		content := buf.Bytes()
+		if strings.HasSuffix(name, ".go") {
+			if content, err = format.Source(content); err != nil {
+				return nil, fmt.Errorf("failed to format %s: %w", name, err)
+			}
+		}
+		files[filepath.Join(abs, filepath.FromSlash(name))] = content
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if !o.force {
+		var existing []string
+		for _, path := range paths {
+			if _, err := os.Stat(path); err == nil {
+				existing = append(existing, path)
+			}
+		}
+		if len(existing) > 0 {
+			return nil, fmt.Errorf("refusing to overwrite existing files: %s", strings.Join(existing, ", "))
+		}
+	}
+
+	var errs []error
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := os.WriteFile(path, files[path], 0o644); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("failed to write project: %w", err)
+	}
+	return paths, nil
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// scaffold holds the files Init writes, as templates delimited by [[ ]] so
+// that the starter template's own actions pass through untouched.
+var scaffold = map[string]string{
+	"go.mod": `module [[ .Module ]]
+
+go 1.24
+`,
+
+	"main.go": `// Command [[ .Name ]] generates code from a specification file.
+//
+//	go run . -config configs/example.yaml
+package main
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+
+	"github.com/cpcf/weft/config"
+	"github.com/cpcf/weft/engine"
+	"github.com/cpcf/weft/processors"
+
+	"[[ .Module ]]/spec"
+)
+
+//go:embed templates
+var templates embed.FS
+
+func main() {
+	configPath := flag.String("config", "configs/example.yaml", "path to the specification file")
+	output := flag.String("output", "generated", "directory to write generated files to")
+	flag.Parse()
+
+	var s spec.Spec
+	if err := config.LoadYAML(*configPath, &s); err != nil {
+		log.Fatalf("failed to load specification: %v", err)
+	}
+
+	templateFS, err := fs.Sub(templates, "templates")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	eng := engine.New(engine.WithOutputRoot(*output))
+	eng.AddPostProcessor(processors.NewGoImports())
+	eng.AddPostProcessor(processors.NewAddGeneratedHeader("[[ .Name ]]", ".go"))
+
+	ctx := engine.NewContext(templateFS, *output, s.Package)
+	if err := eng.RenderDir(ctx, ".", map[string]any{"Spec": &s}); err != nil {
+		log.Fatalf("failed to generate code: %v", err)
+	}
+	fmt.Printf("generated %d files in %s\n", len(eng.GeneratedFiles()), *output)
+}
+`,
+
+	"spec/spec.go": `// Package spec defines the specification that drives the [[ .Name ]]
+// generator. Templates see it as .Spec.
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+)
+
+// Spec is the root of a specification file.
+type Spec struct {
+	Name        string  ` + "`yaml:\"name\"`" + `
+	Description string  ` + "`yaml:\"description\"`" + `
+	Package     string  ` + "`yaml:\"package\"`" + `
+	Models      []Model ` + "`yaml:\"models\"`" + `
+}
+
+// Model is a type to generate.
+type Model struct {
+	Name        string  ` + "`yaml:\"name\"`" + `
+	Description string  ` + "`yaml:\"description\"`" + `
+	Fields      []Field ` + "`yaml:\"fields\"`" + `
+}
+
+// Field is a field of a model. Type is a Go type, such as string or
+// time.Time.
+type Field struct {
+	Name        string ` + "`yaml:\"name\"`" + `
+	Type        string ` + "`yaml:\"type\"`" + `
+	Description string ` + "`yaml:\"description\"`" + `
+}
+
+// Validate implements config.Validator; it runs after the file is loaded.
+func (s *Spec) Validate() error {
+	if s.Name == "" {
+		return errors.New("name is required")
+	}
+	if !token.IsIdentifier(s.Package) {
+		return fmt.Errorf("package %q is not a valid Go package name", s.Package)
+	}
+	if len(s.Models) == 0 {
+		return errors.New("at least one model is required")
+	}
+
+	seen := make(map[string]bool)
+	for i, m := range s.Models {
+		if m.Name == "" {
+			return fmt.Errorf("models[%d]: name is required", i)
+		}
+		if seen[m.Name] {
+			return fmt.Errorf("models[%d]: duplicate model %q", i, m.Name)
+		}
+		seen[m.Name] = true
+		for j, f := range m.Fields {
+			if f.Name == "" || f.Type == "" {
+				return fmt.Errorf("models[%d].fields[%d]: name and type are required", i, j)
+			}
+		}
+	}
+	return nil
+}
+`,
+
+	"configs/example.yaml": `name: [[ .Name ]]
+description: Example specification for the [[ .Name ]] generator
+package: models
+
+models:
+  - name: user
+    description: A registered user.
+    fields:
+      - name: id
+        type: string
+      - name: email
+        type: string
+        description: Login e-mail address.
+      - name: created_at
+        type: time.Time
+  - name: order
+    description: An order placed by a user.
+    fields:
+      - name: id
+        type: string
+      - name: user_id
+        type: string
+      - name: total
+        type: float64
+`,
+
+	"templates/models.go.tmpl": `{{/* One struct per model in the specification. */}}
+package {{ .Spec.Package }}
+{{ range .Spec.Models }}
+// {{ .Name | pascal }} is generated from the {{ .Name }} model.
+{{- with .Description }}
+// {{ . }}
+{{- end }}
+type {{ .Name | pascal }} struct {
+{{- range .Fields }}
+	{{ with .Description }}// {{ . }}
+	{{ end }}{{ .Name | pascal }} {{ .Type }} ` + "`json:\"{{ .Name }}\"`" + `
+{{- end }}
+}
+{{ end -}}
+`,
+
+	"README.md": `# [[ .Name ]]
+
+A code generator built on [weft](https://github.com/cpcf/weft).
+
+- ` + "`spec/spec.go`" + ` defines the specification and validates it.
+- ` + "`configs/example.yaml`" + ` is an example specification.
+- ` + "`templates/`" + ` holds the templates; they see the specification as ` + "`.Spec`" + `.
+- ` + "`main.go`" + ` loads a specification and renders the templates.
+
+Fetch the dependencies and run the generator:
+
+` + "```" + `bash
+go mod tidy
+go run . -config configs/example.yaml
+` + "```" + `
+
+The output is written to ` + "`generated/`" + `. Add fields to the spec types, use them in new
+templates and run again.
+`,
+}
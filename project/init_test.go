@@ -0,0 +1,113 @@
+package project
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cpcf/weft/config"
+	"github.com/cpcf/weft/engine"
+)
+
+// The scaffolded spec types, mirrored so the example specification and the
+// starter template can be checked together.
+type initSpec struct {
+	Name        string      `yaml:"name"`
+	Description string      `yaml:"description"`
+	Package     string      `yaml:"package"`
+	Models      []initModel `yaml:"models"`
+}
+
+type initModel struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Fields      []struct {
+		Name        string `yaml:"name"`
+		Type        string `yaml:"type"`
+		Description string `yaml:"description"`
+	} `yaml:"fields"`
+}
+
+func TestInit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shopgen")
+	paths, err := Init(dir, WithModule("example.com/shopgen"))
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if len(paths) != len(scaffold) {
+		t.Errorf("Init() wrote %d files, want %d", len(paths), len(scaffold))
+	}
+
+	read := func(name string) string {
+		t.Helper()
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+	if got := read("go.mod"); !strings.HasPrefix(got, "module example.com/shopgen\n") {
+		t.Errorf("go.mod = %q", got)
+	}
+	for _, name := range []string{"main.go", "spec/spec.go"} {
+		if _, err := parser.ParseFile(token.NewFileSet(), name, read(name), 0); err != nil {
+			t.Errorf("%s does not parse: %v", name, err)
+		}
+	}
+	if main := read("main.go"); !strings.Contains(main, `"example.com/shopgen/spec"`) || !strings.Contains(main, `NewAddGeneratedHeader("shopgen"`) {
+		t.Errorf("main.go:\n%s", main)
+	}
+
+	var spec initSpec
+	if err := config.LoadYAMLStrict(filepath.Join(dir, "configs", "example.yaml"), &spec); err != nil {
+		t.Fatalf("example.yaml does not match the spec types: %v", err)
+	}
+
+	out := t.TempDir()
+	eng := engine.New(engine.WithOutputRoot(out))
+	ctx := engine.NewContext(os.DirFS(filepath.Join(dir, "templates")), out, spec.Package)
+	if err := eng.RenderDir(ctx, ".", map[string]any{"Spec": &spec}); err != nil {
+		t.Fatalf("starter template failed to render: %v", err)
+	}
+	models, err := os.ReadFile(filepath.Join(out, "models.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "models.go", models, 0); err != nil {
+		t.Errorf("generated models.go does not parse: %v\n%s", err, models)
+	}
+	for _, want := range []string{"type User struct", "CreatedAt time.Time `json:\"created_at\"`", "// Login e-mail address."} {
+		if !strings.Contains(string(models), want) {
+			t.Errorf("models.go missing %q:\n%s", want, models)
+		}
+	}
+}
+
+func TestInit_Existing(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Init(dir, WithName("gen"))
+	if err == nil || !strings.Contains(err.Error(), "refusing to overwrite existing files") {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); !os.IsNotExist(err) {
+		t.Error("Init() wrote files despite failing")
+	}
+
+	if _, err := Init(dir, WithName("gen"), WithOverwrite()); err != nil {
+		t.Fatalf("Init() with overwrite error = %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "main.go")); !strings.Contains(string(content), "Command gen generates") {
+		t.Errorf("main.go was not replaced:\n%s", content)
+	}
+
+	if _, err := Init(t.TempDir(), WithName("my gen")); err == nil || !strings.Contains(err.Error(), "invalid generator name") {
+		t.Errorf("Init() error = %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LockFileName is the name of the file, next to the project file, that
+// records the exact revisions of a project's remote packs.
+const LockFileName = "weft.lock"
+
+// Lock is the content of weft.lock. While a pack's source and version match
+// its entry, ResolvePacks fetches the locked revision and fails if the
+// pack's files do not match the locked digest, so every checkout generates
+// from the same templates even if a tag is moved.
+type Lock struct {
+	Packs []LockedPack `yaml:"packs"`
+}
+
+// LockedPack is the locked state of one remote pack.
+type LockedPack struct {
+	Source  string `yaml:"source"`
+	Version string `yaml:"version,omitempty"`
+	// Revision is the git commit or OCI manifest digest the version
+	// resolved to.
+	Revision string `yaml:"revision"`
+	// Digest is the hash of the pack's files; see Pack.Digest.
+	Digest string `yaml:"digest"`
+}
+
+const lockHeader = "# weft.lock records the exact revisions of this project's template packs.\n" +
+	"# It is maintained by weft generate and weft upgrade; commit it and do not edit it.\n"
+
+// ReadLock reads the lock file at path. A missing file is an empty lock.
+func ReadLock(path string) (*Lock, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Lock{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	var lock Lock
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file %s: %w", path, err)
+	}
+	return &lock, nil
+}
+
+// Find returns the entry for ref, or nil if ref is not locked at its
+// version.
+func (l *Lock) Find(ref PackRef) *LockedPack {
+	for i, locked := range l.Packs {
+		if locked.Source == ref.Source && locked.Version == ref.Version {
+			return &l.Packs[i]
+		}
+	}
+	return nil
+}
+
+func (l *Lock) encode() ([]byte, error) {
+	content, err := encodeYAML(l)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(lockHeader), content...), nil
+}
+
+// encodeYAML encodes v with two-space indentation, as project files are
+// written by hand.
+func encodeYAML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LockPath returns the path of the project's lock file.
+func (p *Project) LockPath() string {
+	return p.Path(LockFileName)
+}
+
+// Lock returns the lock for the packs fetched by ResolvePacks. Local packs
+// are part of the project and are not locked.
+func (p *Project) Lock() *Lock {
+	lock := &Lock{Packs: []LockedPack{}}
+	for _, pk := range p.packs {
+		if pk.Revision == "" {
+			continue
+		}
+		lock.Packs = append(lock.Packs, LockedPack{
+			Source:   pk.Ref.Source,
+			Version:  pk.Ref.Version,
+			Revision: pk.Revision,
+			Digest:   pk.Digest,
+		})
+	}
+	return lock
+}
+
+// WriteLock writes the project's lock file if it differs from Lock, and
+// reports whether it did. No file is created for a project without remote
+// packs.
+func (p *Project) WriteLock() (bool, error) {
+	if err := p.checkPacksResolved(); err != nil {
+		return false, err
+	}
+	lock := p.Lock()
+	existing, err := os.ReadFile(p.LockPath())
+	if errors.Is(err, fs.ErrNotExist) && len(lock.Packs) == 0 {
+		return false, nil
+	}
+	content, err := lock.encode()
+	if err != nil {
+		return false, fmt.Errorf("failed to encode lock file: %w", err)
+	}
+	if bytes.Equal(existing, content) {
+		return false, nil
+	}
+	if err := os.WriteFile(p.LockPath(), content, 0o644); err != nil {
+		return false, fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return true, nil
+}
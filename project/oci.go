@@ -0,0 +1,291 @@
+package project
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Media types of the manifests and layers an OCI pack may use. A pack is an
+// artifact with a single gzipped tar layer holding the pack directory, as
+// pushed by, for example:
+//
+//	tar czf pack.tgz -C go-rest-client .
+//	oras push ghcr.io/acme/packs/go-rest-client:1.2.0 pack.tgz:application/vnd.oci.image.layer.v1.tar+gzip
+const (
+	ociManifestType = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifest  = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+var ociLayerTypes = []string{
+	"application/vnd.oci.image.layer.v1.tar+gzip",
+	"application/vnd.docker.image.rootfs.diff.tar.gzip",
+	"application/vnd.cpcf.weft.pack.v1.tar+gzip",
+}
+
+type ociManifest struct {
+	Layers []struct {
+		MediaType string `json:"mediaType"`
+		Digest    string `json:"digest"`
+	} `json:"layers"`
+}
+
+// ociClient pulls from one repository of a registry, answering bearer token
+// challenges anonymously.
+type ociClient struct {
+	client     *http.Client
+	registry   string
+	repository string
+	token      string
+}
+
+func newOCIClient(source string, o *packOptions) (*ociClient, error) {
+	name := strings.TrimPrefix(source, "oci://")
+	registry, repository, ok := strings.Cut(name, "/")
+	if !ok || repository == "" {
+		return nil, fmt.Errorf("invalid OCI reference %q: want oci://registry/repository", source)
+	}
+	return &ociClient{client: o.client, registry: registry, repository: repository}, nil
+}
+
+// fetchOCI pulls ref into the cache and returns the pack directory and the
+// manifest digest.
+func fetchOCI(ctx context.Context, ref PackRef, o *packOptions) (string, string, error) {
+	c, err := newOCIClient(ref.Source, o)
+	if err != nil {
+		return "", "", err
+	}
+	version := ref.Version
+	if version == "" {
+		version = "latest"
+	}
+
+	body, err := c.get(ctx, "manifests/"+version, ociManifestType+", "+dockerManifest)
+	if err != nil {
+		return "", "", err
+	}
+	if strings.HasPrefix(version, "sha256:") {
+		if err := verifyDigest(body, version); err != nil {
+			return "", "", fmt.Errorf("manifest: %w", err)
+		}
+	}
+	manifestDigest := digestOf(body)
+	dir, err := c.pullLayer(ctx, body, o)
+	if err != nil {
+		return "", "", err
+	}
+	return dir, manifestDigest, nil
+}
+
+func (c *ociClient) pullLayer(ctx context.Context, body []byte, o *packOptions) (string, error) {
+	var manifest ociManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return "", fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	var digest string
+	for _, layer := range manifest.Layers {
+		if slices.Contains(ociLayerTypes, layer.MediaType) {
+			digest = layer.Digest
+			break
+		}
+	}
+	if digest == "" {
+		return "", errors.New("manifest has no gzipped tar layer")
+	}
+
+	dest, err := o.cachePath("oci", digest)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(dest, PackFileName)); err == nil {
+		return dest, nil
+	}
+	blob, err := c.get(ctx, "blobs/"+digest, "")
+	if err != nil {
+		return "", err
+	}
+	if err := verifyDigest(blob, digest); err != nil {
+		return "", fmt.Errorf("layer: %w", err)
+	}
+
+	tmp := dest + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return "", err
+	}
+	if err := extractTarGz(blob, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return "", fmt.Errorf("failed to extract layer: %w", err)
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+func (c *ociClient) get(ctx context.Context, path, accept string) ([]byte, error) {
+	u := fmt.Sprintf("https://%s/v2/%s/%s", c.registry, c.repository, path)
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return body, nil
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			if err := c.authenticate(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+		}
+	}
+}
+
+// authenticate fetches an anonymous token for a Bearer challenge such as
+// `Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="..."`.
+func (c *ociClient) authenticate(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("registry %s requires unsupported authentication %q", c.registry, scheme)
+	}
+	values := make(map[string]string)
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		values[key] = strings.Trim(value, `"`)
+	}
+	realm, err := url.Parse(values["realm"])
+	if err != nil || values["realm"] == "" {
+		return fmt.Errorf("registry %s sent an invalid token realm %q", c.registry, values["realm"])
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if values[key] != "" {
+			query.Set(key, values[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get registry token: %s", resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to parse registry token: %w", err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	return nil
+}
+
+func digestOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func verifyDigest(content []byte, digest string) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return fmt.Errorf("unsupported digest %q", digest)
+	}
+	if digestOf(content) != digest {
+		return fmt.Errorf("content does not match digest %s", digest)
+	}
+	return nil
+}
+
+// extractTarGz unpacks regular files and directories into dir, rejecting
+// entries that would land outside it.
+func extractTarGz(content []byte, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.FromSlash(strings.TrimPrefix(hdr.Name, "./"))
+		if name == "" || name == "." {
+			continue
+		}
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("entry %q is outside the pack", hdr.Name)
+		}
+		path := filepath.Join(dir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/cpcf/weft/config"
+	"github.com/cpcf/weft/render"
+)
+
+// PackFileName is the name of a template pack's manifest.
+const PackFileName = "weft-pack.yaml"
+
+// Pack is a reusable set of templates described by a weft-pack.yaml
+// manifest at the root of the pack:
+//
+//	name: go-rest-client
+//	version: 1.2.0
+//	description: Typed HTTP client for an OpenAPI service
+//	templates: templates
+//	data:
+//	  - path: api
+//	    type: map
+//	    description: the OpenAPI document
+//	  - path: client.package
+//	    type: string
+//	funcs: [regexReplace]
+//	processors:
+//	  - name: goimports
+//
+// Projects list packs under packs in weft.yaml; their templates are rendered
+// before the project's own, so a project can replace a pack's output file by
+// providing a template with the same path.
+type Pack struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	// Templates is the template directory inside the pack (default
+	// "templates").
+	Templates string `yaml:"templates,omitempty"`
+	// Data describes the data the templates require.
+	Data []DataField `yaml:"data,omitempty"`
+	// Funcs names functions from render.ExtendedFuncMap the templates use,
+	// on top of the defaults every template has.
+	Funcs []string `yaml:"funcs,omitempty"`
+	// Processors run on every generated file, after the project's own.
+	Processors []Processor `yaml:"processors,omitempty"`
+
+	// Dir is the directory the pack was loaded from.
+	Dir string `yaml:"-"`
+	// Ref is the reference the pack was fetched with, and Revision the git
+	// commit or OCI manifest digest it resolved to; both are empty for packs
+	// loaded with LoadPack, and Revision is empty for local packs.
+	Ref      PackRef `yaml:"-"`
+	Revision string  `yaml:"-"`
+	// Digest is a hash of the pack's files, which weft.lock records.
+	Digest string `yaml:"-"`
+}
+
+// DataField is a value a pack's templates require, at a dotted path into
+// the template data.
+type DataField struct {
+	Path string `yaml:"path"`
+	// Type is string, number, bool, list, map or any (the default).
+	Type        string `yaml:"type,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	Optional    bool   `yaml:"optional,omitempty"`
+}
+
+var dataTypes = []string{"", "any", "string", "number", "bool", "list", "map"}
+
+// LoadPack reads the pack in dir.
+func LoadPack(dir string) (*Pack, error) {
+	var pk Pack
+	if err := config.LoadYAMLStrict(filepath.Join(dir, PackFileName), &pk); err != nil {
+		return nil, fmt.Errorf("failed to load pack: %w", err)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve pack directory: %w", err)
+	}
+	pk.Dir = abs
+	if info, err := os.Stat(pk.TemplateDir()); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("pack %s has no template directory %s", pk.Name, pk.TemplateDir())
+	}
+	files, err := packFiles(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack %s: %w", pk.Name, err)
+	}
+	pk.Digest = filesDigest(files)
+	return &pk, nil
+}
+
+// Validate implements config.Validator.
+func (pk *Pack) Validate() error {
+	var errs []error
+	if pk.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if pk.Templates != "" && !filepath.IsLocal(pk.Templates) {
+		errs = append(errs, fmt.Errorf("templates: %q must be a path inside the pack", pk.Templates))
+	}
+	for i, f := range pk.Data {
+		if f.Path == "" {
+			errs = append(errs, fmt.Errorf("data[%d]: path is required", i))
+		}
+		if !slices.Contains(dataTypes, f.Type) {
+			errs = append(errs, fmt.Errorf("data[%d]: unknown type %q; use string, number, bool, list, map or any", i, f.Type))
+		}
+	}
+	extended := render.ExtendedFuncMap()
+	for _, name := range pk.Funcs {
+		if _, ok := extended[name]; !ok {
+			errs = append(errs, fmt.Errorf("funcs: unknown function %q", name))
+		}
+	}
+	for i, proc := range pk.Processors {
+		if err := proc.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("processors[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// packFiles reads every file of the pack in dir, keyed by slash-separated
+// path, skipping the .git directory.
+func packFiles(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	return files, err
+}
+
+// filesDigest hashes the sorted paths and contents of files.
+func filesDigest(files map[string][]byte) string {
+	paths := slices.Sorted(maps.Keys(files))
+	h := sha256.New()
+	for _, path := range paths {
+		sum := sha256.Sum256(files[path])
+		fmt.Fprintf(h, "%x  %s\n", sum, path)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// TemplateDir returns the resolved template directory.
+func (pk *Pack) TemplateDir() string {
+	if pk.Templates == "" {
+		return filepath.Join(pk.Dir, "templates")
+	}
+	return filepath.Join(pk.Dir, pk.Templates)
+}
+
+// FuncMap returns the functions the pack asks for.
+func (pk *Pack) FuncMap() template.FuncMap {
+	extended := render.ExtendedFuncMap()
+	funcs := make(template.FuncMap, len(pk.Funcs))
+	for _, name := range pk.Funcs {
+		funcs[name] = extended[name]
+	}
+	return funcs
+}
+
+// CheckData reports every required field that is missing from data or has
+// the wrong type.
+func (pk *Pack) CheckData(data map[string]any) error {
+	var errs []error
+	for _, f := range pk.Data {
+		value, ok := lookupPath(data, f.Path)
+		if !ok {
+			if !f.Optional {
+				errs = append(errs, fmt.Errorf("%s is required", f.describe()))
+			}
+			continue
+		}
+		if !hasType(value, f.Type) {
+			errs = append(errs, fmt.Errorf("%s must be a %s, got %T", f.describe(), f.Type, value))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("pack %s: %w", pk.Name, err)
+	}
+	return nil
+}
+
+func (f DataField) describe() string {
+	if f.Description == "" {
+		return f.Path
+	}
+	return fmt.Sprintf("%s (%s)", f.Path, f.Description)
+}
+
+// lookupPath follows a dotted path through maps and structs.
+func lookupPath(data any, path string) (any, bool) {
+	value := data
+	for _, key := range strings.Split(path, ".") {
+		v := reflect.ValueOf(value)
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil, false
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			elem := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+			if !elem.IsValid() {
+				return nil, false
+			}
+			value = elem.Interface()
+		case reflect.Struct:
+			field := v.FieldByName(key)
+			if !field.IsValid() || !field.CanInterface() {
+				return nil, false
+			}
+			value = field.Interface()
+		default:
+			return nil, false
+		}
+	}
+	return value, value != nil
+}
+
+func hasType(value any, typ string) bool {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	switch typ {
+	case "string":
+		return v.Kind() == reflect.String
+	case "number":
+		return v.CanInt() || v.CanUint() || v.CanFloat()
+	case "bool":
+		return v.Kind() == reflect.Bool
+	case "list":
+		return v.Kind() == reflect.Slice || v.Kind() == reflect.Array
+	case "map":
+		return v.Kind() == reflect.Map || v.Kind() == reflect.Struct
+	}
+	return true
+}
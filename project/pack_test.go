@@ -0,0 +1,359 @@
+package project
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testManifest = `
+name: client
+version: 1.2.0
+description: HTTP client
+data:
+  - path: service.name
+    type: string
+    description: service name
+  - path: endpoints
+    type: list
+  - path: timeout
+    type: number
+    optional: true
+funcs: [sha256]
+processors:
+  - name: header
+    generator: client-pack
+    extensions: [.go]
+`
+
+var testPack = map[string]string{
+	PackFileName:                  testManifest,
+	"templates/client.go.tmpl":    "package {{ .service.name }}\n\nconst Endpoints = {{ len .endpoints }}\n",
+	"templates/checksum.txt.tmpl": "{{ sha256 .service.name }}\n",
+}
+
+func TestPack_Project(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, filepath.Join(dir, "packs", "client"), testPack)
+	writeFiles(t, dir, map[string]string{
+		FileName: `
+templates: [templates]
+output: out
+data:
+  - file: data.yaml
+packs:
+  - source: packs/client
+    version: v1.2.0
+`,
+		"data.yaml":                   "service: {name: shop}\nendpoints: [a, b]\n",
+		"templates/checksum.txt.tmpl": "overridden\n",
+	})
+
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Engine(); err == nil || !strings.Contains(err.Error(), "ResolvePacks") {
+		t.Errorf("Engine() before ResolvePacks error = %v", err)
+	}
+	if err := p.ResolvePacks(context.Background()); err != nil {
+		t.Fatalf("ResolvePacks() error = %v", err)
+	}
+	eng, err := p.Engine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Generate(context.Background(), eng); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	client, _ := os.ReadFile(filepath.Join(dir, "out", "client.go"))
+	if !strings.Contains(string(client), "client-pack") || !strings.Contains(string(client), "const Endpoints = 2") {
+		t.Errorf("client.go:\n%s", client)
+	}
+	if checksum, _ := os.ReadFile(filepath.Join(dir, "out", "checksum.txt")); string(checksum) != "overridden\n" {
+		t.Errorf("project template did not replace the pack's: %q", checksum)
+	}
+
+	writeFiles(t, dir, map[string]string{"data.yaml": "service: {name: 1}\ntimeout: slow\n"})
+	_, err = p.LoadData(context.Background())
+	for _, want := range []string{"service.name (service name) must be a string", "endpoints is required", "timeout must be a number"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadData() error = %v, want %q", err, want)
+		}
+	}
+
+	p.Packs[0].Version = "1.3.0"
+	if err := p.ResolvePacks(context.Background()); err == nil || !strings.Contains(err.Error(), "manifest version is 1.2.0") {
+		t.Errorf("ResolvePacks() with wrong version error = %v", err)
+	}
+}
+
+func TestLoadPack_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{"no name", "version: 1.0.0\n", "name is required"},
+		{"bad type", "name: p\ndata:\n  - path: a\n    type: object\n", `data[0]: unknown type "object"`},
+		{"unknown func", "name: p\nfuncs: [nope]\n", `unknown function "nope"`},
+		{"escaping templates", "name: p\ntemplates: ../elsewhere\n", "must be a path inside the pack"},
+		{"no templates", "name: p\n", "has no template directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{PackFileName: tt.manifest})
+			if _, err := LoadPack(dir); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadPack() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchPack_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := filepath.Join(t.TempDir(), "packs.git")
+	writeFiles(t, filepath.Join(repo, "client"), testPack)
+	git(t, repo, "init", "--quiet")
+	commitAndTag(t, repo, "v1.2.0")
+
+	cache := t.TempDir()
+	ref := PackRef{Source: "file://" + filepath.ToSlash(repo) + "//client", Version: "v1.2.0"}
+	pk, err := FetchPack(context.Background(), ref, WithCacheDir(cache))
+	if err != nil {
+		t.Fatalf("FetchPack() error = %v", err)
+	}
+	if pk.Name != "client" || !strings.HasPrefix(pk.Dir, cache) {
+		t.Errorf("FetchPack() = %+v", pk)
+	}
+
+	// A pinned pack is served from the cache.
+	os.RemoveAll(repo)
+	if _, err := FetchPack(context.Background(), ref, WithCacheDir(cache)); err != nil {
+		t.Errorf("FetchPack() from cache error = %v", err)
+	}
+	ref.Version = "v2.0.0"
+	if _, err := FetchPack(context.Background(), ref, WithCacheDir(cache)); err == nil || !strings.Contains(err.Error(), "git fetch") {
+		t.Errorf("FetchPack() of a missing version error = %v", err)
+	}
+}
+
+func TestFetchPack_GitOptionInjection(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	marker := filepath.Join(t.TempDir(), "pwned")
+	for ref, want := range map[PackRef]string{
+		{Source: "git+--upload-pack=touch " + marker + "//client"}:                                  "invalid repository",
+		{Source: "https://example.com/packs.git//client", Version: "--upload-pack=touch " + marker}: "invalid version",
+	} {
+		_, err := FetchPack(context.Background(), ref, WithCacheDir(t.TempDir()))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("FetchPack(%s) error = %v, want %q", ref, err, want)
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Expected no command to run")
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", args[0], err, out)
+	}
+}
+
+func commitAndTag(t *testing.T, repo, tag string) {
+	t.Helper()
+	git(t, repo, "add", ".")
+	git(t, repo, "-c", "user.name=weft", "-c", "user.email=weft@example.com", "commit", "--quiet", "-m", tag)
+	git(t, repo, "tag", "--force", tag)
+}
+
+func TestLockAndUpgrade(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := filepath.Join(t.TempDir(), "packs.git")
+	pack := filepath.Join(repo, "client")
+	writeFiles(t, pack, testPack)
+	git(t, repo, "init", "--quiet")
+	commitAndTag(t, repo, "v1.2.0")
+	writeFiles(t, pack, map[string]string{
+		PackFileName:               strings.Replace(testManifest, "1.2.0", "1.3.0", 1),
+		"templates/client.go.tmpl": "package {{ .service.name }}\n\n// Client calls the service.\ntype Client struct{}\n",
+	})
+	commitAndTag(t, repo, "v1.3.0")
+	writeFiles(t, pack, map[string]string{"templates/rc.tmpl": "rc\n"})
+	commitAndTag(t, repo, "v1.4.0-rc.1")
+
+	source := "file://" + filepath.ToSlash(repo) + "//client"
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		FileName: "templates: [templates]\npacks:\n  # The shared client.\n  - source: " + source + "\n    version: v1.2.0\n",
+	})
+	os.Mkdir(filepath.Join(dir, "templates"), 0o755)
+	cache := WithCacheDir(t.TempDir())
+
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ResolvePacks(context.Background(), cache); err != nil {
+		t.Fatal(err)
+	}
+	if written, err := p.WriteLock(); !written || err != nil {
+		t.Fatalf("WriteLock() = %v, %v", written, err)
+	}
+	if written, _ := p.WriteLock(); written {
+		t.Error("WriteLock() rewrote an unchanged lock")
+	}
+	lock, err := ReadLock(p.LockPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := lock.Find(p.Packs[0])
+	if locked == nil || len(locked.Revision) != 40 || locked.Digest != p.ResolvedPacks()[0].Digest {
+		t.Fatalf("lock = %+v", lock)
+	}
+
+	// A moved tag does not change what the lock resolves to.
+	writeFiles(t, pack, map[string]string{"templates/moved.tmpl": "moved\n"})
+	commitAndTag(t, repo, "v1.2.0")
+	p, _ = Load(dir)
+	if err := p.ResolvePacks(context.Background(), WithCacheDir(t.TempDir())); err != nil {
+		t.Fatalf("ResolvePacks() with lock error = %v", err)
+	}
+	if got := p.ResolvedPacks()[0].Revision; got != locked.Revision {
+		t.Errorf("resolved revision %s, locked %s", got, locked.Revision)
+	}
+
+	locked.Digest = "sha256:0"
+	content, _ := lock.encode()
+	os.WriteFile(p.LockPath(), content, 0o644)
+	if err := p.ResolvePacks(context.Background(), cache); err == nil || !strings.Contains(err.Error(), "does not match weft.lock") {
+		t.Errorf("ResolvePacks() with a bad digest error = %v", err)
+	}
+	os.Remove(p.LockPath())
+
+	if latest, err := LatestVersion(context.Background(), p.Packs[0]); err != nil || latest != "v1.3.0" {
+		t.Errorf("LatestVersion() = %q, %v", latest, err)
+	}
+	if err := p.ResolvePacks(context.Background(), cache); err != nil {
+		t.Fatal(err)
+	}
+	upgrades, err := p.Upgrade(context.Background(), nil, cache)
+	if err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	if len(upgrades) != 1 || upgrades[0].From != "v1.2.0" || upgrades[0].To != "v1.3.0" {
+		t.Fatalf("Upgrade() = %+v", upgrades)
+	}
+	var changes []string
+	for _, c := range upgrades[0].Changes {
+		changes = append(changes, c.String())
+	}
+	want := []string{
+		"templates/client.go.tmpl: modified (+2 -1 lines)",
+		"weft-pack.yaml: modified (+1 -1 lines)",
+	}
+	if strings.Join(changes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(changes, "\n"), strings.Join(want, "\n"))
+	}
+
+	if err := p.SavePackVersions(); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := os.ReadFile(filepath.Join(dir, FileName))
+	if !strings.Contains(string(saved), "version: v1.3.0") || !strings.Contains(string(saved), "# The shared client.") {
+		t.Errorf("weft.yaml after upgrade:\n%s", saved)
+	}
+	if again, err := p.Upgrade(context.Background(), []string{source}, cache); err != nil || len(again) != 0 {
+		t.Errorf("second Upgrade() = %+v, %v", again, err)
+	}
+	if _, err := p.Upgrade(context.Background(), []string{"other"}); err == nil {
+		t.Error("Upgrade() of an unknown source succeeded")
+	}
+}
+
+func TestFetchPack_OCI(t *testing.T) {
+	var layer bytes.Buffer
+	gz := gzip.NewWriter(&layer)
+	tw := tar.NewWriter(gz)
+	for name, content := range testPack {
+		tw.WriteHeader(&tar.Header{Name: "./" + name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	layerDigest := digestOf(layer.Bytes())
+	manifest := fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":%q,"size":%d}]}`,
+		ociManifestType, layerDigest, layer.Len())
+	manifestDigest := digestOf([]byte(manifest))
+
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:acme/client:pull" {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"token":"secret"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:acme/client:pull"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/acme/client/manifests/1.2.0", "/v2/acme/client/manifests/" + manifestDigest:
+			w.Header().Set("Content-Type", ociManifestType)
+			fmt.Fprint(w, manifest)
+		case "/v2/acme/client/blobs/" + layerDigest:
+			w.Write(layer.Bytes())
+		case "/v2/acme/client/tags/list":
+			fmt.Fprint(w, `{"name":"acme/client","tags":["1.2.0","1.10.0","1.9.1","2.0.0-beta","latest"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	source := "oci://" + srv.Listener.Addr().String() + "/acme/client"
+	opts := []PackOption{WithCacheDir(t.TempDir()), WithHTTPClient(srv.Client())}
+	for _, version := range []string{"1.2.0", manifestDigest} {
+		pk, err := FetchPack(context.Background(), PackRef{Source: source, Version: version}, opts...)
+		if err != nil {
+			t.Fatalf("FetchPack(%s) error = %v", version, err)
+		}
+		if pk.Name != "client" || len(pk.Funcs) != 1 {
+			t.Errorf("FetchPack(%s) = %+v", version, pk)
+		}
+	}
+
+	if latest, err := LatestVersion(context.Background(), PackRef{Source: source}, opts...); err != nil || latest != "1.10.0" {
+		t.Errorf("LatestVersion() = %q, %v", latest, err)
+	}
+
+	wrong := "sha256:" + strings.Repeat("0", 64)
+	if _, err := FetchPack(context.Background(), PackRef{Source: source, Version: wrong}, opts...); err == nil {
+		t.Error("FetchPack() with an unknown digest succeeded")
+	}
+}
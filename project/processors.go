@@ -0,0 +1,71 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cpcf/weft/postprocess"
+	"github.com/cpcf/weft/processors"
+)
+
+// Processor declares a post-processor by name, with the settings that
+// processor takes:
+//
+//	goimports        format Go files and fix their imports
+//	trim-whitespace  trim trailing whitespace
+//	header           add a "Code generated" header; generator, extensions
+//	markdown         normalise Markdown files
+//	json, yaml       reformat JSON and YAML files
+//	sql              format SQL files; dialect
+//	command          run an external tool; command, with "{file}" for the path
+type Processor struct {
+	Name       string   `yaml:"name"`
+	Generator  string   `yaml:"generator,omitempty"`
+	Extensions []string `yaml:"extensions,omitempty"`
+	Dialect    string   `yaml:"dialect,omitempty"`
+	Command    []string `yaml:"command,omitempty"`
+}
+
+func (p Processor) validate() error {
+	_, err := p.build()
+	return err
+}
+
+// build creates the processor p declares.
+func (p Processor) build() (postprocess.Processor, error) {
+	switch p.Name {
+	case "goimports":
+		return processors.NewGoImports(), nil
+	case "trim-whitespace":
+		return processors.NewTrimWhitespace(), nil
+	case "header":
+		generator := p.Generator
+		if generator == "" {
+			generator = "weft"
+		}
+		return processors.NewAddGeneratedHeader(generator, p.Extensions...), nil
+	case "markdown":
+		return processors.NewMarkdown(), nil
+	case "json":
+		return processors.NewJSONFormat(), nil
+	case "yaml":
+		return processors.NewYAMLFormat(), nil
+	case "sql":
+		switch dialect := processors.SQLDialect(p.Dialect); dialect {
+		case "", processors.SQLPostgres, processors.SQLMySQL, processors.SQLSQLite:
+			if dialect == "" {
+				dialect = processors.SQLPostgres
+			}
+			return processors.NewSQLFormat(dialect), nil
+		}
+		return nil, fmt.Errorf("unknown SQL dialect %q", p.Dialect)
+	case "command":
+		if len(p.Command) == 0 {
+			return nil, errors.New("command processor requires command")
+		}
+		return processors.NewCommand(p.Command[0], p.Command[1:]...), nil
+	case "":
+		return nil, errors.New("name is required")
+	}
+	return nil, fmt.Errorf("unknown processor %q", p.Name)
+}
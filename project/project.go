@@ -0,0 +1,181 @@
+// Package project loads weft.yaml project files, which declare everything a
+// generator needs — template directories, data sources, the output root and
+// post-processors — so simple generators need no Go code at all:
+//
+//	templates:
+//	  - templates
+//	output: generated
+//	package: github.com/acme/shop
+//	data:
+//	  - file: data/shop.yaml
+//	  - name: codes
+//	    csv: data/error_codes.csv
+//	    keyBy: code
+//	processors:
+//	  - name: goimports
+//	  - name: header
+//	    generator: weft
+//	    extensions: [.go]
+//	packs:
+//	  - source: https://github.com/acme/packs.git//go-rest-client
+//	    version: v1.2.0
+//
+// Relative paths are resolved against the directory of the project file.
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cpcf/weft/config"
+)
+
+// FileName is the conventional name of a project file.
+const FileName = "weft.yaml"
+
+// Project is a parsed project file.
+type Project struct {
+	// Templates are the template directories, rendered in order into Output.
+	Templates []string `yaml:"templates"`
+	// Output is the root directory for generated files (default "generated").
+	Output string `yaml:"output"`
+	// Package is the Go package path of the generated code, passed to the
+	// engine context.
+	Package string `yaml:"package,omitempty"`
+	// FailureMode is fail-fast (default), fail-at-end or best-effort.
+	FailureMode string `yaml:"failureMode,omitempty"`
+	// Data are merged in order into the data passed to every template.
+	Data []DataSource `yaml:"data,omitempty"`
+	// Processors run on every generated file, in order.
+	Processors []Processor `yaml:"processors,omitempty"`
+	// Packs are template packs rendered before the project's own templates;
+	// see Pack. ResolvePacks must be called before Engine and Generate.
+	Packs []PackRef `yaml:"packs,omitempty"`
+
+	// Dir is the directory of the project file; relative paths are resolved
+	// against it.
+	Dir string `yaml:"-"`
+
+	file  string
+	packs []*Pack
+}
+
+// Load reads the project file at path, or weft.yaml inside path if it is a
+// directory.
+func Load(path string) (*Project, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, FileName)
+	}
+
+	var p Project
+	if err := config.LoadYAMLStrict(path, &p); err != nil {
+		return nil, fmt.Errorf("failed to load project: %w", err)
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	p.Dir = dir
+	p.file = filepath.Join(dir, filepath.Base(path))
+	return &p, nil
+}
+
+// Validate implements config.Validator.
+func (p *Project) Validate() error {
+	var errs []error
+	if len(p.Templates) == 0 {
+		errs = append(errs, errors.New("templates: at least one template directory is required"))
+	}
+	if _, err := p.failureMode(); err != nil {
+		errs = append(errs, fmt.Errorf("failureMode: %w", err))
+	}
+	for i, d := range p.Data {
+		if err := d.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("data[%d]: %w", i, err))
+		}
+	}
+	for i, proc := range p.Processors {
+		if err := proc.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("processors[%d]: %w", i, err))
+		}
+	}
+	for i, ref := range p.Packs {
+		if ref.Source == "" {
+			errs = append(errs, fmt.Errorf("packs[%d]: source is required", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Path resolves a path from the project file against the project directory.
+func (p *Project) Path(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.Dir, path)
+}
+
+// OutputDir returns the resolved output root.
+func (p *Project) OutputDir() string {
+	if p.Output == "" {
+		return p.Path("generated")
+	}
+	return p.Path(p.Output)
+}
+
+// TemplateDirs returns the resolved template directories: those of the
+// resolved packs, then the project's own.
+func (p *Project) TemplateDirs() []string {
+	var dirs []string
+	for _, pk := range p.packs {
+		dirs = append(dirs, pk.TemplateDir())
+	}
+	for _, dir := range p.Templates {
+		dirs = append(dirs, p.Path(dir))
+	}
+	return dirs
+}
+
+// ResolvePacks fetches the project's packs. Relative local sources are
+// resolved against the project directory, and packs locked in weft.lock at
+// their current version are fetched at the locked revision.
+func (p *Project) ResolvePacks(ctx context.Context, opts ...PackOption) error {
+	lock, err := ReadLock(p.LockPath())
+	if err != nil {
+		return err
+	}
+	o := newPackOptions(append([]PackOption{WithPackDir(p.Dir)}, opts...))
+	packs := make([]*Pack, 0, len(p.Packs))
+	for _, ref := range p.Packs {
+		revision := ""
+		locked := lock.Find(ref)
+		if locked != nil {
+			revision = locked.Revision
+		}
+		pk, err := fetchPack(ctx, ref, revision, o)
+		if err != nil {
+			return err
+		}
+		if locked != nil && pk.Digest != locked.Digest {
+			return fmt.Errorf("pack %s does not match %s: its files hash to %s, locked %s", ref, LockFileName, pk.Digest, locked.Digest)
+		}
+		packs = append(packs, pk)
+	}
+	p.packs = packs
+	return nil
+}
+
+// ResolvedPacks returns the packs fetched by ResolvePacks.
+func (p *Project) ResolvedPacks() []*Pack {
+	return p.packs
+}
+
+func (p *Project) checkPacksResolved() error {
+	if len(p.Packs) > 0 && p.packs == nil {
+		return errors.New("project packs have not been resolved; call ResolvePacks first")
+	}
+	return nil
+}
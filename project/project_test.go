@@ -0,0 +1,171 @@
+package project
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cpcf/weft/engine"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		FileName: `
+templates: [templates]
+output: out
+package: example.com/shop
+data:
+  - file: data/shop.yaml
+  - name: codes
+    csv: data/codes.csv
+    keyBy: code
+processors:
+  - name: header
+    generator: shop-gen
+    extensions: [.go]
+`,
+		"data/shop.yaml": "name: shop\n",
+		"data/codes.csv": "code,status\nE001,404\n",
+		"templates/errors.go.tmpl": `package {{ .name }}
+
+const NotFoundStatus = {{ (index .codes "E001").status }}
+`,
+	})
+
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if p.Dir != dir {
+		t.Errorf("Dir = %q, want %q", p.Dir, dir)
+	}
+
+	eng, err := p.Engine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := p.Generate(context.Background(), eng)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	want := filepath.Join(dir, "out", "errors.go")
+	if len(files) != 1 || files[0].OutputPath != want {
+		t.Fatalf("Generate() = %+v, want %s", files, want)
+	}
+	content, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "shop-gen") || !strings.Contains(string(content), "NotFoundStatus = 404") {
+		t.Errorf("generated file:\n%s", content)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		wantErr string
+	}{
+		{"no templates", "output: out\n", "at least one template directory is required"},
+		{"unknown key", "templates: [t]\noutptu: out\n", "outptu"},
+		{"failure mode", "templates: [t]\nfailureMode: sometimes\n", `unknown failure mode "sometimes"`},
+		{"no source", "templates: [t]\ndata:\n  - name: x\n", "data[0]: one of file, url"},
+		{"two sources", "templates: [t]\ndata:\n  - file: a.yaml\n    csv: a.csv\n", "only one source is allowed, got file, csv"},
+		{"sheet without xlsx", "templates: [t]\ndata:\n  - csv: a.csv\n    sheet: One\n", "sheet requires xlsx"},
+		{"unknown processor", "templates: [t]\nprocessors:\n  - name: prettier\n", `processors[0]: unknown processor "prettier"`},
+		{"sql dialect", "templates: [t]\nprocessors:\n  - name: sql\n    dialect: oracle\n", `unknown SQL dialect "oracle"`},
+		{"command", "templates: [t]\nprocessors:\n  - name: command\n", "requires command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			writeFiles(t, filepath.Dir(path), map[string]string{FileName: tt.project})
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProject_Paths(t *testing.T) {
+	p := &Project{Dir: "/work", Templates: []string{"templates", "/shared/templates"}}
+	if got := p.OutputDir(); got != filepath.Join("/work", "generated") {
+		t.Errorf("OutputDir() = %q", got)
+	}
+	dirs := p.TemplateDirs()
+	if dirs[0] != filepath.Join("/work", "templates") || dirs[1] != "/shared/templates" {
+		t.Errorf("TemplateDirs() = %q", dirs)
+	}
+
+	p.FailureMode = "best-effort"
+	if mode, err := p.failureMode(); err != nil || mode != engine.BestEffort {
+		t.Errorf("failureMode() = %v, %v", mode, err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		FileName:               "templates: [templates]\noutput: out\ndata:\n  - file: data.yaml\n",
+		"data.yaml":            "names: [a, b, c]\n",
+		"templates/list.tmpl":  "{{ range .names }}{{ . }}\n{{ end }}",
+		"templates/other.tmpl": "static\n",
+	})
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drifts, err := p.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if len(drifts) != 2 || !drifts[0].Missing || drifts[0].Path != "list" || drifts[0].Added != 3 {
+		t.Fatalf("Check() before generating = %+v", drifts)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); !os.IsNotExist(err) {
+		t.Error("Check() wrote to the output directory")
+	}
+
+	eng, err := p.Engine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Generate(context.Background(), eng); err != nil {
+		t.Fatal(err)
+	}
+	if drifts, err := p.Check(context.Background()); err != nil || len(drifts) != 0 {
+		t.Fatalf("Check() after generating = %+v, %v", drifts, err)
+	}
+
+	writeFiles(t, dir, map[string]string{"data.yaml": "names: [a, x, y, c]\n"})
+	drifts, err = p.Check(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Drift{Path: "list", Line: 2, Added: 2, Removed: 1}
+	if len(drifts) != 1 || drifts[0] != want {
+		t.Errorf("Check() after changing data = %+v, want %+v", drifts, want)
+	}
+	if got := drifts[0].String(); got != "list:2: out of date (+2 -1 lines)" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,258 @@
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PackUpgrade is a pack moved to a newer version by Upgrade.
+type PackUpgrade struct {
+	Source   string
+	From, To string
+	// Changes lists the files that differ between the two versions.
+	Changes []PackChange
+}
+
+// PackChange is a file that differs between two versions of a pack.
+type PackChange struct {
+	// Path is the file's slash-separated path inside the pack.
+	Path string
+	// Status is "added", "removed" or "modified".
+	Status string
+	// Added and Removed estimate the lines added and removed, as for Drift.
+	Added, Removed int
+}
+
+func (c PackChange) String() string {
+	switch c.Status {
+	case "added":
+		return fmt.Sprintf("%s: added (+%d lines)", c.Path, c.Added)
+	case "removed":
+		return fmt.Sprintf("%s: removed (-%d lines)", c.Path, c.Removed)
+	}
+	return fmt.Sprintf("%s: modified (+%d -%d lines)", c.Path, c.Added, c.Removed)
+}
+
+// DiffPacks compares the files of two versions of a pack.
+func DiffPacks(from, to *Pack) ([]PackChange, error) {
+	old, err := packFiles(from.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack %s: %w", from.Name, err)
+	}
+	next, err := packFiles(to.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack %s: %w", to.Name, err)
+	}
+
+	paths := slices.Sorted(maps.Keys(old))
+	for path := range next {
+		if _, ok := old[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+
+	var changes []PackChange
+	for _, path := range paths {
+		before, inOld := old[path]
+		after, inNext := next[path]
+		switch {
+		case !inOld:
+			changes = append(changes, PackChange{Path: path, Status: "added", Added: len(splitLines(after))})
+		case !inNext:
+			changes = append(changes, PackChange{Path: path, Status: "removed", Removed: len(splitLines(before))})
+		case string(before) != string(after):
+			c := PackChange{Path: path, Status: "modified"}
+			_, c.Added, c.Removed = compareLines(before, after)
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+// LatestVersion returns the newest release of a remote pack: the highest
+// version-like tag ("v1.2.3" or "1.2.3"), ignoring pre-releases.
+func LatestVersion(ctx context.Context, ref PackRef, opts ...PackOption) (string, error) {
+	return latestVersion(ctx, ref, newPackOptions(opts))
+}
+
+func latestVersion(ctx context.Context, ref PackRef, o *packOptions) (string, error) {
+	var tags []string
+	switch ref.kind() {
+	case localPack:
+		return "", fmt.Errorf("pack %s is local and has no versions", ref.Source)
+	case gitPack:
+		repo, _, err := gitRepo(ref.Source)
+		if err != nil {
+			return "", err
+		}
+		out, err := runGit(ctx, "", "ls-remote", "--tags", "--refs", "--", repo)
+		if err != nil {
+			return "", fmt.Errorf("failed to list tags of %s: %w", repo, err)
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if _, name, ok := strings.Cut(line, "\trefs/tags/"); ok {
+				tags = append(tags, name)
+			}
+		}
+	case ociPack:
+		c, err := newOCIClient(ref.Source, o)
+		if err != nil {
+			return "", err
+		}
+		body, err := c.get(ctx, "tags/list", "")
+		if err != nil {
+			return "", fmt.Errorf("failed to list tags: %w", err)
+		}
+		var list struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return "", fmt.Errorf("failed to parse tag list: %w", err)
+		}
+		tags = list.Tags
+	}
+
+	latest := ""
+	for _, tag := range tags {
+		if !semverRef.MatchString(tag) || strings.ContainsAny(tag, "-+") {
+			continue
+		}
+		if latest == "" || compareVersions(tag, latest) > 0 {
+			latest = tag
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("pack %s has no released versions", ref.Source)
+	}
+	return latest, nil
+}
+
+// compareVersions compares two version-like strings numerically, ignoring a
+// leading "v" and any pre-release or build suffix.
+func compareVersions(a, b string) int {
+	parse := func(v string) [3]int {
+		var parts [3]int
+		v, _, _ = strings.Cut(strings.TrimPrefix(v, "v"), "-")
+		v, _, _ = strings.Cut(v, "+")
+		for i, field := range strings.SplitN(v, ".", 3) {
+			parts[i], _ = strconv.Atoi(field)
+		}
+		return parts
+	}
+	pa, pb := parse(a), parse(b)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			return pa[i] - pb[i]
+		}
+	}
+	return 0
+}
+
+// Upgrade moves remote packs to their latest version, or only the packs
+// whose source is in sources if any are given, fetching the new versions in
+// place of the resolved ones. Packs pinned to a version newer than the
+// latest release are left alone; packs pinned to a branch or commit are
+// moved to the latest release. Upgrade changes p in memory only: write the
+// result with SavePackVersions and WriteLock.
+func (p *Project) Upgrade(ctx context.Context, sources []string, opts ...PackOption) ([]PackUpgrade, error) {
+	if err := p.checkPacksResolved(); err != nil {
+		return nil, err
+	}
+	for _, source := range sources {
+		if !slices.ContainsFunc(p.Packs, func(ref PackRef) bool { return ref.Source == source }) {
+			return nil, fmt.Errorf("no pack with source %q", source)
+		}
+	}
+
+	o := newPackOptions(append([]PackOption{WithPackDir(p.Dir)}, opts...))
+	var upgrades []PackUpgrade
+	for i, ref := range p.Packs {
+		if ref.kind() == localPack || (len(sources) > 0 && !slices.Contains(sources, ref.Source)) {
+			continue
+		}
+		latest, err := latestVersion(ctx, ref, o)
+		if err != nil {
+			return nil, err
+		}
+		if latest == ref.Version || semverRef.MatchString(ref.Version) && compareVersions(latest, ref.Version) <= 0 {
+			continue
+		}
+
+		next := PackRef{Source: ref.Source, Version: latest}
+		pk, err := fetchPack(ctx, next, "", o)
+		if err != nil {
+			return nil, err
+		}
+		changes, err := DiffPacks(p.packs[i], pk)
+		if err != nil {
+			return nil, err
+		}
+		upgrades = append(upgrades, PackUpgrade{Source: ref.Source, From: ref.Version, To: latest, Changes: changes})
+		p.Packs[i] = next
+		p.packs[i] = pk
+	}
+	return upgrades, nil
+}
+
+// SavePackVersions writes the versions in p.Packs back to the project file.
+// The rest of the file, including comments, is kept, although its
+// indentation is normalised.
+func (p *Project) SavePackVersions() error {
+	if p.file == "" {
+		return errors.New("project was not loaded from a file")
+	}
+	content, err := os.ReadFile(p.file)
+	if err != nil {
+		return fmt.Errorf("failed to read project file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("failed to parse project file: %w", err)
+	}
+	packs := mappingValue(doc.Content[0], "packs")
+	if packs == nil || packs.Kind != yaml.SequenceNode || len(packs.Content) != len(p.Packs) {
+		return errors.New("project file packs do not match the loaded project")
+	}
+	for i, item := range packs.Content {
+		version := p.Packs[i].Version
+		if node := mappingValue(item, "version"); node != nil {
+			node.SetString(version)
+		} else if version != "" {
+			var key, value yaml.Node
+			key.SetString("version")
+			value.SetString(version)
+			item.Content = append(item.Content, &key, &value)
+		}
+	}
+
+	out, err := encodeYAML(&doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode project file: %w", err)
+	}
+	if err := os.WriteFile(p.file, out, 0o644); err != nil {
+		return fmt.Errorf("failed to write project file: %w", err)
+	}
+	return nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
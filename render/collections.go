@@ -1,10 +1,10 @@
 package render
 
 import (
-	"crypto/rand"
+	"cmp"
 	"fmt"
 	"reflect"
-	"sort"
+	"slices"
 	"strings"
 )
 
@@ -41,19 +41,26 @@ func filterSlice(slice any, predicate func(any) bool) any {
 		return slice
 	}
 
-	sliceType := v.Type()
-	result := reflect.MakeSlice(sliceType, 0, v.Len())
-
+	// Collect the indexes first so the result is allocated once, at the
+	// size it needs.
+	var kept []int
 	for i := 0; i < v.Len(); i++ {
-		item := v.Index(i).Interface()
-		if predicate(item) {
-			result = reflect.Append(result, v.Index(i))
+		if predicate(v.Index(i).Interface()) {
+			kept = append(kept, i)
 		}
 	}
 
+	result := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), len(kept), len(kept))
+	for i, index := range kept {
+		result.Index(i).Set(v.Index(index))
+	}
+
 	return result.Interface()
 }
 
+// mapSlice applies mapper to each element. The result has the type of the
+// first mapped value's slice, or is a []any when the mapped values differ in
+// type.
 func mapSlice(slice any, mapper func(any) any) any {
 	if slice == nil {
 		return slice
@@ -64,16 +71,34 @@ func mapSlice(slice any, mapper func(any) any) any {
 		return slice
 	}
 
-	elementType := reflect.TypeOf(mapper(v.Index(0).Interface()))
-	resultType := reflect.SliceOf(elementType)
-	result := reflect.MakeSlice(resultType, 0, v.Len())
+	mapped := make([]any, v.Len())
+	for i := range mapped {
+		mapped[i] = mapper(v.Index(i).Interface())
+	}
 
-	for i := 0; i < v.Len(); i++ {
-		item := v.Index(i).Interface()
-		mapped := mapper(item)
-		result = reflect.Append(result, reflect.ValueOf(mapped))
+	return typedSlice(mapped)
+}
+
+// typedSlice returns values as a slice of their type when they all have the
+// same type, and as they are otherwise.
+func typedSlice(values []any) any {
+	if len(values) == 0 {
+		return values
+	}
+	elementType := reflect.TypeOf(values[0])
+	for _, value := range values[1:] {
+		if reflect.TypeOf(value) != elementType {
+			return values
+		}
+	}
+	if elementType == nil {
+		return values
 	}
 
+	result := reflect.MakeSlice(reflect.SliceOf(elementType), len(values), len(values))
+	for i, value := range values {
+		result.Index(i).Set(reflect.ValueOf(value))
+	}
 	return result.Interface()
 }
 
@@ -139,7 +164,7 @@ func reverseSlice(slice any) any {
 	}
 
 	length := v.Len()
-	result := reflect.MakeSlice(v.Type(), length, length)
+	result := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), length, length)
 
 	for i := range length {
 		result.Index(i).Set(v.Index(length - 1 - i))
@@ -148,53 +173,90 @@ func reverseSlice(slice any) any {
 	return result.Interface()
 }
 
+// sortSlice returns a sorted copy of a slice or array. Strings sort
+// lexically and numbers numerically, including in a []any holding only
+// numbers; other values sort by their formatted text. The sort is stable,
+// and nil elements sort last.
 func sortSlice(slice any) any {
-	if slice == nil {
-		return slice
+	return sortValues(slice, nil, false)
+}
+
+// sortDesc is sortSlice in descending order. Equal elements keep their
+// order and nil elements still sort last.
+func sortDesc(slice any) any {
+	return sortValues(slice, nil, true)
+}
+
+// sortBy returns a copy of slice sorted by the value at field in each
+// element, where field is a path as in lookup, such as "Name" or
+// "Owner.Email":
+//
+//	{{ range sortBy "Name" .Fields }}
+//
+// Elements with equal values keep their order, and elements for which
+// field is missing or nil sort last.
+func sortBy(field string, slice any) (any, error) {
+	steps, err := lookupSteps(field)
+	if err != nil {
+		return nil, err
+	}
+	return sortValues(slice, steps, false), nil
+}
+
+// sortByDesc is sortBy in descending order.
+func sortByDesc(field string, slice any) (any, error) {
+	steps, err := lookupSteps(field)
+	if err != nil {
+		return nil, err
 	}
+	return sortValues(slice, steps, true), nil
+}
 
-	v := reflect.ValueOf(slice)
-	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+// sortWith returns a copy of slice stably sorted by less, which reports
+// whether a sorts before b. The function usually comes from the data or
+// from a registered function.
+func sortWith(less func(a, b any) bool, slice any) any {
+	if slice == nil || less == nil {
 		return slice
 	}
 
-	length := v.Len()
-	if length == 0 {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
 		return slice
 	}
 
-	result := reflect.MakeSlice(v.Type(), length, length)
-	reflect.Copy(result, v)
-
-	elementType := v.Type().Elem()
-	switch elementType.Kind() {
-	case reflect.String:
-		sort.Slice(result.Interface(), func(i, j int) bool {
-			return result.Index(i).String() < result.Index(j).String()
-		})
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		sort.Slice(result.Interface(), func(i, j int) bool {
-			return result.Index(i).Int() < result.Index(j).Int()
-		})
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		sort.Slice(result.Interface(), func(i, j int) bool {
-			return result.Index(i).Uint() < result.Index(j).Uint()
-		})
-	case reflect.Float32, reflect.Float64:
-		sort.Slice(result.Interface(), func(i, j int) bool {
-			return result.Index(i).Float() < result.Index(j).Float()
-		})
-	default:
-		sort.Slice(result.Interface(), func(i, j int) bool {
-			return fmt.Sprintf("%v", result.Index(i).Interface()) <
-				fmt.Sprintf("%v", result.Index(j).Interface())
-		})
-	}
+	order := identityOrder(v.Len())
+	slices.SortStableFunc(order, func(i, j int) int {
+		a, b := v.Index(i).Interface(), v.Index(j).Interface()
+		switch {
+		case less(a, b):
+			return -1
+		case less(b, a):
+			return 1
+		default:
+			return 0
+		}
+	})
 
-	return result.Interface()
+	return permute(v, order)
 }
 
-func uniqueSlice(slice any) any {
+// sortValues returns a stably sorted copy of slice, ordered by the value at
+// steps in each element.
+func sortValues(slice any, steps []string, descending bool) any {
+	if len(steps) == 0 {
+		switch s := slice.(type) {
+		case []string:
+			return sortedCopy(s, descending)
+		case []int:
+			return sortedCopy(s, descending)
+		case []int64:
+			return sortedCopy(s, descending)
+		case []float64:
+			return sortedCopy(s, descending)
+		}
+	}
+
 	if slice == nil {
 		return slice
 	}
@@ -204,42 +266,173 @@ func uniqueSlice(slice any) any {
 		return slice
 	}
 
-	seen := make(map[any]bool)
-	result := reflect.MakeSlice(v.Type(), 0, v.Len())
+	if v.Len() == 0 {
+		return slice
+	}
 
-	for i := 0; i < v.Len(); i++ {
-		item := v.Index(i).Interface()
-		key := makeComparableKey(item)
-		if !seen[key] {
-			seen[key] = true
-			result = reflect.Append(result, v.Index(i))
-		}
+	keys := sortKeysOf(v.Len(), func(i int) reflect.Value {
+		return lookupValue(v.Index(i), steps)
+	})
+	keys.descending = descending
+	order := identityOrder(v.Len())
+	slices.SortStableFunc(order, keys.compare)
+
+	return permute(v, order)
+}
+
+func sortedCopy[S ~[]E, E cmp.Ordered](s S, descending bool) S {
+	sorted := slices.Clone(s)
+	slices.Sort(sorted)
+	if descending {
+		slices.Reverse(sorted)
 	}
+	return sorted
+}
 
+// identityOrder returns the indexes 0 to n-1.
+func identityOrder(n int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
+
+// permute returns a new slice holding the elements of v in order.
+func permute(v reflect.Value, order []int) any {
+	result := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), len(order), len(order))
+	for i, index := range order {
+		result.Index(i).Set(v.Index(index))
+	}
 	return result.Interface()
 }
 
-func makeComparableKey(item any) any {
-	v := reflect.ValueOf(item)
+// sortKeys holds one key per element, extracted once so that sorting
+// compares plain values rather than going through reflection or fmt for
+// every comparison. Only one of the key slices is used.
+type sortKeys struct {
+	strings []string
+	ints    []int64
+	uints   []uint64
+	floats  []float64
+	// missing marks the elements without a value, which sort last.
+	missing    []bool
+	descending bool
+}
 
-	switch v.Kind() {
-	case reflect.Slice, reflect.Array:
-		return fmt.Sprintf("%v", item)
-	case reflect.Map:
-		return fmt.Sprintf("%v", item)
-	case reflect.Struct:
-		return fmt.Sprintf("%v", item)
-	case reflect.Ptr:
-		if v.IsNil() {
-			return "<nil>"
+// sortKeysOf extracts the keys of n values: strings if every value is a
+// string, integers or floats if every value is a number, and the formatted
+// values otherwise. Pointers and interfaces are followed, and invalid or
+// nil values are marked missing.
+func sortKeysOf(n int, value func(i int) reflect.Value) sortKeys {
+	values := make([]reflect.Value, n)
+	missing := make([]bool, n)
+	kinds := make(map[string]bool)
+	for i := range values {
+		values[i] = indirectValue(value(i))
+		switch values[i].Kind() {
+		case reflect.Invalid:
+			missing[i] = true
+		case reflect.String:
+			kinds["string"] = true
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			kinds["int"] = true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			kinds["uint"] = true
+		case reflect.Float32, reflect.Float64:
+			kinds["float"] = true
+		default:
+			kinds["other"] = true
+		}
+	}
+
+	keys := sortKeys{missing: missing}
+	switch {
+	case len(kinds) == 1 && kinds["string"]:
+		keys.strings = make([]string, n)
+		for i, v := range values {
+			if !missing[i] {
+				keys.strings[i] = v.String()
+			}
+		}
+	case len(kinds) == 1 && kinds["int"]:
+		keys.ints = make([]int64, n)
+		for i, v := range values {
+			if !missing[i] {
+				keys.ints[i] = v.Int()
+			}
+		}
+	case len(kinds) == 1 && kinds["uint"]:
+		keys.uints = make([]uint64, n)
+		for i, v := range values {
+			if !missing[i] {
+				keys.uints[i] = v.Uint()
+			}
+		}
+	case !kinds["string"] && !kinds["other"]:
+		keys.floats = make([]float64, n)
+		for i, v := range values {
+			switch {
+			case missing[i]:
+			case v.CanInt():
+				keys.floats[i] = float64(v.Int())
+			case v.CanUint():
+				keys.floats[i] = float64(v.Uint())
+			default:
+				keys.floats[i] = v.Float()
+			}
 		}
-		return makeComparableKey(v.Elem().Interface())
 	default:
-		return item
+		keys.strings = make([]string, n)
+		for i, v := range values {
+			if !missing[i] {
+				keys.strings[i] = fmt.Sprintf("%v", v.Interface())
+			}
+		}
+	}
+	return keys
+}
+
+// compare compares the keys of elements i and j.
+func (k sortKeys) compare(i, j int) int {
+	if k.missing[i] || k.missing[j] {
+		switch {
+		case !k.missing[j]:
+			return 1
+		case !k.missing[i]:
+			return -1
+		default:
+			return 0
+		}
 	}
+
+	var c int
+	switch {
+	case k.ints != nil:
+		c = cmp.Compare(k.ints[i], k.ints[j])
+	case k.uints != nil:
+		c = cmp.Compare(k.uints[i], k.uints[j])
+	case k.floats != nil:
+		c = cmp.Compare(k.floats[i], k.floats[j])
+	default:
+		c = strings.Compare(k.strings[i], k.strings[j])
+	}
+	if k.descending {
+		return -c
+	}
+	return c
 }
 
-func shuffleSlice(slice any) any {
+// uniqueSlice returns the elements of a slice or array without repeats,
+// keeping the first of each.
+func uniqueSlice(slice any) any {
+	switch s := slice.(type) {
+	case []string:
+		return uniqueCopy(s)
+	case []int:
+		return uniqueCopy(s)
+	}
+
 	if slice == nil {
 		return slice
 	}
@@ -249,24 +442,50 @@ func shuffleSlice(slice any) any {
 		return slice
 	}
 
-	length := v.Len()
-	result := reflect.MakeSlice(v.Type(), length, length)
-	reflect.Copy(result, v)
+	seen := make(map[any]bool, v.Len())
+	kept := make([]int, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		key := makeComparableKey(v.Index(i).Interface())
+		if !seen[key] {
+			seen[key] = true
+			kept = append(kept, i)
+		}
+	}
+
+	return permute(v, kept)
+}
 
-	for i := length - 1; i > 0; i-- {
-		randBytes := make([]byte, 4)
-		rand.Read(randBytes)
-		n := int(randBytes[0])<<24 | int(randBytes[1])<<16 | int(randBytes[2])<<8 | int(randBytes[3])
-		if n < 0 {
-			n = -n
+func uniqueCopy[S ~[]E, E comparable](s S) S {
+	seen := make(map[E]bool, len(s))
+	result := make(S, 0, len(s))
+	for _, item := range s {
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
 		}
-		j := n % (i + 1)
-		temp := result.Index(i).Interface()
-		result.Index(i).Set(result.Index(j))
-		result.Index(j).Set(reflect.ValueOf(temp))
 	}
+	return result
+}
 
-	return result.Interface()
+// makeComparableKey returns a map key identifying item: item itself when
+// it is comparable, the value it points to for pointers, and its formatted
+// text for slices, maps and structs that cannot be compared.
+func makeComparableKey(item any) any {
+	v := reflect.ValueOf(item)
+
+	switch {
+	case !v.IsValid():
+		return nil
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			return "<nil>"
+		}
+		return makeComparableKey(v.Elem().Interface())
+	case v.Comparable():
+		return item
+	default:
+		return fmt.Sprintf("%v", item)
+	}
 }
 
 func sliceContains(slice any, item any) bool {
@@ -449,30 +668,68 @@ func sliceFlatten(slice any) any {
 	return result.Interface()
 }
 
-func sliceGroup(slice any, keyFunc func(any) any) any {
-	if slice == nil {
-		return make(map[any]any)
+// pluck returns the value at field, a path as in lookup, of each element of
+// a slice of structs or maps, skipping elements where it is missing or nil:
+//
+//	{{ join (pluck "Name" .Columns) ", " }}
+func pluck(field string, slice any) (any, error) {
+	steps, err := lookupSteps(field)
+	if err != nil {
+		return nil, err
 	}
 
-	v := reflect.ValueOf(slice)
+	v := indirectValue(reflect.ValueOf(slice))
+	if !v.IsValid() {
+		return []any{}, nil
+	}
 	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
-		return make(map[any]any)
+		return nil, fmt.Errorf("expected a list, got %T", slice)
 	}
 
-	groups := make(map[any][]any)
-
+	values := make([]any, 0, v.Len())
 	for i := 0; i < v.Len(); i++ {
-		item := v.Index(i).Interface()
-		key := keyFunc(item)
-		groups[key] = append(groups[key], item)
+		if value := lookupValue(v.Index(i), steps); value.IsValid() {
+			values = append(values, value.Interface())
+		}
 	}
+	return typedSlice(values), nil
+}
 
-	result := make(map[any]any)
-	for k, v := range groups {
-		result[k] = v
+// groupByField groups the elements of a slice of structs or maps by the
+// formatted value at field, a path as in lookup. Each group keeps the
+// elements' type and order, and elements where the field is missing or nil
+// are grouped under "". Ranging over the result visits the groups in key
+// order:
+//
+//	{{ range $category, $items := groupByField "Category" .Items }}
+func groupByField(field string, slice any) (map[string]any, error) {
+	steps, err := lookupSteps(field)
+	if err != nil {
+		return nil, err
 	}
 
-	return result
+	v := indirectValue(reflect.ValueOf(slice))
+	if !v.IsValid() {
+		return map[string]any{}, nil
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, got %T", slice)
+	}
+
+	groups := make(map[string][]int)
+	for i := 0; i < v.Len(); i++ {
+		var key string
+		if value := lookupValue(v.Index(i), steps); value.IsValid() {
+			key = toString(value.Interface())
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	result := make(map[string]any, len(groups))
+	for key, indexes := range groups {
+		result[key] = permute(v, indexes)
+	}
+	return result, nil
 }
 
 func sliceReduce(slice any, initialValue any, reducer func(any, any) any) any {
@@ -519,6 +776,8 @@ func slicePartition(slice any, predicate func(any) bool) any {
 	return [2]any{truthy, falsy}
 }
 
+// sliceChunk splits a slice or array into slices of at most size
+// elements, sharing the input's backing array rather than copying it.
 func sliceChunk(slice any, size int) any {
 	if slice == nil || size <= 0 {
 		return []any{}
@@ -533,11 +792,17 @@ func sliceChunk(slice any, size int) any {
 	if length == 0 {
 		return []any{}
 	}
+	if v.Kind() == reflect.Array {
+		// Arrays passed by value cannot be sliced.
+		copied := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), length, length)
+		reflect.Copy(copied, v)
+		v = copied
+	}
 
-	var chunks []any
+	chunks := make([]any, 0, (length+size-1)/size)
 	for i := 0; i < length; i += size {
 		end := min(i+size, length)
-		chunks = append(chunks, v.Slice(i, end).Interface())
+		chunks = append(chunks, v.Slice3(i, end, end).Interface())
 	}
 
 	return chunks
@@ -655,3 +920,106 @@ func sliceIntersection(slice1, slice2 any) any {
 
 	return result.Interface()
 }
+
+// dict builds a map from alternating keys and values, so templates can pass
+// several values to a partial: {{ template "field" (dict "name" .Name "required" true) }}.
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict requires key/value pairs, got an odd number of arguments (%d)", len(pairs))
+	}
+	result := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings, got %T", pairs[i])
+		}
+		result[key] = pairs[i+1]
+	}
+	return result, nil
+}
+
+func list(items ...any) []any {
+	return append([]any{}, items...)
+}
+
+// toStringSlice returns the elements of a slice or array as strings.
+func toStringSlice(list any) ([]string, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, got %T", list)
+	}
+	items := make([]string, v.Len())
+	for i := range items {
+		items[i] = toString(v.Index(i).Interface())
+	}
+	return items, nil
+}
+
+// setKey sets key in m and returns m, so it can be used in a pipeline.
+func setKey(m map[string]any, key string, value any) map[string]any {
+	m[key] = value
+	return m
+}
+
+// mergeMaps merges the keys of each source into dst and returns dst. Keys
+// already in dst are kept, except that nested maps are merged in turn, so
+// earlier maps take precedence.
+func mergeMaps(dst map[string]any, sources ...map[string]any) map[string]any {
+	for _, src := range sources {
+		for key, value := range src {
+			existing, ok := dst[key]
+			if !ok {
+				dst[key] = value
+				continue
+			}
+			dstMap, dstIsMap := existing.(map[string]any)
+			srcMap, srcIsMap := value.(map[string]any)
+			if dstIsMap && srcIsMap {
+				dst[key] = mergeMaps(dstMap, srcMap)
+			}
+		}
+	}
+	return dst
+}
+
+// deepCopy returns a copy of value in which maps and slices are copied
+// recursively, so the copy can be changed with set or merge without
+// affecting the original.
+func deepCopy(value any) any {
+	if value == nil {
+		return nil
+	}
+	return copyValue(reflect.ValueOf(value)).Interface()
+}
+
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(copyValue(v.Elem()))
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return copied
+	}
+	return v
+}
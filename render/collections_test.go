@@ -0,0 +1,38 @@
+package render
+
+import "testing"
+
+func TestCollectionFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"dict", `{{ define "opt" }}{{ .name }}={{ .retries }}{{ end }}{{ template "opt" (dict "name" .Name "retries" 3) }}`, map[string]any{"Name": "api"}, "api=3"},
+		{"list", `{{ range list "a" 1 true }}{{ . }},{{ end }}{{ len (list) }}`, nil, "a,1,true,0"},
+		{"set", `{{ $d := dict "a" 1 }}{{ $_ := set $d "b" 2 }}{{ $d.a }}{{ $d.b }}{{ (set $d "a" 3).a }}`, nil, "123"},
+		{"merge", `{{ $m := merge (dict "a" 1 "n" (dict "x" 1)) (dict "a" 2 "b" 2 "n" (dict "x" 2 "y" 2)) }}{{ $m.a }}{{ $m.b }}{{ $m.n.x }}{{ $m.n.y }}`, nil, "1212"},
+		{"deepCopy", `{{ $c := deepCopy .D }}{{ $_ := set $c.n "x" 9 }}{{ .D.n.x }}{{ $c.n.x }}{{ index (deepCopy .L) 1 }}`, map[string]any{"D": map[string]any{"n": map[string]any{"x": 1}}, "L": []string{"a", "b"}}, "19b"},
+		{"sort", `{{ sort .S }} {{ sort .N }} {{ sort .Mixed }} {{ sort .A }} {{ .S }}`, map[string]any{"S": []string{"b", "c", "a"}, "N": []any{10, 9.5, 2, uint8(3)}, "Mixed": []any{"b", 10, "a"}, "A": [3]int{3, 1, 2}}, "[a b c] [2 3 9.5 10] [10 a b] [1 2 3] [b c a]"},
+		{"pluck", `{{ join (pluck "Name" .Cols) "," }} {{ pluck "type.size" .Maps }} {{ pluck "x" nil }}`, map[string]any{
+			"Cols": []struct{ Name string }{{"id"}, {"email"}},
+			"Maps": []map[string]any{{"type": map[string]any{"size": 4}}, {"type": nil}, {"type": map[string]any{"size": 8}}},
+		}, "id,email [4 8] []"},
+		{"groupByField", `{{ range $k, $items := groupByField "Kind" .Items }}{{ $k }}:{{ range $items }}{{ .Name }}{{ end }};{{ end }}`, map[string]any{"Items": []map[string]any{{"Kind": "b", "Name": "x"}, {"Kind": "a", "Name": "y"}, {"Name": "z"}, {"Kind": "b", "Name": "w"}}}, ":z;a:y;b:xw;"},
+		{"sortDesc", `{{ sortDesc .S }} {{ sortDesc .N }}`, map[string]any{"S": []string{"b", "c", "a"}, "N": []any{2, nil, 10, 1.5}}, "[c b a] [10 2 1.5 <nil>]"},
+		{"sortBy", `{{ range sortBy "Name" .Users }}{{ .Name }}{{ .Age }} {{ end }}| {{ range sortByDesc "Age" .Users }}{{ .Name }} {{ end }}| {{ range sortBy "owner.id" .Repos }}{{ .name }} {{ end }}`, map[string]any{
+			"Users": []struct {
+				Name string
+				Age  int
+			}{{"bo", 40}, {"al", 30}, {"cy", 30}, {"al", 25}},
+			"Repos": []map[string]any{{"name": "x", "owner": map[string]any{"id": 10}}, {"name": "y"}, {"name": "z", "owner": map[string]any{"id": 9}}},
+		}, "al30 al25 bo40 cy30 | bo al cy al | z x y "},
+		{"sortWith", `{{ sortWith .ByLen .S }}`, map[string]any{"S": []string{"ccc", "a", "bb", "d"}, "ByLen": func(a, b any) bool { return len(a.(string)) < len(b.(string)) }}, "[a d bb ccc]"},
+		{"unique", `{{ unique .S }} {{ unique .Points }} {{ unique .Lists }}`, map[string]any{"S": []string{"a", "b", "a"}, "Points": []struct{ X, Y int }{{1, 2}, {1, 2}, {2, 1}}, "Lists": []any{[]int{1}, []int{1}, []int{2}}}, "[a b] [{1 2} {2 1}] [[1] [2]]"},
+		{"map empty", `{{ len (map (list) .F) }}`, map[string]any{"F": func(v any) any { return v }}, "0"},
+		{"chunk", `{{ range chunk .Items 2 }}{{ join . "," }};{{ end }} {{ chunk .A 2 }}`, map[string]any{"Items": []string{"a", "b", "c"}, "A": [3]int{1, 2, 3}}, "a,b;c; [[1 2] [3]]"},
+	}
+	errorTests := []funcErrorTest{
+		{"pluck list", `{{ pluck "Name" "x" }}`, "expected a list, got string"},
+		{"sortBy path", `{{ sortBy "a..b" (list 1) }}`, `invalid lookup path "a..b": empty key`},
+		{"dict", `{{ dict "a" }}`, "dict requires key/value pairs, got an odd number of arguments (1)"},
+		{"dict keys", `{{ dict 1 2 }}`, "dict keys must be strings, got int"},
+	}
+	runFuncTests(t, tests, errorTests)
+}
@@ -0,0 +1,149 @@
+package render
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// fromCSV parses a CSV document whose first row is the header into one map
+// per row, from column name to cell. Rows shorter than the header get empty
+// cells; blank lines are skipped.
+func fromCSV(document string) ([]map[string]any, error) {
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(document, "\ufeff")))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("failed to parse CSV: no header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("failed to parse CSV: column %d has no name", i+1)
+		}
+		if slices.Contains(columns[:i], name) {
+			return nil, fmt.Errorf("failed to parse CSV: duplicate column %q", name)
+		}
+		columns[i] = name
+	}
+
+	rows := []map[string]any{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		if len(record) > len(columns) {
+			line, _ := r.FieldPos(0)
+			return nil, fmt.Errorf("failed to parse CSV: row %d has %d fields, header has %d", line, len(record), len(columns))
+		}
+		row := make(map[string]any, len(columns))
+		for i, name := range columns {
+			row[name] = ""
+			if i < len(record) {
+				row[name] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// toCSV renders rows as CSV. Rows that are lists are written as they are,
+// after a header row of columns if any are given. Rows that are maps or
+// structs are written as the given columns, or otherwise every map key in
+// sorted order or every exported struct field in declaration order, with a
+// header row naming them.
+func toCSV(rows any, columns ...string) (string, error) {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("toCSV requires a list of rows, got %T", rows)
+	}
+
+	records := make([][]string, 0, v.Len()+1)
+	if len(columns) == 0 {
+		columns = csvColumns(v)
+	}
+	if len(columns) > 0 {
+		records = append(records, columns)
+	}
+
+	for i := range v.Len() {
+		row := reflect.Indirect(reflect.ValueOf(v.Index(i).Interface()))
+		switch row.Kind() {
+		case reflect.Slice, reflect.Array:
+			record := make([]string, row.Len())
+			for j := range record {
+				record[j] = toString(row.Index(j).Interface())
+			}
+			records = append(records, record)
+		case reflect.Map, reflect.Struct:
+			record := make([]string, len(columns))
+			for j, column := range columns {
+				if cell := csvCell(row, column); cell.IsValid() {
+					record[j] = toString(cell.Interface())
+				}
+			}
+			records = append(records, record)
+		default:
+			return "", fmt.Errorf("toCSV: row %d is a %s, not a list, map or struct", i+1, row.Kind())
+		}
+	}
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if err := w.WriteAll(records); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return b.String(), nil
+}
+
+// csvColumns returns the default columns for rows: the sorted keys of all
+// map rows, or the exported fields of the first struct row.
+func csvColumns(rows reflect.Value) []string {
+	var columns []string
+	for i := range rows.Len() {
+		row := reflect.Indirect(reflect.ValueOf(rows.Index(i).Interface()))
+		switch row.Kind() {
+		case reflect.Map:
+			for _, key := range row.MapKeys() {
+				if name := fmt.Sprint(key.Interface()); !slices.Contains(columns, name) {
+					columns = append(columns, name)
+				}
+			}
+		case reflect.Struct:
+			for _, field := range reflect.VisibleFields(row.Type()) {
+				if field.IsExported() && !field.Anonymous {
+					columns = append(columns, field.Name)
+				}
+			}
+			return columns
+		}
+	}
+	slices.Sort(columns)
+	return columns
+}
+
+func csvCell(row reflect.Value, column string) reflect.Value {
+	if row.Kind() == reflect.Map {
+		if row.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}
+		}
+		return row.MapIndex(reflect.ValueOf(column).Convert(row.Type().Key()))
+	}
+	if field, ok := row.Type().FieldByName(column); !ok || !field.IsExported() {
+		return reflect.Value{}
+	}
+	return row.FieldByName(column)
+}
@@ -0,0 +1,19 @@
+package render
+
+import "testing"
+
+func TestCSVFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"fromCSV", `{{ range fromCSV .Seed }}{{ .name }}={{ .role }};{{ end }}`, map[string]any{"Seed": "name, role\nAda,admin\n\n\"Lovelace, A\"\nBob,\"dev\"\n"}, "Ada=admin;Lovelace, A=;Bob=dev;"},
+		{"toCSV", `{{ toCSV .Maps }}{{ toCSV .Maps "name" }}{{ toCSV .Structs }}{{ toCSV (list (list "a" "b,c") (list 1 2)) }}`, map[string]any{
+			"Maps":    []map[string]any{{"name": "Ada", "id": 1}, {"name": "Bob \"B\"", "id": 2}},
+			"Structs": []struct{ ID, Email string }{{"1", "ada@example.com"}},
+		}, "id,name\n1,Ada\n2,\"Bob \"\"B\"\"\"\nname\nAda\n\"Bob \"\"B\"\"\"\nID,Email\n1,ada@example.com\na,\"b,c\"\n1,2\n"},
+	}
+	errorTests := []funcErrorTest{
+		{"fromCSV header", `{{ fromCSV "a,a\n1,2" }}`, `failed to parse CSV: duplicate column "a"`},
+		{"fromCSV row", `{{ fromCSV "a,b\n1,2,3" }}`, "failed to parse CSV: row 2 has 3 fields, header has 2"},
+		{"toCSV rows", `{{ toCSV (list 1 2) }}`, "toCSV: row 1 is a int, not a list, map or struct"},
+	}
+	runFuncTests(t, tests, errorTests)
+}
@@ -0,0 +1,160 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayouts names layouts commonly needed in generated files. The date
+// functions accept these names, in any case, wherever they take a layout.
+var dateLayouts = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"rfc1123":     time.RFC1123,
+	"date":        time.DateOnly,
+	"datetime":    time.DateTime,
+	"time":        time.TimeOnly,
+	"migration":   "20060102150405",
+}
+
+func dateLayout(layout string) string {
+	if named, ok := dateLayouts[strings.ToLower(layout)]; ok {
+		return named
+	}
+	return layout
+}
+
+// toTime converts a template value to a time: a time.Time or pointer to
+// one, Unix seconds as an integer, or an RFC 3339 string.
+func toTime(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, fmt.Errorf("cannot convert nil *time.Time to a time")
+		}
+		return *v, nil
+	case int, int32, int64, uint, uint32, uint64, float64:
+		seconds, err := unixSeconds(v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse time %q: %w", v, err)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %T to a time", value)
+}
+
+func unixSeconds(value any) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, fmt.Errorf("cannot convert %T to Unix seconds", value)
+}
+
+// dateFormat formats t with layout, which is a Go reference layout or one
+// of the names in dateLayouts. The time comes last so it can be piped:
+// {{ now | dateFormat "date" }}.
+func dateFormat(layout string, t any) (string, error) {
+	tm, err := toTime(t)
+	if err != nil {
+		return "", err
+	}
+	return tm.Format(dateLayout(layout)), nil
+}
+
+// dateParse parses value with layout, which may be one of the names in
+// dateLayouts.
+func dateParse(layout, value string) (time.Time, error) {
+	t, err := time.Parse(dateLayout(layout), value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse date %q: %w", value, err)
+	}
+	return t, nil
+}
+
+var calendarDuration = regexp.MustCompile(`^([+-]?\d+)(d|w|mo|y)$`)
+
+// dateAdd adds duration to t. Besides Go durations such as "36h" or
+// "-90m", it accepts a whole number of days, weeks, months or years, such
+// as "30d", "2w", "-6mo" or "1y", which follow the calendar rather than
+// counting hours.
+func dateAdd(duration string, t any) (time.Time, error) {
+	tm, err := toTime(t)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if m := calendarDuration.FindStringSubmatch(strings.TrimSpace(duration)); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid duration %q: %w", duration, err)
+		}
+		switch m[2] {
+		case "d":
+			return tm.AddDate(0, 0, n), nil
+		case "w":
+			return tm.AddDate(0, 0, 7*n), nil
+		case "mo":
+			return tm.AddDate(0, n, 0), nil
+		default:
+			return tm.AddDate(n, 0, 0), nil
+		}
+	}
+
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid duration %q: %w", duration, err)
+	}
+	return tm.Add(d), nil
+}
+
+func dateInUTC(t any) (time.Time, error) {
+	tm, err := toTime(t)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return tm.UTC(), nil
+}
+
+// unixToTime converts Unix seconds to a time in UTC, unlike fromUnix, which
+// uses the local time zone.
+func unixToTime(seconds any) (time.Time, error) {
+	s, err := unixSeconds(seconds)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(s, 0).UTC(), nil
+}
+
+// fixedNow returns a function that always reports the time it was created,
+// so every file rendered with one function map shares a timestamp.
+// DeterministicFuncMap replaces it with the pinned time.
+func fixedNow() func() time.Time {
+	now := time.Now()
+	return func() time.Time { return now }
+}
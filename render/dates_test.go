@@ -0,0 +1,23 @@
+package render
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"dateFormat", `{{ dateFormat "2006-01-02 15:04" .T }} {{ .T | dateFormat "migration" }} {{ dateFormat "DATE" 86400 }} {{ dateFormat "rfc3339" "2024-03-01T10:00:00+02:00" }}`, map[string]any{"T": time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)}, "2024-03-01 09:30 20240301093000 1970-01-02 2024-03-01T10:00:00+02:00"},
+		{"dateParse", `{{ (dateParse "date" "2024-03-01").Weekday }} {{ (dateParse "02/01/2006" "15/04/2024").Month }}`, nil, "Friday April"},
+		{"dateAdd", `{{ dateAdd "30d" .T | dateFormat "date" }} {{ .T | dateAdd "1mo" | dateFormat "date" }} {{ .T | dateAdd "-1y" | dateFormat "date" }} {{ .T | dateAdd "2w" | dateFormat "date" }} {{ .T | dateAdd "-90m" | dateFormat "time" }}`, map[string]any{"T": time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)}, "2024-03-01 2024-03-02 2023-01-31 2024-02-14 10:30:00"},
+		{"dateInUTC", `{{ dateInUTC .T | dateFormat "datetime" }}`, map[string]any{"T": time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))}, "2024-03-01 11:00:00"},
+		{"unixToTime", `{{ unixToTime 1700000000 | dateFormat "rfc3339" }} {{ unixToTime .S | dateFormat "date" }}`, map[string]any{"S": int64(0)}, "2023-11-14T22:13:20Z 1970-01-01"},
+		{"fixedNow", `{{ eq (fixedNow).UnixNano (fixedNow).UnixNano }}`, nil, "true"},
+	}
+	errorTests := []funcErrorTest{
+		{"dateAdd duration", `{{ dateAdd "3 days" 0 }}`, `invalid duration "3 days"`},
+		{"dateFormat type", `{{ dateFormat "date" true }}`, "cannot convert bool to a time"},
+		{"dateParse value", `{{ dateParse "date" "01/03/2024" }}`, `failed to parse date "01/03/2024"`},
+	}
+	runFuncTests(t, tests, errorTests)
+}
@@ -0,0 +1,72 @@
+package render
+
+import (
+	"hash/fnv"
+	"maps"
+	"math/rand/v2"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// DeterministicFuncMap returns a copy of base in which every function whose
+// output changes between runs is replaced by a reproducible equivalent: "now"
+// and "fixedNow" return the pinned time, and the functions of RandomFuncMap
+// and FakeFuncMap draw from a pseudo-random source seeded with seed.
+// Functions absent from base are not added.
+//
+// The seeded source is shared by the returned functions, so two function maps
+// built with the same seed produce the same sequence of values.
+func DeterministicFuncMap(base template.FuncMap, seed uint64, now time.Time) template.FuncMap {
+	funcs := make(template.FuncMap, len(base))
+	maps.Copy(funcs, base)
+
+	src := newSeededSource(seed)
+	overrides := randomFuncs(src)
+	maps.Copy(overrides, fakeFuncs(src))
+	overrides["now"] = func() time.Time { return now }
+	overrides["fixedNow"] = func() time.Time { return now }
+
+	for name, fn := range overrides {
+		if _, exists := funcs[name]; exists {
+			funcs[name] = fn
+		}
+	}
+
+	return funcs
+}
+
+// SeedFor derives a per-template seed from a base seed so that each template
+// gets an independent, stable sequence regardless of rendering order.
+func SeedFor(seed uint64, name string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return seed ^ h.Sum64()
+}
+
+// seededSource is a goroutine-safe pseudo-random source for deterministic
+// template functions. It implements randomSource.
+type seededSource struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newSeededSource(seed uint64) *seededSource {
+	return &seededSource{rng: rand.New(rand.NewPCG(seed, seed>>1|1))}
+}
+
+func (s *seededSource) intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rng.IntN(n)
+}
+
+// Read implements io.Reader so the source can feed uuid generation.
+func (s *seededSource) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range p {
+		p[i] = byte(s.rng.Uint32())
+	}
+	return len(p), nil
+}
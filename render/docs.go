@@ -0,0 +1,261 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CatalogFunction describes a function in the catalog written by WriteDocs.
+type CatalogFunction struct {
+	Name        string      `json:"name" yaml:"name"`
+	Signature   string      `json:"signature" yaml:"signature"`
+	Category    string      `json:"category" yaml:"category"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+	Parameters  []ParamInfo `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	ReturnType  string      `json:"return_type,omitempty" yaml:"return_type,omitempty"`
+	Examples    []string    `json:"examples,omitempty" yaml:"examples,omitempty"`
+	Since       string      `json:"since,omitempty" yaml:"since,omitempty"`
+	Deprecated  bool        `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Replacement string      `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+	Aliases     []string    `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+}
+
+// CatalogCategory is a category of functions in the catalog.
+type CatalogCategory struct {
+	Name      string            `json:"name" yaml:"name"`
+	Page      string            `json:"page" yaml:"page"`
+	Functions []CatalogFunction `json:"functions" yaml:"functions"`
+}
+
+// Catalog returns the registered functions grouped by category, in the
+// order GetDocumentation uses.
+func (fr *FunctionRegistry) Catalog() []CatalogCategory {
+	fr.mu.RLock()
+	defer fr.mu.RUnlock()
+
+	categories := fr.listByCategory()
+	catalog := make([]CatalogCategory, 0, len(categories))
+	for _, category := range orderedCategories(categories) {
+		c := CatalogCategory{Name: category, Page: slug(category) + ".md"}
+		for _, name := range categories[category] {
+			meta := fr.metadata[name]
+			c.Functions = append(c.Functions, CatalogFunction{
+				Name:        name,
+				Signature:   functionSignature(meta),
+				Category:    category,
+				Description: meta.Description,
+				Parameters:  meta.Parameters,
+				ReturnType:  meta.ReturnType,
+				Examples:    meta.Examples,
+				Since:       meta.Since,
+				Deprecated:  meta.Deprecated,
+				Replacement: meta.Replacement,
+				Aliases:     meta.Aliases,
+			})
+		}
+		catalog = append(catalog, c)
+	}
+	return catalog
+}
+
+// WriteDocs writes a function reference to dir, creating it if needed:
+//
+//   - index.md, linking to one Markdown page per category, such as string.md
+//   - functions.html, the whole reference as a single page
+//   - functions.json and functions.yaml, the Catalog for other tools
+//
+// The output depends only on the registered metadata, so it can be
+// committed and published with a template pack.
+func (fr *FunctionRegistry) WriteDocs(dir string) error {
+	catalog := fr.Catalog()
+	metadata := fr.ExportJSON()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create docs directory: %w", err)
+	}
+
+	files := map[string][]byte{}
+
+	var index strings.Builder
+	index.WriteString("# Template Functions\n\n")
+	for _, category := range catalog {
+		index.WriteString(fmt.Sprintf("- [%s](%s) (%d)\n", strings.Title(category.Name), category.Page, len(category.Functions)))
+
+		var page strings.Builder
+		page.WriteString(fmt.Sprintf("# %s Functions\n\n", strings.Title(category.Name)))
+		for _, function := range category.Functions {
+			writeFunctionDoc(&page, "##", metadata[function.Name])
+		}
+		files[category.Page] = []byte(page.String())
+	}
+	files["index.md"] = []byte(index.String())
+
+	var html strings.Builder
+	if err := docsPage.Execute(&html, catalog); err != nil {
+		return fmt.Errorf("failed to render HTML docs: %w", err)
+	}
+	files["functions.html"] = []byte(html.String())
+
+	catalogJSON, err := json.MarshalIndent(map[string]any{"categories": catalog}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode function catalog: %w", err)
+	}
+	files["functions.json"] = append(catalogJSON, '\n')
+
+	catalogYAML, err := yaml.Marshal(map[string]any{"categories": catalog})
+	if err != nil {
+		return fmt.Errorf("failed to encode function catalog: %w", err)
+	}
+	files["functions.yaml"] = catalogYAML
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// CompletionItem is an entry of the schema returned by
+// ExportCompletionSchema.
+type CompletionItem struct {
+	Label string `json:"label"`
+	// Snippet inserts a call with placeholders for the required
+	// parameters, in the TextMate snippet syntax VS Code uses, such as
+	// "snake ${1:input}".
+	Snippet       string `json:"snippet"`
+	Signature     string `json:"signature"`
+	Documentation string `json:"documentation"`
+	Category      string `json:"category"`
+	Deprecated    bool   `json:"deprecated,omitempty"`
+}
+
+// ExportCompletionSchema returns JSON describing the registered functions
+// for editor extensions that offer autocomplete in templates: one
+// CompletionItem per name a template can call, including aliases, in a
+// document of the form {"version": 1, "functions": [...]}.
+func (fr *FunctionRegistry) ExportCompletionSchema() ([]byte, error) {
+	var items []CompletionItem
+	for _, category := range fr.Catalog() {
+		for _, function := range category.Functions {
+			var doc strings.Builder
+			if function.Description != "" {
+				doc.WriteString(function.Description + "\n\n")
+			}
+			if function.Deprecated {
+				doc.WriteString("Deprecated")
+				if function.Replacement != "" {
+					doc.WriteString(fmt.Sprintf(": use `%s` instead", function.Replacement))
+				}
+				doc.WriteString(".\n\n")
+			}
+			for _, example := range function.Examples {
+				doc.WriteString(fmt.Sprintf("```\n%s\n```\n", example))
+			}
+
+			item := CompletionItem{
+				Label:         function.Name,
+				Snippet:       completionSnippet(function),
+				Signature:     function.Signature,
+				Documentation: strings.TrimSpace(doc.String()),
+				Category:      function.Category,
+				Deprecated:    function.Deprecated,
+			}
+			items = append(items, item)
+			for _, alias := range function.Aliases {
+				item.Label = alias
+				item.Snippet = completionSnippet(CatalogFunction{Name: alias, Parameters: function.Parameters})
+				items = append(items, item)
+			}
+		}
+	}
+
+	schema, err := json.MarshalIndent(map[string]any{"version": 1, "functions": items}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode completion schema: %w", err)
+	}
+	return append(schema, '\n'), nil
+}
+
+// completionSnippet returns a call of function with a placeholder for each
+// required parameter. Namespaced functions are called through call.
+func completionSnippet(function CatalogFunction) string {
+	call := function.Name
+	if strings.Contains(call, ".") {
+		call = "call " + call
+	}
+	n := 0
+	for _, param := range function.Parameters {
+		if !param.Required {
+			continue
+		}
+		n++
+		call += fmt.Sprintf(" ${%d:%s}", n, strings.NewReplacer("$", "\\$", "}", "\\}").Replace(param.Name))
+	}
+	return call
+}
+
+var docsPage = htmltemplate.Must(htmltemplate.New("functions.html").Funcs(htmltemplate.FuncMap{
+	"title": strings.Title,
+	"slug":  slug,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Template Functions</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; }
+code, pre { font-family: ui-monospace, monospace; background: #f4f4f4; }
+pre { padding: .5rem; overflow-x: auto; }
+.deprecated { color: #a40000; }
+</style>
+</head>
+<body>
+<h1>Template Functions</h1>
+<nav><ul>
+{{- range . }}
+<li><a href="#{{ slug .Name }}">{{ title .Name }}</a> ({{ len .Functions }})</li>
+{{- end }}
+</ul></nav>
+{{- range . }}
+<section id="{{ slug .Name }}">
+<h2>{{ title .Name }} Functions</h2>
+{{- range .Functions }}
+<article id="fn-{{ .Name }}">
+<h3>{{ .Name }}</h3>
+<p><code>{{ .Signature }}</code></p>
+{{- if .Description }}
+<p>{{ .Description }}</p>
+{{- end }}
+{{- if .Deprecated }}
+<p class="deprecated">Deprecated{{ if .Replacement }}: use <code>{{ .Replacement }}</code> instead{{ end }}.</p>
+{{- end }}
+{{- if .Aliases }}
+<p>Aliases: {{ range $i, $a := .Aliases }}{{ if $i }}, {{ end }}<code>{{ $a }}</code>{{ end }}</p>
+{{- end }}
+{{- if .Parameters }}
+<ul>
+{{- range .Parameters }}
+<li><code>{{ .Name }}</code> ({{ .Type }}){{ if .Required }} required{{ end }}{{ if .Description }}: {{ .Description }}{{ end }}</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- range .Examples }}
+<pre>{{ . }}</pre>
+{{- end }}
+{{- if .Since }}
+<p>Since {{ .Since }}</p>
+{{- end }}
+</article>
+{{- end }}
+</section>
+{{- end }}
+</body>
+</html>
+`))
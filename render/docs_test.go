@@ -0,0 +1,83 @@
+package render
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFunctionRegistryWriteDocs(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registry.RegisterDefaults()
+	registry.Register("toSnake", strings.ToLower, WithCategory("string"), WithReplacement("snake"))
+
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := registry.WriteDocs(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+	if index := read("index.md"); !strings.Contains(index, "- [String](string.md)") || !strings.Contains(index, "- [Template](template.md) (2)") {
+		t.Errorf("Unexpected index:\n%s", index)
+	}
+	if page := read("string.md"); !strings.HasPrefix(page, "# String Functions\n\n## camel\n") || !strings.Contains(page, "**⚠️ Deprecated:** use `snake` instead") {
+		t.Errorf("Unexpected string page:\n%s", page)
+	}
+	if html := read("functions.html"); !strings.Contains(html, `<article id="fn-snake">`) || !strings.Contains(html, "<code>snake(input string) -&gt; string</code>") {
+		t.Errorf("Unexpected HTML reference:\n%s", html)
+	}
+
+	var catalog struct{ Categories []CatalogCategory }
+	if err := json.Unmarshal([]byte(read("functions.json")), &catalog); err != nil {
+		t.Fatal(err)
+	}
+	if catalog.Categories[0].Name != "string" || catalog.Categories[0].Functions[0].Name != "camel" {
+		t.Errorf("Unexpected catalog order: %+v", catalog.Categories[0])
+	}
+	if yamlCatalog := read("functions.yaml"); !strings.Contains(yamlCatalog, "- name: snake\n          signature: snake(input string) -> string\n") {
+		t.Errorf("Unexpected YAML catalog:\n%s", yamlCatalog)
+	}
+}
+
+func TestFunctionRegistryCompletionSchema(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registry.RegisterDefaults()
+	registry.Register("acme.header", func(year int, owner ...string) string { return "" },
+		WithUnqualifiedAlias(),
+		WithDescription("License header"),
+		WithParameters(ParamInfo{Name: "year", Type: "int", Required: true}, ParamInfo{Name: "owner", Type: "string"}))
+
+	data, err := registry.ExportCompletionSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Version   int
+		Functions []CompletionItem
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	items := make(map[string]CompletionItem)
+	for _, item := range schema.Functions {
+		items[item.Label] = item
+	}
+
+	if schema.Version != 1 || items["snake"].Snippet != "snake ${1:input}" || items["snake"].Signature != "snake(input string) -> string" {
+		t.Errorf("Unexpected snake item: %+v", items["snake"])
+	}
+	if !strings.Contains(items["snake"].Documentation, "```\n{{ \"HelloWorld\" | snake }} // hello_world\n```") {
+		t.Errorf("Expected examples in the documentation, got %q", items["snake"].Documentation)
+	}
+	if items["acme.header"].Snippet != "call acme.header ${1:year}" || items["header"].Snippet != "header ${1:year}" || items["header"].Documentation != "License header" {
+		t.Errorf("Unexpected namespaced items: %+v, %+v", items["acme.header"], items["header"])
+	}
+}
@@ -0,0 +1,92 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+var (
+	fakeFirstNames = []string{
+		"Ada", "Alan", "Amara", "Ben", "Chen", "Diego", "Elena", "Farah", "Grace", "Hiro",
+		"Ines", "Jonas", "Kofi", "Lena", "Mateo", "Nadia", "Omar", "Priya", "Quinn", "Rosa",
+		"Sam", "Tariq", "Uma", "Victor", "Wen", "Yara", "Zoe",
+	}
+	fakeLastNames = []string{
+		"Adams", "Bauer", "Costa", "Dubois", "Evans", "Fischer", "Garcia", "Hansen", "Ito",
+		"Jensen", "Kim", "Lopez", "Müller", "Nakamura", "Okafor", "Patel", "Rossi", "Silva",
+		"Tanaka", "Novak", "Walker", "Young",
+	}
+	fakeStreets = []string{
+		"Maple Street", "Oak Avenue", "Cedar Lane", "Elm Road", "Harbor Way", "Hillside Drive",
+		"Lake Street", "Mill Road", "Park Avenue", "River Lane", "Station Road", "Willow Court",
+	}
+	fakeCities = []string{
+		"Springfield", "Riverton", "Fairview", "Lakewood", "Greenville", "Franklin",
+		"Clinton", "Georgetown", "Salem", "Madison", "Oakdale", "Ashford",
+	}
+	fakeStates = []string{"CA", "CO", "IL", "MA", "MN", "NY", "OR", "TX", "VA", "WA"}
+	// fakeDomains are reserved for documentation by RFC 2606, so generated
+	// addresses never reach a real mailbox.
+	fakeDomains = []string{"example.com", "example.org", "example.net"}
+)
+
+// FakeFuncMap returns functions that generate plausible fixture data for
+// generated tests and documentation: "fakeName", "fakeEmail",
+// "fakeAddress" and "fakeUUIDSeq". With a seed they produce the same
+// values on every run; DeterministicFuncMap seeds them as well. They are
+// part of ExtendedFuncMap.
+func FakeFuncMap(seed ...uint64) template.FuncMap {
+	if len(seed) > 0 {
+		return fakeFuncs(newSeededSource(seed[0]))
+	}
+	return fakeFuncs(cryptoSource{})
+}
+
+func fakeFuncs(src randomSource) template.FuncMap {
+	f := faker{randomizer{src}}
+	return template.FuncMap{
+		"fakeName":    f.name,
+		"fakeEmail":   f.email,
+		"fakeAddress": f.address,
+		"fakeUUIDSeq": fakeUUIDSeq,
+	}
+}
+
+type faker struct {
+	randomizer
+}
+
+func (f faker) pick(values []string) string {
+	return values[f.src.intn(len(values))]
+}
+
+func (f faker) name() string {
+	return f.pick(fakeFirstNames) + " " + f.pick(fakeLastNames)
+}
+
+// email returns an address at a reserved example domain, for a given name
+// such as one from fakeName, or for a new one.
+func (f faker) email(name ...string) string {
+	person := f.name()
+	if len(name) > 0 && strings.TrimSpace(name[0]) != "" {
+		person = name[0]
+	}
+	local := strings.Join(strings.Fields(strings.ToLower(transliterate(person))), ".")
+	return local + "@" + f.pick(fakeDomains)
+}
+
+func (f faker) address() string {
+	return fmt.Sprintf("%d %s, %s, %s %05d",
+		1+f.src.intn(9999), f.pick(fakeStreets), f.pick(fakeCities), f.pick(fakeStates), 10000+f.src.intn(89999))
+}
+
+// fakeUUIDSeq returns the nth UUID of a readable sequence, such as
+// 00000000-0000-4000-8000-000000000001 for 1, so fixtures can refer to the
+// same record by number in several files.
+func fakeUUIDSeq(n int) (string, error) {
+	if n < 0 || n >= 1e12 {
+		return "", fmt.Errorf("fakeUUIDSeq requires a number from 0 to 999999999999, got %d", n)
+	}
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", n), nil
+}
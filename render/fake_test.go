@@ -0,0 +1,52 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFakeFuncMap(t *testing.T) {
+	execute := func(funcs template.FuncMap) string {
+		t.Helper()
+		tmpl := template.Must(template.New("t").Funcs(DefaultFuncMap()).Funcs(funcs).Parse(
+			`{{ $name := fakeName }}{{ $name }}|{{ fakeEmail $name }}|{{ fakeEmail "José Müller" }}|{{ fakeAddress }}|{{ fakeUUIDSeq 1 }}|{{ fakeUUIDSeq 42 }}`))
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	first := execute(FakeFuncMap(1))
+	if second := execute(FakeFuncMap(1)); first != second {
+		t.Errorf("Expected seeded fake data to repeat:\n%s\n%s", first, second)
+	}
+
+	parts := strings.Split(first, "|")
+	name := strings.Fields(strings.ToLower(parts[0]))
+	if len(name) != 2 || !strings.HasPrefix(parts[1], name[0]+".") || !strings.Contains(parts[1], "@example.") {
+		t.Errorf("Expected email for %q, got %q", parts[0], parts[1])
+	}
+	if !strings.HasPrefix(parts[2], "jose.muller@example.") {
+		t.Errorf("Expected transliterated email, got %q", parts[2])
+	}
+	if !regexp.MustCompile(`^\d+ [A-Za-z ]+, [A-Za-z]+, [A-Z]{2} \d{5}$`).MatchString(parts[3]) {
+		t.Errorf("Unexpected address %q", parts[3])
+	}
+	if parts[4] != "00000000-0000-4000-8000-000000000001" || parts[5] != "00000000-0000-4000-8000-000000000042" {
+		t.Errorf("Unexpected UUID sequence %q, %q", parts[4], parts[5])
+	}
+	if _, err := uuid.Parse(parts[5]); err != nil {
+		t.Errorf("fakeUUIDSeq 42 is not a UUID: %v", err)
+	}
+
+	pinned := DeterministicFuncMap(ExtendedFuncMap(), 1, time.Unix(0, 0))
+	if execute(pinned) != execute(DeterministicFuncMap(ExtendedFuncMap(), 1, time.Unix(0, 0))) {
+		t.Error("Expected deterministic mode to seed fake data")
+	}
+}
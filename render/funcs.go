@@ -1,17 +1,23 @@
 package render
 
 import (
+	"encoding/json"
 	"fmt"
 	"maps"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
 	"text/template"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func DefaultFuncMap() template.FuncMap {
 	funcs := template.FuncMap{
+		"extends":    extends,
+		"include":    include,
 		"snake":      toSnakeCase,
 		"camel":      toCamelCase,
 		"pascal":     toPascalCase,
@@ -32,6 +38,14 @@ func DefaultFuncMap() template.FuncMap {
 		"hasSuffix":  strings.HasSuffix,
 		"repeat":     strings.Repeat,
 
+		"slug":           slug,
+		"sanitizeIdent":  sanitizeIdent,
+		"sanitizeIdents": sanitizeIdents,
+
+		"levenshtein":  levenshtein,
+		"similarity":   similarity,
+		"closestMatch": closestMatch,
+
 		"formatSlice": formatSlice,
 		"filter":      filterSlice,
 		"map":         mapSlice,
@@ -40,19 +54,45 @@ func DefaultFuncMap() template.FuncMap {
 		"rest":        getRest,
 		"reverse":     reverseSlice,
 		"sort":        sortSlice,
+		"sortDesc":    sortDesc,
+		"sortBy":      sortBy,
+		"sortByDesc":  sortByDesc,
+		"sortWith":    sortWith,
 		"unique":      uniqueSlice,
+		"chunk":       sliceChunk,
 		"len":         getLength,
 		"isEmpty":     isEmpty,
 		"isNotEmpty":  isNotEmpty,
-
-		"plural":    pluralize,
-		"singular":  singularize,
-		"humanize":  humanize,
-		"indent":    indentLines,
-		"quote":     quote,
-		"squote":    singleQuote,
-		"comment":   comment,
-		"goComment": goComment,
+		"dict":        dict,
+		"list":        list,
+		"set":         setKey,
+		"merge":       mergeMaps,
+		"deepCopy":    deepCopy,
+		"keys":        mapKeys,
+		"values":      mapValues,
+		"hasKey":      hasKey,
+		"pick":        pick,
+		"omit":        omit,
+		"mergeMaps":   mergeMapsCopy,
+		"toPairs":     toPairs,
+		"fromPairs":   fromPairs,
+		"lookup":      lookup,
+
+		"pluck":        pluck,
+		"groupByField": groupByField,
+		"filterBy":     filterBy,
+		"partitionBy":  partitionBy,
+		"where":        where,
+
+		"plural":      pluralize,
+		"singular":    singularize,
+		"humanize":    humanize,
+		"indent":      indentLines,
+		"quote":       quote,
+		"squote":      singleQuote,
+		"comment":     comment,
+		"goComment":   goComment,
+		"wrapComment": wrapComment,
 
 		"add":      add,
 		"subtract": subtract,
@@ -62,6 +102,15 @@ func DefaultFuncMap() template.FuncMap {
 		"max":      maximum,
 		"min":      minimum,
 		"abs":      absolute,
+		"sub":      subtract,
+		"mul":      multiply,
+		"div":      divide,
+		"ceil":     ceiling,
+		"floor":    floor,
+		"round":    round,
+
+		"formatNumber":  formatNumber,
+		"humanizeBytes": humanizeBytes,
 
 		"now":         time.Now,
 		"formatTime":  formatTime,
@@ -70,19 +119,40 @@ func DefaultFuncMap() template.FuncMap {
 		"fromUnix":    fromUnixTime,
 		"addDuration": addDuration,
 		"subDuration": subtractDuration,
-
-		"default":  defaultValue,
-		"coalesce": coalesce,
-		"ternary":  ternary,
-		"isNil":    isNil,
-		"isNotNil": isNotNil,
-		"toString": toString,
-		"toInt":    toInt,
-		"toBool":   toBool,
-		"typeOf":   typeOf,
-		"kindOf":   kindOf,
+		"fixedNow":    fixedNow(),
+		"dateFormat":  dateFormat,
+		"dateParse":   dateParse,
+		"dateAdd":     dateAdd,
+		"dateInUTC":   dateInUTC,
+		"unixToTime":  unixToTime,
+
+		"default":   defaultValue,
+		"coalesce":  coalesce,
+		"ternary":   ternary,
+		"empty":     empty,
+		"isNil":     isNil,
+		"isNotNil":  isNotNil,
+		"toString":  toString,
+		"toInt":     toInt,
+		"toBool":    toBool,
+		"typeOf":    typeOf,
+		"kindOf":    kindOf,
+		"fromJSON":  fromJSON,
+		"fromYAML":  fromYAML,
+		"fromCSV":   fromCSV,
+		"toCSV":     toCSV,
+		"toXML":     toXML,
+		"xmlEscape": xmlEscape,
+		"mapType":   mapType,
+
+		// SQL
+		"sqlQuoteIdent":  sqlQuoteIdent,
+		"sqlQuoteString": sqlQuoteString,
+		"sqlPlaceholder": sqlPlaceholder,
 	}
 
+	maps.Copy(funcs, GoTypeFuncMap())
+
 	return funcs
 }
 
@@ -90,19 +160,21 @@ func ExtendedFuncMap() template.FuncMap {
 	funcs := DefaultFuncMap()
 
 	extended := template.FuncMap{
-		"uuid":       generateUUID,
-		"md5":        calculateMD5,
-		"sha1":       calculateSHA1,
-		"sha256":     calculateSHA256,
-		"base64":     encodeBase64,
-		"base64dec":  decodeBase64,
-		"urlQuery":   urlQueryEscape,
-		"urlPath":    urlPathEscape,
-		"jsonEscape": jsonEscape,
-		"yamlEscape": yamlEscape,
-		"htmlEscape": htmlEscape,
-		"cssEscape":  cssEscape,
-		"jsEscape":   jsEscape,
+		"md5":         calculateMD5,
+		"sha1":        calculateSHA1,
+		"sha256":      calculateSHA256,
+		"base64":      encodeBase64,
+		"base64dec":   decodeBase64,
+		"urlQuery":    urlQueryEscape,
+		"urlPath":     urlPathEscape,
+		"urlParse":    urlParse,
+		"urlBuild":    urlBuild,
+		"urlJoinPath": urlJoinPath,
+		"jsonEscape":  jsonEscape,
+		"yamlEscape":  yamlEscape,
+		"htmlEscape":  htmlEscape,
+		"cssEscape":   cssEscape,
+		"jsEscape":    jsEscape,
 
 		"env":       getEnvVar,
 		"expandEnv": expandEnvVars,
@@ -120,16 +192,14 @@ func ExtendedFuncMap() template.FuncMap {
 		"pathClean": pathClean,
 		"pathIsAbs": pathIsAbs,
 
-		"semver":        parseSemver,
-		"semverMajor":   semverMajor,
-		"semverMinor":   semverMinor,
-		"semverPatch":   semverPatch,
-		"semverCompare": semverCompare,
-
-		"genPassword": generatePassword,
-		"randInt":     randomInt,
-		"randString":  randomString,
-		"shuffle":     shuffleSlice,
+		"semver":          parseSemver,
+		"semverMajor":     semverMajor,
+		"semverMinor":     semverMinor,
+		"semverPatch":     semverPatch,
+		"semverCompare":   semverCompare,
+		"semverSatisfies": semverSatisfies,
+		"semverBump":      semverBump,
+		"semverSort":      semverSort,
 
 		"wrap":     wrapText,
 		"truncate": truncateString,
@@ -140,6 +210,8 @@ func ExtendedFuncMap() template.FuncMap {
 	}
 
 	maps.Copy(funcs, extended)
+	maps.Copy(funcs, RandomFuncMap())
+	maps.Copy(funcs, FakeFuncMap())
 
 	return funcs
 }
@@ -158,6 +230,8 @@ func defaultValue(def any, given any) any {
 	return given
 }
 
+// coalesce returns the first of values that is neither nil nor an empty
+// string, or nil. Zero and false are returned like any other value.
 func coalesce(values ...any) any {
 	for _, v := range values {
 		if v != nil {
@@ -178,6 +252,14 @@ func ternary(condition bool, trueVal, falseVal any) any {
 	return falseVal
 }
 
+// empty reports whether value is nil, false, zero, or an empty string,
+// slice or map: the values {{ if }} treats as false. Structs are never
+// empty.
+func empty(value any) bool {
+	truth, _ := template.IsTrue(value)
+	return !truth
+}
+
 func isNil(value any) bool {
 	return value == nil || reflect.ValueOf(value).IsNil()
 }
@@ -238,6 +320,31 @@ func kindOf(value any) string {
 	return reflect.ValueOf(value).Kind().String()
 }
 
+// fromJSON parses a JSON document into maps, slices and scalars, so
+// templates can use structured values carried in strings.
+func fromJSON(document string) (any, error) {
+	var value any
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return value, nil
+}
+
+// fromYAML parses a YAML document into maps, slices and scalars.
+func fromYAML(document string) (any, error) {
+	var value any
+	if err := yaml.Unmarshal([]byte(document), &value); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return value, nil
+}
+
+// extends marks a template as extending a layout; ParseTemplate resolves it
+// when the template is parsed, so at execution time it does nothing.
+func extends(layout string) string {
+	return ""
+}
+
 func getLength(value any) int {
 	if value == nil {
 		return 0
@@ -273,6 +380,9 @@ func multiply(a, b any) (any, error) {
 }
 
 func divide(a, b any) (any, error) {
+	if divisor, err := toFloat64(b); err == nil && divisor == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
 	return performMath(a, b, func(x, y float64) float64 { return x / y })
 }
 
@@ -285,6 +395,9 @@ func modulo(a, b any) (any, error) {
 	if err != nil {
 		return nil, err
 	}
+	if bInt == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
 	return aInt % bInt, nil
 }
 
@@ -297,22 +410,44 @@ func performMath(a, b any, op func(float64, float64) float64) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	result := op(aFloat, bFloat)
+	return number(op(aFloat, bFloat)), nil
+}
 
-	if result == float64(int64(result)) {
-		return int64(result), nil
+// number returns f as an int64 when it is a whole number, so results print
+// without a decimal point.
+func number(f float64) any {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+		return int64(f)
 	}
-	return result, nil
+	return f
 }
 
 func toFloat64(value any) (float64, error) {
 	switch v := value.(type) {
 	case float64:
 		return v, nil
+	case float32:
+		return float64(v), nil
 	case int:
 		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
 	case int64:
 		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
 	case string:
 		return strconv.ParseFloat(v, 64)
 	default:
@@ -320,7 +455,25 @@ func toFloat64(value any) (float64, error) {
 	}
 }
 
+// numbers flattens a single slice argument into its elements, so max and
+// min accept either several numbers or a list of them.
+func numbers(values []any) []any {
+	if len(values) != 1 {
+		return values
+	}
+	v := reflect.ValueOf(values[0])
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return values
+	}
+	flat := make([]any, v.Len())
+	for i := range flat {
+		flat[i] = v.Index(i).Interface()
+	}
+	return flat
+}
+
 func maximum(values ...any) (any, error) {
+	values = numbers(values)
 	if len(values) == 0 {
 		return nil, fmt.Errorf("max requires at least one argument")
 	}
@@ -346,6 +499,7 @@ func maximum(values ...any) (any, error) {
 }
 
 func minimum(values ...any) (any, error) {
+	values = numbers(values)
 	if len(values) == 0 {
 		return nil, fmt.Errorf("min requires at least one argument")
 	}
@@ -375,14 +529,94 @@ func absolute(value any) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	if f < 0 {
-		f = -f
+	return number(math.Abs(f)), nil
+}
+
+func ceiling(value any) (any, error) {
+	f, err := toFloat64(value)
+	if err != nil {
+		return nil, err
+	}
+	return number(math.Ceil(f)), nil
+}
+
+func floor(value any) (any, error) {
+	f, err := toFloat64(value)
+	if err != nil {
+		return nil, err
 	}
+	return number(math.Floor(f)), nil
+}
 
-	if f == float64(int64(f)) {
-		return int64(f), nil
+// round rounds value half away from zero to the given number of decimal
+// places, or to a whole number without them.
+func round(value any, places ...int) (any, error) {
+	f, err := toFloat64(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(places) == 0 || places[0] <= 0 {
+		return number(math.Round(f)), nil
+	}
+	scale := math.Pow(10, float64(places[0]))
+	return number(math.Round(f*scale) / scale), nil
+}
+
+// formatNumber formats value with commas between thousands. With decimals,
+// it is rounded to that many places; otherwise it keeps the digits it has.
+// Infinities and NaN are returned as "+Inf", "-Inf" and "NaN".
+func formatNumber(value any, decimals ...int) (string, error) {
+	f, err := toFloat64(value)
+	if err != nil {
+		return "", err
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	precision := -1
+	if len(decimals) > 0 {
+		precision = decimals[0]
+	}
+	formatted := strconv.FormatFloat(f, 'f', precision, 64)
+
+	sign := ""
+	if strings.HasPrefix(formatted, "-") {
+		sign, formatted = "-", formatted[1:]
+	}
+	whole, fraction, hasFraction := strings.Cut(formatted, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	if hasFraction {
+		b.WriteString("." + fraction)
+	}
+	return b.String(), nil
+}
+
+// humanizeBytes formats a size in bytes with binary units, such as
+// "512 B", "1.5 KiB" or "20 MiB".
+func humanizeBytes(value any) (string, error) {
+	size, err := toFloat64(value)
+	if err != nil {
+		return "", err
+	}
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	unit := 0
+	for math.Abs(size) >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", int64(size), units[0]), nil
 	}
-	return f, nil
+	formatted := strings.TrimSuffix(strconv.FormatFloat(size, 'f', 1, 64), ".0")
+	return formatted + " " + units[unit], nil
 }
 
 func formatTime(t time.Time, layout string) string {
@@ -0,0 +1,83 @@
+package render
+
+import (
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"fromJSON", `{{ $o := fromJSON .Raw }}{{ $o.name }} {{ index $o.tags 1 }} {{ $o.retries }}`, map[string]any{"Raw": `{"name":"api","tags":["a","b"],"retries":3}`}, "api b 3"},
+		{"fromYAML", `{{ range (fromYAML .Raw).servers }}{{ .host }}:{{ .port }};{{ end }}`, map[string]any{"Raw": "servers:\n  - host: a\n    port: 80\n  - host: b\n    port: 443\n"}, "a:80;b:443;"},
+		{"ternary", `{{ ternary true "a" "b" }}{{ ternary .Done "c" "d" }}{{ ternary (empty .Missing) "e" "f" }}`, map[string]any{"Done": false}, "ade"},
+		{"coalesce", `{{ coalesce .Missing "" .Name "z" }}`, map[string]any{"Name": "x"}, "x"},
+		{"coalesce zero and false", `{{ coalesce .Retries 3 }} {{ coalesce .Enabled true }} {{ coalesce "" 0 false }}`, map[string]any{"Retries": 0, "Enabled": false}, "0 false 0"},
+		{"coalesce none", `{{ coalesce "" nil }}`, nil, "<no value>"},
+		{"arithmetic", `{{ sub 10 3 }} {{ mul 4 2.5 }} {{ div 7 2 }} {{ mod 7 3 }} {{ add .N 1 }} {{ sub 1.5 0.25 }}`, map[string]any{"N": int32(41)}, "7 10 3.5 1 42 1.25"},
+		{"max and min", `{{ max 3 7 5 }} {{ min 3 7 5 }} {{ max .Sizes }} {{ min .Sizes }}`, map[string]any{"Sizes": []int{4, 9, 2}}, "7 3 9 2"},
+		{"rounding", `{{ ceil 2.1 }} {{ floor 2.9 }} {{ floor -2.1 }} {{ round 2.5 }} {{ round -2.5 }} {{ round 3.14159 2 }} {{ ceil (div 11 5) }}`, nil, "3 2 -3 3 -3 3.14 3"},
+		{"formatNumber", `{{ formatNumber 1234567 }} {{ formatNumber 999 }} {{ formatNumber -1234.5 }} {{ formatNumber 1234.5 2 }} {{ formatNumber 1000000 0 }}`, nil, "1,234,567 999 -1,234.5 1,234.50 1,000,000"},
+		{"formatNumber non-finite", `{{ formatNumber .Inf }} {{ formatNumber .NegInf 2 }} {{ formatNumber .NaN }}`, map[string]any{"Inf": math.Inf(1), "NegInf": math.Inf(-1), "NaN": math.NaN()}, "+Inf -Inf NaN"},
+		{"humanizeBytes", `{{ humanizeBytes 512 }} {{ humanizeBytes 1536 }} {{ humanizeBytes 1048576 }} {{ humanizeBytes .Size }}`, map[string]any{"Size": int64(5 << 30)}, "512 B 1.5 KiB 1 MiB 5 GiB"},
+		{"empty", `{{ empty "" }} {{ empty 0 }} {{ empty (list) }} {{ empty .Missing }} {{ empty "a" }} {{ empty 1 }} {{ empty (dict "a" 1) }}`, map[string]any{}, "true true true true false false false"},
+	}
+	errorTests := []funcErrorTest{
+		{"fromJSON", `{{ fromJSON "{" }}`, "failed to parse JSON"},
+		{"fromYAML", `{{ fromYAML "a: [" }}`, "failed to parse YAML"},
+		{"div zero", `{{ div 1 0 }}`, "division by zero"},
+		{"mod zero", `{{ mod 1 0 }}`, "division by zero"},
+	}
+	runFuncTests(t, tests, errorTests)
+}
+
+// funcTest executes template with ExtendedFuncMap on data and expects want.
+type funcTest struct {
+	name     string
+	template string
+	data     any
+	want     string
+}
+
+// funcErrorTest executes template with ExtendedFuncMap and expects an error
+// containing want.
+type funcErrorTest struct {
+	name     string
+	template string
+	want     string
+}
+
+func runFuncTests(t *testing.T, tests []funcTest, errorTests []funcErrorTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := execTemplate(t, tt.template, tt.data); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name+" error", func(t *testing.T) {
+			tmpl := template.Must(template.New(tt.name).Funcs(ExtendedFuncMap()).Parse(tt.template))
+			err := tmpl.Execute(io.Discard, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func execTemplate(t *testing.T, text string, data any) string {
+	t.Helper()
+	tmpl, err := template.New("test").Funcs(ExtendedFuncMap()).Parse(text)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,208 @@
+package render
+
+import (
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// GoTypeFuncMap returns the functions for generating Go code that works
+// with type names as strings, such as "int64", "*time.Time" or "[]User".
+// They are part of DefaultFuncMap.
+func GoTypeFuncMap() template.FuncMap {
+	return template.FuncMap{
+		"zeroValue":     zeroValue,
+		"isPointer":     isPointer,
+		"goName":        GoName,
+		"receiverName":  receiverName,
+		"isBuiltinType": isBuiltinType,
+		"importForType": importForType,
+	}
+}
+
+// builtinTypes are Go's predeclared types.
+var builtinTypes = map[string]bool{
+	"bool": true, "string": true, "error": true, "any": true, "comparable": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+	"byte": true, "rune": true,
+}
+
+// namedZeroValues are the zero values of well-known named types that are
+// not structs.
+var namedZeroValues = map[string]string{
+	"time.Duration":   "0",
+	"time.Month":      "0",
+	"time.Weekday":    "0",
+	"os.FileMode":     "0",
+	"fs.FileMode":     "0",
+	"json.RawMessage": "nil",
+	"json.Number":     `""`,
+	"http.Header":     "nil",
+	"url.Values":      "nil",
+	"unsafe.Pointer":  "nil",
+}
+
+// typeImports maps the package names of well-known qualified types to their
+// import paths.
+var typeImports = map[string]string{
+	"bytes":    "bytes",
+	"context":  "context",
+	"big":      "math/big",
+	"decimal":  "github.com/shopspring/decimal",
+	"driver":   "database/sql/driver",
+	"fs":       "io/fs",
+	"http":     "net/http",
+	"io":       "io",
+	"json":     "encoding/json",
+	"netip":    "net/netip",
+	"net":      "net",
+	"os":       "os",
+	"regexp":   "regexp",
+	"sql":      "database/sql",
+	"strings":  "strings",
+	"sync":     "sync",
+	"template": "text/template",
+	"time":     "time",
+	"unsafe":   "unsafe",
+	"url":      "net/url",
+	"uuid":     "github.com/google/uuid",
+}
+
+// zeroValue returns the Go expression for the zero value of typ: 0, "",
+// false, nil for pointers, slices, maps, channels, functions and
+// interfaces, and a composite literal such as User{} for arrays and other
+// named types, which are assumed to be structs.
+func zeroValue(typ string) string {
+	typ = strings.TrimSpace(typ)
+	if zero, ok := namedZeroValues[typ]; ok {
+		return zero
+	}
+	switch {
+	case typ == "":
+		return ""
+	case strings.HasPrefix(typ, "*"), strings.HasPrefix(typ, "[]"), strings.HasPrefix(typ, "map["),
+		strings.HasPrefix(typ, "chan"), strings.HasPrefix(typ, "<-chan"), strings.HasPrefix(typ, "func"),
+		strings.HasPrefix(typ, "interface"), typ == "any", typ == "error":
+		return "nil"
+	case typ == "string":
+		return `""`
+	case typ == "bool":
+		return "false"
+	case builtinTypes[typ]:
+		return "0"
+	}
+	return typ + "{}"
+}
+
+func isPointer(typ string) bool {
+	return strings.HasPrefix(strings.TrimSpace(typ), "*")
+}
+
+// isBuiltinType reports whether typ is one of Go's predeclared types.
+func isBuiltinType(typ string) bool {
+	return builtinTypes[strings.TrimSpace(typ)]
+}
+
+// importForType returns the import path of the first qualified type in typ,
+// such as "time" for "*time.Time" or "github.com/google/uuid" for
+// "[]uuid.UUID". It returns "" for types that need no import and for
+// packages it does not know.
+func importForType(typ string) string {
+	for i, r := range typ {
+		if r != '.' {
+			continue
+		}
+		start := i
+		for start > 0 && isIdentRune(rune(typ[start-1])) {
+			start--
+		}
+		if pkg := typ[start:i]; pkg != "" {
+			return typeImports[pkg]
+		}
+	}
+	return ""
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// goInitialisms are written in upper case in Go names.
+var goInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DB": true,
+	"DNS": true, "EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "JWT": true, "OS": true, "RAM": true,
+	"RPC": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true,
+	"UDP": true, "UI": true, "URI": true, "URL": true, "UTF8": true, "UUID": true,
+	"XML": true, "YAML": true,
+}
+
+// IsGoInitialism reports whether word, in upper case, is an initialism Go
+// names write in upper case, such as "ID" or "URL".
+func IsGoInitialism(word string) bool {
+	return goInitialisms[word]
+}
+
+// GoName converts a name in any casing to an exported Go identifier with
+// Go's initialisms, such as "user_id" to "UserID" or "api-url" to "APIURL".
+// It is the goName template function.
+func GoName(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])):
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	var b strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); goInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		} else if singular := strings.TrimSuffix(upper, "S"); len(word) > 2 && word[len(word)-1] == 's' && goInitialisms[singular] {
+			b.WriteString(singular + "s")
+			continue
+		}
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	result := b.String()
+	if result != "" && unicode.IsDigit([]rune(result)[0]) {
+		result = "X" + result
+	}
+	return result
+}
+
+// receiverName returns the conventional receiver name for methods on typ:
+// the lower-cased first letter of the type's name, so "*UserService" gives
+// "u".
+func receiverName(typ string) string {
+	typ = strings.TrimLeft(strings.TrimSpace(typ), "*")
+	if i := strings.LastIndex(typ, "."); i >= 0 {
+		typ = typ[i+1:]
+	}
+	for _, r := range typ {
+		if unicode.IsLetter(r) {
+			return string(unicode.ToLower(r))
+		}
+	}
+	return "x"
+}
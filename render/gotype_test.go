@@ -0,0 +1,15 @@
+package render
+
+import "testing"
+
+func TestGoTypeFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"zeroValue", `{{ range .Types }}{{ zeroValue . }} {{ end }}`, map[string]any{"Types": []string{"int64", "string", "bool", "float32", "*User", "[]int", "map[string]any", "error", "chan int", "func()", "[4]byte", "User", "time.Time", "time.Duration"}}, `0 "" false 0 nil nil nil nil nil nil [4]byte{} User{} time.Time{} 0 `},
+		{"isPointer", `{{ isPointer "*User" }} {{ isPointer "User" }} {{ isPointer "[]*User" }}`, nil, "true false false"},
+		{"goName", `{{ range .Names }}{{ goName . }} {{ end }}`, map[string]any{"Names": []string{"user_id", "api-url", "HTTPServer", "userIds", "created at", "2fa_code", "utf8"}}, "UserID APIURL HTTPServer UserIDs CreatedAt X2faCode UTF8 "},
+		{"receiverName", `{{ receiverName "*UserService" }}{{ receiverName "http.Client" }}{{ receiverName "Node[T]" }}`, nil, "ucn"},
+		{"isBuiltinType", `{{ isBuiltinType "int64" }} {{ isBuiltinType "error" }} {{ isBuiltinType "User" }} {{ isBuiltinType "[]int" }}`, nil, "true true false false"},
+		{"importForType", `{{ importForType "*time.Time" }},{{ importForType "[]uuid.UUID" }},{{ importForType "map[string]json.RawMessage" }},{{ importForType "int" }},{{ importForType "foo.Bar" }}`, nil, "time,github.com/google/uuid,encoding/json,,"},
+	}
+	runFuncTests(t, tests, nil)
+}
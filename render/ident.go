@@ -0,0 +1,177 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// transliterations spell common accented and special Latin letters in ASCII.
+var transliterations = func() map[rune]string {
+	table := make(map[rune]string)
+	for ascii, letters := range map[string]string{
+		"a": "àáâãäåāăą", "A": "ÀÁÂÃÄÅĀĂĄ", "ae": "æ", "AE": "Æ",
+		"c": "çćĉċč", "C": "ÇĆĈĊČ", "d": "ďđð", "D": "ĎĐÐ",
+		"e": "èéêëēĕėęě", "E": "ÈÉÊËĒĔĖĘĚ", "g": "ĝğġģ", "G": "ĜĞĠĢ",
+		"h": "ĥħ", "H": "ĤĦ", "i": "ìíîïĩīĭįı", "I": "ÌÍÎÏĨĪĬĮİ",
+		"j": "ĵ", "J": "Ĵ", "k": "ķ", "K": "Ķ", "l": "ĺļľŀł", "L": "ĹĻĽĿŁ",
+		"n": "ñńņň", "N": "ÑŃŅŇ", "o": "òóôõöøōŏő", "O": "ÒÓÔÕÖØŌŎŐ",
+		"oe": "œ", "OE": "Œ", "r": "ŕŗř", "R": "ŔŖŘ", "s": "śŝşš", "S": "ŚŜŞŠ",
+		"ss": "ß", "t": "ţťŧ", "T": "ŢŤŦ", "th": "þ", "TH": "Þ",
+		"u": "ùúûüũūŭůűų", "U": "ÙÚÛÜŨŪŬŮŰŲ", "w": "ŵ", "W": "Ŵ",
+		"y": "ýÿŷ", "Y": "ÝŸŶ", "z": "źżž", "Z": "ŹŻŽ",
+	} {
+		for _, r := range letters {
+			table[r] = ascii
+		}
+	}
+	return table
+}()
+
+// transliterate replaces the letters in transliterations with their ASCII
+// spelling and leaves other characters as they are.
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if ascii, ok := transliterations[r]; ok {
+			b.WriteString(ascii)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// slug returns s as a lower-case, URL-safe slug: accented letters are
+// transliterated, other runs of characters that are not ASCII letters or
+// digits become a single hyphen, and leading and trailing hyphens are
+// dropped, so "Crème Brûlée!" becomes "creme-brulee".
+func slug(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(transliterate(s)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
+
+// identKeywords are the reserved words of the languages sanitizeIdent
+// supports, which cannot be used as identifiers.
+var identKeywords = map[string]map[string]bool{
+	"go": wordSet(`break case chan const continue default defer else fallthrough for func go
+		goto if import interface map package range return select struct switch type var`),
+	"python": wordSet(`False None True and as assert async await break class continue def del
+		elif else except finally for from global if import in is lambda nonlocal not or pass
+		raise return try while with yield`),
+	"typescript": wordSet(`break case catch class const continue debugger default delete do else
+		enum export extends false finally for function if import in instanceof new null return
+		super switch this throw true try typeof var void while with as implements interface let
+		package private protected public static yield any boolean number string symbol type
+		await`),
+	"java": wordSet(`abstract assert boolean break byte case catch char class const continue
+		default do double else enum extends final finally float for goto if implements import
+		instanceof int interface long native new package private protected public return short
+		static strictfp super switch synchronized this throw throws transient try void volatile
+		while true false null var record yield`),
+	"rust": wordSet(`as async await break const continue crate dyn else enum extern false fn
+		for if impl in let loop match mod move mut pub ref return self Self static struct super
+		trait true type unsafe use where while abstract become box do final macro override priv
+		typeof unsized virtual yield try`),
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// identLanguage normalises a language name for sanitizeIdent.
+func identLanguage(lang string) (string, error) {
+	switch l := strings.ToLower(strings.TrimSpace(lang)); l {
+	case "go", "python", "java", "rust":
+		return l, nil
+	case "golang":
+		return "go", nil
+	case "py":
+		return "python", nil
+	case "typescript", "ts", "javascript", "js":
+		return "typescript", nil
+	}
+	return "", fmt.Errorf("unknown identifier language %q", lang)
+}
+
+// sanitizeIdent turns s into a valid identifier in lang ("go", "python",
+// "typescript" or "javascript", "java" or "rust") without otherwise changing
+// its casing: accented letters are transliterated, runs of other characters
+// that cannot appear in an identifier become an underscore, a leading digit
+// is prefixed with an underscore and reserved words get a trailing one, so
+// "2nd Address" becomes "_2nd_Address" and "type" becomes "type_" in Go.
+func sanitizeIdent(lang, s string) (string, error) {
+	l, err := identLanguage(lang)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	underscore := false
+	for _, r := range transliterate(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$' && l == "typescript") {
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			underscore = false
+			continue
+		}
+		underscore = true
+	}
+
+	ident := b.String()
+	switch {
+	case ident == "":
+		return "_", nil
+	case unicode.IsDigit(rune(ident[0])):
+		ident = "_" + ident
+	case identKeywords[l][ident]:
+		ident += "_"
+	}
+	return ident, nil
+}
+
+// sanitizeIdents sanitizes each element of list with sanitizeIdent and
+// reports an error if two different elements become the same identifier,
+// such as "user-id" and "user id", which would otherwise produce code that
+// does not compile.
+func sanitizeIdents(lang string, list any) ([]string, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("sanitizeIdents requires a list, got %T", list)
+	}
+
+	idents := make([]string, v.Len())
+	sources := make(map[string]string, v.Len())
+	for i := range idents {
+		s := toString(v.Index(i).Interface())
+		ident, err := sanitizeIdent(lang, s)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := sources[ident]; ok && prev != s {
+			return nil, fmt.Errorf("identifiers collide: %q and %q both become %s", prev, s, ident)
+		}
+		sources[ident] = s
+		idents[i] = ident
+	}
+	return idents, nil
+}
@@ -0,0 +1,16 @@
+package render
+
+import "testing"
+
+func TestIdentFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"slug", `{{ slug "Crème Brûlée!" }} {{ slug "  Hello,  World  " }} {{ slug "Straße_2024" }} {{ slug "日本 Guide" }}`, nil, "creme-brulee hello-world strasse-2024 guide"},
+		{"sanitizeIdent", `{{ sanitizeIdent "go" "2nd Address" }} {{ sanitizeIdent "go" "type" }} {{ sanitizeIdent "python" "class" }} {{ sanitizeIdent "js" "$el-name" }} {{ sanitizeIdent "rust" "Größe (kg)" }} {{ sanitizeIdent "java" "!!!" }}`, nil, "_2nd_Address type_ class_ $el_name Grosse_kg _"},
+		{"sanitizeIdents", `{{ sanitizeIdents "go" .Names }}`, map[string]any{"Names": []string{"first name", "last-name", "type", "first name"}}, "[first_name last_name type_ first_name]"},
+	}
+	errorTests := []funcErrorTest{
+		{"sanitizeIdent lang", `{{ sanitizeIdent "cobol" "x" }}`, `unknown identifier language "cobol"`},
+		{"sanitizeIdents collision", `{{ sanitizeIdents "go" (list "user id" "user-id") }}`, `identifiers collide: "user id" and "user-id" both become user_id`},
+	}
+	runFuncTests(t, tests, errorTests)
+}
@@ -0,0 +1,233 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Inflector pluralizes and singularizes English words with ordered rules,
+// irregular forms and uncountable words. Rules added later take precedence
+// over earlier ones, so custom rules override the defaults.
+//
+// Only the last word of an identifier is inflected and its casing is kept:
+// "UserStatus" becomes "UserStatuses" and "ITEM" becomes "ITEMS".
+type Inflector struct {
+	mu          sync.RWMutex
+	plurals     []inflection
+	singulars   []inflection
+	irregular   map[string]string // singular to plural
+	regular     map[string]string // plural to singular
+	uncountable map[string]bool
+}
+
+type inflection struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// DefaultInflector backs the plural and singular template functions. Add
+// rules to it during initialisation, or create an Inflector and use its
+// FuncMap to configure a single engine.
+var DefaultInflector = NewInflector()
+
+// NewInflector returns an Inflector with the default English rules.
+func NewInflector() *Inflector {
+	in := &Inflector{
+		irregular:   make(map[string]string),
+		regular:     make(map[string]string),
+		uncountable: make(map[string]bool),
+	}
+
+	for _, rule := range [][2]string{
+		{`$`, `s`},
+		{`(s|x|z|ch|sh)$`, `${1}es`},
+		{`([^aeiouy]|qu)y$`, `${1}ies`},
+		{`([lr]|ea|oa)f$`, `${1}ves`},
+		{`([^f])fe$`, `${1}ves`},
+		{`(buffal|tomat|potat|her|ech|vet)o$`, `${1}oes`},
+		{`^(analy|ba|diagno|parenthe|progno|synop|the|cri|ax)sis$`, `${1}ses`},
+		{`(matr|vert|ind)(ix|ex)$`, `${1}ices`},
+		{`(octop|cact|fung|radi|stimul|syllab|alumn)us$`, `${1}i`},
+		{`(quiz)$`, `${1}zes`},
+	} {
+		in.mustAdd(&in.plurals, rule)
+	}
+
+	for _, rule := range [][2]string{
+		{`s$`, ``},
+		{`(ss)$`, `${1}`},
+		{`([^aeiouy]|qu)ies$`, `${1}y`},
+		{`(x|ch|ss|sh|zz)es$`, `${1}`},
+		{`(bus|alias|status|campus|virus)(es)?$`, `${1}`},
+		{`([lr]|ea|oa)ves$`, `${1}f`},
+		{`^(kni|wi|li)ves$`, `${1}fe`},
+		{`(buffal|tomat|potat|her|ech|vet)oes$`, `${1}o`},
+		{`^(analy|ba|diagno|parenthe|progno|synop|the|cri|ax)(sis|ses)$`, `${1}sis`},
+		{`(matr)ices$`, `${1}ix`},
+		{`(vert|ind)ices$`, `${1}ex`},
+		{`(octop|cact|fung|radi|stimul|syllab|alumn)(i|us)$`, `${1}us`},
+		{`(quiz)zes$`, `${1}`},
+	} {
+		in.mustAdd(&in.singulars, rule)
+	}
+
+	for singular, plural := range map[string]string{
+		"person":     "people",
+		"man":        "men",
+		"woman":      "women",
+		"child":      "children",
+		"tooth":      "teeth",
+		"foot":       "feet",
+		"mouse":      "mice",
+		"goose":      "geese",
+		"ox":         "oxen",
+		"datum":      "data",
+		"medium":     "media",
+		"criterion":  "criteria",
+		"phenomenon": "phenomena",
+		"movie":      "movies",
+		"cookie":     "cookies",
+		"shoe":       "shoes",
+	} {
+		in.AddIrregular(singular, plural)
+	}
+
+	in.AddUncountable("information", "series", "species", "equipment", "news", "metadata",
+		"feedback", "software", "hardware", "firmware", "middleware", "money", "rice",
+		"fish", "sheep", "deer", "moose", "traffic", "staff", "police")
+
+	return in
+}
+
+func (in *Inflector) mustAdd(rules *[]inflection, rule [2]string) {
+	*rules = append(*rules, inflection{regexp.MustCompile(rule[0]), rule[1]})
+}
+
+// AddPlural adds a rule that pluralizes lower-case words matching pattern,
+// replacing the match with replacement as regexp.ReplaceAllString does.
+func (in *Inflector) AddPlural(pattern, replacement string) error {
+	return in.add(&in.plurals, pattern, replacement)
+}
+
+// AddSingular adds a rule that singularizes lower-case words matching
+// pattern, replacing the match with replacement.
+func (in *Inflector) AddSingular(pattern, replacement string) error {
+	return in.add(&in.singulars, pattern, replacement)
+}
+
+func (in *Inflector) add(rules *[]inflection, pattern, replacement string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid inflection pattern %q: %w", pattern, err)
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	*rules = append(*rules, inflection{re, replacement})
+	return nil
+}
+
+// AddIrregular registers a word whose plural does not follow the rules.
+func (in *Inflector) AddIrregular(singular, plural string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	singular, plural = strings.ToLower(singular), strings.ToLower(plural)
+	in.irregular[singular] = plural
+	in.regular[plural] = singular
+}
+
+// AddUncountable registers words that are the same in singular and plural.
+func (in *Inflector) AddUncountable(words ...string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for _, word := range words {
+		in.uncountable[strings.ToLower(word)] = true
+	}
+}
+
+// Pluralize returns the plural of word.
+func (in *Inflector) Pluralize(word string) string {
+	return in.inflect(word, in.irregular, in.regular, in.plurals)
+}
+
+// Singularize returns the singular of word.
+func (in *Inflector) Singularize(word string) string {
+	return in.inflect(word, in.regular, in.irregular, in.singulars)
+}
+
+// FuncMap returns plural and singular template functions that use in.
+func (in *Inflector) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"plural":   in.Pluralize,
+		"singular": in.Singularize,
+	}
+}
+
+// inflect applies the rules to the last word of word. forms maps words to
+// their inflected form and done holds words that are already inflected.
+func (in *Inflector) inflect(word string, forms, done map[string]string, rules []inflection) string {
+	start := lastWordStart(word)
+	prefix, last := word[:start], word[start:]
+	if last == "" {
+		return word
+	}
+	lower := strings.ToLower(last)
+
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+
+	if in.uncountable[lower] {
+		return word
+	}
+	if form, ok := forms[lower]; ok {
+		return prefix + matchCase(form, last)
+	}
+	if _, ok := done[lower]; ok {
+		return word
+	}
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].pattern.MatchString(lower) {
+			return prefix + matchCase(rules[i].pattern.ReplaceAllString(lower, rules[i].replacement), last)
+		}
+	}
+	return word
+}
+
+// lastWordStart returns the index at which the last word of an identifier
+// starts: after the last separator, at the last lower-to-upper case change,
+// or at the last capital of a leading acronym ("HTTPServer").
+func lastWordStart(s string) int {
+	runes := []rune(s)
+	offset := len(s)
+	for i := len(runes) - 1; i > 0; i-- {
+		offset -= utf8.RuneLen(runes[i])
+		prev := runes[i-1]
+		if strings.ContainsRune("_- ./", prev) {
+			return offset
+		}
+		if unicode.IsUpper(runes[i]) && unicode.IsLower(prev) {
+			return offset
+		}
+		if unicode.IsUpper(runes[i]) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			return offset
+		}
+	}
+	return 0
+}
+
+// matchCase returns the lower-case word in the casing of original: all
+// upper case, capitalised, or lower case.
+func matchCase(word, original string) string {
+	switch {
+	case len(original) > 1 && strings.ToUpper(original) == original && strings.ToLower(original) != original:
+		return strings.ToUpper(word)
+	case unicode.IsUpper([]rune(original)[0]):
+		r, size := utf8.DecodeRuneInString(word)
+		return string(unicode.ToUpper(r)) + word[size:]
+	}
+	return word
+}
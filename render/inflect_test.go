@@ -0,0 +1,51 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestInflectFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"plural", `{{ range .Words }}{{ plural . }} {{ end }}`, map[string]any{"Words": []string{"Status", "user_status", "UserStatus", "ITEM", "Person", "category", "box", "leaf", "knife", "analysis", "Index", "information", "Series", "HTTPServer", "quiz", "hero"}}, "Statuses user_statuses UserStatuses ITEMS People categories boxes leaves knives analyses Indices information Series HTTPServers quizzes heroes "},
+		{"singular", `{{ range .Words }}{{ singular . }} {{ end }}`, map[string]any{"Words": []string{"Statuses", "Status", "UserStatuses", "ITEMS", "People", "categories", "boxes", "leaves", "knives", "analyses", "Indices", "cases", "databases", "addresses", "Movies", "Species", "class"}}, "Status Status UserStatus ITEM Person category box leaf knife analysis Index case database address Movie Species class "},
+	}
+	runFuncTests(t, tests, nil)
+}
+
+func TestInflectorCustomRules(t *testing.T) {
+	in := NewInflector()
+	in.AddIrregular("cactus", "cactuses")
+	in.AddUncountable("Kudos")
+	if err := in.AddPlural(`(schem)a$`, `${1}as`); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.AddSingular(`(schem)as$`, `${1}a`); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.AddPlural(`(`, ``); err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+
+	for word, want := range map[string]string{"Cactus": "Cactuses", "kudos": "kudos", "UserSchema": "UserSchemas", "octopus": "octopi"} {
+		if got := in.Pluralize(word); got != want {
+			t.Errorf("Pluralize(%q) = %q, want %q", word, got, want)
+		}
+	}
+	if got := in.Singularize("Schemas"); got != "Schema" {
+		t.Errorf("Singularize(Schemas) = %q, want Schema", got)
+	}
+	if got := DefaultInflector.Pluralize("cactus"); got != "cacti" {
+		t.Errorf("Expected custom rules not to change the default inflector, got %q", got)
+	}
+
+	tmpl := template.Must(template.New("t").Funcs(DefaultFuncMap()).Funcs(in.FuncMap()).Parse(`{{ plural "cactus" }}`))
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "cactuses" {
+		t.Errorf("FuncMap plural = %q, want cactuses", buf.String())
+	}
+}
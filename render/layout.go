@@ -0,0 +1,143 @@
+package render
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// parseLayered parses the template at name, resolving the layouts it
+// extends as described by ParseTemplate. chain holds the templates that
+// extend name, to detect cycles.
+func parseLayered(fsys fs.FS, name string, funcs template.FuncMap, chain []string) (*template.Template, error) {
+	if slices.Contains(chain, name) {
+		return nil, fmt.Errorf("template %s extends itself: %s", name, strings.Join(append(chain, name), " -> "))
+	}
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	layout, err := extendsTarget(tmpl.Tree)
+	if err != nil || layout == "" {
+		return tmpl, err
+	}
+	layoutPath, err := ResolveLayout(fsys, name, layout)
+	if err != nil {
+		return nil, err
+	}
+	base, err := parseLayered(fsys, layoutPath, funcs, append(chain, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse layout %s of %s: %w", layoutPath, name, err)
+	}
+
+	// The result is named after the child, executes the layout's body and
+	// holds the layout's blocks overridden by the child's defines.
+	// Base templates the child defines are left out rather than replaced, as
+	// AddParseTree will not replace a template with an empty one and an empty
+	// define is how a child removes a block.
+	result := template.New(name).Funcs(funcs)
+	for _, t := range base.Templates() {
+		if t.Tree == nil || t.Name() != base.Name() && tmpl.Lookup(t.Name()) != nil {
+			continue
+		}
+		treeName := t.Name()
+		if treeName == base.Name() {
+			treeName = name
+		}
+		if _, err := result.AddParseTree(treeName, t.Tree); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Name() == name {
+			continue
+		}
+		if _, err := result.AddParseTree(t.Name(), t.Tree); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// extendsTarget returns the layout named by a leading extends action in
+// tree, checking that the rest of the body is only whitespace.
+func extendsTarget(tree *parse.Tree) (string, error) {
+	if tree == nil || tree.Root == nil {
+		return "", nil
+	}
+	layout := ""
+	for _, node := range tree.Root.Nodes {
+		if text, ok := node.(*parse.TextNode); ok && len(strings.TrimSpace(string(text.Text))) == 0 {
+			continue
+		}
+		if name, ok := extendsAction(node); ok && layout == "" {
+			layout = name
+			continue
+		}
+		if layout == "" {
+			return "", checkNoExtends(tree)
+		}
+		location, _ := tree.ErrorContext(node)
+		return "", fmt.Errorf("%s: a template that extends a layout may only contain define blocks", location)
+	}
+	return layout, nil
+}
+
+// checkNoExtends reports an extends action that is not the first action of
+// the template, which would otherwise be silently ignored.
+func checkNoExtends(tree *parse.Tree) error {
+	for _, node := range tree.Root.Nodes {
+		if _, ok := extendsAction(node); ok {
+			location, _ := tree.ErrorContext(node)
+			return fmt.Errorf("%s: extends must be the first action in the template", location)
+		}
+	}
+	return nil
+}
+
+func extendsAction(node parse.Node) (string, bool) {
+	action, ok := node.(*parse.ActionNode)
+	if !ok || action.Pipe == nil || len(action.Pipe.Decl) > 0 || len(action.Pipe.Cmds) != 1 {
+		return "", false
+	}
+	args := action.Pipe.Cmds[0].Args
+	if len(args) != 2 {
+		return "", false
+	}
+	if ident, ok := args[0].(*parse.IdentifierNode); !ok || ident.Ident != "extends" {
+		return "", false
+	}
+	str, ok := args[1].(*parse.StringNode)
+	if !ok {
+		return "", false
+	}
+	return str.Text, true
+}
+
+// ResolveLayout returns the path in fsys of the layout that the template at
+// from names, as described by ParseTemplate.
+func ResolveLayout(fsys fs.FS, from, layout string) (string, error) {
+	if path.IsAbs(layout) || !fs.ValidPath(path.Clean(path.Join(path.Dir(from), layout))) {
+		return "", fmt.Errorf("invalid layout %q in %s", layout, from)
+	}
+	var candidates []string
+	for _, dir := range []string{path.Dir(from), "."} {
+		p := path.Join(dir, layout)
+		candidates = append(candidates, p, path.Join(path.Dir(p), "_"+path.Base(p)))
+	}
+	for _, candidate := range candidates {
+		if info, err := fs.Stat(fsys, candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("layout %q extended by %s not found", layout, from)
+}
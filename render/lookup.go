@@ -0,0 +1,134 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// lookup returns the value at path in data, or fallback (nil if none is
+// given) when any step of the path is missing or nil, so templates can read
+// deep optional values without nesting with and if:
+//
+//	{{ lookup "server.tls.port" .Config 443 }}
+//	{{ lookup "/paths/~1users/get" .Spec }}
+//
+// A path starting with "/" is a JSON Pointer (RFC 6901); any other path is a
+// list of keys separated by dots, in which an index can also be written in
+// brackets, as in "servers[0].host". Steps through maps use keys, steps
+// through structs use exported field names or json tag names, and steps
+// through lists use indexes. An empty path returns data itself.
+func lookup(path string, data any, fallback ...any) (any, error) {
+	if len(fallback) > 1 {
+		return nil, fmt.Errorf("lookup takes at most one default, got %d", len(fallback))
+	}
+	steps, err := lookupSteps(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := lookupValue(reflect.ValueOf(data), steps)
+	if !v.IsValid() {
+		if len(fallback) == 1 {
+			return fallback[0], nil
+		}
+		return nil, nil
+	}
+	return v.Interface(), nil
+}
+
+// lookupSteps splits path into the keys and indexes it names.
+func lookupSteps(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(path, "/") {
+		steps := strings.Split(path[1:], "/")
+		for i, step := range steps {
+			steps[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(step)
+		}
+		return steps, nil
+	}
+
+	var steps []string
+	for _, part := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key != "" {
+			steps = append(steps, key)
+		}
+		for rest != "" {
+			index, after, ok := strings.Cut(rest, "]")
+			if !ok || index == "" || (after != "" && after[0] != '[') {
+				return nil, fmt.Errorf("invalid lookup path %q", path)
+			}
+			steps = append(steps, index)
+			rest = strings.TrimPrefix(after, "[")
+		}
+		if key == "" && !strings.Contains(part, "[") {
+			return nil, fmt.Errorf("invalid lookup path %q: empty key", path)
+		}
+	}
+	return steps, nil
+}
+
+// lookupValue follows steps from v, returning the value found with
+// pointers and interfaces followed, or an invalid value if any step is
+// missing or nil.
+func lookupValue(v reflect.Value, steps []string) reflect.Value {
+	for _, step := range steps {
+		if v = lookupStep(v, step); !v.IsValid() {
+			return v
+		}
+	}
+	return indirectValue(v)
+}
+
+// lookupStep returns the element of v named by step, or an invalid value if
+// there is none.
+func lookupStep(v reflect.Value, step string) reflect.Value {
+	v = indirectValue(v)
+	if !v.IsValid() {
+		return v
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			return v.MapIndex(reflect.ValueOf(step).Convert(v.Type().Key()))
+		}
+		for _, key := range v.MapKeys() {
+			if fmt.Sprint(key.Interface()) == step {
+				return v.MapIndex(key)
+			}
+		}
+	case reflect.Struct:
+		for _, field := range reflect.VisibleFields(v.Type()) {
+			if !field.IsExported() || field.Anonymous {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if field.Name == step || name == step {
+				f, _ := v.FieldByIndexErr(field.Index)
+				return f
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if i, err := strconv.Atoi(step); err == nil && i >= 0 && i < v.Len() {
+			return v.Index(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// indirectValue follows pointers and interfaces, returning an invalid value
+// for nil.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
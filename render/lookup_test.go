@@ -0,0 +1,24 @@
+package render
+
+import "testing"
+
+func TestLookupFunc(t *testing.T) {
+	tests := []funcTest{
+		{"lookup", `{{ lookup "server.tls.port" .C 443 }} {{ lookup "server.port" .C 80 }} {{ lookup "servers[1].host" .C }} {{ lookup "servers.0.host" .C }} {{ lookup "servers[5].host" .C "none" }} {{ lookup "/paths/~1users/get" .C }} {{ lookup "x.y" nil "-" }}`, map[string]any{"C": map[string]any{"server": map[string]any{"port": 8080, "tls": nil}, "servers": []any{map[string]any{"host": "a"}, map[string]any{"host": "b"}}, "paths": map[string]any{"/users": map[string]any{"get": "listUsers"}}}}, "443 8080 b a none listUsers -"},
+		{"lookup struct", `{{ lookup "Owner.Name" . }} {{ lookup "owner.email" . "n/a" }} {{ lookup "Tags[0]" . }}`, struct {
+			Owner *struct {
+				Name  string
+				Email *string `json:"email"`
+			} `json:"owner"`
+			Tags []string
+		}{Owner: &struct {
+			Name  string
+			Email *string `json:"email"`
+		}{Name: "ada"}, Tags: []string{"x"}}, "ada n/a x"},
+	}
+	errorTests := []funcErrorTest{
+		{"lookup path", `{{ lookup "a[0" nil }}`, `invalid lookup path "a[0"`},
+		{"lookup default", `{{ lookup "a" nil 1 2 }}`, "lookup takes at most one default, got 2"},
+	}
+	runFuncTests(t, tests, errorTests)
+}
@@ -0,0 +1,94 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// macroDir is the name of the directories that hold macro files.
+const macroDir = "_macros"
+
+// addMacros adds to tmpl the templates defined by the macro files of name,
+// as described by ParseTemplate. Templates already defined by tmpl are kept.
+func addMacros(fsys fs.FS, name string, funcs template.FuncMap, tmpl *template.Template) error {
+	for dir := path.Dir(name); ; dir = path.Dir(dir) {
+		macros, err := parseMacroDir(fsys, path.Join(dir, macroDir), funcs)
+		if err != nil {
+			return err
+		}
+		for _, t := range macros {
+			if tmpl.Lookup(t.Name()) != nil {
+				continue
+			}
+			if _, err := tmpl.AddParseTree(t.Name(), t.Tree); err != nil {
+				return err
+			}
+		}
+		if dir == "." {
+			return nil
+		}
+	}
+}
+
+// parseMacroDir parses the macro files in dir and returns the templates they
+// define. A missing directory defines nothing.
+func parseMacroDir(fsys fs.FS, dir string, funcs template.FuncMap) ([]*template.Template, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read macros: %w", err)
+	}
+
+	var macros []*template.Template
+	definedIn := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmpl") && !strings.HasSuffix(entry.Name(), ".tpl") {
+			continue
+		}
+		file := path.Join(dir, entry.Name())
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		set, err := template.New(file).Funcs(funcs).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse macros %s: %w", file, err)
+		}
+		if node := firstContent(set.Tree); node != nil {
+			location, _ := set.Tree.ErrorContext(node)
+			return nil, fmt.Errorf("%s: a macro file may only contain define blocks", location)
+		}
+		for _, t := range set.Templates() {
+			if t.Name() == file || t.Tree == nil {
+				continue
+			}
+			if other, ok := definedIn[t.Name()]; ok {
+				return nil, fmt.Errorf("macro %q is defined in both %s and %s", t.Name(), other, file)
+			}
+			definedIn[t.Name()] = file
+			macros = append(macros, t)
+		}
+	}
+	return macros, nil
+}
+
+// firstContent returns the first node of tree that is not whitespace, or nil.
+func firstContent(tree *parse.Tree) parse.Node {
+	if tree == nil || tree.Root == nil {
+		return nil
+	}
+	for _, node := range tree.Root.Nodes {
+		if text, ok := node.(*parse.TextNode); ok && len(strings.TrimSpace(string(text.Text))) == 0 {
+			continue
+		}
+		return node
+	}
+	return nil
+}
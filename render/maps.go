@@ -0,0 +1,186 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// mapOf returns m as a map value, or an invalid value if m is nil. Pointers
+// and interfaces are followed.
+func mapOf(m any) (reflect.Value, error) {
+	v := indirectValue(reflect.ValueOf(m))
+	if v.IsValid() && v.Kind() != reflect.Map {
+		return reflect.Value{}, fmt.Errorf("expected a map, got %T", m)
+	}
+	return v, nil
+}
+
+// mapKeys returns the keys of a map sorted as by sort, so that templates
+// produce the same output on every run.
+func mapKeys(m any) (any, error) {
+	v, err := mapOf(m)
+	if err != nil || !v.IsValid() {
+		return []string{}, err
+	}
+	keys := reflect.MakeSlice(reflect.SliceOf(v.Type().Key()), 0, v.Len())
+	keys = reflect.Append(keys, v.MapKeys()...)
+	return sortValues(keys.Interface(), nil, false), nil
+}
+
+// mapValues returns the values of a map in the order of its sorted keys.
+func mapValues(m any) (any, error) {
+	v, err := mapOf(m)
+	if err != nil || !v.IsValid() {
+		return []any{}, err
+	}
+	keys, _ := mapKeys(m)
+	k := reflect.ValueOf(keys)
+	values := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), k.Len(), k.Len())
+	for i := range k.Len() {
+		values.Index(i).Set(v.MapIndex(k.Index(i)))
+	}
+	return values.Interface(), nil
+}
+
+// hasKey reports whether a map has key, even if its value is nil. Keys of
+// maps not keyed by strings are compared in their formatted form.
+func hasKey(m any, key string) (bool, error) {
+	v, err := mapOf(m)
+	if err != nil || !v.IsValid() {
+		return false, err
+	}
+	return lookupStep(v, key).IsValid(), nil
+}
+
+// pick returns a copy of a map holding only the given keys.
+func pick(m any, keys ...string) (any, error) {
+	wanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		wanted[key] = true
+	}
+	return filterMap(m, func(key string) bool { return wanted[key] })
+}
+
+// omit returns a copy of a map without the given keys.
+func omit(m any, keys ...string) (any, error) {
+	unwanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		unwanted[key] = true
+	}
+	return filterMap(m, func(key string) bool { return !unwanted[key] })
+}
+
+// filterMap returns a map of the same type as m holding the entries whose
+// formatted key keep accepts.
+func filterMap(m any, keep func(key string) bool) (any, error) {
+	v, err := mapOf(m)
+	if err != nil || !v.IsValid() {
+		return map[string]any{}, err
+	}
+	result := reflect.MakeMap(v.Type())
+	iter := v.MapRange()
+	for iter.Next() {
+		if keep(toString(iter.Key().Interface())) {
+			result.SetMapIndex(iter.Key(), iter.Value())
+		}
+	}
+	return result.Interface(), nil
+}
+
+// mergeMapsCopy returns a new map holding the entries of maps, in which
+// later maps take precedence and nested map[string]any values are merged in
+// turn. Unlike merge, it does not change its arguments, and it accepts any
+// map keyed by strings, such as map[string]string headers:
+//
+//	{{ $headers := mergeMaps .DefaultHeaders .Headers }}
+func mergeMapsCopy(maps ...any) (map[string]any, error) {
+	result := make(map[string]any)
+	for _, m := range maps {
+		v, err := mapOf(m)
+		if err != nil {
+			return nil, err
+		}
+		if !v.IsValid() {
+			continue
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("failed to merge maps: keys must be strings, got %T", m)
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			key, value := iter.Key().String(), iter.Value().Interface()
+			existing, existingIsMap := result[key].(map[string]any)
+			nested, nestedIsMap := value.(map[string]any)
+			if existingIsMap && nestedIsMap {
+				value, _ = mergeMapsCopy(existing, nested)
+			} else if nestedIsMap {
+				value = deepCopy(nested)
+			}
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+// toPairs returns the entries of a map as a list of maps with "key" and
+// "value" entries, sorted by key:
+//
+//	{{ range toPairs .Labels }}{{ .key }}={{ .value }} {{ end }}
+func toPairs(m any) ([]map[string]any, error) {
+	v, err := mapOf(m)
+	if err != nil || !v.IsValid() {
+		return []map[string]any{}, err
+	}
+	keys, _ := mapKeys(m)
+	k := reflect.ValueOf(keys)
+	pairs := make([]map[string]any, k.Len())
+	for i := range pairs {
+		pairs[i] = map[string]any{
+			"key":   k.Index(i).Interface(),
+			"value": v.MapIndex(k.Index(i)).Interface(),
+		}
+	}
+	return pairs, nil
+}
+
+// fromPairs builds a map from a list of pairs, each either a two-element
+// list or a map with "key" and "value" entries, as returned by toPairs.
+// Later pairs replace earlier ones with the same key.
+func fromPairs(pairs any) (map[string]any, error) {
+	v := indirectValue(reflect.ValueOf(pairs))
+	if !v.IsValid() {
+		return map[string]any{}, nil
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list of pairs, got %T", pairs)
+	}
+
+	result := make(map[string]any, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		pair := indirectValue(v.Index(i))
+		var key, value reflect.Value
+		switch {
+		case (pair.Kind() == reflect.Slice || pair.Kind() == reflect.Array) && pair.Len() == 2:
+			key, value = pair.Index(0), pair.Index(1)
+		case pair.Kind() == reflect.Map:
+			key, value = lookupStep(pair, "key"), lookupStep(pair, "value")
+		}
+		if !key.IsValid() {
+			return nil, fmt.Errorf("failed to build map: pair %d must be a [key value] list or a map with a key, got %s", i, describeValue(pair))
+		}
+		if value.IsValid() {
+			result[toString(key.Interface())] = value.Interface()
+		} else {
+			result[toString(key.Interface())] = nil
+		}
+	}
+	return result, nil
+}
+
+// describeValue returns the type of v for error messages.
+func describeValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	return v.Type().String()
+}
@@ -0,0 +1,19 @@
+package render
+
+import "testing"
+
+func TestMapFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"keys values", `{{ keys .H }} {{ values .H }} {{ keys .N }} {{ keys .Missing }}`, map[string]any{"H": map[string]string{"b": "2", "a": "1"}, "N": map[int]bool{10: true, 9: true}}, "[a b] [1 2] [9 10] []"},
+		{"hasKey", `{{ hasKey .M "a" }} {{ hasKey .M "n" }} {{ hasKey .M "x" }} {{ hasKey .Missing "a" }}`, map[string]any{"M": map[string]any{"a": 1, "n": nil}}, "true true false false"},
+		{"pick omit", `{{ pick .H "a" "c" }} {{ omit .H "a" }} {{ .H }}`, map[string]any{"H": map[string]string{"a": "1", "b": "2", "c": "3"}}, "map[a:1 c:3] map[b:2 c:3] map[a:1 b:2 c:3]"},
+		{"mergeMaps", `{{ mergeMaps .D .O nil }} {{ .D }}`, map[string]any{"D": map[string]any{"a": 1, "n": map[string]any{"x": 1, "y": 2}}, "O": map[string]any{"a": 2, "n": map[string]any{"y": 3}}}, "map[a:2 n:map[x:1 y:3]] map[a:1 n:map[x:1 y:2]]"},
+		{"toPairs fromPairs", `{{ range toPairs .M }}{{ .key }}={{ .value }};{{ end }} {{ fromPairs (toPairs .M) }} {{ fromPairs (list (list "x" 1) (list "y" nil)) }}`, map[string]any{"M": map[string]int{"b": 2, "a": 1}}, "a=1;b=2; map[a:1 b:2] map[x:1 y:<nil>]"},
+	}
+	errorTests := []funcErrorTest{
+		{"keys map", `{{ keys (list 1) }}`, "expected a map, got []interface {}"},
+		{"mergeMaps map", `{{ mergeMaps (dict) "x" }}`, "expected a map, got string"},
+		{"fromPairs pair", `{{ fromPairs (list (list 1 2 3)) }}`, "failed to build map: pair 0 must be a [key value] list or a map with a key, got []interface {}"},
+	}
+	runFuncTests(t, tests, errorTests)
+}
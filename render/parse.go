@@ -0,0 +1,247 @@
+package render
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+// ParseTemplate parses the template at name in fsys with funcs, resolving
+// layouts, macros, partials and includes.
+//
+// A template whose first action is
+//
+//	{{ extends "base.go.tmpl" }}
+//
+// is rendered through that layout: the layout's {{ block "name" . }}
+// sections are replaced by the child's {{ define "name" }} sections, and any
+// block the child does not define keeps the layout's default. A child may
+// contain nothing but the extends action and its defines. Layouts may
+// themselves extend other layouts. A layout name is resolved against the
+// child's directory and then the root of fsys; for each, a file name with a
+// leading underscore is tried too, so layouts can be kept out of rendered
+// output as _base.go.tmpl.
+//
+// Every template can call the macros defined in the _macros directories of
+// its own directory and its parents. Macro files (*.tmpl or *.tpl) may
+// contain only define blocks. A macro in a nearer directory takes precedence
+// over one of the same name further up, and a template's own defines take
+// precedence over macros.
+//
+// A {{ template "header" . }} call to a template that is not defined is
+// resolved to the partial _header.tmpl (or _header.tpl) in the directory of
+// name or the nearest of its parents, which is parsed into the template set.
+//
+// The include function renders another file with explicit data and inserts
+// the result:
+//
+//	{{ include "license" .Year }}
+//
+// The file is looked up as given, in the directory of name and in an
+// includes directory at the root of fsys, each with and without a .tmpl or
+// .tpl extension, and is itself parsed with ParseTemplate.
+//
+// funcs must include "extends" and "include", as DefaultFuncMap does.
+func ParseTemplate(fsys fs.FS, name string, funcs template.FuncMap) (*template.Template, error) {
+	return parseTemplate(fsys, name, funcs, nil)
+}
+
+// parseTemplate is ParseTemplate for a file included by the files in
+// including, outermost first.
+func parseTemplate(fsys fs.FS, name string, funcs template.FuncMap, including []string) (*template.Template, error) {
+	tmpl, err := parseLayered(fsys, name, funcs, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := addMacros(fsys, name, funcs, tmpl); err != nil {
+		return nil, err
+	}
+	if err := addPartials(fsys, name, tmpl); err != nil {
+		return nil, err
+	}
+	return bindInclude(tmpl, fsys, name, funcs, including), nil
+}
+
+// BindInclude sets the include function of tmpl, parsed from name in fsys,
+// to render included files with funcs. ParseTemplate already does this; call
+// it again after replacing the functions of a template, so that included
+// files use the same functions.
+func BindInclude(tmpl *template.Template, fsys fs.FS, name string, funcs template.FuncMap) *template.Template {
+	return bindInclude(tmpl, fsys, name, funcs, nil)
+}
+
+func bindInclude(tmpl *template.Template, fsys fs.FS, name string, funcs template.FuncMap, including []string) *template.Template {
+	in := &includer{
+		fsys:   fsys,
+		chain:  append(slices.Clip(including), name),
+		funcs:  funcs,
+		parsed: make(map[string]*template.Template),
+	}
+	return tmpl.Funcs(template.FuncMap{"include": in.include})
+}
+
+// includer implements include for one template. Included files are parsed
+// once and reused.
+type includer struct {
+	fsys  fs.FS
+	chain []string
+	funcs template.FuncMap
+
+	mu     sync.Mutex
+	parsed map[string]*template.Template
+}
+
+func (in *includer) include(name string, data any) (string, error) {
+	from := in.chain[len(in.chain)-1]
+	target := resolveInclude(in.fsys, from, name)
+	if target == "" {
+		return "", fmt.Errorf("include %q from %s not found", name, from)
+	}
+	if slices.Contains(in.chain, target) {
+		return "", fmt.Errorf("circular include: %s", strings.Join(append(slices.Clip(in.chain), target), " -> "))
+	}
+
+	in.mu.Lock()
+	tmpl, ok := in.parsed[target]
+	if !ok {
+		var err error
+		tmpl, err = parseTemplate(in.fsys, target, in.funcs, in.chain)
+		if err != nil {
+			in.mu.Unlock()
+			return "", fmt.Errorf("failed to parse include %s: %w", target, err)
+		}
+		in.parsed[target] = tmpl
+	}
+	in.mu.Unlock()
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// include is the include function of templates that were not parsed with
+// ParseTemplate, which have no file to resolve includes against.
+func include(name string, data any) (string, error) {
+	return "", fmt.Errorf("include %q: template was not parsed with ParseTemplate", name)
+}
+
+func resolveInclude(fsys fs.FS, from, name string) string {
+	name = strings.TrimPrefix(name, "/")
+	var candidates []string
+	for _, dir := range []string{".", path.Dir(from), "includes"} {
+		p := path.Join(dir, name)
+		candidates = append(candidates, p, p+".tmpl", p+".tpl")
+	}
+	for _, candidate := range candidates {
+		if !fs.ValidPath(candidate) {
+			continue
+		}
+		if info, err := fs.Stat(fsys, candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// addPartials parses into tmpl the partials of the templates it calls but
+// does not define, as described by ParseTemplate. Partials may call further
+// partials.
+func addPartials(fsys fs.FS, name string, tmpl *template.Template) error {
+	tried := make(map[string]bool)
+	for {
+		added := false
+		for _, call := range templateCalls(tmpl) {
+			if tmpl.Lookup(call) != nil || tried[call] {
+				continue
+			}
+			tried[call] = true
+			partial := resolvePartial(fsys, name, call)
+			if partial == "" {
+				// Left for execution to report, in case it is never called.
+				continue
+			}
+			content, err := fs.ReadFile(fsys, partial)
+			if err != nil {
+				return err
+			}
+			if _, err := tmpl.New(call).Parse(string(content)); err != nil {
+				return fmt.Errorf("failed to parse partial %s: %w", partial, err)
+			}
+			// Name the partial's file in error locations rather than the
+			// name it was called by.
+			for _, t := range tmpl.Templates() {
+				if t.Tree != nil && t.Tree.ParseName == call {
+					t.Tree.ParseName = partial
+				}
+			}
+			added = true
+		}
+		if !added {
+			return nil
+		}
+	}
+}
+
+func resolvePartial(fsys fs.FS, from, call string) string {
+	for dir := path.Dir(from); ; dir = path.Dir(dir) {
+		p := path.Join(dir, path.Dir(call), "_"+path.Base(call))
+		for _, candidate := range []string{p + ".tmpl", p + ".tpl"} {
+			if !fs.ValidPath(candidate) {
+				continue
+			}
+			if info, err := fs.Stat(fsys, candidate); err == nil && !info.IsDir() {
+				return candidate
+			}
+		}
+		if dir == "." {
+			return ""
+		}
+	}
+}
+
+// templateCalls returns the sorted names of the templates called with
+// {{ template }} in the template set of tmpl.
+func templateCalls(tmpl *template.Template) []string {
+	calls := make(map[string]bool)
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			collectCalls(t.Tree.Root, calls)
+		}
+	}
+	names := make([]string, 0, len(calls))
+	for name := range calls {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func collectCalls(node parse.Node, calls map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectCalls(child, calls)
+		}
+	case *parse.TemplateNode:
+		calls[n.Name] = true
+	case *parse.IfNode:
+		collectCalls(n.List, calls)
+		collectCalls(n.ElseList, calls)
+	case *parse.RangeNode:
+		collectCalls(n.List, calls)
+		collectCalls(n.ElseList, calls)
+	case *parse.WithNode:
+		collectCalls(n.List, calls)
+		collectCalls(n.ElseList, calls)
+	}
+}
@@ -0,0 +1,219 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"plugin"
+	"sort"
+	"sync"
+)
+
+// LoadPlugin registers the template functions of the Go plugin at path, a
+// .so file built with "go build -buildmode=plugin" against the same version
+// of weft. The plugin exports its functions as a variable named Functions,
+// and may describe them in a variable named Metadata:
+//
+//	var Functions = map[string]any{"acmeLicense": license}
+//	var Metadata = map[string]render.FunctionMetadata{
+//		"acmeLicense": {Description: "ACME license header", Category: "acme"},
+//	}
+//
+// Plugin functions may not replace functions already registered.
+func (fr *FunctionRegistry) LoadPlugin(path string) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open plugin: %w", err)
+	}
+	sym, err := p.Lookup("Functions")
+	if err != nil {
+		return fmt.Errorf("failed to load plugin %s: %w", path, err)
+	}
+	functions, ok := sym.(*map[string]any)
+	if !ok {
+		return fmt.Errorf("failed to load plugin %s: Functions is a %T, want map[string]any", path, sym)
+	}
+	metadata := map[string]FunctionMetadata{}
+	if sym, err := p.Lookup("Metadata"); err == nil {
+		m, ok := sym.(*map[string]FunctionMetadata)
+		if !ok {
+			return fmt.Errorf("failed to load plugin %s: Metadata is a %T, want map[string]render.FunctionMetadata", path, sym)
+		}
+		metadata = *m
+	}
+
+	names := make([]string, 0, len(*functions))
+	for name := range *functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fr.registerPluginFunction(path, name, (*functions)[name], metadata[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProcessPlugin is a subprocess that provides template functions. weft
+// writes one JSON request per line to its standard input and reads one JSON
+// response per line from its standard output. The first request is
+//
+//	{"id": 1, "method": "describe"}
+//
+// to which the plugin replies with the functions it provides, described as
+// in FunctionMetadata:
+//
+//	{"id": 1, "functions": [{"name": "acmeLicense", "description": "..."}]}
+//
+// Each call of a function is then a request such as
+//
+//	{"id": 2, "method": "call", "function": "acmeLicense", "args": [2024]}
+//
+// answered with {"id": 2, "result": ...} or {"id": 2, "error": "..."}.
+// Requests are sent one at a time.
+type ProcessPlugin struct {
+	mu     sync.Mutex
+	name   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	dec    *json.Decoder
+	nextID int
+	closed bool
+}
+
+type pluginRequest struct {
+	ID       int    `json:"id"`
+	Method   string `json:"method"`
+	Function string `json:"function,omitempty"`
+	Args     []any  `json:"args,omitempty"`
+}
+
+type pluginResponse struct {
+	ID        int                `json:"id"`
+	Functions []FunctionMetadata `json:"functions,omitempty"`
+	Result    any                `json:"result,omitempty"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// LoadProcessPlugin starts command with args as a ProcessPlugin and
+// registers the functions it describes. Plugin functions take any number of
+// arguments, which must be encodable as JSON, and may not replace functions
+// already registered. The caller must Close the plugin when rendering is
+// done.
+func (fr *FunctionRegistry) LoadProcessPlugin(command string, args ...string) (*ProcessPlugin, error) {
+	return fr.startProcessPlugin(command, exec.Command(command, args...))
+}
+
+// startProcessPlugin starts cmd as the plugin called name in errors and
+// registers the functions it describes.
+func (fr *FunctionRegistry) startProcessPlugin(name string, cmd *exec.Cmd) (*ProcessPlugin, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", name, err)
+	}
+
+	p := &ProcessPlugin{name: name, cmd: cmd, stdin: stdin, dec: json.NewDecoder(stdout)}
+	resp, err := p.roundTrip(pluginRequest{Method: "describe"})
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	for _, meta := range resp.Functions {
+		if err := fr.registerPluginFunction(name, meta.Name, p.function(meta.Name), meta); err != nil {
+			p.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// function returns a template function that calls name in the plugin.
+func (p *ProcessPlugin) function(name string) func(args ...any) (any, error) {
+	return func(args ...any) (any, error) {
+		return p.Call(name, args...)
+	}
+}
+
+// Call calls the plugin function name with args.
+func (p *ProcessPlugin) Call(name string, args ...any) (any, error) {
+	resp, err := p.roundTrip(pluginRequest{Method: "call", Function: name, Args: args})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s: %s", name, resp.Error)
+	}
+	return resp.Result, nil
+}
+
+func (p *ProcessPlugin) roundTrip(req pluginRequest) (pluginResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return pluginResponse{}, fmt.Errorf("plugin %s is closed", p.name)
+	}
+	p.nextID++
+	req.ID = p.nextID
+	line, err := json.Marshal(req)
+	if err != nil {
+		return pluginResponse{}, fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+	if _, err := p.stdin.Write(append(line, '\n')); err != nil {
+		return pluginResponse{}, fmt.Errorf("failed to write to plugin %s: %w", p.name, err)
+	}
+
+	var resp pluginResponse
+	if err := p.dec.Decode(&resp); err != nil {
+		return pluginResponse{}, fmt.Errorf("failed to read from plugin %s: %w", p.name, err)
+	}
+	if resp.ID != req.ID {
+		return pluginResponse{}, fmt.Errorf("plugin %s answered request %d with id %d", p.name, req.ID, resp.ID)
+	}
+	return resp, nil
+}
+
+// Close closes the plugin's standard input and waits for it to exit.
+func (p *ProcessPlugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	p.stdin.Close()
+	return p.cmd.Wait()
+}
+
+// registerPluginFunction registers fn from the plugin source under name,
+// described by meta.
+func (fr *FunctionRegistry) registerPluginFunction(source, name string, fn any, meta FunctionMetadata) error {
+	if fr.HasFunction(name) {
+		return fmt.Errorf("plugin %s: function %q is already registered", source, name)
+	}
+	err := fr.Register(name, fn, func(m *FunctionMetadata) {
+		name, added := m.Name, m.AddedAt
+		*m = meta
+		m.Name, m.AddedAt = name, added
+		if m.Category == "" {
+			m.Category = "plugin"
+		}
+		if m.ReturnType == "" {
+			m.ReturnType = "interface{}"
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", source, err)
+	}
+	return nil
+}
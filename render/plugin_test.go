@@ -0,0 +1,76 @@
+package render
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestProcessPlugin(t *testing.T) {
+	t.Setenv("WEFT_TEST_PLUGIN", "acme")
+	registry := NewFunctionRegistry()
+	registry.RegisterDefaults()
+	plugin, err := registry.LoadProcessPlugin(os.Args[0], "-test.run=^TestProcessPluginHelper$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plugin.Close()
+
+	if meta, ok := registry.GetMetadata("acmeLicense"); !ok || meta.Description != "ACME license header" || meta.Category != "plugin" {
+		t.Errorf("GetMetadata(acmeLicense) = %+v, %v", meta, ok)
+	}
+	tmpl := template.Must(template.New("t").Funcs(registry.GetFuncMap()).Parse(`{{ acmeLicense 2024 }}`))
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, nil); err != nil || buf.String() != "Copyright 2024 ACME" {
+		t.Errorf("got %q, %v; want Copyright 2024 ACME", buf.String(), err)
+	}
+	tmpl = template.Must(template.New("t").Funcs(registry.GetFuncMap()).Parse(`{{ acmeLicense }}`))
+	if err := tmpl.Execute(io.Discard, nil); err == nil || !strings.Contains(err.Error(), "acmeLicense: want a year") {
+		t.Errorf("Expected the plugin's error, got %v", err)
+	}
+
+	t.Setenv("WEFT_TEST_PLUGIN", "collide")
+	if _, err := registry.LoadProcessPlugin(os.Args[0], "-test.run=^TestProcessPluginHelper$"); err == nil || !strings.Contains(err.Error(), `function "snake" is already registered`) {
+		t.Errorf("Expected a collision error, got %v", err)
+	}
+	if err := registry.LoadPlugin(filepath.Join(t.TempDir(), "missing.so")); err == nil || !strings.Contains(err.Error(), "failed to open plugin") {
+		t.Errorf("Expected an error for a missing plugin, got %v", err)
+	}
+}
+
+// TestProcessPluginHelper is the plugin process started by TestProcessPlugin
+// and TestLoadWASMPlugin.
+func TestProcessPluginHelper(t *testing.T) {
+	mode := os.Getenv("WEFT_TEST_PLUGIN")
+	if mode == "" {
+		t.Skip("plugin process for TestProcessPlugin")
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     int
+			Method string
+			Args   []any
+		}
+		json.Unmarshal(scanner.Bytes(), &req)
+		switch {
+		case req.Method == "describe" && mode == "collide":
+			enc.Encode(map[string]any{"id": req.ID, "functions": []map[string]any{{"name": "snake"}}})
+		case req.Method == "describe":
+			enc.Encode(map[string]any{"id": req.ID, "functions": []map[string]any{{"name": "acmeLicense", "description": "ACME license header"}}})
+		case len(req.Args) != 1:
+			enc.Encode(map[string]any{"id": req.ID, "error": "want a year"})
+		default:
+			enc.Encode(map[string]any{"id": req.ID, "result": fmt.Sprintf("Copyright %v ACME", req.Args[0])})
+		}
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,305 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// filterOperators maps the operators filterBy and where accept to their
+// canonical names.
+var filterOperators = map[string]string{
+	"eq": "eq", "==": "eq",
+	"ne": "ne", "!=": "ne",
+	"lt": "lt", "<": "lt",
+	"le": "le", "<=": "le",
+	"gt": "gt", ">": "gt",
+	"ge": "ge", ">=": "ge",
+	"contains": "contains",
+	"in":       "in",
+}
+
+// filterBy returns the elements of slice whose value at field, a path as in
+// lookup, compares to value with op:
+//
+//	{{ range filterBy .Items "Status" "eq" "active" }}
+//
+// The operators are eq, ne, lt, le, gt and ge (or ==, !=, <, <=, > and >=),
+// contains, which matches strings containing value, lists holding it and
+// maps with it as a key, and in, which matches values in the list value.
+// Numbers compare numerically whatever their type, strings lexically, and
+// eq and ne compare other values by their formatted text. Elements where
+// field is missing compare as nil.
+func filterBy(slice any, field, op string, value any) (any, error) {
+	predicate, err := fieldPredicate(field, op, value)
+	if err != nil {
+		return nil, err
+	}
+	return filterSlice(slice, predicate), nil
+}
+
+// partitionBy splits slice into the elements filterBy would return and the
+// rest, returned as a list of the two.
+func partitionBy(slice any, field, op string, value any) (any, error) {
+	predicate, err := fieldPredicate(field, op, value)
+	if err != nil {
+		return nil, err
+	}
+	return slicePartition(slice, predicate), nil
+}
+
+// where returns the elements of slice that match expr, a condition of the
+// form "field op value", such as "Age > 30" or `Status == "active"`, or a
+// field alone, which matches truthy values, optionally negated with "!":
+//
+//	{{ range where .Users "Age >= 18" }}
+//	{{ range where .Fields "!Deprecated" }}
+//
+// The operators and comparisons are those of filterBy. The value may be a
+// number, a quoted string, true, false, nil, a list such as [a, "b c"] for
+// in, or a bare word, which is taken as a string. Conditions are combined by
+// chaining calls.
+func where(slice any, expr string) (any, error) {
+	predicate, err := parseWhere(expr)
+	if err != nil {
+		return nil, err
+	}
+	return filterSlice(slice, predicate), nil
+}
+
+// fieldPredicate returns a predicate matching elements whose value at field
+// compares to want with op.
+func fieldPredicate(field, op string, want any) (func(any) bool, error) {
+	steps, err := lookupSteps(field)
+	if err != nil {
+		return nil, err
+	}
+	name, ok := filterOperators[op]
+	if !ok {
+		return nil, fmt.Errorf("unknown filter operator %q", op)
+	}
+	return func(item any) bool {
+		return compareValues(lookupValue(reflect.ValueOf(item), steps), name, want)
+	}, nil
+}
+
+// parseWhere compiles a where expression into a predicate.
+func parseWhere(expr string) (func(any) bool, error) {
+	text := strings.TrimSpace(expr)
+	end := strings.IndexAny(text, " \t=!<>")
+	if end == 0 && text[0] == '!' && !strings.ContainsAny(text[1:], " \t=!<>") {
+		steps, err := lookupSteps(text[1:])
+		if err != nil || len(steps) == 0 {
+			return nil, fmt.Errorf("invalid where expression %q", expr)
+		}
+		return func(item any) bool {
+			return !truthyValue(lookupValue(reflect.ValueOf(item), steps))
+		}, nil
+	}
+	if end == -1 {
+		steps, err := lookupSteps(text)
+		if err != nil || len(steps) == 0 {
+			return nil, fmt.Errorf("invalid where expression %q", expr)
+		}
+		return func(item any) bool {
+			return truthyValue(lookupValue(reflect.ValueOf(item), steps))
+		}, nil
+	}
+	if end == 0 {
+		return nil, fmt.Errorf("invalid where expression %q: missing field", expr)
+	}
+
+	field, rest := text[:end], strings.TrimSpace(text[end:])
+	op := ""
+	for _, candidate := range []string{"==", "!=", "<=", ">=", "<", ">", "contains ", "in "} {
+		if strings.HasPrefix(rest, candidate) {
+			op = strings.TrimSpace(candidate)
+			rest = strings.TrimSpace(rest[len(candidate):])
+			break
+		}
+	}
+	if op == "" {
+		return nil, fmt.Errorf("invalid where expression %q: missing operator", expr)
+	}
+	if rest == "" {
+		return nil, fmt.Errorf("invalid where expression %q: missing value", expr)
+	}
+	value, err := parseLiteral(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid where expression %q: %w", expr, err)
+	}
+	return fieldPredicate(field, op, value)
+}
+
+// parseLiteral parses the value of a where expression.
+func parseLiteral(text string) (any, error) {
+	switch {
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return nil, fmt.Errorf("unterminated list %s", text)
+		}
+		items := []any{}
+		for _, part := range splitList(text[1 : len(text)-1]) {
+			item, err := parseLiteral(part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case strings.HasPrefix(text, `"`):
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", text)
+		}
+		return s, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("invalid string %s", text)
+		}
+		return text[1 : len(text)-1], nil
+	case text == "true" || text == "false":
+		return text == "true", nil
+	case text == "nil" || text == "null":
+		return nil, nil
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f, nil
+	}
+	return text, nil
+}
+
+// splitList splits the items of a list literal at commas outside quotes.
+func splitList(text string) []string {
+	var items []string
+	var quote rune
+	start := 0
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote && (i == 0 || text[i-1] != '\\') {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(text[start:]); last != "" || len(items) > 0 {
+		items = append(items, last)
+	}
+	return items
+}
+
+// compareValues reports whether actual compares to want with op, one of
+// the canonical filter operators.
+func compareValues(actual reflect.Value, op string, want any) bool {
+	actual = indirectValue(actual)
+	expected := indirectValue(reflect.ValueOf(want))
+
+	switch op {
+	case "eq":
+		return valuesEqual(actual, expected)
+	case "ne":
+		return !valuesEqual(actual, expected)
+	case "lt", "le", "gt", "ge":
+		c, ok := orderValues(actual, expected)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "lt":
+			return c < 0
+		case "le":
+			return c <= 0
+		case "gt":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	case "contains":
+		switch actual.Kind() {
+		case reflect.String:
+			return expected.IsValid() && strings.Contains(actual.String(), toString(expected.Interface()))
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < actual.Len(); i++ {
+				if valuesEqual(indirectValue(actual.Index(i)), expected) {
+					return true
+				}
+			}
+		case reflect.Map:
+			return expected.IsValid() && lookupStep(actual, toString(expected.Interface())).IsValid()
+		}
+	case "in":
+		if expected.Kind() == reflect.Slice || expected.Kind() == reflect.Array {
+			for i := 0; i < expected.Len(); i++ {
+				if valuesEqual(actual, indirectValue(expected.Index(i))) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// valuesEqual compares numbers numerically and other values by their
+// formatted text. Invalid values are only equal to each other.
+func valuesEqual(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if c, ok := orderValues(a, b); ok {
+		return c == 0
+	}
+	return toString(a.Interface()) == toString(b.Interface())
+}
+
+// orderValues compares two numbers or two strings, reporting false for any
+// other pair.
+func orderValues(a, b reflect.Value) (int, bool) {
+	if x, ok := numberValue(a); ok {
+		if y, ok := numberValue(b); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+		return 0, false
+	}
+	if a.Kind() == reflect.String && b.Kind() == reflect.String {
+		return strings.Compare(a.String(), b.String()), true
+	}
+	return 0, false
+}
+
+// numberValue returns v as a float64 if it is a number.
+func numberValue(v reflect.Value) (float64, bool) {
+	switch {
+	case !v.IsValid():
+		return 0, false
+	case v.CanInt():
+		return float64(v.Int()), true
+	case v.CanUint():
+		return float64(v.Uint()), true
+	case v.CanFloat():
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// truthyValue reports whether v is true in the sense of template if.
+func truthyValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	return !empty(v.Interface())
+}
@@ -0,0 +1,25 @@
+package render
+
+import "testing"
+
+func TestPredicateFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"filterBy", `{{ range filterBy .Items "Status" "eq" "active" }}{{ .Name }}{{ end }} {{ range filterBy .Items "Age" ">=" 30 }}{{ .Name }}{{ end }} {{ range filterBy .Items "Tags" "contains" "go" }}{{ .Name }}{{ end }} {{ range filterBy .Items "Status" "in" (list "new" "gone") }}{{ .Name }}{{ end }}`, map[string]any{"Items": []map[string]any{
+			{"Name": "a", "Status": "active", "Age": 30, "Tags": []string{"go"}},
+			{"Name": "b", "Status": "new", "Age": 41.5},
+			{"Name": "c", "Status": "active", "Age": uint(2)},
+		}}, "ac ab a b"},
+		{"partitionBy", `{{ $p := partitionBy .Items "n" "gt" 1 }}{{ index $p 0 }} {{ index $p 1 }}`, map[string]any{"Items": []map[string]any{{"n": 1}, {"n": 2}, {"n": 3}}}, "[map[n:2] map[n:3]] [map[n:1]]"},
+		{"where", `{{ range where .Users "Age > 30" }}{{ .Name }}{{ end }} {{ range where .Users "Name == 'b'" }}{{ .Name }}{{ end }} {{ range where .Users "Admin" }}{{ .Name }}{{ end }} {{ range where .Users "!Admin" }}{{ .Name }}{{ end }} {{ range where .Users "Name in [a, \"c\"]" }}{{ .Name }}{{ end }} {{ range where .Users "Boss.Name != nil" }}{{ .Name }}{{ end }}`, map[string]any{"Users": []any{
+			map[string]any{"Name": "a", "Age": 31, "Admin": true},
+			map[string]any{"Name": "b", "Age": 30, "Boss": map[string]any{"Name": "a"}},
+			map[string]any{"Name": "c", "Age": 45.5},
+		}}, "ac b a bc ac b"},
+	}
+	errorTests := []funcErrorTest{
+		{"filterBy operator", `{{ filterBy (list) "a" "like" 1 }}`, `unknown filter operator "like"`},
+		{"where operator", `{{ where (list) "Age ~ 3" }}`, `invalid where expression "Age ~ 3": missing operator`},
+		{"where value", `{{ where (list) "Name == \"a" }}`, `invalid where expression "Name == \"a": invalid string "a`},
+	}
+	runFuncTests(t, tests, errorTests)
+}
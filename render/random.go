@@ -0,0 +1,138 @@
+package render
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"text/template"
+
+	"github.com/google/uuid"
+)
+
+// randomSource supplies the random template functions: crypto/rand
+// normally, and a seeded pseudo-random source in deterministic mode.
+type randomSource interface {
+	// intn returns a uniformly distributed integer in [0, n).
+	intn(n int) int
+	Read(p []byte) (int, error)
+}
+
+// cryptoSource draws from crypto/rand, so values such as example passwords
+// and keys are safe to use as real secrets.
+type cryptoSource struct{}
+
+func (cryptoSource) intn(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return int(v.Int64())
+}
+
+func (cryptoSource) Read(p []byte) (int, error) {
+	return rand.Read(p)
+}
+
+// RandomFuncMap returns the random template functions: "uuid",
+// "genPassword", "randInt", "randString", "randHex", "randBase64" and
+// "shuffle". With a seed they draw from a pseudo-random source seeded with
+// it and produce the same values on every run; without one they use
+// crypto/rand. They are part of ExtendedFuncMap, and DeterministicFuncMap
+// replaces them with seeded equivalents.
+func RandomFuncMap(seed ...uint64) template.FuncMap {
+	if len(seed) > 0 {
+		return randomFuncs(newSeededSource(seed[0]))
+	}
+	return randomFuncs(cryptoSource{})
+}
+
+func randomFuncs(src randomSource) template.FuncMap {
+	r := randomizer{src}
+	return template.FuncMap{
+		"uuid":        r.uuid,
+		"genPassword": r.password,
+		"randInt":     r.intRange,
+		"randString":  r.alphanumeric,
+		"randHex":     r.hex,
+		"randBase64":  r.base64,
+		"shuffle":     r.shuffle,
+	}
+}
+
+type randomizer struct {
+	src randomSource
+}
+
+func (r randomizer) uuid() string {
+	id, err := uuid.NewRandomFromReader(r.src)
+	if err != nil {
+		return uuid.Nil.String()
+	}
+	return id.String()
+}
+
+func (r randomizer) fromCharset(charset string, length int) string {
+	result := make([]byte, max(length, 0))
+	for i := range result {
+		result[i] = charset[r.src.intn(len(charset))]
+	}
+	return string(result)
+}
+
+func (r randomizer) password(length int) string {
+	return r.fromCharset("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*", length)
+}
+
+func (r randomizer) alphanumeric(length int) string {
+	return r.fromCharset("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789", length)
+}
+
+// intRange returns an integer in [min, max), or min if the range is empty.
+func (r randomizer) intRange(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + r.src.intn(max-min)
+}
+
+func (r randomizer) bytes(n int) []byte {
+	b := make([]byte, max(n, 0))
+	r.src.Read(b)
+	return b
+}
+
+// hex returns n random bytes hex-encoded, such as a key or token.
+func (r randomizer) hex(n int) string {
+	return hex.EncodeToString(r.bytes(n))
+}
+
+// base64 returns n random bytes in standard base64.
+func (r randomizer) base64(n int) string {
+	return base64.StdEncoding.EncodeToString(r.bytes(n))
+}
+
+// shuffle returns a shuffled copy of a slice or array, and other values as
+// they are.
+func (r randomizer) shuffle(slice any) any {
+	if slice == nil {
+		return slice
+	}
+
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return slice
+	}
+
+	length := v.Len()
+	result := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), length, length)
+	reflect.Copy(result, v)
+
+	swap := reflect.Swapper(result.Interface())
+	for i := length - 1; i > 0; i-- {
+		swap(i, r.src.intn(i+1))
+	}
+
+	return result.Interface()
+}
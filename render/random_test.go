@@ -0,0 +1,59 @@
+package render
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"maps"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/google/uuid"
+)
+
+func TestRandomFuncMap(t *testing.T) {
+	execute := func(funcs template.FuncMap, text string) string {
+		t.Helper()
+		tmpl := template.Must(template.New("t").Funcs(funcs).Parse(text))
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			t.Fatalf("Execute(%s) failed: %v", text, err)
+		}
+		return buf.String()
+	}
+
+	const text = `{{ uuid }} {{ genPassword 16 }} {{ randString 10 }} {{ randHex 16 }} {{ randBase64 12 }} {{ randInt 10 20 }} {{ randInt 5 5 }} {{ shuffle (list 1 2 3 4) }}`
+	funcs := ExtendedFuncMap()
+	fields := strings.Fields(execute(funcs, text))
+	if len(fields) != 11 {
+		t.Fatalf("Unexpected output %q", fields)
+	}
+	if _, err := uuid.Parse(fields[0]); err != nil {
+		t.Errorf("uuid = %q: %v", fields[0], err)
+	}
+	if len(fields[1]) != 16 || len(fields[2]) != 10 {
+		t.Errorf("genPassword = %q, randString = %q", fields[1], fields[2])
+	}
+	if b, err := hex.DecodeString(fields[3]); err != nil || len(b) != 16 {
+		t.Errorf("randHex 16 = %q", fields[3])
+	}
+	if b, err := base64.StdEncoding.DecodeString(fields[4]); err != nil || len(b) != 12 {
+		t.Errorf("randBase64 12 = %q", fields[4])
+	}
+	if n, err := strconv.Atoi(fields[5]); err != nil || n < 10 || n >= 20 {
+		t.Errorf("randInt 10 20 = %q", fields[5])
+	}
+	if fields[6] != "5" {
+		t.Errorf("randInt 5 5 = %q, want 5", fields[6])
+	}
+
+	seeded := func() string {
+		funcs := ExtendedFuncMap()
+		maps.Copy(funcs, RandomFuncMap(7))
+		return execute(funcs, text)
+	}
+	if first, second := seeded(), seeded(); first != second {
+		t.Errorf("Expected seeded functions to repeat:\n%s\n%s", first, second)
+	}
+}
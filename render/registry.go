@@ -4,17 +4,21 @@ import (
 	"fmt"
 	"maps"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"text/template"
 	"time"
+	"unicode"
 )
 
 type FunctionRegistry struct {
 	mu        sync.RWMutex
 	functions map[string]any
 	metadata  map[string]FunctionMetadata
+	// aliases maps each alias to the name of the function it stands for.
+	aliases map[string]string
 }
 
 type FunctionMetadata struct {
@@ -26,6 +30,8 @@ type FunctionMetadata struct {
 	Examples    []string    `json:"examples"`
 	Since       string      `json:"since"`
 	Deprecated  bool        `json:"deprecated"`
+	Replacement string      `json:"replacement,omitempty"`
+	Aliases     []string    `json:"aliases,omitempty"`
 	AddedAt     time.Time   `json:"added_at"`
 }
 
@@ -43,6 +49,7 @@ func NewFunctionRegistry() *FunctionRegistry {
 	return &FunctionRegistry{
 		functions: make(map[string]any),
 		metadata:  make(map[string]FunctionMetadata),
+		aliases:   make(map[string]string),
 	}
 }
 
@@ -88,10 +95,39 @@ func WithDeprecated() FunctionOption {
 	}
 }
 
+// WithReplacement deprecates a function in favour of replacement, which
+// warnings and documentation suggest instead.
+func WithReplacement(replacement string) FunctionOption {
+	return func(meta *FunctionMetadata) {
+		meta.Deprecated = true
+		meta.Replacement = replacement
+	}
+}
+
+// WithUnqualifiedAlias also exposes a namespaced function under its name
+// without the namespace, so "strings.snake" can be called as snake too.
+func WithUnqualifiedAlias() FunctionOption {
+	return func(meta *FunctionMetadata) {
+		if namespace, name, ok := strings.Cut(meta.Name, "."); ok && namespace != "" {
+			meta.Aliases = append(meta.Aliases, name)
+		}
+	}
+}
+
+// Register adds fn to the registry, replacing any function already
+// registered under name. A name of the form "namespace.name" registers fn
+// in a namespace: templates reach it through a function named after the
+// namespace that returns the namespace's functions, as in
+// {{ call strings.snake .Name }}, or through an alias. Register reports an
+// error if a namespace or alias would hide another function.
 func (fr *FunctionRegistry) Register(name string, fn any, opts ...FunctionOption) error {
 	fr.mu.Lock()
 	defer fr.mu.Unlock()
 
+	if err := validateFunctionName(name); err != nil {
+		return err
+	}
+
 	if fn == nil {
 		return fmt.Errorf("function cannot be nil")
 	}
@@ -116,16 +152,113 @@ func (fr *FunctionRegistry) Register(name string, fn any, opts ...FunctionOption
 		meta.Parameters = fr.inferParameters(fnValue)
 	}
 
+	if err := fr.checkCollisions(name, meta.Aliases); err != nil {
+		return err
+	}
+
+	fr.removeAliases(name)
 	fr.functions[name] = fn
 	fr.metadata[name] = meta
+	for _, alias := range meta.Aliases {
+		fr.aliases[alias] = name
+	}
+
+	return nil
+}
+
+// validateFunctionName checks that name is a template identifier, or two
+// joined by a dot.
+func validateFunctionName(name string) error {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return fmt.Errorf("invalid function name %q: namespaces cannot be nested", name)
+	}
+	for _, part := range parts {
+		if !isIdentifier(part) {
+			return fmt.Errorf("invalid function name %q", name)
+		}
+	}
+	return nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkCollisions reports an error if registering name with aliases would
+// make a template name refer to two functions: a namespace with the name of
+// a function, or an alias with the name of a function, namespace or another
+// alias. Re-registering name itself is allowed.
+func (fr *FunctionRegistry) checkCollisions(name string, aliases []string) error {
+	namespace, _, namespaced := strings.Cut(name, ".")
+	if !namespaced {
+		if owner, ok := fr.aliases[name]; ok && owner != name {
+			return fmt.Errorf("function %q collides with an alias of %q", name, owner)
+		}
+		if fr.hasNamespace(name) {
+			return fmt.Errorf("function %q collides with namespace %q", name, name)
+		}
+		return nil
+	}
 
+	if _, ok := fr.functions[namespace]; ok {
+		return fmt.Errorf("namespace %q of %q collides with function %q", namespace, name, namespace)
+	}
+	if owner, ok := fr.aliases[namespace]; ok {
+		return fmt.Errorf("namespace %q of %q collides with an alias of %q", namespace, name, owner)
+	}
+	for _, alias := range aliases {
+		if _, ok := fr.functions[alias]; ok {
+			return fmt.Errorf("alias %q of %q collides with function %q", alias, name, alias)
+		}
+		if owner, ok := fr.aliases[alias]; ok && owner != name {
+			return fmt.Errorf("alias %q of %q collides with an alias of %q", alias, name, owner)
+		}
+		if fr.hasNamespace(alias) {
+			return fmt.Errorf("alias %q of %q collides with namespace %q", alias, name, alias)
+		}
+	}
 	return nil
 }
 
+func (fr *FunctionRegistry) hasNamespace(namespace string) bool {
+	for name := range fr.functions {
+		if strings.HasPrefix(name, namespace+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (fr *FunctionRegistry) removeAliases(name string) {
+	for alias, owner := range fr.aliases {
+		if owner == name {
+			delete(fr.aliases, alias)
+		}
+	}
+}
+
+// resolve returns the name of the function that name or an alias refers to.
+func (fr *FunctionRegistry) resolve(name string) string {
+	if owner, ok := fr.aliases[name]; ok {
+		return owner
+	}
+	return name
+}
+
 func (fr *FunctionRegistry) Unregister(name string) {
 	fr.mu.Lock()
 	defer fr.mu.Unlock()
 
+	fr.removeAliases(name)
 	delete(fr.functions, name)
 	delete(fr.metadata, name)
 }
@@ -134,7 +267,7 @@ func (fr *FunctionRegistry) Get(name string) (any, bool) {
 	fr.mu.RLock()
 	defer fr.mu.RUnlock()
 
-	fn, exists := fr.functions[name]
+	fn, exists := fr.functions[fr.resolve(name)]
 	return fn, exists
 }
 
@@ -142,10 +275,32 @@ func (fr *FunctionRegistry) GetMetadata(name string) (FunctionMetadata, bool) {
 	fr.mu.RLock()
 	defer fr.mu.RUnlock()
 
-	meta, exists := fr.metadata[name]
+	meta, exists := fr.metadata[fr.resolve(name)]
 	return meta, exists
 }
 
+// Deprecated returns the names templates call deprecated functions by,
+// including aliases, mapped to their replacements, or to "" for functions
+// deprecated without one.
+func (fr *FunctionRegistry) Deprecated() map[string]string {
+	fr.mu.RLock()
+	defer fr.mu.RUnlock()
+
+	deprecated := make(map[string]string)
+	for name, meta := range fr.metadata {
+		if !meta.Deprecated {
+			continue
+		}
+		if _, ok := fr.functions[name]; ok && !strings.Contains(name, ".") {
+			deprecated[name] = meta.Replacement
+		}
+		for _, alias := range meta.Aliases {
+			deprecated[alias] = meta.Replacement
+		}
+	}
+	return deprecated
+}
+
 func (fr *FunctionRegistry) List() []string {
 	fr.mu.RLock()
 	defer fr.mu.RUnlock()
@@ -163,6 +318,10 @@ func (fr *FunctionRegistry) ListByCategory() map[string][]string {
 	fr.mu.RLock()
 	defer fr.mu.RUnlock()
 
+	return fr.listByCategory()
+}
+
+func (fr *FunctionRegistry) listByCategory() map[string][]string {
 	categories := make(map[string][]string)
 
 	for name, meta := range fr.metadata {
@@ -184,28 +343,82 @@ func (fr *FunctionRegistry) GetFuncMap() template.FuncMap {
 	fr.mu.RLock()
 	defer fr.mu.RUnlock()
 
-	funcMap := make(template.FuncMap)
-	maps.Copy(funcMap, fr.functions)
+	return fr.funcMap(Unrestricted)
+}
+
+// SandboxedFuncMap returns the functions profile allows, in the same form
+// as GetFuncMap. Aliases of removed functions are removed too.
+func (fr *FunctionRegistry) SandboxedFuncMap(profile SandboxProfile) template.FuncMap {
+	fr.mu.RLock()
+	defer fr.mu.RUnlock()
 
-	return funcMap
+	return fr.funcMap(profile)
 }
 
 func (fr *FunctionRegistry) MergeFuncMap(external template.FuncMap) template.FuncMap {
 	fr.mu.RLock()
 	defer fr.mu.RUnlock()
 
-	funcMap := make(template.FuncMap)
-
-	maps.Copy(funcMap, fr.functions)
+	funcMap := fr.funcMap(Unrestricted)
 
 	maps.Copy(funcMap, external)
 
 	return funcMap
 }
 
+// funcMap returns the functions profile allows as templates see them:
+// functions outside a namespace and aliases by name, and each namespace as a
+// function returning a map of its functions.
+func (fr *FunctionRegistry) funcMap(profile SandboxProfile) template.FuncMap {
+	funcMap := make(template.FuncMap, len(fr.functions))
+	namespaces := make(map[string]map[string]any)
+	for name, fn := range fr.functions {
+		if !profile.Allows(name) {
+			continue
+		}
+		namespace, short, ok := strings.Cut(name, ".")
+		if !ok {
+			funcMap[name] = fn
+			continue
+		}
+		if namespaces[namespace] == nil {
+			namespaces[namespace] = make(map[string]any)
+		}
+		namespaces[namespace][short] = fn
+	}
+	for namespace, functions := range namespaces {
+		funcMap[namespace] = func() map[string]any { return functions }
+	}
+	for alias, name := range fr.aliases {
+		if profile.Allows(name) {
+			funcMap[alias] = fr.functions[name]
+		}
+	}
+	return funcMap
+}
+
 func (fr *FunctionRegistry) RegisterDefaults() {
 	defaultFuncs := DefaultFuncMap()
 
+	fr.Register("extends", defaultFuncs["extends"],
+		WithDescription("Render this template through a layout, replacing the layout's blocks with this template's defines; must be the first action"),
+		WithCategory("template"),
+		WithParameters(ParamInfo{Name: "layout", Type: "string", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`{{ extends "base.go.tmpl" }}{{ define "body" }}...{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("include", defaultFuncs["include"],
+		WithDescription("Render another template file with the given data and insert the result"),
+		WithCategory("template"),
+		WithParameters(
+			ParamInfo{Name: "path", Type: "string", Required: true},
+			ParamInfo{Name: "data", Type: "interface{}", Required: true},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ include "license" .Year }}`),
+		WithSince("1.0.0"))
+
 	fr.Register("snake", defaultFuncs["snake"],
 		WithDescription("Convert string to snake_case"),
 		WithCategory("string"),
@@ -238,6 +451,81 @@ func (fr *FunctionRegistry) RegisterDefaults() {
 		WithExamples(`{{ "HelloWorld" | kebab }} // hello-world`),
 		WithSince("1.0.0"))
 
+	fr.Register("slug", defaultFuncs["slug"],
+		WithDescription("Lower-case URL-safe slug with accented letters transliterated to ASCII"),
+		WithCategory("string"),
+		WithParameters(ParamInfo{Name: "input", Type: "string", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`{{ "Crème Brûlée!" | slug }} // creme-brulee`),
+		WithSince("1.0.0"))
+
+	fr.Register("sanitizeIdent", defaultFuncs["sanitizeIdent"],
+		WithDescription("Make a string a valid identifier in go, python, typescript, javascript, java or rust, escaping reserved words"),
+		WithCategory("string"),
+		WithParameters(
+			ParamInfo{Name: "lang", Type: "string", Required: true},
+			ParamInfo{Name: "input", Type: "string", Required: true},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ sanitizeIdent "go" "2nd Address" }} // _2nd_Address`, `{{ .Name | pascal | sanitizeIdent "go" }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("sanitizeIdents", defaultFuncs["sanitizeIdents"],
+		WithDescription("Sanitize a list of strings as identifiers, failing if two become the same identifier"),
+		WithCategory("string"),
+		WithParameters(
+			ParamInfo{Name: "lang", Type: "string", Required: true},
+			ParamInfo{Name: "list", Type: "[]string", Required: true},
+		),
+		WithReturnType("[]string"),
+		WithExamples(`{{ range sanitizeIdents "python" .Columns }}{{ . }} = None{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("wrapComment", defaultFuncs["wrapComment"],
+		WithDescription("Word-wrap text into comment lines of at most width characters, keeping paragraphs and indented lines"),
+		WithCategory("string"),
+		WithParameters(
+			ParamInfo{Name: "width", Type: "int", Required: true},
+			ParamInfo{Name: "prefix", Type: "string", Required: true},
+			ParamInfo{Name: "text", Type: "string", Required: true},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ wrapComment 80 "//" .Description }}`, `{{ .Summary | wrapComment 72 "#" }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("levenshtein", defaultFuncs["levenshtein"],
+		WithDescription("Number of single-character edits between two strings"),
+		WithCategory("string"),
+		WithParameters(
+			ParamInfo{Name: "a", Type: "string", Required: true},
+			ParamInfo{Name: "b", Type: "string", Required: true},
+		),
+		WithReturnType("int"),
+		WithExamples(`{{ levenshtein "kitten" "sitting" }} // 3`),
+		WithSince("1.0.0"))
+
+	fr.Register("similarity", defaultFuncs["similarity"],
+		WithDescription("Similarity of two strings from 0 to 1, based on their Levenshtein distance"),
+		WithCategory("string"),
+		WithParameters(
+			ParamInfo{Name: "a", Type: "string", Required: true},
+			ParamInfo{Name: "b", Type: "string", Required: true},
+		),
+		WithReturnType("float64"),
+		WithExamples(`{{ similarity "color" "colour" }} // 0.8333333333333334`),
+		WithSince("1.0.0"))
+
+	fr.Register("closestMatch", defaultFuncs["closestMatch"],
+		WithDescription("Element of a list nearest to a string, ignoring case, or empty if none is close enough to suggest"),
+		WithCategory("string"),
+		WithParameters(
+			ParamInfo{Name: "list", Type: "[]string", Required: true},
+			ParamInfo{Name: "s", Type: "string", Required: true},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ closestMatch .Commands "stauts" }} // status`, `{{ with closestMatch .Fields .Name }}did you mean {{ . }}?{{ end }}`),
+		WithSince("1.0.0"))
+
 	fr.Register("formatSlice", defaultFuncs["formatSlice"],
 		WithDescription("Format slice elements with separator and format string"),
 		WithCategory("collection"),
@@ -260,8 +548,251 @@ func (fr *FunctionRegistry) RegisterDefaults() {
 		WithReturnType("[]interface{}"),
 		WithSince("1.0.0"))
 
+	fr.Register("chunk", defaultFuncs["chunk"],
+		WithDescription("Split a slice into slices of at most size elements"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "slice", Type: "[]interface{}", Required: true},
+			ParamInfo{Name: "size", Type: "int", Required: true},
+		),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ range chunk .Items 3 }}{{ join . ", " }}{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("sortBy", defaultFuncs["sortBy"],
+		WithDescription("Stably sort a slice by a field or lookup path, with missing values last"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "field", Type: "string", Required: true},
+			ParamInfo{Name: "slice", Type: "[]interface{}", Required: true},
+		),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ range sortBy "Name" .Fields }}{{ .Name }}{{ end }}`, `{{ .Repos | sortByDesc "Stars" }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("sortByDesc", defaultFuncs["sortByDesc"],
+		WithDescription("Stably sort a slice by a field or lookup path in descending order, with missing values last"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "field", Type: "string", Required: true},
+			ParamInfo{Name: "slice", Type: "[]interface{}", Required: true},
+		),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ range sortByDesc "Priority" .Tasks }}{{ .Title }}{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("sortDesc", defaultFuncs["sortDesc"],
+		WithDescription("Stably sort a slice in descending order"),
+		WithCategory("collection"),
+		WithParameters(ParamInfo{Name: "slice", Type: "[]interface{}", Required: true}),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ .Versions | sortDesc }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("sortWith", defaultFuncs["sortWith"],
+		WithDescription("Stably sort a slice with a less function"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "less", Type: "func(interface{}, interface{}) bool", Required: true},
+			ParamInfo{Name: "slice", Type: "[]interface{}", Required: true},
+		),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ sortWith .ByPriority .Tasks }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("dict", defaultFuncs["dict"],
+		WithDescription("Build a map from alternating string keys and values"),
+		WithCategory("collection"),
+		WithParameters(ParamInfo{Name: "pairs", Type: "...interface{}", Required: false}),
+		WithReturnType("map[string]interface{}"),
+		WithExamples(`{{ template "field" (dict "name" .Name "retries" 3) }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("list", defaultFuncs["list"],
+		WithDescription("Build a list from its arguments"),
+		WithCategory("collection"),
+		WithParameters(ParamInfo{Name: "items", Type: "...interface{}", Required: false}),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ range list "create" "update" "delete" }}{{ . }}{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("set", defaultFuncs["set"],
+		WithDescription("Set a key in a map and return the map"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "map", Type: "map[string]interface{}", Required: true},
+			ParamInfo{Name: "key", Type: "string", Required: true},
+			ParamInfo{Name: "value", Type: "interface{}", Required: true},
+		),
+		WithReturnType("map[string]interface{}"),
+		WithExamples(`{{ $_ := set $opts "retries" 5 }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("merge", defaultFuncs["merge"],
+		WithDescription("Merge maps into the first map, keeping keys it already has and merging nested maps"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "dst", Type: "map[string]interface{}", Required: true},
+			ParamInfo{Name: "sources", Type: "...map[string]interface{}", Required: false},
+		),
+		WithReturnType("map[string]interface{}"),
+		WithExamples(`{{ $opts := merge (dict "retries" 5) .Defaults }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("deepCopy", defaultFuncs["deepCopy"],
+		WithDescription("Copy a value, recursively copying maps and slices"),
+		WithCategory("collection"),
+		WithParameters(ParamInfo{Name: "value", Type: "interface{}", Required: true}),
+		WithReturnType("interface{}"),
+		WithExamples(`{{ $opts := merge (deepCopy .Overrides) .Defaults }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("pluck", defaultFuncs["pluck"],
+		WithDescription("Get a field or lookup path from each element of a slice, skipping missing values"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "field", Type: "string", Required: true},
+			ParamInfo{Name: "slice", Type: "[]interface{}", Required: true},
+		),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ join (pluck "Name" .Columns) ", " }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("groupByField", defaultFuncs["groupByField"],
+		WithDescription("Group the elements of a slice by the value of a field or lookup path"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "field", Type: "string", Required: true},
+			ParamInfo{Name: "slice", Type: "[]interface{}", Required: true},
+		),
+		WithReturnType("map[string]interface{}"),
+		WithExamples(`{{ range $category, $items := groupByField "Category" .Items }}{{ $category }}: {{ len $items }}{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("filterBy", defaultFuncs["filterBy"],
+		WithDescription("Keep the elements whose field compares to a value with eq, ne, lt, le, gt, ge, contains or in"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "slice", Type: "[]interface{}", Required: true},
+			ParamInfo{Name: "field", Type: "string", Required: true},
+			ParamInfo{Name: "op", Type: "string", Required: true},
+			ParamInfo{Name: "value", Type: "interface{}", Required: true},
+		),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ range filterBy .Items "Status" "eq" "active" }}{{ .Name }}{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("partitionBy", defaultFuncs["partitionBy"],
+		WithDescription("Split a slice into the elements filterBy keeps and the rest"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "slice", Type: "[]interface{}", Required: true},
+			ParamInfo{Name: "field", Type: "string", Required: true},
+			ParamInfo{Name: "op", Type: "string", Required: true},
+			ParamInfo{Name: "value", Type: "interface{}", Required: true},
+		),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ $parts := partitionBy .Fields "Required" "eq" true }}{{ index $parts 0 }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("where", defaultFuncs["where"],
+		WithDescription(`Keep the elements matching a condition such as "Age > 30" or "!Deprecated"`),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "slice", Type: "[]interface{}", Required: true},
+			ParamInfo{Name: "expr", Type: "string", Required: true},
+		),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ range where .Users "Age >= 18" }}{{ .Name }}{{ end }}`, `{{ where .Items "Kind in [table, view]" }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("keys", defaultFuncs["keys"],
+		WithDescription("Get the sorted keys of a map"),
+		WithCategory("collection"),
+		WithParameters(ParamInfo{Name: "map", Type: "map[string]interface{}", Required: true}),
+		WithReturnType("[]string"),
+		WithExamples(`{{ join (keys .Headers) ", " }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("values", defaultFuncs["values"],
+		WithDescription("Get the values of a map in the order of its sorted keys"),
+		WithCategory("collection"),
+		WithParameters(ParamInfo{Name: "map", Type: "map[string]interface{}", Required: true}),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ range values .Services }}{{ .Name }}{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("hasKey", defaultFuncs["hasKey"],
+		WithDescription("Check whether a map has a key, even if its value is nil"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "map", Type: "map[string]interface{}", Required: true},
+			ParamInfo{Name: "key", Type: "string", Required: true},
+		),
+		WithReturnType("bool"),
+		WithExamples(`{{ if hasKey .Annotations "deprecated" }}// Deprecated{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("pick", defaultFuncs["pick"],
+		WithDescription("Copy a map keeping only the given keys"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "map", Type: "map[string]interface{}", Required: true},
+			ParamInfo{Name: "keys", Type: "...string", Required: false},
+		),
+		WithReturnType("map[string]interface{}"),
+		WithExamples(`{{ range $k, $v := pick .Labels "app" "team" }}{{ $k }}: {{ $v }}{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("omit", defaultFuncs["omit"],
+		WithDescription("Copy a map without the given keys"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "map", Type: "map[string]interface{}", Required: true},
+			ParamInfo{Name: "keys", Type: "...string", Required: false},
+		),
+		WithReturnType("map[string]interface{}"),
+		WithExamples(`{{ range $k, $v := omit .Headers "Authorization" }}{{ $k }}{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("mergeMaps", defaultFuncs["mergeMaps"],
+		WithDescription("Merge maps into a new map in which later maps win, merging nested maps"),
+		WithCategory("collection"),
+		WithParameters(ParamInfo{Name: "maps", Type: "...map[string]interface{}", Required: false}),
+		WithReturnType("map[string]interface{}"),
+		WithExamples(`{{ $headers := mergeMaps .DefaultHeaders .Headers }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("toPairs", defaultFuncs["toPairs"],
+		WithDescription("List the entries of a map as key/value maps sorted by key"),
+		WithCategory("collection"),
+		WithParameters(ParamInfo{Name: "map", Type: "map[string]interface{}", Required: true}),
+		WithReturnType("[]map[string]interface{}"),
+		WithExamples(`{{ range toPairs .Labels }}{{ .key }}={{ .value }} {{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("fromPairs", defaultFuncs["fromPairs"],
+		WithDescription("Build a map from [key value] lists or key/value maps"),
+		WithCategory("collection"),
+		WithParameters(ParamInfo{Name: "pairs", Type: "[]interface{}", Required: true}),
+		WithReturnType("map[string]interface{}"),
+		WithExamples(`{{ $m := fromPairs (list (list "a" 1) (list "b" 2)) }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("lookup", defaultFuncs["lookup"],
+		WithDescription("Get the value at a dotted path or JSON Pointer, or a default when any step is missing or nil"),
+		WithCategory("collection"),
+		WithParameters(
+			ParamInfo{Name: "path", Type: "string", Required: true},
+			ParamInfo{Name: "data", Type: "interface{}", Required: true},
+			ParamInfo{Name: "default", Type: "interface{}", Required: false},
+		),
+		WithReturnType("interface{}"),
+		WithExamples(`{{ lookup "server.tls.port" .Config 443 }}`, `{{ lookup "servers[0].host" . }}`, `{{ lookup "/paths/~1users/get/summary" .Spec "" }}`),
+		WithSince("1.0.0"))
+
 	fr.Register("plural", defaultFuncs["plural"],
-		WithDescription("Convert word to plural form"),
+		WithDescription("Convert the last word of an identifier to plural form, keeping its casing"),
 		WithCategory("string"),
 		WithParameters(ParamInfo{Name: "word", Type: "string", Required: true}),
 		WithReturnType("string"),
@@ -269,7 +800,7 @@ func (fr *FunctionRegistry) RegisterDefaults() {
 		WithSince("1.0.0"))
 
 	fr.Register("singular", defaultFuncs["singular"],
-		WithDescription("Convert word to singular form"),
+		WithDescription("Convert the last word of an identifier to singular form, keeping its casing"),
 		WithCategory("string"),
 		WithParameters(ParamInfo{Name: "word", Type: "string", Required: true}),
 		WithReturnType("string"),
@@ -287,8 +818,90 @@ func (fr *FunctionRegistry) RegisterDefaults() {
 		WithExamples(`{{ default "unknown" .Name }}`),
 		WithSince("1.0.0"))
 
-	fr.Register("add", defaultFuncs["add"],
-		WithDescription("Add two numbers"),
+	fr.Register("coalesce", defaultFuncs["coalesce"],
+		WithDescription("Return the first argument that is neither nil nor an empty string"),
+		WithCategory("utility"),
+		WithParameters(ParamInfo{Name: "values", Type: "...interface{}", Required: true}),
+		WithReturnType("interface{}"),
+		WithExamples(`{{ coalesce .DisplayName .Name "anonymous" }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("ternary", defaultFuncs["ternary"],
+		WithDescription("Return the second argument if the condition is true, otherwise the third"),
+		WithCategory("utility"),
+		WithParameters(
+			ParamInfo{Name: "condition", Type: "bool", Required: true},
+			ParamInfo{Name: "trueVal", Type: "interface{}", Required: true},
+			ParamInfo{Name: "falseVal", Type: "interface{}", Required: true},
+		),
+		WithReturnType("interface{}"),
+		WithExamples(`{{ ternary .Field.Required "" "?" }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("empty", defaultFuncs["empty"],
+		WithDescription("Report whether a value is nil, false, zero or an empty string, slice or map"),
+		WithCategory("utility"),
+		WithParameters(ParamInfo{Name: "value", Type: "interface{}", Required: true}),
+		WithReturnType("bool"),
+		WithExamples(`{{ if empty .Fields }}// no fields{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("fromJSON", defaultFuncs["fromJSON"],
+		WithDescription("Parse a JSON document into maps, slices and scalars"),
+		WithCategory("encoding"),
+		WithParameters(ParamInfo{Name: "document", Type: "string", Required: true}),
+		WithReturnType("interface{}"),
+		WithExamples(`{{ $opts := fromJSON .Options }}{{ $opts.retries }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("fromYAML", defaultFuncs["fromYAML"],
+		WithDescription("Parse a YAML document into maps, slices and scalars"),
+		WithCategory("encoding"),
+		WithParameters(ParamInfo{Name: "document", Type: "string", Required: true}),
+		WithReturnType("interface{}"),
+		WithExamples(`{{ range (fromYAML .Config).servers }}{{ .host }}{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("fromCSV", defaultFuncs["fromCSV"],
+		WithDescription("Parse a CSV document whose first row is the header into a list of maps from column to cell"),
+		WithCategory("encoding"),
+		WithParameters(ParamInfo{Name: "document", Type: "string", Required: true}),
+		WithReturnType("[]map[string]interface{}"),
+		WithExamples(`{{ range fromCSV .Seed }}INSERT INTO users (name) VALUES ({{ sqlQuoteString "postgres" .name }});{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("toCSV", defaultFuncs["toCSV"],
+		WithDescription("Render a list of rows as CSV; map and struct rows get a header row of the given columns, or of every key or field"),
+		WithCategory("encoding"),
+		WithParameters(
+			ParamInfo{Name: "rows", Type: "[]interface{}", Required: true},
+			ParamInfo{Name: "columns", Type: "...string", Required: false},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ toCSV .Users "id" "email" }}`, `{{ toCSV (list (list "a" "b") (list 1 2)) }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("toXML", defaultFuncs["toXML"],
+		WithDescription("Serialize a struct or map as an indented XML element; map keys starting with @ become attributes and #text becomes character data"),
+		WithCategory("encoding"),
+		WithParameters(
+			ParamInfo{Name: "root", Type: "string", Required: true},
+			ParamInfo{Name: "value", Type: "interface{}", Required: true},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ toXML "dependencies" (dict "dependency" .Dependencies) }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("xmlEscape", defaultFuncs["xmlEscape"],
+		WithDescription("Escape text for XML character data or attribute values"),
+		WithCategory("encoding"),
+		WithParameters(ParamInfo{Name: "value", Type: "interface{}", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`<description>{{ xmlEscape .Description }}</description>`),
+		WithSince("1.0.0"))
+
+	fr.Register("add", defaultFuncs["add"],
+		WithDescription("Add two numbers"),
 		WithCategory("math"),
 		WithParameters(
 			ParamInfo{Name: "a", Type: "number", Required: true},
@@ -298,12 +911,267 @@ func (fr *FunctionRegistry) RegisterDefaults() {
 		WithExamples(`{{ add 5 3 }} // 8`),
 		WithSince("1.0.0"))
 
+	fr.Register("sub", defaultFuncs["sub"],
+		WithDescription("Subtract the second number from the first"),
+		WithCategory("math"),
+		WithParameters(
+			ParamInfo{Name: "a", Type: "number", Required: true},
+			ParamInfo{Name: "b", Type: "number", Required: true},
+		),
+		WithReturnType("number"),
+		WithExamples(`{{ sub .Count 1 }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("mul", defaultFuncs["mul"],
+		WithDescription("Multiply two numbers"),
+		WithCategory("math"),
+		WithParameters(
+			ParamInfo{Name: "a", Type: "number", Required: true},
+			ParamInfo{Name: "b", Type: "number", Required: true},
+		),
+		WithReturnType("number"),
+		WithExamples(`{{ mul .PageSize 2 }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("div", defaultFuncs["div"],
+		WithDescription("Divide the first number by the second; dividing by zero is an error"),
+		WithCategory("math"),
+		WithParameters(
+			ParamInfo{Name: "a", Type: "number", Required: true},
+			ParamInfo{Name: "b", Type: "number", Required: true},
+		),
+		WithReturnType("number"),
+		WithExamples(`{{ div 7 2 }} // 3.5`),
+		WithSince("1.0.0"))
+
+	fr.Register("mod", defaultFuncs["mod"],
+		WithDescription("Remainder of dividing two integers"),
+		WithCategory("math"),
+		WithParameters(
+			ParamInfo{Name: "a", Type: "number", Required: true},
+			ParamInfo{Name: "b", Type: "number", Required: true},
+		),
+		WithReturnType("number"),
+		WithExamples(`{{ if eq (mod $i 2) 0 }}even{{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("max", defaultFuncs["max"],
+		WithDescription("Largest of several numbers or of a list of numbers"),
+		WithCategory("math"),
+		WithParameters(ParamInfo{Name: "values", Type: "...number", Required: true}),
+		WithReturnType("number"),
+		WithExamples(`{{ max 3 7 5 }} // 7`, `{{ max .Sizes }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("min", defaultFuncs["min"],
+		WithDescription("Smallest of several numbers or of a list of numbers"),
+		WithCategory("math"),
+		WithParameters(ParamInfo{Name: "values", Type: "...number", Required: true}),
+		WithReturnType("number"),
+		WithExamples(`{{ min 3 7 5 }} // 3`, `{{ min .Sizes }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("ceil", defaultFuncs["ceil"],
+		WithDescription("Round a number up to a whole number"),
+		WithCategory("math"),
+		WithParameters(ParamInfo{Name: "value", Type: "number", Required: true}),
+		WithReturnType("number"),
+		WithExamples(`{{ ceil (div .Total .PageSize) }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("floor", defaultFuncs["floor"],
+		WithDescription("Round a number down to a whole number"),
+		WithCategory("math"),
+		WithParameters(ParamInfo{Name: "value", Type: "number", Required: true}),
+		WithReturnType("number"),
+		WithExamples(`{{ floor 2.7 }} // 2`),
+		WithSince("1.0.0"))
+
+	fr.Register("round", defaultFuncs["round"],
+		WithDescription("Round a number half away from zero, optionally to a number of decimal places"),
+		WithCategory("math"),
+		WithParameters(
+			ParamInfo{Name: "value", Type: "number", Required: true},
+			ParamInfo{Name: "places", Type: "int", Required: false, Default: "0"},
+		),
+		WithReturnType("number"),
+		WithExamples(`{{ round 2.5 }} // 3`, `{{ round 3.14159 2 }} // 3.14`),
+		WithSince("1.0.0"))
+
+	fr.Register("formatNumber", defaultFuncs["formatNumber"],
+		WithDescription("Format a number with commas between thousands, optionally to a number of decimal places"),
+		WithCategory("math"),
+		WithParameters(
+			ParamInfo{Name: "value", Type: "number", Required: true},
+			ParamInfo{Name: "decimals", Type: "int", Required: false},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ formatNumber 1234567 }} // 1,234,567`, `{{ formatNumber 1234.5 2 }} // 1,234.50`),
+		WithSince("1.0.0"))
+
+	fr.Register("humanizeBytes", defaultFuncs["humanizeBytes"],
+		WithDescription("Format a size in bytes with binary units"),
+		WithCategory("math"),
+		WithParameters(ParamInfo{Name: "bytes", Type: "number", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`{{ humanizeBytes 1536 }} // 1.5 KiB`, `{{ humanizeBytes .MaxUpload }}`),
+		WithSince("1.0.0"))
+
 	fr.Register("now", defaultFuncs["now"],
 		WithDescription("Get current time"),
 		WithCategory("time"),
 		WithReturnType("time.Time"),
 		WithExamples(`{{ now.Format "2006-01-02" }}`),
 		WithSince("1.0.0"))
+
+	fr.Register("fixedNow", defaultFuncs["fixedNow"],
+		WithDescription("Time the function map was created, shared by every file of a run; the pinned time in deterministic mode"),
+		WithCategory("time"),
+		WithReturnType("time.Time"),
+		WithExamples(`// Generated at {{ fixedNow | dateFormat "rfc3339" }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("dateFormat", defaultFuncs["dateFormat"],
+		WithDescription("Format a time, Unix seconds or RFC 3339 string with a Go layout or a named layout: rfc3339, rfc3339nano, rfc1123, date, datetime, time or migration"),
+		WithCategory("time"),
+		WithParameters(
+			ParamInfo{Name: "layout", Type: "string", Required: true},
+			ParamInfo{Name: "time", Type: "interface{}", Required: true},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ fixedNow | dateFormat "migration" }}_create_users.sql`, `{{ dateFormat "Jan 2, 2006" .ReleasedAt }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("dateParse", defaultFuncs["dateParse"],
+		WithDescription("Parse a time with a Go layout or a named layout"),
+		WithCategory("time"),
+		WithParameters(
+			ParamInfo{Name: "layout", Type: "string", Required: true},
+			ParamInfo{Name: "value", Type: "string", Required: true},
+		),
+		WithReturnType("time.Time"),
+		WithExamples(`{{ dateParse "date" "2024-03-01" }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("dateAdd", defaultFuncs["dateAdd"],
+		WithDescription("Add a Go duration or a number of calendar days (d), weeks (w), months (mo) or years (y) to a time"),
+		WithCategory("time"),
+		WithParameters(
+			ParamInfo{Name: "duration", Type: "string", Required: true},
+			ParamInfo{Name: "time", Type: "interface{}", Required: true},
+		),
+		WithReturnType("time.Time"),
+		WithExamples(`{{ fixedNow | dateAdd "90d" | dateFormat "date" }}`, `{{ dateAdd "-36h" .CreatedAt }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("dateInUTC", defaultFuncs["dateInUTC"],
+		WithDescription("Convert a time to UTC"),
+		WithCategory("time"),
+		WithParameters(ParamInfo{Name: "time", Type: "interface{}", Required: true}),
+		WithReturnType("time.Time"),
+		WithExamples(`{{ .CreatedAt | dateInUTC | dateFormat "rfc3339" }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("unixToTime", defaultFuncs["unixToTime"],
+		WithDescription("Convert Unix seconds to a time in UTC"),
+		WithCategory("time"),
+		WithParameters(ParamInfo{Name: "seconds", Type: "int", Required: true}),
+		WithReturnType("time.Time"),
+		WithExamples(`{{ unixToTime .Expires | dateFormat "datetime" }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("zeroValue", defaultFuncs["zeroValue"],
+		WithDescription("Go expression for the zero value of a type; named types other than well-known ones are assumed to be structs"),
+		WithCategory("gotype"),
+		WithParameters(ParamInfo{Name: "type", Type: "string", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`{{ zeroValue "int64" }} // 0`, `{{ zeroValue "*User" }} // nil`, `{{ zeroValue "User" }} // User{}`),
+		WithSince("1.0.0"))
+
+	fr.Register("isPointer", defaultFuncs["isPointer"],
+		WithDescription("Report whether a Go type is a pointer type"),
+		WithCategory("gotype"),
+		WithParameters(ParamInfo{Name: "type", Type: "string", Required: true}),
+		WithReturnType("bool"),
+		WithExamples(`{{ if isPointer .Type }}if v != nil {{ end }}`),
+		WithSince("1.0.0"))
+
+	fr.Register("goName", defaultFuncs["goName"],
+		WithDescription("Convert a name to an exported Go identifier, using Go's initialisms"),
+		WithCategory("gotype"),
+		WithParameters(ParamInfo{Name: "name", Type: "string", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`{{ goName "user_id" }} // UserID`, `{{ goName "api-url" }} // APIURL`),
+		WithSince("1.0.0"))
+
+	fr.Register("receiverName", defaultFuncs["receiverName"],
+		WithDescription("Conventional receiver name for methods on a type"),
+		WithCategory("gotype"),
+		WithParameters(ParamInfo{Name: "type", Type: "string", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`func ({{ receiverName .Type }} *{{ .Type }}) Close() error`),
+		WithSince("1.0.0"))
+
+	fr.Register("isBuiltinType", defaultFuncs["isBuiltinType"],
+		WithDescription("Report whether a type is one of Go's predeclared types"),
+		WithCategory("gotype"),
+		WithParameters(ParamInfo{Name: "type", Type: "string", Required: true}),
+		WithReturnType("bool"),
+		WithExamples(`{{ isBuiltinType "int64" }} // true`),
+		WithSince("1.0.0"))
+
+	fr.Register("mapType", defaultFuncs["mapType"],
+		WithDescription("Convert a type name between type systems using the tables registered with DefaultTypeMapper"),
+		WithCategory("gotype"),
+		WithParameters(
+			ParamInfo{Name: "from", Type: "string", Required: true},
+			ParamInfo{Name: "to", Type: "string", Required: true},
+			ParamInfo{Name: "type", Type: "string", Required: true},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ mapType "openapi" "go" "integer/int32" }} // int32`, `{{ mapType "sql" "typescript" "varchar(255)" }} // string`),
+		WithSince("1.0.0"))
+
+	fr.Register("importForType", defaultFuncs["importForType"],
+		WithDescription("Import path of the first package-qualified type in a Go type, or empty if none is needed or the package is unknown"),
+		WithCategory("gotype"),
+		WithParameters(ParamInfo{Name: "type", Type: "string", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`{{ importForType "*time.Time" }} // time`, `{{ importForType "[]uuid.UUID" }} // github.com/google/uuid`),
+		WithSince("1.0.0"))
+
+	fr.Register("sqlQuoteIdent", defaultFuncs["sqlQuoteIdent"],
+		WithDescription("Quote a SQL identifier for a dialect: postgres, mysql, sqlite or mssql"),
+		WithCategory("sql"),
+		WithParameters(
+			ParamInfo{Name: "dialect", Type: "string", Required: true},
+			ParamInfo{Name: "name", Type: "string", Required: true},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ sqlQuoteIdent "postgres" .Table }} // "users"`, `{{ sqlQuoteIdent "mssql" "order" }} // [order]`),
+		WithSince("1.0.0"))
+
+	fr.Register("sqlQuoteString", defaultFuncs["sqlQuoteString"],
+		WithDescription("Format a value as a SQL string literal for a dialect, escaping quotes"),
+		WithCategory("sql"),
+		WithParameters(
+			ParamInfo{Name: "dialect", Type: "string", Required: true},
+			ParamInfo{Name: "value", Type: "interface{}", Required: true},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ sqlQuoteString "postgres" "it's" }} // 'it''s'`),
+		WithSince("1.0.0"))
+
+	fr.Register("sqlPlaceholder", defaultFuncs["sqlPlaceholder"],
+		WithDescription("Bind parameter for the nth argument of a query in a dialect"),
+		WithCategory("sql"),
+		WithParameters(
+			ParamInfo{Name: "dialect", Type: "string", Required: true},
+			ParamInfo{Name: "n", Type: "int", Required: true},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ sqlPlaceholder "postgres" 2 }} // $2`, `{{ sqlPlaceholder "mysql" 2 }} // ?`),
+		WithSince("1.0.0"))
 }
 
 func (fr *FunctionRegistry) RegisterExtended() {
@@ -316,6 +1184,87 @@ func (fr *FunctionRegistry) RegisterExtended() {
 		WithExamples(`{{ uuid }} // 550e8400-e29b-41d4-a716-446655440000`),
 		WithSince("1.1.0"))
 
+	fr.Register("genPassword", extendedFuncs["genPassword"],
+		WithDescription("Generate a random password of letters, digits and symbols from crypto/rand"),
+		WithCategory("crypto"),
+		WithParameters(ParamInfo{Name: "length", Type: "int", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`POSTGRES_PASSWORD={{ genPassword 24 }}`),
+		WithSince("1.1.0"))
+
+	fr.Register("randInt", extendedFuncs["randInt"],
+		WithDescription("Random integer from min up to but not including max, or min if max is not greater"),
+		WithCategory("crypto"),
+		WithParameters(
+			ParamInfo{Name: "min", Type: "int", Required: true},
+			ParamInfo{Name: "max", Type: "int", Required: true},
+		),
+		WithReturnType("int"),
+		WithExamples(`{{ randInt 1024 65536 }}`),
+		WithSince("1.1.0"))
+
+	fr.Register("randString", extendedFuncs["randString"],
+		WithDescription("Random string of letters and digits"),
+		WithCategory("crypto"),
+		WithParameters(ParamInfo{Name: "length", Type: "int", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`{{ randString 12 }}`),
+		WithSince("1.1.0"))
+
+	fr.Register("randHex", extendedFuncs["randHex"],
+		WithDescription("Random bytes, hex-encoded"),
+		WithCategory("crypto"),
+		WithParameters(ParamInfo{Name: "bytes", Type: "int", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`SESSION_KEY={{ randHex 32 }} // 64 hex digits`),
+		WithSince("1.1.0"))
+
+	fr.Register("randBase64", extendedFuncs["randBase64"],
+		WithDescription("Random bytes, base64-encoded"),
+		WithCategory("crypto"),
+		WithParameters(ParamInfo{Name: "bytes", Type: "int", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`secret: {{ randBase64 32 }}`),
+		WithSince("1.1.0"))
+
+	fr.Register("fakeName", extendedFuncs["fakeName"],
+		WithDescription("Random first and last name for fixtures and examples"),
+		WithCategory("fake"),
+		WithReturnType("string"),
+		WithExamples(`{{ fakeName }} // Grace Okafor`),
+		WithSince("1.1.0"))
+
+	fr.Register("fakeEmail", extendedFuncs["fakeEmail"],
+		WithDescription("Email address at a reserved example domain, for the given name or a random one"),
+		WithCategory("fake"),
+		WithParameters(ParamInfo{Name: "name", Type: "string", Required: false}),
+		WithReturnType("string"),
+		WithExamples(`{{ fakeEmail }} // lena.kim@example.org`, `{{ $name := fakeName }}{{ fakeEmail $name }}`),
+		WithSince("1.1.0"))
+
+	fr.Register("fakeAddress", extendedFuncs["fakeAddress"],
+		WithDescription("Random street address in the United States format"),
+		WithCategory("fake"),
+		WithReturnType("string"),
+		WithExamples(`{{ fakeAddress }} // 42 Maple Street, Springfield, OR 97403`),
+		WithSince("1.1.0"))
+
+	fr.Register("fakeUUIDSeq", extendedFuncs["fakeUUIDSeq"],
+		WithDescription("The nth UUID of a readable sequence, for fixtures that refer to records by number"),
+		WithCategory("fake"),
+		WithParameters(ParamInfo{Name: "n", Type: "int", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`{{ fakeUUIDSeq 1 }} // 00000000-0000-4000-8000-000000000001`),
+		WithSince("1.1.0"))
+
+	fr.Register("shuffle", extendedFuncs["shuffle"],
+		WithDescription("Shuffled copy of a list"),
+		WithCategory("collection"),
+		WithParameters(ParamInfo{Name: "list", Type: "[]interface{}", Required: true}),
+		WithReturnType("[]interface{}"),
+		WithExamples(`{{ range shuffle .Cards }}{{ . }}{{ end }}`),
+		WithSince("1.1.0"))
+
 	fr.Register("md5", extendedFuncs["md5"],
 		WithDescription("Calculate MD5 hash of string"),
 		WithCategory("crypto"),
@@ -332,6 +1281,33 @@ func (fr *FunctionRegistry) RegisterExtended() {
 		WithExamples(`{{ "hello" | base64 }}`),
 		WithSince("1.1.0"))
 
+	fr.Register("urlParse", extendedFuncs["urlParse"],
+		WithDescription("Split a URL into scheme, user, host, hostname, port, path, rawQuery, fragment and a query map"),
+		WithCategory("url"),
+		WithParameters(ParamInfo{Name: "url", Type: "string", Required: true}),
+		WithReturnType("map[string]any"),
+		WithExamples(`{{ (urlParse .BaseURL).host }}`, `{{ $u := urlParse "https://api.example.com/v1?page=2" }}{{ $u.query.page }} // 2`),
+		WithSince("1.1.0"))
+
+	fr.Register("urlBuild", extendedFuncs["urlBuild"],
+		WithDescription("Assemble a URL from components named as urlParse returns them"),
+		WithCategory("url"),
+		WithParameters(ParamInfo{Name: "parts", Type: "map[string]any", Required: true}),
+		WithReturnType("string"),
+		WithExamples(`{{ urlBuild (dict "scheme" "https" "host" "api.example.com" "path" "/v1/users" "query" (dict "limit" 10)) }}`),
+		WithSince("1.1.0"))
+
+	fr.Register("urlJoinPath", extendedFuncs["urlJoinPath"],
+		WithDescription("Append path elements to a base URL, keeping its query"),
+		WithCategory("url"),
+		WithParameters(
+			ParamInfo{Name: "base", Type: "string", Required: true},
+			ParamInfo{Name: "elements", Type: "...any", Required: false},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ urlJoinPath .BaseURL "users" (urlPath .ID) }}`, `{{ urlJoinPath "https://api.example.com/v1/" "/orders" }} // https://api.example.com/v1/orders`),
+		WithSince("1.1.0"))
+
 	fr.Register("env", extendedFuncs["env"],
 		WithDescription("Get environment variable value"),
 		WithCategory("system"),
@@ -340,6 +1316,36 @@ func (fr *FunctionRegistry) RegisterExtended() {
 		WithExamples(`{{ env "HOME" }}`),
 		WithSince("1.1.0"))
 
+	fr.Register("semverSatisfies", extendedFuncs["semverSatisfies"],
+		WithDescription("Report whether a version meets a constraint such as \">=1.2.0 <2.0.0\", \"~1.4\", \"^0.3.1\" or \"1.x || 2.x\""),
+		WithCategory("semver"),
+		WithParameters(
+			ParamInfo{Name: "constraint", Type: "string", Required: true},
+			ParamInfo{Name: "version", Type: "string", Required: true},
+		),
+		WithReturnType("bool"),
+		WithExamples(`{{ if semverSatisfies ">=1.21" .GoVersion }}...{{ end }}`, `{{ semverSatisfies "^1.2.0" "1.9.3" }} // true`),
+		WithSince("1.1.0"))
+
+	fr.Register("semverBump", extendedFuncs["semverBump"],
+		WithDescription("Increment a version's major, minor, patch or prerelease part"),
+		WithCategory("semver"),
+		WithParameters(
+			ParamInfo{Name: "level", Type: "string", Required: true},
+			ParamInfo{Name: "version", Type: "string", Required: true},
+		),
+		WithReturnType("string"),
+		WithExamples(`{{ semverBump "minor" "v1.2.3" }} // v1.3.0`, `{{ .Version | semverBump "prerelease" }} // 1.3.0-rc.2 from 1.3.0-rc.1`),
+		WithSince("1.1.0"))
+
+	fr.Register("semverSort", extendedFuncs["semverSort"],
+		WithDescription("Sort versions in ascending order of precedence"),
+		WithCategory("semver"),
+		WithParameters(ParamInfo{Name: "versions", Type: "[]string", Required: true}),
+		WithReturnType("[]string"),
+		WithExamples(`{{ range semverSort .Releases | reverse }}## {{ . }}{{ end }}`),
+		WithSince("1.1.0"))
+
 	fr.Register("regexMatch", extendedFuncs["regexMatch"],
 		WithDescription("Test if string matches regex pattern"),
 		WithCategory("regex"),
@@ -375,6 +1381,10 @@ func (fr *FunctionRegistry) ValidateFunction(name string, fn any) error {
 		return fmt.Errorf("function name cannot be empty")
 	}
 
+	if err := validateFunctionName(name); err != nil {
+		return err
+	}
+
 	if fn == nil {
 		return fmt.Errorf("function cannot be nil")
 	}
@@ -394,59 +1404,87 @@ func (fr *FunctionRegistry) GetDocumentation() string {
 	var doc strings.Builder
 	doc.WriteString("# Template Functions\n\n")
 
-	categories := fr.ListByCategory()
-	categoryOrder := []string{"string", "collection", "math", "time", "utility", "crypto", "encoding", "system", "regex", "general"}
+	categories := fr.listByCategory()
+	for _, category := range orderedCategories(categories) {
+		doc.WriteString(fmt.Sprintf("## %s Functions\n\n", strings.Title(category)))
 
+		for _, name := range categories[category] {
+			writeFunctionDoc(&doc, "###", fr.metadata[name])
+		}
+	}
+
+	return doc.String()
+}
+
+// orderedCategories returns the categories in documentation order: the
+// built-in categories first, then any others alphabetically.
+func orderedCategories(categories map[string][]string) []string {
+	categoryOrder := []string{"string", "collection", "math", "time", "utility", "gotype", "sql", "crypto", "fake", "semver", "encoding", "url", "system", "regex", "plugin", "general"}
+
+	var ordered []string
 	for _, category := range categoryOrder {
-		if functions, exists := categories[category]; exists {
-			doc.WriteString(fmt.Sprintf("## %s Functions\n\n", strings.Title(category)))
-
-			for _, name := range functions {
-				meta := fr.metadata[name]
-				doc.WriteString(fmt.Sprintf("### %s\n\n", name))
-
-				if meta.Description != "" {
-					doc.WriteString(fmt.Sprintf("%s\n\n", meta.Description))
-				}
-
-				if meta.Deprecated {
-					doc.WriteString("**⚠️ Deprecated**\n\n")
-				}
-
-				if len(meta.Parameters) > 0 {
-					doc.WriteString("**Parameters:**\n")
-					for _, param := range meta.Parameters {
-						required := ""
-						if param.Required {
-							required = " (required)"
-						}
-						doc.WriteString(fmt.Sprintf("- `%s` (%s)%s: %s\n",
-							param.Name, param.Type, required, param.Description))
-					}
-					doc.WriteString("\n")
-				}
-
-				if meta.ReturnType != "" {
-					doc.WriteString(fmt.Sprintf("**Returns:** %s\n\n", meta.ReturnType))
-				}
-
-				if len(meta.Examples) > 0 {
-					doc.WriteString("**Examples:**\n")
-					for _, example := range meta.Examples {
-						doc.WriteString(fmt.Sprintf("```\n%s\n```\n\n", example))
-					}
-				}
-
-				if meta.Since != "" {
-					doc.WriteString(fmt.Sprintf("**Since:** %s\n\n", meta.Since))
-				}
-
-				doc.WriteString("---\n\n")
+		if _, exists := categories[category]; exists {
+			ordered = append(ordered, category)
+		}
+	}
+	var others []string
+	for category := range categories {
+		if !slices.Contains(categoryOrder, category) {
+			others = append(others, category)
+		}
+	}
+	sort.Strings(others)
+	return append(ordered, others...)
+}
+
+// writeFunctionDoc writes the Markdown documentation of one function under a
+// heading of the given level, such as "###".
+func writeFunctionDoc(doc *strings.Builder, heading string, meta FunctionMetadata) {
+	doc.WriteString(fmt.Sprintf("%s %s\n\n", heading, meta.Name))
+
+	if meta.Description != "" {
+		doc.WriteString(fmt.Sprintf("%s\n\n", meta.Description))
+	}
+
+	if meta.Deprecated && meta.Replacement != "" {
+		doc.WriteString(fmt.Sprintf("**⚠️ Deprecated:** use `%s` instead\n\n", meta.Replacement))
+	} else if meta.Deprecated {
+		doc.WriteString("**⚠️ Deprecated**\n\n")
+	}
+
+	if len(meta.Aliases) > 0 {
+		doc.WriteString(fmt.Sprintf("**Aliases:** %s\n\n", strings.Join(meta.Aliases, ", ")))
+	}
+
+	if len(meta.Parameters) > 0 {
+		doc.WriteString("**Parameters:**\n")
+		for _, param := range meta.Parameters {
+			required := ""
+			if param.Required {
+				required = " (required)"
 			}
+			doc.WriteString(fmt.Sprintf("- `%s` (%s)%s: %s\n",
+				param.Name, param.Type, required, param.Description))
 		}
+		doc.WriteString("\n")
 	}
 
-	return doc.String()
+	if meta.ReturnType != "" {
+		doc.WriteString(fmt.Sprintf("**Returns:** %s\n\n", meta.ReturnType))
+	}
+
+	if len(meta.Examples) > 0 {
+		doc.WriteString("**Examples:**\n")
+		for _, example := range meta.Examples {
+			doc.WriteString(fmt.Sprintf("```\n%s\n```\n\n", example))
+		}
+	}
+
+	if meta.Since != "" {
+		doc.WriteString(fmt.Sprintf("**Since:** %s\n\n", meta.Since))
+	}
+
+	doc.WriteString("---\n\n")
 }
 
 func (fr *FunctionRegistry) ExportJSON() map[string]FunctionMetadata {
@@ -477,7 +1515,7 @@ func (fr *FunctionRegistry) HasFunction(name string) bool {
 	fr.mu.RLock()
 	defer fr.mu.RUnlock()
 
-	_, exists := fr.functions[name]
+	_, exists := fr.functions[fr.resolve(name)]
 	return exists
 }
 
@@ -487,8 +1525,13 @@ func (fr *FunctionRegistry) GetFunctionSignature(name string) string {
 		return ""
 	}
 
+	return functionSignature(meta)
+}
+
+// functionSignature formats meta as "name(param type, optional? type) -> type".
+func functionSignature(meta FunctionMetadata) string {
 	var sig strings.Builder
-	sig.WriteString(name)
+	sig.WriteString(meta.Name)
 	sig.WriteString("(")
 
 	for i, param := range meta.Parameters {
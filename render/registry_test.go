@@ -0,0 +1,50 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestFunctionRegistryNamespaces(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registry.RegisterDefaults()
+
+	if err := registry.Register("acme.snake", func(s string) string { return "acme_" + s }); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register("acme.upper", strings.ToUpper, WithUnqualifiedAlias()); err != nil {
+		t.Fatal(err)
+	}
+	if meta, ok := registry.GetMetadata("upper"); !ok || meta.Name != "acme.upper" {
+		t.Errorf("GetMetadata(upper) = %+v, %v; want acme.upper", meta, ok)
+	}
+
+	tmpl := template.Must(template.New("t").Funcs(registry.GetFuncMap()).Parse(`{{ call acme.snake "id" }} {{ snake "UserID" }} {{ upper "x" }}`))
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := "acme_id user_id X"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	for name, want := range map[string]string{
+		"strings.snake": `alias "snake" of "strings.snake" collides with function "snake"`,
+		"snake.lower":   `namespace "snake" of "snake.lower" collides with function "snake"`,
+		"acme":          `function "acme" collides with namespace "acme"`,
+		"upper":         `function "upper" collides with an alias of "acme.upper"`,
+		"a.b.c":         "namespaces cannot be nested",
+		"acme.":         `invalid function name "acme."`,
+	} {
+		err := registry.Register(name, strings.ToLower, WithUnqualifiedAlias())
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Register(%q) error = %v, want %q", name, err, want)
+		}
+	}
+
+	registry.Unregister("acme.upper")
+	if registry.HasFunction("upper") {
+		t.Error("Expected unregistering a function to remove its alias")
+	}
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"strings"
+	"text/template"
+)
+
+// SandboxProfile selects which template functions templates may call, so
+// templates from other teams can be rendered without access to the host.
+type SandboxProfile int
+
+const (
+	// Unrestricted allows every function.
+	Unrestricted SandboxProfile = iota
+	// Strict removes the functions that read environment variables or work
+	// with host filesystem paths.
+	Strict
+)
+
+// strictDenied lists the functions Strict removes: the environment
+// functions, which would reveal secrets such as tokens in CI, and the path
+// functions, which only make sense for templates that know the host's
+// layout.
+var strictDenied = wordSet(`
+	env expandEnv hasEnv
+	pathJoin pathBase pathDir pathExt pathClean pathIsAbs
+`)
+
+func (p SandboxProfile) String() string {
+	switch p {
+	case Unrestricted:
+		return "unrestricted"
+	case Strict:
+		return "strict"
+	default:
+		return "unknown"
+	}
+}
+
+// Allows reports whether templates may call the function registered as
+// name. Namespaced functions are judged by their name without the
+// namespace, so "sys.env" is removed along with "env".
+func (p SandboxProfile) Allows(name string) bool {
+	if p == Unrestricted {
+		return true
+	}
+	if _, short, ok := strings.Cut(name, "."); ok {
+		name = short
+	}
+	return !strictDenied[name]
+}
+
+// Restrict returns the functions in funcs that p allows. It returns funcs
+// itself when p is Unrestricted.
+func (p SandboxProfile) Restrict(funcs template.FuncMap) template.FuncMap {
+	if p == Unrestricted {
+		return funcs
+	}
+	restricted := make(template.FuncMap, len(funcs))
+	for name, fn := range funcs {
+		if p.Allows(name) {
+			restricted[name] = fn
+		}
+	}
+	return restricted
+}
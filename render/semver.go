@@ -0,0 +1,297 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var semverPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// semVersion is a parsed semantic version. Unlike parseSemver, which
+// returns 0.0.0 for anything it cannot parse, the functions built on it
+// report invalid versions as errors.
+type semVersion struct {
+	major, minor, patch int
+	prerelease          string
+	metadata            string
+	prefix              string
+}
+
+func newSemVersion(version string) (semVersion, error) {
+	m := semverPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if m == nil {
+		return semVersion{}, fmt.Errorf("invalid semantic version %q", version)
+	}
+	v := semVersion{prerelease: m[4], metadata: m[5]}
+	if strings.HasPrefix(strings.TrimSpace(version), "v") {
+		v.prefix = "v"
+	}
+	v.major, _ = strconv.Atoi(m[1])
+	v.minor, _ = strconv.Atoi(m[2])
+	v.patch, _ = strconv.Atoi(m[3])
+	return v, nil
+}
+
+func (v semVersion) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.prefix, v.major, v.minor, v.patch)
+	if v.prerelease != "" {
+		s += "-" + v.prerelease
+	}
+	if v.metadata != "" {
+		s += "+" + v.metadata
+	}
+	return s
+}
+
+// compare orders versions by semantic version precedence, ignoring build
+// metadata.
+func (v semVersion) compare(o semVersion) int {
+	for _, d := range []int{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d != 0 {
+			return d
+		}
+	}
+	return comparePrerelease(v.prerelease, o.prerelease)
+}
+
+// comparePrerelease compares pre-release identifiers as the semver spec
+// requires: a release is greater than any of its pre-releases, numeric
+// identifiers compare numerically and are lower than alphanumeric ones,
+// and a longer list of identifiers is greater when the shorter is a prefix.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range min(len(as), len(bs)) {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return an - bn
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// semverBump increments version at level: "major", "minor" or "patch",
+// resetting the lower parts and dropping any pre-release, or "prerelease",
+// which increments the last numeric pre-release identifier or starts one
+// on the next patch, so 1.2.3-rc.1 becomes 1.2.3-rc.2 and 1.2.3 becomes
+// 1.2.4-0. Bumping the patch of a pre-release releases it, so 1.2.3-rc.1
+// becomes 1.2.3. A leading "v" is kept and build metadata is dropped.
+func semverBump(level, version string) (string, error) {
+	v, err := newSemVersion(version)
+	if err != nil {
+		return "", err
+	}
+	v.metadata = ""
+
+	switch strings.ToLower(level) {
+	case "major":
+		if v.prerelease == "" || v.minor != 0 || v.patch != 0 {
+			v.major++
+		}
+		v.minor, v.patch = 0, 0
+	case "minor":
+		if v.prerelease == "" || v.patch != 0 {
+			v.minor++
+		}
+		v.patch = 0
+	case "patch":
+		if v.prerelease == "" {
+			v.patch++
+		}
+	case "prerelease", "pre":
+		if v.prerelease == "" {
+			v.patch++
+			v.prerelease = "0"
+			return v.String(), nil
+		}
+		ids := strings.Split(v.prerelease, ".")
+		if n, err := strconv.Atoi(ids[len(ids)-1]); err == nil {
+			ids[len(ids)-1] = strconv.Itoa(n + 1)
+		} else {
+			ids = append(ids, "1")
+		}
+		v.prerelease = strings.Join(ids, ".")
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("unknown version level %q: want major, minor, patch or prerelease", level)
+	}
+	v.prerelease = ""
+	return v.String(), nil
+}
+
+// semverSort returns the versions in list in ascending order of precedence.
+func semverSort(list any) ([]string, error) {
+	items, err := toStringSlice(list)
+	if err != nil {
+		return nil, fmt.Errorf("semverSort: %w", err)
+	}
+	versions := make([]semVersion, len(items))
+	for i, item := range items {
+		if versions[i], err = newSemVersion(item); err != nil {
+			return nil, err
+		}
+	}
+	slices.SortStableFunc(versions, semVersion.compare)
+
+	sorted := make([]string, len(versions))
+	for i, v := range versions {
+		sorted[i] = v.String()
+	}
+	return sorted, nil
+}
+
+// semverSatisfies reports whether version meets constraint. A constraint is
+// one or more ranges separated by "||", each a list of comparisons that
+// must all hold, separated by spaces or commas:
+//
+//   - =, !=, >, >=, < and <= compare with a version, = by default
+//   - ~1.2.3 allows patch changes (>=1.2.3 <1.3.0)
+//   - ^1.2.3 allows changes that keep the leftmost non-zero part
+//     (>=1.2.3 <2.0.0, and ^0.2.3 is >=0.2.3 <0.3.0)
+//   - 1.2.x, 1.x, 1.2 and * match any version with the given parts
+//
+// Missing parts of a version count as zero. As in npm and Cargo, a
+// pre-release only satisfies a range that names a pre-release of the same
+// major, minor and patch version.
+func semverSatisfies(constraint, version string) (bool, error) {
+	v, err := newSemVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	constraint = operatorSpace.ReplaceAllString(constraint, "$1")
+	for _, alternative := range strings.Split(constraint, "||") {
+		comparisons := strings.FieldsFunc(alternative, func(r rune) bool { return r == ' ' || r == ',' })
+		if len(comparisons) == 0 {
+			comparisons = []string{"*"}
+		}
+		ok, allowPre := true, v.prerelease == ""
+		for _, comparison := range comparisons {
+			matched, bound, err := matchComparison(comparison, v)
+			if err != nil {
+				return false, err
+			}
+			ok = ok && matched
+			if bound.prerelease != "" && bound.major == v.major && bound.minor == v.minor && bound.patch == v.patch {
+				allowPre = true
+			}
+		}
+		if ok && allowPre {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// operatorSpace matches the space allowed between an operator and its
+// version, as in ">= 1.2.3".
+var operatorSpace = regexp.MustCompile(`(=|!=|>=|<=|>|<|~|\^)\s+`)
+
+var comparisonPattern = regexp.MustCompile(`^(=|!=|>=|<=|>|<|~|\^)?\s*v?(\*|x|X|\d+)(?:\.(\*|x|X|\d+))?(?:\.(\*|x|X|\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// matchComparison reports whether v meets one comparison, and returns the
+// version the comparison names.
+func matchComparison(comparison string, v semVersion) (bool, semVersion, error) {
+	m := comparisonPattern.FindStringSubmatch(comparison)
+	if m == nil {
+		return false, semVersion{}, fmt.Errorf("invalid version constraint %q", comparison)
+	}
+	op := m[1]
+
+	// parts holds the numeric parts the comparison gives; a wildcard or a
+	// missing part ends them.
+	var parts []int
+	for _, part := range m[2:5] {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	bound := semVersion{prerelease: m[5]}
+	for i, n := range parts {
+		switch i {
+		case 0:
+			bound.major = n
+		case 1:
+			bound.minor = n
+		case 2:
+			bound.patch = n
+		}
+	}
+	if len(parts) < 3 && bound.prerelease != "" {
+		return false, semVersion{}, fmt.Errorf("invalid version constraint %q: pre-release of a partial version", comparison)
+	}
+
+	c := v.compare(bound)
+	switch op {
+	case "", "=":
+		if len(parts) == 3 {
+			return c == 0, bound, nil
+		}
+		return c >= 0 && v.compare(nextVersion(bound, len(parts))) < 0, bound, nil
+	case "!=":
+		return c != 0, bound, nil
+	case ">":
+		if len(parts) < 3 {
+			return v.compare(nextVersion(bound, len(parts))) >= 0, bound, nil
+		}
+		return c > 0, bound, nil
+	case ">=":
+		return c >= 0, bound, nil
+	case "<":
+		return c < 0, bound, nil
+	case "<=":
+		if len(parts) < 3 {
+			return v.compare(nextVersion(bound, len(parts))) < 0, bound, nil
+		}
+		return c <= 0, bound, nil
+	case "~":
+		return c >= 0 && v.compare(nextVersion(bound, max(min(len(parts), 2), 1))) < 0, bound, nil
+	default: // ^
+		keep := 1
+		if bound.major == 0 && len(parts) > 1 {
+			keep = 2
+			if bound.minor == 0 && len(parts) > 2 {
+				keep = 3
+			}
+		}
+		return c >= 0 && v.compare(nextVersion(bound, keep)) < 0, bound, nil
+	}
+}
+
+// nextVersion returns the lowest version above every version that shares
+// the first n parts of v, as a lower bound excluding pre-releases.
+func nextVersion(v semVersion, n int) semVersion {
+	switch n {
+	case 0:
+		return semVersion{major: 1 << 30}
+	case 1:
+		return semVersion{major: v.major + 1, prerelease: "0"}
+	case 2:
+		return semVersion{major: v.major, minor: v.minor + 1, prerelease: "0"}
+	}
+	return semVersion{major: v.major, minor: v.minor, patch: v.patch + 1, prerelease: "0"}
+}
@@ -0,0 +1,19 @@
+package render
+
+import "testing"
+
+func TestSemverFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"semverSatisfies", `{{ range .C }}{{ semverSatisfies . "1.4.2" }} {{ end }}`, map[string]any{"C": []string{"^1.2.0", "~1.3", ">= 1.0.0, < 1.4.0", "1.x || >=3", "^0.4.2", "!=1.4.2", "*"}}, "true false false true false false true "},
+		{"semverSatisfies prerelease", `{{ semverSatisfies ">=1.2.0" "1.3.0-rc.1" }} {{ semverSatisfies ">=1.3.0-beta" "1.3.0-rc.1" }} {{ semverSatisfies "^0.2.3" "0.2.9" }} {{ semverSatisfies "^0.2.3" "0.3.0" }}`, nil, "false true true false"},
+		{"semverBump", `{{ range list "major" "minor" "patch" "prerelease" }}{{ semverBump . "v1.2.3+build.5" }} {{ end }}{{ semverBump "prerelease" "1.2.3-rc.1" }} {{ semverBump "patch" "1.2.3-rc.1" }} {{ semverBump "minor" "2.0.0-beta" }}`, nil, "v2.0.0 v1.3.0 v1.2.4 v1.2.4-0 1.2.3-rc.2 1.2.3 2.0.0"},
+		{"semverSort", `{{ join (semverSort .V) " " }}`, map[string]any{"V": []string{"1.0.0", "v0.9.10", "1.0.0-beta", "1.0.0-alpha.1", "0.9.2", "1.0.0-alpha", "1.0.0-beta.11", "1.0.0-beta.2"}}, "0.9.2 v0.9.10 1.0.0-alpha 1.0.0-alpha.1 1.0.0-beta 1.0.0-beta.2 1.0.0-beta.11 1.0.0"},
+	}
+	errorTests := []funcErrorTest{
+		{"semverSatisfies version", `{{ semverSatisfies "^1.0.0" "1.2" }}`, `invalid semantic version "1.2"`},
+		{"semverSatisfies constraint", `{{ semverSatisfies "~>1.2" "1.2.0" }}`, `invalid version constraint "~>1.2"`},
+		{"semverBump level", `{{ semverBump "build" "1.2.3" }}`, `unknown version level "build"`},
+		{"semverSort version", `{{ semverSort (list "1.0.0" "latest") }}`, `invalid semantic version "latest"`},
+	}
+	runFuncTests(t, tests, errorTests)
+}
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sqlDialect normalises a dialect name for the SQL quoting functions, which
+// accept "postgres", "mysql", "sqlite" and "mssql" in any case, and the
+// aliases "postgresql", "sqlite3" and "sqlserver".
+func sqlDialect(dialect string) (string, error) {
+	switch d := strings.ToLower(strings.TrimSpace(dialect)); d {
+	case "postgres", "postgresql":
+		return "postgres", nil
+	case "mysql":
+		return d, nil
+	case "sqlite", "sqlite3":
+		return "sqlite", nil
+	case "mssql", "sqlserver":
+		return "mssql", nil
+	}
+	return "", fmt.Errorf("unknown SQL dialect %q", dialect)
+}
+
+// sqlQuoteIdent quotes name as a single identifier: "name" for postgres and
+// sqlite, `name` for mysql and [name] for mssql, doubling the closing quote
+// where it appears in name. Quote each part of a qualified name such as
+// schema.table separately.
+func sqlQuoteIdent(dialect, name string) (string, error) {
+	d, err := sqlDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", fmt.Errorf("cannot quote an empty SQL identifier")
+	}
+	if strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("SQL identifier %q contains a NUL byte", name)
+	}
+	switch d {
+	case "mysql":
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`", nil
+	case "mssql":
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]", nil
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`, nil
+}
+
+// sqlQuoteString returns s as a SQL string literal, doubling single quotes.
+// mysql also treats backslashes as escapes, so they are doubled too, and
+// mssql literals are written N'...' to keep non-ASCII text intact.
+func sqlQuoteString(dialect string, s any) (string, error) {
+	d, err := sqlDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	str := toString(s)
+	if strings.ContainsRune(str, 0) {
+		return "", fmt.Errorf("SQL string literal contains a NUL byte")
+	}
+	if d == "mysql" {
+		str = strings.ReplaceAll(str, `\`, `\\`)
+	}
+	quoted := "'" + strings.ReplaceAll(str, "'", "''") + "'"
+	if d == "mssql" {
+		quoted = "N" + quoted
+	}
+	return quoted, nil
+}
+
+// sqlPlaceholder returns the bind parameter for the nth (1-based) argument
+// of a query: $n for postgres, ? for mysql and sqlite, and @pn for mssql.
+func sqlPlaceholder(dialect string, n int) (string, error) {
+	d, err := sqlDialect(dialect)
+	if err != nil {
+		return "", err
+	}
+	if n < 1 {
+		return "", fmt.Errorf("SQL placeholder index must be at least 1, got %d", n)
+	}
+	switch d {
+	case "postgres":
+		return "$" + strconv.Itoa(n), nil
+	case "mssql":
+		return "@p" + strconv.Itoa(n), nil
+	}
+	return "?", nil
+}
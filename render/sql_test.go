@@ -0,0 +1,17 @@
+package render
+
+import "testing"
+
+func TestSQLFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"sqlQuoteIdent", `{{ sqlQuoteIdent "postgres" "user" }} {{ sqlQuoteIdent "sqlite" "a\"b" }} {{ sqlQuoteIdent "MySQL" "a` + "`" + `b" }} {{ sqlQuoteIdent "mssql" "a]b" }}`, nil, `"user" "a""b" ` + "`a``b`" + ` [a]]b]`},
+		{"sqlQuoteString", `{{ sqlQuoteString "postgres" "it's" }} {{ sqlQuoteString "mysql" "a\\b'c" }} {{ sqlQuoteString "mssql" "café" }} {{ sqlQuoteString "sqlite" 42 }}`, nil, `'it''s' 'a\\b''c' N'café' '42'`},
+		{"sqlPlaceholder", `{{ sqlPlaceholder "postgres" 3 }} {{ sqlPlaceholder "postgresql" 1 }} {{ sqlPlaceholder "mysql" 2 }} {{ sqlPlaceholder "sqlite3" 2 }} {{ sqlPlaceholder "sqlserver" 2 }}`, nil, "$3 $1 ? ? @p2"},
+	}
+	errorTests := []funcErrorTest{
+		{"sqlQuoteIdent dialect", `{{ sqlQuoteIdent "oracle" "t" }}`, `unknown SQL dialect "oracle"`},
+		{"sqlQuoteIdent empty", `{{ sqlQuoteIdent "postgres" "" }}`, "cannot quote an empty SQL identifier"},
+		{"sqlPlaceholder index", `{{ sqlPlaceholder "postgres" 0 }}`, "SQL placeholder index must be at least 1, got 0"},
+	}
+	runFuncTests(t, tests, errorTests)
+}
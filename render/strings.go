@@ -2,7 +2,6 @@ package render
 
 import (
 	"crypto/md5"
-	"crypto/rand"
 	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/base64"
@@ -12,13 +11,12 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
-
-	"github.com/google/uuid"
 )
 
 func toSnakeCase(s string) string {
@@ -168,104 +166,11 @@ func splitWords(s string) []string {
 }
 
 func pluralize(word string) string {
-	if word == "" {
-		return ""
-	}
-
-	word = strings.ToLower(word)
-
-	irregularPlurals := map[string]string{
-		"person":     "people",
-		"man":        "men",
-		"woman":      "women",
-		"child":      "children",
-		"tooth":      "teeth",
-		"foot":       "feet",
-		"mouse":      "mice",
-		"goose":      "geese",
-		"ox":         "oxen",
-		"datum":      "data",
-		"medium":     "media",
-		"criterion":  "criteria",
-		"phenomenon": "phenomena",
-	}
-
-	if plural, exists := irregularPlurals[word]; exists {
-		return plural
-	}
-
-	if strings.HasSuffix(word, "s") || strings.HasSuffix(word, "x") ||
-		strings.HasSuffix(word, "z") || strings.HasSuffix(word, "ch") ||
-		strings.HasSuffix(word, "sh") {
-		return word + "es"
-	}
-
-	if strings.HasSuffix(word, "y") && len(word) > 1 {
-		if !strings.ContainsRune("aeiou", rune(word[len(word)-2])) {
-			return word[:len(word)-1] + "ies"
-		}
-	}
-
-	if strings.HasSuffix(word, "f") {
-		return word[:len(word)-1] + "ves"
-	}
-
-	if strings.HasSuffix(word, "fe") {
-		return word[:len(word)-2] + "ves"
-	}
-
-	return word + "s"
+	return DefaultInflector.Pluralize(word)
 }
 
 func singularize(word string) string {
-	if word == "" {
-		return ""
-	}
-
-	word = strings.ToLower(word)
-
-	irregularSingulars := map[string]string{
-		"people":    "person",
-		"men":       "man",
-		"women":     "woman",
-		"children":  "child",
-		"teeth":     "tooth",
-		"feet":      "foot",
-		"mice":      "mouse",
-		"geese":     "goose",
-		"oxen":      "ox",
-		"data":      "datum",
-		"media":     "medium",
-		"criteria":  "criterion",
-		"phenomena": "phenomenon",
-	}
-
-	if singular, exists := irregularSingulars[word]; exists {
-		return singular
-	}
-
-	if strings.HasSuffix(word, "ies") && len(word) > 3 {
-		return word[:len(word)-3] + "y"
-	}
-
-	if strings.HasSuffix(word, "ves") && len(word) > 3 {
-		return word[:len(word)-3] + "f"
-	}
-
-	if strings.HasSuffix(word, "es") && len(word) > 2 {
-		base := word[:len(word)-2]
-		if strings.HasSuffix(base, "s") || strings.HasSuffix(base, "x") ||
-			strings.HasSuffix(base, "z") || strings.HasSuffix(base, "ch") ||
-			strings.HasSuffix(base, "sh") {
-			return base
-		}
-	}
-
-	if strings.HasSuffix(word, "s") && len(word) > 1 {
-		return word[:len(word)-1]
-	}
-
-	return word
+	return DefaultInflector.Singularize(word)
 }
 
 func humanize(s string) string {
@@ -329,8 +234,45 @@ func goComment(text string) string {
 	return comment(text, "//")
 }
 
-func generateUUID() string {
-	return uuid.New().String()
+// wrapComment word-wraps text into comment lines starting with prefix, each
+// at most width characters including the prefix unless a single word is
+// longer. Paragraphs separated by blank lines stay separate, with an empty
+// comment line between them, and indented lines, such as code in a doc
+// comment, are kept as they are.
+func wrapComment(width int, prefix, text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return ""
+	}
+
+	var lines []string
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			wrapped := wrapText(strings.Join(paragraph, " "), width-utf8.RuneCountInString(prefix)-1)
+			for _, line := range strings.Split(wrapped, "\n") {
+				lines = append(lines, prefix+" "+line)
+			}
+			paragraph = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+			if len(lines) > 0 && lines[len(lines)-1] != prefix {
+				lines = append(lines, prefix)
+			}
+		case line[0] == ' ' || line[0] == '\t':
+			flush()
+			lines = append(lines, prefix+" "+strings.TrimRight(line, " \t"))
+		default:
+			paragraph = append(paragraph, strings.TrimSpace(line))
+		}
+	}
+	flush()
+	return strings.Join(lines, "\n")
 }
 
 func calculateMD5(text string) string {
@@ -501,44 +443,6 @@ func pathIsAbs(path string) bool {
 	return filepath.IsAbs(path)
 }
 
-func generatePassword(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*"
-
-	password := make([]byte, length)
-
-	for i := range password {
-		randBytes := make([]byte, 1)
-		rand.Read(randBytes)
-		password[i] = charset[randBytes[0]%byte(len(charset))]
-	}
-
-	return string(password)
-}
-
-func randomInt(min, max int) int {
-	randBytes := make([]byte, 4)
-	rand.Read(randBytes)
-	n := int(randBytes[0])<<24 | int(randBytes[1])<<16 | int(randBytes[2])<<8 | int(randBytes[3])
-	if n < 0 {
-		n = -n
-	}
-	return n%(max-min) + min
-}
-
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-
-	result := make([]byte, length)
-
-	for i := range result {
-		randBytes := make([]byte, 1)
-		rand.Read(randBytes)
-		result[i] = charset[randBytes[0]%byte(len(charset))]
-	}
-
-	return string(result)
-}
-
 func wrapText(text string, width int) string {
 	if width <= 0 {
 		return text
@@ -634,8 +538,7 @@ func padStringRight(s string, width int) string {
 }
 
 func parseSemver(version string) map[string]any {
-	re := regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
-	matches := re.FindStringSubmatch(version)
+	matches := semverPattern.FindStringSubmatch(version)
 
 	if len(matches) < 4 {
 		return map[string]any{
@@ -706,18 +609,87 @@ func semverCompare(v1, v2 string) int {
 		return patch1 - patch2
 	}
 
-	pre1 := p1["prerelease"].(string)
-	pre2 := p2["prerelease"].(string)
+	return comparePrerelease(p1["prerelease"].(string), p2["prerelease"].(string))
+}
 
-	if pre1 == "" && pre2 != "" {
-		return 1
+// levenshtein returns the number of single-character insertions, deletions
+// and substitutions needed to turn a into b.
+func levenshtein(a, b string) int {
+	return editDistance(a, b, false)
+}
+
+// EditDistance returns the optimal string alignment distance between a and
+// b: the Levenshtein distance with a transposition of adjacent runes counted
+// as one edit, so "tpye" is one edit from "type".
+func EditDistance(a, b string) int {
+	return editDistance(a, b, true)
+}
+
+// editDistance counts the edits needed to turn a into b, treating a
+// transposition of adjacent runes as a single edit when transpositions is
+// set.
+func editDistance(a, b string, transpositions bool) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
 	}
-	if pre1 != "" && pre2 == "" {
-		return -1
+	for j := range d[0] {
+		d[0][j] = j
 	}
-	if pre1 != pre2 {
-		return strings.Compare(pre1, pre2)
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if transpositions && i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
 	}
+	return d[len(ra)][len(rb)]
+}
 
-	return 0
+// ClosestName returns the candidate nearest to name by EditDistance,
+// ignoring case, for "did you mean" suggestions. It returns "" when no
+// candidate is within a third of name's length in edits, as such a
+// suggestion would not help. Ties go to the earlier candidate.
+func ClosestName(name string, candidates []string) string {
+	target := strings.ToLower(name)
+	best, bestDist := "", utf8.RuneCountInString(name)/3+1
+	for _, candidate := range candidates {
+		if d := EditDistance(target, strings.ToLower(candidate)); d < bestDist {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+// similarity returns how alike a and b are, from 0 for nothing in common to
+// 1 for identical strings, as one minus their Levenshtein distance over the
+// length of the longer string.
+func similarity(a, b string) float64 {
+	longest := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// closestMatch returns the element of list nearest to s, as chosen by
+// ClosestName.
+func closestMatch(list any, s string) (string, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("closestMatch requires a list, got %T", list)
+	}
+
+	candidates := make([]string, v.Len())
+	for i := range candidates {
+		candidates[i] = toString(v.Index(i).Interface())
+	}
+	return ClosestName(s, candidates), nil
 }
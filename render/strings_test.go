@@ -0,0 +1,27 @@
+package render
+
+import "testing"
+
+func TestStringFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"wrapComment", `{{ wrapComment 24 "//" .Text }}`, map[string]any{"Text": "Lists the users of an account, newest first.\r\n\n\nExample:\n\n\tGET /users\nSee https://example.com/docs/users for details."}, `// Lists the users of an
+// account, newest
+// first.
+//
+// Example:
+//
+// 	GET /users
+// See
+// https://example.com/docs/users
+// for details.`},
+		{"wrapComment empty", `{{ wrapComment 80 "#" "  " }}|{{ "short" | wrapComment 80 "#" }}`, nil, "|# short"},
+		{"levenshtein", `{{ levenshtein "kitten" "sitting" }} {{ levenshtein "" "abc" }} {{ levenshtein "same" "same" }} {{ levenshtein "café" "cafe" }}`, nil, "3 3 0 1"},
+		{"similarity", `{{ similarity "abcd" "abcf" }} {{ similarity "" "" }} {{ similarity "abc" "xyz" }}`, nil, "0.75 1 0"},
+		{"closestMatch", `{{ closestMatch .Commands "stauts" }}|{{ closestMatch .Commands "LIST" }}|{{ closestMatch .Commands "zzzzzz" }}|{{ closestMatch (list "get" "set") "gett" }}`, map[string]any{"Commands": []string{"status", "list", "start"}}, "status|list||get"},
+		{"closestMatch transposition", `{{ closestMatch (list "type" "name") "tpye" }}|{{ levenshtein "tpye" "type" }}`, nil, "type|2"},
+	}
+	errorTests := []funcErrorTest{
+		{"closestMatch list", `{{ closestMatch "abc" "a" }}`, "closestMatch requires a list, got string"},
+	}
+	runFuncTests(t, tests, errorTests)
+}
@@ -0,0 +1,136 @@
+package render
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// TypeMapper converts type names from one type system to another, such as
+// OpenAPI or SQL types to Go or TypeScript types, with tables registered by
+// the caller. It backs the mapType template function:
+//
+//	{{ mapType "openapi" "go" .Type }}
+//
+// A type is looked up in the table for its source and target as given, in
+// lower case, without a parenthesised size ("varchar(255)" as "varchar"),
+// and without an OpenAPI format ("integer/int64" as "integer"). A table may
+// map "*" to the result for types it does not list.
+type TypeMapper struct {
+	mu     sync.RWMutex
+	tables map[[2]string]map[string]string
+}
+
+// DefaultTypeMapper backs the mapType template function. It has tables from
+// "openapi", "jsonschema" and "sql" (the portable types of the sqlschema
+// importer plus common column types) to "go", "typescript" and "python".
+// Register tables with it during initialisation, or create a TypeMapper and
+// use its FuncMap to configure a single engine.
+var DefaultTypeMapper = NewTypeMapper()
+
+// NewTypeMapper returns a TypeMapper with the default tables.
+func NewTypeMapper() *TypeMapper {
+	m := &TypeMapper{tables: make(map[[2]string]map[string]string)}
+	for _, schema := range []string{"openapi", "jsonschema"} {
+		m.Register(schema, "go", map[string]string{
+			"integer": "int64", "integer/int32": "int32", "integer/int64": "int64",
+			"number": "float64", "number/float": "float32", "number/double": "float64",
+			"string": "string", "string/date-time": "time.Time", "string/date": "time.Time",
+			"string/byte": "[]byte", "string/binary": "[]byte",
+			"boolean": "bool", "array": "[]any", "object": "map[string]any", "*": "any",
+		})
+		m.Register(schema, "typescript", map[string]string{
+			"integer": "number", "number": "number", "string": "string", "boolean": "boolean",
+			"array": "unknown[]", "object": "Record<string, unknown>", "*": "unknown",
+		})
+		m.Register(schema, "python", map[string]string{
+			"integer": "int", "number": "float", "string": "str", "string/date-time": "datetime",
+			"string/date": "date", "string/byte": "bytes", "string/binary": "bytes",
+			"boolean": "bool", "array": "list", "object": "dict", "*": "Any",
+		})
+	}
+
+	m.Register("sql", "go", map[string]string{
+		"string": "string", "text": "string", "varchar": "string", "char": "string",
+		"character varying": "string", "integer": "int32", "int": "int32", "int4": "int32",
+		"serial": "int32", "smallint": "int16", "int2": "int16", "bigint": "int64",
+		"int8": "int64", "bigserial": "int64", "decimal": "string", "numeric": "string",
+		"float": "float64", "real": "float32", "double precision": "float64", "float8": "float64",
+		"boolean": "bool", "bool": "bool", "date": "time.Time", "timestamp": "time.Time",
+		"timestamptz": "time.Time", "datetime": "time.Time", "uuid": "string",
+		"json": "json.RawMessage", "jsonb": "json.RawMessage", "bytea": "[]byte", "blob": "[]byte",
+	})
+	m.Register("sql", "typescript", map[string]string{
+		"string": "string", "text": "string", "varchar": "string", "char": "string",
+		"integer": "number", "int": "number", "smallint": "number", "bigint": "string",
+		"decimal": "string", "numeric": "string", "float": "number", "real": "number",
+		"double precision": "number", "boolean": "boolean", "bool": "boolean", "date": "string",
+		"timestamp": "string", "timestamptz": "string", "uuid": "string", "json": "unknown",
+		"jsonb": "unknown",
+	})
+	m.Register("sql", "python", map[string]string{
+		"string": "str", "text": "str", "varchar": "str", "char": "str", "integer": "int",
+		"int": "int", "smallint": "int", "bigint": "int", "decimal": "Decimal", "numeric": "Decimal",
+		"float": "float", "real": "float", "double precision": "float", "boolean": "bool",
+		"bool": "bool", "date": "date", "timestamp": "datetime", "timestamptz": "datetime",
+		"uuid": "UUID", "json": "Any", "jsonb": "Any", "bytea": "bytes", "blob": "bytes",
+	})
+	return m
+}
+
+// Register adds mapping to the table from one type system to another,
+// replacing existing entries for the same types. Source types are matched
+// case-insensitively.
+func (m *TypeMapper) Register(from, to string, mapping map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{strings.ToLower(from), strings.ToLower(to)}
+	table := m.tables[key]
+	if table == nil {
+		table = make(map[string]string, len(mapping))
+		m.tables[key] = table
+	}
+	for typ, mapped := range mapping {
+		table[strings.ToLower(typ)] = mapped
+	}
+}
+
+var typeSize = regexp.MustCompile(`\s*\(.*\)\s*$`)
+
+// Map returns the type in the to system for typ in the from system.
+func (m *TypeMapper) Map(from, to, typ string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	table, ok := m.tables[[2]string{strings.ToLower(from), strings.ToLower(to)}]
+	if !ok {
+		return "", fmt.Errorf("no type mapping from %s to %s", from, to)
+	}
+
+	name := strings.ToLower(strings.TrimSpace(typ))
+	base, _, _ := strings.Cut(name, "/")
+	for _, candidate := range []string{name, typeSize.ReplaceAllString(name, ""), base, "*"} {
+		if mapped, ok := table[candidate]; ok {
+			return mapped, nil
+		}
+	}
+	return "", fmt.Errorf("no %s type for %s type %q", to, from, typ)
+}
+
+// Table returns a copy of the table from one type system to another.
+func (m *TypeMapper) Table(from, to string) map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return maps.Clone(m.tables[[2]string{strings.ToLower(from), strings.ToLower(to)}])
+}
+
+// FuncMap returns a mapType template function that uses m.
+func (m *TypeMapper) FuncMap() template.FuncMap {
+	return template.FuncMap{"mapType": m.Map}
+}
+
+func mapType(from, to, typ string) (string, error) {
+	return DefaultTypeMapper.Map(from, to, typ)
+}
@@ -0,0 +1,48 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestMapTypeFunc(t *testing.T) {
+	tests := []funcTest{
+		{"mapType", `{{ mapType "openapi" "go" "integer" }} {{ mapType "OpenAPI" "go" "integer/int32" }} {{ mapType "openapi" "go" "string/uuid" }} {{ mapType "openapi" "go" "whatever" }} {{ mapType "sql" "go" "VARCHAR(255)" }} {{ mapType "sql" "typescript" "double precision" }} {{ mapType "jsonschema" "python" "string/date-time" }}`, nil, "int64 int32 string any string number datetime"},
+	}
+	errorTests := []funcErrorTest{
+		{"mapType systems", `{{ mapType "sql" "rust" "text" }}`, "no type mapping from sql to rust"},
+		{"mapType type", `{{ mapType "sql" "go" "geometry" }}`, `no go type for sql type "geometry"`},
+	}
+	runFuncTests(t, tests, errorTests)
+}
+
+func TestTypeMapperRegister(t *testing.T) {
+	mapper := NewTypeMapper()
+	mapper.Register("openapi", "go", map[string]string{"string/uuid": "uuid.UUID"})
+	mapper.Register("proto", "go", map[string]string{"int64": "int64", "*": "any"})
+
+	for _, tt := range []struct{ from, typ, want string }{
+		{"openapi", "string/uuid", "uuid.UUID"},
+		{"openapi", "string", "string"},
+		{"proto", "Int64", "int64"},
+		{"proto", "google.protobuf.Any", "any"},
+	} {
+		got, err := mapper.Map(tt.from, "go", tt.typ)
+		if err != nil || got != tt.want {
+			t.Errorf("Map(%s, go, %s) = %q, %v; want %q", tt.from, tt.typ, got, err, tt.want)
+		}
+	}
+	if got, _ := DefaultTypeMapper.Map("openapi", "go", "string/uuid"); got != "string" {
+		t.Errorf("Expected registration not to change the default mapper, got %q", got)
+	}
+	if table := mapper.Table("proto", "go"); len(table) != 2 {
+		t.Errorf("Table = %v, want 2 entries", table)
+	}
+
+	tmpl := template.Must(template.New("t").Funcs(DefaultFuncMap()).Funcs(mapper.FuncMap()).Parse(`{{ mapType "proto" "go" "int64" }}`))
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, nil); err != nil || buf.String() != "int64" {
+		t.Errorf("FuncMap mapType = %q, %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,115 @@
+package render
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+)
+
+// urlParse splits rawURL into its components: "scheme", "user", "host",
+// "hostname", "port", "path", "rawQuery", "fragment" and "query", a map from
+// each query parameter to its value, or to a list of values when it is
+// repeated.
+func urlParse(rawURL string) (map[string]any, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	query := make(map[string]any)
+	for key, values := range u.Query() {
+		if len(values) == 1 {
+			query[key] = values[0]
+		} else {
+			query[key] = values
+		}
+	}
+
+	return map[string]any{
+		"scheme":   u.Scheme,
+		"user":     u.User.String(),
+		"host":     u.Host,
+		"hostname": u.Hostname(),
+		"port":     u.Port(),
+		"path":     u.Path,
+		"rawQuery": u.RawQuery,
+		"fragment": u.Fragment,
+		"query":    query,
+	}, nil
+}
+
+// urlBuild assembles a URL from components named as urlParse returns them,
+// so a parsed URL can be changed with set and built again. "host" takes
+// precedence over "hostname" and "port", and "query" over "rawQuery".
+// Query parameters are encoded in sorted order; a list value repeats the
+// parameter.
+func urlBuild(parts map[string]any) (string, error) {
+	u := &url.URL{
+		Scheme:   partString(parts, "scheme"),
+		Host:     partString(parts, "host"),
+		Path:     partString(parts, "path"),
+		RawQuery: partString(parts, "rawQuery"),
+		Fragment: partString(parts, "fragment"),
+	}
+	if u.Host == "" {
+		u.Host = partString(parts, "hostname")
+		if port := partString(parts, "port"); port != "" {
+			u.Host += ":" + port
+		}
+	}
+	if user := partString(parts, "user"); user != "" {
+		info, err := url.Parse("//" + user + "@")
+		if err != nil {
+			return "", fmt.Errorf("failed to build URL: invalid user %q", user)
+		}
+		u.User = info.User
+	}
+
+	if query, ok := parts["query"]; ok && query != nil {
+		v := reflect.Indirect(reflect.ValueOf(query))
+		if v.Kind() != reflect.Map {
+			return "", fmt.Errorf("failed to build URL: query must be a map, got %T", query)
+		}
+		values := url.Values{}
+		for _, key := range v.MapKeys() {
+			name := toString(key.Interface())
+			value := reflect.ValueOf(v.MapIndex(key).Interface())
+			if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+				for i := range value.Len() {
+					values.Add(name, toString(value.Index(i).Interface()))
+				}
+				continue
+			}
+			values.Add(name, toString(value.Interface()))
+		}
+		u.RawQuery = values.Encode()
+	}
+
+	if u.Host != "" && u.Path != "" && u.Path[0] != '/' {
+		u.Path = "/" + u.Path
+	}
+	return u.String(), nil
+}
+
+func partString(parts map[string]any, key string) string {
+	if value, ok := parts[key]; ok && value != nil {
+		return toString(value)
+	}
+	return ""
+}
+
+// urlJoinPath appends elements to the path of base, keeping its query and
+// fragment, and cleans the result. Each element may contain slashes, such as
+// "/v1/users/"; a path parameter that may itself contain one should be
+// escaped with urlPath first.
+func urlJoinPath(base string, elements ...any) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse URL: %w", err)
+	}
+	elems := make([]string, len(elements))
+	for i, element := range elements {
+		elems[i] = toString(element)
+	}
+	return u.JoinPath(elems...).String(), nil
+}
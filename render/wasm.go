@@ -0,0 +1,93 @@
+package render
+
+import (
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+)
+
+// WASMRuntime runs WebAssembly modules built for WASI by starting an
+// external runtime's command line, rather than embedding a runtime in weft.
+// The sandbox is the runtime's own: weft grants a module the directories
+// and environment variables set here and nothing else, and relies on the
+// runtime's defaults to withhold everything else. wasmtime, wasmer and
+// wazero preopen no other directories, pass on none of the host
+// environment and give WASI modules no sockets by default, but weft does
+// not check this. The runtime executable is therefore trusted as much as
+// weft itself; set Path to a known build rather than relying on PATH.
+//
+//	rt := render.WASMRuntime{
+//		Dirs: map[string]string{"/schemas": "./schemas"},
+//		Env:  map[string]string{"ACME_YEAR": "2024"},
+//	}
+type WASMRuntime struct {
+	// Name selects the runtime's command line: "wasmtime" (the default),
+	// "wasmer" or "wazero".
+	Name string
+	// Path is the runtime executable. The default is Name, looked up on
+	// PATH.
+	Path string
+	// Dirs grants the module access to host directories, keyed by the path
+	// the module sees each one at.
+	Dirs map[string]string
+	// Env sets the only environment variables the module sees.
+	Env map[string]string
+}
+
+// Command returns the executable and arguments that run module with args
+// under the runtime.
+func (rt WASMRuntime) Command(module string, args ...string) (string, []string, error) {
+	name := rt.Name
+	if name == "" {
+		name = "wasmtime"
+	}
+	path := rt.Path
+	if path == "" {
+		path = name
+	}
+
+	var dirs, env []string
+	for _, guest := range slices.Sorted(maps.Keys(rt.Dirs)) {
+		host := rt.Dirs[guest]
+		switch name {
+		case "wasmtime":
+			dirs = append(dirs, "--dir", host+"::"+guest)
+		case "wasmer":
+			dirs = append(dirs, "--mapdir", guest+":"+host)
+		case "wazero":
+			dirs = append(dirs, "-mount="+host+":"+guest)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(rt.Env)) {
+		if name == "wazero" {
+			env = append(env, "-env="+key+"="+rt.Env[key])
+		} else {
+			env = append(env, "--env", key+"="+rt.Env[key])
+		}
+	}
+
+	cmdArgs := append([]string{"run"}, dirs...)
+	cmdArgs = append(cmdArgs, env...)
+	switch name {
+	case "wasmtime", "wazero":
+		cmdArgs = append(cmdArgs, module)
+	case "wasmer":
+		cmdArgs = append(cmdArgs, module, "--")
+	default:
+		return "", nil, fmt.Errorf("unknown WASM runtime %q", name)
+	}
+	return path, append(cmdArgs, args...), nil
+}
+
+// LoadWASMPlugin starts module, a plugin built for WASI, under rt and
+// registers the functions it describes. The module speaks the ProcessPlugin
+// protocol on its standard input and output, and is otherwise limited to
+// what rt grants. The caller must Close the plugin when rendering is done.
+func (fr *FunctionRegistry) LoadWASMPlugin(rt WASMRuntime, module string, args ...string) (*ProcessPlugin, error) {
+	command, cmdArgs, err := rt.Command(module, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", module, err)
+	}
+	return fr.startProcessPlugin(module, exec.Command(command, cmdArgs...))
+}
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestWASMRuntimeCommand(t *testing.T) {
+	grants := WASMRuntime{
+		Dirs: map[string]string{"/schemas": "./schemas", "/out": "/tmp/out"},
+		Env:  map[string]string{"YEAR": "2024"},
+	}
+	for _, tt := range []struct {
+		name, path string
+		want       []string
+	}{
+		{"", "wasmtime", []string{"run", "--dir", "/tmp/out::/out", "--dir", "./schemas::/schemas", "--env", "YEAR=2024", "acme.wasm", "-v"}},
+		{"wasmer", "wasmer", []string{"run", "--mapdir", "/out:/tmp/out", "--mapdir", "/schemas:./schemas", "--env", "YEAR=2024", "acme.wasm", "--", "-v"}},
+		{"wazero", "wazero", []string{"run", "-mount=/tmp/out:/out", "-mount=./schemas:/schemas", "-env=YEAR=2024", "acme.wasm", "-v"}},
+	} {
+		rt := grants
+		rt.Name = tt.name
+		path, args, err := rt.Command("acme.wasm", "-v")
+		if err != nil || path != tt.path || !slices.Equal(args, tt.want) {
+			t.Errorf("%s: Command() = %s %q, %v; want %s %q", tt.name, path, args, err, tt.path, tt.want)
+		}
+	}
+
+	if path, _, _ := (WASMRuntime{Path: "/opt/bin/wasmtime"}).Command("acme.wasm"); path != "/opt/bin/wasmtime" {
+		t.Errorf("Expected Path to override the executable, got %s", path)
+	}
+	if _, _, err := (WASMRuntime{Name: "v8"}).Command("acme.wasm"); err == nil || !strings.Contains(err.Error(), `unknown WASM runtime "v8"`) {
+		t.Errorf("Expected an unknown runtime error, got %v", err)
+	}
+}
+
+// fakeWASMRuntime writes a script standing in for wasmtime that records its
+// arguments in the returned file and runs TestProcessPluginHelper.
+func fakeWASMRuntime(t *testing.T) (runtime, argsFile string) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	runtime = filepath.Join(dir, "wasmtime")
+	argsFile = filepath.Join(dir, "args")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > '" + argsFile + "'\nexec '" + os.Args[0] + "' -test.run='^TestProcessPluginHelper$'\n"
+	if err := os.WriteFile(runtime, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return runtime, argsFile
+}
+
+func TestLoadWASMPlugin(t *testing.T) {
+	t.Setenv("WEFT_TEST_PLUGIN", "acme")
+	runtime, argsFile := fakeWASMRuntime(t)
+	registry := NewFunctionRegistry()
+	registry.RegisterDefaults()
+
+	rt := WASMRuntime{Path: runtime, Env: map[string]string{"YEAR": "2024"}}
+	plugin, err := registry.LoadWASMPlugin(rt, "acme.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plugin.Close()
+
+	args, _ := os.ReadFile(argsFile)
+	if want := "run\n--env\nYEAR=2024\nacme.wasm\n"; string(args) != want {
+		t.Errorf("runtime args = %q, want %q", args, want)
+	}
+	tmpl := template.Must(template.New("t").Funcs(registry.GetFuncMap()).Parse(`{{ acmeLicense 2024 }}`))
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, nil); err != nil || buf.String() != "Copyright 2024 ACME" {
+		t.Errorf("got %q, %v; want Copyright 2024 ACME", buf.String(), err)
+	}
+
+	t.Setenv("WEFT_TEST_PLUGIN", "collide")
+	if _, err := registry.LoadWASMPlugin(rt, "acme.wasm"); err == nil || !strings.Contains(err.Error(), `plugin acme.wasm: function "snake" is already registered`) {
+		t.Errorf("Expected a collision error naming the module, got %v", err)
+	}
+	if _, err := registry.LoadWASMPlugin(WASMRuntime{Name: "v8"}, "acme.wasm"); err == nil || !strings.Contains(err.Error(), "failed to start plugin acme.wasm") {
+		t.Errorf("Expected a start error, got %v", err)
+	}
+}
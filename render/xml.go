@@ -0,0 +1,102 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// xmlEscape escapes s for use as XML character data or an attribute value.
+func xmlEscape(s any) (string, error) {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(toString(s))); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// toXML serializes value as an indented XML element named root.
+//
+// Structs are marshalled by encoding/xml and honour xml struct tags. Maps
+// become elements with one child per key, in sorted order, except that keys
+// starting with "@" become attributes and a "#text" key becomes the
+// element's character data. Lists become one element per item, repeating
+// the name, so {"dependency": [...]} renders each dependency in turn.
+func toXML(root string, value any) (string, error) {
+	var b strings.Builder
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+	if err := encodeXML(enc, root, reflect.ValueOf(value)); err != nil {
+		return "", fmt.Errorf("failed to encode XML: %w", err)
+	}
+	if err := enc.Flush(); err != nil {
+		return "", fmt.Errorf("failed to encode XML: %w", err)
+	}
+	return b.String(), nil
+}
+
+func encodeXML(enc *xml.Encoder, name string, v reflect.Value) error {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return enc.EncodeElement("", xml.StartElement{Name: xml.Name{Local: name}})
+		}
+		v = v.Elem()
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch {
+	case !v.IsValid():
+		return enc.EncodeElement("", start)
+	case v.Kind() == reflect.Map:
+		return encodeXMLMap(enc, start, v)
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8:
+		for i := range v.Len() {
+			if err := encodeXML(enc, name, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return enc.EncodeElement(v.Interface(), start)
+}
+
+func encodeXMLMap(enc *xml.Encoder, start xml.StartElement, v reflect.Value) error {
+	keys := make([]string, 0, v.Len())
+	values := make(map[string]reflect.Value, v.Len())
+	for _, key := range v.MapKeys() {
+		k := fmt.Sprint(key.Interface())
+		keys = append(keys, k)
+		values[k] = v.MapIndex(key)
+	}
+	slices.Sort(keys)
+
+	var text string
+	var children []string
+	for _, key := range keys {
+		switch {
+		case strings.HasPrefix(key, "@"):
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: key[1:]}, Value: toString(values[key].Interface())})
+		case key == "#text":
+			text = toString(values[key].Interface())
+		default:
+			children = append(children, key)
+		}
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if text != "" {
+		if err := enc.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+	for _, key := range children {
+		if err := encodeXML(enc, key, values[key]); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
@@ -0,0 +1,32 @@
+package render
+
+import "testing"
+
+func TestXMLFuncs(t *testing.T) {
+	tests := []funcTest{
+		{"xmlEscape", `{{ xmlEscape "a < b && \"c\" > 'd'" }}`, nil, "a &lt; b &amp;&amp; &#34;c&#34; &gt; &#39;d&#39;"},
+		{"toXML map", `{{ toXML "project" .Project }}`, map[string]any{"Project": map[string]any{
+			"@xmlns":       "http://maven.apache.org/POM/4.0.0",
+			"artifactId":   "demo & co",
+			"dependencies": map[string]any{"dependency": []map[string]string{{"groupId": "junit"}, {"groupId": "org.slf4j"}}},
+			"name":         map[string]any{"@lang": "en", "#text": "Demo"},
+			"empty":        nil,
+		}}, `<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <artifactId>demo &amp; co</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>junit</groupId>
+    </dependency>
+    <dependency>
+      <groupId>org.slf4j</groupId>
+    </dependency>
+  </dependencies>
+  <empty></empty>
+  <name lang="en">Demo</name>
+</project>`},
+		{"toXML struct", `{{ toXML "uses-permission" .Permission }}`, map[string]any{"Permission": struct {
+			Name string `xml:"name,attr"`
+		}{Name: "android.permission.INTERNET"}}, `<uses-permission name="android.permission.INTERNET"></uses-permission>`},
+	}
+	runFuncTests(t, tests, nil)
+}
@@ -17,6 +17,7 @@ type ManifestEntry struct {
 	ModTime      time.Time         `json:"mod_time"`
 	GeneratedBy  string            `json:"generated_by"`
 	TemplatePath string            `json:"template_path"`
+	RunID        string            `json:"run_id,omitempty"`
 	Metadata     map[string]string `json:"metadata,omitempty"`
 }
 
@@ -24,6 +25,7 @@ type Manifest struct {
 	Version    string                   `json:"version"`
 	Generated  time.Time                `json:"generated"`
 	Generator  string                   `json:"generator"`
+	RunID      string                   `json:"run_id,omitempty"`
 	OutputRoot string                   `json:"output_root"`
 	Entries    map[string]ManifestEntry `json:"entries"`
 	Metadata   map[string]string        `json:"metadata,omitempty"`
@@ -111,6 +113,7 @@ func (mm *ManifestManager) AddEntry(manifest *Manifest, path, templatePath strin
 		ModTime:      stat.ModTime(),
 		GeneratedBy:  "weft",
 		TemplatePath: templatePath,
+		RunID:        manifest.RunID,
 		Metadata:     metadata,
 	}
 
@@ -74,6 +74,26 @@ func NewStateTracker(outputRoot string, mode TrackingMode) *StateTracker {
 	}
 }
 
+// SetRunID records the engine run that produced the files tracked from now
+// on, stamping it on the manifest and on each new entry.
+func (st *StateTracker) SetRunID(id string) error {
+	if st.mode == TrackingModeDisabled {
+		return nil
+	}
+
+	manifest, err := st.getManifest()
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	manifest.RunID = id
+	err = st.manifestManager.SaveManifest(manifest)
+	if err == nil {
+		st.invalidateCache()
+	}
+	return err
+}
+
 func (st *StateTracker) TrackFile(path, templatePath string, metadata map[string]string) error {
 	if st.mode == TrackingModeDisabled {
 		return nil
@@ -206,6 +206,7 @@ func (asw *AppendSectionWriter) appendSection(existing, newContent string) (stri
 type TimestampWriter struct {
 	baseWriter Writer
 	format     string
+	clock      func() time.Time
 }
 
 func NewTimestampWriter(baseWriter Writer) *TimestampWriter {
@@ -215,6 +216,7 @@ func NewTimestampWriter(baseWriter Writer) *TimestampWriter {
 	return &TimestampWriter{
 		baseWriter: baseWriter,
 		format:     "// Generated at: 2006-01-02 15:04:05\n\n",
+		clock:      time.Now,
 	}
 }
 
@@ -222,8 +224,14 @@ func (tw *TimestampWriter) SetFormat(format string) {
 	tw.format = format
 }
 
+// SetClock replaces the time source used for the header, e.g. with a
+// deterministic engine's Clock so headers are stable across runs.
+func (tw *TimestampWriter) SetClock(clock func() time.Time) {
+	tw.clock = clock
+}
+
 func (tw *TimestampWriter) Write(path string, content []byte, options WriteOptions) error {
-	timestamp := fmt.Sprintf(tw.format, time.Now().Format("2006-01-02 15:04:05"))
+	timestamp := fmt.Sprintf(tw.format, tw.clock().Format("2006-01-02 15:04:05"))
 	prefixed := timestamp + string(content)
 	return tw.baseWriter.Write(path, []byte(prefixed), options)
 }
@@ -233,7 +241,7 @@ func (tw *TimestampWriter) CanWrite(path string) bool {
 }
 
 func (tw *TimestampWriter) NeedsWrite(path string, content []byte) (bool, error) {
-	timestamp := fmt.Sprintf(tw.format, time.Now().Format("2006-01-02 15:04:05"))
+	timestamp := fmt.Sprintf(tw.format, tw.clock().Format("2006-01-02 15:04:05"))
 	prefixed := timestamp + string(content)
 	return tw.baseWriter.NeedsWrite(path, []byte(prefixed))
 }